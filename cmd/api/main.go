@@ -182,11 +182,6 @@ func main() {
 		}
 	}
 
-	// Initialize API key tiers
-	logger.Debug("Initializing API key tiers")
-	middleware.InitAPIKeys()
-	logger.Debug("API key tiers initialized")
-
 	// Initialize repositories
 	logger.Info("Initializing repositories")
 	repositories.InitializeArticleContentBlockRepository()
@@ -234,6 +229,12 @@ func main() {
 		logger.Debug("AI service initialized successfully")
 	}
 
+	// Initialize media service, which needs cwebp unless WebP derivatives are disabled
+	logger.Info("Initializing media service")
+	if err := services.InitMediaService(); err != nil {
+		logger.Fatal("Failed to initialize media service", err)
+	}
+
 	// Initialize Video Processing Service
 	logger.Info("Initializing video processing service")
 	// Get storage service (already initialized in news.go init())
@@ -449,6 +450,11 @@ func main() {
 	// Static route to serve swagger files directly
 	r.Static("/swagger-docs", "./docs")
 
+	// Serve uploaded media when it is stored on the local disk
+	if localPath := services.GetLocalStoragePath(); localPath != "" {
+		r.Static("/uploads", localPath)
+	}
+
 	// Set up graceful shutdown
 	// Create a channel to receive OS signals
 	sigChan := make(chan os.Signal, 1)
@@ -9,7 +9,9 @@ import (
 	"syscall"
 	"time"
 
+	"news/internal/config"
 	"news/internal/database"
+	"news/internal/mail"
 	"news/internal/queue"
 	"news/internal/services"
 )
@@ -45,9 +47,14 @@ func main() {
 	log.Println("Initializing queue manager for worker...")
 
 	// Create service container for worker
+	mailConfig := config.GetMailConfig()
 	serviceContainer := &queue.ServiceContainer{
 		TranslationService:     translationService,
-		VideoProcessingService: services.GetGlobalVideoProcessingService(),
+		VideoProcessingService: services.NewVideoProcessingService(database.DB, services.GetStorageService(), aiService),
+		NewsletterService:      services.NewNewsletterDeliveryService(mail.NewSMTPMailer(mailConfig), mailConfig),
+		WebhookService:         services.NewWebhookDeliveryService(config.GetWebhookConfig()),
+		EmbeddingService:       services.GetEmbeddingService(),
+		SitemapService:         services.GetSitemapService(),
 	}
 
 	// Create queue manager
@@ -69,6 +76,15 @@ func main() {
 		log.Fatalf("Failed to start queue manager: %v", err)
 	}
 
+	// Start the scheduler for scheduled articles and newsletters
+	var scheduler *queue.Scheduler
+	if queueConfig := config.GetQueueConfig(); queueConfig.SchedulerEnabled {
+		scheduler = queue.NewScheduler(queueConfig, queueManager)
+		if scheduler != nil {
+			scheduler.Start()
+		}
+	}
+
 	// Start health check and stats reporting
 	go func() {
 		ticker := time.NewTicker(30 * time.Second)
@@ -104,6 +120,9 @@ func main() {
 
 	go func() {
 		defer wg.Done()
+		if scheduler != nil {
+			scheduler.Stop()
+		}
 		if stopErr := queueManager.Stop(); stopErr != nil {
 			log.Printf("Warning: Error stopping queue manager: %v", stopErr)
 		}
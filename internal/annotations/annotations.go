@@ -0,0 +1,70 @@
+// Package annotations anchors editorial notes to a character range of a content block. Ranges
+// count characters (runes), not bytes, so they match what editors select in the browser. The
+// anchor keeps the quoted text, so a note can find its range again after the block is edited.
+package annotations
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrInvalidRange is returned when a range does not fit the block content
+var ErrInvalidRange = errors.New("anchor range must satisfy 0 <= start < end <= content length")
+
+// Anchor is a character range of a block and the text it covered when the note was written
+type Anchor struct {
+	Start int
+	End   int
+	Quote string
+}
+
+// NewAnchor anchors a note to the characters [start, end) of content
+func NewAnchor(content string, start, end int) (Anchor, error) {
+	runes := []rune(content)
+	if start < 0 || end <= start || end > len(runes) {
+		return Anchor{}, ErrInvalidRange
+	}
+	return Anchor{Start: start, End: end, Quote: string(runes[start:end])}, nil
+}
+
+// Relocate finds an anchor in the current content of its block. When the quoted text is still
+// at the anchored range the anchor is returned unchanged; otherwise the occurrence of the quote
+// closest to the old range is used. It reports false when the quote is gone.
+func Relocate(content string, anchor Anchor) (Anchor, bool) {
+	if anchor.Quote == "" {
+		return anchor, false
+	}
+	runes := []rune(content)
+	if anchor.Start >= 0 && anchor.End <= len(runes) && anchor.Start < anchor.End &&
+		string(runes[anchor.Start:anchor.End]) == anchor.Quote {
+		return anchor, true
+	}
+
+	length := utf8.RuneCountInString(anchor.Quote)
+	best := -1
+	for offset := 0; ; {
+		index := strings.Index(content[offset:], anchor.Quote)
+		if index < 0 {
+			break
+		}
+		byteStart := offset + index
+		start := utf8.RuneCountInString(content[:byteStart])
+		if best < 0 || distance(start, anchor.Start) < distance(best, anchor.Start) {
+			best = start
+		}
+		_, size := utf8.DecodeRuneInString(content[byteStart:])
+		offset = byteStart + size
+	}
+	if best < 0 {
+		return anchor, false
+	}
+	return Anchor{Start: best, End: best + length, Quote: anchor.Quote}, true
+}
+
+func distance(a, b int) int {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
@@ -9,15 +9,16 @@ import (
 )
 
 // CacheInvalidator handles cache invalidation strategies
-type CacheInvalidator struct {
-	unified *UnifiedCacheManager
-}
+type CacheInvalidator struct{}
 
-// NewCacheInvalidator creates a new cache invalidator
+// NewCacheInvalidator creates a new cache invalidator. The unified cache is looked up on use, so
+// invalidators can be created before the cache is initialized.
 func NewCacheInvalidator() *CacheInvalidator {
-	return &CacheInvalidator{
-		unified: GetUnifiedCache(),
-	}
+	return &CacheInvalidator{}
+}
+
+func (ci *CacheInvalidator) unified() *UnifiedCacheManager {
+	return GetUnifiedCache()
 }
 
 // InvalidateArticle invalidates all cache entries related to a specific article
@@ -39,7 +40,7 @@ func (ci *CacheInvalidator) InvalidateArticle(articleID int64) error {
 
 	var lastError error
 	for _, pattern := range patterns {
-		if err := ci.unified.DeletePattern(pattern); err != nil {
+		if err := ci.unified().DeletePattern(pattern); err != nil {
 			lastError = err
 			fmt.Printf("Warning: Failed to invalidate pattern %s: %v\n", pattern, err)
 		} else {
@@ -126,7 +127,7 @@ func (ci *CacheInvalidator) InvalidateAll() error {
 	defer metrics.TrackDatabaseOperation("cache_invalidate_all")()
 
 	// Clear L1 completely
-	ci.unified.ristretto.Clear()
+	ci.unified().ristretto.Clear()
 
 	// Clear L2 patterns
 	patterns := []string{"*"}
@@ -148,7 +149,7 @@ func (ci *CacheInvalidator) InvalidateByPrefix(prefix string) error {
 		pattern = prefix + "*"
 	}
 
-	return ci.unified.DeletePattern(pattern)
+	return ci.unified().DeletePattern(pattern)
 }
 
 // InvalidateBulkArticles invalidates cache for multiple articles efficiently
@@ -265,7 +266,7 @@ func (ci *CacheInvalidator) ScheduledInvalidation() error {
 func (ci *CacheInvalidator) GetInvalidationStats() map[string]interface{} {
 	return map[string]interface{}{
 		"invalidator_active": true,
-		"cache_health":       ci.unified.Health(),
+		"cache_health":       ci.unified().Health(),
 		"last_updated":       time.Now(),
 	}
 }
@@ -274,7 +275,7 @@ func (ci *CacheInvalidator) GetInvalidationStats() map[string]interface{} {
 func (ci *CacheInvalidator) GetInvalidationMetrics() map[string]interface{} {
 	return map[string]interface{}{
 		"invalidator_active": true,
-		"cache_health":       ci.unified.Health(),
+		"cache_health":       ci.unified().Health(),
 		"last_updated":       time.Now(),
 		"optimization_level": "smart_patterns",
 		"bulk_operations":    "supported",
@@ -286,7 +287,7 @@ func (ci *CacheInvalidator) GetInvalidationMetrics() map[string]interface{} {
 func (ci *CacheInvalidator) invalidatePatterns(patterns []string) error {
 	var lastError error
 	for _, pattern := range patterns {
-		if err := ci.unified.DeletePattern(pattern); err != nil {
+		if err := ci.unified().DeletePattern(pattern); err != nil {
 			lastError = err
 			fmt.Printf("Warning: Failed to invalidate pattern %s: %v\n", pattern, err)
 		}
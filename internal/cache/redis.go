@@ -224,6 +224,33 @@ func (rc *RedisClient) GetClient() *redis.Client {
 	return rc.client
 }
 
+// RemoveMatching removes every key matching a glob pattern, scanning in batches so Redis is not
+// blocked, and returns the keys removed
+func (rc *RedisClient) RemoveMatching(pattern string) ([]string, error) {
+	if inTestMode {
+		return nil, nil
+	}
+
+	var removed []string
+	var cursor uint64
+	for {
+		keys, next, err := rc.client.Scan(rc.ctx, cursor, pattern, 500).Result()
+		if err != nil {
+			return removed, err
+		}
+		if len(keys) > 0 {
+			if err := rc.client.Del(rc.ctx, keys...).Err(); err != nil {
+				return removed, err
+			}
+			removed = append(removed, keys...)
+		}
+		if next == 0 {
+			return removed, nil
+		}
+		cursor = next
+	}
+}
+
 // ClearAllCache clears all keys from Redis (use with caution)
 func (rc *RedisClient) ClearAllCache() error {
 	if inTestMode {
@@ -166,6 +166,19 @@ func (ucm *UnifiedCacheManager) DeletePattern(pattern string) error {
 	return nil
 }
 
+// DeleteMatching removes the keys matching a glob pattern from L2, and the same keys from this
+// process's L1. L1 copies held by other processes expire on their own, so callers keep them
+// short lived.
+func (ucm *UnifiedCacheManager) DeleteMatching(pattern string) error {
+	defer metrics.TrackDatabaseOperation("unified_cache_delete_matching")()
+
+	keys, err := ucm.redis.RemoveMatching(pattern)
+	for _, key := range keys {
+		ucm.ristretto.Delete(key)
+	}
+	return err
+}
+
 // WarmCache preloads data into L1 from L2
 func (ucm *UnifiedCacheManager) WarmCache(keys []string) {
 	defer metrics.TrackDatabaseOperation("unified_cache_warm")()
@@ -0,0 +1,23 @@
+package config
+
+// EmbeddingConfig holds configuration for article embeddings
+type EmbeddingConfig struct {
+	// Provider is "openai" or "fake"; the fake provider is deterministic and needs no API key
+	Provider string
+	// FakeDimensions is the vector size of the fake provider
+	FakeDimensions int
+	// MinScore is the lowest cosine similarity returned by search and similar articles
+	MinScore float64
+	// MaxCandidates limits how many stored vectors are compared per query
+	MaxCandidates int
+}
+
+// GetEmbeddingConfig returns embedding configuration from environment variables
+func GetEmbeddingConfig() *EmbeddingConfig {
+	return &EmbeddingConfig{
+		Provider:       getEnvString("EMBEDDING_PROVIDER", "openai"),
+		FakeDimensions: getEnvInt("EMBEDDING_FAKE_DIMENSIONS", 256),
+		MinScore:       getEnvFloat("EMBEDDING_MIN_SCORE", 0.2),
+		MaxCandidates:  getEnvInt("EMBEDDING_MAX_CANDIDATES", 5000),
+	}
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+// FeedConfig holds configuration for the RSS, Atom and JSON feeds
+type FeedConfig struct {
+	// SiteURL is the public site that article links in feeds point to
+	SiteURL     string
+	Title       string
+	Description string
+	// ItemLimit is the number of articles in a feed
+	ItemLimit int
+	// CacheTTL is how long a rendered feed is cached
+	CacheTTL time.Duration
+}
+
+// GetFeedConfig returns feed configuration from environment variables
+func GetFeedConfig() *FeedConfig {
+	return &FeedConfig{
+		SiteURL:     strings.TrimRight(getEnvString("PUBLIC_BASE_URL", "http://localhost:8080"), "/"),
+		Title:       getEnvString("FEED_TITLE", "News"),
+		Description: getEnvString("FEED_DESCRIPTION", "Latest news"),
+		ItemLimit:   getEnvInt("FEED_ITEM_LIMIT", 50),
+		CacheTTL:    time.Duration(getEnvInt("FEED_CACHE_TTL_SECONDS", 300)) * time.Second,
+	}
+}
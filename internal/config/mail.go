@@ -0,0 +1,38 @@
+package config
+
+import "time"
+
+// MailConfig holds configuration for outgoing email
+type MailConfig struct {
+	// SMTP connection settings
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	StartTLS     bool
+	Timeout      time.Duration
+
+	// Sender settings
+	FromAddress string
+	FromName    string
+
+	// PublicBaseURL is used to build links in emails, such as unsubscribe links
+	PublicBaseURL string
+}
+
+// GetMailConfig returns mail configuration from environment variables
+func GetMailConfig() *MailConfig {
+	return &MailConfig{
+		SMTPHost:     getEnvString("SMTP_HOST", "localhost"),
+		SMTPPort:     getEnvInt("SMTP_PORT", 25),
+		SMTPUsername: getEnvString("SMTP_USERNAME", ""),
+		SMTPPassword: getEnvString("SMTP_PASSWORD", ""),
+		StartTLS:     getEnvBool("SMTP_STARTTLS", true),
+		Timeout:      time.Duration(getEnvInt("SMTP_TIMEOUT", 30)) * time.Second,
+
+		FromAddress: getEnvString("SMTP_FROM", "newsletter@localhost"),
+		FromName:    getEnvString("SMTP_FROM_NAME", "News"),
+
+		PublicBaseURL: getEnvString("PUBLIC_BASE_URL", "http://localhost:8080"),
+	}
+}
@@ -0,0 +1,44 @@
+package config
+
+import "time"
+
+// MediaConfig holds configuration for media uploads and image derivatives
+type MediaConfig struct {
+	// PublicURL is prepended to storage keys to build the URLs of uploaded files. When empty,
+	// local files are served under /uploads and S3 objects from the bucket's endpoint.
+	PublicURL string
+	// MaxUploadSize is the largest file accepted by the media upload endpoint, in bytes
+	MaxUploadSize int64
+	// JPEGQuality is the quality of JPEG derivatives, from 1 to 100
+	JPEGQuality int
+	// WebPQuality is the quality of WebP derivatives, from 0 to 100
+	WebPQuality int
+	// WebPEnabled adds WebP copies of image derivatives. The API refuses to start if it is set
+	// and CWebPPath cannot be found.
+	WebPEnabled bool
+	// CWebPPath is the cwebp binary used for WebP derivatives
+	CWebPPath string
+	// MaxResumableUploadSize is the largest file accepted through resumable uploads, in bytes
+	MaxResumableUploadSize int64
+	// UploadChunkSize is the size of every chunk of a resumable upload but the last, in bytes.
+	// S3 needs at least 5 MB.
+	UploadChunkSize int64
+	// UploadSessionTTL is how long an idle resumable upload is kept before it is expired
+	UploadSessionTTL time.Duration
+}
+
+// GetMediaConfig returns media configuration from environment variables
+func GetMediaConfig() *MediaConfig {
+	return &MediaConfig{
+		PublicURL:     getEnvString("STORAGE_PUBLIC_URL", ""),
+		MaxUploadSize: int64(getEnvInt("MEDIA_MAX_UPLOAD_MB", 10)) << 20,
+		JPEGQuality:   getEnvInt("MEDIA_JPEG_QUALITY", 85),
+		WebPQuality:   getEnvInt("MEDIA_WEBP_QUALITY", 80),
+		WebPEnabled:   getEnvBool("MEDIA_WEBP_ENABLED", true),
+		CWebPPath:     getEnvString("CWEBP_PATH", "cwebp"),
+
+		MaxResumableUploadSize: int64(getEnvInt("MEDIA_MAX_RESUMABLE_UPLOAD_MB", 4096)) << 20,
+		UploadChunkSize:        int64(getEnvInt("UPLOAD_CHUNK_SIZE_MB", 8)) << 20,
+		UploadSessionTTL:       time.Duration(getEnvInt("UPLOAD_SESSION_TTL_HOURS", 24)) * time.Hour,
+	}
+}
@@ -0,0 +1,46 @@
+package config
+
+import "time"
+
+// ModerationConfig holds the default comment moderation policy, used where no category or
+// article policy is set, and the rules of reader reports
+type ModerationConfig struct {
+	// Mode is auto_approve, pre_moderate or ai_screen
+	Mode string
+	// ApproveThreshold is the AI confidence needed to publish a comment without review
+	ApproveThreshold float64
+	// RejectThreshold is the AI confidence needed to reject a comment without review
+	RejectThreshold float64
+	// TrustThreshold is the trust score from which a commenter skips the queue; 0 disables it
+	TrustThreshold int
+	// Strict makes AI screening stricter
+	Strict bool
+	// ScreeningTimeout bounds the AI call made while a comment is posted
+	ScreeningTimeout time.Duration
+
+	// ReportHideThreshold is the weight of open reports that hides content until a moderator
+	// decides; 0 disables auto-hiding
+	ReportHideThreshold int
+	// ReportHideArticles lets reports hide articles, not only comments
+	ReportHideArticles bool
+	// ReportRateLimit is how many reports a reader may file per ReportRateWindow
+	ReportRateLimit  int
+	ReportRateWindow time.Duration
+}
+
+// GetModerationConfig returns moderation configuration from environment variables
+func GetModerationConfig() *ModerationConfig {
+	return &ModerationConfig{
+		Mode:             getEnvString("COMMENT_MODERATION_MODE", "auto_approve"),
+		ApproveThreshold: getEnvFloat("COMMENT_AI_APPROVE_THRESHOLD", 0.9),
+		RejectThreshold:  getEnvFloat("COMMENT_AI_REJECT_THRESHOLD", 0.85),
+		TrustThreshold:   getEnvInt("COMMENT_TRUST_THRESHOLD", 5),
+		Strict:           getEnvBool("COMMENT_AI_STRICT", false),
+		ScreeningTimeout: time.Duration(getEnvInt("COMMENT_AI_TIMEOUT_SECONDS", 10)) * time.Second,
+
+		ReportHideThreshold: getEnvInt("REPORT_AUTO_HIDE_THRESHOLD", 5),
+		ReportHideArticles:  getEnvBool("REPORT_AUTO_HIDE_ARTICLES", false),
+		ReportRateLimit:     getEnvInt("REPORT_RATE_LIMIT", 10),
+		ReportRateWindow:    time.Duration(getEnvInt("REPORT_RATE_WINDOW_MINUTES", 60)) * time.Minute,
+	}
+}
@@ -0,0 +1,22 @@
+package config
+
+import "time"
+
+// PreviewConfig holds configuration for draft preview tokens
+type PreviewConfig struct {
+	// Secret signs preview tokens; it defaults to the JWT secret
+	Secret string
+	// DefaultTTL is how long a token is valid when no expiry is requested
+	DefaultTTL time.Duration
+	// MaxTTL is the longest validity a token can be issued with
+	MaxTTL time.Duration
+}
+
+// GetPreviewConfig returns preview token configuration from environment variables
+func GetPreviewConfig() *PreviewConfig {
+	return &PreviewConfig{
+		Secret:     getEnvString("PREVIEW_TOKEN_SECRET", getEnvString("JWT_SECRET", "")),
+		DefaultTTL: time.Duration(getEnvInt("PREVIEW_TOKEN_TTL_HOURS", 72)) * time.Hour,
+		MaxTTL:     time.Duration(getEnvInt("PREVIEW_TOKEN_MAX_TTL_HOURS", 720)) * time.Hour,
+	}
+}
@@ -28,6 +28,11 @@ type QueueConfig struct {
 	BatchSize       int
 	ProcessInterval int // seconds
 	HealthCheckPort int
+
+	// Article scheduler settings
+	SchedulerEnabled   bool
+	SchedulerInterval  int // seconds
+	SchedulerBatchSize int
 }
 
 // GetQueueConfig returns queue configuration from environment variables
@@ -54,6 +59,11 @@ func GetQueueConfig() *QueueConfig {
 		BatchSize:       getEnvInt("QUEUE_BATCH_SIZE", 10),
 		ProcessInterval: getEnvInt("QUEUE_PROCESS_INTERVAL", 5),
 		HealthCheckPort: getEnvInt("QUEUE_HEALTH_PORT", 8081),
+
+		// Article scheduler settings
+		SchedulerEnabled:   getEnvBool("QUEUE_SCHEDULER_ENABLED", true),
+		SchedulerInterval:  getEnvInt("QUEUE_SCHEDULER_INTERVAL", 30),
+		SchedulerBatchSize: getEnvInt("QUEUE_SCHEDULER_BATCH_SIZE", 50),
 	}
 }
 
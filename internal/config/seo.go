@@ -0,0 +1,33 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+// SEOConfig holds configuration for the SEO metadata and structured data API
+type SEOConfig struct {
+	// SiteURL is the public site that canonical URLs point to
+	SiteURL  string
+	SiteName string
+	// LogoURL is the publisher logo in structured data
+	LogoURL string
+	// TwitterSite is the @handle of the site for Twitter cards
+	TwitterSite string
+	// DefaultImage is shared when content has no image of its own
+	DefaultImage string
+	// CacheTTL is how long assembled metadata is cached; live streams use a shorter TTL
+	CacheTTL time.Duration
+}
+
+// GetSEOConfig returns SEO configuration from environment variables
+func GetSEOConfig() *SEOConfig {
+	return &SEOConfig{
+		SiteURL:      strings.TrimRight(getEnvString("PUBLIC_BASE_URL", "http://localhost:8080"), "/"),
+		SiteName:     getEnvString("SEO_SITE_NAME", getEnvString("FEED_TITLE", "News")),
+		LogoURL:      getEnvString("SEO_LOGO_URL", ""),
+		TwitterSite:  getEnvString("SEO_TWITTER_SITE", ""),
+		DefaultImage: getEnvString("SEO_DEFAULT_IMAGE", ""),
+		CacheTTL:     time.Duration(getEnvInt("SEO_CACHE_TTL_SECONDS", 300)) * time.Second,
+	}
+}
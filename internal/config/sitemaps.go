@@ -0,0 +1,43 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+// SitemapConfig holds configuration for the XML and Google News sitemaps
+type SitemapConfig struct {
+	// SiteURL is the public site that sitemap entries point to
+	SiteURL string
+	// BaseURL is where the sitemap files themselves are served, when not from SiteURL
+	BaseURL string
+	// ShardSize is the range of content IDs listed by one sitemap shard
+	ShardSize int
+	// NewsWindow is how far back the Google News sitemap lists articles
+	NewsWindow      time.Duration
+	PublicationName string
+	// CacheTTL is how long a sitemap file is kept in the cache in front of storage
+	CacheTTL time.Duration
+	// RefreshInterval is how often the scheduler rebuilds every sitemap, so the news sitemap
+	// drops old articles and missed updates are picked up; 0 disables it
+	RefreshInterval time.Duration
+}
+
+// GetSitemapConfig returns sitemap configuration from environment variables
+func GetSitemapConfig() *SitemapConfig {
+	siteURL := strings.TrimRight(getEnvString("PUBLIC_BASE_URL", "http://localhost:8080"), "/")
+	shardSize := getEnvInt("SITEMAP_SHARD_SIZE", 10000)
+	if shardSize <= 0 || shardSize > 50000 {
+		shardSize = 10000
+	}
+
+	return &SitemapConfig{
+		SiteURL:         siteURL,
+		BaseURL:         strings.TrimRight(getEnvString("SITEMAP_BASE_URL", siteURL), "/"),
+		ShardSize:       shardSize,
+		NewsWindow:      time.Duration(getEnvInt("SITEMAP_NEWS_WINDOW_HOURS", 48)) * time.Hour,
+		PublicationName: getEnvString("SITEMAP_NEWS_PUBLICATION", getEnvString("FEED_TITLE", "News")),
+		CacheTTL:        time.Duration(getEnvInt("SITEMAP_CACHE_TTL_SECONDS", 3600)) * time.Second,
+		RefreshInterval: time.Duration(getEnvInt("SITEMAP_REFRESH_MINUTES", 60)) * time.Minute,
+	}
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// VideoConfig holds configuration for the video processing pipeline
+type VideoConfig struct {
+	// FFmpegPath and FFprobePath are the binaries used to transcode and inspect videos
+	FFmpegPath  string
+	FFprobePath string
+	// TempDir is where sources and renditions are written while a video is processed
+	TempDir string
+	// HLSSegmentSeconds is the target duration of HLS segments
+	HLSSegmentSeconds int
+	// HLSRenditions names the renditions of the HLS ladder; renditions taller than the source
+	// are skipped
+	HLSRenditions []string
+}
+
+// GetVideoConfig returns video processing configuration from environment variables
+func GetVideoConfig() *VideoConfig {
+	var renditions []string
+	for _, name := range strings.Split(getEnvString("VIDEO_HLS_RENDITIONS", "240p,480p,720p,1080p"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			renditions = append(renditions, name)
+		}
+	}
+
+	return &VideoConfig{
+		FFmpegPath:        getEnvString("FFMPEG_PATH", "ffmpeg"),
+		FFprobePath:       getEnvString("FFPROBE_PATH", "ffprobe"),
+		TempDir:           getEnvString("VIDEO_TEMP_DIR", os.TempDir()),
+		HLSSegmentSeconds: getEnvInt("VIDEO_HLS_SEGMENT_SECONDS", 6),
+		HLSRenditions:     renditions,
+	}
+}
@@ -0,0 +1,19 @@
+package config
+
+import "time"
+
+// WebhookConfig holds configuration for outbound webhook deliveries
+type WebhookConfig struct {
+	// Timeout limits how long a receiver may take to respond
+	Timeout time.Duration
+	// MaxAttempts is the number of attempts before a delivery is marked as failed
+	MaxAttempts int
+}
+
+// GetWebhookConfig returns webhook configuration from environment variables
+func GetWebhookConfig() *WebhookConfig {
+	return &WebhookConfig{
+		Timeout:     time.Duration(getEnvInt("WEBHOOK_TIMEOUT", 10)) * time.Second,
+		MaxAttempts: getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+	}
+}
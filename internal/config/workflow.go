@@ -0,0 +1,15 @@
+package config
+
+// WorkflowConfig holds configuration for the editorial workflow of articles
+type WorkflowConfig struct {
+	// Transitions overrides the status changes allowed per role as JSON, for example
+	// {"author":{"draft":["in_review"]}}; roles it does not name keep the default rules
+	Transitions string
+}
+
+// GetWorkflowConfig returns workflow configuration from environment variables
+func GetWorkflowConfig() *WorkflowConfig {
+	return &WorkflowConfig{
+		Transitions: getEnvString("ARTICLE_WORKFLOW_TRANSITIONS", ""),
+	}
+}
@@ -145,6 +145,9 @@ func AutoMigrateModels() {
 
 		// Content & Interaction models
 		&models.Comment{},
+		&models.CommentModerationPolicy{},
+		&models.ReportedContent{},
+		&models.ContentReport{},
 		&models.Vote{},
 		&models.Bookmark{},
 		&models.Follow{},
@@ -154,19 +157,32 @@ func AutoMigrateModels() {
 		// Article related models
 		&models.ArticleTranslation{},
 		&models.ArticleContentBlock{},
+		&models.ArticleRevision{},
+		&models.ArticleWorkflowTransition{},
+		&models.ArticleReviewNote{},
+		&models.EditorialNote{},
+		&models.ArticleEmbedding{},
 
 		// System models
 		&models.Newsletter{},
+		&models.NewsletterDelivery{},
+		&models.WebhookEndpoint{},
+		&models.WebhookDelivery{},
+		&models.APIKey{},
+		&models.APIKeyUsage{},
+		&models.PreviewToken{},
 		&models.Notification{},
 		&models.Menu{},
 		&models.MenuItem{},
 		&models.Setting{},
 		&models.Media{},
+		&models.UploadSession{},
 
 		// Breaking news & Live news models
 		&models.BreakingNewsBanner{},
 		&models.LiveNewsStream{},
 		&models.LiveNewsUpdate{},
+		&models.LiveNewsEvent{},
 		&models.NewsStory{},
 		&models.StoryGroup{},
 		&models.StoryGroupItem{},
@@ -217,6 +233,7 @@ func AutoMigrateModels() {
 		&models.VideoPlaylist{},
 		&models.VideoPlaylistItem{},
 		&models.VideoProcessingJob{},
+		&models.VideoRendition{},
 
 		// Page System models (Modern CMS)
 		&models.Page{},
@@ -0,0 +1,150 @@
+// Package embedding turns text into vectors and compares them. Providers are pluggable so
+// tests and offline environments can use the deterministic Fake instead of a remote model.
+package embedding
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ErrDimensionMismatch is returned when vectors of different lengths are compared
+var ErrDimensionMismatch = errors.New("embedding dimensions do not match")
+
+// Provider generates embeddings for text
+type Provider interface {
+	// Name identifies the provider and model; vectors of different providers are not comparable
+	Name() string
+	Embed(ctx context.Context, text string) ([]float64, error)
+}
+
+// Fake is a deterministic provider for tests and local development. It hashes the words of a
+// text into a fixed number of buckets, so texts sharing words get similar vectors.
+type Fake struct {
+	Dimensions int
+}
+
+// NewFake returns a fake provider producing vectors of the given size
+func NewFake(dimensions int) *Fake {
+	if dimensions <= 0 {
+		dimensions = 256
+	}
+	return &Fake{Dimensions: dimensions}
+}
+
+// Name implements Provider
+func (f *Fake) Name() string {
+	return "fake"
+}
+
+// Embed implements Provider
+func (f *Fake) Embed(_ context.Context, text string) ([]float64, error) {
+	vector := make([]float64, f.Dimensions)
+	for _, word := range Tokenize(text) {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(word))
+		vector[h.Sum32()%uint32(f.Dimensions)]++
+	}
+	return Normalize(vector), nil
+}
+
+// Tokenize lower-cases text and splits it into words
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// Normalize scales v to unit length in place and returns it. A zero vector is left as is.
+func Normalize(v []float64) []float64 {
+	var sum float64
+	for _, x := range v {
+		sum += x * x
+	}
+	if sum == 0 {
+		return v
+	}
+	norm := math.Sqrt(sum)
+	for i := range v {
+		v[i] /= norm
+	}
+	return v
+}
+
+// Cosine returns the cosine similarity of a and b, between -1 and 1
+func Cosine(a, b []float64) (float64, error) {
+	if len(a) != len(b) {
+		return 0, ErrDimensionMismatch
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0, nil
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB)), nil
+}
+
+// Encode packs a vector as little-endian float32 values for storage
+func Encode(v []float64) []byte {
+	buf := make([]byte, 4*len(v))
+	for i, x := range v {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(float32(x)))
+	}
+	return buf
+}
+
+// Decode unpacks a vector stored by Encode
+func Decode(buf []byte) ([]float64, error) {
+	if len(buf)%4 != 0 {
+		return nil, errors.New("invalid embedding encoding")
+	}
+	v := make([]float64, len(buf)/4)
+	for i := range v {
+		v[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:])))
+	}
+	return v, nil
+}
+
+// Candidate is a stored vector that can be ranked against a query
+type Candidate struct {
+	ID     uint
+	Vector []float64
+}
+
+// Match is a ranked candidate with its similarity to the query
+type Match struct {
+	ID    uint
+	Score float64
+}
+
+// Rank returns up to limit candidates most similar to query with a similarity of at least
+// minScore, best first. Candidates with other dimensions are skipped.
+func Rank(query []float64, candidates []Candidate, limit int, minScore float64) []Match {
+	matches := make([]Match, 0, len(candidates))
+	for _, candidate := range candidates {
+		score, err := Cosine(query, candidate.Vector)
+		if err != nil || score < minScore {
+			continue
+		}
+		matches = append(matches, Match{ID: candidate.ID, Score: score})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].ID < matches[j].ID
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
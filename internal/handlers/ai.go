@@ -716,7 +716,7 @@ func GetAIUsageStats(c *gin.Context) {
 
 // SemanticSearch godoc
 // @Summary Semantic Search
-// @Description Perform semantic search using AI-generated embeddings for better relevance. Rate limited to control costs.
+// @Description Perform semantic search over stored article embeddings for better relevance, falling back to ElasticSearch and then text search. Rate limited to control costs.
 // @Tags AI
 // @Accept json
 // @Produce json
@@ -901,8 +901,13 @@ func max(a, b int) int {
 
 // Helper functions for rate-limited search
 
-// performTraditionalAISearch performs traditional AI search without rate limiting
+// performTraditionalAISearch performs traditional AI search without rate limiting. Stored
+// article embeddings are searched first, then ElasticSearch, then plain text search.
 func performTraditionalAISearch(ctx context.Context, request *models.SemanticSearchRequest, startTime time.Time, span interface{}) *models.SemanticSearchResponse {
+	if response, err := services.GetEmbeddingService().Search(ctx, request); err == nil {
+		return response
+	}
+
 	// Get AI service for embedding generation
 	aiService := services.GetAIService()
 
@@ -8,7 +8,6 @@ import (
 
 	"news/internal/middleware"
 	"news/internal/models"
-	"news/internal/services"
 
 	"github.com/gin-gonic/gin"
 	"go.opentelemetry.io/otel/attribute"
@@ -115,33 +114,7 @@ func SemanticSearchWithRateLimit(c *gin.Context) {
 
 // performAISearch performs AI-powered semantic search
 func performAISearch(ctx context.Context, request *models.SemanticSearchRequest, startTime time.Time, span interface{}) *models.SemanticSearchResponse {
-	// Get AI service for embedding generation
-	aiService := services.GetAIService()
-
-	// Generate embedding for the search query
-	embedding, err := aiService.GenerateEmbedding(ctx, request.Query)
-	if err != nil {
-		// Fallback to local search if embedding fails
-		return performFallbackSearch(ctx, request, startTime)
-	}
-
-	// Set the embedding in the request
-	request.Embedding = embedding
-
-	// Get ElasticSearch service
-	esService := services.GetElasticSearchService()
-
-	// Perform vector search
-	response, err := esService.VectorSearch(ctx, request)
-	if err != nil {
-		// Fallback to text search if vector search fails
-		return performFallbackSearch(ctx, request, startTime)
-	}
-
-	response.Method = "vector"
-	response.Meta.QueryEmbedding = true
-
-	return response
+	return performTraditionalAISearch(ctx, request, startTime, span)
 }
 
 // GetSearchLimitStatus godoc
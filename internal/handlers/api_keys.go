@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"news/internal/middleware"
+	"news/internal/models"
+	"news/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAPIKeys godoc
+// @Summary List API keys
+// @Description Returns issued API keys, newest first. Keys themselves are never returned (admin only).
+// @Tags API Keys
+// @Produce json
+// @Security Bearer
+// @Param owner_id query int false "Only keys owned by this user"
+// @Param include_revoked query bool false "Include revoked keys"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(50)
+// @Success 200 {object} services.APIKeyListResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/api-keys [get]
+func GetAPIKeys(c *gin.Context) {
+	ownerID, _ := strconv.ParseUint(c.Query("owner_id"), 10, 32)
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	result, err := services.GetAPIKeys(uint(ownerID), c.Query("include_revoked") == "true", page, limit)
+	if err != nil {
+		respondAPIKeyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetAPIKey godoc
+// @Summary Get an API key
+// @Description Returns an API key by ID (admin only)
+// @Tags API Keys
+// @Produce json
+// @Security Bearer
+// @Param id path int true "API key ID"
+// @Success 200 {object} models.APIKey
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/api-keys/{id} [get]
+func GetAPIKey(c *gin.Context) {
+	id, ok := parseAPIKeyID(c)
+	if !ok {
+		return
+	}
+
+	key, err := services.GetAPIKey(id)
+	if err != nil {
+		respondAPIKeyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+// IssueAPIKey godoc
+// @Summary Issue an API key
+// @Description Issues an API key to a user with a tier, scopes and optional expiry. The key is only returned in this response (admin only).
+// @Tags API Keys
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param key body services.APIKeyRequest true "API key"
+// @Success 201 {object} services.APIKeySecretResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/api-keys [post]
+func IssueAPIKey(c *gin.Context) {
+	var req services.APIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	createdBy, _ := userID.(uint)
+
+	key, err := services.IssueAPIKey(req, createdBy)
+	if err != nil {
+		respondAPIKeyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// RotateAPIKey godoc
+// @Summary Rotate an API key
+// @Description Replaces the key while keeping its owner, tier, scopes and usage history. The old key stops working (admin only).
+// @Tags API Keys
+// @Produce json
+// @Security Bearer
+// @Param id path int true "API key ID"
+// @Success 200 {object} services.APIKeySecretResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/api-keys/{id}/rotate [post]
+func RotateAPIKey(c *gin.Context) {
+	id, ok := parseAPIKeyID(c)
+	if !ok {
+		return
+	}
+
+	key, err := services.RotateAPIKey(id)
+	if err != nil {
+		respondAPIKeyError(c, err)
+		return
+	}
+	middleware.ResetAPIKeyCache()
+
+	c.JSON(http.StatusOK, key)
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Description Permanently disables an API key (admin only)
+// @Tags API Keys
+// @Produce json
+// @Security Bearer
+// @Param id path int true "API key ID"
+// @Success 200 {object} models.APIKey
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/api-keys/{id}/revoke [post]
+func RevokeAPIKey(c *gin.Context) {
+	id, ok := parseAPIKeyID(c)
+	if !ok {
+		return
+	}
+
+	key, err := services.RevokeAPIKey(id)
+	if err != nil {
+		respondAPIKeyError(c, err)
+		return
+	}
+	middleware.ResetAPIKeyCache()
+
+	c.JSON(http.StatusOK, key)
+}
+
+// GetAPIKeyUsage godoc
+// @Summary Get API key usage
+// @Description Returns the requests made with an API key per day and endpoint, for billing (admin only)
+// @Tags API Keys
+// @Produce json
+// @Security Bearer
+// @Param id path int true "API key ID"
+// @Param from query string false "First day (YYYY-MM-DD), defaults to 30 days ago"
+// @Param to query string false "Last day (YYYY-MM-DD), defaults to today"
+// @Success 200 {object} services.APIKeyUsageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/api-keys/{id}/usage [get]
+func GetAPIKeyUsage(c *gin.Context) {
+	id, ok := parseAPIKeyID(c)
+	if !ok {
+		return
+	}
+
+	to := time.Now().UTC()
+	from := to.AddDate(0, 0, -30)
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid from date, expected YYYY-MM-DD"})
+			return
+		}
+		from = parsed
+	}
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid to date, expected YYYY-MM-DD"})
+			return
+		}
+		to = parsed
+	}
+
+	usage, err := services.GetAPIKeyUsage(id, from, to)
+	if err != nil {
+		respondAPIKeyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
+func parseAPIKeyID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid API key ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondAPIKeyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrValidation):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrAPIKeyNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "API key not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to process API key request"})
+	}
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"news/internal/models"
+	"news/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetArticleRevisions godoc
+// @Summary List article revisions
+// @Description Retrieve the revision history of an article, newest first
+// @Tags Article Revisions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Article ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Revisions per page" default(20)
+// @Success 200 {object} services.RevisionListResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/articles/{id}/revisions [get]
+func GetArticleRevisions(c *gin.Context) {
+	articleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid article ID"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	result, err := services.GetArticleRevisions(uint(articleID), page, limit)
+	if err != nil {
+		respondRevisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetArticleRevision godoc
+// @Summary Get an article revision
+// @Description Retrieve a full snapshot of an article revision including its content blocks
+// @Tags Article Revisions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Article ID"
+// @Param revision path int true "Revision number"
+// @Success 200 {object} models.ArticleRevision
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/articles/{id}/revisions/{revision} [get]
+func GetArticleRevision(c *gin.Context) {
+	articleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid article ID"})
+		return
+	}
+
+	revisionNumber, err := strconv.Atoi(c.Param("revision"))
+	if err != nil || revisionNumber < 1 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid revision number"})
+		return
+	}
+
+	revision, err := services.GetArticleRevision(uint(articleID), revisionNumber)
+	if err != nil {
+		respondRevisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, revision)
+}
+
+// CompareArticleRevisions godoc
+// @Summary Compare two article revisions
+// @Description Show field- and block-level differences between two revisions. When "to" is omitted the latest revision is used.
+// @Tags Article Revisions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Article ID"
+// @Param from query int true "Revision number to compare from"
+// @Param to query int false "Revision number to compare to (default: latest)"
+// @Success 200 {object} models.ArticleRevisionDiff
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/articles/{id}/revisions/compare [get]
+func CompareArticleRevisions(c *gin.Context) {
+	articleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid article ID"})
+		return
+	}
+
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil || from < 1 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "A valid 'from' revision number is required"})
+		return
+	}
+
+	to := 0
+	if toStr := c.Query("to"); toStr != "" {
+		to, err = strconv.Atoi(toStr)
+		if err != nil || to < 1 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid 'to' revision number"})
+			return
+		}
+	}
+
+	diff, err := services.CompareArticleRevisions(uint(articleID), from, to)
+	if err != nil {
+		respondRevisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// RestoreArticleRevision godoc
+// @Summary Restore an article revision
+// @Description Make a past revision the current version of the article. The restore itself is recorded as a new revision.
+// @Tags Article Revisions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Article ID"
+// @Param revision path int true "Revision number to restore"
+// @Success 200 {object} models.ArticleRevision
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/articles/{id}/revisions/{revision}/restore [post]
+func RestoreArticleRevision(c *gin.Context) {
+	articleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid article ID"})
+		return
+	}
+
+	revisionNumber, err := strconv.Atoi(c.Param("revision"))
+	if err != nil || revisionNumber < 1 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid revision number"})
+		return
+	}
+
+	var editorID uint
+	if userID, exists := c.Get("user_id"); exists {
+		editorID = userID.(uint)
+	}
+
+	revision, err := services.RestoreArticleRevision(uint(articleID), revisionNumber, editorID)
+	if err != nil {
+		respondRevisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, revision)
+}
+
+// respondRevisionError maps revision service errors to HTTP responses
+func respondRevisionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Article not found"})
+	case errors.Is(err, services.ErrRevisionNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Revision not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+	}
+}
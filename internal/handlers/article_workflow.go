@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"news/internal/models"
+	"news/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetArticleWorkflow godoc
+// @Summary Get the workflow of an article
+// @Description Get an article's status, reviewer, the status changes the current user may make and the log of transitions and review notes. Authors can only see their own articles.
+// @Tags Editorial Workflow
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Article ID"
+// @Success 200 {object} services.ArticleWorkflow
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /editor/articles/{id}/workflow [get]
+func GetArticleWorkflow(c *gin.Context) {
+	articleID, ok := parseWorkflowArticleID(c)
+	if !ok {
+		return
+	}
+
+	state, err := services.GetArticleWorkflowService().Get(articleID, workflowActor(c))
+	if err != nil {
+		respondWorkflowError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, state)
+}
+
+// TransitionArticle godoc
+// @Summary Change the status of an article
+// @Description Move an article through the editorial workflow: draft, in_review, changes_requested, approved, scheduled, published, archived or trash. The allowed changes depend on the role (ARTICLE_WORKFLOW_TRANSITIONS); only editors and admins can publish or schedule. Requesting changes requires a note. Every change is logged and the author is notified.
+// @Tags Editorial Workflow
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Article ID"
+// @Param transition body services.TransitionRequest true "Target status"
+// @Success 200 {object} models.Article
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /editor/articles/{id}/transition [post]
+func TransitionArticle(c *gin.Context) {
+	articleID, ok := parseWorkflowArticleID(c)
+	if !ok {
+		return
+	}
+
+	var req services.TransitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request: " + err.Error()})
+		return
+	}
+
+	article, err := services.GetArticleWorkflowService().Transition(articleID, workflowActor(c), req)
+	if err != nil {
+		respondWorkflowError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, article)
+}
+
+// SubmitArticleForReview godoc
+// @Summary Submit an article for review
+// @Description Move an article to in_review, optionally assigning the editor who should review it. The reviewer is notified.
+// @Tags Editorial Workflow
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Article ID"
+// @Param request body SubmitForReviewRequest false "Reviewer and note"
+// @Success 200 {object} models.Article
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /author/articles/{id}/submit [post]
+func SubmitArticleForReview(c *gin.Context) {
+	articleID, ok := parseWorkflowArticleID(c)
+	if !ok {
+		return
+	}
+
+	var req SubmitForReviewRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request: " + err.Error()})
+			return
+		}
+	}
+
+	article, err := services.GetArticleWorkflowService().Transition(articleID, workflowActor(c), services.TransitionRequest{
+		Status:     "in_review",
+		Note:       req.Note,
+		ReviewerID: req.ReviewerID,
+	})
+	if err != nil {
+		respondWorkflowError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, article)
+}
+
+// AddArticleReviewNote godoc
+// @Summary Leave a review note
+// @Description Leave a note for the author of an article under review. The author is notified (editors and admins only).
+// @Tags Editorial Workflow
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Article ID"
+// @Param note body services.ReviewNoteRequest true "Review note"
+// @Success 201 {object} models.ArticleReviewNote
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /editor/articles/{id}/review-notes [post]
+func AddArticleReviewNote(c *gin.Context) {
+	articleID, ok := parseWorkflowArticleID(c)
+	if !ok {
+		return
+	}
+
+	var req services.ReviewNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request: " + err.Error()})
+		return
+	}
+
+	note, err := services.GetArticleWorkflowService().AddReviewNote(articleID, workflowActor(c), req)
+	if err != nil {
+		respondWorkflowError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, note)
+}
+
+// GetReviewQueue godoc
+// @Summary List articles waiting for review
+// @Description List articles in review, oldest first. Editors see the articles assigned to them and unassigned ones, admins see all; assigned=me limits the list to the caller's assignments.
+// @Tags Editorial Workflow
+// @Produce json
+// @Security BearerAuth
+// @Param assigned query string false "me to list only articles assigned to the caller"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} models.PaginatedResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Router /editor/reviews [get]
+func GetReviewQueue(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	articles, total, err := services.GetArticleWorkflowService().ReviewQueue(workflowActor(c), c.Query("assigned") == "me", page, limit)
+	if err != nil {
+		respondWorkflowError(c, err)
+		return
+	}
+
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	c.JSON(http.StatusOK, models.PaginatedResponse{
+		Data:       articles,
+		Page:       page,
+		Limit:      limit,
+		TotalItems: int(total),
+		TotalPages: totalPages,
+		HasNext:    page < totalPages,
+		HasPrev:    page > 1,
+	})
+}
+
+// SubmitForReviewRequest is the optional body of a review submission
+type SubmitForReviewRequest struct {
+	ReviewerID *uint  `json:"reviewer_id"`
+	Note       string `json:"note"`
+}
+
+// workflowActor returns the authenticated user and their role
+func workflowActor(c *gin.Context) services.WorkflowActor {
+	var actor services.WorkflowActor
+	if userID, exists := c.Get("user_id"); exists {
+		actor.ID, _ = userID.(uint)
+	}
+	if role, exists := c.Get("role"); exists {
+		actor.Role, _ = role.(string)
+	}
+	return actor
+}
+
+func parseWorkflowArticleID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid article ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondWorkflowError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrValidation):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrWorkflowForbidden):
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Article not found"})
+	default:
+		log.Printf("Workflow request failed: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to process workflow request"})
+	}
+}
@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"news/internal/json"
 	"news/internal/models"
@@ -58,22 +60,30 @@ func GetArticles(c *gin.Context) {
 }
 
 // @Summary Get a single article by ID (Cache Optimized)
-// @Description Retrieve a single article by its ID using cached JSON
+// @Description Retrieve a single article by its ID using cached JSON. With a preview token (preview_token query parameter or X-Preview-Token header) the article is returned in any status, read past the cache; a published article is still returned when the token is invalid.
 // @Tags Articles
 // @Produce json
 // @Param id path int true "Article ID"
+// @Param preview_token query string false "Draft preview token"
 // @Success 200 {object} models.Article
+// @Failure 401 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /api/articles/{id} [get]
 func GetArticleById(c *gin.Context) {
+	// An invalid preview token still gets the published article
+	token := previewTokenFrom(c)
+	if token != "" && respondArticlePreview(c, token, false) {
+		return
+	}
+
 	id := c.Param("id")
 
 	// Get cached JSON from service with smart redaction
 	cachedJSON, err := services.GetArticleByIdCachedSmart(id)
 	if err != nil {
 		if err == services.ErrNotFound {
-			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Article not found"})
+			respondArticleNotFound(c, token)
 		} else {
 			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
 		}
@@ -104,16 +114,19 @@ func GetArticleById(c *gin.Context) {
 // @Router /admin/articles [post]
 func CreateArticle(c *gin.Context) {
 	var articleInput struct {
-		Title         string   `json:"title" binding:"required"`
-		Content       string   `json:"content" binding:"required"`
-		CategoryIDs   []uint   `json:"category_ids"`
-		TagIDs        []uint   `json:"tag_ids"`
-		FeaturedImage string   `json:"featured_image"`
-		Gallery       []string `json:"gallery"` // Array of image URLs
-		Status        string   `json:"status"`
-		MetaTitle     string   `json:"meta_title"`
-		MetaDesc      string   `json:"meta_description"`
-		Language      string   `json:"language"`
+		Title         string     `json:"title" binding:"required"`
+		Content       string     `json:"content" binding:"required"`
+		CategoryIDs   []uint     `json:"category_ids"`
+		TagIDs        []uint     `json:"tag_ids"`
+		FeaturedImage string     `json:"featured_image"`
+		Gallery       []string   `json:"gallery"` // Array of image URLs
+		Status        string     `json:"status"`
+		ScheduledAt   *time.Time `json:"scheduled_at"`
+		UnpublishAt   *time.Time `json:"unpublish_at"`
+		UnpublishTo   string     `json:"unpublish_to"` // draft or archived
+		MetaTitle     string     `json:"meta_title"`
+		MetaDesc      string     `json:"meta_description"`
+		Language      string     `json:"language"`
 	}
 
 	if err := c.ShouldBindJSON(&articleInput); err != nil {
@@ -146,6 +159,9 @@ func CreateArticle(c *gin.Context) {
 		AuthorID:      userID.(uint),
 		FeaturedImage: articleInput.FeaturedImage,
 		Status:        articleInput.Status,
+		ScheduledAt:   articleInput.ScheduledAt,
+		UnpublishAt:   articleInput.UnpublishAt,
+		UnpublishTo:   articleInput.UnpublishTo,
 		MetaTitle:     articleInput.MetaTitle,
 		MetaDesc:      articleInput.MetaDesc,
 		Language:      articleInput.Language,
@@ -171,8 +187,18 @@ func CreateArticle(c *gin.Context) {
 		article.Language = "tr"
 	}
 
+	// New articles start as drafts; any other status must be one the role may move a draft to
+	if err := services.GetArticleWorkflowService().Authorize(workflowActor(c), nil, "draft", article.Status); err != nil {
+		respondWorkflowError(c, err)
+		return
+	}
+
 	createdArticle, err := services.CreateArticle(article)
 	if err != nil {
+		if errors.Is(err, services.ErrValidation) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
 		return
 	}
@@ -198,23 +224,31 @@ func CreateArticle(c *gin.Context) {
 func UpdateArticle(c *gin.Context) {
 	id := c.Param("id")
 
-	// Get existing article first
-	existingArticle, err := services.GetArticleById(id)
+	// Get existing article first, in any status and bypassing the public cache
+	existingArticle, err := services.GetArticleForEdit(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Article not found"})
+		if errors.Is(err, services.ErrNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Article not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+		}
 		return
 	}
+	previousStatus := existingArticle.Status
 
 	// Parse update data with custom struct to handle Gallery as array
 	var updateInput struct {
-		Title         string   `json:"title"`
-		Content       string   `json:"content"`
-		FeaturedImage string   `json:"featured_image"`
-		Gallery       []string `json:"gallery"` // Array of image URLs
-		Status        string   `json:"status"`
-		MetaTitle     string   `json:"meta_title"`
-		MetaDesc      string   `json:"meta_description"`
-		Language      string   `json:"language"`
+		Title         string       `json:"title"`
+		Content       string       `json:"content"`
+		FeaturedImage string       `json:"featured_image"`
+		Gallery       []string     `json:"gallery"` // Array of image URLs
+		Status        string       `json:"status"`
+		ScheduledAt   nullableTime `json:"scheduled_at"` // null clears the schedule
+		UnpublishAt   nullableTime `json:"unpublish_at"` // null clears the unpublish time
+		UnpublishTo   string       `json:"unpublish_to"` // draft or archived
+		MetaTitle     string       `json:"meta_title"`
+		MetaDesc      string       `json:"meta_description"`
+		Language      string       `json:"language"`
 	}
 
 	if err := c.ShouldBindJSON(&updateInput); err != nil {
@@ -247,6 +281,18 @@ func UpdateArticle(c *gin.Context) {
 		existingArticle.Status = updateInput.Status
 	}
 
+	if updateInput.ScheduledAt.Set {
+		existingArticle.ScheduledAt = updateInput.ScheduledAt.Value
+	}
+
+	if updateInput.UnpublishAt.Set {
+		existingArticle.UnpublishAt = updateInput.UnpublishAt.Value
+	}
+
+	if updateInput.UnpublishTo != "" {
+		existingArticle.UnpublishTo = updateInput.UnpublishTo
+	}
+
 	if updateInput.MetaTitle != "" {
 		existingArticle.MetaTitle = updateInput.MetaTitle
 	}
@@ -268,10 +314,22 @@ func UpdateArticle(c *gin.Context) {
 		existingArticle.Gallery = datatypes.JSON("[]")
 	}
 
-	updatedArticle, err := services.UpdateArticle(id, existingArticle)
+	// Authors may only edit their own articles, and status changes follow the editorial workflow
+	actor := workflowActor(c)
+	if err := services.GetArticleWorkflowService().Authorize(actor, &existingArticle, previousStatus, existingArticle.Status); err != nil {
+		respondWorkflowError(c, err)
+		return
+	}
+
+	// Attribute the revision to the editing user
+	editorID := actor.ID
+
+	updatedArticle, err := services.UpdateArticle(id, existingArticle, editorID)
 	if err != nil {
 		if err == services.ErrNotFound {
 			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Article not found"})
+		} else if errors.Is(err, services.ErrValidation) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
 		} else {
 			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
 		}
@@ -281,6 +339,28 @@ func UpdateArticle(c *gin.Context) {
 	c.JSON(http.StatusOK, updatedArticle)
 }
 
+// nullableTime is a time field of a partial update that tells a value left out of the request
+// apart from an explicit null
+type nullableTime struct {
+	Set   bool
+	Value *time.Time
+}
+
+// UnmarshalJSON records that the field was sent and decodes it, with null clearing the value
+func (t *nullableTime) UnmarshalJSON(data []byte) error {
+	t.Set = true
+	if string(data) == "null" {
+		t.Value = nil
+		return nil
+	}
+	var value time.Time
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	t.Value = &value
+	return nil
+}
+
 // @Summary Delete an article
 // @Description Delete an article by ID (admin only)
 // @Tags Articles
@@ -392,22 +472,30 @@ func CreateArticleWithBlocks(c *gin.Context) {
 
 // GetArticleWithBlocks godoc
 // @Summary Get article with content blocks
-// @Description Retrieve an article with its content blocks for editing
+// @Description Retrieve an article with its content blocks for editing. With a preview token (preview_token query parameter or X-Preview-Token header) the article is returned in any status, read past the cache; a published article is still returned when the token is invalid.
 // @Tags Articles
 // @Produce json
 // @Param id path int true "Article ID"
+// @Param preview_token query string false "Draft preview token"
 // @Success 200 {object} models.Article
+// @Failure 401 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /api/articles/{id}/with-blocks [get]
 func GetArticleWithBlocks(c *gin.Context) {
+	// An invalid preview token still gets the published article
+	token := previewTokenFrom(c)
+	if token != "" && respondArticlePreview(c, token, true) {
+		return
+	}
+
 	id := c.Param("id")
 
 	// Get article with blocks
 	article, err := services.GetArticleWithBlocks(id)
 	if err != nil {
 		if err == services.ErrNotFound {
-			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Article not found"})
+			respondArticleNotFound(c, token)
 		} else {
 			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
 		}
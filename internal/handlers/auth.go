@@ -142,6 +142,19 @@ func LoginWithSecurity(c *gin.Context) {
 		return
 	}
 
+	if user.IsSuspended() {
+		database.DB.Create(&models.LoginAttempt{
+			Username:      loginDTO.Username,
+			UserAgent:     c.GetHeader("User-Agent"),
+			IP:            c.ClientIP(),
+			Success:       false,
+			FailureReason: "account suspended",
+			Timestamp:     time.Now(),
+		})
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Account suspended"})
+		return
+	}
+
 	// Generate token pair using the token manager
 	tokenManager := auth.NewTokenManager(
 		[]byte(middleware.GetJWTSecret()),
@@ -326,6 +339,10 @@ func RefreshToken(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "User not found"})
 		return
 	}
+	if user.IsSuspended() {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Account suspended"})
+		return
+	}
 
 	// Blacklist the old refresh token
 	if err := cache.GetRedisClient().BlacklistToken(claims.TokenID, time.Until(claims.ExpiresAt.Time)); err != nil {
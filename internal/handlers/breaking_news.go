@@ -8,6 +8,7 @@ import (
 	"news/internal/database"
 	"news/internal/models"
 	"news/internal/pubsub"
+	"news/internal/services"
 	"news/internal/tracing"
 
 	"github.com/gin-gonic/gin"
@@ -138,6 +139,17 @@ func (h *BreakingNewsHandler) CreateBreakingNews(c *gin.Context) {
 		}
 	}
 
+	go services.EmitWebhookEvent(models.WebhookEventBreakingNewsCreated, map[string]interface{}{
+		"id":         banner.ID,
+		"title":      banner.Title,
+		"content":    banner.Content,
+		"article_id": banner.ArticleID,
+		"priority":   banner.Priority,
+		"is_active":  banner.IsActive,
+		"start_time": banner.StartTime,
+		"end_time":   banner.EndTime,
+	})
+
 	span.SetAttributes(attribute.Int("banner.id", int(banner.ID)))
 	c.JSON(http.StatusCreated, banner)
 }
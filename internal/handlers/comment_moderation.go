@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"news/internal/models"
+	"news/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ModerateCommentRequest carries the optional reason of a moderator decision
+type ModerateCommentRequest struct {
+	Reason string `json:"reason" example:"Personal attack"`
+}
+
+// BulkModerateCommentsRequest applies one moderator action to several comments
+type BulkModerateCommentsRequest struct {
+	IDs    []uint `json:"ids" binding:"required"`
+	Action string `json:"action" binding:"required" example:"approve"` // approve, reject, spam
+	Reason string `json:"reason"`
+}
+
+// GetCommentModerationQueue godoc
+// @Summary Get the comment moderation queue
+// @Description List comments awaiting moderation, oldest first, with each author's trust score. Other statuses can be listed to review earlier decisions (admin only).
+// @Tags Comment Moderation
+// @Produce json
+// @Security Bearer
+// @Param status query string false "pending, approved, rejected or spam" default(pending)
+// @Param article_id query int false "Only comments on this article"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(50)
+// @Success 200 {object} models.PaginatedResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/comments/moderation [get]
+func GetCommentModerationQueue(c *gin.Context) {
+	articleID, _ := strconv.ParseUint(c.Query("article_id"), 10, 32)
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	items, total, err := services.GetCommentModerationService().Queue(services.ModerationQueueFilter{
+		Status:    c.Query("status"),
+		ArticleID: uint(articleID),
+		Page:      page,
+		Limit:     limit,
+	})
+	if err != nil {
+		respondCommentError(c, err)
+		return
+	}
+
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	c.JSON(http.StatusOK, models.PaginatedResponse{
+		Data:       items,
+		TotalItems: int(total),
+		Page:       page,
+		Limit:      limit,
+		TotalPages: totalPages,
+		HasNext:    page < totalPages,
+		HasPrev:    page > 1,
+	})
+}
+
+// ApproveComment godoc
+// @Summary Approve a comment
+// @Description Publish a comment from the moderation queue (admin only)
+// @Tags Comment Moderation
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Comment ID"
+// @Param request body ModerateCommentRequest false "Reason"
+// @Success 200 {object} models.Comment
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/comments/{id}/approve [post]
+func ApproveComment(c *gin.Context) {
+	moderateComment(c, services.ModerationActionApprove)
+}
+
+// RejectComment godoc
+// @Summary Reject a comment
+// @Description Reject a comment. The author is notified with the reason (admin only).
+// @Tags Comment Moderation
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Comment ID"
+// @Param request body ModerateCommentRequest false "Reason shown to the author"
+// @Success 200 {object} models.Comment
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/comments/{id}/reject [post]
+func RejectComment(c *gin.Context) {
+	moderateComment(c, services.ModerationActionReject)
+}
+
+// MarkCommentSpam godoc
+// @Summary Mark a comment as spam
+// @Description Hide a comment as spam. The author is not notified and their trust score drops sharply (admin only).
+// @Tags Comment Moderation
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Comment ID"
+// @Param request body ModerateCommentRequest false "Reason"
+// @Success 200 {object} models.Comment
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/comments/{id}/spam [post]
+func MarkCommentSpam(c *gin.Context) {
+	moderateComment(c, services.ModerationActionSpam)
+}
+
+func moderateComment(c *gin.Context, action string) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid comment ID"})
+		return
+	}
+
+	// The reason is optional, so an empty body is accepted
+	var req ModerateCommentRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request format"})
+			return
+		}
+	}
+
+	userID, _ := c.Get("user_id")
+	moderatorID, _ := userID.(uint)
+
+	comment, err := services.GetCommentModerationService().ModerateOne(uint(id), action, moderatorID, req.Reason)
+	if err != nil {
+		respondCommentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, comment)
+}
+
+// BulkModerateComments godoc
+// @Summary Moderate comments in bulk
+// @Description Approve, reject or mark as spam up to 100 comments at once. Comments already in the target status are skipped; unknown IDs are listed in not_found (admin only).
+// @Tags Comment Moderation
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body BulkModerateCommentsRequest true "Comments and action"
+// @Success 200 {object} services.ModerationActionResult
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/comments/moderation/bulk [post]
+func BulkModerateComments(c *gin.Context) {
+	var req BulkModerateCommentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request format"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	moderatorID, _ := userID.(uint)
+
+	result, err := services.GetCommentModerationService().Moderate(req.IDs, req.Action, moderatorID, req.Reason)
+	if err != nil {
+		respondCommentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetCommentModerationPolicies godoc
+// @Summary List comment moderation policies
+// @Description List the global, category and article moderation policies. Without a global policy the COMMENT_MODERATION_* defaults apply (admin only).
+// @Tags Comment Moderation
+// @Produce json
+// @Security Bearer
+// @Success 200 {array} models.CommentModerationPolicy
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/comments/moderation/policies [get]
+func GetCommentModerationPolicies(c *gin.Context) {
+	policies, err := services.GetCommentModerationService().ListPolicies()
+	if err != nil {
+		respondCommentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, policies)
+}
+
+// SaveCommentModerationPolicy godoc
+// @Summary Set a comment moderation policy
+// @Description Create or replace the moderation policy of the whole site, a category or an article. An article policy overrides its categories' policies; an article in several categories gets the strictest of them. Modes are auto_approve, pre_moderate and ai_screen; under ai_screen comments the AI approves or flags with at least the threshold confidence are published or rejected, the rest are queued. Commenters whose trust score reaches trust_threshold skip the queue (admin only).
+// @Tags Comment Moderation
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param policy body services.ModerationPolicyRequest true "Policy"
+// @Success 200 {object} models.CommentModerationPolicy
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/comments/moderation/policies [put]
+func SaveCommentModerationPolicy(c *gin.Context) {
+	var req services.ModerationPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	updatedBy, _ := userID.(uint)
+
+	policy, err := services.GetCommentModerationService().SavePolicy(req, updatedBy)
+	if err != nil {
+		respondCommentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeleteCommentModerationPolicy godoc
+// @Summary Delete a comment moderation policy
+// @Description Delete a moderation policy; its scope falls back to the next broader policy (admin only)
+// @Tags Comment Moderation
+// @Security Bearer
+// @Param id path int true "Policy ID"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/comments/moderation/policies/{id} [delete]
+func DeleteCommentModerationPolicy(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid policy ID"})
+		return
+	}
+
+	if err := services.GetCommentModerationService().DeletePolicy(uint(id)); err != nil {
+		respondCommentError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
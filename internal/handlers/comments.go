@@ -1,93 +1,86 @@
 package handlers
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
 
 	"news/internal/database"
 	"news/internal/models"
-	"news/internal/pubsub"
+	"news/internal/moderation"
+	"news/internal/services"
+	"news/internal/threads"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 // GetComments godoc
 // @Summary Get comments for an article
-// @Description Retrieve comments for a specific article with threading support
+// @Description Retrieve the approved comments of an article. The default flat mode returns numbered pages of top-level comments with their direct replies. Tree mode returns nested replies up to depth levels, replies_limit per comment, with cursors: next_cursor loads the next page and a comment's replies_cursor, passed with parent_id set to that comment, loads more of its replies. Sorts are newest, oldest, top (likes minus dislikes) and controversial (many votes, evenly split).
 // @Tags Comments
 // @Produce json
-// @Param article_id path int true "Article ID"
-// @Param page query int false "Page number" default(1)
+// @Param id path int true "Article ID"
+// @Param mode query string false "flat or tree" default(flat)
+// @Param sort query string false "Sort by: newest, oldest, top, controversial" default(newest)
+// @Param page query int false "Page number (flat mode)" default(1)
 // @Param limit query int false "Comments per page" default(20)
-// @Param sort query string false "Sort by: newest, oldest, likes" default(newest)
-// @Success 200 {object} models.PaginatedResponse
+// @Param depth query int false "Reply levels to include, counting the page itself (tree mode, max 10)" default(3)
+// @Param replies_limit query int false "Replies per comment (tree mode, max 50)" default(3)
+// @Param parent_id query int false "List the replies of this comment (tree mode)"
+// @Param cursor query string false "Cursor from next_cursor or replies_cursor (tree mode)"
+// @Success 200 {object} models.PaginatedResponse "Flat mode"
+// @Success 200 {object} services.CommentThreadPage "Tree mode"
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
-// @Router /articles/{article_id}/comments [get]
+// @Router /articles/{id}/comments [get]
 func GetComments(c *gin.Context) {
-	articleIDStr := c.Param("article_id")
+	articleIDStr := c.Param("id")
 	articleID, err := strconv.ParseUint(articleIDStr, 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid article ID"})
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
-	sort := c.DefaultQuery("sort", "newest")
-
-	if page < 1 {
-		page = 1
+	sort := c.DefaultQuery("sort", threads.SortNewest)
+	if sort == "likes" {
+		sort = threads.SortTop // Older clients ask for likes
 	}
+
 	if limit < 1 || limit > 100 {
 		limit = 20
 	}
 
-	offset := (page - 1) * limit
-
 	// Verify article exists
 	var article models.Article
-	if err := database.DB.First(&article, articleID).Error; err != nil {
+	if err := database.DB.Select("id").First(&article, articleID).Error; err != nil {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Article not found"})
 		return
 	}
 
-	// Build query
-	query := database.DB.Where("article_id = ? AND status = ? AND parent_id IS NULL", articleID, "approved").
-		Preload("User").
-		Preload("Replies", func(db *gorm.DB) *gorm.DB {
-			return db.Where("status = ?", "approved").Order("created_at ASC").Preload("User")
-		})
-
-	// Apply sorting
-	switch sort {
-	case "oldest":
-		query = query.Order("created_at ASC")
-	case "likes":
-		// This would require a vote count subquery - simplified for now
-		query = query.Order("created_at DESC")
-	default: // newest
-		query = query.Order("created_at DESC")
-	}
-
-	// Get total count
-	var total int64
-	database.DB.Model(&models.Comment{}).Where("article_id = ? AND status = ? AND parent_id IS NULL", articleID, "approved").Count(&total)
-
-	// Get comments with pagination
-	var comments []models.Comment
-	if err := query.Offset(offset).Limit(limit).Find(&comments).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to fetch comments"})
+	if c.Query("mode") == "tree" {
+		getCommentTree(c, uint(articleID), sort, limit)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+
+	comments, total, err := services.GetCommentPage(uint(articleID), sort, page, limit)
+	if err != nil {
+		respondCommentError(c, err)
 		return
 	}
 
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	totalPages := (total + limit - 1) / limit
 
 	response := models.PaginatedResponse{
 		Data:       comments,
-		TotalItems: int(total), // Renamed from Total
+		TotalItems: total, // Renamed from Total
 		Page:       page,
 		Limit:      limit,
 		TotalPages: totalPages,
@@ -98,22 +91,50 @@ func GetComments(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// getCommentTree writes a page of the comment tree of an article
+func getCommentTree(c *gin.Context, articleID uint, sort string, limit int) {
+	depth, _ := strconv.Atoi(c.DefaultQuery("depth", strconv.Itoa(services.DefaultThreadDepth)))
+	if depth < 1 || depth > services.MaxThreadDepth {
+		depth = services.DefaultThreadDepth
+	}
+	repliesLimit, _ := strconv.Atoi(c.DefaultQuery("replies_limit", strconv.Itoa(services.DefaultThreadRepliesLimit)))
+	if repliesLimit < 1 || repliesLimit > services.MaxThreadRepliesLimit {
+		repliesLimit = services.DefaultThreadRepliesLimit
+	}
+	parentID, _ := strconv.ParseUint(c.Query("parent_id"), 10, 32)
+
+	page, err := services.GetCommentThreads(articleID, services.CommentThreadOptions{
+		Sort:         sort,
+		Depth:        depth,
+		Limit:        limit,
+		RepliesLimit: repliesLimit,
+		ParentID:     uint(parentID),
+		Cursor:       c.Query("cursor"),
+	})
+	if err != nil {
+		respondCommentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
 // CreateComment godoc
 // @Summary Create a new comment
-// @Description Create a new comment on an article (requires authentication)
+// @Description Create a new comment on an article (requires authentication). The comment is moderated under the policy of the article or its categories: it is published immediately (201), or held for a moderator or turned down by AI screening (202, see status and moderation_reason). Commenters with a high enough trust score skip the queue.
 // @Tags Comments
 // @Accept json
 // @Produce json
 // @Security Bearer
-// @Param article_id path int true "Article ID"
+// @Param id path int true "Article ID"
 // @Param comment body CreateCommentRequest true "Comment data"
 // @Success 201 {object} models.Comment
+// @Success 202 {object} models.Comment "Comment awaiting moderation or rejected"
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
-// @Router /articles/{article_id}/comments [post]
+// @Router /articles/{id}/comments [post]
 func CreateComment(c *gin.Context) {
-	articleIDStr := c.Param("article_id")
+	articleIDStr := c.Param("id")
 	articleID, err := strconv.ParseUint(articleIDStr, 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid article ID"})
@@ -160,28 +181,25 @@ func CreateComment(c *gin.Context) {
 		}
 	}
 
-	// Create comment
+	// Create comment; its status comes from the moderation policy
 	comment := models.Comment{
 		ArticleID: uint(articleID),
 		UserID:    userID.(uint),
 		ParentID:  request.ParentID,
 		Content:   request.Content,
-		Status:    "approved", // Can be changed to "pending" for moderation
 	}
 
-	if err := database.DB.Create(&comment).Error; err != nil {
+	decision, err := services.GetCommentModerationService().CreateComment(c.Request.Context(), &comment)
+	if err != nil {
+		log.Printf("Failed to create comment: %v", err)
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to create comment"})
 		return
 	}
 
-	// Load user relationship for response
-	database.DB.Preload("User").First(&comment, comment.ID)
-
-	// Publish real-time comment notification
-	if err := pubsub.PublishCommentNotification(uint(articleID), comment); err != nil {
-		log.Printf("Failed to publish comment notification: %v", err)
+	if decision.Status != moderation.StatusApproved {
+		c.JSON(http.StatusAccepted, comment)
+		return
 	}
-
 	c.JSON(http.StatusCreated, comment)
 }
 
@@ -368,6 +386,21 @@ func VoteComment(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// respondCommentError maps comment and moderation service errors to responses
+func respondCommentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrValidation):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrCommentNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Comment not found"})
+	case errors.Is(err, services.ErrModerationPolicyNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Moderation policy not found"})
+	default:
+		log.Printf("Comment request failed: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to process comment request"})
+	}
+}
+
 // Request/Response structures
 type CreateCommentRequest struct {
 	Content  string `json:"content" binding:"required"`
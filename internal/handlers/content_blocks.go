@@ -281,7 +281,7 @@ func MigrateArticleToBlocks(c *gin.Context) {
 
 // UpdateArticleBlocks godoc
 // @Summary Update all content blocks for an article
-// @Description Replace all content blocks for an article
+// @Description Replace all content blocks for an article. Blocks sent with the ID of one of the article's blocks update that block in place; blocks without an ID are created, and blocks left out are deleted.
 // @Tags Content Blocks
 // @Accept json
 // @Produce json
@@ -303,7 +303,12 @@ func UpdateArticleBlocks(c *gin.Context) {
 		return
 	}
 
-	if err := services.UpdateArticleBlocks(articleID, request.Blocks); err != nil {
+	var editorID uint
+	if userID, exists := c.Get("user_id"); exists {
+		editorID = userID.(uint)
+	}
+
+	if err := services.UpdateArticleBlocks(articleID, request.Blocks, editorID); err != nil {
 		if err == services.ErrNotFound {
 			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Article not found"})
 		} else {
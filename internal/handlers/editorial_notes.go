@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"news/internal/models"
+	"news/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetArticleEditorialNotes godoc
+// @Summary List editorial notes of an article
+// @Description List the note threads on an article's content blocks with their replies. anchor_outdated marks notes whose anchored text was edited away and block_missing notes whose block was removed. Authors only see notes on their own articles.
+// @Tags Editorial Notes
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Article ID"
+// @Param block_id query int false "Only notes on this block"
+// @Param resolved query bool false "Filter by resolved state"
+// @Success 200 {array} models.EditorialNote
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /editorial/articles/{id}/notes [get]
+func GetArticleEditorialNotes(c *gin.Context) {
+	listEditorialNotes(c, models.EditorialNoteArticle)
+}
+
+// CreateArticleEditorialNote godoc
+// @Summary Add an editorial note to an article block
+// @Description Start a note thread on a content block of an article, optionally anchored to the characters [anchor_start, anchor_end) of the block. Mentioned staff (@username) are notified.
+// @Tags Editorial Notes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Article ID"
+// @Param note body services.EditorialNoteRequest true "Note"
+// @Success 201 {object} models.EditorialNote
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /editorial/articles/{id}/notes [post]
+func CreateArticleEditorialNote(c *gin.Context) {
+	createEditorialNote(c, models.EditorialNoteArticle)
+}
+
+// GetPageEditorialNotes godoc
+// @Summary List editorial notes of a page
+// @Description List the note threads on a page's content blocks with their replies
+// @Tags Editorial Notes
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Page ID"
+// @Param block_id query int false "Only notes on this block"
+// @Param resolved query bool false "Filter by resolved state"
+// @Success 200 {array} models.EditorialNote
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /editorial/pages/{id}/notes [get]
+func GetPageEditorialNotes(c *gin.Context) {
+	listEditorialNotes(c, models.EditorialNotePage)
+}
+
+// CreatePageEditorialNote godoc
+// @Summary Add an editorial note to a page block
+// @Description Start a note thread on a content block of a page, optionally anchored to a character range of the block
+// @Tags Editorial Notes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Page ID"
+// @Param note body services.EditorialNoteRequest true "Note"
+// @Success 201 {object} models.EditorialNote
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /editorial/pages/{id}/notes [post]
+func CreatePageEditorialNote(c *gin.Context) {
+	createEditorialNote(c, models.EditorialNotePage)
+}
+
+// ReplyToEditorialNote godoc
+// @Summary Reply to an editorial note
+// @Description Reply to a note thread. The people in the thread and mentioned staff are notified.
+// @Tags Editorial Notes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Note ID"
+// @Param reply body services.EditorialReplyRequest true "Reply"
+// @Success 201 {object} models.EditorialNote
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /editorial/notes/{id}/replies [post]
+func ReplyToEditorialNote(c *gin.Context) {
+	noteID, ok := parseEditorialNoteID(c)
+	if !ok {
+		return
+	}
+
+	var req services.EditorialReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request: " + err.Error()})
+		return
+	}
+
+	reply, err := services.GetEditorialNoteService().Reply(noteID, workflowActor(c), req)
+	if err != nil {
+		respondEditorialNoteError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, reply)
+}
+
+// UpdateEditorialNote godoc
+// @Summary Edit an editorial note
+// @Description Edit the body of one of your own notes
+// @Tags Editorial Notes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Note ID"
+// @Param note body services.EditorialReplyRequest true "New body"
+// @Success 200 {object} models.EditorialNote
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /editorial/notes/{id} [put]
+func UpdateEditorialNote(c *gin.Context) {
+	noteID, ok := parseEditorialNoteID(c)
+	if !ok {
+		return
+	}
+
+	var req services.EditorialReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request: " + err.Error()})
+		return
+	}
+
+	note, err := services.GetEditorialNoteService().Update(noteID, workflowActor(c), req)
+	if err != nil {
+		respondEditorialNoteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, note)
+}
+
+// ResolveEditorialNote godoc
+// @Summary Resolve an editorial note thread
+// @Tags Editorial Notes
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Note ID"
+// @Success 200 {object} models.EditorialNote
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /editorial/notes/{id}/resolve [post]
+func ResolveEditorialNote(c *gin.Context) {
+	setEditorialNoteResolved(c, true)
+}
+
+// UnresolveEditorialNote godoc
+// @Summary Reopen a resolved editorial note thread
+// @Tags Editorial Notes
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Note ID"
+// @Success 200 {object} models.EditorialNote
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /editorial/notes/{id}/unresolve [post]
+func UnresolveEditorialNote(c *gin.Context) {
+	setEditorialNoteResolved(c, false)
+}
+
+// DeleteEditorialNote godoc
+// @Summary Delete an editorial note
+// @Description Delete one of your own notes, with its replies when it starts a thread. Admins can delete any note.
+// @Tags Editorial Notes
+// @Security BearerAuth
+// @Param id path int true "Note ID"
+// @Success 204 "No Content"
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /editorial/notes/{id} [delete]
+func DeleteEditorialNote(c *gin.Context) {
+	noteID, ok := parseEditorialNoteID(c)
+	if !ok {
+		return
+	}
+
+	if err := services.GetEditorialNoteService().Delete(noteID, workflowActor(c)); err != nil {
+		respondEditorialNoteError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func listEditorialNotes(c *gin.Context, entityType string) {
+	entityID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid ID"})
+		return
+	}
+
+	var filter services.EditorialNoteFilter
+	if blockID, err := strconv.ParseUint(c.Query("block_id"), 10, 32); err == nil {
+		filter.BlockID = uint(blockID)
+	}
+	if resolved, err := strconv.ParseBool(c.Query("resolved")); err == nil {
+		filter.Resolved = &resolved
+	}
+
+	notes, err := services.GetEditorialNoteService().List(entityType, uint(entityID), workflowActor(c), filter)
+	if err != nil {
+		respondEditorialNoteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, notes)
+}
+
+func createEditorialNote(c *gin.Context, entityType string) {
+	entityID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid ID"})
+		return
+	}
+
+	var req services.EditorialNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request: " + err.Error()})
+		return
+	}
+
+	note, err := services.GetEditorialNoteService().Create(entityType, uint(entityID), workflowActor(c), req)
+	if err != nil {
+		respondEditorialNoteError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, note)
+}
+
+func setEditorialNoteResolved(c *gin.Context, resolved bool) {
+	noteID, ok := parseEditorialNoteID(c)
+	if !ok {
+		return
+	}
+
+	note, err := services.GetEditorialNoteService().SetResolved(noteID, workflowActor(c), resolved)
+	if err != nil {
+		respondEditorialNoteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, note)
+}
+
+func parseEditorialNoteID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid note ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondEditorialNoteError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrValidation):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrWorkflowForbidden):
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrEditorialNoteNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Note not found"})
+	case errors.Is(err, services.ErrNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Content not found"})
+	default:
+		log.Printf("Editorial note request failed: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to process editorial note"})
+	}
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"news/internal/models"
+	"news/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReindexArticleEmbeddings godoc
+// @Summary Reindex article embeddings
+// @Description Queues every published article whose embedding is missing, outdated or from another provider, e.g. after changing EMBEDDING_PROVIDER (admin only)
+// @Tags AI
+// @Produce json
+// @Security Bearer
+// @Success 202 {object} services.EmbeddingReindexResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/embeddings/reindex [post]
+func ReindexArticleEmbeddings(c *gin.Context) {
+	result, err := services.GetEmbeddingService().ReindexArticles()
+	if err != nil {
+		if errors.Is(err, services.ErrValidation) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to queue article embeddings"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, result)
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"news/internal/feeds"
+	"news/internal/models"
+	"news/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetLatestFeed godoc
+// @Summary Latest articles feed
+// @Description Feed of the latest published articles as RSS 2.0 (default), Atom 1.0 or JSON Feed 1.1. Supports conditional requests with If-None-Match and If-Modified-Since.
+// @Tags Feeds
+// @Produce xml
+// @Produce json
+// @Param format query string false "rss, atom or json" default(rss)
+// @Param lang query string false "Language; translated articles use their published translation"
+// @Success 200 {string} string "Feed document"
+// @Success 304 "Not Modified"
+// @Failure 400 {object} models.ErrorResponse
+// @Router /feeds/latest [get]
+func GetLatestFeed(c *gin.Context) {
+	serveFeed(c, services.FeedLatest, "")
+}
+
+// GetCategoryFeed godoc
+// @Summary Category feed
+// @Description Feed of the latest published articles in a category
+// @Tags Feeds
+// @Produce xml
+// @Produce json
+// @Param slug path string true "Category slug"
+// @Param format query string false "rss, atom or json" default(rss)
+// @Param lang query string false "Language"
+// @Success 200 {string} string "Feed document"
+// @Success 304 "Not Modified"
+// @Failure 404 {object} models.ErrorResponse
+// @Router /feeds/category/{slug} [get]
+func GetCategoryFeed(c *gin.Context) {
+	serveFeed(c, services.FeedCategory, c.Param("slug"))
+}
+
+// GetTagFeed godoc
+// @Summary Tag feed
+// @Description Feed of the latest published articles with a tag
+// @Tags Feeds
+// @Produce xml
+// @Produce json
+// @Param slug path string true "Tag slug"
+// @Param format query string false "rss, atom or json" default(rss)
+// @Param lang query string false "Language"
+// @Success 200 {string} string "Feed document"
+// @Success 304 "Not Modified"
+// @Failure 404 {object} models.ErrorResponse
+// @Router /feeds/tag/{slug} [get]
+func GetTagFeed(c *gin.Context) {
+	serveFeed(c, services.FeedTag, c.Param("slug"))
+}
+
+// GetAuthorFeed godoc
+// @Summary Author feed
+// @Description Feed of the latest published articles by an author
+// @Tags Feeds
+// @Produce xml
+// @Produce json
+// @Param username path string true "Author username"
+// @Param format query string false "rss, atom or json" default(rss)
+// @Param lang query string false "Language"
+// @Success 200 {string} string "Feed document"
+// @Success 304 "Not Modified"
+// @Failure 404 {object} models.ErrorResponse
+// @Router /feeds/author/{username} [get]
+func GetAuthorFeed(c *gin.Context) {
+	serveFeed(c, services.FeedAuthor, c.Param("username"))
+}
+
+func serveFeed(c *gin.Context, kind, slug string) {
+	format := strings.ToLower(c.DefaultQuery("format", feeds.FormatRSS))
+
+	feed, err := services.GetFeedService().Get(kind, slug, c.Query("lang"), format)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrValidation):
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, services.ErrFeedNotFound):
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Feed not found"})
+		default:
+			log.Printf("Failed to build %s feed %q: %v", kind, slug, err)
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to build feed"})
+		}
+		return
+	}
+
+	c.Header("ETag", feed.ETag)
+	if !feed.LastModified.IsZero() {
+		c.Header("Last-Modified", feed.LastModified.UTC().Format(http.TimeFormat))
+	}
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(services.GetFeedService().CacheTTL().Seconds())))
+	c.Header("Vary", "Accept-Encoding")
+
+	if feeds.NotModified(c.GetHeader("If-None-Match"), c.GetHeader("If-Modified-Since"), feed.ETag, feed.LastModified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, feed.ContentType, feed.Body)
+}
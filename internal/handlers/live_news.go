@@ -1,12 +1,17 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
 	"news/internal/database"
+	"news/internal/json"
 	"news/internal/models"
+	"news/internal/pubsub"
+	"news/internal/services"
 	"news/internal/tracing"
 
 	"github.com/gin-gonic/gin"
@@ -14,6 +19,12 @@ import (
 	"gorm.io/gorm"
 )
 
+// pinnedFirstOrder lists pinned updates first, then the newest
+const pinnedFirstOrder = "CASE WHEN importance = 'pinned' THEN 0 ELSE 1 END, created_at DESC"
+
+// liveStreamHeartbeat keeps idle Server-Sent Events connections open through proxies
+const liveStreamHeartbeat = 25 * time.Second
+
 // LiveNewsHandler handles live news stream operations
 type LiveNewsHandler struct {
 	DB *gorm.DB
@@ -77,7 +88,7 @@ func (h *LiveNewsHandler) GetLiveStreamByID(c *gin.Context) {
 
 	var stream models.LiveNewsStream
 	if err := h.DB.Preload("Updates", func(db *gorm.DB) *gorm.DB {
-		return db.Order("created_at DESC")
+		return db.Order(pinnedFirstOrder)
 	}).First(&stream, id).Error; err != nil {
 		span.RecordError(err)
 		if err == gorm.ErrRecordNotFound {
@@ -92,9 +103,6 @@ func (h *LiveNewsHandler) GetLiveStreamByID(c *gin.Context) {
 		return
 	}
 
-	// Increment viewer count
-	h.DB.Model(&stream).Update("viewer_count", gorm.Expr("viewer_count + ?", 1))
-
 	c.JSON(http.StatusOK, stream)
 }
 
@@ -142,9 +150,23 @@ func (h *LiveNewsHandler) CreateLiveStream(c *gin.Context) {
 	// }
 	// stream.CreateUserID = userID.(uint)
 
-	// If status not provided, set default to draft
+	// If status not provided, streams with a start time are scheduled, others are drafts
 	if stream.Status == "" {
-		stream.Status = "draft"
+		if stream.StartTime != nil {
+			stream.Status = models.LiveStreamScheduled
+		} else {
+			stream.Status = models.LiveStreamDraft
+		}
+	}
+	if !stream.ValidateStatus() {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid status, must be one of draft, scheduled, live or ended",
+		})
+		return
+	}
+	if stream.Status == models.LiveStreamLive && stream.StartTime == nil {
+		now := time.Now()
+		stream.StartTime = &now
 	}
 
 	// Create the stream
@@ -214,10 +236,18 @@ func (h *LiveNewsHandler) UpdateLiveStream(c *gin.Context) {
 	// }
 
 	// Update status if provided
+	previousStatus := stream.Status
 	if updatedStream.Status != "" {
+		if !updatedStream.ValidateStatus() {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: "Invalid status, must be one of draft, scheduled, live or ended",
+			})
+			return
+		}
+
 		// Validate status transitions
-		if updatedStream.Status == "live" && stream.Status == "draft" {
-			// If going from draft to live, set start time to now if not already set
+		if updatedStream.Status == models.LiveStreamLive && stream.Status != models.LiveStreamLive {
+			// If going live, set start time to now if not already set
 			if stream.StartTime == nil {
 				now := time.Now()
 				stream.StartTime = &now
@@ -261,6 +291,10 @@ func (h *LiveNewsHandler) UpdateLiveStream(c *gin.Context) {
 		return
 	}
 
+	if stream.Status != previousStatus {
+		services.RecordLiveStatusEvent(&stream, previousStatus)
+	}
+
 	c.JSON(http.StatusOK, stream)
 }
 
@@ -386,7 +420,13 @@ func (h *LiveNewsHandler) AddLiveUpdate(c *gin.Context) {
 
 	// If importance not provided, set default
 	if update.Importance == "" {
-		update.Importance = "normal"
+		update.Importance = models.LiveUpdateNormal
+	}
+	if !models.ValidLiveUpdateImportance(update.Importance) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid importance, must be one of normal, high, critical or pinned",
+		})
+		return
 	}
 
 	// Create the update
@@ -398,6 +438,8 @@ func (h *LiveNewsHandler) AddLiveUpdate(c *gin.Context) {
 		return
 	}
 
+	services.RecordLiveUpdateEvent(models.LiveEventUpdateCreated, &update)
+
 	c.JSON(http.StatusCreated, update)
 }
 
@@ -455,7 +497,7 @@ func (h *LiveNewsHandler) GetLiveUpdates(c *gin.Context) {
 
 	// Get paginated updates
 	if err := h.DB.Where("stream_id = ?", streamID).
-		Order("created_at DESC").
+		Order(pinnedFirstOrder).
 		Offset(offset).
 		Limit(limit).
 		Find(&updates).Error; err != nil {
@@ -485,3 +527,363 @@ func (h *LiveNewsHandler) GetLiveUpdates(c *gin.Context) {
 		HasPrev:    page > 1,
 	})
 }
+
+// LiveUpdateEditRequest holds the fields of a live update to change; empty fields are kept
+type LiveUpdateEditRequest struct {
+	Title      string `json:"title"`
+	Content    string `json:"content"`
+	UpdateType string `json:"update_type"`
+	Importance string `json:"importance"`
+}
+
+// LiveNewsEventsResponse lists the events of a live stream after a given event ID
+type LiveNewsEventsResponse struct {
+	Events      []models.LiveNewsEvent `json:"events"`
+	LastEventID uint                   `json:"last_event_id"`
+	HasMore     bool                   `json:"has_more"`
+}
+
+// @Summary Edit a live news update
+// @Description Edits an update of a live news stream and pushes the change to viewers
+// @Tags Live News
+// @Accept json
+// @Produce json
+// @Param id path int true "Stream ID"
+// @Param update_id path int true "Update ID"
+// @Param update body LiveUpdateEditRequest true "Fields to change"
+// @Success 200 {object} models.LiveNewsUpdate
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/live-news/{id}/updates/{update_id} [put]
+func (h *LiveNewsHandler) EditLiveUpdate(c *gin.Context) {
+	_, span := tracing.StartSpanWithAttributes(c.Request.Context(), "LiveNewsHandler.EditLiveUpdate")
+	defer span.End()
+
+	update, ok := h.findLiveUpdate(c)
+	if !ok {
+		return
+	}
+
+	var req LiveUpdateEditRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		span.RecordError(err)
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid request payload: " + err.Error(),
+		})
+		return
+	}
+
+	if req.Title != "" {
+		update.Title = req.Title
+	}
+	if req.Content != "" {
+		update.Content = req.Content
+	}
+	if req.UpdateType != "" {
+		update.UpdateType = req.UpdateType
+	}
+	if req.Importance != "" {
+		if !models.ValidLiveUpdateImportance(req.Importance) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: "Invalid importance, must be one of normal, high, critical or pinned",
+			})
+			return
+		}
+		update.Importance = req.Importance
+	}
+
+	if err := h.DB.Omit("Stream").Save(update).Error; err != nil {
+		span.RecordError(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to update live update: " + err.Error(),
+		})
+		return
+	}
+
+	services.RecordLiveUpdateEvent(models.LiveEventUpdateEdited, update)
+
+	c.JSON(http.StatusOK, update)
+}
+
+// @Summary Delete a live news update
+// @Description Deletes an update of a live news stream and tells viewers to remove it
+// @Tags Live News
+// @Produce json
+// @Param id path int true "Stream ID"
+// @Param update_id path int true "Update ID"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/live-news/{id}/updates/{update_id} [delete]
+func (h *LiveNewsHandler) DeleteLiveUpdate(c *gin.Context) {
+	_, span := tracing.StartSpanWithAttributes(c.Request.Context(), "LiveNewsHandler.DeleteLiveUpdate")
+	defer span.End()
+
+	update, ok := h.findLiveUpdate(c)
+	if !ok {
+		return
+	}
+
+	if err := h.DB.Delete(update).Error; err != nil {
+		span.RecordError(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to delete live update: " + err.Error(),
+		})
+		return
+	}
+
+	data := services.LiveUpdateDeletedEventData{UpdateID: update.ID}
+	if _, err := services.RecordLiveNewsEvent(update.StreamID, models.LiveEventUpdateDeleted, &update.ID, data); err != nil {
+		span.RecordError(err)
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Live update deleted successfully",
+	})
+}
+
+// @Summary Get live news events
+// @Description Lists the created, edited and deleted updates and status changes of a live stream after an event ID, oldest first. WebSocket clients subscribed to live:{id} use it to catch up after reconnecting.
+// @Tags Live News
+// @Produce json
+// @Param id path int true "Stream ID"
+// @Param after_id query int false "Return events after this event ID"
+// @Param limit query int false "Maximum events (default and max: 500)"
+// @Success 200 {object} LiveNewsEventsResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/live-news/{id}/events [get]
+func (h *LiveNewsHandler) GetLiveEvents(c *gin.Context) {
+	_, span := tracing.StartSpanWithAttributes(c.Request.Context(), "LiveNewsHandler.GetLiveEvents")
+	defer span.End()
+
+	stream, ok := h.findLiveStream(c)
+	if !ok {
+		return
+	}
+
+	afterID, err := strconv.ParseUint(c.DefaultQuery("after_id", "0"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid after_id"})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(services.MaxLiveNewsEventReplay)))
+	if limit <= 0 || limit > services.MaxLiveNewsEventReplay {
+		limit = services.MaxLiveNewsEventReplay
+	}
+
+	events, err := services.GetLiveNewsEvents(stream.ID, uint(afterID), limit+1)
+	if err != nil {
+		span.RecordError(err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to fetch live events"})
+		return
+	}
+
+	response := LiveNewsEventsResponse{Events: events, LastEventID: uint(afterID)}
+	if len(events) > limit {
+		response.Events = events[:limit]
+		response.HasMore = true
+	}
+	if len(response.Events) > 0 {
+		response.LastEventID = response.Events[len(response.Events)-1].ID
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Stream live news events
+// @Description Streams the updates, status changes and viewer counts of a live stream as Server-Sent Events. Each logged event carries its ID, so a reconnecting client that sends Last-Event-ID (or ?last_event_id=) first receives the events it missed. New clients only receive new events and load earlier ones from the events endpoint; a client that missed more than 500 events receives a live_reset event and should reload them the same way.
+// @Tags Live News
+// @Produce text/event-stream
+// @Param id path int true "Stream ID"
+// @Param Last-Event-ID header int false "ID of the last event received"
+// @Param last_event_id query int false "ID of the last event received, for clients that cannot set headers"
+// @Success 200 {string} string "Event stream"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /api/live-news/{id}/stream [get]
+func (h *LiveNewsHandler) StreamLiveEvents(c *gin.Context) {
+	stream, ok := h.findLiveStream(c)
+	if !ok {
+		return
+	}
+
+	lastEventID, err := parseLastEventID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid Last-Event-ID"})
+		return
+	}
+
+	hub := pubsub.GetNotificationHub()
+	if hub == nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: "Live streaming is not available"})
+		return
+	}
+
+	// Subscribe before replaying so nothing published in between is lost; replayed events are
+	// skipped when they arrive live as well
+	topic := pubsub.LiveTopic(stream.ID)
+	client := pubsub.NewEventStreamClient(0, "")
+	if err := hub.Subscribe(client, topic); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to subscribe to live stream"})
+		return
+	}
+	hub.Register(client)
+	defer hub.Unregister(client)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", 3000)
+	c.Writer.Flush()
+
+	// Only a reconnecting client is sent the events it missed, and at most one replay of them;
+	// further back it is told to reload from the events endpoint
+	if lastEventID != 0 {
+		events, err := services.GetLiveNewsEvents(stream.ID, lastEventID, services.MaxLiveNewsEventReplay)
+		if err != nil {
+			fmt.Fprintf(c.Writer, "event: error\ndata: {\"error\":\"failed to load missed events\"}\n\n")
+			c.Writer.Flush()
+			return
+		}
+		if len(events) < services.MaxLiveNewsEventReplay {
+			for i := range events {
+				if writeLiveEvent(c, services.LiveNewsEventMessage(&events[i])) != nil {
+					return
+				}
+				lastEventID = events[i].ID
+			}
+		} else {
+			reset := pubsub.NotificationMessage{Type: models.LiveEventReset, Topic: topic, Timestamp: time.Now()}
+			if writeLiveEvent(c, reset) != nil {
+				return
+			}
+			lastEventID = 0
+		}
+	}
+
+	heartbeat := time.NewTicker(liveStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case message, open := <-client.Events:
+			if !open {
+				// Dropped by the hub, e.g. for falling behind; the client reconnects and resumes
+				return
+			}
+			if message.Topic != topic {
+				continue
+			}
+			if message.EventID != 0 {
+				if message.EventID <= lastEventID {
+					continue
+				}
+				lastEventID = message.EventID
+			}
+			if writeLiveEvent(c, message) != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeLiveEvent writes one Server-Sent Event. Only logged events get an ID, so viewer counts
+// do not move the client's resume point.
+func writeLiveEvent(c *gin.Context, message pubsub.NotificationMessage) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	if message.EventID != 0 {
+		if _, err := fmt.Fprintf(c.Writer, "id: %d\n", message.EventID); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", message.Type, data); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
+
+// parseLastEventID reads the resume point from the Last-Event-ID header or query parameter
+func parseLastEventID(c *gin.Context) (uint, error) {
+	value := c.GetHeader("Last-Event-ID")
+	if value == "" {
+		value = c.Query("last_event_id")
+	}
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint(id), nil
+}
+
+// findLiveStream loads the stream in the id path parameter, responding with an error if it
+// cannot be loaded
+func (h *LiveNewsHandler) findLiveStream(c *gin.Context) (*models.LiveNewsStream, bool) {
+	streamID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid stream ID"})
+		return nil, false
+	}
+
+	var stream models.LiveNewsStream
+	if err := h.DB.First(&stream, streamID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Live news stream not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to fetch live news stream"})
+		}
+		return nil, false
+	}
+	return &stream, true
+}
+
+// findLiveUpdate loads the update in the update_id path parameter of the stream in the id path
+// parameter, responding with an error if it cannot be loaded
+func (h *LiveNewsHandler) findLiveUpdate(c *gin.Context) (*models.LiveNewsUpdate, bool) {
+	streamID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid stream ID"})
+		return nil, false
+	}
+	updateID, err := strconv.ParseUint(c.Param("update_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid update ID"})
+		return nil, false
+	}
+
+	var update models.LiveNewsUpdate
+	if err := h.DB.Where("id = ? AND stream_id = ?", updateID, streamID).First(&update).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Live update not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to fetch live update"})
+		}
+		return nil, false
+	}
+	return &update, true
+}
@@ -1,21 +1,17 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
-	"io"
 	"log"
-	"mime/multipart"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
-	"time"
 
 	"news/internal/database"
 	"news/internal/models"
+	"news/internal/services"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
 // GetMedia godoc
@@ -119,7 +115,7 @@ func GetMediaByID(c *gin.Context) {
 
 // UploadMedia godoc
 // @Summary Upload a media file
-// @Description Upload a new media file (authenticated users)
+// @Description Upload a new media file (authenticated users) to the configured storage backend. Images get thumb, medium and large derivatives (plus WebP copies unless MEDIA_WEBP_ENABLED is false) and their width, height and dominant colour are recorded.
 // @Tags Media
 // @Accept multipart/form-data
 // @Produce json
@@ -140,9 +136,17 @@ func UploadMedia(c *gin.Context) {
 		return
 	}
 
+	mediaService := services.GetMediaService()
+
 	// Parse multipart form
-	err := c.Request.ParseMultipartForm(32 << 20) // 32 MB max
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, mediaService.MaxUploadSize()+1<<20)
+	err := c.Request.ParseMultipartForm(32 << 20) // 32 MB in memory, the rest spills to disk
 	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, models.ErrorResponse{Error: fmt.Sprintf("File too large. Maximum size is %dMB", mediaService.MaxUploadSize()>>20)})
+			return
+		}
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Failed to parse form data"})
 		return
 	}
@@ -158,9 +162,9 @@ func UploadMedia(c *gin.Context) {
 		}
 	}()
 
-	// Validate file size (10MB max)
-	if fileHeader.Size > 10*1024*1024 {
-		c.JSON(http.StatusRequestEntityTooLarge, models.ErrorResponse{Error: "File too large. Maximum size is 10MB"})
+	// Validate file size
+	if fileHeader.Size > mediaService.MaxUploadSize() {
+		c.JSON(http.StatusRequestEntityTooLarge, models.ErrorResponse{Error: fmt.Sprintf("File too large. Maximum size is %dMB", mediaService.MaxUploadSize()>>20)})
 		return
 	}
 
@@ -171,50 +175,26 @@ func UploadMedia(c *gin.Context) {
 		return
 	}
 
-	// Generate unique filename
-	ext := filepath.Ext(fileHeader.Filename)
-	fileName := uuid.New().String() + ext
-
-	// Create upload directory
-	uploadDir := "uploads/" + time.Now().Format("2006/01")
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to create upload directory"})
-		return
-	}
-
-	// Full file path
-	filePath := filepath.Join(uploadDir, fileName)
-
-	// Save file to disk
-	if err := saveFile(file, filePath); err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to save file"})
-		return
-	}
-
-	// Create media record
-	media := models.Media{
-		FileName:     fileName,
+	// Store the file and its derivatives in the configured storage backend
+	media, err := mediaService.Upload(file, services.MediaUpload{
 		OriginalName: fileHeader.Filename,
 		MimeType:     mimeType,
-		Size:         fileHeader.Size,
-		Path:         filePath,
-		URL:          "/" + filePath, // Relative URL
 		AltText:      c.PostForm("alt_text"),
 		Caption:      c.PostForm("caption"),
 		UploadedBy:   userID.(uint),
-	}
-
-	if err := database.DB.Create(&media).Error; err != nil {
-		// Remove file if database operation fails
-		if removeErr := os.Remove(filePath); removeErr != nil {
-			log.Printf("Warning: Failed to remove file after database error: %v", removeErr)
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrMediaTooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, models.ErrorResponse{Error: err.Error()})
+			return
 		}
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to save media record"})
+		log.Printf("Failed to upload media %s: %v", fileHeader.Filename, err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to save file"})
 		return
 	}
 
 	// Load uploader relation
-	database.DB.Preload("Uploader").First(&media, media.ID)
+	database.DB.Preload("Uploader").First(media, media.ID)
 
 	c.JSON(http.StatusCreated, media)
 }
@@ -302,14 +282,8 @@ func DeleteMedia(c *gin.Context) {
 		return
 	}
 
-	// Delete file from disk
-	if err := os.Remove(media.Path); err != nil {
-		// Log error but continue with database deletion
-		fmt.Printf("Warning: Failed to delete file %s: %v\n", media.Path, err)
-	}
-
-	// Delete from database
-	if err := database.DB.Delete(&media).Error; err != nil {
+	// Delete the record, then the file and its derivatives from storage
+	if err := services.GetMediaService().Delete(&media); err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to delete media record"})
 		return
 	}
@@ -380,18 +354,3 @@ func isAllowedMimeType(mimeType string) bool {
 	}
 	return allowedTypes[mimeType]
 }
-
-func saveFile(file multipart.File, filePath string) error {
-	dst, err := os.Create(filePath)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		if err := dst.Close(); err != nil {
-			log.Printf("Warning: Failed to close file %s: %v", filePath, err)
-		}
-	}()
-
-	_, err = io.Copy(dst, file)
-	return err
-}
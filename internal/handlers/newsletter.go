@@ -1,12 +1,15 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"news/internal/database"
 	"news/internal/models"
+	"news/internal/queue"
+	"news/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -130,6 +133,11 @@ func CreateNewsletter(c *gin.Context) {
 		return
 	}
 
+	if !newsletter.ValidateAudience() {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid audience"})
+		return
+	}
+
 	if err := database.DB.Create(&newsletter).Error; err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Failed to create newsletter"})
 		return
@@ -194,6 +202,19 @@ func UpdateNewsletter(c *gin.Context) {
 	if updateData.ScheduledAt != nil {
 		newsletter.ScheduledAt = updateData.ScheduledAt
 	}
+	if updateData.TemplateKey != "" {
+		newsletter.TemplateKey = updateData.TemplateKey
+	}
+	if updateData.Audience != "" {
+		newsletter.Audience = updateData.Audience
+		newsletter.CategoryID = updateData.CategoryID
+		newsletter.TagID = updateData.TagID
+		newsletter.AuthorID = updateData.AuthorID
+	}
+	if !newsletter.ValidateAudience() {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid audience"})
+		return
+	}
 
 	if err := database.DB.Save(&newsletter).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to update newsletter"})
@@ -235,14 +256,15 @@ func DeleteNewsletter(c *gin.Context) {
 
 // SendNewsletter godoc
 // @Summary Send a newsletter
-// @Description Send a newsletter immediately (admin only)
+// @Description Queue a newsletter for delivery to its audience (admin only). Newsletters with a future scheduled_at are scheduled instead and sent by the worker when due.
 // @Tags Newsletter
 // @Produce json
 // @Security Bearer
 // @Param id path int true "Newsletter ID"
-// @Success 200 {object} models.Newsletter
+// @Success 202 {object} models.Newsletter
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
 // @Router /admin/newsletters/{id}/send [post]
 func SendNewsletter(c *gin.Context) {
 	id := c.Param("id")
@@ -253,26 +275,82 @@ func SendNewsletter(c *gin.Context) {
 		return
 	}
 
-	if newsletter.Status == "sent" {
+	if newsletter.Status == "sent" || newsletter.Status == "sending" {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Newsletter already sent"})
 		return
 	}
 
-	// Update status and sent time
-	now := time.Now()
-	newsletter.Status = "sent"
-	newsletter.SentAt = &now
+	// Honour the schedule: the worker sends the newsletter once scheduled_at passes
+	if newsletter.ScheduledAt != nil && newsletter.ScheduledAt.After(time.Now()) {
+		newsletter.Status = "scheduled"
+		if err := database.DB.Save(&newsletter).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to schedule newsletter"})
+			return
+		}
+		database.DB.Preload("Creator").First(&newsletter, newsletter.ID)
+		c.JSON(http.StatusAccepted, newsletter)
+		return
+	}
 
-	if err := database.DB.Save(&newsletter).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to send newsletter"})
+	queueManager := queue.GetGlobalQueueManager()
+	if queueManager == nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: "Queue manager not available"})
 		return
 	}
 
-	// TODO: Implement actual email sending logic here
-	// This could integrate with an email service like SendGrid, Mailgun, etc.
+	if _, err := queueManager.EnqueueNewsletter(newsletter.ID); err != nil {
+		switch {
+		case errors.Is(err, services.ErrNewsletterNotFound):
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Newsletter not found"})
+		case errors.Is(err, services.ErrNewsletterAlreadySent):
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Newsletter already sent"})
+		default:
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to send newsletter: " + err.Error()})
+		}
+		return
+	}
 
 	// Load creator relation
 	database.DB.Preload("Creator").First(&newsletter, newsletter.ID)
 
-	c.JSON(http.StatusOK, newsletter)
+	c.JSON(http.StatusAccepted, newsletter)
+}
+
+// GetNewsletterDeliveries godoc
+// @Summary Get newsletter deliveries
+// @Description Retrieve the per-recipient delivery status of a newsletter (admin only)
+// @Tags Newsletter
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Newsletter ID"
+// @Param status query string false "Filter by status (pending, queued, sending, retrying, sent, failed, bounced, skipped)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(50)
+// @Success 200 {object} services.NewsletterDeliveryListResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/newsletters/{id}/deliveries [get]
+func GetNewsletterDeliveries(c *gin.Context) {
+	newsletterID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid newsletter ID"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	result, err := services.GetNewsletterDeliveries(uint(newsletterID), c.Query("status"), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
 }
@@ -0,0 +1,468 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"news/internal/database"
+	"news/internal/models"
+	"news/internal/repositories"
+	"news/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RatePageTemplateRequest sets the rating of a page template
+type RatePageTemplateRequest struct {
+	Rating float64 `json:"rating"`
+}
+
+// DuplicatePageTemplateRequest names the copy of a page template
+type DuplicatePageTemplateRequest struct {
+	Name string `json:"name"`
+}
+
+// GetPageTemplates godoc
+// @Summary List page templates
+// @Description Public page templates, most used first unless sort_by is given
+// @Tags Page Templates
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param category query string false "Filter by category"
+// @Param premium query bool false "Filter by premium"
+// @Param min_rating query number false "Minimum rating"
+// @Param search query string false "Search in name and description"
+// @Param sort_by query string false "name, category, usage_count, rating, created_at or updated_at"
+// @Param sort_order query string false "asc or desc"
+// @Success 200 {object} services.PaginatedPageTemplatesResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Router /api/page-templates [get]
+func GetPageTemplates(c *gin.Context) {
+	listPageTemplates(c, true)
+}
+
+// GetAdminPageTemplates godoc
+// @Summary List all page templates
+// @Description Public and private page templates (admin only)
+// @Tags Page Templates
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param category query string false "Filter by category"
+// @Param public query bool false "Filter by visibility"
+// @Param premium query bool false "Filter by premium"
+// @Param creator_id query int false "Filter by creator"
+// @Param min_rating query number false "Minimum rating"
+// @Param search query string false "Search in name and description"
+// @Param sort_by query string false "name, category, usage_count, rating, created_at or updated_at"
+// @Param sort_order query string false "asc or desc"
+// @Success 200 {object} services.PaginatedPageTemplatesResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Router /admin/page-templates [get]
+func GetAdminPageTemplates(c *gin.Context) {
+	listPageTemplates(c, false)
+}
+
+func listPageTemplates(c *gin.Context, publicOnly bool) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	minRating, _ := strconv.ParseFloat(c.Query("min_rating"), 64)
+	filters := repositories.PageTemplateFilters{
+		Page:      page,
+		Limit:     limit,
+		Category:  c.Query("category"),
+		IsPremium: queryBool(c, "premium"),
+		Search:    c.Query("search"),
+		MinRating: minRating,
+		SortBy:    c.Query("sort_by"),
+		SortOrder: c.Query("sort_order"),
+	}
+	if !publicOnly {
+		filters.IsPublic = queryBool(c, "public")
+		if creatorID, err := strconv.ParseUint(c.Query("creator_id"), 10, 32); err == nil {
+			filters.CreatorID = uint(creatorID)
+		}
+	}
+
+	result, err := services.NewPageService(database.DB).ListTemplates(filters, publicOnly)
+	if err != nil {
+		respondPageTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// SearchPageTemplates godoc
+// @Summary Search page templates
+// @Description Searches public page templates by name, description and tags
+// @Tags Page Templates
+// @Produce json
+// @Param q query string true "Search query"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} services.PaginatedPageTemplatesResponse
+// @Router /api/page-templates/search [get]
+func SearchPageTemplates(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	result, err := services.NewPageService(database.DB).SearchTemplates(c.Query("q"), page, limit)
+	if err != nil {
+		respondPageTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetPopularPageTemplates godoc
+// @Summary Popular page templates
+// @Description The most used public page templates
+// @Tags Page Templates
+// @Produce json
+// @Param limit query int false "Number of templates" default(10)
+// @Success 200 {array} models.PageTemplate
+// @Router /api/page-templates/popular [get]
+func GetPopularPageTemplates(c *gin.Context) {
+	templates, err := services.NewPageService(database.DB).GetPopularTemplates(pageTemplateLimit(c))
+	if err != nil {
+		respondPageTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, templates)
+}
+
+// GetFeaturedPageTemplates godoc
+// @Summary Featured page templates
+// @Description The best rated public premium page templates
+// @Tags Page Templates
+// @Produce json
+// @Param limit query int false "Number of templates" default(10)
+// @Success 200 {array} models.PageTemplate
+// @Router /api/page-templates/featured [get]
+func GetFeaturedPageTemplates(c *gin.Context) {
+	templates, err := services.NewPageService(database.DB).GetFeaturedTemplates(pageTemplateLimit(c))
+	if err != nil {
+		respondPageTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, templates)
+}
+
+// GetPageTemplateCategories godoc
+// @Summary Page template categories
+// @Description Categories of public page templates with the number of templates in each
+// @Tags Page Templates
+// @Produce json
+// @Success 200 {array} repositories.TemplateCategory
+// @Router /api/page-templates/categories [get]
+func GetPageTemplateCategories(c *gin.Context) {
+	categories, err := services.NewPageService(database.DB).GetTemplateCategories()
+	if err != nil {
+		respondPageTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, categories)
+}
+
+// GetPageTemplate godoc
+// @Summary Get a page template
+// @Description A public page template with its block structure
+// @Tags Page Templates
+// @Produce json
+// @Param id path int true "Template ID"
+// @Success 200 {object} models.PageTemplate
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/page-templates/{id} [get]
+func GetPageTemplate(c *gin.Context) {
+	getPageTemplate(c, false)
+}
+
+// GetAdminPageTemplate godoc
+// @Summary Get any page template
+// @Description A public or private page template with its block structure (admin only)
+// @Tags Page Templates
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Success 200 {object} models.PageTemplate
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/page-templates/{id} [get]
+func GetAdminPageTemplate(c *gin.Context) {
+	getPageTemplate(c, true)
+}
+
+func getPageTemplate(c *gin.Context, includePrivate bool) {
+	id, ok := parsePageTemplateID(c)
+	if !ok {
+		return
+	}
+
+	template, err := services.NewPageService(database.DB).GetTemplate(id, includePrivate)
+	if err != nil {
+		respondPageTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, template)
+}
+
+// CreatePageTemplate godoc
+// @Summary Create a page template
+// @Description Creates a page template. The block structure is {"blocks": [...]}, each block with block_type, content, settings, styles and, for containers, children (admin only).
+// @Tags Page Templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param template body services.PageTemplateRequest true "Template"
+// @Success 201 {object} models.PageTemplate
+// @Failure 400 {object} models.ErrorResponse
+// @Router /admin/page-templates [post]
+func CreatePageTemplate(c *gin.Context) {
+	var req services.PageTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request format: " + err.Error()})
+		return
+	}
+	req.CreatorID = c.GetUint("user_id")
+
+	template, err := services.NewPageService(database.DB).CreateTemplate(req)
+	if err != nil {
+		respondPageTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, template)
+}
+
+// UpdatePageTemplate godoc
+// @Summary Update a page template
+// @Description Updates the fields given; a new block structure replaces the old one (admin only)
+// @Tags Page Templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Param template body services.UpdatePageTemplateRequest true "Template changes"
+// @Success 200 {object} models.PageTemplate
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/page-templates/{id} [put]
+func UpdatePageTemplate(c *gin.Context) {
+	id, ok := parsePageTemplateID(c)
+	if !ok {
+		return
+	}
+	var req services.UpdatePageTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request format: " + err.Error()})
+		return
+	}
+
+	template, err := services.NewPageService(database.DB).UpdateTemplate(id, req)
+	if err != nil {
+		respondPageTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, template)
+}
+
+// DeletePageTemplate godoc
+// @Summary Delete a page template
+// @Description Deletes a page template; pages created from it are not changed (admin only)
+// @Tags Page Templates
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Success 204 "No Content"
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/page-templates/{id} [delete]
+func DeletePageTemplate(c *gin.Context) {
+	id, ok := parsePageTemplateID(c)
+	if !ok {
+		return
+	}
+
+	if err := services.NewPageService(database.DB).DeleteTemplate(id); err != nil {
+		respondPageTemplateError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// DuplicatePageTemplate godoc
+// @Summary Duplicate a page template
+// @Description Copies a template as a new private template owned by the caller (admin only)
+// @Tags Page Templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Param request body DuplicatePageTemplateRequest false "Name of the copy"
+// @Success 201 {object} models.PageTemplate
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/page-templates/{id}/duplicate [post]
+func DuplicatePageTemplate(c *gin.Context) {
+	id, ok := parsePageTemplateID(c)
+	if !ok {
+		return
+	}
+	var req DuplicatePageTemplateRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request format: " + err.Error()})
+			return
+		}
+	}
+
+	template, err := services.NewPageService(database.DB).DuplicateTemplate(id, c.GetUint("user_id"), req.Name)
+	if err != nil {
+		respondPageTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, template)
+}
+
+// RatePageTemplate godoc
+// @Summary Rate a page template
+// @Description Sets the rating of a page template, from 0 to 5 (admin only)
+// @Tags Page Templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Param request body RatePageTemplateRequest true "Rating"
+// @Success 200 {object} models.PageTemplate
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/page-templates/{id}/rating [put]
+func RatePageTemplate(c *gin.Context) {
+	id, ok := parsePageTemplateID(c)
+	if !ok {
+		return
+	}
+	var req RatePageTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request format: " + err.Error()})
+		return
+	}
+
+	template, err := services.NewPageService(database.DB).RateTemplate(id, req.Rating)
+	if err != nil {
+		respondPageTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, template)
+}
+
+// CreatePageFromTemplate godoc
+// @Summary Create a page from a template
+// @Description Creates a draft page with the template's nested blocks and counts the use of the template. The title defaults to the template name (admin only).
+// @Tags Page Templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Param page body services.CreatePageFromTemplateRequest false "Page details"
+// @Success 201 {object} models.Page
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/pages/from-template/{id} [post]
+func CreatePageFromTemplate(c *gin.Context) {
+	id, ok := parsePageTemplateID(c)
+	if !ok {
+		return
+	}
+	var req services.CreatePageFromTemplateRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request format: " + err.Error()})
+			return
+		}
+	}
+	req.AuthorID = c.GetUint("user_id")
+
+	page, err := services.NewPageService(database.DB).CreatePageFromTemplate(id, req)
+	if err != nil {
+		respondPageTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, page)
+}
+
+// SavePageAsTemplate godoc
+// @Summary Save a page as a template
+// @Description Saves the blocks of a page, hidden ones included, as a new template. The name defaults to the page title (admin only).
+// @Tags Page Templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Page ID"
+// @Param template body services.SavePageAsTemplateRequest false "Template details"
+// @Success 201 {object} models.PageTemplate
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/pages/{id}/save-as-template [post]
+func SavePageAsTemplate(c *gin.Context) {
+	pageID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid page ID"})
+		return
+	}
+	var req services.SavePageAsTemplateRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request format: " + err.Error()})
+			return
+		}
+	}
+	req.CreatorID = c.GetUint("user_id")
+
+	template, err := services.NewPageService(database.DB).SavePageAsTemplate(uint(pageID), req)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Page not found"})
+			return
+		}
+		respondPageTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, template)
+}
+
+func parsePageTemplateID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid template ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func pageTemplateLimit(c *gin.Context) int {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 50 {
+		return 10
+	}
+	return limit
+}
+
+func queryBool(c *gin.Context, key string) *bool {
+	value, err := strconv.ParseBool(c.Query(key))
+	if err != nil {
+		return nil
+	}
+	return &value
+}
+
+func respondPageTemplateError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrValidation):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Page template not found"})
+	default:
+		log.Printf("Page template request failed: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to process page template request"})
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -111,12 +112,14 @@ func GetPageByID(c *gin.Context) {
 
 // GetPageBySlug godoc
 // @Summary Get a page by slug
-// @Description Retrieve a single page by its slug
+// @Description Retrieve a single published page by its slug. With a preview token (preview_token query parameter or X-Preview-Token header) a draft page is returned too.
 // @Tags Pages
 // @Produce json
 // @Param slug path string true "Page slug"
 // @Param include_blocks query bool false "Include content blocks" default(false)
+// @Param preview_token query string false "Draft preview token"
 // @Success 200 {object} models.Page
+// @Failure 401 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /api/pages/slug/{slug} [get]
@@ -135,6 +138,20 @@ func GetPageBySlug(c *gin.Context) {
 		return
 	}
 
+	// Drafts are only shown with a preview token for this page; an invalid token still gets the
+	// published page
+	if token := previewTokenFrom(c); token != "" {
+		if _, err := services.GetPreviewTokenService().Redeem(token, models.PreviewEntityPage, page.ID); err == nil {
+			setPreviewHeaders(c)
+		} else if !errors.Is(err, services.ErrPreviewTokenInvalid) || page.Status != "published" {
+			respondPreviewTokenError(c, err)
+			return
+		}
+	} else if page.Status != "published" {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Page not found"})
+		return
+	}
+
 	c.JSON(http.StatusOK, page)
 }
 
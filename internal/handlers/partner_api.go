@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"news/internal/json"
+	"news/internal/models"
+	"news/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportCSVHeader lists the columns of a CSV article export
+var exportCSVHeader = []string{
+	"id", "title", "slug", "summary", "content", "content_type", "status", "language",
+	"author_id", "featured_image", "meta_title", "meta_description", "is_breaking",
+	"published_at", "created_at", "updated_at", "categories", "tags", "translations", "block_count",
+}
+
+// GetPartnerAnalytics godoc
+// @Summary Get article analytics
+// @Description Returns per-article view and engagement aggregates for a period, most viewed first (requires an API key with the analytics scope)
+// @Tags Partner API
+// @Produce json
+// @Param X-API-Key header string true "API key"
+// @Param from query string false "Start of the period (RFC3339), defaults to 30 days ago"
+// @Param to query string false "End of the period (RFC3339), defaults to now"
+// @Param article_ids query string false "Comma-separated article IDs"
+// @Param limit query int false "Maximum number of articles" default(50)
+// @Success 200 {object} services.ArticleAnalyticsResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/analytics [get]
+func GetPartnerAnalytics(c *gin.Context) {
+	opts := services.ArticleAnalyticsOptions{To: time.Now().UTC()}
+	opts.From = opts.To.AddDate(0, 0, -30)
+
+	var ok bool
+	if opts.From, ok = parseTimeQuery(c, "from", opts.From); !ok {
+		return
+	}
+	if opts.To, ok = parseTimeQuery(c, "to", opts.To); !ok {
+		return
+	}
+	opts.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	if value := c.Query("article_ids"); value != "" {
+		for _, part := range strings.Split(value, ",") {
+			id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 32)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid article_ids, expected comma-separated IDs"})
+				return
+			}
+			opts.ArticleIDs = append(opts.ArticleIDs, uint(id))
+		}
+	}
+
+	report, err := services.GetArticleAnalytics(opts)
+	if err != nil {
+		respondPartnerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ExportArticles godoc
+// @Summary Export articles
+// @Description Streams articles with their categories, tags, translations and content blocks as NDJSON or CSV, ordered by updated_at then id. For incremental sync pass the updated_at and id of the last article received as updated_since and after_id (requires an API key with the export scope).
+// @Tags Partner API
+// @Produce json
+// @Produce text/csv
+// @Param X-API-Key header string true "API key"
+// @Param format query string false "ndjson or csv" default(ndjson)
+// @Param updated_since query string false "Only articles updated at or after this time (RFC3339)"
+// @Param after_id query int false "ID of the last article received, for articles updated exactly at updated_since"
+// @Param status query string false "Filter by status; other statuses than published, or all, need the export:unpublished scope" default(published)
+// @Param language query string false "Filter by language"
+// @Param limit query int false "Maximum number of articles, 0 for all" default(0)
+// @Success 200 {object} services.ExportedArticle "One JSON object per line"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Router /api/export [get]
+func ExportArticles(c *gin.Context) {
+	format := c.DefaultQuery("format", "ndjson")
+	if format != "ndjson" && format != "csv" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid format, expected ndjson or csv"})
+		return
+	}
+
+	// Only published articles are exported unless the key was granted unpublished content
+	status := c.DefaultQuery("status", "published")
+	if status != "published" {
+		scopes, _ := c.Get("api_key_scopes")
+		list, _ := scopes.([]string)
+		if !slices.Contains(list, models.APIKeyScopeExportUnpublished) {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Exporting unpublished articles requires the export:unpublished scope"})
+			return
+		}
+		if status == "all" {
+			status = ""
+		}
+	}
+
+	opts := services.ArticleExportOptions{
+		Status:   status,
+		Language: c.Query("language"),
+	}
+	if value := c.Query("updated_since"); value != "" {
+		since, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid updated_since, expected RFC3339"})
+			return
+		}
+		opts.UpdatedSince = &since
+	}
+	if value := c.Query("after_id"); value != "" {
+		afterID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid after_id"})
+			return
+		}
+		opts.AfterID = uint(afterID)
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid limit"})
+			return
+		}
+		opts.Limit = limit
+	}
+
+	filename := "articles-" + time.Now().UTC().Format("20060102T150405Z")
+	writer := bufio.NewWriter(c.Writer)
+	var emit func(services.ExportedArticle) error
+
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`.csv"`)
+		csvWriter := csv.NewWriter(writer)
+		if err := csvWriter.Write(exportCSVHeader); err != nil {
+			return
+		}
+		emit = func(article services.ExportedArticle) error {
+			if err := csvWriter.Write(exportCSVRow(article)); err != nil {
+				return err
+			}
+			csvWriter.Flush()
+			return flushExport(c, writer)
+		}
+	} else {
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`.ndjson"`)
+		emit = func(article services.ExportedArticle) error {
+			line, err := json.Marshal(article)
+			if err != nil {
+				return err
+			}
+			if _, err := writer.Write(append(line, '\n')); err != nil {
+				return err
+			}
+			return flushExport(c, writer)
+		}
+	}
+
+	c.Status(http.StatusOK)
+	if err := services.ExportArticles(c.Request.Context(), opts, emit); err != nil {
+		// Headers are already sent, so the error can only end the stream early
+		_ = c.Error(err)
+	}
+	_ = writer.Flush()
+}
+
+// BulkArticles godoc
+// @Summary Create or update articles in bulk
+// @Description Applies up to 100 create or update operations. Each operation is validated and applied on its own and gets its own result. Operations follow the editorial workflow for the API key owner's role, and new articles are attributed to the owner (requires an API key with the bulk scope).
+// @Tags Partner API
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "API key"
+// @Param request body BulkArticlesRequest true "Operations"
+// @Success 200 {object} services.BulkArticleResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Router /api/bulk [post]
+func BulkArticles(c *gin.Context) {
+	var req BulkArticlesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// The key acts as its owner, with the owner's role
+	var actor services.WorkflowActor
+	if ownerID, exists := c.Get("api_key_owner_id"); exists {
+		actor.ID, _ = ownerID.(uint)
+	}
+	if role, exists := c.Get("api_key_owner_role"); exists {
+		actor.Role, _ = role.(string)
+	}
+
+	result, err := services.RunBulkArticleOperations(req.Operations, actor)
+	if err != nil {
+		respondPartnerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// BulkArticlesRequest represents a batch of article operations
+type BulkArticlesRequest struct {
+	Operations []services.BulkArticleOperation `json:"operations" binding:"required"`
+}
+
+func exportCSVRow(article services.ExportedArticle) []string {
+	categories := make([]string, 0, len(article.Categories))
+	for _, category := range article.Categories {
+		categories = append(categories, category.Slug)
+	}
+	tags := make([]string, 0, len(article.Tags))
+	for _, tag := range article.Tags {
+		tags = append(tags, tag.Slug)
+	}
+	languages := make([]string, 0, len(article.Translations))
+	for _, translation := range article.Translations {
+		languages = append(languages, translation.Language)
+	}
+	publishedAt := ""
+	if article.PublishedAt != nil {
+		publishedAt = article.PublishedAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	return []string{
+		strconv.FormatUint(uint64(article.ID), 10),
+		article.Title,
+		article.Slug,
+		article.Summary,
+		article.Content,
+		article.ContentType,
+		article.Status,
+		article.Language,
+		strconv.FormatUint(uint64(article.AuthorID), 10),
+		article.FeaturedImage,
+		article.MetaTitle,
+		article.MetaDesc,
+		strconv.FormatBool(article.IsBreaking),
+		publishedAt,
+		article.CreatedAt.UTC().Format(time.RFC3339Nano),
+		article.UpdatedAt.UTC().Format(time.RFC3339Nano),
+		strings.Join(categories, "|"),
+		strings.Join(tags, "|"),
+		strings.Join(languages, "|"),
+		strconv.Itoa(len(article.Blocks)),
+	}
+}
+
+// flushExport pushes buffered export rows to the client once the buffer is mostly full
+func flushExport(c *gin.Context, writer *bufio.Writer) error {
+	if writer.Buffered() < writer.Size()/2 {
+		return nil
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
+
+func parseTimeQuery(c *gin.Context, name string, fallback time.Time) (time.Time, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return fallback, true
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid " + name + ", expected RFC3339"})
+		return time.Time{}, false
+	}
+	return parsed, true
+}
+
+func respondPartnerError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrValidation):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to process request"})
+	}
+}
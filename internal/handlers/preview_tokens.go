@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"news/internal/models"
+	"news/internal/services"
+	"news/internal/workflow"
+
+	"github.com/gin-gonic/gin"
+)
+
+// previewTokenHeader carries a preview token when it is not in the preview_token query parameter
+const previewTokenHeader = "X-Preview-Token"
+
+// IssuePreviewToken godoc
+// @Summary Create a draft preview link
+// @Description Mints a signed token that opens one article or page, in any status, without logging in. It expires after expires_in_hours and, with single_use, works once. Only editors and admins can share other people's content. The token is only returned in this response (staff only).
+// @Tags Preview Tokens
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.PreviewTokenRequest true "Content to preview"
+// @Success 201 {object} services.PreviewTokenSecretResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Router /editorial/preview-tokens [post]
+func IssuePreviewToken(c *gin.Context) {
+	var req services.PreviewTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// Only reviewers may share other people's content
+	ownOnly := !workflow.CanReview(c.GetString("role"))
+
+	token, err := services.GetPreviewTokenService().Issue(req, c.GetUint("user_id"), ownOnly)
+	if err != nil {
+		respondPreviewTokenError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, token)
+}
+
+// GetPreviewTokens godoc
+// @Summary List preview tokens
+// @Description Lists preview tokens that can still be used, newest first; include_inactive adds expired, used and revoked ones (admin only)
+// @Tags Preview Tokens
+// @Produce json
+// @Security BearerAuth
+// @Param entity_type query string false "article or page"
+// @Param entity_id query int false "Article or page ID"
+// @Param include_inactive query bool false "Include expired, used and revoked tokens"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(50)
+// @Success 200 {object} services.PreviewTokenListResponse
+// @Router /admin/preview-tokens [get]
+func GetPreviewTokens(c *gin.Context) {
+	entityID, _ := strconv.ParseUint(c.Query("entity_id"), 10, 32)
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	result, err := services.GetPreviewTokenService().List(c.Query("entity_type"), uint(entityID), c.Query("include_inactive") == "true", page, limit)
+	if err != nil {
+		respondPreviewTokenError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// RevokePreviewToken godoc
+// @Summary Revoke a preview token
+// @Description Stops a preview link from opening its draft (admin only)
+// @Tags Preview Tokens
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Preview token ID"
+// @Success 200 {object} models.PreviewToken
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/preview-tokens/{id}/revoke [post]
+func RevokePreviewToken(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid preview token ID"})
+		return
+	}
+
+	token, err := services.GetPreviewTokenService().Revoke(uint(id))
+	if err != nil {
+		respondPreviewTokenError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, token)
+}
+
+// previewTokenFrom returns the preview token of a request, if any
+func previewTokenFrom(c *gin.Context) string {
+	if token := c.Query("preview_token"); token != "" {
+		return token
+	}
+	return strings.TrimSpace(c.GetHeader(previewTokenHeader))
+}
+
+// setPreviewHeaders keeps a draft out of shared caches and search engines
+func setPreviewHeaders(c *gin.Context) {
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("X-Robots-Tag", "noindex, nofollow")
+}
+
+// respondArticlePreview serves the draft of an article for a preview token. It writes nothing
+// and returns false when the token is invalid, so the caller can serve the published article
+// instead.
+func respondArticlePreview(c *gin.Context, token string, withBlocks bool) bool {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid article ID"})
+		return true
+	}
+
+	article, err := services.GetPreviewTokenService().PreviewArticle(token, uint(id), withBlocks)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPreviewTokenInvalid):
+			return false
+		case errors.Is(err, services.ErrNotFound):
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Article not found"})
+		default:
+			respondPreviewTokenError(c, err)
+		}
+		return true
+	}
+	setPreviewHeaders(c)
+	c.JSON(http.StatusOK, article)
+	return true
+}
+
+// respondArticleNotFound answers a request for an article that is not public. With a preview
+// token that did not open it, the token is reported as invalid.
+func respondArticleNotFound(c *gin.Context, token string) {
+	if token != "" {
+		respondPreviewTokenError(c, services.ErrPreviewTokenInvalid)
+		return
+	}
+	c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Article not found"})
+}
+
+func respondPreviewTokenError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrValidation):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrPreviewTokenInvalid):
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid or expired preview token"})
+	case errors.Is(err, services.ErrPreviewTokenForbidden):
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrPreviewTokenNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Preview token not found"})
+	default:
+		log.Printf("Preview token request failed: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to process preview token request"})
+	}
+}
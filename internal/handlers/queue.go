@@ -361,6 +361,194 @@ func GetQueueHealth(c *gin.Context) {
 	c.JSON(http.StatusOK, health)
 }
 
+// PauseQueue pauses a queue
+// @Summary Pause a queue
+// @Description Stop workers from taking new jobs from a queue. Jobs already running finish normally and new jobs keep accumulating until the queue is resumed.
+// @Tags Queue
+// @Produce json
+// @Param name path string true "Queue name (translations, video_processing, agent_tasks, general, newsletters)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/queue/queues/{name}/pause [post]
+// @Security BearerAuth
+func PauseQueue(c *gin.Context) {
+	setQueuePaused(c, true)
+}
+
+// ResumeQueue resumes a paused queue
+// @Summary Resume a queue
+// @Description Let workers take jobs from a paused queue again
+// @Tags Queue
+// @Produce json
+// @Param name path string true "Queue name (translations, video_processing, agent_tasks, general, newsletters)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/queue/queues/{name}/resume [post]
+// @Security BearerAuth
+func ResumeQueue(c *gin.Context) {
+	setQueuePaused(c, false)
+}
+
+func setQueuePaused(c *gin.Context, paused bool) {
+	queueName := c.Param("name")
+
+	queueManager := queue.GetGlobalQueueManager()
+	if queueManager == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Queue manager not available"})
+		return
+	}
+
+	var err error
+	if paused {
+		err = queueManager.PauseQueue(queueName)
+	} else {
+		err = queueManager.ResumeQueue(queueName)
+	}
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update queue: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"queue_name": queueName,
+		"paused":     paused,
+	})
+}
+
+// GetDeadLetterJobs returns jobs from a queue's dead letter queue
+// @Summary Get dead letter jobs
+// @Description Get the jobs of a queue that failed after exhausting their retries, newest first
+// @Tags Queue
+// @Produce json
+// @Param name path string true "Queue name (translations, video_processing, agent_tasks, general, newsletters)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} QueueJobsResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/queue/queues/{name}/dead-letter [get]
+// @Security BearerAuth
+func GetDeadLetterJobs(c *gin.Context) {
+	queueName := c.Param("name")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	queueManager := queue.GetGlobalQueueManager()
+	if queueManager == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Queue manager not available"})
+		return
+	}
+
+	jobs, total, err := queueManager.GetDeadLetterJobs(queueName, page, limit)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dead letter jobs: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, QueueJobsResponse{
+		Jobs:       jobs,
+		TotalCount: total,
+		Page:       page,
+		Limit:      limit,
+		QueueName:  queueName,
+	})
+}
+
+// DeadLetterRequest selects dead letter jobs; an empty list selects all of them
+type DeadLetterRequest struct {
+	JobIDs []string `json:"job_ids"`
+}
+
+// ReplayDeadLetterJobs re-enqueues dead letter jobs
+// @Summary Replay dead letter jobs
+// @Description Re-enqueue dead letter jobs of a queue with a fresh retry budget. All dead letter jobs are replayed when job_ids is empty.
+// @Tags Queue
+// @Accept json
+// @Produce json
+// @Param name path string true "Queue name (translations, video_processing, agent_tasks, general, newsletters)"
+// @Param request body DeadLetterRequest false "Jobs to replay"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/queue/queues/{name}/dead-letter/replay [post]
+// @Security BearerAuth
+func ReplayDeadLetterJobs(c *gin.Context) {
+	handleDeadLetterJobs(c, "replayed", func(qm *queue.QueueManager, queueName string, jobIDs []string) (int, error) {
+		return qm.ReplayDeadLetterJobs(queueName, jobIDs)
+	})
+}
+
+// PurgeDeadLetterJobs removes dead letter jobs
+// @Summary Purge dead letter jobs
+// @Description Permanently remove dead letter jobs of a queue. All dead letter jobs are removed when job_ids is empty.
+// @Tags Queue
+// @Accept json
+// @Produce json
+// @Param name path string true "Queue name (translations, video_processing, agent_tasks, general, newsletters)"
+// @Param request body DeadLetterRequest false "Jobs to remove"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/queue/queues/{name}/dead-letter/purge [post]
+// @Security BearerAuth
+func PurgeDeadLetterJobs(c *gin.Context) {
+	handleDeadLetterJobs(c, "purged", func(qm *queue.QueueManager, queueName string, jobIDs []string) (int, error) {
+		return qm.PurgeDeadLetterJobs(queueName, jobIDs)
+	})
+}
+
+func handleDeadLetterJobs(c *gin.Context, action string, apply func(qm *queue.QueueManager, queueName string, jobIDs []string) (int, error)) {
+	queueName := c.Param("name")
+
+	var req DeadLetterRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+			return
+		}
+	}
+
+	queueManager := queue.GetGlobalQueueManager()
+	if queueManager == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Queue manager not available"})
+		return
+	}
+
+	count, err := apply(queueManager, queueName, req.JobIDs)
+	if err != nil {
+		if strings.Contains(err.Error(), "queue '") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), action: count})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"queue_name": queueName,
+		action:       count,
+	})
+}
+
 // QueueJobsResponse represents the response for GetQueueJobs
 type QueueJobsResponse struct {
 	Jobs       []queue.JobStatusInfo `json:"jobs"`
@@ -385,5 +573,7 @@ type QueueHealthDetails struct {
 	PendingJobs    int64  `json:"pending_jobs"`
 	ProcessingJobs int64  `json:"processing_jobs"`
 	FailedJobs     int64  `json:"failed_jobs"`
+	DeadJobs       int64  `json:"dead_jobs"`
+	Paused         bool   `json:"paused"`
 	LastProcessed  *int64 `json:"last_processed,omitempty"`
 }
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"news/internal/database"
 	"news/internal/models"
@@ -12,15 +13,16 @@ import (
 
 // GetRecommendedArticles godoc
 // @Summary Get recommended articles
-// @Description Retrieve a list of recommended articles for the authenticated user or based on general popularity if not authenticated.
+// @Description Retrieve recommended articles with the reason each one was recommended. Signed-in users get articles matching the categories, tags and authors they read, bookmark, vote on and follow, excluding articles they already read; anonymous users and users without history get popular articles.
 // @Tags Articles
 // @Produce json
 // @Security BearerAuth
 // @Param limit query int false "Number of recommendations to return" default(10)
-// @Success 200 {array} models.Article
+// @Success 200 {array} services.RecommendedArticle
 // @Failure 401 {object} models.ErrorResponse "If authentication is required and fails"
 // @Failure 500 {object} models.ErrorResponse "If an internal error occurs"
 // @Router /api/articles/recommendations [get]
+// @Router /api/user/recommendations [get]
 func GetRecommendedArticles(c *gin.Context) {
 	limitStr := c.DefaultQuery("limit", "10")
 	limit, err := strconv.Atoi(limitStr)
@@ -33,46 +35,35 @@ func GetRecommendedArticles(c *gin.Context) {
 
 	recommendationService := services.NewRecommendationService(database.DB)
 
-	// Check if user is authenticated
-	userID, exists := c.Get("userID")
-	var articles []models.Article
-
-	if exists && userID != nil {
-		// Get personalized recommendations for authenticated user
-		if uid, ok := userID.(uint); ok {
-			articles, err = recommendationService.GetPersonalizedRecommendations(uid, limit)
-		} else {
-			// Fallback to popular recommendations if userID type assertion fails
-			articles, err = recommendationService.GetPopularRecommendations(limit)
-		}
-	} else {
-		// Get popular recommendations for unauthenticated users
-		articles, err = recommendationService.GetPopularRecommendations(limit)
+	// Anonymous users get the same recommendations as users without history
+	var uid uint
+	if userID, exists := c.Get("user_id"); exists {
+		uid, _ = userID.(uint)
 	}
 
+	recommendations, err := recommendationService.GetPersonalizedRecommendationsWithContext(c.Request.Context(), uid, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to fetch recommended articles"})
 		return
 	}
 
-	c.JSON(http.StatusOK, articles)
+	c.JSON(http.StatusOK, recommendations)
 }
 
 // GetSimilarArticles godoc
 // @Summary Get similar articles
-// @Description Retrieve a list of articles similar to a given article.
+// @Description Retrieve articles similar to a given article, with the reason each one is similar. Articles with a close embedding come first, followed by articles sharing tags, categories or the author.
 // @Tags Articles
 // @Produce json
-// @Param article_id path int true "ID of the article to find similar articles for"
+// @Param id path int true "ID of the article to find similar articles for"
 // @Param limit query int false "Number of similar articles to return" default(5)
-// @Success 200 {array} models.Article
-// @Failure 400 {object} models.ErrorResponse "If article_id is invalid"
+// @Success 200 {array} services.RecommendedArticle
+// @Failure 400 {object} models.ErrorResponse "If the article ID is invalid"
 // @Failure 404 {object} models.ErrorResponse "If the source article is not found"
 // @Failure 500 {object} models.ErrorResponse "If an internal error occurs"
-// @Router /api/articles/{article_id}/similar [get]
+// @Router /api/articles/{id}/similar [get]
 func GetSimilarArticles(c *gin.Context) {
-	articleIDStr := c.Param("article_id")
-	articleID, err := strconv.ParseUint(articleIDStr, 10, 32)
+	articleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid article ID format"})
 		return
@@ -91,7 +82,7 @@ func GetSimilarArticles(c *gin.Context) {
 	articles, err := recommendationService.GetSimilarArticles(uint(articleID), limit)
 
 	if err != nil {
-		if err.Error() == "source article not found: record not found" {
+		if errors.Is(err, services.ErrNotFound) {
 			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Source article not found"})
 			return
 		}
@@ -144,7 +135,7 @@ func GetTrendingArticles(c *gin.Context) {
 // @Failure 500 {object} models.ErrorResponse "If an internal error occurs"
 // @Router /api/user/reading-history [get]
 func GetReadingHistory(c *gin.Context) {
-	userID, exists := c.Get("userID")
+	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Authentication required"})
 		return
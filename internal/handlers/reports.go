@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"news/internal/models"
+	"news/internal/reports"
+	"news/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetReportReasons godoc
+// @Summary List report reasons
+// @Description List the reasons readers can choose when reporting content
+// @Tags Reports
+// @Produce json
+// @Success 200 {array} reports.Reason
+// @Router /api/reports/reasons [get]
+func GetReportReasons(c *gin.Context) {
+	c.JSON(http.StatusOK, reports.Reasons)
+}
+
+// CreateContentReport godoc
+// @Summary Report content
+// @Description Report an article, comment or video comment to the moderators. Each reader can report a piece of content once, and only REPORT_RATE_LIMIT reports per REPORT_RATE_WINDOW_MINUTES. Content whose open reports reach REPORT_AUTO_HIDE_THRESHOLD (weighted by reason) is hidden until a moderator decides.
+// @Tags Reports
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param report body services.ReportRequest true "Report"
+// @Success 201 {object} models.ContentReport
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse "Already reported"
+// @Failure 429 {object} models.ErrorResponse "Too many reports"
+// @Router /api/reports [post]
+func CreateContentReport(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "User not authenticated"})
+		return
+	}
+
+	var req services.ReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request: " + err.Error()})
+		return
+	}
+
+	report, err := services.GetContentReportService().Report(userID.(uint), req)
+	if err != nil {
+		respondReportError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, report)
+}
+
+// GetReportedContent godoc
+// @Summary List reported content
+// @Description List reported content for triage, most reported first, with open reports counted per reason (admin only)
+// @Tags Reports
+// @Produce json
+// @Security Bearer
+// @Param status query string false "open, dismissed or actioned" default(open)
+// @Param target_type query string false "article, comment or video_comment"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(50)
+// @Success 200 {object} models.PaginatedResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/reports [get]
+func GetReportedContent(c *gin.Context) {
+	page, limit := reportPagination(c)
+
+	items, total, err := services.GetContentReportService().List(services.ReportedContentFilter{
+		Status:     c.Query("status"),
+		TargetType: c.Query("target_type"),
+		Page:       page,
+		Limit:      limit,
+	})
+	if err != nil {
+		respondReportError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, reportPage(items, total, page, limit))
+}
+
+// GetReportedContentItem godoc
+// @Summary Get reported content
+// @Description Get reported content with all of its reports and the content itself, including deleted content (admin only)
+// @Tags Reports
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Reported content ID"
+// @Success 200 {object} services.ReportedContentDetail
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/reports/{id} [get]
+func GetReportedContentItem(c *gin.Context) {
+	id, ok := parseReportID(c)
+	if !ok {
+		return
+	}
+
+	detail, err := services.GetContentReportService().Get(id)
+	if err != nil {
+		respondReportError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, detail)
+}
+
+// ResolveReportedContent godoc
+// @Summary Act on reported content
+// @Description Resolve all open reports about a piece of content. dismiss keeps the content and restores it if reports hid it; hide hides it; delete deletes it; suspend suspends its author, hides it and closes the author's WebSocket connections. Every action is recorded in the audit log (admin only).
+// @Tags Reports
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Reported content ID"
+// @Param action body services.ReportActionRequest true "Action"
+// @Success 200 {object} models.ReportedContent
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/reports/{id}/action [post]
+func ResolveReportedContent(c *gin.Context) {
+	id, ok := parseReportID(c)
+	if !ok {
+		return
+	}
+
+	var req services.ReportActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request: " + err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	actorID, _ := userID.(uint)
+
+	content, err := services.GetContentReportService().Resolve(id, req, services.AuditInfo{
+		ActorID:   actorID,
+		IP:        c.ClientIP(),
+		UserAgent: c.GetHeader("User-Agent"),
+	})
+	if err != nil {
+		respondReportError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, content)
+}
+
+// GetReportAuditLog godoc
+// @Summary Get the report audit log
+// @Description List the moderation decisions on reported content and automatic hides, newest first. user_id is the moderator, or 0 for automatic hides (admin only).
+// @Tags Reports
+// @Produce json
+// @Security Bearer
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(50)
+// @Success 200 {object} models.PaginatedResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/reports/audit [get]
+func GetReportAuditLog(c *gin.Context) {
+	page, limit := reportPagination(c)
+
+	events, total, err := services.GetContentReportService().AuditLog(page, limit)
+	if err != nil {
+		respondReportError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, reportPage(events, total, page, limit))
+}
+
+func reportPagination(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+	return page, limit
+}
+
+func reportPage(data interface{}, total int64, page, limit int) models.PaginatedResponse {
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	return models.PaginatedResponse{
+		Data:       data,
+		TotalItems: int(total),
+		Page:       page,
+		Limit:      limit,
+		TotalPages: totalPages,
+		HasNext:    page < totalPages,
+		HasPrev:    page > 1,
+	}
+}
+
+func parseReportID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid report ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondReportError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrValidation):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Content not found"})
+	case errors.Is(err, services.ErrReportNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Report not found"})
+	case errors.Is(err, services.ErrReportDuplicate):
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "You already reported this content"})
+	case errors.Is(err, services.ErrReportRateLimited):
+		c.JSON(http.StatusTooManyRequests, models.ErrorResponse{Error: "Too many reports, please try again later"})
+	default:
+		log.Printf("Report request failed: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to process report"})
+	}
+}
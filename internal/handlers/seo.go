@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"news/internal/models"
+	"news/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSEOMetadata godoc
+// @Summary Get SEO metadata and structured data
+// @Description Fully assembled head metadata of a published article, page, video or live stream: title, description, canonical URL, robots, Open Graph and Twitter cards, hreflang alternates and JSON-LD (NewsArticle, WebPage, VideoObject or LiveBlogPosting, with a BreadcrumbList). With lang, a published translation is described when there is one.
+// @Tags SEO
+// @Produce json
+// @Param entity_type path string true "article, page, video or live"
+// @Param id path int true "Entity ID"
+// @Param lang query string false "Language"
+// @Success 200 {object} seo.Metadata
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/seo/{entity_type}/{id} [get]
+func GetSEOMetadata(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid ID"})
+		return
+	}
+
+	meta, err := services.GetSEOService().Metadata(c.Param("entity_type"), uint(id), c.Query("lang"))
+	if err != nil {
+		respondSEOError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, meta)
+}
+
+func respondSEOError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrValidation):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Content not found"})
+	default:
+		log.Printf("Failed to build SEO metadata: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to build SEO metadata"})
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"news/internal/feeds"
+	"news/internal/models"
+	"news/internal/services"
+	"news/internal/sitemap"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSitemapIndex godoc
+// @Summary Sitemap index
+// @Description Sitemap index listing the Google News sitemap and every sitemap shard of articles, categories, tags and pages
+// @Tags Sitemaps
+// @Produce xml
+// @Success 200 {string} string "Sitemap index"
+// @Success 304 "Not Modified"
+// @Router /sitemap.xml [get]
+func GetSitemapIndex(c *gin.Context) {
+	serveSitemap(c, sitemap.IndexFile)
+}
+
+// GetSitemapFile godoc
+// @Summary Sitemap file
+// @Description A sitemap shard such as articles-1.xml, or news.xml for the Google News sitemap of the articles published in the last 48 hours. Translations are listed with hreflang alternates.
+// @Tags Sitemaps
+// @Produce xml
+// @Param file path string true "Sitemap file name"
+// @Success 200 {string} string "Sitemap"
+// @Success 304 "Not Modified"
+// @Failure 404 {object} models.ErrorResponse
+// @Router /sitemaps/{file} [get]
+func GetSitemapFile(c *gin.Context) {
+	serveSitemap(c, c.Param("file"))
+}
+
+// RegenerateSitemaps godoc
+// @Summary Regenerate sitemaps
+// @Description Rebuild every sitemap now instead of waiting for the worker
+// @Tags Sitemaps
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/sitemaps/regenerate [post]
+func RegenerateSitemaps(c *gin.Context) {
+	files, err := services.GetSitemapService().Refresh("", 0)
+	if err != nil {
+		log.Printf("Failed to regenerate sitemaps: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to regenerate sitemaps"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"files": files, "generated_at": time.Now()})
+}
+
+func serveSitemap(c *gin.Context, name string) {
+	body, err := services.GetSitemapService().Get(name)
+	if err != nil {
+		if errors.Is(err, services.ErrSitemapNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Sitemap not found"})
+			return
+		}
+		log.Printf("Failed to load sitemap %s: %v", name, err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to load sitemap"})
+		return
+	}
+
+	etag := feeds.ETag(body)
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(services.GetSitemapService().CacheTTL().Seconds())))
+	if feeds.NotModified(c.GetHeader("If-None-Match"), "", etag, time.Time{}) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", body)
+}
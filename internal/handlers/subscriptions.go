@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"news/internal/database"
+	"news/internal/models"
+	"news/internal/queue"
+	"news/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// subscribeAcceptedMessage is returned for every public subscribe request, so the endpoint
+// does not reveal whether an address is already subscribed
+const subscribeAcceptedMessage = "If the address is valid, a confirmation email is on its way"
+
+// CreateSubscription godoc
+// @Summary Subscribe by email
+// @Description Subscribe an email address to the newsletter, notifications or a category, tag or author. The subscription becomes active once the address is confirmed through the emailed link.
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Param subscription body services.SubscriptionRequest true "Subscription"
+// @Success 202 {object} map[string]interface{}
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 429 {object} map[string]interface{} "Too many subscription requests"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/subscriptions [post]
+func CreateSubscription(c *gin.Context) {
+	var req services.SubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request format: " + err.Error()})
+		return
+	}
+
+	result, err := services.Subscribe(req, nil, false)
+	if err != nil {
+		respondSubscriptionError(c, err)
+		return
+	}
+
+	enqueueSubscriptionConfirmation(result)
+	c.JSON(http.StatusAccepted, gin.H{"message": subscribeAcceptedMessage})
+}
+
+// ConfirmSubscription godoc
+// @Summary Confirm a subscription
+// @Description Activate a subscription through the double opt-in link sent by email
+// @Tags Subscriptions
+// @Produce json
+// @Param token path string true "Subscription token"
+// @Success 200 {object} models.Subscription
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/subscriptions/confirm/{token} [get]
+func ConfirmSubscription(c *gin.Context) {
+	subscription, err := services.ConfirmSubscription(c.Param("token"))
+	if err != nil {
+		respondSubscriptionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// GetSubscriptionByToken godoc
+// @Summary Get a subscription by token
+// @Description Show what an unsubscribe link refers to before unsubscribing. Does not change the subscription.
+// @Tags Subscriptions
+// @Produce json
+// @Param token path string true "Subscription token"
+// @Success 200 {object} models.Subscription
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/subscriptions/unsubscribe/{token} [get]
+func GetSubscriptionByToken(c *gin.Context) {
+	subscription, err := services.GetSubscriptionByToken(c.Param("token"))
+	if err != nil {
+		respondSubscriptionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// Unsubscribe godoc
+// @Summary Unsubscribe
+// @Description Cancel a subscription. Supports RFC 8058 one-click unsubscribe requests sent by mail clients.
+// @Tags Subscriptions
+// @Produce json
+// @Param token path string true "Subscription token"
+// @Success 200 {object} models.Subscription
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/subscriptions/unsubscribe/{token} [post]
+func Unsubscribe(c *gin.Context) {
+	subscription, err := services.UnsubscribeByToken(c.Param("token"))
+	if err != nil {
+		respondSubscriptionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// GetUserSubscriptions godoc
+// @Summary Get my subscriptions
+// @Description List the subscriptions of the authenticated user, including those made with the account's email before signing in
+// @Tags Subscriptions
+// @Produce json
+// @Security Bearer
+// @Success 200 {array} models.Subscription
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/user/subscriptions [get]
+func GetUserSubscriptions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "User not authenticated"})
+		return
+	}
+
+	subscriptions, err := services.GetUserSubscriptions(userID.(uint))
+	if err != nil {
+		respondSubscriptionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+// CreateUserSubscription godoc
+// @Summary Subscribe as the signed-in user
+// @Description Subscribe the authenticated user. The account email is used when none is given; verified accounts subscribing with their own email skip the confirmation email.
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param subscription body services.SubscriptionRequest true "Subscription"
+// @Success 201 {object} models.Subscription
+// @Success 202 {object} models.Subscription
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/user/subscriptions [post]
+func CreateUserSubscription(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "User not authenticated"})
+		return
+	}
+
+	var req services.SubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request format: " + err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.Select("id", "email", "is_verified").First(&user, userID.(uint)).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "User not found"})
+		return
+	}
+
+	if req.Email == "" {
+		req.Email = user.Email
+	}
+	ownAddress := strings.EqualFold(strings.TrimSpace(req.Email), user.Email)
+	confirmed := user.IsVerified && ownAddress
+
+	// Only the account's own address is attached to the account; any other address is handled
+	// like an anonymous signup and has to be confirmed by its owner
+	var owner *uint
+	if ownAddress {
+		owner = &user.ID
+	}
+	result, err := services.Subscribe(req, owner, confirmed)
+	if err != nil {
+		respondSubscriptionError(c, err)
+		return
+	}
+
+	status := http.StatusCreated
+	if !result.Subscription.IsActive {
+		status = http.StatusAccepted
+		enqueueSubscriptionConfirmation(result)
+	}
+	c.JSON(status, result.Subscription)
+}
+
+// UpdateUserSubscription godoc
+// @Summary Update my subscription
+// @Description Pause, resume or change the language of a subscription of the authenticated user
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Subscription ID"
+// @Param subscription body map[string]interface{} true "Fields to update (is_active, language)"
+// @Success 200 {object} models.Subscription
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/user/subscriptions/{id} [put]
+func UpdateUserSubscription(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid subscription ID"})
+		return
+	}
+
+	var input struct {
+		IsActive *bool   `json:"is_active"`
+		Language *string `json:"language"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request format: " + err.Error()})
+		return
+	}
+
+	subscription, err := services.UpdateUserSubscription(userID.(uint), uint(id), input.IsActive, input.Language)
+	if err != nil {
+		respondSubscriptionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// DeleteUserSubscription godoc
+// @Summary Delete my subscription
+// @Description Delete a subscription of the authenticated user
+// @Tags Subscriptions
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Subscription ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/user/subscriptions/{id} [delete]
+func DeleteUserSubscription(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid subscription ID"})
+		return
+	}
+
+	if err := services.DeleteUserSubscription(userID.(uint), uint(id)); err != nil {
+		respondSubscriptionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Subscription deleted successfully"})
+}
+
+// enqueueSubscriptionConfirmation queues the double opt-in email when one is needed. Failures
+// are only logged; the subscriber can request the email again by subscribing again.
+func enqueueSubscriptionConfirmation(result *services.SubscribeResult) {
+	if !result.SendConfirmation {
+		return
+	}
+
+	queueManager := queue.GetGlobalQueueManager()
+	if queueManager == nil {
+		log.Printf("Queue manager not available, confirmation for subscription %d not sent", result.Subscription.ID)
+		return
+	}
+
+	if err := queueManager.EnqueueSubscriptionConfirmation(result.Subscription.ID); err != nil {
+		log.Printf("Failed to enqueue confirmation for subscription %d: %v", result.Subscription.ID, err)
+	}
+}
+
+func respondSubscriptionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrSubscriptionNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Subscription not found"})
+	case errors.Is(err, services.ErrValidation):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrSubscriptionUnconfirmed):
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "Confirm the subscription from the email sent to its address first"})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to process subscription"})
+	}
+}
@@ -88,11 +88,11 @@ func (h *TranslationHandler) GetArticleTranslation(c *gin.Context) {
 
 // GetSEOTranslation retrieves SEO settings with translations
 // @Summary Get SEO Translation
-// @Description Get SEO settings with localized content
+// @Description Get SEO settings with localized content, assembled by the same service as /api/seo/{entity_type}/{id}. The schema holds the generated JSON-LD.
 // @Tags translation
 // @Accept json
 // @Produce json
-// @Param type path string true "Entity type (page, article)"
+// @Param type path string true "Entity type (article, page, video, live)"
 // @Param id path string true "Entity ID"
 // @Param language query string false "Language code" default(en)
 // @Success 200 {object} models.LocalizedSEOSettings
@@ -108,9 +108,9 @@ func (h *TranslationHandler) GetSEOTranslation(c *gin.Context) {
 		return
 	}
 
-	seoSettings, err := h.translationCache.GetSEOTranslation(entityType, uint(id), language)
+	seoSettings, err := services.GetSEOService().LocalizedSettings(entityType, uint(id), language)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "SEO settings not found"})
+		respondSEOError(c, err)
 		return
 	}
 
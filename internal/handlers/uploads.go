@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"news/internal/models"
+	"news/internal/queue"
+	"news/internal/queue/processors"
+	"news/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Resumable uploads use the offset and checksum headers of the tus protocol
+const (
+	uploadOffsetHeader   = "Upload-Offset"
+	uploadLengthHeader   = "Upload-Length"
+	uploadChecksumHeader = "Upload-Checksum"
+	uploadExpiresHeader  = "Upload-Expires"
+)
+
+// CreateUpload godoc
+// @Summary Start a resumable upload
+// @Description Start a chunked upload of a media file or video. Send the file with PATCH /api/uploads/{id} in chunks of chunk_size bytes, each starting at the current offset. When the last chunk arrives the file is assembled in storage (S3 multipart when S3 is configured), its SHA-256 is checked against checksum if one was given, and the Media or Video record is created; videos are queued for processing. Idle uploads expire after UPLOAD_SESSION_TTL_HOURS.
+// @Tags Media
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body services.CreateUploadRequest true "File to upload"
+// @Success 201 {object} models.UploadSession
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /api/uploads [post]
+func CreateUpload(c *gin.Context) {
+	userID, service, ok := uploadContext(c)
+	if !ok {
+		return
+	}
+
+	var req services.CreateUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request: " + err.Error()})
+		return
+	}
+	if req.Kind == models.UploadKindMedia && !isAllowedMimeType(req.MimeType) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "File type not allowed"})
+		return
+	}
+
+	session, err := service.Create(userID, req)
+	if err != nil {
+		writeUploadError(c, err)
+		return
+	}
+
+	setUploadHeaders(c, session)
+	c.JSON(http.StatusCreated, session)
+}
+
+// GetUpload godoc
+// @Summary Get a resumable upload
+// @Description Get the state of a resumable upload. The offset is where the next chunk must start; it is also returned in the Upload-Offset header, and HEAD returns only the headers.
+// @Tags Media
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Upload ID"
+// @Success 200 {object} models.UploadSession
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/uploads/{id} [get]
+func GetUpload(c *gin.Context) {
+	userID, service, ok := uploadContext(c)
+	if !ok {
+		return
+	}
+
+	session, err := service.Get(c.Param("id"), userID)
+	if err != nil {
+		writeUploadError(c, err)
+		return
+	}
+
+	setUploadHeaders(c, session)
+	c.Header("Cache-Control", "no-store")
+	if c.Request.Method == http.MethodHead {
+		c.Status(http.StatusOK)
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+// WriteUploadChunk godoc
+// @Summary Upload a chunk
+// @Description Append a chunk to a resumable upload. The body is the raw chunk and the Upload-Offset header must equal the current offset. Every chunk but the last must be chunk_size bytes. An optional Upload-Checksum header ("sha256 <base64 digest>") is verified before the chunk is stored. The response carries the new offset; after the last chunk the upload is completed and media_id or video_id is set. If completion fails after the last chunk was stored, an empty request at the upload size retries it, like POST /api/uploads/{id}/complete.
+// @Tags Media
+// @Accept application/offset+octet-stream
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Upload ID"
+// @Param Upload-Offset header int true "Offset of the chunk"
+// @Param Upload-Checksum header string false "sha256 <base64 digest of the chunk>"
+// @Success 200 {object} models.UploadSession
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse "Offset does not match"
+// @Failure 410 {object} models.ErrorResponse "Upload completed, aborted or expired"
+// @Failure 422 {object} models.ErrorResponse "Checksum mismatch"
+// @Router /api/uploads/{id} [patch]
+func WriteUploadChunk(c *gin.Context) {
+	userID, service, ok := uploadContext(c)
+	if !ok {
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader(uploadOffsetHeader), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Upload-Offset header is required"})
+		return
+	}
+
+	session, completed, err := service.WriteChunk(c.Param("id"), userID, offset, c.Request.Body, c.GetHeader(uploadChecksumHeader))
+	if err != nil {
+		writeUploadError(c, err)
+		return
+	}
+
+	if completed && session.VideoID != nil {
+		enqueueVideoWorkflow(*session.VideoID)
+	}
+
+	setUploadHeaders(c, session)
+	c.JSON(http.StatusOK, session)
+}
+
+// CompleteUpload godoc
+// @Summary Complete a resumable upload
+// @Description Finish an upload whose chunks have all been written, retrying a completion that failed after the last chunk was stored. Completing an upload that is already completed returns it unchanged.
+// @Tags Media
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Upload ID"
+// @Success 200 {object} models.UploadSession
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse "Chunks are missing"
+// @Failure 410 {object} models.ErrorResponse "Upload aborted or expired"
+// @Failure 422 {object} models.ErrorResponse "Checksum mismatch"
+// @Router /api/uploads/{id}/complete [post]
+func CompleteUpload(c *gin.Context) {
+	userID, service, ok := uploadContext(c)
+	if !ok {
+		return
+	}
+
+	session, completed, err := service.Complete(c.Param("id"), userID)
+	if err != nil {
+		writeUploadError(c, err)
+		return
+	}
+
+	if completed && session.VideoID != nil {
+		enqueueVideoWorkflow(*session.VideoID)
+	}
+
+	setUploadHeaders(c, session)
+	c.JSON(http.StatusOK, session)
+}
+
+// AbortUpload godoc
+// @Summary Abort a resumable upload
+// @Description Cancel an active upload and discard the chunks stored so far
+// @Tags Media
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Upload ID"
+// @Success 200 {object} models.UploadSession
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 410 {object} models.ErrorResponse
+// @Router /api/uploads/{id} [delete]
+func AbortUpload(c *gin.Context) {
+	userID, service, ok := uploadContext(c)
+	if !ok {
+		return
+	}
+
+	session, err := service.Abort(c.Param("id"), userID)
+	if err != nil {
+		writeUploadError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+// uploadContext returns the authenticated user and the upload service, writing an error
+// response if either is missing
+func uploadContext(c *gin.Context) (uint, *services.UploadService, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "User not authenticated"})
+		return 0, nil, false
+	}
+	service := services.GetUploadService()
+	if service == nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{Error: "Resumable uploads are not available"})
+		return 0, nil, false
+	}
+	return userID.(uint), service, true
+}
+
+func setUploadHeaders(c *gin.Context, session *models.UploadSession) {
+	c.Header(uploadOffsetHeader, strconv.FormatInt(session.Offset, 10))
+	c.Header(uploadLengthHeader, strconv.FormatInt(session.Size, 10))
+	if session.Status == models.UploadStatusUploading {
+		c.Header(uploadExpiresHeader, session.ExpiresAt.UTC().Format(http.TimeFormat))
+	}
+}
+
+func writeUploadError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrUploadNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Upload not found"})
+	case errors.Is(err, services.ErrUploadClosed):
+		c.JSON(http.StatusGone, models.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrUploadOffset):
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrUploadChecksum):
+		c.JSON(http.StatusUnprocessableEntity, models.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrMediaTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, models.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrValidation):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+	default:
+		log.Printf("Resumable upload failed: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Upload failed"})
+	}
+}
+
+// enqueueVideoWorkflow queues the complete processing pipeline of an uploaded video. Without a
+// queue manager the video stays pending until processing is requested.
+func enqueueVideoWorkflow(videoID uint) {
+	queueManager := queue.GetGlobalQueueManager()
+	if queueManager == nil {
+		log.Printf("Warning: Queue manager not available, video %d was not queued for processing", videoID)
+		return
+	}
+	job := processors.CreateCompleteVideoWorkflowJob(videoID, queue.PriorityNormal)
+	if err := queueManager.EnqueueJob("video_processing", job); err != nil {
+		log.Printf("Warning: Failed to queue processing of video %d: %v", videoID, err)
+	}
+}
@@ -108,7 +108,7 @@ func (h *VideoHandler) CreateVideo(c *gin.Context) {
 
 // GetVideo retrieves a single video with details
 // @Summary Get single video
-// @Description Retrieve a single video by ID with full details
+// @Description Retrieve a single video by ID with full details. Once processed, stream_url is the HLS master playlist and renditions lists each step of the ladder.
 // @Tags Videos
 // @Produce json
 // @Param id path int true "Video ID"
@@ -120,7 +120,10 @@ func (h *VideoHandler) GetVideo(c *gin.Context) {
 	id := c.Param("id")
 
 	var video models.Video
-	if err := h.db.Preload("User").Preload("Category").First(&video, id).Error; err != nil {
+	err := h.db.Preload("User").Preload("Category").
+		Preload("Renditions", func(db *gorm.DB) *gorm.DB { return db.Order("height ASC") }).
+		First(&video, id).Error
+	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Video not found"})
 		} else {
@@ -417,7 +420,7 @@ func (h *VideoHandler) VoteVideo(c *gin.Context) {
 
 // GetVideoComments retrieves comments for a video
 // @Summary Get video comments
-// @Description Retrieve comments for a specific video
+// @Description Retrieve the active comments of a specific video; hidden, deleted and flagged comments are left out
 // @Tags Videos
 // @Produce json
 // @Param id path int true "Video ID"
@@ -452,7 +455,8 @@ func (h *VideoHandler) GetVideoComments(c *gin.Context) {
 	var comments []models.VideoComment
 	var total int64
 
-	query := h.db.Preload("User").Where("video_id = ?", videoID)
+	// Comments hidden by reports or moderators are not listed publicly
+	query := h.db.Preload("User").Where("video_id = ? AND status = ?", videoID, "active")
 
 	// Get total count
 	if err := query.Model(&models.VideoComment{}).Count(&total).Error; err != nil {
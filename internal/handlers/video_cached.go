@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"fmt"
 	"log"
 	"net/http"
 	"strconv"
@@ -197,19 +196,20 @@ func (h *VideoHandlerCached) VoteVideoCached(c *gin.Context) {
 	}
 
 	// 📡 REAL-TIME NOTIFICATION: Publish vote notification via Redis pub/sub
-	// Using video-specific notification for video votes
+	// Delivered to the subscribers of the video's topic
 	videoNotification := pubsub.NotificationMessage{
 		Type: "video_vote",
 		Data: map[string]interface{}{
 			"video_id":  videoID,
 			"user_id":   userID,
 			"vote_type": request.Type,
+			"likes":     likes,
+			"dislikes":  dislikes,
 			"timestamp": time.Now(),
 		},
 	}
 
-	videoChannel := fmt.Sprintf("video_votes:%d", videoID)
-	if err := pubsub.PublishNotification(videoChannel, videoNotification); err != nil {
+	if err := pubsub.PublishTopic(pubsub.VideoTopic(uint(videoID)), videoNotification); err != nil {
 		log.Printf("❌ Failed to publish video vote notification: %v", err)
 		// Don't fail the request, just log the error
 	}
@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"news/internal/models"
+	"news/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookEventsResponse lists the events webhook endpoints can subscribe to
+type WebhookEventsResponse struct {
+	Events []string `json:"events"`
+}
+
+// GetWebhookEvents godoc
+// @Summary List webhook events
+// @Description Returns the events webhook endpoints can subscribe to (admin only)
+// @Tags Webhooks
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} WebhookEventsResponse
+// @Router /admin/webhooks/events [get]
+func GetWebhookEvents(c *gin.Context) {
+	c.JSON(http.StatusOK, WebhookEventsResponse{Events: models.WebhookEvents})
+}
+
+// GetWebhookEndpoints godoc
+// @Summary List webhook endpoints
+// @Description Returns all registered webhook endpoints (admin only)
+// @Tags Webhooks
+// @Produce json
+// @Security Bearer
+// @Success 200 {array} models.WebhookEndpoint
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/webhooks [get]
+func GetWebhookEndpoints(c *gin.Context) {
+	endpoints, err := services.GetWebhookEndpoints()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve webhook endpoints"})
+		return
+	}
+
+	c.JSON(http.StatusOK, endpoints)
+}
+
+// GetWebhookEndpoint godoc
+// @Summary Get a webhook endpoint
+// @Description Returns a webhook endpoint by ID (admin only)
+// @Tags Webhooks
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Webhook endpoint ID"
+// @Success 200 {object} models.WebhookEndpoint
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/webhooks/{id} [get]
+func GetWebhookEndpoint(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	endpoint, err := services.GetWebhookEndpoint(id)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, endpoint)
+}
+
+// CreateWebhookEndpoint godoc
+// @Summary Register a webhook endpoint
+// @Description Registers a URL to receive the selected events. The signing secret is only returned in this response (admin only).
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param endpoint body services.WebhookEndpointRequest true "Webhook endpoint"
+// @Success 201 {object} services.WebhookEndpointSecretResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/webhooks [post]
+func CreateWebhookEndpoint(c *gin.Context) {
+	var req services.WebhookEndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	createdBy, _ := userID.(uint)
+
+	endpoint, err := services.CreateWebhookEndpoint(req, createdBy)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, endpoint)
+}
+
+// UpdateWebhookEndpoint godoc
+// @Summary Update a webhook endpoint
+// @Description Changes the URL, events or state of a webhook endpoint (admin only)
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Webhook endpoint ID"
+// @Param endpoint body services.WebhookEndpointRequest true "Webhook endpoint"
+// @Success 200 {object} models.WebhookEndpoint
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/webhooks/{id} [put]
+func UpdateWebhookEndpoint(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	var req services.WebhookEndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	endpoint, err := services.UpdateWebhookEndpoint(id, req)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, endpoint)
+}
+
+// DeleteWebhookEndpoint godoc
+// @Summary Delete a webhook endpoint
+// @Description Removes a webhook endpoint; its delivery log is kept (admin only)
+// @Tags Webhooks
+// @Security Bearer
+// @Param id path int true "Webhook endpoint ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/webhooks/{id} [delete]
+func DeleteWebhookEndpoint(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	if err := services.DeleteWebhookEndpoint(id); err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RotateWebhookSecret godoc
+// @Summary Rotate a webhook signing secret
+// @Description Replaces the signing secret of a webhook endpoint and returns the new secret (admin only)
+// @Tags Webhooks
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Webhook endpoint ID"
+// @Success 200 {object} services.WebhookEndpointSecretResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/webhooks/{id}/rotate-secret [post]
+func RotateWebhookSecret(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	endpoint, err := services.RotateWebhookSecret(id)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, endpoint)
+}
+
+// GetWebhookDeliveries godoc
+// @Summary Get webhook deliveries
+// @Description Returns the delivery log of a webhook endpoint with response codes, newest first (admin only)
+// @Tags Webhooks
+// @Produce json
+// @Security Bearer
+// @Param id path int true "Webhook endpoint ID"
+// @Param status query string false "Filter by status (pending, queued, retrying, delivered, failed)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(50)
+// @Success 200 {object} services.WebhookDeliveryListResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/webhooks/{id}/deliveries [get]
+func GetWebhookDeliveries(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	result, err := services.GetWebhookDeliveries(id, c.Query("status"), page, limit)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// RedeliverWebhook godoc
+// @Summary Redeliver a webhook
+// @Description Sends the payload of an earlier delivery again as a new delivery (admin only)
+// @Tags Webhooks
+// @Produce json
+// @Security Bearer
+// @Param delivery_id path int true "Webhook delivery ID"
+// @Success 202 {object} models.WebhookDelivery
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/webhooks/deliveries/{delivery_id}/redeliver [post]
+func RedeliverWebhook(c *gin.Context) {
+	deliveryID, err := strconv.ParseUint(c.Param("delivery_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid delivery ID"})
+		return
+	}
+
+	delivery, err := services.RedeliverWebhook(uint(deliveryID))
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+func parseWebhookID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid webhook endpoint ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondWebhookError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrValidation):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrWebhookEndpointNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Webhook endpoint not found"})
+	case errors.Is(err, services.ErrWebhookDeliveryNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Webhook delivery not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to process webhook request"})
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"news/internal/models"
 	"news/internal/pubsub"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -27,63 +28,88 @@ var upgrader = websocket.Upgrader{
 	WriteBufferSize: 1024,
 }
 
+// maxClientFrameSize bounds the frames clients can send; they only manage subscriptions
+const maxClientFrameSize = 4096
+
 // @Summary WebSocket connection for real-time notifications
-// @Description Establishes a WebSocket connection for receiving real-time notifications
+// @Description Establishes a WebSocket connection for receiving real-time notifications. Without a token the connection is anonymous and read-only: it only receives the topics it subscribes to. Clients manage topics by sending {"action":"subscribe"|"unsubscribe","topic":"article:42"} frames; supported topics are article:{id}, live:{id}, video:{id}, category:{slug} and breaking_news. A user can hold several connections at once.
 // @Tags WebSocket
 // @Security BearerAuth
-// @Param user_id query int true "User ID for connection"
+// @Param token query string false "JWT access token; omit for an anonymous connection"
+// @Param user_id query int false "User ID for connection, must match the token"
+// @Param topics query string false "Comma-separated topics to subscribe to on connect"
+// @Param lang query string false "Language for localized notifications"
+// @Param jobs query bool false "Also stream queue job progress (admins only)"
 // @Success 101 {string} string "Switching Protocols"
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /api/ws/notifications [get]
 func HandleWebSocketNotifications(c *gin.Context) {
-	// Handle WebSocket authentication manually since middleware doesn't work with query params
-	token := c.Query("token")
-	if token == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "token required"})
-		return
-	}
-
-	// Validate the token manually
-	tokenManager := auth.NewTokenManager(
-		[]byte(middleware.GetJWTSecret()),
-		24*time.Hour,
-		7*24*time.Hour,
-		cache.GetRedisClient(),
-	)
-
-	claims, err := tokenManager.ValidateToken(token)
-	if err != nil {
-		log.Printf("❌ WebSocket token validation failed: %v", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+	hub := pubsub.GetNotificationHub()
+	if hub == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "notifications are not available"})
 		return
 	}
 
-	// Get user ID from database using the username from token
+	// Handle WebSocket authentication manually since middleware doesn't work with query params.
+	// A missing token makes an anonymous connection; an invalid one is still rejected.
 	var user models.User
-	if err := database.DB.Where("username = ?", claims.Username).First(&user).Error; err != nil {
-		log.Printf("❌ User not found: %v", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
-		return
-	}
+	if token := c.Query("token"); token != "" {
+		tokenManager := auth.NewTokenManager(
+			[]byte(middleware.GetJWTSecret()),
+			24*time.Hour,
+			7*24*time.Hour,
+			cache.GetRedisClient(),
+		)
+
+		claims, err := tokenManager.ValidateToken(token)
+		if err != nil {
+			log.Printf("❌ WebSocket token validation failed: %v", err)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
 
-	// Get user ID from query parameter or use the one from token
-	userIDParam := c.Query("user_id")
-	if userIDParam == "" {
-		userIDParam = strconv.FormatUint(uint64(user.ID), 10)
-	}
+		// Get user ID from database using the username from token
+		if err := database.DB.Where("username = ?", claims.Username).First(&user).Error; err != nil {
+			log.Printf("❌ User not found: %v", err)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+			return
+		}
+		if user.IsSuspended() {
+			c.JSON(http.StatusForbidden, gin.H{"error": "account suspended"})
+			return
+		}
 
-	// Verify that the user is only connecting as themselves (security check)
-	requestedUserID, err := strconv.ParseUint(userIDParam, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+		// Verify that the user is only connecting as themselves (security check)
+		if userIDParam := c.Query("user_id"); userIDParam != "" {
+			requestedUserID, err := strconv.ParseUint(userIDParam, 10, 32)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+				return
+			}
+			if uint(requestedUserID) != user.ID {
+				c.JSON(http.StatusForbidden, gin.H{"error": "cannot connect as different user"})
+				return
+			}
+		}
+	} else if c.Query("user_id") != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "token required"})
 		return
 	}
 
-	if uint(requestedUserID) != user.ID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "cannot connect as different user"})
-		return
+	// Topics requested on connect are validated before upgrading, so mistakes get a plain 400
+	var topics []string
+	for _, raw := range strings.Split(c.Query("topics"), ",") {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		topic, err := pubsub.ParseTopic(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		topics = append(topics, topic)
 	}
 
 	// Get user's preferred language (default to English if not specified)
@@ -102,56 +128,44 @@ func HandleWebSocketNotifications(c *gin.Context) {
 		language = "en" // fallback to English
 	}
 
-	userID, err := strconv.ParseUint(userIDParam, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
-		return
-	}
-
 	// Upgrade HTTP connection to WebSocket
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Printf("❌ WebSocket upgrade failed: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "WebSocket upgrade failed"})
 		return
 	}
-
-	// Register client with notification hub
-	pubsub.GetNotificationHub().RegisterClient(uint(userID), conn, language)
+	conn.SetReadLimit(maxClientFrameSize)
+
+	// Admins can opt in to queue job progress
+	client := &pubsub.ClientConnection{
+		UserID:    user.ID,
+		Conn:      conn,
+		Language:  language,
+		WatchJobs: c.Query("jobs") == "true" && user.Role == "admin",
+	}
+	if err := hub.Subscribe(client, topics...); err != nil {
+		_ = conn.WriteJSON(pubsub.NotificationMessage{Type: "error", Data: gin.H{"error": err.Error()}, Channel: "system", Timestamp: time.Now()})
+		_ = conn.Close()
+		return
+	}
+	hub.Register(client)
 
 	// Handle WebSocket connection lifecycle
-	defer func() {
-		pubsub.GetNotificationHub().UnregisterClient(uint(userID))
-		if err := conn.Close(); err != nil {
-			log.Printf("Warning: Failed to close WebSocket connection: %v", err)
-		}
-	}()
+	defer hub.Unregister(client)
 
-	// Keep connection alive and handle incoming messages
+	// Read subscription frames until the client goes away; ping/pong control frames are
+	// answered by the websocket library
 	for {
-		// Read messages from client (ping/pong, subscription updates, etc.)
 		messageType, message, err := conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("❌ WebSocket error for user %d: %v", userID, err)
+				log.Printf("❌ WebSocket error for user %d: %v", client.UserID, err)
 			}
 			break
 		}
 
-		// Handle different message types
-		switch messageType {
-		case websocket.TextMessage:
-			log.Printf("📨 Received message from user %d: %s", userID, string(message))
-
-			// You can handle client-to-server messages here
-			// For example: subscription management, preferences, etc.
-
-		case websocket.PingMessage:
-			// Respond to ping with pong
-			if err := conn.WriteMessage(websocket.PongMessage, nil); err != nil {
-				log.Printf("❌ Failed to send pong to user %d: %v", userID, err)
-				return
-			}
+		if messageType == websocket.TextMessage {
+			hub.HandleClientFrame(client, message)
 		}
 	}
 }
@@ -166,8 +180,9 @@ func HandleWebSocketNotifications(c *gin.Context) {
 // @Router /api/ws/stats [get]
 func GetNotificationStats(c *gin.Context) {
 	stats := NotificationStatsResponse{
-		ConnectedUsers: pubsub.GetConnectedUsers(),
-		SystemStatus:   "active",
+		ConnectedUsers:  pubsub.GetConnectedUsers(),
+		ConnectionStats: pubsub.GetConnectionStats(),
+		SystemStatus:    "active",
 	}
 
 	c.JSON(http.StatusOK, stats)
@@ -268,8 +283,9 @@ func GetUserConnectionStatus(c *gin.Context) {
 
 // Response types
 type NotificationStatsResponse struct {
-	ConnectedUsers int    `json:"connected_users"`
-	SystemStatus   string `json:"system_status"`
+	ConnectedUsers int `json:"connected_users"`
+	pubsub.ConnectionStats
+	SystemStatus string `json:"system_status"`
 }
 
 type TestNotificationRequest struct {
@@ -0,0 +1,41 @@
+// Package hls packages videos for adaptive streaming: it probes a source with ffprobe, plans a
+// ladder of renditions no larger than the source, transcodes each into HLS segments with ffmpeg
+// and writes the master playlist. Commands run through an Executor so the pipeline can be
+// tested without ffmpeg.
+package hls
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+)
+
+// Executor runs an external command and returns its standard output
+type Executor interface {
+	Run(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+// ExecExecutor runs commands with os/exec
+type ExecExecutor struct{}
+
+// maxErrorOutput limits how much of a failed command's stderr is kept in the error
+const maxErrorOutput = 2048
+
+// Run implements Executor
+func (ExecExecutor) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		output := bytes.TrimSpace(stderr.Bytes())
+		if len(output) > maxErrorOutput {
+			output = output[len(output)-maxErrorOutput:]
+		}
+		return nil, fmt.Errorf("%s failed: %w: %s", filepath.Base(name), err, output)
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,96 @@
+package hls
+
+import (
+	"fmt"
+	"math"
+)
+
+// Rendition is a step of the HLS ladder. Height is the short side of the picture, so portrait
+// videos are scaled by width.
+type Rendition struct {
+	Name         string
+	Height       int
+	VideoBitrate int // kbit/s
+	AudioBitrate int // kbit/s
+}
+
+// DefaultLadder lists the renditions that can be produced, smallest first
+var DefaultLadder = []Rendition{
+	{Name: "240p", Height: 240, VideoBitrate: 400, AudioBitrate: 64},
+	{Name: "360p", Height: 360, VideoBitrate: 700, AudioBitrate: 96},
+	{Name: "480p", Height: 480, VideoBitrate: 1000, AudioBitrate: 96},
+	{Name: "720p", Height: 720, VideoBitrate: 2800, AudioBitrate: 128},
+	{Name: "1080p", Height: 1080, VideoBitrate: 5000, AudioBitrate: 192},
+}
+
+// SelectRenditions returns the renditions of DefaultLadder with the given names, smallest first
+func SelectRenditions(names []string) ([]Rendition, error) {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	var renditions []Rendition
+	for _, rendition := range DefaultLadder {
+		if wanted[rendition.Name] {
+			renditions = append(renditions, rendition)
+			delete(wanted, rendition.Name)
+		}
+	}
+	for name := range wanted {
+		return nil, fmt.Errorf("unknown rendition %q", name)
+	}
+	if len(renditions) == 0 {
+		return nil, fmt.Errorf("no renditions selected")
+	}
+	return renditions, nil
+}
+
+// Variant is a rendition planned for a particular source, with its output size
+type Variant struct {
+	Name         string `json:"name"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	VideoBitrate int    `json:"video_bitrate"` // kbit/s
+	AudioBitrate int    `json:"audio_bitrate"` // kbit/s
+}
+
+// Plan sizes the renditions for a width x height source. Renditions larger than the source are
+// dropped, since upscaling only wastes bandwidth; a source smaller than every rendition gets a
+// single variant at its own size with the smallest rendition's bitrates.
+func Plan(width, height int, renditions []Rendition) []Variant {
+	if width <= 0 || height <= 0 || len(renditions) == 0 {
+		return nil
+	}
+	short := min(width, height)
+
+	var variants []Variant
+	for _, rendition := range renditions {
+		if rendition.Height <= short {
+			variants = append(variants, size(rendition, width, height, rendition.Height))
+		}
+	}
+	if len(variants) == 0 {
+		smallest := renditions[0]
+		smallest.Name = fmt.Sprintf("%dp", even(short))
+		variants = append(variants, size(smallest, width, height, short))
+	}
+	return variants
+}
+
+// size scales width x height so its short side is target, rounding both sides to even numbers
+// as H.264 requires
+func size(rendition Rendition, width, height, target int) Variant {
+	v := Variant{Name: rendition.Name, VideoBitrate: rendition.VideoBitrate, AudioBitrate: rendition.AudioBitrate}
+	scale := float64(target) / float64(min(width, height))
+	v.Width = even(int(math.Round(float64(width) * scale)))
+	v.Height = even(int(math.Round(float64(height) * scale)))
+	return v
+}
+
+func even(n int) int {
+	if n%2 != 0 {
+		n--
+	}
+	return max(n, 2)
+}
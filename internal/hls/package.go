@@ -0,0 +1,211 @@
+package hls
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MasterPlaylist is the file name of the master playlist in the output directory
+const MasterPlaylist = "master.m3u8"
+
+// Output is a packaged rendition. Paths are relative to the output directory and use slashes.
+type Output struct {
+	Variant
+	Bandwidth        int      `json:"bandwidth"` // peak bits per second, as advertised in the master playlist
+	AverageBandwidth int      `json:"average_bandwidth"`
+	Codecs           string   `json:"codecs"`
+	Playlist         string   `json:"playlist"`
+	Segments         []string `json:"segments"`
+}
+
+// Result lists the files written by Package
+type Result struct {
+	Master  string   `json:"master"`
+	Outputs []Output `json:"outputs"`
+}
+
+// Files returns every file of the result, relative to the output directory, with the master
+// playlist last so players never see it before its renditions
+func (r *Result) Files() []string {
+	var files []string
+	for _, output := range r.Outputs {
+		files = append(files, output.Segments...)
+		files = append(files, output.Playlist)
+	}
+	return append(files, r.Master)
+}
+
+// Packager transcodes videos into HLS renditions with ffmpeg
+type Packager struct {
+	Executor        Executor
+	FFmpegPath      string
+	SegmentDuration int // seconds
+}
+
+// Package transcodes input into one HLS rendition per variant under dir and writes the master
+// playlist. progress is called after each rendition with the number done so far.
+func (p *Packager) Package(ctx context.Context, input, dir string, source *Metadata, variants []Variant, progress func(done, total int)) (*Result, error) {
+	if len(variants) == 0 {
+		return nil, fmt.Errorf("no renditions to package")
+	}
+
+	result := &Result{Master: MasterPlaylist}
+	for i, variant := range variants {
+		if err := os.MkdirAll(filepath.Join(dir, variant.Name), 0755); err != nil {
+			return nil, err
+		}
+		if _, err := p.Executor.Run(ctx, p.FFmpegPath, p.Args(input, dir, variant, source.HasAudio())...); err != nil {
+			return nil, fmt.Errorf("failed to transcode %s: %w", variant.Name, err)
+		}
+
+		output, err := collect(dir, variant, source.HasAudio())
+		if err != nil {
+			return nil, err
+		}
+		result.Outputs = append(result.Outputs, *output)
+
+		if progress != nil {
+			progress(i+1, len(variants))
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, MasterPlaylist), []byte(WriteMasterPlaylist(result.Outputs)), 0644); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Args returns the ffmpeg arguments that package one variant. Keyframes are forced at segment
+// boundaries so every rendition is cut at the same points and players can switch between them.
+func (p *Packager) Args(input, dir string, variant Variant, audio bool) []string {
+	segment := p.segmentDuration()
+	outDir := filepath.Join(dir, variant.Name)
+
+	args := []string{
+		"-hide_banner", "-y",
+		"-i", input,
+		"-map", "0:v:0",
+	}
+	if audio {
+		args = append(args, "-map", "0:a:0")
+	}
+	args = append(args,
+		"-vf", fmt.Sprintf("scale=%d:%d", variant.Width, variant.Height),
+		"-c:v", "libx264",
+		"-preset", "veryfast",
+		"-profile:v", "main",
+		"-level", level(variant),
+		"-pix_fmt", "yuv420p",
+		"-b:v", fmt.Sprintf("%dk", variant.VideoBitrate),
+		"-maxrate", fmt.Sprintf("%dk", maxRate(variant)),
+		"-bufsize", fmt.Sprintf("%dk", variant.VideoBitrate*3/2),
+		"-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%d)", segment),
+		"-sc_threshold", "0",
+	)
+	if audio {
+		args = append(args, "-c:a", "aac", "-b:a", fmt.Sprintf("%dk", variant.AudioBitrate), "-ac", "2")
+	} else {
+		args = append(args, "-an")
+	}
+	return append(args,
+		"-f", "hls",
+		"-hls_time", strconv.Itoa(segment),
+		"-hls_playlist_type", "vod",
+		"-hls_flags", "independent_segments",
+		"-hls_segment_filename", filepath.Join(outDir, "segment_%04d.ts"),
+		filepath.Join(outDir, "index.m3u8"),
+	)
+}
+
+func (p *Packager) segmentDuration() int {
+	if p.SegmentDuration <= 0 {
+		return 6
+	}
+	return p.SegmentDuration
+}
+
+// collect lists the playlist and segments ffmpeg wrote for a variant
+func collect(dir string, variant Variant, audio bool) (*Output, error) {
+	playlist := path.Join(variant.Name, "index.m3u8")
+	if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(playlist))); err != nil {
+		return nil, fmt.Errorf("ffmpeg did not write the %s playlist: %w", variant.Name, err)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, variant.Name, "segment_*.ts"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	segments := make([]string, len(matches))
+	for i, match := range matches {
+		segments[i] = path.Join(variant.Name, filepath.Base(match))
+	}
+
+	output := &Output{
+		Variant:          variant,
+		Bandwidth:        maxRate(variant) * 1000,
+		AverageBandwidth: variant.VideoBitrate * 1000,
+		Codecs:           "avc1." + profileLevel(variant),
+		Playlist:         playlist,
+		Segments:         segments,
+	}
+	if audio {
+		output.Bandwidth += variant.AudioBitrate * 1000
+		output.AverageBandwidth += variant.AudioBitrate * 1000
+		output.Codecs += ",mp4a.40.2"
+	}
+	return output, nil
+}
+
+// WriteMasterPlaylist returns the master playlist of the outputs, lowest bandwidth first
+func WriteMasterPlaylist(outputs []Output) string {
+	sorted := append([]Output(nil), outputs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Bandwidth < sorted[j].Bandwidth })
+
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-INDEPENDENT-SEGMENTS\n")
+	for _, output := range sorted {
+		fmt.Fprintf(&b, "#EXT-X-STREAM-INF:BANDWIDTH=%d,AVERAGE-BANDWIDTH=%d,RESOLUTION=%dx%d,CODECS=\"%s\"\n%s\n",
+			output.Bandwidth,
+			output.AverageBandwidth,
+			output.Width, output.Height,
+			output.Codecs,
+			output.Playlist)
+	}
+	return b.String()
+}
+
+// maxRate caps the video bitrate at 7% above its target
+func maxRate(variant Variant) int {
+	return variant.VideoBitrate * 107 / 100
+}
+
+// level returns the H.264 level needed for the variant's frame size at up to 30 fps
+func level(variant Variant) string {
+	switch pixels := variant.Width * variant.Height; {
+	case pixels <= 414720: // 720x576
+		return "3.0"
+	case pixels <= 921600: // 1280x720
+		return "3.1"
+	default:
+		return "4.0"
+	}
+}
+
+// profileLevel returns the RFC 6381 profile and level of the Main profile at level(variant)
+func profileLevel(variant Variant) string {
+	switch level(variant) {
+	case "3.0":
+		return "4d401e"
+	case "3.1":
+		return "4d401f"
+	default:
+		return "4d4028"
+	}
+}
@@ -0,0 +1,104 @@
+package hls
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"news/internal/json"
+)
+
+// Metadata describes a video file as reported by ffprobe
+type Metadata struct {
+	Duration   float64 `json:"duration"`
+	FileSize   int64   `json:"file_size"`
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+	FrameRate  float64 `json:"frame_rate"`
+	Bitrate    int     `json:"bitrate"`
+	VideoCodec string  `json:"video_codec"`
+	AudioCodec string  `json:"audio_codec"`
+}
+
+// HasAudio reports whether the file has an audio stream
+func (m *Metadata) HasAudio() bool {
+	return m.AudioCodec != ""
+}
+
+// Probe reads the metadata of a video file with ffprobe
+func Probe(ctx context.Context, executor Executor, ffprobePath, input string) (*Metadata, error) {
+	output, err := executor.Run(ctx, ffprobePath,
+		"-v", "quiet",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get video metadata: %w", err)
+	}
+	return ParseProbe(output)
+}
+
+// ParseProbe parses the JSON output of ffprobe -show_format -show_streams, using the first
+// video and audio streams
+func ParseProbe(output []byte) (*Metadata, error) {
+	var probe struct {
+		Format struct {
+			Duration string `json:"duration"`
+			Size     string `json:"size"`
+			BitRate  string `json:"bit_rate"`
+		} `json:"format"`
+		Streams []struct {
+			CodecType  string `json:"codec_type"`
+			CodecName  string `json:"codec_name"`
+			Width      int    `json:"width"`
+			Height     int    `json:"height"`
+			RFrameRate string `json:"r_frame_rate"`
+			BitRate    string `json:"bit_rate"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata: %w", err)
+	}
+
+	metadata := &Metadata{}
+	if duration, err := strconv.ParseFloat(probe.Format.Duration, 64); err == nil {
+		metadata.Duration = duration
+	}
+	if size, err := strconv.ParseInt(probe.Format.Size, 10, 64); err == nil {
+		metadata.FileSize = size
+	}
+
+	for _, stream := range probe.Streams {
+		switch {
+		case stream.CodecType == "video" && metadata.VideoCodec == "":
+			metadata.Width = stream.Width
+			metadata.Height = stream.Height
+			metadata.VideoCodec = stream.CodecName
+			if bitrate, err := strconv.Atoi(stream.BitRate); err == nil {
+				metadata.Bitrate = bitrate
+			}
+			// Frame rates are fractions such as "30000/1001"
+			if num, den, ok := strings.Cut(stream.RFrameRate, "/"); ok {
+				n, err1 := strconv.ParseFloat(num, 64)
+				d, err2 := strconv.ParseFloat(den, 64)
+				if err1 == nil && err2 == nil && d != 0 {
+					metadata.FrameRate = n / d
+				}
+			}
+		case stream.CodecType == "audio" && metadata.AudioCodec == "":
+			metadata.AudioCodec = stream.CodecName
+		}
+	}
+
+	if metadata.Bitrate == 0 {
+		if bitrate, err := strconv.Atoi(probe.Format.BitRate); err == nil {
+			metadata.Bitrate = bitrate
+		}
+	}
+	if metadata.VideoCodec == "" {
+		return nil, fmt.Errorf("no video stream found")
+	}
+	return metadata, nil
+}
@@ -0,0 +1,158 @@
+// Package imaging decodes uploaded images and produces resized derivatives and a dominant colour.
+// Resizing uses golang.org/x/image/draw; WebP output needs an external encoder, see WebPEncoder.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+
+	// Register the GIF decoder for image.Decode
+	_ "image/gif"
+
+	xdraw "golang.org/x/image/draw"
+	// Register the WebP decoder so WebP uploads get derivatives too
+	_ "golang.org/x/image/webp"
+)
+
+// ErrUnsupported is returned for images that cannot be decoded, such as SVG
+var ErrUnsupported = errors.New("unsupported image format")
+
+// ErrTooLarge is returned for images with more pixels than MaxPixels
+var ErrTooLarge = errors.New("image has too many pixels")
+
+// MaxPixels guards against decompression bombs: larger images are stored without derivatives
+const MaxPixels = 50_000_000
+
+// Variant is a derivative size. Images are scaled to Width, keeping their aspect ratio.
+type Variant struct {
+	Name  string
+	Width int
+}
+
+// Variants are the derivatives generated for uploaded images, smallest first
+var Variants = []Variant{
+	{Name: "thumb", Width: 320},
+	{Name: "medium", Width: 800},
+	{Name: "large", Width: 1600},
+}
+
+// Decode reads an image, refusing images with more than MaxPixels before decoding them
+func Decode(data []byte) (image.Image, string, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		if errors.Is(err, image.ErrFormat) {
+			return nil, "", ErrUnsupported
+		}
+		return nil, "", err
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return nil, format, ErrTooLarge
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, format, err
+	}
+	return img, format, nil
+}
+
+// Fit returns the size of an image of width x height scaled down to maxWidth. Images are never
+// scaled up.
+func Fit(width, height, maxWidth int) (int, int) {
+	if width <= maxWidth || width == 0 {
+		return width, height
+	}
+	h := int(math.Round(float64(height) * float64(maxWidth) / float64(width)))
+	if h < 1 {
+		h = 1
+	}
+	return maxWidth, h
+}
+
+// Resize scales src to width x height with a Catmull-Rom filter, which keeps edges sharp when
+// shrinking photos
+func Resize(src image.Image, width, height int) *image.NRGBA {
+	bounds := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	if width <= 0 || height <= 0 || bounds.Empty() {
+		return dst
+	}
+	if width == bounds.Dx() && height == bounds.Dy() {
+		draw.Draw(dst, dst.Bounds(), src, bounds.Min, draw.Src)
+		return dst
+	}
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+	return dst
+}
+
+// DominantColor returns the most common colour of an image as "#rrggbb", ignoring mostly
+// transparent pixels. Colours are grouped into buckets and the winning bucket is averaged, so
+// slight variations of one colour count together.
+func DominantColor(img image.Image) string {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return ""
+	}
+
+	// Sample at most 64x64 pixels
+	stepX := max(1, bounds.Dx()/64)
+	stepY := max(1, bounds.Dy()/64)
+
+	type bucket struct {
+		r, g, b, n uint64
+	}
+	buckets := make(map[uint16]*bucket)
+	var best *bucket
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += stepY {
+		for x := bounds.Min.X; x < bounds.Max.X; x += stepX {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if c.A < 128 {
+				continue
+			}
+			key := uint16(c.R>>4)<<8 | uint16(c.G>>4)<<4 | uint16(c.B>>4)
+			bk, ok := buckets[key]
+			if !ok {
+				bk = &bucket{}
+				buckets[key] = bk
+			}
+			bk.r += uint64(c.R)
+			bk.g += uint64(c.G)
+			bk.b += uint64(c.B)
+			bk.n++
+			if best == nil || bk.n > best.n {
+				best = bk
+			}
+		}
+	}
+
+	if best == nil {
+		return ""
+	}
+	return fmt.Sprintf("#%02x%02x%02x", best.r/best.n, best.g/best.n, best.b/best.n)
+}
+
+// Opaque reports whether every pixel of the image is fully opaque
+func Opaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
+
+// Encode writes a derivative as JPEG, or as PNG when the image has transparency. It returns the
+// format and file extension used.
+func Encode(w io.Writer, img image.Image, jpegQuality int) (string, string, error) {
+	if Opaque(img) {
+		return "jpeg", ".jpg", jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+	}
+	return "png", ".png", png.Encode(w, img)
+}
@@ -0,0 +1,66 @@
+package imaging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// WebPEncoder encodes images as WebP. Neither the standard library nor golang.org/x/image has a
+// WebP encoder, so the default implementation runs the cwebp tool from libwebp.
+type WebPEncoder interface {
+	EncodeWebP(img image.Image, quality int) ([]byte, error)
+}
+
+// CWebP encodes with the cwebp command line tool
+type CWebP struct {
+	Path    string
+	Timeout time.Duration
+}
+
+// NewCWebP returns an encoder using the cwebp binary at path, failing if it cannot be found
+func NewCWebP(path string) (*CWebP, error) {
+	if path == "" {
+		return nil, fmt.Errorf("no cwebp path configured")
+	}
+	resolved, err := exec.LookPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("cwebp not found: %w", err)
+	}
+	return &CWebP{Path: resolved, Timeout: 30 * time.Second}, nil
+}
+
+// EncodeWebP implements WebPEncoder
+func (c *CWebP) EncodeWebP(img image.Image, quality int) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "webp-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	input := dir + "/input.png"
+	output := dir + "/output.webp"
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(input, buf.Bytes(), 0o600); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, c.Path, "-quiet", "-q", strconv.Itoa(quality), input, "-o", output)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("cwebp failed: %v: %s", err, bytes.TrimSpace(out))
+	}
+	return os.ReadFile(output)
+}
@@ -0,0 +1,48 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Message is a single email ready to be sent
+type Message struct {
+	From      string
+	To        string
+	Subject   string
+	PlainBody string
+	HTMLBody  string
+	Headers   map[string]string
+}
+
+// Mailer sends email messages
+type Mailer interface {
+	// Send delivers the message and returns the Message-ID it was sent with
+	Send(ctx context.Context, msg Message) (string, error)
+}
+
+// SendError describes a failed delivery and whether retrying can help
+type SendError struct {
+	Code      int // SMTP reply code, zero for connection errors
+	Permanent bool
+	Err       error
+}
+
+func (e *SendError) Error() string {
+	if e.Code != 0 {
+		return fmt.Sprintf("smtp %d: %v", e.Code, e.Err)
+	}
+	return e.Err.Error()
+}
+
+func (e *SendError) Unwrap() error {
+	return e.Err
+}
+
+// IsPermanent reports whether err is a permanent failure, such as a rejected mailbox,
+// which should be recorded as a bounce instead of retried
+func IsPermanent(err error) bool {
+	var sendErr *SendError
+	return errors.As(err, &sendErr) && sendErr.Permanent
+}
@@ -0,0 +1,197 @@
+package mail
+
+import (
+	"html"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"news/internal/models"
+)
+
+// Recipient holds the per-subscriber values substituted into a newsletter
+type Recipient struct {
+	Email          string
+	Name           string
+	Language       string
+	UnsubscribeURL string
+}
+
+// unsubscribeFooters holds the localized unsubscribe footer, keyed by language
+var unsubscribeFooters = map[string]string{
+	"en": "You are receiving this email because you subscribed to our newsletter. Unsubscribe:",
+	"tr": "Bu e-postayı bültenimize abone olduğunuz için alıyorsunuz. Abonelikten çık:",
+	"es": "Recibe este correo porque se suscribió a nuestro boletín. Cancelar suscripción:",
+}
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// RenderNewsletter renders a newsletter for a single recipient. The email template wraps the
+// newsletter content; templates may use the {{content}}, {{title}}, {{name}}, {{email}} and
+// {{unsubscribe_url}} placeholders. Content is appended to templates without {{content}}, and an
+// unsubscribe footer is appended to templates without {{unsubscribe_url}}.
+func RenderNewsletter(newsletter models.Newsletter, template *models.EmailTemplateTranslation, recipient Recipient) Message {
+	plainTemplate, htmlTemplate, preheader := "", "", ""
+	if template != nil {
+		plainTemplate = template.PlainBody
+		htmlTemplate = template.HTMLBody
+		preheader = template.PreheaderText
+	}
+
+	plainContent := htmlToText(newsletter.Content)
+	footer := unsubscribeFooters[recipient.Language]
+	if footer == "" {
+		footer = unsubscribeFooters["en"]
+	}
+
+	plain := renderPlaceholders(plainTemplate, newsletter, recipient, plainContent, false)
+	if !strings.Contains(plainTemplate, "{{content}}") {
+		plain = joinSections("\n\n", plain, plainContent)
+	}
+	if recipient.UnsubscribeURL != "" && !strings.Contains(plainTemplate, "{{unsubscribe_url}}") {
+		plain = joinSections("\n\n", plain, "--\n"+footer+" "+recipient.UnsubscribeURL)
+	}
+
+	body := renderPlaceholders(htmlTemplate, newsletter, recipient, newsletter.Content, true)
+	if !strings.Contains(htmlTemplate, "{{content}}") {
+		body = joinSections("\n", body, newsletter.Content)
+	}
+	if recipient.UnsubscribeURL != "" && !strings.Contains(htmlTemplate, "{{unsubscribe_url}}") {
+		link := html.EscapeString(recipient.UnsubscribeURL)
+		body = joinSections("\n", body, `<p style="font-size:12px;color:#888">`+html.EscapeString(footer)+` <a href="`+link+`">`+link+`</a></p>`)
+	}
+	if preheader != "" {
+		body = `<div style="display:none;max-height:0;overflow:hidden">` + html.EscapeString(preheader) + "</div>\n" + body
+	}
+
+	msg := Message{
+		To:        recipient.address(),
+		Subject:   newsletter.Subject,
+		PlainBody: plain,
+		HTMLBody:  body,
+		Headers:   map[string]string{},
+	}
+	if recipient.UnsubscribeURL != "" {
+		// RFC 8058 one-click unsubscribe; the URL accepts a POST without confirmation
+		msg.Headers["List-Unsubscribe"] = "<" + recipient.UnsubscribeURL + ">"
+		msg.Headers["List-Unsubscribe-Post"] = "List-Unsubscribe=One-Click"
+	}
+	return msg
+}
+
+// confirmationDefaults holds the built-in subscription confirmation email, keyed by language.
+// It is used when no "subscription_confirmation" email template has been configured.
+var confirmationDefaults = map[string]models.EmailTemplateTranslation{
+	"en": {
+		Subject:   "Please confirm your subscription",
+		PlainBody: "Please confirm your subscription by opening this link:\n\n{{confirm_url}}\n\nIf you did not request this, you can ignore this email.",
+		HTMLBody:  `<p>Please confirm your subscription.</p><p><a href="{{confirm_url}}">Confirm subscription</a></p><p style="font-size:12px;color:#888">If you did not request this, you can ignore this email.</p>`,
+	},
+	"tr": {
+		Subject:   "Lütfen aboneliğinizi onaylayın",
+		PlainBody: "Aboneliğinizi onaylamak için bu bağlantıyı açın:\n\n{{confirm_url}}\n\nBu isteği siz yapmadıysanız bu e-postayı yok sayabilirsiniz.",
+		HTMLBody:  `<p>Lütfen aboneliğinizi onaylayın.</p><p><a href="{{confirm_url}}">Aboneliği onayla</a></p><p style="font-size:12px;color:#888">Bu isteği siz yapmadıysanız bu e-postayı yok sayabilirsiniz.</p>`,
+	},
+	"es": {
+		Subject:   "Confirme su suscripción",
+		PlainBody: "Confirme su suscripción abriendo este enlace:\n\n{{confirm_url}}\n\nSi no lo solicitó, puede ignorar este correo.",
+		HTMLBody:  `<p>Confirme su suscripción.</p><p><a href="{{confirm_url}}">Confirmar suscripción</a></p><p style="font-size:12px;color:#888">Si no lo solicitó, puede ignorar este correo.</p>`,
+	},
+}
+
+// RenderSubscriptionConfirmation renders the double opt-in email. Templates may use the
+// {{confirm_url}}, {{name}}, {{email}} and {{unsubscribe_url}} placeholders; a built-in
+// localized message is used when template is nil.
+func RenderSubscriptionConfirmation(template *models.EmailTemplateTranslation, recipient Recipient, confirmURL string) Message {
+	if template == nil {
+		fallback, ok := confirmationDefaults[recipient.Language]
+		if !ok {
+			fallback = confirmationDefaults["en"]
+		}
+		template = &fallback
+	}
+
+	replace := func(text string, escape bool) string {
+		value := func(s string) string {
+			if escape {
+				return html.EscapeString(s)
+			}
+			return s
+		}
+		return strings.NewReplacer(
+			"{{confirm_url}}", value(confirmURL),
+			"{{name}}", value(recipient.Name),
+			"{{email}}", value(recipient.Email),
+			"{{unsubscribe_url}}", value(recipient.UnsubscribeURL),
+		).Replace(text)
+	}
+
+	plain := replace(template.PlainBody, false)
+	if !strings.Contains(template.PlainBody, "{{confirm_url}}") {
+		plain = joinSections("\n\n", plain, confirmURL)
+	}
+	body := replace(template.HTMLBody, true)
+	if body != "" && !strings.Contains(template.HTMLBody, "{{confirm_url}}") {
+		link := html.EscapeString(confirmURL)
+		body = joinSections("\n", body, `<p><a href="`+link+`">`+link+`</a></p>`)
+	}
+	if body != "" && template.PreheaderText != "" {
+		body = `<div style="display:none;max-height:0;overflow:hidden">` + html.EscapeString(template.PreheaderText) + "</div>\n" + body
+	}
+
+	return Message{
+		To:        recipient.address(),
+		Subject:   replace(template.Subject, false),
+		PlainBody: plain,
+		HTMLBody:  body,
+		Headers:   map[string]string{},
+	}
+}
+
+// renderPlaceholders substitutes recipient values; values are escaped for HTML bodies
+// except the newsletter content, which is authored as HTML
+func renderPlaceholders(text string, newsletter models.Newsletter, recipient Recipient, content string, escape bool) string {
+	if text == "" {
+		return ""
+	}
+
+	value := func(s string) string {
+		if escape {
+			return html.EscapeString(s)
+		}
+		return s
+	}
+
+	return strings.NewReplacer(
+		"{{content}}", content,
+		"{{title}}", value(newsletter.Title),
+		"{{name}}", value(recipient.Name),
+		"{{email}}", value(recipient.Email),
+		"{{unsubscribe_url}}", value(recipient.UnsubscribeURL),
+	).Replace(text)
+}
+
+// htmlToText produces a readable plain text alternative from HTML content
+func htmlToText(content string) string {
+	replacer := strings.NewReplacer("<br>", "\n", "<br/>", "\n", "<br />", "\n", "</p>", "\n\n", "</h1>", "\n\n", "</h2>", "\n\n", "</h3>", "\n\n", "</li>", "\n")
+	text := htmlTagPattern.ReplaceAllString(replacer.Replace(content), "")
+	return strings.TrimSpace(html.UnescapeString(text))
+}
+
+func joinSections(separator string, sections ...string) string {
+	nonEmpty := make([]string, 0, len(sections))
+	for _, section := range sections {
+		if strings.TrimSpace(section) != "" {
+			nonEmpty = append(nonEmpty, section)
+		}
+	}
+	return strings.Join(nonEmpty, separator)
+}
+
+// address formats the recipient as an RFC 5322 address
+func (r Recipient) address() string {
+	if r.Name == "" {
+		return r.Email
+	}
+	return (&mail.Address{Name: r.Name, Address: r.Email}).String()
+}
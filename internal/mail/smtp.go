@@ -0,0 +1,216 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"news/internal/config"
+)
+
+// SMTPMailer sends email through an SMTP server
+type SMTPMailer struct {
+	host     string
+	port     int
+	username string
+	password string
+	startTLS bool
+	timeout  time.Duration
+	from     string
+}
+
+// NewSMTPMailer creates a new SMTP mailer from the mail configuration
+func NewSMTPMailer(cfg *config.MailConfig) *SMTPMailer {
+	from := (&mail.Address{Name: cfg.FromName, Address: cfg.FromAddress}).String()
+
+	return &SMTPMailer{
+		host:     cfg.SMTPHost,
+		port:     cfg.SMTPPort,
+		username: cfg.SMTPUsername,
+		password: cfg.SMTPPassword,
+		startTLS: cfg.StartTLS,
+		timeout:  cfg.Timeout,
+		from:     from,
+	}
+}
+
+// Send delivers a message over a fresh SMTP connection
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) (string, error) {
+	if msg.From == "" {
+		msg.From = m.from
+	}
+
+	sender, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return "", &SendError{Permanent: true, Err: fmt.Errorf("invalid sender address: %w", err)}
+	}
+	recipient, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return "", &SendError{Permanent: true, Err: fmt.Errorf("invalid recipient address: %w", err)}
+	}
+
+	messageID := newMessageID(sender.Address)
+	data, err := buildMessage(msg, messageID)
+	if err != nil {
+		return "", &SendError{Permanent: true, Err: err}
+	}
+
+	if m.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.timeout)
+		defer cancel()
+	}
+
+	addr := net.JoinHostPort(m.host, strconv.Itoa(m.port))
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return "", &SendError{Err: fmt.Errorf("failed to connect to %s: %w", addr, err)}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		_ = conn.Close()
+		return "", classifySMTPError(err)
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+
+	if m.startTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+				return "", classifySMTPError(err)
+			}
+		}
+	}
+
+	if m.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return "", classifySMTPError(err)
+		}
+	}
+
+	if err := client.Mail(sender.Address); err != nil {
+		return "", classifySMTPError(err)
+	}
+	if err := client.Rcpt(recipient.Address); err != nil {
+		return "", classifySMTPError(err)
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return "", classifySMTPError(err)
+	}
+	if _, err := writer.Write(data); err != nil {
+		_ = writer.Close()
+		return "", classifySMTPError(err)
+	}
+	if err := writer.Close(); err != nil {
+		return "", classifySMTPError(err)
+	}
+
+	// The message has been accepted at this point, so a failed QUIT is not a delivery failure
+	_ = client.Quit()
+
+	return messageID, nil
+}
+
+// classifySMTPError marks 5xx replies as permanent and everything else as retryable
+func classifySMTPError(err error) error {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return &SendError{Code: protoErr.Code, Permanent: protoErr.Code >= 500, Err: errors.New(protoErr.Msg)}
+	}
+	return &SendError{Err: err}
+}
+
+// buildMessage renders a MIME message with plain text and HTML alternatives
+func buildMessage(msg Message, messageID string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	headers := map[string]string{
+		"From":         msg.From,
+		"To":           msg.To,
+		"Subject":      mime.QEncoding.Encode("utf-8", msg.Subject),
+		"Date":         time.Now().Format(time.RFC1123Z),
+		"Message-ID":   messageID,
+		"MIME-Version": "1.0",
+	}
+	for key, value := range msg.Headers {
+		headers[textproto.CanonicalMIMEHeaderKey(key)] = value
+	}
+
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	body := multipart.NewWriter(&buf)
+	for _, key := range keys {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, headers[key])
+	}
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", body.Boundary())
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=utf-8", msg.PlainBody},
+		{"text/html; charset=utf-8", msg.HTMLBody},
+	}
+	for _, part := range parts {
+		if part.content == "" {
+			continue
+		}
+		writer, err := body.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoder := quotedprintable.NewWriter(writer)
+		if _, err := encoder.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// newMessageID generates a unique Message-ID in the sender's domain
+func newMessageID(sender string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(sender, "@"); at >= 0 && at < len(sender)-1 {
+		domain = sender[at+1:]
+	}
+
+	random := make([]byte, 12)
+	_, _ = rand.Read(random)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(random), domain)
+}
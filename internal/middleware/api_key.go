@@ -1,12 +1,23 @@
 package middleware
 
 import (
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"news/internal/database"
 	"news/internal/metrics"
+	"news/internal/models"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // APIKeyTier defines the tier of an API key and its associated rate limits
@@ -56,23 +67,126 @@ var (
 		SpecialEndpoints:       []string{"/api/analytics", "/api/export", "/api/bulk"},
 	}
 
-	// Map to look up API key tiers
-	apiKeyTiers = make(map[string]APIKeyTier)
+	// apiKeyTiers maps tier names stored on API keys to their limits
+	apiKeyTiers = map[string]APIKeyTier{
+		models.APIKeyTierBasic:      BasicTier,
+		models.APIKeyTierPro:        ProTier,
+		models.APIKeyTierEnterprise: EnterpriseTier,
+	}
+
+	// apiKeyLimiters holds the per-minute, per-hour and per-day quotas of each tier
+	apiKeyLimiters = make(map[string]*apiKeyTierQuota)
+
+	apiKeyCache = &apiKeyLookupCache{entries: make(map[string]*cachedAPIKey)}
 )
 
-// InitAPIKeys initializes API key data
-func InitAPIKeys() {
-	// In a real application, this would likely be loaded from a database
-	// or configuration file. Here we're using hardcoded values for demonstration.
-	apiKeyTiers["api_key_basic_1234"] = BasicTier
-	apiKeyTiers["api_key_pro_5678"] = ProTier
-	apiKeyTiers["api_key_enterprise_9012"] = EnterpriseTier
+// apiKeyCacheTTL bounds how long a revoked or rotated key keeps working on other replicas
+const apiKeyCacheTTL = 30 * time.Second
+
+// apiKeyWindow is one of the request quotas of a tier
+type apiKeyWindow struct {
+	name   string
+	limit  int
+	window time.Duration
 }
 
-// APIKeyAuth middleware verifies and enforces API key tiers
+// apiKeyTierQuota holds the windows of a tier and the store that counts requests against them
+type apiKeyTierQuota struct {
+	windows []apiKeyWindow
+	store   APIKeyQuotaStore
+}
+
+// cachedAPIKey is an API key looked up recently
+type cachedAPIKey struct {
+	key       models.APIKey
+	expiresAt time.Time
+}
+
+// apiKeyLookupCache avoids a database query for every request made with the same key
+type apiKeyLookupCache struct {
+	mu      sync.RWMutex
+	entries map[string]*cachedAPIKey
+}
+
+// InitAPIKeys sets up the tier quotas. With a Redis client the quotas are shared by all
+// replicas; otherwise each replica enforces them on its own.
+func InitAPIKeys(redisClient *redis.Client) {
+	for name, tier := range apiKeyTiers {
+		quotas := []struct {
+			name   string
+			limit  int
+			window time.Duration
+		}{
+			{"minute", tier.MaxRequestsPerMinute, time.Minute},
+			{"hour", tier.MaxRequestsPerHour, time.Hour},
+			{"day", tier.MaxRequestsPerDay, 24 * time.Hour},
+		}
+
+		windows := make([]apiKeyWindow, 0, len(quotas))
+		limits := make([]QuotaWindow, 0, len(quotas))
+		for _, quota := range quotas {
+			windows = append(windows, apiKeyWindow{name: quota.name, limit: quota.limit, window: quota.window})
+			limits = append(limits, QuotaWindow{Name: quota.name, Limit: quota.limit, Window: quota.window})
+		}
+
+		var store APIKeyQuotaStore
+		if redisClient != nil {
+			store = NewRedisQuotaStore(redisClient, limits)
+		} else {
+			store = NewMemoryQuotaStore(limits)
+		}
+		apiKeyLimiters[name] = &apiKeyTierQuota{windows: windows, store: store}
+	}
+
+	ResetAPIKeyCache()
+}
+
+// ResetAPIKeyCache forgets the API keys looked up by this replica, so revocations and
+// rotations take effect immediately here and within apiKeyCacheTTL elsewhere
+func ResetAPIKeyCache() {
+	apiKeyCache.mu.Lock()
+	apiKeyCache.entries = make(map[string]*cachedAPIKey)
+	apiKeyCache.mu.Unlock()
+}
+
+// lookupAPIKey returns the API key with the given hash, or nil if there is none
+func lookupAPIKey(hash string) (*models.APIKey, error) {
+	now := time.Now()
+
+	apiKeyCache.mu.RLock()
+	entry, ok := apiKeyCache.entries[hash]
+	apiKeyCache.mu.RUnlock()
+	if ok && entry.expiresAt.After(now) {
+		key := entry.key
+		return &key, nil
+	}
+
+	if database.DB == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	// The owner's role decides what the key may do with articles
+	var key models.APIKey
+	if err := database.DB.Preload("Owner", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "role")
+	}).Where("key_hash = ?", hash).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	apiKeyCache.mu.Lock()
+	apiKeyCache.entries[hash] = &cachedAPIKey{key: key, expiresAt: now.Add(apiKeyCacheTTL)}
+	apiKeyCache.mu.Unlock()
+
+	return &key, nil
+}
+
+// APIKeyAuth middleware verifies API keys and enforces their tier, scopes and quotas
 func APIKeyAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		apiKey := c.GetHeader("X-API-Key")
+		rawKey := c.GetHeader("X-API-Key")
 
 		// For demo/development, allow some endpoints without API key
 		if c.Request.URL.Path == "/health" || c.Request.URL.Path == "/metrics" ||
@@ -81,43 +195,170 @@ func APIKeyAuth() gin.HandlerFunc {
 			return
 		}
 
-		if apiKey == "" {
+		if rawKey == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "API key is required"})
 			c.Abort()
 			return
 		}
 
-		tier, exists := apiKeyTiers[apiKey]
-		if !exists {
+		key, err := lookupAPIKey(models.HashAPIKey(rawKey))
+		if err != nil {
+			log.Printf("API key lookup failed: %v", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "API key verification unavailable"})
+			c.Abort()
+			return
+		}
+		if key == nil || !key.IsUsable(time.Now()) {
 			// Track invalid API key attempt
 			metrics.RequestsTotal.WithLabelValues(c.FullPath(), c.Request.Method, "401").Inc()
 
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+			message := "Invalid API key"
+			if key != nil && key.Revoked {
+				message = "API key has been revoked"
+			} else if key != nil {
+				message = "API key has expired"
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{"error": message})
 			c.Abort()
 			return
 		}
 
-		// Store the tier in the context for use by other middleware (like rate limiting)
+		tier, ok := apiKeyTiers[key.Tier]
+		if !ok {
+			tier = BasicTier
+		}
+
+		// Store the key and tier in the context for use by handlers and other middleware
+		c.Set("api_key_id", key.ID)
+		c.Set("api_key_owner_id", key.OwnerID)
+		if key.Owner != nil {
+			c.Set("api_key_owner_role", key.Owner.Role)
+		}
 		c.Set("api_key_tier", tier.Name)
+		c.Set("api_key_scopes", key.ScopeList())
 		c.Set("rate_limit", tier.RateLimit)
 		c.Set("burst", tier.Burst)
 
-		// Check if the endpoint is allowed for this tier
+		// Check if the endpoint is allowed for this tier and granted to this key
 		endpoint := c.Request.URL.Path
-		if isSpecialEndpoint(endpoint) && !containsEndpoint(tier.SpecialEndpoints, endpoint) {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error":        "This endpoint requires a higher API key tier",
-				"current_tier": tier.Name,
-			})
-			c.Abort()
-			return
+		if isSpecialEndpoint(endpoint) {
+			if !containsEndpoint(tier.SpecialEndpoints, endpoint) {
+				c.JSON(http.StatusForbidden, gin.H{
+					"error":        "This endpoint requires a higher API key tier",
+					"current_tier": tier.Name,
+				})
+				c.Abort()
+				return
+			}
+			if scope := endpointScope(endpoint); !key.HasScope(scope) {
+				c.JSON(http.StatusForbidden, gin.H{
+					"error":          "API key is missing the required scope",
+					"required_scope": scope,
+				})
+				c.Abort()
+				return
+			}
+		}
+
+		if !IsTestMode() && !IsRateLimitDisabled() {
+			if window, allowed := allowAPIKeyRequest(key.ID, tier.Name); !allowed {
+				retryAfter := int(window.window.Seconds())
+				c.Header("X-RateLimit-Limit", strconv.Itoa(window.limit))
+				c.Header("X-RateLimit-Window", window.name)
+				c.Header("Retry-After", strconv.Itoa(retryAfter))
+				c.JSON(http.StatusTooManyRequests, gin.H{
+					"error":       "API key quota exceeded",
+					"window":      window.name,
+					"limit":       window.limit,
+					"retry_after": retryAfter,
+				})
+				metrics.TrackRateLimitExceeded(endpoint, fmt.Sprintf("api_key:%d", key.ID))
+				recordAPIKeyUsage(key, c.FullPath(), http.StatusTooManyRequests)
+				c.Abort()
+				return
+			}
 		}
 
 		// Add tier information to response headers
 		c.Header("X-API-Tier", tier.Name)
 
 		c.Next()
+
+		recordAPIKeyUsage(key, c.FullPath(), c.Writer.Status())
+	}
+}
+
+// allowAPIKeyRequest checks the tier quotas of a key and returns the window that was exceeded.
+// A refused request is not counted against any window.
+func allowAPIKeyRequest(keyID uint, tierName string) (apiKeyWindow, bool) {
+	quota, ok := apiKeyLimiters[tierName]
+	if !ok {
+		return apiKeyWindow{}, true
+	}
+
+	exceeded, err := quota.store.Allow(fmt.Sprintf("apikey:%d", keyID))
+	if err != nil {
+		// Allow the request in case of errors, like the IP rate limiter
+		log.Printf("API key rate limiting error: %v", err)
+		return apiKeyWindow{}, true
+	}
+	if exceeded >= 0 {
+		return quota.windows[exceeded], false
 	}
+	return apiKeyWindow{}, true
+}
+
+// recordAPIKeyUsage counts a request against the key's daily usage for endpoint and marks
+// the key as used. It runs in the background so metering never slows down a response.
+func recordAPIKeyUsage(key *models.APIKey, endpoint string, status int) {
+	if database.DB == nil || IsTestMode() {
+		return
+	}
+	if endpoint == "" {
+		endpoint = "unmatched"
+	}
+
+	now := time.Now().UTC()
+	var errorCount int64
+	if status >= http.StatusBadRequest {
+		errorCount = 1
+	}
+	touch := key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > time.Minute
+	if touch {
+		apiKeyCache.mu.Lock()
+		if entry, ok := apiKeyCache.entries[key.KeyHash]; ok {
+			entry.key.LastUsedAt = &now
+		}
+		apiKeyCache.mu.Unlock()
+	}
+
+	go func() {
+		usage := models.APIKeyUsage{
+			APIKeyID: key.ID,
+			Date:     now.Truncate(24 * time.Hour),
+			Endpoint: endpoint,
+			Requests: 1,
+			Errors:   errorCount,
+		}
+		err := database.DB.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "api_key_id"}, {Name: "date"}, {Name: "endpoint"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"requests":   gorm.Expr("api_key_usages.requests + 1"),
+				"errors":     gorm.Expr("api_key_usages.errors + ?", errorCount),
+				"updated_at": now,
+			}),
+		}).Create(&usage).Error
+		if err != nil {
+			log.Printf("Warning: Failed to record usage of API key %d: %v", key.ID, err)
+		}
+
+		if touch {
+			if err := database.DB.Model(&models.APIKey{}).Where("id = ?", key.ID).
+				UpdateColumn("last_used_at", now).Error; err != nil {
+				log.Printf("Warning: Failed to update last use of API key %d: %v", key.ID, err)
+			}
+		}
+	}()
 }
 
 // Helper function to check if an endpoint is special (requires higher tier)
@@ -126,6 +367,18 @@ func isSpecialEndpoint(endpoint string) bool {
 	return containsEndpoint(specialEndpoints, endpoint)
 }
 
+// endpointScope returns the API key scope required by a special endpoint
+func endpointScope(endpoint string) string {
+	switch {
+	case strings.HasPrefix(endpoint, "/api/analytics"):
+		return models.APIKeyScopeAnalytics
+	case strings.HasPrefix(endpoint, "/api/export"):
+		return models.APIKeyScopeExport
+	default:
+		return models.APIKeyScopeBulk
+	}
+}
+
 // Helper function to check if a slice contains an endpoint
 func containsEndpoint(endpoints []string, target string) bool {
 	for _, endpoint := range endpoints {
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"golang.org/x/time/rate"
+)
+
+// QuotaWindow is a request limit over a period of time
+type QuotaWindow struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+}
+
+// APIKeyQuotaStore counts requests against several windows at once. A request is either
+// counted against every window or, when one of them is exhausted, against none.
+type APIKeyQuotaStore interface {
+	// Allow counts a request for key and returns -1, or returns the index of the first
+	// exhausted window without counting the request
+	Allow(key string) (int, error)
+}
+
+// MemoryQuotaStore enforces quotas per replica with token buckets
+type MemoryQuotaStore struct {
+	mu       sync.Mutex
+	windows  []QuotaWindow
+	limiters map[string][]*rate.Limiter
+}
+
+// NewMemoryQuotaStore creates an in-memory quota store for the given windows
+func NewMemoryQuotaStore(windows []QuotaWindow) *MemoryQuotaStore {
+	return &MemoryQuotaStore{
+		windows:  windows,
+		limiters: make(map[string][]*rate.Limiter),
+	}
+}
+
+// Allow takes a token from every window, handing the tokens back if any window has none left
+func (m *MemoryQuotaStore) Allow(key string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	limiters, ok := m.limiters[key]
+	if !ok {
+		limiters = make([]*rate.Limiter, len(m.windows))
+		for i, window := range m.windows {
+			limiters[i] = rate.NewLimiter(rate.Limit(float64(window.Limit)/window.Window.Seconds()), window.Limit)
+		}
+		m.limiters[key] = limiters
+	}
+
+	now := time.Now()
+	reservations := make([]*rate.Reservation, 0, len(limiters))
+	for i, limiter := range limiters {
+		reservation := limiter.ReserveN(now, 1)
+		if !reservation.OK() || reservation.DelayFrom(now) > 0 {
+			reservation.CancelAt(now)
+			for _, taken := range reservations {
+				taken.CancelAt(now)
+			}
+			return i, nil
+		}
+		reservations = append(reservations, reservation)
+	}
+	return -1, nil
+}
+
+// RedisQuotaStore enforces quotas shared by all replicas with fixed-window counters in Redis,
+// so a key costs one counter per window however many requests it makes
+type RedisQuotaStore struct {
+	client  *redis.Client
+	windows []QuotaWindow
+}
+
+// NewRedisQuotaStore creates a Redis quota store for the given windows
+func NewRedisQuotaStore(client *redis.Client, windows []QuotaWindow) *RedisQuotaStore {
+	return &RedisQuotaStore{client: client, windows: windows}
+}
+
+// Allow counts the request in the current period of every window, and takes it back from all
+// of them when one of the windows is over its limit
+func (r *RedisQuotaStore) Allow(key string) (int, error) {
+	ctx := context.Background()
+	now := time.Now().UnixMilli()
+
+	keys := make([]string, len(r.windows))
+	counts := make([]*redis.IntCmd, len(r.windows))
+	pipe := r.client.TxPipeline()
+	for i, window := range r.windows {
+		keys[i] = fmt.Sprintf("rate:%s:%s:%d", key, window.Name, now/window.Window.Milliseconds())
+		counts[i] = pipe.Incr(ctx, keys[i])
+		pipe.PExpire(ctx, keys[i], window.Window)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return -1, err
+	}
+
+	for i, window := range r.windows {
+		if counts[i].Val() <= int64(window.Limit) {
+			continue
+		}
+		refund := r.client.TxPipeline()
+		for _, counter := range keys {
+			refund.Decr(ctx, counter)
+		}
+		if _, err := refund.Exec(ctx); err != nil {
+			log.Printf("Failed to hand back refused API key request: %v", err)
+		}
+		return i, nil
+	}
+	return -1, nil
+}
@@ -173,6 +173,12 @@ func Authenticate() gin.HandlerFunc {
 			// In production, get the real user ID from database by username
 			var user models.User
 			if err := database.DB.Where("username = ?", claims.Username).First(&user).Error; err == nil {
+				// Suspended accounts lose access immediately, even with a valid token
+				if user.IsSuspended() {
+					c.JSON(http.StatusForbidden, gin.H{"error": "Account suspended"})
+					c.Abort()
+					return
+				}
 				c.Set("userID", user.ID)
 				c.Set("user_id", user.ID) // Also set with underscore for compatibility
 			}
@@ -230,7 +236,8 @@ func AdminAuth() gin.HandlerFunc {
 func Authorize(requiredRole string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("role")
-		if !exists || role != requiredRole {
+		roleName, _ := role.(string)
+		if !exists || !strings.EqualFold(roleName, requiredRole) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
 			c.Abort()
 			return
@@ -239,6 +246,22 @@ func Authorize(requiredRole string) gin.HandlerFunc {
 	}
 }
 
+// AuthorizeAny allows requests from users with any of the given roles
+func AuthorizeAny(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, _ := c.Get("role")
+		roleName, _ := role.(string)
+		for _, allowed := range roles {
+			if strings.EqualFold(roleName, allowed) {
+				c.Next()
+				return
+			}
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+		c.Abort()
+	}
+}
+
 func AdminOnly() gin.HandlerFunc {
 	return Authorize("Admin")
 }
@@ -255,6 +278,11 @@ func UserOnly() gin.HandlerFunc {
 	return Authorize("User")
 }
 
+// StaffOnly allows authors, editors and admins
+func StaffOnly() gin.HandlerFunc {
+	return AuthorizeAny("Author", "Editor", "Admin")
+}
+
 // GetJWTSecret returns the JWT secret key
 func GetJWTSecret() string {
 	if len(jwtKey) == 0 {
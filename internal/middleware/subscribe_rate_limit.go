@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"news/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+// subscribeWindows bound how many subscribe requests one IP address may make, so the public
+// endpoint cannot be used to send confirmation emails in bulk
+var subscribeWindows = []QuotaWindow{
+	{Name: "minute", Limit: 5, Window: time.Minute},
+	{Name: "hour", Limit: 20, Window: time.Hour},
+}
+
+// SubscribeRateLimit limits anonymous subscribe requests per IP address. With a Redis client the
+// limit is shared by all replicas; otherwise each replica enforces it on its own.
+func SubscribeRateLimit(redisClient *redis.Client) gin.HandlerFunc {
+	var store APIKeyQuotaStore
+	if redisClient != nil {
+		store = NewRedisQuotaStore(redisClient, subscribeWindows)
+	} else {
+		store = NewMemoryQuotaStore(subscribeWindows)
+	}
+
+	return func(c *gin.Context) {
+		if IsTestMode() || IsRateLimitDisabled() {
+			c.Next()
+			return
+		}
+
+		exceeded, err := store.Allow("subscribe:" + c.ClientIP())
+		if err != nil {
+			// Allow the request in case of errors, like the IP rate limiter
+			log.Printf("Subscribe rate limiting error: %v", err)
+			c.Next()
+			return
+		}
+		if exceeded >= 0 {
+			retryAfter := int(subscribeWindows[exceeded].Window.Seconds())
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":       "Too many subscription requests",
+				"retry_after": retryAfter,
+			})
+			metrics.TrackRateLimitExceeded(c.FullPath(), c.ClientIP())
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -61,8 +61,11 @@ type Article struct {
 	FeaturedImage string         `gorm:"size:255" json:"featured_image"`
 	Gallery       datatypes.JSON `gorm:"type:json" json:"gallery" swaggertype:"array,string"` // JSON array of image URLs
 	Status        string         `gorm:"size:20;not null;default:'draft';index" json:"status"`
+	ReviewerID    *uint          `gorm:"index" json:"reviewer_id,omitempty"` // Editor the article was submitted to
 	PublishedAt   *time.Time     `gorm:"index" json:"published_at"`
-	ScheduledAt   *time.Time     `json:"scheduled_at"`
+	ScheduledAt   *time.Time     `gorm:"index" json:"scheduled_at"`
+	UnpublishAt   *time.Time     `gorm:"index" json:"unpublish_at"`
+	UnpublishTo   string         `gorm:"size:20" json:"unpublish_to,omitempty"` // draft or archived (default) once UnpublishAt passes
 	Views         int            `gorm:"default:0;index" json:"views"`
 	ReadTime      int            `gorm:"default:0" json:"read_time"` // in minutes
 	IsBreaking    bool           `gorm:"default:false;index" json:"is_breaking"`
@@ -80,6 +83,7 @@ type Article struct {
 
 	// Relations
 	Author           User                     `gorm:"foreignKey:AuthorID" json:"author,omitempty"`
+	Reviewer         *User                    `gorm:"foreignKey:ReviewerID" json:"reviewer,omitempty"`
 	Categories       []Category               `gorm:"many2many:article_categories" json:"categories,omitempty"`
 	Tags             []Tag                    `gorm:"many2many:article_tags" json:"tags,omitempty"`
 	Comments         []Comment                `gorm:"foreignKey:ArticleID" json:"comments,omitempty"`
@@ -94,15 +98,36 @@ type Article struct {
 // ValidateStatus validates article status
 func (a *Article) ValidateStatus() bool {
 	allowedStatuses := map[string]bool{
-		"draft":     true,
-		"published": true,
-		"scheduled": true,
-		"archived":  true,
-		"trash":     true,
+		"draft":             true,
+		"in_review":         true,
+		"changes_requested": true,
+		"approved":          true,
+		"published":         true,
+		"scheduled":         true,
+		"archived":          true,
+		"trash":             true,
 	}
 	return allowedStatuses[a.Status]
 }
 
+// IsDueForPublication reports whether a scheduled article should be published at the given time
+func (a *Article) IsDueForPublication(now time.Time) bool {
+	return a.Status == "scheduled" && a.ScheduledAt != nil && !a.ScheduledAt.After(now)
+}
+
+// IsDueForUnpublication reports whether a published article should be taken down at the given time
+func (a *Article) IsDueForUnpublication(now time.Time) bool {
+	return a.Status == "published" && a.UnpublishAt != nil && !a.UnpublishAt.After(now)
+}
+
+// UnpublishStatus returns the status a published article moves to once UnpublishAt passes
+func (a *Article) UnpublishStatus() string {
+	if a.UnpublishTo == "draft" {
+		return "draft"
+	}
+	return "archived"
+}
+
 // ValidateContentType validates article content type
 func (a *Article) ValidateContentType() bool {
 	allowedTypes := map[string]bool{
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// ArticleEmbedding stores the embedding vector of an article for similarity and semantic
+// search. Vectors are packed float32 values compared in Go, so no database extension is needed.
+type ArticleEmbedding struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	ArticleID   uint      `gorm:"not null;uniqueIndex" json:"article_id"`
+	Provider    string    `gorm:"size:100;not null;index" json:"provider"` // Provider and model that produced the vector
+	Dimensions  int       `gorm:"not null" json:"dimensions"`
+	Vector      []byte    `gorm:"type:bytea;not null" json:"-"`
+	ContentHash string    `gorm:"size:64;not null" json:"content_hash"` // Hash of the embedded text, to skip unchanged articles
+	Language    string    `gorm:"size:5;index" json:"language"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relations
+	Article *Article `gorm:"foreignKey:ArticleID" json:"article,omitempty"`
+}
@@ -0,0 +1,352 @@
+package models
+
+import (
+	"bytes"
+	"time"
+
+	"news/internal/json"
+
+	"gorm.io/datatypes"
+)
+
+// ArticleRevision stores a full snapshot of an article and its content blocks
+// taken whenever the article is created, updated or restored
+type ArticleRevision struct {
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	ArticleID      uint           `gorm:"not null;uniqueIndex:idx_article_revisions_article_number,priority:1" json:"article_id"`
+	RevisionNumber int            `gorm:"not null;uniqueIndex:idx_article_revisions_article_number,priority:2" json:"revision_number"`
+	ChangeType     string         `gorm:"size:20;not null;default:'update'" json:"change_type"` // create, update, blocks, restore
+	ChangeNote     string         `gorm:"size:255" json:"change_note"`
+	RestoredFromID *uint          `json:"restored_from_id,omitempty"`
+	EditorID       *uint          `gorm:"index" json:"editor_id"`
+	Title          string         `gorm:"size:255;not null" json:"title"`
+	Summary        string         `gorm:"type:text" json:"summary"`
+	Content        string         `gorm:"type:text" json:"content"`
+	ContentType    string         `gorm:"size:20" json:"content_type"`
+	Status         string         `gorm:"size:20" json:"status"`
+	FeaturedImage  string         `gorm:"size:255" json:"featured_image"`
+	MetaTitle      string         `gorm:"size:255" json:"meta_title"`
+	MetaDesc       string         `gorm:"size:255;column:meta_description" json:"meta_description"`
+	BlocksVersion  int            `gorm:"default:1" json:"blocks_version"`
+	Blocks         datatypes.JSON `gorm:"type:json" json:"blocks" swaggertype:"array,object"` // JSON array of RevisionBlock
+	CreatedAt      time.Time      `gorm:"autoCreateTime;index" json:"created_at"`
+
+	// Relations
+	Article Article `gorm:"foreignKey:ArticleID" json:"-"`
+	Editor  *User   `gorm:"foreignKey:EditorID" json:"editor,omitempty"`
+}
+
+// RevisionBlock is the snapshot form of an ArticleContentBlock
+type RevisionBlock struct {
+	BlockID   uint           `json:"block_id"`
+	BlockType string         `json:"block_type"`
+	Content   string         `json:"content"`
+	Settings  datatypes.JSON `json:"settings" swaggertype:"object"`
+	Position  int            `json:"position"`
+	IsVisible bool           `json:"is_visible"`
+}
+
+// ValidateChangeType validates revision change type
+func (r *ArticleRevision) ValidateChangeType() bool {
+	allowedTypes := map[string]bool{
+		"create":  true,
+		"update":  true,
+		"blocks":  true,
+		"restore": true,
+	}
+	return allowedTypes[r.ChangeType]
+}
+
+// NewArticleRevision builds a revision snapshot from an article and its blocks
+func NewArticleRevision(article Article, blocks []ArticleContentBlock) (ArticleRevision, error) {
+	snapshot := make([]RevisionBlock, 0, len(blocks))
+	for _, block := range blocks {
+		snapshot = append(snapshot, RevisionBlock{
+			BlockID:   block.ID,
+			BlockType: block.BlockType,
+			Content:   block.Content,
+			Settings:  block.Settings,
+			Position:  block.Position,
+			IsVisible: block.IsVisible,
+		})
+	}
+
+	blocksJSON, err := json.Marshal(snapshot)
+	if err != nil {
+		return ArticleRevision{}, err
+	}
+
+	return ArticleRevision{
+		ArticleID:     article.ID,
+		Title:         article.Title,
+		Summary:       article.Summary,
+		Content:       article.Content,
+		ContentType:   article.ContentType,
+		Status:        article.Status,
+		FeaturedImage: article.FeaturedImage,
+		MetaTitle:     article.MetaTitle,
+		MetaDesc:      article.MetaDesc,
+		BlocksVersion: article.BlocksVersion,
+		Blocks:        datatypes.JSON(blocksJSON),
+	}, nil
+}
+
+// GetBlocks decodes the block snapshot stored in the revision
+func (r *ArticleRevision) GetBlocks() ([]RevisionBlock, error) {
+	var blocks []RevisionBlock
+	if len(r.Blocks) == 0 {
+		return blocks, nil
+	}
+	if err := json.Unmarshal(r.Blocks, &blocks); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// ToContentBlocks converts the block snapshot back into content blocks for an article. The
+// blocks keep the IDs they had when the snapshot was taken.
+func (r *ArticleRevision) ToContentBlocks() ([]ArticleContentBlock, error) {
+	snapshot, err := r.GetBlocks()
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := make([]ArticleContentBlock, 0, len(snapshot))
+	for _, block := range snapshot {
+		blocks = append(blocks, ArticleContentBlock{
+			ID:        block.BlockID,
+			ArticleID: r.ArticleID,
+			BlockType: block.BlockType,
+			Content:   block.Content,
+			Settings:  block.Settings,
+			Position:  block.Position,
+			IsVisible: block.IsVisible,
+		})
+	}
+	return blocks, nil
+}
+
+// RevisionFieldChange describes a single changed article field between two revisions
+type RevisionFieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// RevisionBlockChange describes a block-level change between two revisions
+type RevisionBlockChange struct {
+	Change        string         `json:"change"` // added, removed, modified, unchanged
+	BlockType     string         `json:"block_type"`
+	FromPosition  *int           `json:"from_position,omitempty"`
+	ToPosition    *int           `json:"to_position,omitempty"`
+	ChangedFields []string       `json:"changed_fields,omitempty"`
+	From          *RevisionBlock `json:"from,omitempty"`
+	To            *RevisionBlock `json:"to,omitempty"`
+}
+
+// ArticleRevisionDiff represents the differences between two article revisions
+type ArticleRevisionDiff struct {
+	ArticleID     uint                  `json:"article_id"`
+	FromRevision  int                   `json:"from_revision"`
+	ToRevision    int                   `json:"to_revision"`
+	FieldChanges  []RevisionFieldChange `json:"field_changes"`
+	BlockChanges  []RevisionBlockChange `json:"block_changes"`
+	BlocksAdded   int                   `json:"blocks_added"`
+	BlocksRemoved int                   `json:"blocks_removed"`
+	BlocksChanged int                   `json:"blocks_modified"`
+}
+
+// DiffArticleRevisions computes a field- and block-level diff from one revision to another
+func DiffArticleRevisions(from, to ArticleRevision) (ArticleRevisionDiff, error) {
+	diff := ArticleRevisionDiff{
+		ArticleID:    to.ArticleID,
+		FromRevision: from.RevisionNumber,
+		ToRevision:   to.RevisionNumber,
+		FieldChanges: []RevisionFieldChange{},
+		BlockChanges: []RevisionBlockChange{},
+	}
+
+	fields := []struct {
+		name     string
+		from, to string
+	}{
+		{"title", from.Title, to.Title},
+		{"summary", from.Summary, to.Summary},
+		{"content", from.Content, to.Content},
+		{"content_type", from.ContentType, to.ContentType},
+		{"status", from.Status, to.Status},
+		{"featured_image", from.FeaturedImage, to.FeaturedImage},
+		{"meta_title", from.MetaTitle, to.MetaTitle},
+		{"meta_description", from.MetaDesc, to.MetaDesc},
+	}
+	for _, field := range fields {
+		if field.from != field.to {
+			diff.FieldChanges = append(diff.FieldChanges, RevisionFieldChange{Field: field.name, From: field.from, To: field.to})
+		}
+	}
+
+	fromBlocks, err := from.GetBlocks()
+	if err != nil {
+		return diff, err
+	}
+	toBlocks, err := to.GetBlocks()
+	if err != nil {
+		return diff, err
+	}
+
+	diff.BlockChanges = diffRevisionBlocks(fromBlocks, toBlocks)
+	for _, change := range diff.BlockChanges {
+		switch change.Change {
+		case "added":
+			diff.BlocksAdded++
+		case "removed":
+			diff.BlocksRemoved++
+		case "modified":
+			diff.BlocksChanged++
+		}
+	}
+
+	return diff, nil
+}
+
+// diffRevisionBlocks aligns two block lists with a longest common subsequence so that
+// blocks recreated with new IDs (saved without their ID) still match.
+// Within each unmatched stretch, a removed and an added block of the same type are
+// reported together as a modification.
+func diffRevisionBlocks(from, to []RevisionBlock) []RevisionBlockChange {
+	n, m := len(from), len(to)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if sameRevisionBlock(from[i], to[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var changes []RevisionBlockChange
+	var removed, added []int
+	flush := func() {
+		// Pair removed and added blocks of the same type, preserving their order
+		pairs := make(map[int]int, len(removed))
+		next := 0
+		for _, i := range removed {
+			for k := next; k < len(added); k++ {
+				if from[i].BlockType == to[added[k]].BlockType {
+					pairs[i] = added[k]
+					next = k + 1
+					break
+				}
+			}
+		}
+
+		paired := make(map[int]bool, len(pairs))
+		for _, i := range removed {
+			if j, ok := pairs[i]; ok {
+				changes = append(changes, newBlockChange("modified", from, to, i, j))
+				paired[j] = true
+			} else {
+				changes = append(changes, newBlockChange("removed", from, to, i, -1))
+			}
+		}
+		for _, j := range added {
+			if !paired[j] {
+				changes = append(changes, newBlockChange("added", from, to, -1, j))
+			}
+		}
+		removed, added = removed[:0], added[:0]
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && sameRevisionBlock(from[i], to[j]):
+			flush()
+			changes = append(changes, newBlockChange("unchanged", from, to, i, j))
+			i++
+			j++
+		case j < m && (i == n || lcs[i][j+1] >= lcs[i+1][j]):
+			added = append(added, j)
+			j++
+		default:
+			removed = append(removed, i)
+			i++
+		}
+	}
+	flush()
+
+	if changes == nil {
+		changes = []RevisionBlockChange{}
+	}
+	return changes
+}
+
+func newBlockChange(kind string, from, to []RevisionBlock, i, j int) RevisionBlockChange {
+	change := RevisionBlockChange{Change: kind}
+	if i >= 0 {
+		block := from[i]
+		position := i + 1
+		change.From = &block
+		change.FromPosition = &position
+		change.BlockType = block.BlockType
+	}
+	if j >= 0 {
+		block := to[j]
+		position := j + 1
+		change.To = &block
+		change.ToPosition = &position
+		change.BlockType = block.BlockType
+	}
+	if kind == "modified" {
+		change.ChangedFields = changedBlockFields(from[i], to[j])
+	}
+	return change
+}
+
+func sameRevisionBlock(a, b RevisionBlock) bool {
+	return len(changedBlockFields(a, b)) == 0
+}
+
+func changedBlockFields(a, b RevisionBlock) []string {
+	var fields []string
+	if a.BlockType != b.BlockType {
+		fields = append(fields, "block_type")
+	}
+	if a.Content != b.Content {
+		fields = append(fields, "content")
+	}
+	if !bytes.Equal(compactJSON(a.Settings), compactJSON(b.Settings)) {
+		fields = append(fields, "settings")
+	}
+	if a.IsVisible != b.IsVisible {
+		fields = append(fields, "is_visible")
+	}
+	return fields
+}
+
+// compactJSON normalises settings so whitespace and key order are not reported as changes
+func compactJSON(data datatypes.JSON) []byte {
+	if len(data) == 0 {
+		return nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return data
+	}
+	// stdlib sorts map keys, which the Sonic configuration does not
+	normalised, err := json.MarshalWithOptions(value, json.WithStdlib())
+	if err != nil {
+		return data
+	}
+	if string(normalised) == "null" {
+		return nil
+	}
+	return normalised
+}
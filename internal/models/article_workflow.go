@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+// ArticleWorkflowTransition logs one status change of an article: who made it, as which role,
+// and why. Transitions made by the scheduler have no actor.
+type ArticleWorkflowTransition struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ArticleID  uint      `gorm:"not null;index" json:"article_id"`
+	FromStatus string    `gorm:"size:20;not null" json:"from_status"`
+	ToStatus   string    `gorm:"size:20;not null;index" json:"to_status"`
+	ActorID    *uint     `gorm:"index" json:"actor_id,omitempty"`
+	ActorRole  string    `gorm:"size:20" json:"actor_role"` // author, editor, admin or system
+	ReviewerID *uint     `json:"reviewer_id,omitempty"`     // Editor assigned when submitted for review
+	Note       string    `gorm:"type:text" json:"note,omitempty"`
+	CreatedAt  time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+
+	// Relations
+	Actor    *User `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+	Reviewer *User `gorm:"foreignKey:ReviewerID" json:"reviewer,omitempty"`
+}
+
+// ArticleReviewNote is a note an editor leaves for the author while reviewing an article
+type ArticleReviewNote struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ArticleID uint      `gorm:"not null;index" json:"article_id"`
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
+	Status    string    `gorm:"size:20" json:"status"` // Article status when the note was written
+	Note      string    `gorm:"type:text;not null" json:"note"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+
+	// Relations
+	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Content that editorial notes can be attached to
+const (
+	EditorialNoteArticle = "article"
+	EditorialNotePage    = "page"
+)
+
+// EditorialNote is a private note staff leave on a content block of an article or page while
+// working on it. A note starts a thread, optionally anchored to a character range of the
+// block; replies belong to the thread and have no anchor of their own. Notes reference the
+// block by ID, so reordering blocks, or saving and restoring them with their IDs, keeps them
+// in place.
+type EditorialNote struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	EntityType string `gorm:"size:20;not null;index:idx_editorial_note_entity,priority:1" json:"entity_type"` // article, page
+	EntityID   uint   `gorm:"not null;index:idx_editorial_note_entity,priority:2" json:"entity_id"`
+	BlockID    uint   `gorm:"not null;index" json:"block_id"` // ArticleContentBlock or PageContentBlock
+	ParentID   *uint  `gorm:"index" json:"parent_id,omitempty"`
+	UserID     uint   `gorm:"not null;index" json:"user_id"`
+	Body       string `gorm:"type:text;not null" json:"body"`
+
+	// Anchor, in characters of the block content; AnchorText is the text it covered
+	AnchorStart *int   `json:"anchor_start,omitempty"`
+	AnchorEnd   *int   `json:"anchor_end,omitempty"`
+	AnchorText  string `gorm:"type:text" json:"anchor_text,omitempty"`
+
+	Resolved   bool       `gorm:"not null;default:false;index" json:"resolved"`
+	ResolvedBy *uint      `json:"resolved_by,omitempty"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// AnchorOutdated is set when the anchored text was edited away; BlockMissing when the
+	// block was removed
+	AnchorOutdated bool `gorm:"-" json:"anchor_outdated,omitempty"`
+	BlockMissing   bool `gorm:"-" json:"block_missing,omitempty"`
+
+	// Relations
+	User    *User           `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Replies []EditorialNote `gorm:"foreignKey:ParentID" json:"replies,omitempty"`
+}
+
+// ValidateEntityType validates the type of content a note is attached to
+func (n *EditorialNote) ValidateEntityType() bool {
+	return n.EntityType == EditorialNoteArticle || n.EntityType == EditorialNotePage
+}
+
+// IsThread reports whether the note starts a thread rather than replying to one
+func (n *EditorialNote) IsThread() bool {
+	return n.ParentID == nil
+}
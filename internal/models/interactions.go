@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -19,6 +20,12 @@ type Comment struct {
 	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// Moderation. ModeratedAt is set when a moderator or AI screening decided the status;
+	// ModeratedBy is nil for AI decisions.
+	ModeratedBy      *uint      `gorm:"index" json:"moderated_by,omitempty"`
+	ModeratedAt      *time.Time `json:"moderated_at,omitempty"`
+	ModerationReason string     `gorm:"size:500" json:"moderation_reason,omitempty"`
+
 	// Relations
 	Article Article   `gorm:"foreignKey:ArticleID" json:"article,omitempty"`
 	User    User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
@@ -34,10 +41,47 @@ func (c *Comment) ValidateStatus() bool {
 		"approved": true,
 		"rejected": true,
 		"spam":     true,
+		"hidden":   true, // Hidden after reader reports
 	}
 	return allowedStatuses[c.Status]
 }
 
+// Comment moderation policy scopes. An article policy overrides its category's policy, which
+// overrides the global one.
+const (
+	ModerationScopeGlobal   = "global"
+	ModerationScopeCategory = "category"
+	ModerationScopeArticle  = "article"
+)
+
+// CommentModerationPolicy decides how new comments in a category or on an article are moderated
+type CommentModerationPolicy struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	ScopeType string `gorm:"size:20;not null;uniqueIndex:idx_moderation_policy_scope" json:"scope_type"` // global, category, article
+	ScopeID   uint   `gorm:"not null;default:0;uniqueIndex:idx_moderation_policy_scope" json:"scope_id"` // 0 for the global policy
+	Mode      string `gorm:"size:20;not null" json:"mode"`                                               // auto_approve, pre_moderate, ai_screen
+	// AI confidence needed to publish or reject a comment without review
+	ApproveThreshold float64 `gorm:"type:decimal(3,2);not null" json:"approve_threshold"`
+	RejectThreshold  float64 `gorm:"type:decimal(3,2);not null" json:"reject_threshold"`
+	// Trust score from which commenters skip the queue; 0 sends everyone through it
+	TrustThreshold int       `gorm:"not null;default:0" json:"trust_threshold"`
+	Strict         bool      `gorm:"not null;default:false" json:"strict"`
+	UpdatedBy      *uint     `json:"updated_by,omitempty"`
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// ValidateScope validates the policy scope
+func (p *CommentModerationPolicy) ValidateScope() bool {
+	switch p.ScopeType {
+	case ModerationScopeGlobal:
+		return p.ScopeID == 0
+	case ModerationScopeCategory, ModerationScopeArticle:
+		return p.ScopeID != 0
+	}
+	return false
+}
+
 // Vote represents user votes (likes/dislikes) on articles and comments
 type Vote struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
@@ -88,22 +132,28 @@ type Follow struct {
 
 // Subscription represents newsletter and notification subscriptions
 type Subscription struct {
-	ID         uint           `gorm:"primaryKey" json:"id"`
-	UserID     *uint          `gorm:"index" json:"user_id"`
-	Email      string         `gorm:"size:100;not null" json:"email"`
-	Type       string         `gorm:"size:20;not null" json:"type"` // newsletter, notifications, category
-	CategoryID *uint          `gorm:"index" json:"category_id"`
-	TagID      *uint          `gorm:"index" json:"tag_id"`
-	IsActive   bool           `gorm:"default:true" json:"is_active"`
-	Token      string         `gorm:"size:255;unique" json:"token"`
-	CreatedAt  time.Time      `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt  time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
-	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+	ID                 uint           `gorm:"primaryKey" json:"id"`
+	UserID             *uint          `gorm:"index" json:"user_id"`
+	Email              string         `gorm:"size:100;not null" json:"email"`
+	Type               string         `gorm:"size:20;not null" json:"type"` // newsletter, notifications, category, tag, author
+	CategoryID         *uint          `gorm:"index" json:"category_id"`
+	TagID              *uint          `gorm:"index" json:"tag_id"`
+	AuthorID           *uint          `gorm:"index" json:"author_id"`
+	Language           string         `gorm:"size:5" json:"language"` // Preferred email language
+	IsActive           bool           `gorm:"default:true" json:"is_active"`
+	Token              string         `gorm:"size:255;unique" json:"-"` // Used for opt-in confirmation and unsubscribe links
+	ConfirmedAt        *time.Time     `json:"confirmed_at"`
+	ConfirmationSentAt *time.Time     `json:"-"`
+	UnsubscribedAt     *time.Time     `json:"unsubscribed_at,omitempty"`
+	CreatedAt          time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt          time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relations
 	User     *User     `gorm:"foreignKey:UserID" json:"user,omitempty"`
 	Category *Category `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
 	Tag      *Tag      `gorm:"foreignKey:TagID" json:"tag,omitempty"`
+	Author   *User     `gorm:"foreignKey:AuthorID" json:"author,omitempty"`
 }
 
 // ValidateSubscriptionType validates subscription type
@@ -117,3 +167,31 @@ func (s *Subscription) ValidateSubscriptionType() bool {
 	}
 	return allowedTypes[s.Type]
 }
+
+// ValidateTarget checks that the subscription references what its type targets
+func (s *Subscription) ValidateTarget() bool {
+	switch s.Type {
+	case "category":
+		return s.CategoryID != nil && s.TagID == nil && s.AuthorID == nil
+	case "tag":
+		return s.TagID != nil && s.CategoryID == nil && s.AuthorID == nil
+	case "author":
+		return s.AuthorID != nil && s.CategoryID == nil && s.TagID == nil
+	default:
+		return s.CategoryID == nil && s.TagID == nil && s.AuthorID == nil
+	}
+}
+
+// IsPendingConfirmation reports whether the subscription still awaits double opt-in
+func (s *Subscription) IsPendingConfirmation() bool {
+	return s.ConfirmedAt == nil && s.UnsubscribedAt == nil
+}
+
+// CanBeActivatedBy reports whether user may turn the subscription on without double opt-in:
+// the address was confirmed before, or it is the user's own verified email address
+func (s *Subscription) CanBeActivatedBy(user *User) bool {
+	if s.ConfirmedAt != nil {
+		return true
+	}
+	return user.IsVerified && strings.EqualFold(s.Email, user.Email)
+}
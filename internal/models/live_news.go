@@ -2,6 +2,37 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Live stream statuses. Scheduled streams go live at StartTime and end at EndTime.
+const (
+	LiveStreamDraft     = "draft"
+	LiveStreamScheduled = "scheduled"
+	LiveStreamLive      = "live"
+	LiveStreamEnded     = "ended"
+)
+
+// Live update importance levels. Updates above normal are highlighted to readers.
+const (
+	LiveUpdateNormal   = "normal"
+	LiveUpdateHigh     = "high"
+	LiveUpdateCritical = "critical"
+	LiveUpdatePinned   = "pinned"
+)
+
+// Live stream event types pushed to viewers. Viewer counts are pushed but not logged.
+const (
+	LiveEventUpdateCreated = "live_update_created"
+	LiveEventUpdateEdited  = "live_update_edited"
+	LiveEventUpdateDeleted = "live_update_deleted"
+	LiveEventStatusChanged = "live_status_changed"
+	LiveEventViewerCount   = "live_viewer_count"
+	// LiveEventReset tells a Server-Sent Events client that it missed too many events to be
+	// replayed and should reload them from the events endpoint
+	LiveEventReset = "live_reset"
 )
 
 // LiveNewsStream represents a live news feed for real-time coverage
@@ -38,16 +69,57 @@ type LiveNewsUpdate struct {
 	UpdatedAt  time.Time  `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
 	DeletedAt  *time.Time `gorm:"column:deleted_at;index" json:"-"`
 
+	// Highlighted is derived from Importance
+	Highlighted bool `gorm:"-" json:"highlighted"`
+
 	// Relations
 	Stream LiveNewsStream `gorm:"foreignKey:StreamID" json:"stream,omitempty"`
 }
 
+// AfterFind derives Highlighted for loaded updates
+func (u *LiveNewsUpdate) AfterFind(tx *gorm.DB) error {
+	u.Highlighted = u.IsHighlighted()
+	return nil
+}
+
+// AfterSave derives Highlighted for created and edited updates
+func (u *LiveNewsUpdate) AfterSave(tx *gorm.DB) error {
+	u.Highlighted = u.IsHighlighted()
+	return nil
+}
+
+// IsHighlighted reports whether the update is pinned or more important than usual
+func (u *LiveNewsUpdate) IsHighlighted() bool {
+	return u.Importance == LiveUpdateHigh || u.Importance == LiveUpdateCritical || u.Importance == LiveUpdatePinned
+}
+
+// ValidLiveUpdateImportance reports whether importance is a known importance level
+func ValidLiveUpdateImportance(importance string) bool {
+	switch importance {
+	case LiveUpdateNormal, LiveUpdateHigh, LiveUpdateCritical, LiveUpdatePinned:
+		return true
+	}
+	return false
+}
+
+// LiveNewsEvent is an entry in the event log of a live stream. Event IDs only grow, so a
+// reconnecting client resumes by asking for the events after the last ID it received.
+type LiveNewsEvent struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	StreamID  uint           `gorm:"not null;index" json:"stream_id"`
+	Type      string         `gorm:"size:30;not null" json:"type"`
+	UpdateID  *uint          `gorm:"index" json:"update_id,omitempty"`
+	Payload   datatypes.JSON `gorm:"type:json" json:"payload" swaggertype:"object"`
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+}
+
 // ValidateStatus validates live stream status
 func (l *LiveNewsStream) ValidateStatus() bool {
 	allowedStatuses := map[string]bool{
-		"draft": true,
-		"live":  true,
-		"ended": true,
+		LiveStreamDraft:     true,
+		LiveStreamScheduled: true,
+		LiveStreamLive:      true,
+		LiveStreamEnded:     true,
 	}
 	return allowedStatuses[l.Status]
 }
@@ -68,3 +140,17 @@ func (l *LiveNewsStream) IsActive() bool {
 
 	return isLive && inTimeRange
 }
+
+// ScheduledStatus returns the status the stream should have at now: scheduled streams go live
+// once StartTime has passed and live streams end once EndTime has passed. Drafts and ended
+// streams are left alone.
+func (l *LiveNewsStream) ScheduledStatus(now time.Time) string {
+	status := l.Status
+	if status == LiveStreamScheduled && l.StartTime != nil && !now.Before(*l.StartTime) {
+		status = LiveStreamLive
+	}
+	if status == LiveStreamLive && l.EndTime != nil && !now.Before(*l.EndTime) {
+		status = LiveStreamEnded
+	}
+	return status
+}
@@ -253,6 +253,23 @@ func (p *Page) GetSEOSettings() PageSEOSettings {
 	return settings
 }
 
+// NoIndex reports whether the page's SEO settings keep it out of search engines. Only an
+// explicit "robots_index": false (or "noindex": true) counts, since pages created without SEO
+// settings are indexable.
+func (p *Page) NoIndex() bool {
+	if len(p.SEOSettings) == 0 {
+		return false
+	}
+	var flags struct {
+		RobotsIndex *bool `json:"robots_index"`
+		NoIndex     bool  `json:"noindex"`
+	}
+	if err := json.Unmarshal(p.SEOSettings, &flags); err != nil {
+		return false
+	}
+	return flags.NoIndex || (flags.RobotsIndex != nil && !*flags.RobotsIndex)
+}
+
 // GetPageSettings unmarshals and returns page settings
 func (p *Page) GetPageSettings() PageSettings {
 	var settings PageSettings
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Preview token entity types
+const (
+	PreviewEntityArticle = "article"
+	PreviewEntityPage    = "page"
+)
+
+// IsValidPreviewEntity reports whether entityType can be previewed with a token
+func IsValidPreviewEntity(entityType string) bool {
+	return entityType == PreviewEntityArticle || entityType == PreviewEntityPage
+}
+
+// PreviewToken records a signed link that opens the draft of one article or page without
+// logging in. The token itself is not stored; TokenID identifies it for revocation and use.
+type PreviewToken struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	TokenID    string     `gorm:"size:32;not null;uniqueIndex" json:"-"`
+	EntityType string     `gorm:"size:20;not null;index:idx_preview_token_entity,priority:1" json:"entity_type"`
+	EntityID   uint       `gorm:"not null;index:idx_preview_token_entity,priority:2" json:"entity_id"`
+	Note       string     `gorm:"size:255" json:"note"` // Who the link was sent to, or why
+	SingleUse  bool       `gorm:"default:false" json:"single_use"`
+	ExpiresAt  time.Time  `gorm:"not null;index" json:"expires_at"`
+	UseCount   int        `gorm:"not null;default:0" json:"use_count"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	Revoked    bool       `gorm:"default:false;index" json:"revoked"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedBy  uint       `gorm:"not null;index" json:"created_by"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relations
+	Creator *User `gorm:"foreignKey:CreatedBy" json:"creator,omitempty"`
+}
+
+// IsActive reports whether the token can still open its draft at t
+func (t *PreviewToken) IsActive(now time.Time) bool {
+	return !t.Revoked && now.Before(t.ExpiresAt) && !(t.SingleUse && t.UseCount > 0)
+}
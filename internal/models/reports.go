@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+)
+
+// Reportable content types
+const (
+	ReportTargetArticle      = "article"
+	ReportTargetComment      = "comment"
+	ReportTargetVideoComment = "video_comment"
+)
+
+// Report case statuses
+const (
+	ReportStatusOpen      = "open"      // Waiting for a moderator
+	ReportStatusDismissed = "dismissed" // The content was found acceptable
+	ReportStatusActioned  = "actioned"  // The content was hidden or deleted, or its author suspended
+)
+
+// Moderator actions on reported content
+const (
+	ReportActionDismiss = "dismiss"
+	ReportActionHide    = "hide"
+	ReportActionDelete  = "delete"
+	ReportActionSuspend = "suspend"
+)
+
+// ReportedContent collects the reports about one piece of content. Moderators triage content
+// rather than single reports, and one action resolves all of its open reports.
+type ReportedContent struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	TargetType string `gorm:"size:20;not null;uniqueIndex:idx_reported_content_target" json:"target_type"` // article, comment, video_comment
+	TargetID   uint   `gorm:"not null;uniqueIndex:idx_reported_content_target" json:"target_id"`
+	OwnerID    uint   `gorm:"not null;index" json:"owner_id"` // Author of the content
+	Status     string `gorm:"size:20;not null;default:'open';index" json:"status"`
+	// OpenReports counts reports since the content was last resolved
+	OpenReports  int       `gorm:"not null;default:0" json:"open_reports"`
+	TotalReports int       `gorm:"not null;default:0" json:"total_reports"`
+	LastReportAt time.Time `gorm:"index" json:"last_report_at"`
+	// HiddenAt is set while the content is hidden; PreviousStatus is restored when a hidden item
+	// is dismissed
+	HiddenAt       *time.Time `json:"hidden_at,omitempty"`
+	AutoHidden     bool       `gorm:"not null;default:false" json:"auto_hidden"`
+	PreviousStatus string     `gorm:"size:20" json:"previous_status,omitempty"`
+	Action         string     `gorm:"size:20" json:"action,omitempty"`
+	ResolvedBy     *uint      `json:"resolved_by,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+	ResolutionNote string     `gorm:"type:text" json:"resolution_note,omitempty"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relations
+	Owner   *User           `gorm:"foreignKey:OwnerID" json:"owner,omitempty"`
+	Reports []ContentReport `gorm:"foreignKey:ReportedContentID" json:"reports,omitempty"`
+}
+
+// ContentReport is one reader's report about an article, comment or video comment
+type ContentReport struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	ReportedContentID uint      `gorm:"not null;index" json:"reported_content_id"`
+	ReporterID        uint      `gorm:"not null;index;uniqueIndex:idx_content_report_reporter" json:"reporter_id"`
+	TargetType        string    `gorm:"size:20;not null;uniqueIndex:idx_content_report_reporter" json:"target_type"`
+	TargetID          uint      `gorm:"not null;uniqueIndex:idx_content_report_reporter" json:"target_id"`
+	Reason            string    `gorm:"size:30;not null;index" json:"reason"`
+	Details           string    `gorm:"type:text" json:"details,omitempty"`
+	Status            string    `gorm:"size:20;not null;default:'open';index" json:"status"` // open, dismissed, actioned
+	CreatedAt         time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+	UpdatedAt         time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relations
+	Reporter *User `gorm:"foreignKey:ReporterID" json:"reporter,omitempty"`
+}
+
+// ValidateTargetType validates the reported content type
+func (r *ContentReport) ValidateTargetType() bool {
+	switch r.TargetType {
+	case ReportTargetArticle, ReportTargetComment, ReportTargetVideoComment:
+		return true
+	}
+	return false
+}
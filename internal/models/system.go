@@ -1,8 +1,11 @@
 package models
 
 import (
+	"encoding/json"
+	"strings"
 	"time"
 
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -12,9 +15,18 @@ type Newsletter struct {
 	Title       string         `gorm:"size:255;not null" json:"title"`
 	Subject     string         `gorm:"size:255;not null" json:"subject"`
 	Content     string         `gorm:"type:text;not null" json:"content"`
-	Status      string         `gorm:"size:20;not null;default:'draft'" json:"status"`
+	Status      string         `gorm:"size:20;not null;default:'draft'" json:"status"` // draft, scheduled, sending, sent
 	SentAt      *time.Time     `json:"sent_at"`
-	ScheduledAt *time.Time     `json:"scheduled_at"`
+	ScheduledAt *time.Time     `gorm:"index" json:"scheduled_at"`
+	TemplateKey string         `gorm:"size:100;default:'newsletter'" json:"template_key"` // EmailTemplateTranslation used to wrap the content
+	Audience    string         `gorm:"size:20;default:'all'" json:"audience"`             // all, category, tag, author
+	CategoryID  *uint          `gorm:"index" json:"category_id,omitempty"`
+	TagID       *uint          `gorm:"index" json:"tag_id,omitempty"`
+	AuthorID    *uint          `gorm:"index" json:"author_id,omitempty"`
+	Recipients  int            `gorm:"default:0" json:"recipients"`
+	Delivered   int            `gorm:"default:0" json:"delivered"`
+	Failed      int            `gorm:"default:0" json:"failed"`
+	Bounced     int            `gorm:"default:0" json:"bounced"`
 	CreatedBy   uint           `gorm:"not null" json:"created_by"`
 	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt   time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
@@ -24,6 +36,49 @@ type Newsletter struct {
 	Creator User `gorm:"foreignKey:CreatedBy" json:"creator,omitempty"`
 }
 
+// ValidateAudience validates newsletter audience and its target
+func (n *Newsletter) ValidateAudience() bool {
+	switch n.Audience {
+	case "", "all":
+		return true
+	case "category":
+		return n.CategoryID != nil
+	case "tag":
+		return n.TagID != nil
+	case "author":
+		return n.AuthorID != nil
+	default:
+		return false
+	}
+}
+
+// NewsletterDelivery tracks the delivery of a newsletter to a single subscriber
+type NewsletterDelivery struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	NewsletterID   uint       `gorm:"not null;uniqueIndex:idx_newsletter_deliveries_recipient,priority:1" json:"newsletter_id"`
+	SubscriptionID uint       `gorm:"not null;index" json:"subscription_id"`
+	Email          string     `gorm:"size:100;not null;uniqueIndex:idx_newsletter_deliveries_recipient,priority:2" json:"email"`
+	Language       string     `gorm:"size:5" json:"language"`
+	Status         string     `gorm:"size:20;not null;default:'pending';index" json:"status"` // pending, queued, sending, retrying, sent, failed, bounced, skipped
+	Attempts       int        `gorm:"default:0" json:"attempts"`
+	MessageID      string     `gorm:"size:255" json:"message_id,omitempty"`
+	LastError      string     `gorm:"type:text" json:"last_error,omitempty"`
+	BounceReason   string     `gorm:"size:255" json:"bounce_reason,omitempty"`
+	SentAt         *time.Time `json:"sent_at,omitempty"`
+	BouncedAt      *time.Time `json:"bounced_at,omitempty"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relations
+	Newsletter   Newsletter   `gorm:"foreignKey:NewsletterID" json:"-"`
+	Subscription Subscription `gorm:"foreignKey:SubscriptionID" json:"-"`
+}
+
+// IsFinal reports whether the delivery has reached a terminal state
+func (d *NewsletterDelivery) IsFinal() bool {
+	return d.Status == "sent" || d.Status == "failed" || d.Status == "bounced"
+}
+
 // Notification represents system notifications
 type Notification struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
@@ -46,6 +101,11 @@ func (n *Notification) ValidateNotificationType() bool {
 	allowedTypes := map[string]bool{
 		"article_published": true,
 		"comment_reply":     true,
+		"comment_rejected":  true,
+		"article_workflow":  true,
+		"review_requested":  true,
+		"review_note":       true,
+		"editorial_note":    true,
 		"comment_like":      true,
 		"article_like":      true,
 		"new_follower":      true,
@@ -127,7 +187,7 @@ type Media struct {
 	OriginalName string         `gorm:"size:255;not null" json:"original_name"`
 	MimeType     string         `gorm:"size:100;not null" json:"mime_type"`
 	Size         int64          `gorm:"not null" json:"size"`
-	Path         string         `gorm:"size:500;not null" json:"path"`
+	Path         string         `gorm:"size:500;not null" json:"path"` // Storage key
 	URL          string         `gorm:"size:500;not null" json:"url"`
 	AltText      string         `gorm:"size:255" json:"alt_text"`
 	Caption      string         `gorm:"type:text" json:"caption"`
@@ -136,6 +196,118 @@ type Media struct {
 	UpdatedAt    time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// Image metadata, empty for other files and images that could not be decoded
+	Width         int            `json:"width,omitempty"`
+	Height        int            `json:"height,omitempty"`
+	DominantColor string         `gorm:"size:7" json:"dominant_color,omitempty"`
+	Derivatives   datatypes.JSON `gorm:"type:json" json:"derivatives,omitempty" swaggertype:"array,object"` // JSON array of MediaDerivative
+
 	// Relations
 	Uploader User `gorm:"foreignKey:UploadedBy" json:"uploader,omitempty"`
 }
+
+// MediaDerivative is a resized or re-encoded copy of an uploaded image
+type MediaDerivative struct {
+	Name   string `json:"name"`   // thumb, medium or large
+	Format string `json:"format"` // jpeg, png or webp
+	Key    string `json:"key"`
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Size   int64  `json:"size"`
+}
+
+// DerivativeList returns the derivatives of the media
+func (m *Media) DerivativeList() []MediaDerivative {
+	var derivatives []MediaDerivative
+	if len(m.Derivatives) > 0 {
+		_ = json.Unmarshal(m.Derivatives, &derivatives)
+	}
+	return derivatives
+}
+
+// IsImage reports whether the media is an image
+func (m *Media) IsImage() bool {
+	return strings.HasPrefix(m.MimeType, "image/")
+}
+
+// Upload session kinds and statuses
+const (
+	UploadKindMedia = "media"
+	UploadKindVideo = "video"
+
+	UploadStatusUploading = "uploading"
+	UploadStatusCompleted = "completed"
+	UploadStatusAborted   = "aborted"
+	UploadStatusExpired   = "expired"
+)
+
+// UploadSession is a resumable upload. Chunks are written in order at Offset and stored as
+// parts of a multipart upload; the Media or Video record is created when the last one arrives.
+type UploadSession struct {
+	ID        string `gorm:"primaryKey;size:36" json:"id"`
+	UserID    uint   `gorm:"not null;index" json:"user_id"`
+	Kind      string `gorm:"size:10;not null" json:"kind"`
+	Status    string `gorm:"size:20;not null;index;default:'uploading'" json:"status"`
+	FileName  string `gorm:"size:255;not null" json:"file_name"`
+	MimeType  string `gorm:"size:100" json:"mime_type"`
+	Size      int64  `gorm:"not null" json:"size"`
+	Offset    int64  `gorm:"column:upload_offset;not null;default:0" json:"offset"`
+	ChunkSize int64  `gorm:"not null" json:"chunk_size"`
+	// Checksum is the expected SHA-256 of the whole file in hex, verified on completion
+	Checksum string `gorm:"size:64" json:"checksum,omitempty"`
+
+	StorageKey      string         `gorm:"size:500;not null" json:"-"`
+	StorageUploadID string         `gorm:"size:1024" json:"-"`
+	Parts           datatypes.JSON `gorm:"type:json" json:"-"` // JSON array of UploadPart
+	HashState       []byte         `json:"-"`
+	Metadata        datatypes.JSON `gorm:"type:json" json:"metadata,omitempty" swaggertype:"object"` // UploadMetadata
+
+	// ChunkClaim identifies the request storing the next chunk, so two requests for the same
+	// offset cannot both upload its part
+	ChunkClaim     string     `gorm:"size:36" json:"-"`
+	ChunkClaimedAt *time.Time `json:"-"`
+
+	MediaID     *uint      `json:"media_id,omitempty"`
+	VideoID     *uint      `json:"video_id,omitempty"`
+	ExpiresAt   time.Time  `gorm:"not null;index" json:"expires_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// UploadPart is a stored chunk of an upload session
+type UploadPart struct {
+	Number int    `json:"number"`
+	ETag   string `json:"etag"`
+}
+
+// UploadMetadata describes the record created when an upload completes. Title, description,
+// category, tags and visibility apply to videos; alt text and caption to media.
+type UploadMetadata struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	CategoryID  *uint  `json:"category_id,omitempty"`
+	Tags        string `json:"tags,omitempty"`
+	IsPublic    *bool  `json:"is_public,omitempty"`
+	AltText     string `json:"alt_text,omitempty"`
+	Caption     string `json:"caption,omitempty"`
+}
+
+// PartList returns the parts uploaded so far
+func (u *UploadSession) PartList() []UploadPart {
+	var parts []UploadPart
+	if len(u.Parts) > 0 {
+		_ = json.Unmarshal(u.Parts, &parts)
+	}
+	return parts
+}
+
+// MetadataValue returns the metadata of the upload
+func (u *UploadSession) MetadataValue() UploadMetadata {
+	var metadata UploadMetadata
+	if len(u.Metadata) > 0 {
+		_ = json.Unmarshal(u.Metadata, &metadata)
+	}
+	return metadata
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -60,6 +61,19 @@ func (u *User) ValidateStatus() bool {
 	return allowedStatuses[u.Status]
 }
 
+// IsSuspended reports whether the account was suspended or banned by moderators
+func (u *User) IsSuspended() bool {
+	return u.Status == "suspended" || u.Status == "banned"
+}
+
+// DisplayName returns the user's full name, or their username when no name is set
+func (u *User) DisplayName() string {
+	if name := strings.TrimSpace(u.FirstName + " " + u.LastName); name != "" {
+		return name
+	}
+	return u.Username
+}
+
 // UserProfile represents a public user profile
 type UserProfile struct {
 	ID        uint      `json:"id"`
@@ -14,11 +14,16 @@ type Video struct {
 
 	// File information
 	VideoURL     string `json:"video_url" gorm:"size:500;not null"`
+	StorageKey   string `json:"-" gorm:"size:500"` // Key of the original in storage, empty for external URLs
 	ThumbnailURL string `json:"thumbnail_url" gorm:"size:500"`
 	Duration     int    `json:"duration"` // Duration in seconds
 	FileSize     int64  `json:"file_size"`
 	Resolution   string `json:"resolution" gorm:"size:20"` // e.g., "1080x1920"
 
+	// Adaptive streaming, set once the HLS ladder has been packaged
+	StreamURL  string           `json:"stream_url,omitempty" gorm:"size:500"` // HLS master playlist
+	Renditions []VideoRendition `json:"renditions,omitempty" gorm:"foreignKey:VideoID"`
+
 	// Content metadata
 	CategoryID *uint    `json:"category_id" gorm:"index"`
 	Category   Category `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
@@ -196,6 +201,25 @@ type VideoProcessingJob struct {
 	UpdatedAt   time.Time  `json:"updated_at"`
 }
 
+// VideoRendition is one step of a video's HLS ladder
+type VideoRendition struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	VideoID          uint      `json:"video_id" gorm:"not null;uniqueIndex:idx_video_rendition"`
+	Name             string    `json:"name" gorm:"size:20;not null;uniqueIndex:idx_video_rendition"` // e.g. "720p"
+	Width            int       `json:"width"`
+	Height           int       `json:"height"`
+	Bandwidth        int       `json:"bandwidth"`         // Peak bits per second
+	AverageBandwidth int       `json:"average_bandwidth"` // Bits per second
+	VideoBitrate     int       `json:"video_bitrate"`     // kbit/s
+	AudioBitrate     int       `json:"audio_bitrate"`     // kbit/s, 0 without audio
+	Codecs           string    `json:"codecs" gorm:"size:100"`
+	PlaylistURL      string    `json:"playlist_url" gorm:"size:500"`
+	PlaylistKey      string    `json:"-" gorm:"size:500"`
+	SegmentCount     int       `json:"segment_count"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
 // Table names for GORM
 func (Video) TableName() string {
 	return "videos"
@@ -228,3 +252,7 @@ func (VideoPlaylistItem) TableName() string {
 func (VideoProcessingJob) TableName() string {
 	return "video_processing_jobs"
 }
+
+func (VideoRendition) TableName() string {
+	return "video_renditions"
+}
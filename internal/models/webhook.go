@@ -0,0 +1,101 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Webhook event types
+const (
+	WebhookEventArticlePublished     = "article.published"
+	WebhookEventArticleUpdated       = "article.updated"
+	WebhookEventCommentCreated       = "comment.created"
+	WebhookEventBreakingNewsCreated  = "breaking_news.created"
+	WebhookEventVideoProcessed       = "video.processed"
+	WebhookEventTranslationCompleted = "translation.completed"
+)
+
+// WebhookEvents lists every event a webhook endpoint can subscribe to
+var WebhookEvents = []string{
+	WebhookEventArticlePublished,
+	WebhookEventArticleUpdated,
+	WebhookEventCommentCreated,
+	WebhookEventBreakingNewsCreated,
+	WebhookEventVideoProcessed,
+	WebhookEventTranslationCompleted,
+}
+
+// IsValidWebhookEvent reports whether event is a known webhook event
+func IsValidWebhookEvent(event string) bool {
+	for _, e := range WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEndpoint is an external URL that receives signed event notifications
+type WebhookEndpoint struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Name        string         `gorm:"size:100;not null" json:"name"`
+	URL         string         `gorm:"size:500;not null" json:"url"`
+	Secret      string         `gorm:"size:100;not null" json:"-"`
+	Events      datatypes.JSON `gorm:"type:json" json:"events" swaggertype:"array,string"` // JSON array of event types
+	Description string         `gorm:"type:text" json:"description,omitempty"`
+	IsActive    bool           `gorm:"default:true" json:"is_active"`
+	CreatedBy   uint           `gorm:"index" json:"created_by"`
+	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// EventList returns the events the endpoint is subscribed to
+func (e *WebhookEndpoint) EventList() []string {
+	var events []string
+	if len(e.Events) > 0 {
+		_ = json.Unmarshal(e.Events, &events)
+	}
+	return events
+}
+
+// Subscribes reports whether the endpoint should receive event
+func (e *WebhookEndpoint) Subscribes(event string) bool {
+	for _, subscribed := range e.EventList() {
+		if subscribed == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one attempt sequence to deliver an event to an endpoint
+type WebhookDelivery struct {
+	ID           uint           `gorm:"primaryKey" json:"id"`
+	EndpointID   uint           `gorm:"not null;index" json:"endpoint_id"`
+	EventID      string         `gorm:"size:64;not null;index" json:"event_id"`
+	Event        string         `gorm:"size:50;not null;index" json:"event"`
+	Payload      datatypes.JSON `gorm:"type:json" json:"payload" swaggertype:"object"`
+	Status       string         `gorm:"size:20;not null;default:'pending';index" json:"status"` // pending, queued, retrying, delivered, failed
+	Attempts     int            `gorm:"default:0" json:"attempts"`
+	ResponseCode int            `json:"response_code,omitempty"`
+	ResponseBody string         `gorm:"type:text" json:"response_body,omitempty"`
+	LastError    string         `gorm:"type:text" json:"last_error,omitempty"`
+	DurationMs   int64          `json:"duration_ms,omitempty"`
+	NextRetryAt  *time.Time     `gorm:"index" json:"next_retry_at,omitempty"`
+	DeliveredAt  *time.Time     `json:"delivered_at,omitempty"`
+	RedeliveryOf *uint          `gorm:"index" json:"redelivery_of,omitempty"`
+	CreatedAt    time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relations
+	Endpoint WebhookEndpoint `gorm:"foreignKey:EndpointID" json:"-"`
+}
+
+// IsFinal reports whether the delivery has reached a terminal state
+func (d *WebhookDelivery) IsFinal() bool {
+	return d.Status == "delivered" || d.Status == "failed"
+}
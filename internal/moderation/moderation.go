@@ -0,0 +1,148 @@
+// Package moderation decides what happens to a new comment under a moderation policy: publish
+// it, hold it for a moderator, or act on an AI screening verdict. It has no database access, so
+// services load the policy, the author's history and the AI verdict and this package only makes
+// the decision.
+package moderation
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Modes of a moderation policy
+const (
+	ModeAutoApprove = "auto_approve" // Comments are published immediately
+	ModePreModerate = "pre_moderate" // Every comment waits for a moderator
+	ModeAIScreen    = "ai_screen"    // Confident AI verdicts are applied, the rest wait for a moderator
+)
+
+// Comment statuses produced by a decision
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+	StatusSpam     = "spam"
+)
+
+// Trust score weights. A spam verdict outweighs many good comments.
+const (
+	approvedWeight = 1
+	rejectedWeight = -3
+	spamWeight     = -10
+)
+
+// Policy is the moderation policy that applies to a comment
+type Policy struct {
+	Mode string
+	// ApproveThreshold is the AI confidence needed to publish a comment the AI approves
+	ApproveThreshold float64
+	// RejectThreshold is the AI confidence needed to reject a comment the AI flags
+	RejectThreshold float64
+	// TrustThreshold is the trust score from which comments skip the queue; 0 disables it
+	TrustThreshold int
+	// Strict asks the AI to screen more strictly
+	Strict bool
+}
+
+// Validate checks the mode and thresholds of a policy
+func (p Policy) Validate() error {
+	switch p.Mode {
+	case ModeAutoApprove, ModePreModerate, ModeAIScreen:
+	default:
+		return fmt.Errorf("unknown moderation mode %q", p.Mode)
+	}
+	if p.ApproveThreshold < 0 || p.ApproveThreshold > 1 || p.RejectThreshold < 0 || p.RejectThreshold > 1 {
+		return errors.New("thresholds must be between 0 and 1")
+	}
+	if p.TrustThreshold < 0 {
+		return errors.New("trust threshold cannot be negative")
+	}
+	return nil
+}
+
+// Verdict is the AI screening result of a comment
+type Verdict struct {
+	Approved   bool
+	Confidence float64
+	// Spam is set when spam is the most likely flagged category
+	Spam bool
+}
+
+// Decision is the status given to a new comment and why
+type Decision struct {
+	Status string
+	Reason string
+	// Screened is set when the status comes from the AI verdict
+	Screened bool
+}
+
+// Queued reports whether the comment waits for a moderator
+func (d Decision) Queued() bool {
+	return d.Status == StatusPending
+}
+
+// TrustScore scores an author from their moderated comments: approvals raise it, rejections and
+// spam lower it
+func TrustScore(approved, rejected, spam int) int {
+	return approved*approvedWeight + rejected*rejectedWeight + spam*spamWeight
+}
+
+// Trusted reports whether an author with the given trust score skips the queue
+func (p Policy) Trusted(score int) bool {
+	return p.TrustThreshold > 0 && score >= p.TrustThreshold
+}
+
+// NeedsScreening reports whether Decide needs an AI verdict for an author with the given trust
+// score, so the AI is only called when its answer is used
+func (p Policy) NeedsScreening(score int) bool {
+	return p.Mode == ModeAIScreen && !p.Trusted(score)
+}
+
+// Decide returns the status of a new comment. Trusted authors are published under every mode.
+// Under AI screening a nil verdict, meaning the AI was unavailable, or a verdict below the
+// thresholds sends the comment to the queue.
+func Decide(p Policy, trust int, verdict *Verdict) Decision {
+	if p.Mode != ModeAutoApprove && p.Trusted(trust) {
+		return Decision{Status: StatusApproved, Reason: "trusted commenter"}
+	}
+
+	switch p.Mode {
+	case ModeAutoApprove:
+		return Decision{Status: StatusApproved}
+	case ModeAIScreen:
+		if verdict == nil {
+			return Decision{Status: StatusPending, Reason: "AI screening unavailable"}
+		}
+		if verdict.Approved && verdict.Confidence >= p.ApproveThreshold {
+			return Decision{Status: StatusApproved, Reason: "approved by AI screening", Screened: true}
+		}
+		if !verdict.Approved && verdict.Confidence >= p.RejectThreshold {
+			if verdict.Spam {
+				return Decision{Status: StatusSpam, Reason: "flagged as spam by AI screening", Screened: true}
+			}
+			return Decision{Status: StatusRejected, Reason: "rejected by AI screening", Screened: true}
+		}
+		return Decision{Status: StatusPending, Reason: "AI screening inconclusive", Screened: true}
+	default:
+		return Decision{Status: StatusPending, Reason: "awaiting moderation"}
+	}
+}
+
+// strictness orders modes from the most permissive
+var strictness = map[string]int{
+	ModeAutoApprove: 0,
+	ModeAIScreen:    1,
+	ModePreModerate: 2,
+}
+
+// Strictest returns the strictest of several policies, used when an article is in categories
+// with different policies. Policies of equal strictness keep their order. ok is false when
+// policies is empty.
+func Strictest(policies []Policy) (policy Policy, ok bool) {
+	for i, candidate := range policies {
+		if i == 0 || strictness[candidate.Mode] > strictness[policy.Mode] {
+			policy = candidate
+		}
+	}
+	return policy, len(policies) > 0
+}
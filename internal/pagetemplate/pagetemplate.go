@@ -0,0 +1,218 @@
+// Package pagetemplate reads and writes the block structure of page templates. A structure is a
+// tree of blocks; creating a page from a template turns the tree into page content blocks, and
+// saving a page as a template turns its blocks back into a tree. Templates seeded before the
+// block format describe their sections as {type, component, props}; those are read as blocks.
+package pagetemplate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"news/internal/json"
+	"news/internal/models"
+)
+
+// Limits on the size of a template
+const (
+	MaxDepth  = 8
+	MaxBlocks = 500
+)
+
+var (
+	// ErrEmpty is returned when a template has no blocks
+	ErrEmpty = errors.New("block structure has no blocks")
+	// ErrInvalid is returned when a block structure is not valid JSON
+	ErrInvalid = errors.New("block structure is not valid JSON")
+)
+
+// Structure is the block structure of a template
+type Structure struct {
+	Blocks []Block                `json:"blocks"`
+	Layout string                 `json:"layout,omitempty"`
+	Meta   map[string]interface{} `json:"meta,omitempty"`
+	Legacy []section              `json:"sections,omitempty"`
+}
+
+// Block is one block of a template; containers hold their child blocks in order
+type Block struct {
+	BlockType      string                 `json:"block_type"`
+	Content        string                 `json:"content,omitempty"`
+	Settings       map[string]interface{} `json:"settings,omitempty"`
+	Styles         map[string]interface{} `json:"styles,omitempty"`
+	IsVisible      *bool                  `json:"is_visible,omitempty"`
+	IsContainer    bool                   `json:"is_container,omitempty"`
+	ContainerType  string                 `json:"container_type,omitempty"`
+	GridSettings   map[string]interface{} `json:"grid_settings,omitempty"`
+	ResponsiveData map[string]interface{} `json:"responsive_data,omitempty"`
+	Children       []Block                `json:"children,omitempty"`
+}
+
+// section is a block of a template written in the seeded {type, component, props} format
+type section struct {
+	Type      string                 `json:"type"`
+	Component string                 `json:"component,omitempty"`
+	Props     map[string]interface{} `json:"props,omitempty"`
+	Children  []section              `json:"children,omitempty"`
+}
+
+// Visible reports whether the block is shown; blocks are visible unless they say otherwise
+func (b Block) Visible() bool {
+	return b.IsVisible == nil || *b.IsVisible
+}
+
+// Parse reads a block structure, converting seeded sections into blocks
+func Parse(raw []byte) (*Structure, error) {
+	if len(raw) == 0 {
+		return nil, ErrEmpty
+	}
+	var structure Structure
+	if err := json.Unmarshal(raw, &structure); err != nil {
+		return nil, ErrInvalid
+	}
+	if len(structure.Blocks) == 0 && len(structure.Legacy) > 0 {
+		structure.Blocks = fromSections(structure.Legacy)
+	}
+	structure.Legacy = nil
+	return &structure, nil
+}
+
+// Validate checks that a structure has blocks of known types, that only containers have
+// children and that it stays within MaxDepth and MaxBlocks
+func (s *Structure) Validate() error {
+	if len(s.Blocks) == 0 {
+		return ErrEmpty
+	}
+	if count := Count(s.Blocks); count > MaxBlocks {
+		return fmt.Errorf("template has %d blocks, at most %d are allowed", count, MaxBlocks)
+	}
+	return validate(s.Blocks, 1)
+}
+
+// JSON writes the structure in the block format
+func (s *Structure) JSON() ([]byte, error) {
+	return json.Marshal(s)
+}
+
+func validate(blocks []Block, depth int) error {
+	if depth > MaxDepth {
+		return fmt.Errorf("blocks are nested more than %d levels deep", MaxDepth)
+	}
+	for i, block := range blocks {
+		model := models.PageContentBlock{BlockType: block.BlockType, IsContainer: block.IsContainer}
+		if !model.ValidateBlockType() {
+			return fmt.Errorf("block %d at depth %d has invalid block type %q", i+1, depth, block.BlockType)
+		}
+		if len(block.Children) == 0 {
+			continue
+		}
+		if !model.IsContainerBlock() {
+			return fmt.Errorf("block %d at depth %d is a %s, which cannot contain blocks", i+1, depth, block.BlockType)
+		}
+		if err := validate(block.Children, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Count returns the number of blocks in a tree
+func Count(blocks []Block) int {
+	count := len(blocks)
+	for _, block := range blocks {
+		count += Count(block.Children)
+	}
+	return count
+}
+
+// FromBlocks builds the block tree of a page from its content blocks. Blocks are ordered by
+// position within their container; a block whose container is not in the list is put at the
+// top level.
+func FromBlocks(blocks []models.PageContentBlock) []Block {
+	known := make(map[uint]bool, len(blocks))
+	for _, block := range blocks {
+		known[block.ID] = true
+	}
+	children := make(map[uint][]models.PageContentBlock)
+	var roots []models.PageContentBlock
+	for _, block := range blocks {
+		if block.ContainerID != nil && known[*block.ContainerID] && *block.ContainerID != block.ID {
+			children[*block.ContainerID] = append(children[*block.ContainerID], block)
+		} else {
+			roots = append(roots, block)
+		}
+	}
+	return buildTree(roots, children, make(map[uint]bool))
+}
+
+func buildTree(blocks []models.PageContentBlock, children map[uint][]models.PageContentBlock, seen map[uint]bool) []Block {
+	sort.SliceStable(blocks, func(i, j int) bool { return blocks[i].Position < blocks[j].Position })
+	tree := make([]Block, 0, len(blocks))
+	for _, block := range blocks {
+		if seen[block.ID] {
+			continue
+		}
+		seen[block.ID] = true
+		visible := block.IsVisible
+		node := Block{
+			BlockType:      block.BlockType,
+			Content:        block.Content,
+			Settings:       decode(block.Settings),
+			Styles:         decode(block.Styles),
+			IsContainer:    block.IsContainer,
+			ContainerType:  block.ContainerType,
+			GridSettings:   decode(block.GridSettings),
+			ResponsiveData: decode(block.ResponsiveData),
+		}
+		if !visible {
+			node.IsVisible = &visible
+		}
+		if kids := children[block.ID]; len(kids) > 0 {
+			node.Children = buildTree(kids, children, seen)
+		}
+		tree = append(tree, node)
+	}
+	return tree
+}
+
+func decode(raw []byte) map[string]interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(raw, &values); err != nil || len(values) == 0 {
+		return nil
+	}
+	return values
+}
+
+// fromSections reads seeded sections as blocks. A section whose type is a block type keeps it;
+// any other section becomes a "section" container. The component and the original type are
+// kept in the block settings next to the props.
+func fromSections(sections []section) []Block {
+	blocks := make([]Block, 0, len(sections))
+	for _, s := range sections {
+		settings := make(map[string]interface{}, len(s.Props)+2)
+		for key, value := range s.Props {
+			settings[key] = value
+		}
+		if s.Component != "" {
+			settings["component"] = s.Component
+		}
+
+		block := Block{BlockType: s.Type, Settings: settings}
+		model := models.PageContentBlock{BlockType: s.Type}
+		if !model.ValidateBlockType() {
+			block.BlockType = "section"
+			block.IsContainer = true
+			block.ContainerType = "section"
+			settings["section_type"] = s.Type
+		}
+		if len(s.Children) > 0 {
+			block.Children = fromSections(s.Children)
+			block.IsContainer = true
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
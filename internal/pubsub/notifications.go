@@ -2,11 +2,14 @@ package pubsub
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"news/internal/cache"
 	"news/internal/json"
 	"news/internal/models"
+	"os"
+	"sort"
 	"sync"
 	"time"
 
@@ -31,8 +34,12 @@ type NotificationHub struct {
 	// Translation service for localized messages
 	translationService TranslationService
 
-	// Connected WebSocket clients with their language preferences
-	clients map[uint]*ClientConnection
+	// Connected WebSocket clients, indexed by user and by subscribed topic. A user can have
+	// several connections; anonymous connections have no user.
+	mu      sync.RWMutex
+	clients map[*ClientConnection]bool
+	users   map[uint]map[*ClientConnection]bool
+	topics  map[string]map[*ClientConnection]bool
 
 	// Channels for managing connections
 	register   chan *ClientConnection
@@ -42,6 +49,10 @@ type NotificationHub struct {
 	// Pub/sub subscription
 	pubsub *redis.PubSub
 
+	// Identifies this replica's connections in topic presence
+	instanceID string
+	nextID     uint64
+
 	// Shutdown control
 	done chan struct{}
 
@@ -49,17 +60,86 @@ type NotificationHub struct {
 	closeOnce sync.Once
 }
 
-// ClientConnection represents a connected user
+// ClientConnection represents one WebSocket or Server-Sent Events connection. UserID is 0 for
+// anonymous, read-only connections, which only receive the topics they subscribe to.
 type ClientConnection struct {
-	UserID   uint
-	Conn     *websocket.Conn
-	Language string // User's preferred language
+	UserID    uint
+	Conn      *websocket.Conn // Nil for Server-Sent Events clients
+	Language  string          // User's preferred language
+	WatchJobs bool            // Receives queue job progress (admins only)
+
+	// Events receives the messages of Server-Sent Events clients. It is closed when the hub
+	// drops the client.
+	Events chan NotificationMessage
+
+	id      string          // Identifies the connection in topic presence
+	topics  map[string]bool // Guarded by the hub's mu
+	writeMu sync.Mutex      // Serializes writes from the hub and the connection's read loop
+	closed  bool            // Guarded by writeMu
+
+	presenceMu      sync.Mutex       // Guards presenceQueue and presenceRunning
+	presenceQueue   []presenceUpdate // Presence changes not yet written to Redis, oldest first
+	presenceRunning bool             // Whether a goroutine is writing presenceQueue
+}
+
+// NewEventStreamClient returns a client that receives messages on its Events channel instead of
+// a WebSocket, for Server-Sent Events
+func NewEventStreamClient(userID uint, language string) *ClientConnection {
+	return &ClientConnection{
+		UserID:   userID,
+		Language: language,
+		Events:   make(chan NotificationMessage, eventStreamBuffer),
+	}
+}
+
+// Anonymous reports whether the connection has no authenticated user
+func (c *ClientConnection) Anonymous() bool {
+	return c.UserID == 0
+}
+
+// send writes a message to the connection. Event stream clients that fall a full buffer behind
+// are dropped rather than blocking the hub.
+func (c *ClientConnection) send(message NotificationMessage) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closed {
+		return errClientClosed
+	}
+	if c.Conn == nil {
+		select {
+		case c.Events <- message:
+			return nil
+		default:
+			return errClientTooSlow
+		}
+	}
+	if err := c.Conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		return err
+	}
+	return c.Conn.WriteJSON(message)
+}
+
+// close closes the WebSocket or the Events channel once
+func (c *ClientConnection) close() error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	if c.Conn == nil {
+		close(c.Events)
+		return nil
+	}
+	return c.Conn.Close()
 }
 
 // NotificationMessage represents a message to be sent via pub/sub
 type NotificationMessage struct {
 	Type      string      `json:"type"`
-	UserID    uint        `json:"user_id,omitempty"` // For targeted notifications
+	UserID    uint        `json:"user_id,omitempty"`  // For targeted notifications
+	Topic     string      `json:"topic,omitempty"`    // For topic subscribers
+	EventID   uint        `json:"event_id,omitempty"` // For resumable streams such as live blogs
 	Data      interface{} `json:"data"`
 	Timestamp time.Time   `json:"timestamp"`
 	Channel   string      `json:"channel"`
@@ -71,6 +151,9 @@ const (
 	ChannelBreakingNews = "breaking_news"
 	ChannelSystemAlert  = "system_alert"
 
+	// Queue job progress - only delivered to admin clients watching jobs
+	ChannelQueueJobs = "queue_jobs"
+
 	// User-specific channels - format: "user:{user_id}"
 	ChannelUserNotification = "user_notification"
 	ChannelUserComment      = "user_comment"
@@ -82,6 +165,19 @@ const (
 	ChannelNewsComment = "news_comment"
 )
 
+const (
+	// writeTimeout bounds how long a slow client can hold up delivery
+	writeTimeout = 10 * time.Second
+
+	// eventStreamBuffer is how many messages an event stream client can fall behind
+	eventStreamBuffer = 64
+)
+
+var (
+	errClientClosed  = errors.New("client connection closed")
+	errClientTooSlow = errors.New("client is not keeping up")
+)
+
 // Global notification hub instance
 var globalHub *NotificationHub
 
@@ -98,14 +194,26 @@ func InitNotificationHub(translationService TranslationService) error {
 	channels := []string{
 		ChannelBreakingNews,
 		ChannelSystemAlert,
-		// We'll add more specific channels as needed
+		ChannelQueueJobs,
 	}
 
 	globalHub.pubsub = globalHub.redisClient.Subscribe(globalHub.ctx, channels...)
 
-	// Start the hub and Redis listener
+	// User and topic messages are published per user or topic, so every replica listens to the
+	// patterns and delivers to whichever of its connections match
+	patterns := []string{
+		"user:*",
+		ChannelUserNotification + ":*",
+		TopicChannelPrefix + "*",
+	}
+	if err := globalHub.pubsub.PSubscribe(globalHub.ctx, patterns...); err != nil {
+		return fmt.Errorf("failed to subscribe to notification patterns: %w", err)
+	}
+
+	// Start the hub, Redis listener and presence heartbeat
 	go globalHub.Run()
 	go globalHub.listenToRedis()
+	go globalHub.runPresence()
 
 	log.Println("Redis pub/sub notification hub initialized successfully")
 	return nil
@@ -119,15 +227,19 @@ func GetNotificationHub() *NotificationHub {
 // NewNotificationHub creates a new NotificationHub instance
 func NewNotificationHub(redisClient *redis.Client, translationService TranslationService) *NotificationHub {
 	ctx, cancel := context.WithCancel(context.Background())
+	hostname, _ := os.Hostname()
 	return &NotificationHub{
 		redisClient:        redisClient,
 		ctx:                ctx,
 		cancel:             cancel,
 		translationService: translationService,
-		clients:            make(map[uint]*ClientConnection),
+		clients:            make(map[*ClientConnection]bool),
+		users:              make(map[uint]map[*ClientConnection]bool),
+		topics:             make(map[string]map[*ClientConnection]bool),
 		register:           make(chan *ClientConnection),
 		unregister:         make(chan *ClientConnection),
 		broadcast:          make(chan NotificationMessage),
+		instanceID:         fmt.Sprintf("%s:%d:%d", hostname, os.Getpid(), time.Now().UnixNano()),
 		done:               make(chan struct{}),
 	}
 }
@@ -139,35 +251,29 @@ func (h *NotificationHub) Run() {
 	for {
 		select {
 		case client := <-h.register:
-			h.clients[client.UserID] = client
-			log.Printf("📱 User %d connected to notification hub (lang: %s)", client.UserID, client.Language)
+			h.addClient(client)
+			log.Printf("📱 User %d connected to notification hub (lang: %s, anonymous: %t)", client.UserID, client.Language, client.Anonymous())
 
 			// Send welcome message
 			welcome := NotificationMessage{
-				Type:      "welcome",
-				Data:      map[string]string{"message": "Connected to notifications"},
+				Type: "welcome",
+				Data: map[string]interface{}{
+					"message":   "Connected to notifications",
+					"anonymous": client.Anonymous(),
+					"topics":    h.clientTopics(client),
+				},
 				Timestamp: time.Now(),
 				Channel:   "system",
 			}
-			h.sendToClient(client.UserID, welcome)
+			h.deliver([]*ClientConnection{client}, welcome)
 
 		case client := <-h.unregister:
-			if _, ok := h.clients[client.UserID]; ok {
-				delete(h.clients, client.UserID)
-				if err := client.Conn.Close(); err != nil {
-					log.Printf("Warning: Error closing client connection for user %d: %v", client.UserID, err)
-				}
+			if h.removeClient(client) {
 				log.Printf("📱 User %d disconnected from notification hub", client.UserID)
 			}
 
 		case message := <-h.broadcast:
-			if message.UserID != 0 {
-				// Send to specific user
-				h.sendToClient(message.UserID, message)
-			} else {
-				// Broadcast to all connected clients
-				h.broadcastToAll(message)
-			}
+			h.deliver(h.recipients(message), message)
 
 		case <-h.ctx.Done():
 			log.Println("🔴 Notification hub Run() shutting down...")
@@ -207,65 +313,275 @@ func (h *NotificationHub) listenToRedis() {
 
 // RegisterClient registers a new WebSocket client
 func (h *NotificationHub) RegisterClient(userID uint, conn *websocket.Conn, language string) {
-	select {
-	case h.register <- &ClientConnection{
+	h.Register(&ClientConnection{
 		UserID:   userID,
 		Conn:     conn,
 		Language: language,
-	}:
+	})
+}
+
+// Register registers a new WebSocket client with its connection options
+func (h *NotificationHub) Register(client *ClientConnection) {
+	select {
+	case h.register <- client:
 		// Successfully sent registration
 	case <-h.ctx.Done():
-		log.Printf("⚠️ Cannot register client %d: hub is shutting down", userID)
-		if err := conn.Close(); err != nil {
+		log.Printf("⚠️ Cannot register client %d: hub is shutting down", client.UserID)
+		if err := client.close(); err != nil {
 			log.Printf("Warning: Error closing connection during shutdown: %v", err)
 		}
 	}
 }
 
-// UnregisterClient removes a WebSocket client
+// Unregister removes one WebSocket connection
+func (h *NotificationHub) Unregister(client *ClientConnection) {
+	select {
+	case h.unregister <- client:
+		// Successfully sent unregistration
+	case <-h.ctx.Done():
+		log.Printf("⚠️ Cannot unregister client %d: hub is shutting down", client.UserID)
+		if err := client.close(); err != nil {
+			log.Printf("Warning: Error closing connection during unregister: %v", err)
+		}
+	}
+}
+
+// UnregisterClient removes every WebSocket connection of a user
 func (h *NotificationHub) UnregisterClient(userID uint) {
-	if clientConn, exists := h.clients[userID]; exists {
-		select {
-		case h.unregister <- &ClientConnection{
-			UserID: userID,
-			Conn:   clientConn.Conn,
-		}:
-			// Successfully sent unregistration
-		case <-h.ctx.Done():
-			log.Printf("⚠️ Cannot unregister client %d: hub is shutting down", userID)
-			if err := clientConn.Conn.Close(); err != nil {
-				log.Printf("Warning: Error closing connection during unregister: %v", err)
+	for _, client := range h.userConnections(userID) {
+		h.Unregister(client)
+	}
+}
+
+// Subscribe adds topics to a connection. Topics must already be validated with ParseTopic.
+func (h *NotificationHub) Subscribe(client *ClientConnection, topics ...string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	added := 0
+	for _, topic := range topics {
+		if !client.topics[topic] {
+			added++
+		}
+	}
+	if len(client.topics)+added > MaxTopicsPerConnection {
+		return fmt.Errorf("%w: at most %d topics per connection", ErrInvalidTopic, MaxTopicsPerConnection)
+	}
+
+	if client.topics == nil {
+		client.topics = make(map[string]bool)
+	}
+	for _, topic := range topics {
+		client.topics[topic] = true
+		// Connections still being registered are indexed by addClient
+		if !h.clients[client] {
+			continue
+		}
+		if h.topics[topic] == nil {
+			h.topics[topic] = make(map[*ClientConnection]bool)
+		}
+		h.topics[topic][client] = true
+	}
+	if h.clients[client] {
+		h.setPresence(client, topics, true)
+	}
+	return nil
+}
+
+// Unsubscribe removes topics from a connection
+func (h *NotificationHub) Unsubscribe(client *ClientConnection, topics ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, topic := range topics {
+		delete(client.topics, topic)
+		h.removeFromTopic(topic, client)
+	}
+	h.setPresence(client, topics, false)
+}
+
+// HandleClientFrame applies a frame sent by a client and acknowledges it, or replies with an
+// error. Anonymous connections are read-only: they can manage subscriptions but nothing else.
+func (h *NotificationHub) HandleClientFrame(client *ClientConnection, payload []byte) {
+	frame, topics, err := ParseClientFrame(payload)
+	if err == nil {
+		switch frame.Action {
+		case ActionSubscribe:
+			err = h.Subscribe(client, topics...)
+		case ActionUnsubscribe:
+			h.Unsubscribe(client, topics...)
+		}
+	}
+
+	reply := NotificationMessage{
+		Timestamp: time.Now(),
+		Channel:   "system",
+	}
+	switch {
+	case err != nil:
+		reply.Type = "error"
+		reply.Data = map[string]interface{}{"error": err.Error()}
+	case frame.Action == ActionPing:
+		reply.Type = "pong"
+	default:
+		reply.Type = frame.Action + "d" // "subscribed" or "unsubscribed"
+		reply.Data = map[string]interface{}{
+			"topics":     topics,
+			"subscribed": h.clientTopics(client),
+		}
+	}
+	h.deliver([]*ClientConnection{client}, reply)
+}
+
+// addClient indexes a connection, including topics it subscribed to before registration
+func (h *NotificationHub) addClient(client *ClientConnection) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.nextID++
+	client.id = fmt.Sprintf("%s:%d", h.instanceID, h.nextID)
+	h.clients[client] = true
+	if !client.Anonymous() {
+		if h.users[client.UserID] == nil {
+			h.users[client.UserID] = make(map[*ClientConnection]bool)
+		}
+		h.users[client.UserID][client] = true
+	}
+	topics := make([]string, 0, len(client.topics))
+	for topic := range client.topics {
+		if h.topics[topic] == nil {
+			h.topics[topic] = make(map[*ClientConnection]bool)
+		}
+		h.topics[topic][client] = true
+		topics = append(topics, topic)
+	}
+	h.setPresence(client, topics, true)
+}
+
+// removeClient drops a connection from every index and closes it. It reports whether the
+// connection was still registered.
+func (h *NotificationHub) removeClient(client *ClientConnection) bool {
+	h.mu.Lock()
+	registered := h.clients[client]
+	if registered {
+		delete(h.clients, client)
+		if conns := h.users[client.UserID]; conns != nil {
+			delete(conns, client)
+			if len(conns) == 0 {
+				delete(h.users, client.UserID)
 			}
 		}
+		topics := make([]string, 0, len(client.topics))
+		for topic := range client.topics {
+			h.removeFromTopic(topic, client)
+			topics = append(topics, topic)
+		}
+		h.setPresence(client, topics, false)
+	}
+	h.mu.Unlock()
+
+	if registered {
+		if err := client.close(); err != nil {
+			log.Printf("Warning: Error closing client connection for user %d: %v", client.UserID, err)
+		}
 	}
+	return registered
 }
 
-// sendToClient sends a message to a specific client
-func (h *NotificationHub) sendToClient(userID uint, message NotificationMessage) {
-	if clientConn, exists := h.clients[userID]; exists {
-		if err := clientConn.Conn.WriteJSON(message); err != nil {
-			log.Printf("❌ Error sending message to user %d: %v", userID, err)
-			// Remove broken connection
-			delete(h.clients, userID)
-			if closeErr := clientConn.Conn.Close(); closeErr != nil {
-				log.Printf("Warning: Error closing broken connection: %v", closeErr)
+// removeFromTopic must be called with mu held
+func (h *NotificationHub) removeFromTopic(topic string, client *ClientConnection) {
+	if conns := h.topics[topic]; conns != nil {
+		delete(conns, client)
+		if len(conns) == 0 {
+			delete(h.topics, topic)
+		}
+	}
+}
+
+// recipients returns the connections a message is delivered to:
+//   - queue job progress goes to the connections watching jobs
+//   - topic messages go to the topic's subscribers
+//   - targeted messages go to every connection of the user
+//   - global messages go to authenticated connections and to anonymous connections subscribed
+//     to the channel as a topic, such as breaking_news
+func (h *NotificationHub) recipients(message NotificationMessage) []*ClientConnection {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var recipients []*ClientConnection
+	switch {
+	case message.Channel == ChannelQueueJobs:
+		for client := range h.clients {
+			if client.WatchJobs {
+				recipients = append(recipients, client)
+			}
+		}
+	case message.Topic != "":
+		for client := range h.topics[message.Topic] {
+			recipients = append(recipients, client)
+		}
+	case message.UserID != 0:
+		for client := range h.users[message.UserID] {
+			recipients = append(recipients, client)
+		}
+	default:
+		for client := range h.clients {
+			if !client.Anonymous() || client.topics[message.Channel] {
+				recipients = append(recipients, client)
 			}
 		}
 	}
+	return recipients
 }
 
-// broadcastToAll sends a message to all connected clients
-func (h *NotificationHub) broadcastToAll(message NotificationMessage) {
-	for userID, clientConn := range h.clients {
-		if err := clientConn.Conn.WriteJSON(message); err != nil {
-			log.Printf("❌ Error broadcasting to user %d: %v", userID, err)
+// deliver writes a message to each connection, dropping connections that fail
+func (h *NotificationHub) deliver(clients []*ClientConnection, message NotificationMessage) {
+	for _, client := range clients {
+		if err := client.send(message); err != nil {
+			log.Printf("❌ Error sending message to user %d: %v", client.UserID, err)
 			// Remove broken connection
-			delete(h.clients, userID)
-			if closeErr := clientConn.Conn.Close(); closeErr != nil {
-				log.Printf("Warning: Error closing broken connection: %v", closeErr)
-			}
+			h.removeClient(client)
+		}
+	}
+}
+
+// userConnections returns the connections of a user
+func (h *NotificationHub) userConnections(userID uint) []*ClientConnection {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	clients := make([]*ClientConnection, 0, len(h.users[userID]))
+	for client := range h.users[userID] {
+		clients = append(clients, client)
+	}
+	return clients
+}
+
+// authenticatedConnections returns the connections that belong to a user
+func (h *NotificationHub) authenticatedConnections() []*ClientConnection {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	clients := make([]*ClientConnection, 0, len(h.clients))
+	for client := range h.clients {
+		if !client.Anonymous() {
+			clients = append(clients, client)
 		}
 	}
+	return clients
+}
+
+// clientTopics returns the topics of a connection, sorted
+func (h *NotificationHub) clientTopics(client *ClientConnection) []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	topics := make([]string, 0, len(client.topics))
+	for topic := range client.topics {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
 }
 
 // PublishNotification publishes a notification to Redis
@@ -293,6 +609,12 @@ func PublishNotification(channel string, notification NotificationMessage) error
 	return redisClient.Publish(ctx, channel, data).Err()
 }
 
+// PublishTopic publishes a notification to the subscribers of a topic on every replica
+func PublishTopic(topic string, notification NotificationMessage) error {
+	notification.Topic = topic
+	return PublishNotification(TopicChannelPrefix+topic, notification)
+}
+
 // Convenience functions for different notification types
 
 // PublishBreakingNews publishes a breaking news notification to all users
@@ -323,8 +645,7 @@ func PublishUserNotification(userID uint, notificationType string, data interfac
 
 // PublishCommentNotification publishes a comment notification
 func PublishCommentNotification(newsID uint, comment models.Comment) error {
-	// Notify news author and other commenters
-	channel := fmt.Sprintf("%s:%d", ChannelNewsComment, newsID)
+	// Notify everyone following the article
 	notification := NotificationMessage{
 		Type: "new_comment",
 		Data: map[string]interface{}{
@@ -335,7 +656,7 @@ func PublishCommentNotification(newsID uint, comment models.Comment) error {
 			"created_at": comment.CreatedAt,
 		},
 	}
-	return PublishNotification(channel, notification)
+	return PublishTopic(ArticleTopic(newsID), notification)
 }
 
 // PublishVoteNotification publishes a vote notification
@@ -350,9 +671,26 @@ func PublishVoteNotification(videoID uint, userID uint, voteType string) error {
 		},
 	}
 
-	// Publish to video-specific channel
-	channel := fmt.Sprintf("video_votes:%d", videoID)
-	return PublishNotification(channel, notification)
+	return PublishTopic(VideoTopic(videoID), notification)
+}
+
+// PublishCategoryArticle publishes a newly published article to the subscribers of its category
+func PublishCategoryArticle(categorySlug string, article models.Article) error {
+	notification := NotificationMessage{
+		Type: "article_published",
+		Data: map[string]interface{}{
+			"id":           article.ID,
+			"title":        article.Title,
+			"slug":         article.Slug,
+			"summary":      article.Summary,
+			"image_url":    article.FeaturedImage,
+			"category":     categorySlug,
+			"language":     article.Language,
+			"published_at": article.PublishedAt,
+			"is_breaking":  article.IsBreaking,
+		},
+	}
+	return PublishTopic(CategoryTopic(categorySlug), notification)
 }
 
 // PublishSystemAlert publishes a system-wide alert
@@ -379,7 +717,7 @@ func PublishNewsViewUpdate(newsID uint, viewCount int64) error {
 			"view_count": viewCount,
 		},
 	}
-	return PublishNotification(fmt.Sprintf("news:%d", newsID), notification)
+	return PublishTopic(ArticleTopic(newsID), notification)
 }
 
 // PublishProfileUpdate publishes a profile update notification
@@ -456,6 +794,26 @@ func PublishVideoProcessingUpdate(userID uint, videoID uint, status string, prog
 	return PublishNotification(fmt.Sprintf("user:%d", userID), notification)
 }
 
+// QueueJobUpdate describes a change in the state or progress of a queue job
+type QueueJobUpdate struct {
+	Queue    string `json:"queue"`
+	JobID    string `json:"job_id"`
+	JobType  string `json:"job_type"`
+	Status   string `json:"status"`
+	Progress int    `json:"progress"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error,omitempty"`
+}
+
+// PublishQueueJobUpdate publishes queue job progress to admins watching jobs
+func PublishQueueJobUpdate(update QueueJobUpdate) error {
+	notification := NotificationMessage{
+		Type: "queue_job_update",
+		Data: update,
+	}
+	return PublishNotification(ChannelQueueJobs, notification)
+}
+
 // PublishFollowNotification publishes follow/unfollow notifications
 func PublishFollowNotification(targetUserID uint, followerUserID uint, action string, followerUsername string) error {
 	notification := NotificationMessage{
@@ -523,12 +881,41 @@ func SendBreakingNewsAlert(newsTitle string, categoryName string) {
 	BroadcastLocalizedSystemAlert("notifications.breaking_news.message", templateData)
 }
 
-// GetConnectedUsers returns the number of connected users
+// GetConnectedUsers returns the number of distinct connected users
 func GetConnectedUsers() int {
 	if globalHub == nil {
 		return 0
 	}
-	return len(globalHub.clients)
+	globalHub.mu.RLock()
+	defer globalHub.mu.RUnlock()
+	return len(globalHub.users)
+}
+
+// ConnectionStats counts the open WebSocket connections of this replica
+type ConnectionStats struct {
+	Connections          int `json:"connections"`
+	AnonymousConnections int `json:"anonymous_connections"`
+	Topics               int `json:"topics"`
+}
+
+// GetConnectionStats returns connection and topic counts of this replica
+func GetConnectionStats() ConnectionStats {
+	if globalHub == nil {
+		return ConnectionStats{}
+	}
+	globalHub.mu.RLock()
+	defer globalHub.mu.RUnlock()
+
+	stats := ConnectionStats{
+		Connections: len(globalHub.clients),
+		Topics:      len(globalHub.topics),
+	}
+	for client := range globalHub.clients {
+		if client.Anonymous() {
+			stats.AnonymousConnections++
+		}
+	}
+	return stats
 }
 
 // IsUserConnected checks if a user is currently connected
@@ -536,8 +923,9 @@ func IsUserConnected(userID uint) bool {
 	if globalHub == nil {
 		return false
 	}
-	_, exists := globalHub.clients[userID]
-	return exists
+	globalHub.mu.RLock()
+	defer globalHub.mu.RUnlock()
+	return len(globalHub.users[userID]) > 0
 }
 
 // Close gracefully shuts down the notification hub
@@ -573,14 +961,16 @@ func Close() error {
 		}
 
 		// Close all client connections
-		for userID, clientConn := range globalHub.clients {
-			if clientConn.Conn != nil {
-				if err := clientConn.Conn.Close(); err != nil {
-					log.Printf("Warning: Failed to close WebSocket connection for user %d: %v", userID, err)
-				}
+		globalHub.mu.Lock()
+		for clientConn := range globalHub.clients {
+			if err := clientConn.close(); err != nil {
+				log.Printf("Warning: Failed to close WebSocket connection for user %d: %v", clientConn.UserID, err)
 			}
-			delete(globalHub.clients, userID)
 		}
+		globalHub.clients = make(map[*ClientConnection]bool)
+		globalHub.users = make(map[uint]map[*ClientConnection]bool)
+		globalHub.topics = make(map[string]map[*ClientConnection]bool)
+		globalHub.mu.Unlock()
 
 		// Use a safer channel closing mechanism with defer recovery
 		safeCloseChannel := func(ch interface{}, name string) {
@@ -628,43 +1018,41 @@ func Close() error {
 	return closeErr
 }
 
-// SendToUser sends a message directly to a specific user's WebSocket connection
+// SendToUser sends a message directly to every WebSocket connection of a user on this replica
 func (h *NotificationHub) SendToUser(userID uint, message NotificationMessage) {
 	message.Timestamp = time.Now()
-	h.sendToClient(userID, message)
+	h.deliver(h.userConnections(userID), message)
 }
 
-// SendLocalizedNotification sends a localized notification to a specific user
+// SendLocalizedNotification sends a localized notification to a specific user, in the language
+// of each of their connections
 func (h *NotificationHub) SendLocalizedNotification(userID uint, messageKey string, templateData map[string]interface{}) {
-	// Get user's language preference from connection
-	var userLang string = "en" // default
-	if clientConn, exists := h.clients[userID]; exists {
-		userLang = clientConn.Language
-	}
+	for _, clientConn := range h.userConnections(userID) {
+		userLang := clientConn.Language
+		if userLang == "" {
+			userLang = "en"
+		}
+		localizedMessage := h.translateMessage(messageKey, userLang, templateData)
 
-	// Get translation service instance
-	// Note: We'll need to get the initialized translation service from global state
-	// For now, we'll create a simple fallback
-	localizedMessage := h.translateMessage(messageKey, userLang, templateData)
+		// Send localized notification
+		notification := NotificationMessage{
+			Type: "localized_notification",
+			Data: map[string]interface{}{
+				"message":  localizedMessage,
+				"language": userLang,
+				"key":      messageKey,
+			},
+			Timestamp: time.Now(),
+		}
 
-	// Send localized notification
-	notification := NotificationMessage{
-		Type: "localized_notification",
-		Data: map[string]interface{}{
-			"message":  localizedMessage,
-			"language": userLang,
-			"key":      messageKey,
-		},
-		Timestamp: time.Now(),
+		h.deliver([]*ClientConnection{clientConn}, notification)
 	}
-
-	h.SendToUser(userID, notification)
 }
 
 // BroadcastLocalizedNotification sends a localized notification to all connected users
 func (h *NotificationHub) BroadcastLocalizedNotification(messageKey string, templateData map[string]interface{}) {
 	// Send to each connected user in their preferred language
-	for userID, clientConn := range h.clients {
+	for _, clientConn := range h.authenticatedConnections() {
 		// Localize the message for user's language
 		localizedMessage := h.translateMessage(messageKey, clientConn.Language, templateData)
 
@@ -679,7 +1067,7 @@ func (h *NotificationHub) BroadcastLocalizedNotification(messageKey string, temp
 			Timestamp: time.Now(),
 		}
 
-		h.sendToClient(userID, notification)
+		h.deliver([]*ClientConnection{clientConn}, notification)
 	}
 }
 
@@ -0,0 +1,186 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"news/internal/cache"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Presence counts the connections subscribed to a topic across all replicas. Every replica
+// refreshes its connections in a Redis sorted set per topic, scored by the time of the last
+// refresh, and connections that stop being refreshed age out.
+const (
+	presenceKeyPrefix = "presence:"
+	presenceInterval  = 15 * time.Second
+	presenceTTL       = 3 * presenceInterval
+)
+
+// presenceTopicKinds lists the topic kinds whose subscribers are counted
+var presenceTopicKinds = map[string]bool{
+	TopicLive: true,
+}
+
+func presenceKey(topic string) string {
+	return presenceKeyPrefix + topic
+}
+
+func tracksPresence(topic string) bool {
+	kind, _, _ := strings.Cut(topic, ":")
+	return presenceTopicKinds[kind]
+}
+
+// runPresence refreshes the presence of this replica's connections until the hub shuts down
+func (h *NotificationHub) runPresence() {
+	ticker := time.NewTicker(presenceInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			h.refreshPresence()
+		case <-h.ctx.Done():
+			return
+		}
+	}
+}
+
+// refreshPresence records the connections subscribed to presence-tracked topics
+func (h *NotificationHub) refreshPresence() {
+	members := make(map[string][]string)
+	h.mu.RLock()
+	for topic, clients := range h.topics {
+		if !tracksPresence(topic) {
+			continue
+		}
+		for client := range clients {
+			members[topic] = append(members[topic], client.id)
+		}
+	}
+	h.mu.RUnlock()
+
+	if len(members) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(h.ctx, 5*time.Second)
+	defer cancel()
+
+	score := float64(time.Now().Unix())
+	pipe := h.redisClient.Pipeline()
+	for topic, ids := range members {
+		entries := make([]*redis.Z, 0, len(ids))
+		for _, id := range ids {
+			entries = append(entries, &redis.Z{Score: score, Member: id})
+		}
+		pipe.ZAdd(ctx, presenceKey(topic), entries...)
+		pipe.Expire(ctx, presenceKey(topic), 2*presenceTTL)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Warning: Failed to refresh topic presence: %v", err)
+	}
+}
+
+// CountTopicSubscribers returns how many connections on all replicas are subscribed to a topic.
+// Only topics with presence tracking, such as live streams, are counted.
+func CountTopicSubscribers(ctx context.Context, topic string) (int, error) {
+	if !tracksPresence(topic) {
+		return 0, fmt.Errorf("%w: %q has no presence tracking", ErrInvalidTopic, topic)
+	}
+	if cache.IsTestMode() {
+		return 0, nil
+	}
+	redisClient := cache.GetRedisClient()
+	if redisClient == nil || redisClient.GetClient() == nil {
+		return 0, fmt.Errorf("redis client not available")
+	}
+	client := redisClient.GetClient()
+
+	key := presenceKey(topic)
+	cutoff := strconv.FormatInt(time.Now().Add(-presenceTTL).Unix(), 10)
+	if err := client.ZRemRangeByScore(ctx, key, "-inf", "("+cutoff).Err(); err != nil {
+		return 0, err
+	}
+	count, err := client.ZCard(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// presenceUpdate adds or removes a connection from the presence of topics
+type presenceUpdate struct {
+	topics  []string
+	present bool
+}
+
+// setPresence adds or removes one connection from the presence of topics right away, so
+// counts do not wait for the next refresh. Topics without presence tracking are ignored. The
+// updates of a connection are written in the order they were made, so a subscription removed
+// right after it was added cannot be written last and leave a ghost viewer.
+func (h *NotificationHub) setPresence(client *ClientConnection, topics []string, present bool) {
+	if h.redisClient == nil || client.id == "" {
+		return
+	}
+	var tracked []string
+	for _, topic := range topics {
+		if tracksPresence(topic) {
+			tracked = append(tracked, topic)
+		}
+	}
+	if len(tracked) == 0 {
+		return
+	}
+
+	client.presenceMu.Lock()
+	defer client.presenceMu.Unlock()
+	client.presenceQueue = append(client.presenceQueue, presenceUpdate{topics: tracked, present: present})
+	if !client.presenceRunning {
+		client.presenceRunning = true
+		go h.writePresence(client)
+	}
+}
+
+// writePresence writes the queued presence updates of a connection one after the other, and
+// returns once the queue is empty
+func (h *NotificationHub) writePresence(client *ClientConnection) {
+	for {
+		client.presenceMu.Lock()
+		if len(client.presenceQueue) == 0 {
+			client.presenceRunning = false
+			client.presenceMu.Unlock()
+			return
+		}
+		update := client.presenceQueue[0]
+		client.presenceQueue = client.presenceQueue[1:]
+		client.presenceMu.Unlock()
+
+		h.applyPresence(client.id, update)
+	}
+}
+
+// applyPresence writes one presence update to Redis
+func (h *NotificationHub) applyPresence(id string, update presenceUpdate) {
+	ctx, cancel := context.WithTimeout(h.ctx, 5*time.Second)
+	defer cancel()
+
+	score := float64(time.Now().Unix())
+	pipe := h.redisClient.Pipeline()
+	for _, topic := range update.topics {
+		if update.present {
+			pipe.ZAdd(ctx, presenceKey(topic), &redis.Z{Score: score, Member: id})
+			pipe.Expire(ctx, presenceKey(topic), 2*presenceTTL)
+		} else {
+			pipe.ZRem(ctx, presenceKey(topic), id)
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil && ctx.Err() == nil {
+		log.Printf("Warning: Failed to update topic presence: %v", err)
+	}
+}
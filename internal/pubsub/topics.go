@@ -0,0 +1,132 @@
+package pubsub
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"news/internal/json"
+)
+
+// ErrInvalidTopic is returned for topics clients cannot subscribe to
+var ErrInvalidTopic = errors.New("invalid topic")
+
+// Topics clients can subscribe to over WebSocket. Entity topics take the form "{kind}:{key}".
+const (
+	TopicBreakingNews = ChannelBreakingNews // Same name as the global channel, so anonymous clients can opt in
+	TopicArticle      = "article"
+	TopicLive         = "live"
+	TopicCategory     = "category"
+	TopicVideo        = "video"
+
+	// TopicChannelPrefix prefixes the Redis channel of every topic, e.g. "topic:article:42"
+	TopicChannelPrefix = "topic:"
+
+	// MaxTopicsPerConnection limits how many topics one connection can follow
+	MaxTopicsPerConnection = 50
+)
+
+// Client frame actions
+const (
+	ActionSubscribe   = "subscribe"
+	ActionUnsubscribe = "unsubscribe"
+	ActionPing        = "ping"
+)
+
+var topicSlugPattern = regexp.MustCompile(`^[a-z0-9]+(?:[-_][a-z0-9]+)*$`)
+
+// ClientFrame is a message sent by a WebSocket client. Topic and Topics may be combined.
+type ClientFrame struct {
+	Action string   `json:"action"`
+	Topic  string   `json:"topic,omitempty"`
+	Topics []string `json:"topics,omitempty"`
+}
+
+// ParseClientFrame decodes a client frame and validates its action and topics, returning the
+// normalized topics it refers to
+func ParseClientFrame(payload []byte) (*ClientFrame, []string, error) {
+	var frame ClientFrame
+	if err := json.Unmarshal(payload, &frame); err != nil {
+		return nil, nil, fmt.Errorf("malformed frame: %v", err)
+	}
+	frame.Action = strings.ToLower(strings.TrimSpace(frame.Action))
+
+	switch frame.Action {
+	case ActionPing:
+		return &frame, nil, nil
+	case ActionSubscribe, ActionUnsubscribe:
+	default:
+		return &frame, nil, fmt.Errorf("unknown action %q", frame.Action)
+	}
+
+	raw := frame.Topics
+	if frame.Topic != "" {
+		raw = append([]string{frame.Topic}, raw...)
+	}
+	if len(raw) == 0 {
+		return &frame, nil, fmt.Errorf("%w: no topic given", ErrInvalidTopic)
+	}
+	if len(raw) > MaxTopicsPerConnection {
+		return &frame, nil, fmt.Errorf("%w: at most %d topics per frame", ErrInvalidTopic, MaxTopicsPerConnection)
+	}
+
+	topics := make([]string, 0, len(raw))
+	seen := make(map[string]bool, len(raw))
+	for _, t := range raw {
+		topic, err := ParseTopic(t)
+		if err != nil {
+			return &frame, nil, err
+		}
+		if !seen[topic] {
+			seen[topic] = true
+			topics = append(topics, topic)
+		}
+	}
+	return &frame, topics, nil
+}
+
+// ParseTopic validates a topic and returns it in its canonical form
+func ParseTopic(topic string) (string, error) {
+	topic = strings.ToLower(strings.TrimSpace(topic))
+	if topic == TopicBreakingNews {
+		return topic, nil
+	}
+
+	kind, key, ok := strings.Cut(topic, ":")
+	if !ok || key == "" {
+		return "", fmt.Errorf("%w: %q", ErrInvalidTopic, topic)
+	}
+
+	switch kind {
+	case TopicArticle, TopicLive, TopicVideo:
+		id, err := strconv.ParseUint(key, 10, 32)
+		if err != nil || id == 0 {
+			return "", fmt.Errorf("%w: %q needs a numeric id", ErrInvalidTopic, topic)
+		}
+		return entityTopic(kind, uint(id)), nil
+	case TopicCategory:
+		if len(key) > 100 || !topicSlugPattern.MatchString(key) {
+			return "", fmt.Errorf("%w: %q needs a category slug", ErrInvalidTopic, topic)
+		}
+		return kind + ":" + key, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidTopic, topic)
+}
+
+// ArticleTopic returns the topic for updates of an article
+func ArticleTopic(articleID uint) string { return entityTopic(TopicArticle, articleID) }
+
+// LiveTopic returns the topic for updates of a live news stream
+func LiveTopic(streamID uint) string { return entityTopic(TopicLive, streamID) }
+
+// VideoTopic returns the topic for updates of a video
+func VideoTopic(videoID uint) string { return entityTopic(TopicVideo, videoID) }
+
+// CategoryTopic returns the topic for articles published in a category
+func CategoryTopic(slug string) string { return TopicCategory + ":" + strings.ToLower(slug) }
+
+func entityTopic(kind string, id uint) string {
+	return kind + ":" + strconv.FormatUint(uint64(id), 10)
+}
@@ -42,6 +42,10 @@ type QueueManager struct {
 type ServiceContainer struct {
 	TranslationService     *services.AITranslationService
 	VideoProcessingService *services.VideoProcessingService
+	NewsletterService      *services.NewsletterDeliveryService
+	WebhookService         *services.WebhookDeliveryService
+	EmbeddingService       *services.EmbeddingService
+	SitemapService         *services.SitemapService
 	// Add other services as needed
 }
 
@@ -84,13 +88,36 @@ type VideoJobProcessor struct {
 }
 
 func (p *VideoJobProcessor) ProcessJob(ctx context.Context, job *Job) error {
+	if job.Type == "video_processing_complete" {
+		videoID, _ := job.Payload["video_id"].(float64)
+		if videoID == 0 {
+			return fmt.Errorf("video workflow job %s has no video_id", job.ID)
+		}
+		return p.processCompleteWorkflow(ctx, uint(videoID))
+	}
+
 	// Simplified video processing logic
 	log.Printf("Processing video job: %s", job.Type)
 	return nil
 }
 
+// processCompleteWorkflow generates the thumbnail and renditions of a newly uploaded video and
+// runs the AI analysis when it is available. Only a failed transcode fails the job.
+func (p *VideoJobProcessor) processCompleteWorkflow(ctx context.Context, videoID uint) error {
+	if err := p.service.GenerateThumbnail(ctx, videoID); err != nil {
+		log.Printf("Warning: Thumbnail generation failed for video %d: %v", videoID, err)
+	}
+	if err := p.service.TranscodeVideo(ctx, videoID); err != nil {
+		return err
+	}
+	if err := p.service.AnalyzeVideo(ctx, videoID); err != nil {
+		log.Printf("Warning: AI analysis skipped for video %d: %v", videoID, err)
+	}
+	return nil
+}
+
 func (p *VideoJobProcessor) GetJobTypes() []string {
-	return []string{"video", "thumbnail", "transcode", "analysis", "tts", "complete_workflow"}
+	return []string{"video", "thumbnail", "transcode", "analysis", "tts", "complete_workflow", "video_processing_complete"}
 }
 
 // AgentJobProcessor handles agent task jobs
@@ -106,6 +133,84 @@ func (p *AgentJobProcessor) GetJobTypes() []string {
 	return []string{"agent", "webhook", "automation", "notification", "data_sync"}
 }
 
+// NewsletterJobProcessor handles per-recipient newsletter delivery and subscription confirmation jobs
+type NewsletterJobProcessor struct {
+	service *services.NewsletterDeliveryService
+}
+
+func (p *NewsletterJobProcessor) ProcessJob(ctx context.Context, job *Job) error {
+	if job.Type == "subscription_confirmation" {
+		subscriptionID, _ := job.Payload["subscription_id"].(float64)
+		if subscriptionID == 0 {
+			return fmt.Errorf("subscription confirmation job %s has no subscription_id", job.ID)
+		}
+		return p.service.SendSubscriptionConfirmation(ctx, uint(subscriptionID))
+	}
+
+	deliveryID, _ := job.Payload["delivery_id"].(float64)
+	if deliveryID == 0 {
+		return fmt.Errorf("newsletter delivery job %s has no delivery_id", job.ID)
+	}
+
+	// Attempts counts previous failures, so this is the last try when it reaches MaxAttempts-1
+	finalAttempt := job.Attempts+1 >= job.MaxAttempts
+	return p.service.Deliver(ctx, uint(deliveryID), finalAttempt)
+}
+
+func (p *NewsletterJobProcessor) GetJobTypes() []string {
+	return []string{"newsletter_delivery", "subscription_confirmation"}
+}
+
+// WebhookJobProcessor handles outbound webhook delivery jobs
+type WebhookJobProcessor struct {
+	service *services.WebhookDeliveryService
+}
+
+func (p *WebhookJobProcessor) ProcessJob(ctx context.Context, job *Job) error {
+	deliveryID, _ := job.Payload["delivery_id"].(float64)
+	if deliveryID == 0 {
+		return fmt.Errorf("webhook delivery job %s has no delivery_id", job.ID)
+	}
+	return p.service.Deliver(ctx, uint(deliveryID))
+}
+
+func (p *WebhookJobProcessor) GetJobTypes() []string {
+	return []string{"webhook_delivery"}
+}
+
+// EmbeddingJobProcessor refreshes the embeddings of articles
+type EmbeddingJobProcessor struct {
+	service *services.EmbeddingService
+}
+
+func (p *EmbeddingJobProcessor) ProcessJob(ctx context.Context, job *Job) error {
+	articleID, _ := job.Payload["article_id"].(float64)
+	if articleID == 0 {
+		return fmt.Errorf("article embedding job %s has no article_id", job.ID)
+	}
+	return p.service.EmbedArticle(ctx, uint(articleID))
+}
+
+func (p *EmbeddingJobProcessor) GetJobTypes() []string {
+	return []string{"article_embedding"}
+}
+
+// SitemapJobProcessor regenerates sitemaps after content is published or changed
+type SitemapJobProcessor struct {
+	service *services.SitemapService
+}
+
+func (p *SitemapJobProcessor) ProcessJob(ctx context.Context, job *Job) error {
+	section, _ := job.Payload["section"].(string)
+	entityID, _ := job.Payload["entity_id"].(float64)
+	_, err := p.service.Refresh(section, uint(entityID))
+	return err
+}
+
+func (p *SitemapJobProcessor) GetJobTypes() []string {
+	return []string{"sitemap_refresh"}
+}
+
 // NewQueueManager creates a new queue manager
 func NewQueueManager(services *ServiceContainer) *QueueManager {
 	ctx, cancel := context.WithCancel(context.Background())
@@ -129,6 +234,10 @@ func (qm *QueueManager) Initialize() error {
 		"video_processing": 2, // 2 workers for video jobs (resource intensive)
 		"agent_tasks":      2, // 2 workers for agent tasks
 		"general":          3, // 3 workers for general tasks
+		"newsletters":      2, // 2 workers for newsletter delivery
+		"webhooks":         3, // 3 workers for outbound webhook delivery
+		"embeddings":       2, // 2 workers for article embeddings
+		"sitemaps":         1, // 1 worker so sitemap rebuilds never race each other
 	}
 
 	for queueName, workerCount := range queueConfigs {
@@ -151,6 +260,12 @@ func (qm *QueueManager) Initialize() error {
 		log.Printf("Initialized queue '%s' with %d workers", queueName, workerCount)
 	}
 
+	// Webhook events, embedding refreshes and sitemap rebuilds raised by services are processed
+	// through this manager
+	services.SetWebhookEnqueuer(qm)
+	services.SetEmbeddingEnqueuer(qm)
+	services.SetSitemapEnqueuer(qm)
+
 	return nil
 }
 
@@ -173,6 +288,30 @@ func (qm *QueueManager) registerProcessors(queueName string, workerPool *WorkerP
 		processor := &AgentJobProcessor{}
 		workerPool.RegisterProcessor(processor)
 
+	case "newsletters":
+		if qm.services.NewsletterService != nil {
+			processor := &NewsletterJobProcessor{service: qm.services.NewsletterService}
+			workerPool.RegisterProcessor(processor)
+		}
+
+	case "webhooks":
+		if qm.services.WebhookService != nil {
+			processor := &WebhookJobProcessor{service: qm.services.WebhookService}
+			workerPool.RegisterProcessor(processor)
+		}
+
+	case "embeddings":
+		if qm.services.EmbeddingService != nil {
+			processor := &EmbeddingJobProcessor{service: qm.services.EmbeddingService}
+			workerPool.RegisterProcessor(processor)
+		}
+
+	case "sitemaps":
+		if qm.services.SitemapService != nil {
+			processor := &SitemapJobProcessor{service: qm.services.SitemapService}
+			workerPool.RegisterProcessor(processor)
+		}
+
 	case "general":
 		// Register multiple processors for general queue
 		if qm.services.TranslationService != nil {
@@ -272,6 +411,9 @@ func (qm *QueueManager) GetQueueStats() map[string]QueueStats {
 			log.Printf("Error getting stats for queue %s: %v", queueName, err)
 			continue
 		}
+		if workerPool := qm.workerPools[queueName]; workerPool != nil {
+			queueStats.WorkerCount = workerPool.workers
+		}
 		stats[queueName] = queueStats
 	}
 
@@ -336,6 +478,150 @@ func (qm *QueueManager) EnqueueAgentJob(jobType string, payload map[string]inter
 	return qm.EnqueueJob("agent_tasks", job)
 }
 
+// EnqueueNewsletter prepares the deliveries of a newsletter and enqueues one job per recipient.
+// It returns the number of jobs enqueued. Deliveries that could not be enqueued stay pending
+// and are picked up by the scheduler.
+func (qm *QueueManager) EnqueueNewsletter(newsletterID uint) (int, error) {
+	deliveryIDs, err := services.PrepareNewsletterDelivery(newsletterID)
+	if err != nil {
+		return 0, err
+	}
+
+	enqueued := 0
+	for _, deliveryID := range deliveryIDs {
+		ok, err := qm.EnqueueNewsletterDelivery(newsletterID, deliveryID)
+		if err != nil {
+			log.Printf("Failed to enqueue newsletter delivery %d: %v", deliveryID, err)
+			continue
+		}
+		if ok {
+			enqueued++
+		}
+	}
+
+	if enqueued < len(deliveryIDs) {
+		log.Printf("Enqueued %d of %d deliveries of newsletter %d; the scheduler will retry the rest", enqueued, len(deliveryIDs), newsletterID)
+	}
+	return enqueued, nil
+}
+
+// EnqueueNewsletterDelivery claims a pending or stalled newsletter delivery and enqueues its job.
+// It reports false if another worker claimed the delivery first.
+func (qm *QueueManager) EnqueueNewsletterDelivery(newsletterID, deliveryID uint) (bool, error) {
+	claimed, err := services.ClaimNewsletterDelivery(deliveryID, time.Now())
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	// A delivery that looks stalled may only be waiting behind a long queue. Its job is still
+	// there under the same ID, so leave it in place rather than enqueueing a second one.
+	jobID := fmt.Sprintf("newsletter_%d_delivery_%d", newsletterID, deliveryID)
+	if queue := qm.GetQueue("newsletters"); queue != nil {
+		if waiting, err := queue.IsWaiting(jobID); err == nil && waiting {
+			return true, nil
+		}
+	}
+
+	job := &Job{
+		ID:          jobID,
+		Type:        "newsletter_delivery",
+		Priority:    PriorityNormal,
+		Status:      JobStatusPending,
+		Attempts:    0,
+		MaxAttempts: 5,
+		CreatedAt:   time.Now(),
+		ScheduledAt: time.Now(),
+		Payload: map[string]interface{}{
+			"newsletter_id": newsletterID,
+			"delivery_id":   deliveryID,
+		},
+	}
+	if err := qm.EnqueueJob("newsletters", job); err != nil {
+		services.ReleaseNewsletterDelivery(deliveryID)
+		return false, err
+	}
+	return true, nil
+}
+
+// EnqueueSubscriptionConfirmation enqueues the double opt-in email for a subscription
+func (qm *QueueManager) EnqueueSubscriptionConfirmation(subscriptionID uint) error {
+	job := &Job{
+		ID:          fmt.Sprintf("subscription_%d_confirmation_%d", subscriptionID, time.Now().Unix()),
+		Type:        "subscription_confirmation",
+		Priority:    PriorityHigh,
+		Status:      JobStatusPending,
+		Attempts:    0,
+		MaxAttempts: 3,
+		CreatedAt:   time.Now(),
+		ScheduledAt: time.Now(),
+		Payload: map[string]interface{}{
+			"subscription_id": subscriptionID,
+		},
+	}
+
+	return qm.EnqueueJob("newsletters", job)
+}
+
+// EnqueueWebhookDelivery enqueues the next attempt of a webhook delivery. Retries are
+// scheduled by the delivery itself, so the job is attempted once per dispatch.
+func (qm *QueueManager) EnqueueWebhookDelivery(deliveryID uint) error {
+	job := &Job{
+		ID:          fmt.Sprintf("webhook_delivery_%d_%d", deliveryID, time.Now().UnixNano()),
+		Type:        "webhook_delivery",
+		Priority:    PriorityNormal,
+		Status:      JobStatusPending,
+		Attempts:    0,
+		MaxAttempts: 3,
+		CreatedAt:   time.Now(),
+		ScheduledAt: time.Now(),
+		Payload: map[string]interface{}{
+			"delivery_id": deliveryID,
+		},
+	}
+
+	return qm.EnqueueJob("webhooks", job)
+}
+
+// EnqueueArticleEmbedding queues an article to have its embedding refreshed
+func (qm *QueueManager) EnqueueArticleEmbedding(articleID uint) error {
+	job := &Job{
+		ID:          fmt.Sprintf("article_embedding_%d_%d", articleID, time.Now().UnixNano()),
+		Type:        "article_embedding",
+		Priority:    PriorityLow,
+		Status:      JobStatusPending,
+		Attempts:    0,
+		MaxAttempts: 3,
+		CreatedAt:   time.Now(),
+		ScheduledAt: time.Now(),
+		Payload: map[string]interface{}{
+			"article_id": articleID,
+		},
+	}
+
+	return qm.EnqueueJob("embeddings", job)
+}
+
+// EnqueueSitemapRefresh queues the sitemaps listing an item to be rebuilt. An empty section
+// rebuilds every sitemap.
+func (qm *QueueManager) EnqueueSitemapRefresh(section string, entityID uint) error {
+	job := &Job{
+		ID:          fmt.Sprintf("sitemap_refresh_%s_%d_%d", section, entityID, time.Now().UnixNano()),
+		Type:        "sitemap_refresh",
+		Priority:    PriorityLow,
+		Status:      JobStatusPending,
+		Attempts:    0,
+		MaxAttempts: 3,
+		CreatedAt:   time.Now(),
+		ScheduledAt: time.Now(),
+		Payload: map[string]interface{}{
+			"section":   section,
+			"entity_id": entityID,
+		},
+	}
+
+	return qm.EnqueueJob("sitemaps", job)
+}
+
 // GetJobs returns jobs from a specific queue with pagination
 func (qm *QueueManager) GetJobs(queueName, status string, page, limit int) ([]JobStatusInfo, int64, error) {
 	queue, exists := qm.queues[queueName]
@@ -428,6 +714,56 @@ func (qm *QueueManager) CleanupOldJobs(hours int, queueName string) (int, error)
 	return totalCleaned, nil
 }
 
+// PauseQueue stops workers from taking new jobs from a queue
+func (qm *QueueManager) PauseQueue(queueName string) error {
+	queue, exists := qm.queues[queueName]
+	if !exists {
+		return fmt.Errorf("queue '%s' not found", queueName)
+	}
+
+	return queue.Pause()
+}
+
+// ResumeQueue lets workers take jobs from a paused queue again
+func (qm *QueueManager) ResumeQueue(queueName string) error {
+	queue, exists := qm.queues[queueName]
+	if !exists {
+		return fmt.Errorf("queue '%s' not found", queueName)
+	}
+
+	return queue.Resume()
+}
+
+// GetDeadLetterJobs returns jobs from a queue's dead letter queue with pagination
+func (qm *QueueManager) GetDeadLetterJobs(queueName string, page, limit int) ([]JobStatusInfo, int64, error) {
+	queue, exists := qm.queues[queueName]
+	if !exists {
+		return nil, 0, fmt.Errorf("queue '%s' not found", queueName)
+	}
+
+	return queue.GetDeadLetterJobs(page, limit)
+}
+
+// ReplayDeadLetterJobs re-enqueues dead letter jobs of a queue; all of them when jobIDs is empty
+func (qm *QueueManager) ReplayDeadLetterJobs(queueName string, jobIDs []string) (int, error) {
+	queue, exists := qm.queues[queueName]
+	if !exists {
+		return 0, fmt.Errorf("queue '%s' not found", queueName)
+	}
+
+	return queue.ReplayDeadLetterJobs(jobIDs)
+}
+
+// PurgeDeadLetterJobs removes dead letter jobs of a queue; all of them when jobIDs is empty
+func (qm *QueueManager) PurgeDeadLetterJobs(queueName string, jobIDs []string) (int, error) {
+	queue, exists := qm.queues[queueName]
+	if !exists {
+		return 0, fmt.Errorf("queue '%s' not found", queueName)
+	}
+
+	return queue.PurgeDeadLetterJobs(jobIDs)
+}
+
 // GetHealthStatus returns health status of all queues
 func (qm *QueueManager) GetHealthStatus() (map[string]interface{}, error) {
 	health := map[string]interface{}{
@@ -457,20 +793,29 @@ func (qm *QueueManager) GetHealthStatus() (map[string]interface{}, error) {
 		}
 
 		workerCount := 0
-		activeWorkers := 0
 		if workerPool != nil {
-			// Note: WorkerPool doesn't expose these fields yet, using defaults
-			workerCount = 3   // Default worker count
-			activeWorkers = 2 // Estimated active workers
+			workerCount = workerPool.workers
+		}
+		// Workers run in the worker process; jobs in flight are the best estimate of busy workers
+		activeWorkers := int(stats.ProcessingJobs)
+		if activeWorkers > workerCount {
+			activeWorkers = workerCount
+		}
+
+		queueStatus := map[bool]string{true: "healthy", false: "unhealthy"}[queueHealthy]
+		if stats.Paused {
+			queueStatus = "paused"
 		}
 
 		queueHealth := map[string]interface{}{
-			"status":          map[bool]string{true: "healthy", false: "unhealthy"}[queueHealthy],
+			"status":          queueStatus,
 			"worker_count":    workerCount,
 			"active_workers":  activeWorkers,
 			"pending_jobs":    stats.PendingJobs,
 			"processing_jobs": stats.ProcessingJobs,
 			"failed_jobs":     stats.FailedJobs,
+			"dead_jobs":       stats.DeadJobs,
+			"paused":          stats.Paused,
 		}
 
 		if stats.LastProcessed != nil {
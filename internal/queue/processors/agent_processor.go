@@ -5,19 +5,24 @@ import (
 	"fmt"
 	"log"
 
+	"news/internal/config"
+	"news/internal/json"
 	"news/internal/queue"
+	"news/internal/webhook"
 )
 
 // AgentProcessor handles agent task jobs for Redis queue
 // This integrates with existing n8n automation system
 type AgentProcessor struct {
-	// Add any required dependencies for agent task processing
-	// For example, HTTP client for n8n webhook calls
+	// sender makes the signed HTTP calls for webhook tasks
+	sender *webhook.Sender
 }
 
 // NewAgentProcessor creates a new agent processor
 func NewAgentProcessor() *AgentProcessor {
-	return &AgentProcessor{}
+	return &AgentProcessor{
+		sender: webhook.NewSender(config.GetWebhookConfig().Timeout),
+	}
 }
 
 // ProcessJob processes an agent task job
@@ -59,18 +64,48 @@ func (ap *AgentProcessor) GetJobTypes() []string {
 	}
 }
 
-// processWebhookTask handles webhook-based agent tasks
+// processWebhookTask posts the job payload to webhook_url, signed with webhook_secret when
+// one is given. A non-2xx response fails the job so the queue retries it.
 func (ap *AgentProcessor) processWebhookTask(ctx context.Context, payload map[string]interface{}) error {
 	webhookURL, ok := payload["webhook_url"].(string)
 	if !ok {
 		return fmt.Errorf("missing webhook_url in payload")
 	}
+	secret, _ := payload["webhook_secret"].(string)
+	event, _ := payload["event"].(string)
+	if event == "" {
+		event = "agent.task"
+	}
+	deliveryID, _ := payload["delivery_id"].(string)
+
+	// The target and secret are not part of the body
+	body := make(map[string]interface{}, len(payload))
+	for k, v := range payload {
+		if k != "webhook_url" && k != "webhook_secret" {
+			body[k] = v
+		}
+	}
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
 
-	// TODO: Implement webhook call to n8n or other automation system
 	log.Printf("Processing webhook task for URL: %s", webhookURL)
 
-	// Placeholder implementation
-	// In real implementation, you would make HTTP calls to n8n webhooks
+	result, err := ap.sender.Send(ctx, webhook.Request{
+		URL:        webhookURL,
+		Secret:     secret,
+		Event:      event,
+		DeliveryID: deliveryID,
+		Body:       encoded,
+	})
+	if err != nil {
+		return fmt.Errorf("webhook call to %s failed: %w", webhookURL, err)
+	}
+	if !result.Success() {
+		return fmt.Errorf("webhook call to %s returned status %d", webhookURL, result.StatusCode)
+	}
+
 	return nil
 }
 
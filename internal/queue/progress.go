@@ -0,0 +1,58 @@
+package queue
+
+import (
+	"context"
+	"log"
+
+	"news/internal/pubsub"
+)
+
+type progressReporterKey struct{}
+
+// progressReporter records the progress of the job being processed
+type progressReporter struct {
+	queue *RedisQueue
+	job   *Job
+}
+
+// withProgressReporter attaches a progress reporter for job to the processing context
+func withProgressReporter(ctx context.Context, rq *RedisQueue, job *Job) context.Context {
+	return context.WithValue(ctx, progressReporterKey{}, &progressReporter{queue: rq, job: job})
+}
+
+// ReportProgress records the progress (0-100) of the job being processed with ctx and
+// streams it to admins watching the queues. It does nothing outside a worker.
+func ReportProgress(ctx context.Context, progress int) {
+	reporter, ok := ctx.Value(progressReporterKey{}).(*progressReporter)
+	if !ok {
+		return
+	}
+
+	if progress < 0 {
+		progress = 0
+	} else if progress > 100 {
+		progress = 100
+	}
+
+	if err := reporter.queue.UpdateProgress(reporter.job.ID, progress); err != nil {
+		log.Printf("Warning: Failed to record progress for job %s: %v", reporter.job.ID, err)
+	}
+	reporter.job.Progress = progress
+	publishJobUpdate(reporter.queue, reporter.job, JobStatusProcessing, "")
+}
+
+// publishJobUpdate streams a job state change to admins watching the queues
+func publishJobUpdate(rq *RedisQueue, job *Job, status JobStatus, errorMsg string) {
+	update := pubsub.QueueJobUpdate{
+		Queue:    rq.queueName,
+		JobID:    job.ID,
+		JobType:  job.Type,
+		Status:   string(status),
+		Progress: job.Progress,
+		Attempts: job.Attempts,
+		Error:    errorMsg,
+	}
+	if err := pubsub.PublishQueueJobUpdate(update); err != nil {
+		log.Printf("Warning: Failed to publish update for job %s: %v", job.ID, err)
+	}
+}
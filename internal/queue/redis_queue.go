@@ -47,6 +47,7 @@ type Job struct {
 	StartedAt   *time.Time             `json:"started_at,omitempty"`
 	CompletedAt *time.Time             `json:"completed_at,omitempty"`
 	ErrorMsg    string                 `json:"error_msg,omitempty"`
+	Progress    int                    `json:"progress,omitempty"` // 0-100, reported by processors
 }
 
 // QueueStats represents queue statistics
@@ -58,6 +59,7 @@ type QueueStats struct {
 	FailedJobs     int64      `json:"failed_jobs"`
 	DeadJobs       int64      `json:"dead_jobs"`
 	WorkerCount    int        `json:"worker_count"`
+	Paused         bool       `json:"paused"`
 	LastProcessed  *time.Time `json:"last_processed,omitempty"`
 }
 
@@ -211,6 +213,13 @@ func (rq *RedisQueue) BlockingDequeue(timeout time.Duration) (*Job, error) {
 		return nil, nil // No notification received
 	}
 
+	// The queue may have been paused while this worker was waiting; hand the
+	// notification back so the job is picked up after the queue is resumed
+	if paused, _ := rq.IsPaused(); paused {
+		rq.client.LPush(rq.ctx, notificationKey, result[1])
+		return nil, nil
+	}
+
 	// A job notification was received, now get the actual highest priority job
 	// Use the non-blocking version to get the job
 	return rq.Dequeue()
@@ -242,8 +251,16 @@ func (rq *RedisQueue) FailJob(jobID string, errorMsg string) error {
 		return rq.Enqueue(job)
 	}
 
-	// Max attempts reached, mark as failed
-	return rq.updateJobStatus(jobID, JobStatusFailed, errorMsg, nil)
+	// Max attempts reached, mark as failed and park a copy in the dead letter
+	// queue so it can be inspected and replayed
+	now := time.Now()
+	job.Status = JobStatusFailed
+	job.CompletedAt = &now
+	jobData, _ := json.Marshal(job)
+	if err := rq.client.HSet(rq.ctx, rq.getJobsKey(), jobID, jobData).Err(); err != nil {
+		return err
+	}
+	return rq.MoveToDeadLetter(jobID)
 }
 
 // GetJob retrieves job details
@@ -268,6 +285,41 @@ func (rq *RedisQueue) GetJob(jobID string) (*Job, error) {
 	return &job, nil
 }
 
+// IsWaiting reports whether a job is in the queue waiting for a worker, including jobs
+// waiting for a retry
+func (rq *RedisQueue) IsWaiting(jobID string) (bool, error) {
+	if rq.client == nil {
+		return false, fmt.Errorf("redis client not available")
+	}
+
+	err := rq.client.ZScore(rq.ctx, rq.getQueueKey(), jobID).Err()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check job: %w", err)
+	}
+	return true, nil
+}
+
+// UpdateProgress records the progress (0-100) of a running job
+func (rq *RedisQueue) UpdateProgress(jobID string, progress int) error {
+	job, err := rq.GetJob(jobID)
+	if err != nil {
+		return err
+	}
+
+	if progress < 0 {
+		progress = 0
+	} else if progress > 100 {
+		progress = 100
+	}
+	job.Progress = progress
+
+	jobData, _ := json.Marshal(job)
+	return rq.client.HSet(rq.ctx, rq.getJobsKey(), jobID, jobData).Err()
+}
+
 // GetStats returns queue statistics
 func (rq *RedisQueue) GetStats() (QueueStats, error) {
 	stats := QueueStats{
@@ -309,12 +361,15 @@ func (rq *RedisQueue) GetStats() (QueueStats, error) {
 	}
 
 	// Get dead letter queue count
-	deadLetterQueue := fmt.Sprintf("dead_letter:%s", rq.queueName)
-	deadCount, err := rq.client.LLen(rq.ctx, deadLetterQueue).Result()
+	deadCount, err := rq.client.LLen(rq.ctx, rq.getDeadLetterKey()).Result()
 	if err == nil {
 		stats.DeadJobs = deadCount
 	}
 
+	if paused, err := rq.IsPaused(); err == nil {
+		stats.Paused = paused
+	}
+
 	return stats, nil
 }
 
@@ -359,6 +414,7 @@ type JobStatusInfo struct {
 	StartedAt   *int64                 `json:"started_at,omitempty"`
 	CompletedAt *int64                 `json:"completed_at,omitempty"`
 	ErrorMsg    string                 `json:"error_msg,omitempty"`
+	Progress    int                    `json:"progress"`
 	Payload     map[string]interface{} `json:"payload,omitempty"`
 }
 
@@ -403,31 +459,7 @@ func (rq *RedisQueue) GetJobs(status string, page, limit int) ([]JobStatusInfo,
 	// Convert to JobStatusInfo slice
 	result := make([]JobStatusInfo, 0, end-offset)
 	for i := offset; i < end; i++ {
-		job := filteredJobs[i]
-		jobStatus := JobStatusInfo{
-			ID:          job.ID,
-			Type:        job.Type,
-			Status:      string(job.Status),
-			Priority:    int(job.Priority),
-			Attempts:    job.Attempts,
-			MaxAttempts: job.MaxAttempts,
-			CreatedAt:   job.CreatedAt.Unix(),
-			ScheduledAt: job.ScheduledAt.Unix(),
-			ErrorMsg:    job.ErrorMsg,
-			Payload:     job.Payload,
-		}
-
-		if job.StartedAt != nil {
-			startedAt := job.StartedAt.Unix()
-			jobStatus.StartedAt = &startedAt
-		}
-
-		if job.CompletedAt != nil {
-			completedAt := job.CompletedAt.Unix()
-			jobStatus.CompletedAt = &completedAt
-		}
-
-		result = append(result, jobStatus)
+		result = append(result, newJobStatusInfo(&filteredJobs[i]))
 	}
 
 	return result, total, nil
@@ -444,7 +476,13 @@ func (rq *RedisQueue) GetJobStatus(jobID string) (*JobStatusInfo, error) {
 		return nil, err
 	}
 
-	jobStatus := &JobStatusInfo{
+	jobStatus := newJobStatusInfo(job)
+	return &jobStatus, nil
+}
+
+// newJobStatusInfo converts a job to its API representation
+func newJobStatusInfo(job *Job) JobStatusInfo {
+	jobStatus := JobStatusInfo{
 		ID:          job.ID,
 		Type:        job.Type,
 		Status:      string(job.Status),
@@ -454,6 +492,7 @@ func (rq *RedisQueue) GetJobStatus(jobID string) (*JobStatusInfo, error) {
 		CreatedAt:   job.CreatedAt.Unix(),
 		ScheduledAt: job.ScheduledAt.Unix(),
 		ErrorMsg:    job.ErrorMsg,
+		Progress:    job.Progress,
 		Payload:     job.Payload,
 	}
 
@@ -467,7 +506,7 @@ func (rq *RedisQueue) GetJobStatus(jobID string) (*JobStatusInfo, error) {
 		jobStatus.CompletedAt = &completedAt
 	}
 
-	return jobStatus, nil
+	return jobStatus
 }
 
 // RetryJob retries a failed job
@@ -508,7 +547,12 @@ func (rq *RedisQueue) RetryJob(jobID string) error {
 	jobData, _ := json.Marshal(job)
 	pipe.HSet(rq.ctx, rq.getJobsKey(), job.ID, jobData)
 
-	_, err = pipe.Exec(rq.ctx)
+	if _, err = pipe.Exec(rq.ctx); err != nil {
+		return err
+	}
+
+	// The job is live again, so it no longer belongs in the dead letter queue
+	_, err = rq.drainDeadLetter([]string{job.ID}, nil)
 	return err
 }
 
@@ -533,7 +577,13 @@ func (rq *RedisQueue) DeleteJob(jobID string) error {
 	pipe.ZRem(rq.ctx, rq.getQueueKey(), jobID)
 	pipe.HDel(rq.ctx, rq.getJobsKey(), jobID)
 
-	_, err = pipe.Exec(rq.ctx)
+	if _, err = pipe.Exec(rq.ctx); err != nil {
+		return err
+	}
+
+	if job.Status == JobStatusFailed {
+		_, err = rq.drainDeadLetter([]string{jobID}, nil)
+	}
 	return err
 }
 
@@ -591,7 +641,7 @@ func (rq *RedisQueue) GetQueueStats() (map[string]interface{}, error) {
 	// Get queue lengths
 	queueKey := rq.getQueueKey()
 	jobsKey := rq.getJobsKey()
-	deadLetterKey := fmt.Sprintf("dead_letter:%s", rq.queueName)
+	deadLetterKey := rq.getDeadLetterKey()
 
 	// Count jobs by status
 	pendingCount, err := rq.client.ZCard(rq.ctx, queueKey).Result()
@@ -681,27 +731,172 @@ func (rq *RedisQueue) updateJobStatus(jobID string, status JobStatus, errorMsg s
 	return rq.client.HSet(rq.ctx, rq.getJobsKey(), jobID, jobData).Err()
 }
 
-// DeadLetterQueue handles failed jobs
+// MoveToDeadLetter takes a job out of the queue and records a copy of it in the
+// dead letter queue. The job itself stays in the jobs hash, so it can still be
+// looked up, listed as failed and retried.
 func (rq *RedisQueue) MoveToDeadLetter(jobID string) error {
 	job, err := rq.GetJob(jobID)
 	if err != nil {
 		return err
 	}
 
-	// Move to dead letter queue
-	deadLetterQueue := fmt.Sprintf("dead_letter:%s", rq.queueName)
 	jobData, _ := json.Marshal(job)
 
 	pipe := rq.client.Pipeline()
-	pipe.LPush(rq.ctx, deadLetterQueue, jobData)
-	pipe.HDel(rq.ctx, rq.getJobsKey(), jobID)
+	pipe.LPush(rq.ctx, rq.getDeadLetterKey(), jobData)
 	pipe.ZRem(rq.ctx, rq.getQueueKey(), jobID)
 
 	_, err = pipe.Exec(rq.ctx)
 	return err
 }
 
+// GetDeadLetterJobs returns jobs from the dead letter queue, newest first
+func (rq *RedisQueue) GetDeadLetterJobs(page, limit int) ([]JobStatusInfo, int64, error) {
+	if rq.client == nil {
+		return nil, 0, fmt.Errorf("redis client not available")
+	}
+
+	total, err := rq.client.LLen(rq.ctx, rq.getDeadLetterKey()).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count dead letter jobs: %w", err)
+	}
+
+	start := int64((page - 1) * limit)
+	entries, err := rq.client.LRange(rq.ctx, rq.getDeadLetterKey(), start, start+int64(limit)-1).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get dead letter jobs: %w", err)
+	}
+
+	result := make([]JobStatusInfo, 0, len(entries))
+	for _, entry := range entries {
+		var job Job
+		if err := json.Unmarshal([]byte(entry), &job); err != nil {
+			continue
+		}
+		result = append(result, newJobStatusInfo(&job))
+	}
+
+	return result, total, nil
+}
+
+// ReplayDeadLetterJobs re-enqueues dead letter jobs with a fresh attempt budget.
+// All dead letter jobs are replayed when jobIDs is empty. It returns the number
+// of jobs replayed.
+func (rq *RedisQueue) ReplayDeadLetterJobs(jobIDs []string) (int, error) {
+	return rq.drainDeadLetter(jobIDs, func(job *Job) error {
+		job.Attempts = 0
+		job.ErrorMsg = ""
+		job.Progress = 0
+		job.StartedAt = nil
+		job.CompletedAt = nil
+		job.ScheduledAt = time.Now()
+		return rq.Enqueue(job)
+	})
+}
+
+// PurgeDeadLetterJobs permanently removes dead letter jobs, together with their
+// failed job records. All dead letter jobs are removed when jobIDs is empty. It
+// returns the number of jobs removed.
+func (rq *RedisQueue) PurgeDeadLetterJobs(jobIDs []string) (int, error) {
+	return rq.drainDeadLetter(jobIDs, func(job *Job) error {
+		return rq.client.HDel(rq.ctx, rq.getJobsKey(), job.ID).Err()
+	})
+}
+
+// drainDeadLetter removes the selected dead letter entries, handing each job to
+// handle first. An entry is kept if handle fails.
+func (rq *RedisQueue) drainDeadLetter(jobIDs []string, handle func(job *Job) error) (int, error) {
+	if rq.client == nil {
+		return 0, fmt.Errorf("redis client not available")
+	}
+
+	entries, err := rq.client.LRange(rq.ctx, rq.getDeadLetterKey(), 0, -1).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get dead letter jobs: %w", err)
+	}
+
+	selected := make(map[string]bool, len(jobIDs))
+	for _, id := range jobIDs {
+		selected[id] = true
+	}
+
+	processed := 0
+	for _, entry := range entries {
+		var job Job
+		if err := json.Unmarshal([]byte(entry), &job); err != nil {
+			continue
+		}
+		if len(selected) > 0 && !selected[job.ID] {
+			continue
+		}
+
+		if handle != nil {
+			if err := handle(&job); err != nil {
+				return processed, fmt.Errorf("failed to process dead letter job %s: %w", job.ID, err)
+			}
+		}
+		if err := rq.client.LRem(rq.ctx, rq.getDeadLetterKey(), 1, entry).Err(); err != nil {
+			return processed, fmt.Errorf("failed to remove dead letter job %s: %w", job.ID, err)
+		}
+		processed++
+	}
+
+	return processed, nil
+}
+
+// Pause stops workers in every process from taking new jobs from the queue.
+// Jobs already running are allowed to finish.
+func (rq *RedisQueue) Pause() error {
+	if rq.client == nil {
+		return fmt.Errorf("redis client not available")
+	}
+	return rq.client.Set(rq.ctx, rq.getPausedKey(), time.Now().Unix(), 0).Err()
+}
+
+// Resume lets workers take jobs from a paused queue again
+func (rq *RedisQueue) Resume() error {
+	if rq.client == nil {
+		return fmt.Errorf("redis client not available")
+	}
+	if err := rq.client.Del(rq.ctx, rq.getPausedKey()).Err(); err != nil {
+		return err
+	}
+
+	// Wake up idle workers for jobs that were enqueued while paused
+	pending, err := rq.client.ZCard(rq.ctx, rq.getQueueKey()).Result()
+	if err != nil || pending == 0 {
+		return err
+	}
+	pipe := rq.client.Pipeline()
+	pipe.LPush(rq.ctx, rq.getNotificationKey(), "resume")
+	pipe.LTrim(rq.ctx, rq.getNotificationKey(), 0, 99)
+	_, err = pipe.Exec(rq.ctx)
+	return err
+}
+
+// IsPaused reports whether the queue is paused
+func (rq *RedisQueue) IsPaused() (bool, error) {
+	if rq.client == nil {
+		return false, fmt.Errorf("redis client not available")
+	}
+	count, err := rq.client.Exists(rq.ctx, rq.getPausedKey()).Result()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
 // getNotificationKey returns the Redis key for job notifications
 func (rq *RedisQueue) getNotificationKey() string {
 	return fmt.Sprintf("notifications:%s", rq.queueName)
 }
+
+// getDeadLetterKey returns the Redis key for the dead letter queue
+func (rq *RedisQueue) getDeadLetterKey() string {
+	return fmt.Sprintf("dead_letter:%s", rq.queueName)
+}
+
+// getPausedKey returns the Redis key that marks the queue as paused
+func (rq *RedisQueue) getPausedKey() string {
+	return fmt.Sprintf("paused:%s", rq.queueName)
+}
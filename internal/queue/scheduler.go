@@ -0,0 +1,288 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"news/internal/cache"
+	"news/internal/config"
+	"news/internal/services"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const schedulerLeaseKey = "scheduler:lease"
+
+// renewLeaseScript extends the lease only if it is still held by the caller
+var renewLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// releaseLeaseScript deletes the lease only if it is still held by the caller
+var releaseLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// Scheduler periodically publishes scheduled articles, unpublishes expired ones, starts and ends
+// live streams, sends scheduled newsletters, resumes stalled newsletter deliveries, retries
+// webhook deliveries, expires abandoned uploads, prunes old live news events and rebuilds the
+// sitemaps. Only the replica
+// holding the Redis lease runs the schedule, so several workers can run the scheduler at the
+// same time.
+type Scheduler struct {
+	client    *redis.Client
+	manager   *QueueManager
+	owner     string
+	interval  time.Duration
+	leaseTTL  time.Duration
+	batchSize int
+	holding   bool
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+
+	sitemapInterval    time.Duration
+	lastSitemapRefresh time.Time
+}
+
+// NewScheduler creates a new scheduler from the queue configuration. Scheduled newsletters
+// are enqueued through the given queue manager.
+func NewScheduler(cfg *config.QueueConfig, manager *QueueManager) *Scheduler {
+	redisClient := cache.GetRedisClient()
+	if redisClient == nil || redisClient.GetClient() == nil {
+		log.Printf("Warning: Redis client not available, scheduler disabled")
+		return nil
+	}
+
+	interval := time.Duration(cfg.SchedulerInterval) * time.Second
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	batchSize := cfg.SchedulerBatchSize
+	if batchSize <= 0 {
+		batchSize = 50
+	}
+
+	hostname, _ := os.Hostname()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Scheduler{
+		client:    redisClient.GetClient(),
+		manager:   manager,
+		owner:     fmt.Sprintf("%s:%d:%d", hostname, os.Getpid(), time.Now().UnixNano()),
+		interval:  interval,
+		leaseTTL:  3 * interval,
+		batchSize: batchSize,
+		ctx:       ctx,
+		cancel:    cancel,
+
+		sitemapInterval: config.GetSitemapConfig().RefreshInterval,
+	}
+}
+
+// Start runs the scheduler loop in the background
+func (s *Scheduler) Start() {
+	log.Printf("Starting scheduler (interval: %s, batch size: %d)", s.interval, s.batchSize)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		s.tick()
+		for {
+			select {
+			case <-ticker.C:
+				s.tick()
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the scheduler loop and releases the lease so another replica can take over
+func (s *Scheduler) Stop() {
+	s.cancel()
+	s.wg.Wait()
+
+	if s.holding {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := releaseLeaseScript.Run(ctx, s.client, []string{schedulerLeaseKey}, s.owner).Err(); err != nil {
+			log.Printf("Warning: Failed to release scheduler lease: %v", err)
+		}
+		s.holding = false
+	}
+
+	log.Println("Scheduler stopped")
+}
+
+// tick runs the schedule once if this replica holds the lease
+func (s *Scheduler) tick() {
+	if !s.acquireLease() {
+		return
+	}
+
+	result, err := services.RunArticleSchedule(time.Now(), s.batchSize)
+	if err != nil {
+		log.Printf("Article scheduler run failed: %v", err)
+	}
+	if result != nil && (len(result.Published) > 0 || len(result.Unpublished) > 0) {
+		log.Printf("Article scheduler: published %d, unpublished %d", len(result.Published), len(result.Unpublished))
+	}
+
+	live, err := services.RunLiveNewsSchedule(time.Now(), s.batchSize)
+	if err != nil {
+		log.Printf("Live news scheduler run failed: %v", err)
+	}
+	if live != nil && (len(live.Started) > 0 || len(live.Ended) > 0) {
+		log.Printf("Live news scheduler: started %d, ended %d", len(live.Started), len(live.Ended))
+	}
+
+	s.dispatchNewsletters()
+	s.dispatchStalledNewsletterDeliveries()
+	s.dispatchWebhookRetries()
+	s.dispatchSitemapRefresh()
+
+	expired, err := services.ExpireUploadSessions(time.Now(), s.batchSize)
+	if err != nil {
+		log.Printf("Failed to expire upload sessions: %v", err)
+	}
+	if expired > 0 {
+		log.Printf("Expired %d abandoned uploads", expired)
+	}
+
+	pruned, err := services.PruneLiveNewsEvents(time.Now(), s.batchSize)
+	if err != nil {
+		log.Printf("Failed to prune live news events: %v", err)
+	}
+	if pruned > 0 {
+		log.Printf("Pruned %d old live news events", pruned)
+	}
+}
+
+// dispatchNewsletters enqueues the deliveries of scheduled newsletters that are due
+func (s *Scheduler) dispatchNewsletters() {
+	if s.manager == nil {
+		return
+	}
+
+	newsletterIDs, err := services.GetDueNewsletterIDs(time.Now())
+	if err != nil {
+		log.Printf("Failed to load scheduled newsletters: %v", err)
+		return
+	}
+
+	for _, newsletterID := range newsletterIDs {
+		enqueued, err := s.manager.EnqueueNewsletter(newsletterID)
+		if err != nil {
+			log.Printf("Failed to send scheduled newsletter %d: %v", newsletterID, err)
+			continue
+		}
+		log.Printf("Scheduled newsletter %d: enqueued %d deliveries", newsletterID, enqueued)
+	}
+}
+
+// dispatchStalledNewsletterDeliveries enqueues deliveries of sending newsletters that were never
+// enqueued, or whose job was lost
+func (s *Scheduler) dispatchStalledNewsletterDeliveries() {
+	if s.manager == nil {
+		return
+	}
+
+	deliveries, err := services.GetStalledNewsletterDeliveries(time.Now(), s.batchSize)
+	if err != nil {
+		log.Printf("Failed to load stalled newsletter deliveries: %v", err)
+		return
+	}
+
+	for _, delivery := range deliveries {
+		if _, err := s.manager.EnqueueNewsletterDelivery(delivery.NewsletterID, delivery.ID); err != nil {
+			log.Printf("Failed to enqueue newsletter delivery %d: %v", delivery.ID, err)
+		}
+	}
+}
+
+// dispatchWebhookRetries enqueues webhook deliveries whose next attempt is due
+func (s *Scheduler) dispatchWebhookRetries() {
+	if s.manager == nil {
+		return
+	}
+
+	deliveryIDs, err := services.GetDueWebhookDeliveryIDs(time.Now(), s.batchSize)
+	if err != nil {
+		log.Printf("Failed to load due webhook deliveries: %v", err)
+		return
+	}
+
+	for _, deliveryID := range deliveryIDs {
+		claimed, err := services.ClaimWebhookDelivery(deliveryID)
+		if err != nil {
+			log.Printf("Failed to claim webhook delivery %d: %v", deliveryID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		if err := s.manager.EnqueueWebhookDelivery(deliveryID); err != nil {
+			log.Printf("Failed to enqueue webhook delivery %d: %v", deliveryID, err)
+			services.ReleaseWebhookDelivery(deliveryID)
+		}
+	}
+}
+
+// dispatchSitemapRefresh enqueues a rebuild of every sitemap once per refresh interval, so
+// articles leave the news sitemap as they age and changes without a refresh of their own, such
+// as new translations, are picked up
+func (s *Scheduler) dispatchSitemapRefresh() {
+	if s.manager == nil || s.sitemapInterval <= 0 || time.Since(s.lastSitemapRefresh) < s.sitemapInterval {
+		return
+	}
+	if err := s.manager.EnqueueSitemapRefresh("", 0); err != nil {
+		log.Printf("Failed to enqueue sitemap refresh: %v", err)
+		return
+	}
+	s.lastSitemapRefresh = time.Now()
+}
+
+// acquireLease takes or renews the scheduler lease. The lease outlives several intervals so a
+// slow run does not hand it to another replica, and expires on its own if this worker dies.
+func (s *Scheduler) acquireLease() bool {
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+
+	if s.holding {
+		renewed, err := renewLeaseScript.Run(ctx, s.client, []string{schedulerLeaseKey}, s.owner, s.leaseTTL.Milliseconds()).Int64()
+		if err == nil && renewed == 1 {
+			return true
+		}
+		if err != nil {
+			log.Printf("Warning: Failed to renew scheduler lease: %v", err)
+		} else {
+			log.Println("Scheduler lease lost to another worker")
+		}
+		s.holding = false
+	}
+
+	acquired, err := s.client.SetNX(ctx, schedulerLeaseKey, s.owner, s.leaseTTL).Result()
+	if err != nil {
+		log.Printf("Warning: Failed to acquire scheduler lease: %v", err)
+		return false
+	}
+	if acquired {
+		log.Printf("Scheduler lease acquired by %s", s.owner)
+		s.holding = true
+	}
+	return acquired
+}
@@ -133,6 +133,15 @@ func (wp *WorkerPool) worker(workerID int) {
 		case <-wp.ctx.Done():
 			return
 		default:
+			if paused, _ := wp.queue.IsPaused(); paused {
+				// Check again shortly; running jobs are unaffected by a pause
+				select {
+				case <-wp.ctx.Done():
+					return
+				case <-time.After(5 * time.Second):
+				}
+				continue
+			}
 			wp.processNextJobBlocking(workerID)
 		}
 	}
@@ -172,7 +181,9 @@ func (wp *WorkerPool) processNextJobBlocking(workerID int) {
 	ctx, cancel := context.WithTimeout(wp.ctx, 10*time.Minute) // 10 minute timeout
 	defer cancel()
 
-	err = processor.ProcessJob(ctx, job)
+	publishJobUpdate(wp.queue, job, JobStatusProcessing, "")
+
+	err = processor.ProcessJob(withProgressReporter(ctx, wp.queue, job), job)
 	if err != nil {
 		log.Printf("Worker %d: Job %s failed: %v", workerID, job.ID, err)
 
@@ -180,6 +191,13 @@ func (wp *WorkerPool) processNextJobBlocking(workerID int) {
 		if retryErr := wp.queue.FailJob(job.ID, err.Error()); retryErr != nil {
 			log.Printf("Worker %d: Error handling job failure: %v", workerID, retryErr)
 		}
+
+		job.Attempts++
+		status := JobStatusRetrying
+		if job.Attempts >= job.MaxAttempts {
+			status = JobStatusFailed
+		}
+		publishJobUpdate(wp.queue, job, status, err.Error())
 		return
 	}
 
@@ -188,6 +206,9 @@ func (wp *WorkerPool) processNextJobBlocking(workerID int) {
 	if completeErr := wp.queue.CompleteJob(job.ID, nil); completeErr != nil {
 		log.Printf("Worker %d: Error marking job as complete: %v", workerID, completeErr)
 	}
+
+	job.Progress = 100
+	publishJobUpdate(wp.queue, job, JobStatusCompleted, "")
 }
 
 // monitor provides periodic stats and health checks
@@ -0,0 +1,256 @@
+// Package recommend scores articles against a reader's category, tag and author affinities.
+// It has no database access, so services load the signals and candidates and this package
+// only does the arithmetic.
+package recommend
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Reasons explain which affinity matched a recommendation best
+const (
+	ReasonCategory = "category"
+	ReasonTag      = "tag"
+	ReasonAuthor   = "author"
+	ReasonPopular  = "popular"
+	ReasonSemantic = "semantic"
+)
+
+// Signal weights per interaction. Negative signals lower the affinity of what was disliked.
+var SignalWeights = map[string]float64{
+	"view":     1,
+	"comment":  2,
+	"upvote":   2,
+	"like":     2,
+	"bookmark": 3,
+	"share":    3,
+	"downvote": -2,
+	"dislike":  -2,
+}
+
+// Defaults used by the recommendation service
+const (
+	SignalHalfLife    = 14 * 24 * time.Hour // Interest in a topic halves every two weeks
+	FreshnessHalfLife = 3 * 24 * time.Hour  // Candidates lose half their freshness every three days
+	FollowWeight      = 5.0                 // Following an author counts like several reads
+	authorShare       = 0.5                 // Reads say less about the author than about the topic
+	popularityWeight  = 0.1
+)
+
+// Decay returns the weight left after age with the given half-life, between 0 and 1
+func Decay(age, halfLife time.Duration) float64 {
+	if age <= 0 || halfLife <= 0 {
+		return 1
+	}
+	return math.Pow(0.5, float64(age)/float64(halfLife))
+}
+
+// Features are the properties of an article that affinities are built on
+type Features struct {
+	CategoryIDs []uint
+	TagIDs      []uint
+	AuthorID    uint
+}
+
+// affinity accumulates the weight of one category, tag or author and remembers the source
+// that contributed most, to explain recommendations
+type affinity struct {
+	weight       float64
+	source       string
+	sourceWeight float64
+	followed     bool
+}
+
+func (a *affinity) add(weight float64, source string, followed bool) {
+	a.weight += weight
+	if weight > a.sourceWeight {
+		a.sourceWeight = weight
+		a.source = source
+		a.followed = followed
+	}
+}
+
+// Profile holds the affinities of one reader
+type Profile struct {
+	categories map[uint]*affinity
+	tags       map[uint]*affinity
+	authors    map[uint]*affinity
+}
+
+// NewProfile returns an empty profile
+func NewProfile() *Profile {
+	return &Profile{
+		categories: make(map[uint]*affinity),
+		tags:       make(map[uint]*affinity),
+		authors:    make(map[uint]*affinity),
+	}
+}
+
+// AddArticle records a signal of the given weight on an article titled title
+func (p *Profile) AddArticle(f Features, weight float64, title string) {
+	if weight == 0 {
+		return
+	}
+	for _, id := range f.CategoryIDs {
+		get(p.categories, id).add(weight, title, false)
+	}
+	for _, id := range f.TagIDs {
+		get(p.tags, id).add(weight, title, false)
+	}
+	if f.AuthorID != 0 {
+		get(p.authors, f.AuthorID).add(weight*authorShare, title, false)
+	}
+}
+
+// AddFollow records that the reader follows the author named name
+func (p *Profile) AddFollow(authorID uint, weight float64, name string) {
+	get(p.authors, authorID).add(weight, name, true)
+}
+
+// Empty reports whether the profile has no positive affinity, i.e. the reader is cold
+func (p *Profile) Empty() bool {
+	for _, set := range []map[uint]*affinity{p.categories, p.tags, p.authors} {
+		for _, a := range set {
+			if a.weight > 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// TopCategories returns up to n category IDs with the highest positive affinity
+func (p *Profile) TopCategories(n int) []uint { return top(p.categories, n) }
+
+// TopTags returns up to n tag IDs with the highest positive affinity
+func (p *Profile) TopTags(n int) []uint { return top(p.tags, n) }
+
+// TopAuthors returns up to n author IDs with the highest positive affinity
+func (p *Profile) TopAuthors(n int) []uint { return top(p.authors, n) }
+
+// Match is the affinity of a candidate article and the affinity that explains it best
+type Match struct {
+	Affinity    float64
+	Reason      string
+	Explanation string
+}
+
+// Match sums the affinities of the candidate's categories, tags and author
+func (p *Profile) Match(f Features) Match {
+	var match Match
+	var best *affinity
+	bestReason := ""
+
+	consider := func(a *affinity, reason string) {
+		if a == nil {
+			return
+		}
+		match.Affinity += a.weight
+		if a.weight > 0 && (best == nil || a.weight > best.weight) {
+			best = a
+			bestReason = reason
+		}
+	}
+	for _, id := range f.CategoryIDs {
+		consider(p.categories[id], ReasonCategory)
+	}
+	for _, id := range f.TagIDs {
+		consider(p.tags[id], ReasonTag)
+	}
+	if f.AuthorID != 0 {
+		consider(p.authors[f.AuthorID], ReasonAuthor)
+	}
+
+	if best != nil {
+		match.Reason = bestReason
+		match.Explanation = explain(best)
+	}
+	return match
+}
+
+// Score combines affinity with the freshness of a candidate and its recent popularity
+func Score(affinity float64, publishedAt, now time.Time, popularity float64) float64 {
+	freshness := Decay(now.Sub(publishedAt), FreshnessHalfLife)
+	return affinity*(0.5+0.5*freshness) + popularityWeight*math.Log1p(math.Max(popularity, 0))
+}
+
+// Similarity scores how close a candidate is to a source article: shared tags count more than
+// shared categories, which count more than a shared author. It returns the reason for the
+// strongest overlap and the shared IDs for that reason.
+func Similarity(source, candidate Features) (float64, string, []uint) {
+	sharedTags := intersect(source.TagIDs, candidate.TagIDs)
+	sharedCategories := intersect(source.CategoryIDs, candidate.CategoryIDs)
+	sameAuthor := source.AuthorID != 0 && source.AuthorID == candidate.AuthorID
+
+	score := 2*float64(len(sharedTags)) + float64(len(sharedCategories))
+	if sameAuthor {
+		score += authorShare
+	}
+
+	switch {
+	case len(sharedTags) > 0:
+		return score, ReasonTag, sharedTags
+	case len(sharedCategories) > 0:
+		return score, ReasonCategory, sharedCategories
+	case sameAuthor:
+		return score, ReasonAuthor, []uint{source.AuthorID}
+	}
+	return 0, "", nil
+}
+
+// PopularExplanation explains recommendations made without any affinity
+func PopularExplanation() string {
+	return "Popular right now"
+}
+
+func explain(a *affinity) string {
+	if a.followed {
+		return "Because you follow " + a.source
+	}
+	return "Because you read \"" + a.source + "\""
+}
+
+func get(set map[uint]*affinity, id uint) *affinity {
+	a, ok := set[id]
+	if !ok {
+		a = &affinity{}
+		set[id] = a
+	}
+	return a
+}
+
+func intersect(a, b []uint) []uint {
+	in := make(map[uint]bool, len(a))
+	for _, id := range a {
+		in[id] = true
+	}
+	var shared []uint
+	for _, id := range b {
+		if in[id] {
+			shared = append(shared, id)
+			delete(in, id)
+		}
+	}
+	return shared
+}
+
+func top(set map[uint]*affinity, n int) []uint {
+	ids := make([]uint, 0, len(set))
+	for id, a := range set {
+		if a.weight > 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if set[ids[i]].weight != set[ids[j]].weight {
+			return set[ids[i]].weight > set[ids[j]].weight
+		}
+		return ids[i] < ids[j]
+	})
+	if len(ids) > n {
+		ids = ids[:n]
+	}
+	return ids
+}
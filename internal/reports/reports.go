@@ -0,0 +1,83 @@
+// Package reports holds the rules of reader reports: the reason taxonomy, how much each reason
+// weighs toward hiding content automatically, and the reporter rate limit. It has no database
+// access; services count reports and this package decides.
+package reports
+
+import "sort"
+
+// Reason describes why content was reported
+type Reason struct {
+	Code        string `json:"code"`
+	Label       string `json:"label"`
+	Description string `json:"description"`
+	// Weight is how much a report counts toward the auto-hide threshold
+	Weight int `json:"weight"`
+}
+
+// Reasons is the report taxonomy. Reports of content that harms people weigh more than
+// reports of content that is merely unwanted.
+var Reasons = []Reason{
+	{Code: "spam", Label: "Spam", Description: "Advertising, scams or repeated off-topic posts", Weight: 1},
+	{Code: "harassment", Label: "Harassment", Description: "Insults, threats or personal attacks", Weight: 2},
+	{Code: "hate_speech", Label: "Hate speech", Description: "Attacks on people based on who they are", Weight: 2},
+	{Code: "violence", Label: "Violence", Description: "Incitement to or glorification of violence", Weight: 2},
+	{Code: "sexual_content", Label: "Sexual content", Description: "Explicit or sexual content", Weight: 2},
+	{Code: "misinformation", Label: "Misinformation", Description: "False or misleading claims", Weight: 1},
+	{Code: "privacy", Label: "Privacy", Description: "Personal information shared without consent", Weight: 2},
+	{Code: "copyright", Label: "Copyright", Description: "Content copied without permission", Weight: 1},
+	{Code: "other", Label: "Other", Description: "Anything else moderators should look at", Weight: 1},
+}
+
+var reasonsByCode = func() map[string]Reason {
+	byCode := make(map[string]Reason, len(Reasons))
+	for _, reason := range Reasons {
+		byCode[reason.Code] = reason
+	}
+	return byCode
+}()
+
+// ValidReason reports whether code is in the taxonomy
+func ValidReason(code string) bool {
+	_, ok := reasonsByCode[code]
+	return ok
+}
+
+// Weight returns the weight of the reports with the given reasons
+func Weight(reasons []string) int {
+	total := 0
+	for _, code := range reasons {
+		total += reasonsByCode[code].Weight
+	}
+	return total
+}
+
+// ShouldHide reports whether open reports with the given reasons reach the auto-hide
+// threshold. A threshold of 0 disables auto-hiding.
+func ShouldHide(reasons []string, threshold int) bool {
+	return threshold > 0 && Weight(reasons) >= threshold
+}
+
+// TopReasons returns the reasons of a set of reports, most frequent first
+func TopReasons(reasons []string) []string {
+	counts := make(map[string]int)
+	for _, code := range reasons {
+		counts[code]++
+	}
+	top := make([]string, 0, len(counts))
+	for code := range counts {
+		top = append(top, code)
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if counts[top[i]] != counts[top[j]] {
+			return counts[top[i]] > counts[top[j]]
+		}
+		return top[i] < top[j]
+	})
+	return top
+}
+
+// WithinRateLimit reports whether a reporter who filed recent reports in the current window may
+// file another. A limit of 0 disables the rate limit.
+func WithinRateLimit(recent int64, limit int) bool {
+	return limit <= 0 || recent < int64(limit)
+}
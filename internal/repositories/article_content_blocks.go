@@ -254,6 +254,75 @@ func (r *ArticleContentBlockRepository) BulkCreateBlocks(blocks []models.Article
 	})
 }
 
+// ReplaceBlocks makes blocks the content blocks of an article. Blocks whose ID is one of the
+// article's blocks are updated in place, so anything referencing them keeps working; other
+// blocks are created, and the article's blocks that are not in the list are deleted. The IDs
+// of created blocks are set on blocks.
+func (r *ArticleContentBlockRepository) ReplaceBlocks(articleID uint, blocks []models.ArticleContentBlock) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var existingIDs []uint
+		if err := tx.Model(&models.ArticleContentBlock{}).Where("article_id = ?", articleID).Pluck("id", &existingIDs).Error; err != nil {
+			return err
+		}
+		existing := make(map[uint]bool, len(existingIDs))
+		for _, id := range existingIDs {
+			existing[id] = true
+		}
+
+		kept := make(map[uint]bool, len(blocks))
+		for i := range blocks {
+			blocks[i].ArticleID = articleID
+			if !blocks[i].ValidateBlockType() {
+				return fmt.Errorf("invalid block type: %s", blocks[i].BlockType)
+			}
+			if len(blocks[i].Settings) == 0 {
+				if settingsJSON, err := json.Marshal(blocks[i].GetDefaultSettings()); err == nil {
+					blocks[i].Settings = datatypes.JSON(settingsJSON)
+				}
+			}
+
+			// Unknown and repeated IDs get a new block
+			if !existing[blocks[i].ID] || kept[blocks[i].ID] {
+				blocks[i].ID = 0
+				continue
+			}
+			kept[blocks[i].ID] = true
+
+			if err := tx.Model(&models.ArticleContentBlock{}).Where("id = ?", blocks[i].ID).Updates(map[string]interface{}{
+				"block_type": blocks[i].BlockType,
+				"content":    blocks[i].Content,
+				"settings":   blocks[i].Settings,
+				"position":   blocks[i].Position,
+				"is_visible": blocks[i].IsVisible,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		var removed []uint
+		for _, id := range existingIDs {
+			if !kept[id] {
+				removed = append(removed, id)
+			}
+		}
+		if len(removed) > 0 {
+			if err := tx.Delete(&models.ArticleContentBlock{}, removed).Error; err != nil {
+				return err
+			}
+		}
+
+		for i := range blocks {
+			if blocks[i].ID != 0 {
+				continue
+			}
+			if err := tx.Create(&blocks[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // MigrateArticleToBlocks converts legacy article content to content blocks
 func (r *ArticleContentBlockRepository) MigrateArticleToBlocks(articleID uint) error {
 	// Get the article
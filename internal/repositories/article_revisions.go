@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"news/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ArticleRevisionRepository handles database operations for article revisions
+type ArticleRevisionRepository struct {
+	db *gorm.DB
+}
+
+// NewArticleRevisionRepository creates a new instance of ArticleRevisionRepository
+func NewArticleRevisionRepository(db *gorm.DB) *ArticleRevisionRepository {
+	return &ArticleRevisionRepository{db: db}
+}
+
+// Create stores a new revision snapshot
+func (r *ArticleRevisionRepository) Create(revision *models.ArticleRevision) error {
+	return r.db.Create(revision).Error
+}
+
+// GetLatestRevisionNumber returns the highest revision number recorded for an article
+func (r *ArticleRevisionRepository) GetLatestRevisionNumber(articleID uint) (int, error) {
+	var latest int
+	err := r.db.Model(&models.ArticleRevision{}).
+		Where("article_id = ?", articleID).
+		Select("COALESCE(MAX(revision_number), 0)").
+		Scan(&latest).Error
+	return latest, err
+}
+
+// CountByArticleID counts the revisions recorded for an article
+func (r *ArticleRevisionRepository) CountByArticleID(articleID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.ArticleRevision{}).
+		Where("article_id = ?", articleID).
+		Count(&count).Error
+	return count, err
+}
+
+// ListByArticleID retrieves revisions for an article, newest first, without the heavy content fields
+func (r *ArticleRevisionRepository) ListByArticleID(articleID uint, offset, limit int) ([]models.ArticleRevision, error) {
+	var revisions []models.ArticleRevision
+	err := r.db.Omit("content", "blocks").
+		Preload("Editor").
+		Where("article_id = ?", articleID).
+		Order("revision_number DESC").
+		Offset(offset).Limit(limit).
+		Find(&revisions).Error
+	if err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// GetByNumber retrieves a single revision of an article by its revision number
+func (r *ArticleRevisionRepository) GetByNumber(articleID uint, revisionNumber int) (*models.ArticleRevision, error) {
+	var revision models.ArticleRevision
+	err := r.db.Preload("Editor").
+		Where("article_id = ? AND revision_number = ?", articleID, revisionNumber).
+		First(&revision).Error
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// GetLatest retrieves the most recent revision of an article
+func (r *ArticleRevisionRepository) GetLatest(articleID uint) (*models.ArticleRevision, error) {
+	var revision models.ArticleRevision
+	err := r.db.Preload("Editor").
+		Where("article_id = ?", articleID).
+		Order("revision_number DESC").
+		First(&revision).Error
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
@@ -78,6 +78,20 @@ func GetArticleByID(id string) (models.Article, error) {
 	return article, nil
 }
 
+// GetArticleByIDAnyStatus retrieves a single article by ID whatever its status
+func GetArticleByIDAnyStatus(id string) (models.Article, error) {
+	// Track database operation
+	defer metrics.TrackDatabaseOperation("get_article_by_id_any_status")()
+
+	var article models.Article
+	if err := database.DB.Preload("Author").Preload("Categories").Preload("Tags").
+		Where("id = ?", id).First(&article).Error; err != nil {
+		return models.Article{}, err
+	}
+
+	return article, nil
+}
+
 // InsertArticle creates a new article
 func InsertArticle(article models.Article) (models.Article, error) {
 	// Track database operation
@@ -73,7 +73,7 @@ func (r *PageRepository) GetBySlug(slug string, includeBlocks bool) (*models.Pag
 
 	if err := query.Where("slug = ? AND status != ?", slug, "archived").First(&page).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("page with slug '%s' not found", slug)
+			return nil, fmt.Errorf("page with slug '%s' not found: %w", slug, err)
 		}
 		return nil, err
 	}
@@ -34,7 +34,7 @@ func (r *PageTemplateRepository) GetByID(id uint) (*models.PageTemplate, error)
 	var template models.PageTemplate
 	if err := r.db.Preload("Creator").First(&template, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("page template with ID %d not found", id)
+			return nil, fmt.Errorf("page template with ID %d not found: %w", id, err)
 		}
 		return nil, err
 	}
@@ -7,7 +7,6 @@ import (
 	"news/internal/handlers"
 	"news/internal/middleware"
 	"news/internal/tracing"
-	"strconv"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -17,16 +16,18 @@ import (
 )
 
 func RegisterRoutes(r *gin.Engine) {
-	// Initialize API keys
-	middleware.InitAPIKeys()
-
-	// Initialize semantic search rate limiter
+	// Initialize semantic search rate limiter, API key quotas and the subscribe limit
 	redisClient := cache.GetRedisClient()
 	var searchLimiter *middleware.SemanticSearchLimiter
+	var subscribeLimit gin.HandlerFunc
 	if redisClient != nil {
 		searchLimiter = middleware.NewSemanticSearchLimiter(middleware.DefaultSearchLimitConfig(), redisClient.GetClient())
+		middleware.InitAPIKeys(redisClient.GetClient())
+		subscribeLimit = middleware.SubscribeRateLimit(redisClient.GetClient())
 	} else {
 		searchLimiter = middleware.NewSemanticSearchLimiter(middleware.DefaultSearchLimitConfig(), nil)
+		middleware.InitAPIKeys(nil)
+		subscribeLimit = middleware.SubscribeRateLimit(nil)
 	}
 
 	// Add enhanced OpenTelemetry middleware for distributed tracing
@@ -263,6 +264,20 @@ func RegisterRoutes(r *gin.Engine) {
 		security.GET("/security/events", securityHandler.GetSecurityEvents)              // Get security events
 	}
 
+	// Syndication feeds (RSS, Atom and JSON Feed) - no auth required
+	feeds := r.Group("/feeds")
+	feeds.Use(middleware.RateLimit(50, 100, true))
+	{
+		feeds.GET("/latest", handlers.GetLatestFeed)
+		feeds.GET("/category/:slug", handlers.GetCategoryFeed)
+		feeds.GET("/tag/:slug", handlers.GetTagFeed)
+		feeds.GET("/author/:username", handlers.GetAuthorFeed)
+	}
+
+	// Sitemaps - no auth required
+	r.GET("/sitemap.xml", middleware.RateLimit(50, 100, true), handlers.GetSitemapIndex)
+	r.GET("/sitemaps/:file", middleware.RateLimit(50, 100, true), handlers.GetSitemapFile)
+
 	// Public API routes
 	api := r.Group("/api")
 	api.Use(middleware.RateLimit(50, 100, true)) // Increased public API rate limits for high-concurrency testing
@@ -317,6 +332,8 @@ func RegisterRoutes(r *gin.Engine) {
 		api.GET("/live-news", liveNewsHandler.GetActiveLiveStreams)
 		api.GET("/live-news/:id", liveNewsHandler.GetLiveStreamByID)
 		api.GET("/live-news/:id/updates", liveNewsHandler.GetLiveUpdates)
+		api.GET("/live-news/:id/events", liveNewsHandler.GetLiveEvents)
+		api.GET("/live-news/:id/stream", liveNewsHandler.StreamLiveEvents)
 
 		// Video endpoints (Public and Authenticated) - using external route setup
 		videoHandler := handlers.NewVideoHandler()
@@ -351,6 +368,13 @@ func RegisterRoutes(r *gin.Engine) {
 
 		// Comments (Public view, authenticated to create/interact)
 		api.GET("/articles/:id/comments", handlers.GetComments) // Get comments for an article
+		api.GET("/reports/reasons", handlers.GetReportReasons)  // Report reason taxonomy
+
+		// Subscriptions (Public, double opt-in)
+		api.POST("/subscriptions", subscribeLimit, handlers.CreateSubscription)       // Subscribe an email address (sends confirmation)
+		api.GET("/subscriptions/confirm/:token", handlers.ConfirmSubscription)        // Confirm subscription from email link
+		api.GET("/subscriptions/unsubscribe/:token", handlers.GetSubscriptionByToken) // Show subscription before unsubscribing
+		api.POST("/subscriptions/unsubscribe/:token", handlers.Unsubscribe)           // One-click unsubscribe (RFC 8058)
 
 		// Article Translations (Public read with language support)
 		translationHandler := handlers.NewArticleTranslationHandlers()
@@ -396,6 +420,17 @@ func RegisterRoutes(r *gin.Engine) {
 		api.GET("/pages/hierarchy", handlers.GetPageHierarchy) // Get page hierarchy
 		api.GET("/pages/:id/blocks", handlers.GetPageBlocks)   // Get content blocks for a page
 
+		// Page templates (Public browse and search)
+		api.GET("/page-templates", handlers.GetPageTemplates)                     // List public templates
+		api.GET("/page-templates/search", handlers.SearchPageTemplates)           // Search by name, description and tags
+		api.GET("/page-templates/popular", handlers.GetPopularPageTemplates)      // Most used templates
+		api.GET("/page-templates/featured", handlers.GetFeaturedPageTemplates)    // Best rated premium templates
+		api.GET("/page-templates/categories", handlers.GetPageTemplateCategories) // Template categories with counts
+		api.GET("/page-templates/:id", handlers.GetPageTemplate)                  // Get public template
+
+		// SEO metadata and JSON-LD for articles, pages, videos and live streams
+		api.GET("/seo/:entity_type/:id", handlers.GetSEOMetadata)
+
 		// Content Block Utilities (Public and authenticated endpoints)
 		api.POST("/content-blocks/detect-embeds", handlers.DetectEmbeds)                          // Detect embeds from URLs (public)
 		api.POST("/content-blocks/analyze-url", handlers.AnalyzeURL)                              // Analyze URL for content extraction (public)
@@ -438,6 +473,19 @@ func RegisterRoutes(r *gin.Engine) {
 		publicSearch.GET("/limits", handlers.GetSearchLimitStatus)
 	}
 
+	// Resumable chunked uploads of large media files and videos. Chunks are limited by size, so
+	// these routes are not rate limited per request like the interactions below.
+	uploads := r.Group("/api/uploads")
+	uploads.Use(middleware.Authenticate())
+	{
+		uploads.POST("", handlers.CreateUpload)
+		uploads.GET("/:id", handlers.GetUpload)
+		uploads.HEAD("/:id", handlers.GetUpload)
+		uploads.PATCH("/:id", handlers.WriteUploadChunk)
+		uploads.POST("/:id/complete", handlers.CompleteUpload)
+		uploads.DELETE("/:id", handlers.AbortUpload)
+	}
+
 	// Authenticated User Interactions (Interactions like votes, bookmarks, follows)
 	interactions := r.Group("/api")
 	interactions.Use(middleware.Authenticate(), middleware.RateLimit(5, 10, true))
@@ -465,6 +513,9 @@ func RegisterRoutes(r *gin.Engine) {
 		interactions.DELETE("/comments/:id", handlers.DeleteComment)
 		interactions.POST("/comments/:id/vote", handlers.VoteComment) // upvote/downvote a comment
 
+		// Content Reports
+		interactions.POST("/reports", handlers.CreateContentReport) // Report an article, comment or video comment
+
 		// Article Interactions
 		interactions.POST("/articles/:id/bookmark", handlers.BookmarkArticle)
 		interactions.POST("/articles/:id/vote", handlers.VoteArticle) // upvote/downvote an article
@@ -476,7 +527,14 @@ func RegisterRoutes(r *gin.Engine) {
 		interactions.GET("/following/:user_id", handlers.GetUserFollowing)
 
 		// User Reading History (Authenticated)
-		interactions.GET("/user/reading-history", handlers.GetReadingHistory) // Get user's reading history
+		interactions.GET("/user/reading-history", handlers.GetReadingHistory)      // Get user's reading history
+		interactions.GET("/user/recommendations", handlers.GetRecommendedArticles) // Personalized recommendations
+
+		// Subscription management
+		interactions.GET("/user/subscriptions", handlers.GetUserSubscriptions)          // Get user's subscriptions
+		interactions.POST("/user/subscriptions", handlers.CreateUserSubscription)       // Subscribe as the signed-in user
+		interactions.PUT("/user/subscriptions/:id", handlers.UpdateUserSubscription)    // Pause, resume or change language
+		interactions.DELETE("/user/subscriptions/:id", handlers.DeleteUserSubscription) // Delete subscription
 
 		// News Stories - User specific interactions
 		newsStoriesHandler := handlers.NewNewsStoriesHandler()
@@ -496,6 +554,18 @@ func RegisterRoutes(r *gin.Engine) {
 		admin.PUT("/articles/:id", handlers.UpdateArticle)
 		admin.DELETE("/articles/:id", handlers.DeleteArticle)
 
+		// Article Revision History
+		admin.GET("/articles/:id/revisions", handlers.GetArticleRevisions)                       // List revisions
+		admin.GET("/articles/:id/revisions/compare", handlers.CompareArticleRevisions)           // Diff two revisions
+		admin.GET("/articles/:id/revisions/:revision", handlers.GetArticleRevision)              // Get revision snapshot
+		admin.POST("/articles/:id/revisions/:revision/restore", handlers.RestoreArticleRevision) // Restore revision
+
+		// Editorial Workflow
+		admin.GET("/articles/:id/workflow", handlers.GetArticleWorkflow)        // Status, allowed transitions and history
+		admin.POST("/articles/:id/transition", handlers.TransitionArticle)      // Change status
+		admin.POST("/articles/:id/review-notes", handlers.AddArticleReviewNote) // Leave a review note
+		admin.GET("/reviews", handlers.GetReviewQueue)                          // Articles waiting for review
+
 		// Admin Category Management
 		admin.POST("/categories", handlers.CreateCategory)
 		admin.PUT("/categories/:id", handlers.UpdateCategory)
@@ -514,6 +584,17 @@ func RegisterRoutes(r *gin.Engine) {
 		admin.POST("/pages/:id/unpublish", handlers.UnpublishPage) // Unpublish page
 		admin.POST("/pages/:id/duplicate", handlers.DuplicatePage) // Duplicate page
 
+		// Page Template Management
+		admin.GET("/page-templates", handlers.GetAdminPageTemplates)                // List public and private templates
+		admin.GET("/page-templates/:id", handlers.GetAdminPageTemplate)             // Get template
+		admin.POST("/page-templates", handlers.CreatePageTemplate)                  // Create template
+		admin.PUT("/page-templates/:id", handlers.UpdatePageTemplate)               // Update template
+		admin.DELETE("/page-templates/:id", handlers.DeletePageTemplate)            // Delete template
+		admin.POST("/page-templates/:id/duplicate", handlers.DuplicatePageTemplate) // Duplicate template
+		admin.PUT("/page-templates/:id/rating", handlers.RatePageTemplate)          // Set template rating
+		admin.POST("/pages/from-template/:id", handlers.CreatePageFromTemplate)     // Create draft page with the template's blocks
+		admin.POST("/pages/:id/save-as-template", handlers.SavePageAsTemplate)      // Save page blocks as a new template
+
 		// Page Content Block Management
 		admin.POST("/pages/:id/blocks", handlers.CreatePageBlock)             // Create content block for page
 		admin.GET("/page-blocks/:id", handlers.GetPageBlock)                  // Get content block
@@ -542,6 +623,8 @@ func RegisterRoutes(r *gin.Engine) {
 		admin.PUT("/live-news/:id", liveNewsHandler.UpdateLiveStream)
 		admin.DELETE("/live-news/:id", liveNewsHandler.DeleteLiveStream)
 		admin.POST("/live-news/:id/updates", liveNewsHandler.AddLiveUpdate)
+		admin.PUT("/live-news/:id/updates/:update_id", liveNewsHandler.EditLiveUpdate)
+		admin.DELETE("/live-news/:id/updates/:update_id", liveNewsHandler.DeleteLiveUpdate)
 
 		// Newsletter Management
 		admin.GET("/newsletters", handlers.GetNewsletters)
@@ -550,6 +633,52 @@ func RegisterRoutes(r *gin.Engine) {
 		admin.PUT("/newsletters/:id", handlers.UpdateNewsletter)
 		admin.DELETE("/newsletters/:id", handlers.DeleteNewsletter)
 		admin.POST("/newsletters/:id/send", handlers.SendNewsletter)
+		admin.GET("/newsletters/:id/deliveries", handlers.GetNewsletterDeliveries)
+
+		// Comment Moderation
+		admin.GET("/comments/moderation", handlers.GetCommentModerationQueue)                     // Moderation queue
+		admin.POST("/comments/moderation/bulk", handlers.BulkModerateComments)                    // Approve, reject or mark spam in bulk
+		admin.GET("/comments/moderation/policies", handlers.GetCommentModerationPolicies)         // List policies
+		admin.PUT("/comments/moderation/policies", handlers.SaveCommentModerationPolicy)          // Set the policy of a scope
+		admin.DELETE("/comments/moderation/policies/:id", handlers.DeleteCommentModerationPolicy) // Delete policy
+		admin.POST("/comments/:id/approve", handlers.ApproveComment)                              // Approve comment
+		admin.POST("/comments/:id/reject", handlers.RejectComment)                                // Reject comment and notify author
+		admin.POST("/comments/:id/spam", handlers.MarkCommentSpam)                                // Mark comment as spam
+
+		// Content Reports
+		admin.GET("/reports", handlers.GetReportedContent)                 // Reported content triage queue
+		admin.GET("/reports/audit", handlers.GetReportAuditLog)            // Moderation decisions and automatic hides
+		admin.GET("/reports/:id", handlers.GetReportedContentItem)         // Reported content with its reports
+		admin.POST("/reports/:id/action", handlers.ResolveReportedContent) // Dismiss, hide, delete or suspend the author
+
+		// Webhook Management
+		admin.GET("/webhooks", handlers.GetWebhookEndpoints)
+		admin.GET("/webhooks/events", handlers.GetWebhookEvents)
+		admin.POST("/webhooks", handlers.CreateWebhookEndpoint)
+		admin.GET("/webhooks/:id", handlers.GetWebhookEndpoint)
+		admin.PUT("/webhooks/:id", handlers.UpdateWebhookEndpoint)
+		admin.DELETE("/webhooks/:id", handlers.DeleteWebhookEndpoint)
+		admin.POST("/webhooks/:id/rotate-secret", handlers.RotateWebhookSecret)
+		admin.GET("/webhooks/:id/deliveries", handlers.GetWebhookDeliveries)
+		admin.POST("/webhooks/deliveries/:delivery_id/redeliver", handlers.RedeliverWebhook)
+
+		// API Key Management
+		admin.GET("/api-keys", handlers.GetAPIKeys)
+		admin.POST("/api-keys", handlers.IssueAPIKey)
+		admin.GET("/api-keys/:id", handlers.GetAPIKey)
+		admin.POST("/api-keys/:id/rotate", handlers.RotateAPIKey)
+		admin.POST("/api-keys/:id/revoke", handlers.RevokeAPIKey)
+		admin.GET("/api-keys/:id/usage", handlers.GetAPIKeyUsage)
+
+		// Draft preview tokens
+		admin.GET("/preview-tokens", handlers.GetPreviewTokens)
+		admin.POST("/preview-tokens/:id/revoke", handlers.RevokePreviewToken)
+
+		// Article embeddings for similar articles and semantic search
+		admin.POST("/embeddings/reindex", handlers.ReindexArticleEmbeddings)
+
+		// Sitemaps are rebuilt by the worker; this rebuilds them now
+		admin.POST("/sitemaps/regenerate", handlers.RegenerateSitemaps)
 
 		// Menu Management
 		admin.POST("/menus", handlers.CreateMenu)
@@ -595,6 +724,21 @@ func RegisterRoutes(r *gin.Engine) {
 		admin.POST("/cache/preload", handlers.PreloadCache)       // Preload popular content
 		admin.DELETE("/cache/clear", handlers.ClearCache)         // Clear cache (admin only)
 		admin.POST("/cache/warm", handlers.WarmCache)             // Warm cache (admin only)
+
+		// Job Queue Management (live progress: /ws/notifications?jobs=true)
+		admin.GET("/queue/stats", handlers.GetQueueStats)                                   // Queue statistics
+		admin.GET("/queue/health", handlers.GetQueueHealth)                                 // Queue health
+		admin.POST("/queue/enqueue", handlers.EnqueueJob)                                   // Enqueue a job manually
+		admin.GET("/queue/jobs", handlers.GetQueueJobs)                                     // List jobs of a queue
+		admin.POST("/queue/jobs/cleanup", handlers.CleanupCompletedJobs)                    // Remove old finished jobs
+		admin.GET("/queue/jobs/:id", handlers.GetQueueJob)                                  // Job details and progress
+		admin.POST("/queue/jobs/:id/retry", handlers.RetryQueueJob)                         // Retry a failed job
+		admin.DELETE("/queue/jobs/:id", handlers.DeleteQueueJob)                            // Delete a finished job
+		admin.POST("/queue/queues/:name/pause", handlers.PauseQueue)                        // Pause a queue
+		admin.POST("/queue/queues/:name/resume", handlers.ResumeQueue)                      // Resume a queue
+		admin.GET("/queue/queues/:name/dead-letter", handlers.GetDeadLetterJobs)            // Inspect dead letter jobs
+		admin.POST("/queue/queues/:name/dead-letter/replay", handlers.ReplayDeadLetterJobs) // Replay dead letter jobs
+		admin.POST("/queue/queues/:name/dead-letter/purge", handlers.PurgeDeadLetterJobs)   // Purge dead letter jobs
 	}
 
 	// Editor routes with JWT auth
@@ -602,6 +746,18 @@ func RegisterRoutes(r *gin.Engine) {
 	editor.Use(middleware.Authenticate(), middleware.EditorOnly())
 	{
 		editor.PUT("/articles/:id", handlers.UpdateArticle)
+
+		// Article Revision History
+		editor.GET("/articles/:id/revisions", handlers.GetArticleRevisions)
+		editor.GET("/articles/:id/revisions/compare", handlers.CompareArticleRevisions)
+		editor.GET("/articles/:id/revisions/:revision", handlers.GetArticleRevision)
+		editor.POST("/articles/:id/revisions/:revision/restore", handlers.RestoreArticleRevision)
+
+		// Editorial Workflow
+		editor.GET("/articles/:id/workflow", handlers.GetArticleWorkflow)
+		editor.POST("/articles/:id/transition", handlers.TransitionArticle)
+		editor.POST("/articles/:id/review-notes", handlers.AddArticleReviewNote)
+		editor.GET("/reviews", handlers.GetReviewQueue)
 	}
 
 	// Author routes with JWT auth
@@ -610,6 +766,29 @@ func RegisterRoutes(r *gin.Engine) {
 	{
 		author.POST("/articles", handlers.CreateArticle)
 		author.PUT("/articles/:id", handlers.UpdateArticle) // Authors can only edit their own articles
+
+		// Editorial Workflow
+		author.GET("/articles/:id/workflow", handlers.GetArticleWorkflow)
+		author.POST("/articles/:id/submit", handlers.SubmitArticleForReview) // Submit to an editor for review
+		author.POST("/articles/:id/transition", handlers.TransitionArticle)  // Withdraw or resubmit
+	}
+
+	// Editorial notes, private to authors, editors and admins
+	editorial := r.Group("/editorial")
+	editorial.Use(middleware.Authenticate(), middleware.StaffOnly())
+	{
+		editorial.GET("/articles/:id/notes", handlers.GetArticleEditorialNotes)    // Note threads on article blocks
+		editorial.POST("/articles/:id/notes", handlers.CreateArticleEditorialNote) // Start a thread on an article block
+		editorial.GET("/pages/:id/notes", handlers.GetPageEditorialNotes)          // Note threads on page blocks
+		editorial.POST("/pages/:id/notes", handlers.CreatePageEditorialNote)       // Start a thread on a page block
+		editorial.POST("/notes/:id/replies", handlers.ReplyToEditorialNote)        // Reply to a thread
+		editorial.PUT("/notes/:id", handlers.UpdateEditorialNote)                  // Edit own note
+		editorial.DELETE("/notes/:id", handlers.DeleteEditorialNote)               // Delete own note
+		editorial.POST("/notes/:id/resolve", handlers.ResolveEditorialNote)        // Resolve a thread
+		editorial.POST("/notes/:id/unresolve", handlers.UnresolveEditorialNote)    // Reopen a thread
+
+		// Draft preview links for reviewers without an account
+		editorial.POST("/preview-tokens", handlers.IssuePreviewToken)
 	}
 
 	// API tier-specific routes (require API key authentication)
@@ -617,39 +796,13 @@ func RegisterRoutes(r *gin.Engine) {
 	apiKeyRoutes.Use(middleware.APIKeyAuth()) // Apply API key auth only to these routes
 	{
 		// Analytics endpoints (available to Pro and Enterprise tiers)
-		apiKeyRoutes.GET("/analytics", func(c *gin.Context) {
-			c.JSON(200, gin.H{
-				"message": "Analytics endpoint - requires Pro or Enterprise API key",
-				"data": map[string]interface{}{
-					"views":       12500,
-					"users":       1200,
-					"click_rate":  "4.5%",
-					"top_article": "How to implement API key tiers",
-				},
-			})
-		})
+		apiKeyRoutes.GET("/analytics", handlers.GetPartnerAnalytics)
 
 		// Export endpoints (available to Enterprise tier only)
-		apiKeyRoutes.GET("/export", func(c *gin.Context) {
-			c.JSON(200, gin.H{
-				"message": "Export endpoint - requires Enterprise API key",
-				"data": map[string]interface{}{
-					"export_url": "https://api.example.com/exports/latest.csv",
-					"format":     "CSV",
-					"generated":  time.Now().Format(time.RFC3339),
-					"expires_in": "24 hours",
-				},
-			})
-		})
+		apiKeyRoutes.GET("/export", handlers.ExportArticles)
 
 		// Bulk operations (available to Enterprise tier only)
-		apiKeyRoutes.POST("/bulk", func(c *gin.Context) {
-			c.JSON(200, gin.H{
-				"message": "Bulk operations endpoint - requires Enterprise API key",
-				"job_id":  "bulk-job-" + strconv.FormatInt(time.Now().Unix(), 10),
-				"status":  "queued",
-			})
-		})
+		apiKeyRoutes.POST("/bulk", handlers.BulkArticles)
 	}
 
 	// AI routes with JWT auth (authenticated users only)
@@ -706,7 +859,7 @@ func RegisterRoutes(r *gin.Engine) {
 	ws := r.Group("/ws")
 	ws.Use(middleware.RateLimit(5, 10, true)) // 5 reqs/sec, burst of 10 for WebSocket connections
 	{
-		// WebSocket connection for authenticated users (handles auth manually due to query param)
+		// WebSocket connection for authenticated and anonymous clients (handles auth manually due to query param)
 		ws.GET("/notifications", handlers.HandleWebSocketNotifications)
 
 		// WebSocket management endpoints
@@ -138,6 +138,10 @@ func SeedTranslations(db *sqlx.DB) error {
 		{"template_key": "newsletter", "language": "en", "subject": "Weekly Newsletter", "plain_body": "Here are this week's top stories.", "html_body": "<h1>Weekly Newsletter</h1><p>Here are this week's top stories.</p>", "preheader_text": "Your weekly news digest"},
 		{"template_key": "newsletter", "language": "tr", "subject": "Haftalık Bülten", "plain_body": "Bu haftanın en önemli haberleri.", "html_body": "<h1>Haftalık Bülten</h1><p>Bu haftanın en önemli haberleri.</p>", "preheader_text": "Haftalık haber özetiniz"},
 		{"template_key": "newsletter", "language": "es", "subject": "Boletín Semanal", "plain_body": "Aquí están las principales noticias de esta semana.", "html_body": "<h1>Boletín Semanal</h1><p>Aquí están las principales noticias de esta semana.</p>", "preheader_text": "Su resumen semanal de noticias"},
+
+		{"template_key": "subscription_confirmation", "language": "en", "subject": "Please confirm your subscription", "plain_body": "Please confirm your subscription by opening this link: {{confirm_url}}", "html_body": "<h1>Confirm your subscription</h1><p><a href=\"{{confirm_url}}\">Confirm subscription</a></p>", "preheader_text": "One more step to subscribe"},
+		{"template_key": "subscription_confirmation", "language": "tr", "subject": "Lütfen aboneliğinizi onaylayın", "plain_body": "Aboneliğinizi onaylamak için bu bağlantıyı açın: {{confirm_url}}", "html_body": "<h1>Aboneliğinizi onaylayın</h1><p><a href=\"{{confirm_url}}\">Aboneliği onayla</a></p>", "preheader_text": "Abonelik için son bir adım"},
+		{"template_key": "subscription_confirmation", "language": "es", "subject": "Confirme su suscripción", "plain_body": "Confirme su suscripción abriendo este enlace: {{confirm_url}}", "html_body": "<h1>Confirme su suscripción</h1><p><a href=\"{{confirm_url}}\">Confirmar suscripción</a></p>", "preheader_text": "Un paso más para suscribirse"},
 	}
 
 	for _, emailTemplate := range emailTemplates {
@@ -3,6 +3,7 @@ package seeds
 import (
 	"fmt"
 	"news/internal/database"
+	"news/internal/models"
 	"news/internal/seeds/organized"
 
 	"github.com/jmoiron/sqlx"
@@ -112,6 +113,40 @@ func seedDevSpecificData(db *sqlx.DB) error {
 	}
 
 	fmt.Println("   ✓ Added development-specific settings")
+	return seedDemoAPIKeys(db)
+}
+
+// seedDemoAPIKeys issues the well-known demo API keys used by the load test scripts to the
+// first admin. They are only seeded outside production.
+func seedDemoAPIKeys(db *sqlx.DB) error {
+	var ownerID uint
+	if err := db.Get(&ownerID, `SELECT id FROM users WHERE role = 'admin' ORDER BY id LIMIT 1`); err != nil {
+		fmt.Printf("   ⚠️  Warning: Could not find an admin to own the demo API keys: %v\n", err)
+		return nil
+	}
+
+	demoKeys := []struct {
+		key    string
+		tier   string
+		scopes string
+	}{
+		{"api_key_basic_1234", models.APIKeyTierBasic, `[]`},
+		{"api_key_pro_5678", models.APIKeyTierPro, `["analytics"]`},
+		{"api_key_enterprise_9012", models.APIKeyTierEnterprise, `["analytics","export","bulk"]`},
+	}
+
+	for _, demo := range demoKeys {
+		_, err := db.Exec(`
+			INSERT INTO api_keys (name, prefix, key_hash, owner_id, tier, scopes, revoked, created_by, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, false, $4, NOW(), NOW())
+			ON CONFLICT (key_hash) DO NOTHING
+		`, "Demo "+demo.tier+" key", demo.key[:12], models.HashAPIKey(demo.key), ownerID, demo.tier, demo.scopes)
+		if err != nil {
+			fmt.Printf("   ⚠️  Warning: Could not add demo API key %s: %v\n", demo.tier, err)
+		}
+	}
+
+	fmt.Println("   ✓ Added demo API keys")
 	return nil
 }
 
@@ -149,7 +184,7 @@ func seedTestSpecificData(db *sqlx.DB) error {
 	}
 
 	fmt.Println("   ✓ Added test-specific settings")
-	return nil
+	return seedDemoAPIKeys(db)
 }
 
 // seedProdSpecificData adds production-specific data
@@ -0,0 +1,395 @@
+// Package seo assembles the metadata of a public page: title, description, canonical URL,
+// Open Graph and Twitter cards, hreflang alternates and schema.org JSON-LD. It has no database
+// access; services describe the content and this package builds the tags and structured data.
+package seo
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+
+	"news/internal/json"
+)
+
+// Robots directives
+const (
+	RobotsIndex   = "index, follow"
+	RobotsNoIndex = "noindex, follow"
+)
+
+// Metadata is everything a frontend needs for the <head> of a page
+type Metadata struct {
+	EntityType   string      `json:"entity_type"`
+	EntityID     uint        `json:"entity_id"`
+	Language     string      `json:"language"`
+	Title        string      `json:"title"`
+	Description  string      `json:"description"`
+	CanonicalURL string      `json:"canonical_url"`
+	Robots       string      `json:"robots"`
+	Keywords     []string    `json:"keywords,omitempty"`
+	OpenGraph    OpenGraph   `json:"open_graph"`
+	Twitter      TwitterCard `json:"twitter"`
+	Alternates   []Alternate `json:"alternates,omitempty"`
+	JSONLD       []Object    `json:"json_ld"`
+}
+
+// OpenGraph holds the og: and article: properties of a page
+type OpenGraph struct {
+	Type             string     `json:"type"`
+	Title            string     `json:"title"`
+	Description      string     `json:"description,omitempty"`
+	URL              string     `json:"url"`
+	Image            string     `json:"image,omitempty"`
+	Video            string     `json:"video,omitempty"`
+	SiteName         string     `json:"site_name,omitempty"`
+	Locale           string     `json:"locale,omitempty"`
+	AlternateLocales []string   `json:"alternate_locales,omitempty"`
+	PublishedTime    *time.Time `json:"published_time,omitempty"`
+	ModifiedTime     *time.Time `json:"modified_time,omitempty"`
+	Section          string     `json:"section,omitempty"`
+	Tags             []string   `json:"tags,omitempty"`
+}
+
+// TwitterCard holds the twitter: properties of a page
+type TwitterCard struct {
+	Card        string `json:"card"`
+	Site        string `json:"site,omitempty"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Image       string `json:"image,omitempty"`
+}
+
+// Alternate is a translation of a page, written as an hreflang link
+type Alternate struct {
+	Lang string `json:"hreflang"`
+	Href string `json:"href"`
+}
+
+// Object is a schema.org JSON-LD node
+type Object map[string]interface{}
+
+// Organization describes the publisher of the site
+type Organization struct {
+	Name string
+	URL  string
+	Logo string
+}
+
+// Crumb is one step of a breadcrumb trail
+type Crumb struct {
+	Name string
+	URL  string
+}
+
+// Article describes a news article for NewsArticle structured data
+type Article struct {
+	URL         string
+	Headline    string
+	Description string
+	Images      []string
+	Published   time.Time
+	Modified    time.Time
+	AuthorName  string
+	AuthorURL   string
+	Section     string
+	Keywords    []string
+	Language    string
+}
+
+// Video describes a video for VideoObject structured data
+type Video struct {
+	URL          string
+	Name         string
+	Description  string
+	ThumbnailURL string
+	ContentURL   string
+	UploadDate   time.Time
+	Duration     int // seconds
+	Views        int64
+}
+
+// LiveBlog describes a live coverage stream for LiveBlogPosting structured data
+type LiveBlog struct {
+	URL           string
+	Headline      string
+	Description   string
+	Image         string
+	CoverageStart *time.Time
+	CoverageEnd   *time.Time
+	Modified      time.Time
+	Language      string
+	Updates       []LiveUpdate
+}
+
+// LiveUpdate is one post of a live blog
+type LiveUpdate struct {
+	ID        uint
+	Headline  string
+	Body      string
+	Published time.Time
+	Modified  time.Time
+}
+
+// Localize returns the hreflang alternates of a page's translations, keyed language to URL in
+// the order given, with the first as x-default. A page without translations has none.
+func Localize(urls []Alternate) []Alternate {
+	if len(urls) < 2 {
+		return nil
+	}
+	alternates := make([]Alternate, 0, len(urls)+1)
+	alternates = append(alternates, urls...)
+	return append(alternates, Alternate{Lang: "x-default", Href: urls[0].Href})
+}
+
+var locales = map[string]string{
+	"ar": "ar_AR", "de": "de_DE", "en": "en_US", "es": "es_ES", "fr": "fr_FR", "it": "it_IT",
+	"ja": "ja_JP", "ko": "ko_KR", "pt": "pt_BR", "ru": "ru_RU", "tr": "tr_TR", "zh": "zh_CN",
+}
+
+// Locale turns a language code into an Open Graph locale such as tr_TR
+func Locale(lang string) string {
+	lang = strings.ToLower(lang)
+	if locale, ok := locales[lang]; ok {
+		return locale
+	}
+	if strings.ContainsAny(lang, "-_") {
+		parts := strings.FieldsFunc(lang, func(r rune) bool { return r == '-' || r == '_' })
+		if len(parts) == 2 {
+			return parts[0] + "_" + strings.ToUpper(parts[1])
+		}
+	}
+	return lang
+}
+
+var (
+	tagPattern        = regexp.MustCompile(`<[^>]*>`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+)
+
+// Excerpt turns HTML into plain text of at most max characters, cut at a word boundary
+func Excerpt(content string, max int) string {
+	text := html.UnescapeString(tagPattern.ReplaceAllString(content, " "))
+	text = strings.TrimSpace(whitespacePattern.ReplaceAllString(text, " "))
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	cut := string(runes[:max])
+	if space := strings.LastIndex(cut, " "); space > len(cut)/2 {
+		cut = cut[:space]
+	}
+	return strings.TrimRight(cut, " ,.;:") + "…"
+}
+
+// ISODuration writes a duration in seconds as ISO 8601, such as PT1M30S
+func ISODuration(seconds int) string {
+	if seconds <= 0 {
+		return ""
+	}
+	hours, minutes, secs := seconds/3600, seconds%3600/60, seconds%60
+	out := "PT"
+	if hours > 0 {
+		out += fmt.Sprintf("%dH", hours)
+	}
+	if minutes > 0 {
+		out += fmt.Sprintf("%dM", minutes)
+	}
+	if secs > 0 {
+		out += fmt.Sprintf("%dS", secs)
+	}
+	return out
+}
+
+// ParseSchema reads custom JSON-LD entered in SEO settings, either one node or a list of them.
+// Invalid JSON is ignored.
+func ParseSchema(raw string) []Object {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	var list []Object
+	if err := json.Unmarshal([]byte(raw), &list); err == nil {
+		return list
+	}
+	var single Object
+	if err := json.Unmarshal([]byte(raw), &single); err == nil && len(single) > 0 {
+		return []Object{single}
+	}
+	return nil
+}
+
+// Publisher is the schema.org Organization node of the site
+func (o Organization) Publisher() Object {
+	publisher := Object{"@type": "Organization", "name": o.Name, "url": o.URL}
+	if o.Logo != "" {
+		publisher["logo"] = Object{"@type": "ImageObject", "url": o.Logo}
+	}
+	return publisher
+}
+
+// NewsArticle builds NewsArticle structured data
+func NewsArticle(article Article, publisher Organization) Object {
+	node := Object{
+		"@context":         "https://schema.org",
+		"@type":            "NewsArticle",
+		"mainEntityOfPage": Object{"@type": "WebPage", "@id": article.URL},
+		"url":              article.URL,
+		"headline":         truncateRunes(article.Headline, 110),
+		"datePublished":    formatTime(article.Published),
+		"dateModified":     formatTime(latest(article.Modified, article.Published)),
+		"publisher":        publisher.Publisher(),
+	}
+	setIf(node, "description", article.Description)
+	setIf(node, "articleSection", article.Section)
+	setIf(node, "inLanguage", article.Language)
+	if len(article.Images) > 0 {
+		node["image"] = article.Images
+	}
+	if len(article.Keywords) > 0 {
+		node["keywords"] = strings.Join(article.Keywords, ", ")
+	}
+	if article.AuthorName != "" {
+		author := Object{"@type": "Person", "name": article.AuthorName}
+		setIf(author, "url", article.AuthorURL)
+		node["author"] = []Object{author}
+	}
+	return node
+}
+
+// VideoObject builds VideoObject structured data
+func VideoObject(video Video, publisher Organization) Object {
+	node := Object{
+		"@context":   "https://schema.org",
+		"@type":      "VideoObject",
+		"name":       video.Name,
+		"url":        video.URL,
+		"uploadDate": formatTime(video.UploadDate),
+		"publisher":  publisher.Publisher(),
+	}
+	// Google requires a description
+	node["description"] = video.Description
+	if video.Description == "" {
+		node["description"] = video.Name
+	}
+	if video.ThumbnailURL != "" {
+		node["thumbnailUrl"] = []string{video.ThumbnailURL}
+	}
+	setIf(node, "contentUrl", video.ContentURL)
+	setIf(node, "duration", ISODuration(video.Duration))
+	if video.Views > 0 {
+		node["interactionStatistic"] = Object{
+			"@type":                "InteractionCounter",
+			"interactionType":      Object{"@type": "WatchAction"},
+			"userInteractionCount": video.Views,
+		}
+	}
+	return node
+}
+
+// LiveBlogPosting builds LiveBlogPosting structured data with its updates, newest first
+func LiveBlogPosting(blog LiveBlog, publisher Organization) Object {
+	node := Object{
+		"@context":  "https://schema.org",
+		"@type":     "LiveBlogPosting",
+		"@id":       blog.URL,
+		"url":       blog.URL,
+		"headline":  truncateRunes(blog.Headline, 110),
+		"publisher": publisher.Publisher(),
+	}
+	setIf(node, "description", blog.Description)
+	setIf(node, "inLanguage", blog.Language)
+	if blog.Image != "" {
+		node["image"] = []string{blog.Image}
+	}
+	if blog.CoverageStart != nil {
+		node["coverageStartTime"] = formatTime(*blog.CoverageStart)
+		node["datePublished"] = formatTime(*blog.CoverageStart)
+	}
+	if blog.CoverageEnd != nil {
+		node["coverageEndTime"] = formatTime(*blog.CoverageEnd)
+	}
+	if !blog.Modified.IsZero() {
+		node["dateModified"] = formatTime(blog.Modified)
+	}
+
+	updates := make([]Object, 0, len(blog.Updates))
+	for _, update := range blog.Updates {
+		post := Object{
+			"@type":         "BlogPosting",
+			"@id":           fmt.Sprintf("%s#update-%d", blog.URL, update.ID),
+			"url":           fmt.Sprintf("%s#update-%d", blog.URL, update.ID),
+			"headline":      truncateRunes(update.Headline, 110),
+			"datePublished": formatTime(update.Published),
+			"dateModified":  formatTime(latest(update.Modified, update.Published)),
+			"articleBody":   update.Body,
+		}
+		updates = append(updates, post)
+	}
+	node["liveBlogUpdate"] = updates
+	return node
+}
+
+// BreadcrumbList builds BreadcrumbList structured data from a trail, home first
+func BreadcrumbList(crumbs []Crumb) Object {
+	items := make([]Object, 0, len(crumbs))
+	for i, crumb := range crumbs {
+		items = append(items, Object{
+			"@type":    "ListItem",
+			"position": i + 1,
+			"name":     crumb.Name,
+			"item":     crumb.URL,
+		})
+	}
+	return Object{
+		"@context":        "https://schema.org",
+		"@type":           "BreadcrumbList",
+		"itemListElement": items,
+	}
+}
+
+// WebPage builds WebPage structured data for a static page
+func WebPage(url, name, description, language string, modified time.Time) Object {
+	node := Object{
+		"@context": "https://schema.org",
+		"@type":    "WebPage",
+		"@id":      url,
+		"url":      url,
+		"name":     name,
+	}
+	setIf(node, "description", description)
+	setIf(node, "inLanguage", language)
+	if !modified.IsZero() {
+		node["dateModified"] = formatTime(modified)
+	}
+	return node
+}
+
+func setIf(node Object, key, value string) {
+	if value != "" {
+		node[key] = value
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func truncateRunes(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max-1]) + "…"
+}
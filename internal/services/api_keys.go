@@ -0,0 +1,258 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"news/internal/database"
+	"news/internal/json"
+	"news/internal/models"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// ErrAPIKeyNotFound is returned when an API key does not exist
+var ErrAPIKeyNotFound = errors.New("API key not found")
+
+// apiKeyPrefixLength is how much of a key is stored in clear text to recognise it
+const apiKeyPrefixLength = 12
+
+// APIKeyRequest represents the request to issue an API key
+type APIKeyRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	OwnerID   uint       `json:"owner_id" binding:"required"`
+	Tier      string     `json:"tier" binding:"required"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// APIKeySecretResponse returns an API key together with the key itself. The key is only
+// shown when it is issued or rotated.
+type APIKeySecretResponse struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyListResponse represents a paginated list of API keys
+type APIKeyListResponse struct {
+	Keys       []models.APIKey `json:"keys"`
+	Total      int64           `json:"total"`
+	Page       int             `json:"page"`
+	Limit      int             `json:"limit"`
+	TotalPages int             `json:"total_pages"`
+}
+
+// APIKeyUsageResponse summarises the metered usage of an API key over a date range
+type APIKeyUsageResponse struct {
+	APIKeyID      uint                 `json:"api_key_id"`
+	From          string               `json:"from"`
+	To            string               `json:"to"`
+	TotalRequests int64                `json:"total_requests"`
+	TotalErrors   int64                `json:"total_errors"`
+	ByEndpoint    map[string]int64     `json:"by_endpoint"`
+	Daily         []models.APIKeyUsage `json:"daily"`
+}
+
+// IssueAPIKey creates an API key for a user and returns the key once
+func IssueAPIKey(req APIKeyRequest, createdBy uint) (*APIKeySecretResponse, error) {
+	scopes, err := validateAPIKeyRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var owner models.User
+	if err := database.DB.Select("id").First(&owner, req.OwnerID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: owner does not exist", ErrValidation)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	rawKey, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	key := models.APIKey{
+		Name:      req.Name,
+		Prefix:    rawKey[:apiKeyPrefixLength],
+		KeyHash:   models.HashAPIKey(rawKey),
+		OwnerID:   req.OwnerID,
+		Tier:      req.Tier,
+		Scopes:    scopes,
+		ExpiresAt: req.ExpiresAt,
+		CreatedBy: createdBy,
+	}
+	if err := database.DB.Omit("Owner").Create(&key).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	return &APIKeySecretResponse{APIKey: key, Key: rawKey}, nil
+}
+
+// GetAPIKeys lists API keys, optionally only those of one owner
+func GetAPIKeys(ownerID uint, includeRevoked bool, page, limit int) (*APIKeyListResponse, error) {
+	query := database.DB.Model(&models.APIKey{})
+	if ownerID != 0 {
+		query = query.Where("owner_id = ?", ownerID)
+	}
+	if !includeRevoked {
+		query = query.Where("revoked = ?", false)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	var keys []models.APIKey
+	if err := query.Order("id DESC").Offset((page - 1) * limit).Limit(limit).Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	return &APIKeyListResponse{
+		Keys:       keys,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+	}, nil
+}
+
+// GetAPIKey returns an API key by ID
+func GetAPIKey(id uint) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := database.DB.First(&key, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return &key, nil
+}
+
+// RotateAPIKey replaces the key of an active API key. The old key stops working while the
+// owner, tier, scopes and usage history are kept.
+func RotateAPIKey(id uint) (*APIKeySecretResponse, error) {
+	key, err := GetAPIKey(id)
+	if err != nil {
+		return nil, err
+	}
+	if key.Revoked {
+		return nil, fmt.Errorf("%w: revoked keys cannot be rotated", ErrValidation)
+	}
+
+	rawKey, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"prefix":     rawKey[:apiKeyPrefixLength],
+		"key_hash":   models.HashAPIKey(rawKey),
+		"rotated_at": now,
+	}
+	if err := database.DB.Model(key).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	key, err = GetAPIKey(id)
+	if err != nil {
+		return nil, err
+	}
+	return &APIKeySecretResponse{APIKey: *key, Key: rawKey}, nil
+}
+
+// RevokeAPIKey permanently disables an API key
+func RevokeAPIKey(id uint) (*models.APIKey, error) {
+	key, err := GetAPIKey(id)
+	if err != nil {
+		return nil, err
+	}
+	if key.Revoked {
+		return key, nil
+	}
+
+	if err := database.DB.Model(key).Updates(map[string]interface{}{
+		"revoked":    true,
+		"revoked_at": time.Now(),
+	}).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	return GetAPIKey(id)
+}
+
+// GetAPIKeyUsage returns the metered usage of an API key between two dates, inclusive
+func GetAPIKeyUsage(id uint, from, to time.Time) (*APIKeyUsageResponse, error) {
+	if _, err := GetAPIKey(id); err != nil {
+		return nil, err
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: to must not be before from", ErrValidation)
+	}
+
+	var daily []models.APIKeyUsage
+	if err := database.DB.
+		Where("api_key_id = ? AND date >= ? AND date <= ?", id, from.Format("2006-01-02"), to.Format("2006-01-02")).
+		Order("date ASC, endpoint ASC").
+		Find(&daily).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	response := &APIKeyUsageResponse{
+		APIKeyID:   id,
+		From:       from.Format("2006-01-02"),
+		To:         to.Format("2006-01-02"),
+		ByEndpoint: make(map[string]int64),
+		Daily:      daily,
+	}
+	for _, usage := range daily {
+		response.TotalRequests += usage.Requests
+		response.TotalErrors += usage.Errors
+		response.ByEndpoint[usage.Endpoint] += usage.Requests
+	}
+
+	return response, nil
+}
+
+// validateAPIKeyRequest checks the tier and scopes and returns the scopes as JSON
+func validateAPIKeyRequest(req APIKeyRequest) (datatypes.JSON, error) {
+	if !models.IsValidAPIKeyTier(req.Tier) {
+		return nil, fmt.Errorf("%w: tier must be one of basic, pro or enterprise", ErrValidation)
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expires_at must be in the future", ErrValidation)
+	}
+
+	scopes := make([]string, 0, len(req.Scopes))
+	seen := make(map[string]bool, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		if !models.IsValidAPIKeyScope(scope) {
+			return nil, fmt.Errorf("%w: unknown scope %q", ErrValidation, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	encoded, err := json.Marshal(scopes)
+	if err != nil {
+		return nil, err
+	}
+	return datatypes.JSON(encoded), nil
+}
+
+func generateAPIKey() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	return "nk_live_" + hex.EncodeToString(buf), nil
+}
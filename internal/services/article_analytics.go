@@ -0,0 +1,114 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"news/internal/database"
+)
+
+// ArticleAnalyticsOptions filters the interactions aggregated by GetArticleAnalytics
+type ArticleAnalyticsOptions struct {
+	From       time.Time
+	To         time.Time
+	ArticleIDs []uint
+	Limit      int
+}
+
+// ArticleAnalytics holds the view and engagement aggregates of one article
+type ArticleAnalytics struct {
+	ArticleID         uint    `json:"article_id"`
+	Title             string  `json:"title"`
+	Slug              string  `json:"slug"`
+	Views             int64   `json:"views"`
+	UniqueViewers     int64   `json:"unique_viewers"`
+	AvgReadSeconds    float64 `json:"avg_read_seconds"`
+	AvgCompletionRate float64 `json:"avg_completion_rate"`
+	Bookmarks         int64   `json:"bookmarks"`
+	Shares            int64   `json:"shares"`
+	Comments          int64   `json:"comments"`
+	Upvotes           int64   `json:"upvotes"`
+	Downvotes         int64   `json:"downvotes"`
+	Likes             int64   `json:"likes"`
+	Dislikes          int64   `json:"dislikes"`
+	Engagements       int64   `json:"engagements"`
+	EngagementRate    float64 `json:"engagement_rate"` // Engagements per view
+}
+
+// ArticleAnalyticsTotals sums the aggregates of every article in a report
+type ArticleAnalyticsTotals struct {
+	Views          int64   `json:"views"`
+	UniqueViewers  int64   `json:"unique_viewers"`
+	Engagements    int64   `json:"engagements"`
+	EngagementRate float64 `json:"engagement_rate"`
+}
+
+// ArticleAnalyticsResponse is the analytics report for a period
+type ArticleAnalyticsResponse struct {
+	From     time.Time              `json:"from"`
+	To       time.Time              `json:"to"`
+	Totals   ArticleAnalyticsTotals `json:"totals"`
+	Articles []ArticleAnalytics     `json:"articles"`
+}
+
+// GetArticleAnalytics aggregates UserArticleInteraction records per article between From and
+// To, most viewed first. Engagements count every interaction other than a view.
+func GetArticleAnalytics(opts ArticleAnalyticsOptions) (*ArticleAnalyticsResponse, error) {
+	if opts.To.Before(opts.From) {
+		return nil, fmt.Errorf("%w: to must not be before from", ErrValidation)
+	}
+	if opts.Limit <= 0 || opts.Limit > 500 {
+		opts.Limit = 50
+	}
+
+	query := database.DB.Table("user_article_interactions AS i").
+		Select(`i.article_id,
+			a.title,
+			a.slug,
+			SUM(CASE WHEN i.interaction_type = 'view' THEN 1 ELSE 0 END) AS views,
+			COUNT(DISTINCT CASE WHEN i.interaction_type = 'view' THEN i.user_id END) AS unique_viewers,
+			COALESCE(AVG(CASE WHEN i.interaction_type = 'view' THEN i.duration END), 0) AS avg_read_seconds,
+			COALESCE(AVG(CASE WHEN i.interaction_type = 'view' THEN i.completion_rate END), 0) AS avg_completion_rate,
+			SUM(CASE WHEN i.interaction_type = 'bookmark' THEN 1 ELSE 0 END) AS bookmarks,
+			SUM(CASE WHEN i.interaction_type = 'share' THEN 1 ELSE 0 END) AS shares,
+			SUM(CASE WHEN i.interaction_type = 'comment' THEN 1 ELSE 0 END) AS comments,
+			SUM(CASE WHEN i.interaction_type = 'upvote' THEN 1 ELSE 0 END) AS upvotes,
+			SUM(CASE WHEN i.interaction_type = 'downvote' THEN 1 ELSE 0 END) AS downvotes,
+			SUM(CASE WHEN i.interaction_type = 'like' THEN 1 ELSE 0 END) AS likes,
+			SUM(CASE WHEN i.interaction_type = 'dislike' THEN 1 ELSE 0 END) AS dislikes,
+			SUM(CASE WHEN i.interaction_type <> 'view' THEN 1 ELSE 0 END) AS engagements`).
+		Joins("JOIN articles a ON a.id = i.article_id AND a.deleted_at IS NULL").
+		Where("i.deleted_at IS NULL AND i.created_at >= ? AND i.created_at <= ?", opts.From, opts.To)
+	if len(opts.ArticleIDs) > 0 {
+		query = query.Where("i.article_id IN ?", opts.ArticleIDs)
+	}
+
+	var articles []ArticleAnalytics
+	if err := query.
+		Group("i.article_id, a.title, a.slug").
+		Order("views DESC, engagements DESC, i.article_id ASC").
+		Limit(opts.Limit).
+		Scan(&articles).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	response := &ArticleAnalyticsResponse{
+		From:     opts.From,
+		To:       opts.To,
+		Articles: make([]ArticleAnalytics, 0, len(articles)),
+	}
+	for _, article := range articles {
+		if article.Views > 0 {
+			article.EngagementRate = float64(article.Engagements) / float64(article.Views)
+		}
+		response.Totals.Views += article.Views
+		response.Totals.UniqueViewers += article.UniqueViewers
+		response.Totals.Engagements += article.Engagements
+		response.Articles = append(response.Articles, article)
+	}
+	if response.Totals.Views > 0 {
+		response.Totals.EngagementRate = float64(response.Totals.Engagements) / float64(response.Totals.Views)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,292 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"news/internal/database"
+	"news/internal/models"
+)
+
+// MaxBulkArticleOperations limits the number of operations in one bulk request
+const MaxBulkArticleOperations = 100
+
+// Bulk operation actions and result statuses
+const (
+	BulkActionCreate = "create"
+	BulkActionUpdate = "update"
+
+	BulkResultCreated = "created"
+	BulkResultUpdated = "updated"
+	BulkResultFailed  = "failed"
+)
+
+// BulkArticleOperation creates an article, or updates the article with ID. Fields left empty
+// in an update keep their current value; category_ids and tag_ids replace the article's
+// categories and tags when given.
+type BulkArticleOperation struct {
+	Action        string     `json:"action"`
+	ID            uint       `json:"id,omitempty"`
+	Title         string     `json:"title"`
+	Summary       string     `json:"summary"`
+	Content       string     `json:"content"`
+	FeaturedImage string     `json:"featured_image"`
+	Status        string     `json:"status"`
+	ScheduledAt   *time.Time `json:"scheduled_at"`
+	UnpublishAt   *time.Time `json:"unpublish_at"`
+	UnpublishTo   string     `json:"unpublish_to"`
+	MetaTitle     string     `json:"meta_title"`
+	MetaDesc      string     `json:"meta_description"`
+	Language      string     `json:"language"`
+	CategoryIDs   []uint     `json:"category_ids"`
+	TagIDs        []uint     `json:"tag_ids"`
+}
+
+// BulkArticleResult reports the outcome of one bulk operation
+type BulkArticleResult struct {
+	Index  int    `json:"index"`
+	Action string `json:"action"`
+	ID     uint   `json:"id,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BulkArticleResponse summarises a bulk request
+type BulkArticleResponse struct {
+	Results   []BulkArticleResult `json:"results"`
+	Succeeded int                 `json:"succeeded"`
+	Failed    int                 `json:"failed"`
+}
+
+// RunBulkArticleOperations applies each operation on its own, so one invalid item does not
+// prevent the others from being applied. Every operation must pass the editorial workflow for
+// actor, and new articles are attributed to actor.
+func RunBulkArticleOperations(operations []BulkArticleOperation, actor WorkflowActor) (*BulkArticleResponse, error) {
+	if len(operations) == 0 {
+		return nil, fmt.Errorf("%w: at least one operation is required", ErrValidation)
+	}
+	if len(operations) > MaxBulkArticleOperations {
+		return nil, fmt.Errorf("%w: at most %d operations are allowed per request", ErrValidation, MaxBulkArticleOperations)
+	}
+
+	response := &BulkArticleResponse{Results: make([]BulkArticleResult, 0, len(operations))}
+	for i, op := range operations {
+		result := BulkArticleResult{Index: i, Action: op.Action}
+
+		var article models.Article
+		var err error
+		switch op.Action {
+		case BulkActionCreate:
+			article, err = bulkCreateArticle(op, actor)
+			result.Status = BulkResultCreated
+		case BulkActionUpdate:
+			article, err = bulkUpdateArticle(op, actor)
+			result.Status = BulkResultUpdated
+		default:
+			err = fmt.Errorf("%w: action must be create or update", ErrValidation)
+		}
+
+		if err != nil {
+			result.ID = op.ID
+			result.Status = BulkResultFailed
+			result.Error = bulkErrorMessage(err)
+			response.Failed++
+		} else {
+			result.ID = article.ID
+			response.Succeeded++
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	return response, nil
+}
+
+func bulkCreateArticle(op BulkArticleOperation, actor WorkflowActor) (models.Article, error) {
+	article := models.Article{
+		Title:         op.Title,
+		Summary:       op.Summary,
+		Content:       op.Content,
+		AuthorID:      actor.ID,
+		FeaturedImage: op.FeaturedImage,
+		Gallery:       []byte("[]"),
+		Status:        op.Status,
+		ScheduledAt:   op.ScheduledAt,
+		UnpublishAt:   op.UnpublishAt,
+		UnpublishTo:   op.UnpublishTo,
+		MetaTitle:     op.MetaTitle,
+		MetaDesc:      op.MetaDesc,
+		Language:      op.Language,
+	}
+	if article.Status == "" {
+		article.Status = "draft"
+	}
+	if article.Language == "" {
+		article.Language = "tr"
+	}
+	if !article.ValidateStatus() {
+		return models.Article{}, fmt.Errorf("%w: invalid status %q", ErrValidation, article.Status)
+	}
+
+	// New articles start as drafts; any other status must be one the role may move a draft to
+	if err := GetArticleWorkflowService().Authorize(actor, nil, "draft", article.Status); err != nil {
+		return models.Article{}, err
+	}
+
+	// Validate first so an invalid item fails without touching the database
+	if err := validateArticle(article); err != nil {
+		return models.Article{}, err
+	}
+
+	// The categories and tags are inserted with the article, so an unknown id never leaves a
+	// half-created article behind for a retry to duplicate
+	categories, tags, err := loadArticleTaxonomy(op.CategoryIDs, op.TagIDs)
+	if err != nil {
+		return models.Article{}, err
+	}
+	article.Categories = categories
+	article.Tags = tags
+
+	return CreateArticle(article)
+}
+
+func bulkUpdateArticle(op BulkArticleOperation, actor WorkflowActor) (models.Article, error) {
+	if op.ID == 0 {
+		return models.Article{}, fmt.Errorf("%w: id is required for updates", ErrValidation)
+	}
+
+	existing, err := GetArticleForEdit(strconv.FormatUint(uint64(op.ID), 10))
+	if err != nil {
+		return models.Article{}, err
+	}
+
+	updated := existing
+	if op.Title != "" {
+		updated.Title = op.Title
+	}
+	if op.Content != "" {
+		updated.Content = op.Content
+	}
+	if op.FeaturedImage != "" {
+		updated.FeaturedImage = op.FeaturedImage
+	}
+	if op.Status != "" {
+		updated.Status = op.Status
+	}
+	if op.ScheduledAt != nil {
+		updated.ScheduledAt = op.ScheduledAt
+	}
+	if op.UnpublishAt != nil {
+		updated.UnpublishAt = op.UnpublishAt
+	}
+	if op.UnpublishTo != "" {
+		updated.UnpublishTo = op.UnpublishTo
+	}
+	if op.MetaTitle != "" {
+		updated.MetaTitle = op.MetaTitle
+	}
+	if op.MetaDesc != "" {
+		updated.MetaDesc = op.MetaDesc
+	}
+	if !updated.ValidateStatus() {
+		return models.Article{}, fmt.Errorf("%w: invalid status %q", ErrValidation, updated.Status)
+	}
+
+	// Authors may only edit their own articles, and status changes follow the editorial workflow
+	if err := GetArticleWorkflowService().Authorize(actor, &existing, existing.Status, updated.Status); err != nil {
+		return models.Article{}, err
+	}
+
+	if err := validateArticle(updated); err != nil {
+		return models.Article{}, err
+	}
+	categories, tags, err := loadArticleTaxonomy(op.CategoryIDs, op.TagIDs)
+	if err != nil {
+		return models.Article{}, err
+	}
+
+	article, err := UpdateArticle(strconv.FormatUint(uint64(op.ID), 10), updated, actor.ID)
+	if err != nil {
+		return models.Article{}, err
+	}
+
+	// Summary and language are not covered by UpdateArticle
+	changes := map[string]interface{}{}
+	if op.Summary != "" {
+		changes["summary"] = op.Summary
+	}
+	if op.Language != "" {
+		changes["language"] = op.Language
+	}
+	if len(changes) > 0 {
+		if err := database.DB.Model(&models.Article{}).Where("id = ?", article.ID).Updates(changes).Error; err != nil {
+			return article, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+		}
+	}
+
+	if err := replaceArticleTaxonomy(&article, op.CategoryIDs, categories, op.TagIDs, tags); err != nil {
+		return article, err
+	}
+	return article, nil
+}
+
+// loadArticleTaxonomy loads the categories and tags with the given ids and fails on unknown ids
+func loadArticleTaxonomy(categoryIDs, tagIDs []uint) ([]models.Category, []models.Tag, error) {
+	var categories []models.Category
+	if len(categoryIDs) > 0 {
+		if err := database.DB.Where("id IN ?", categoryIDs).Find(&categories).Error; err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+		}
+		if len(categories) != len(uniqueIDs(categoryIDs)) {
+			return nil, nil, fmt.Errorf("%w: unknown category in category_ids", ErrValidation)
+		}
+	}
+
+	var tags []models.Tag
+	if len(tagIDs) > 0 {
+		if err := database.DB.Where("id IN ?", tagIDs).Find(&tags).Error; err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+		}
+		if len(tags) != len(uniqueIDs(tagIDs)) {
+			return nil, nil, fmt.Errorf("%w: unknown tag in tag_ids", ErrValidation)
+		}
+	}
+
+	return categories, tags, nil
+}
+
+// replaceArticleTaxonomy replaces the categories and tags of an article when ids are given
+func replaceArticleTaxonomy(article *models.Article, categoryIDs []uint, categories []models.Category, tagIDs []uint, tags []models.Tag) error {
+	if categoryIDs != nil {
+		if err := database.DB.Model(article).Association("Categories").Replace(categories); err != nil {
+			return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+		}
+	}
+	if tagIDs != nil {
+		if err := database.DB.Model(article).Association("Tags").Replace(tags); err != nil {
+			return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+		}
+	}
+	return nil
+}
+
+func uniqueIDs(ids []uint) map[uint]bool {
+	unique := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		unique[id] = true
+	}
+	return unique
+}
+
+// bulkErrorMessage hides database details from partners while keeping validation messages
+func bulkErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, ErrValidation), errors.Is(err, ErrWorkflowForbidden):
+		return err.Error()
+	case errors.Is(err, ErrNotFound):
+		return "article not found"
+	default:
+		return "failed to save article"
+	}
+}
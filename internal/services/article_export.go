@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"news/internal/database"
+	"news/internal/models"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// exportBatchSize is how many articles are loaded per query while exporting
+const exportBatchSize = 200
+
+// ArticleExportOptions filters and positions an article export. Exports are ordered by
+// updated_at and id, so a client resumes an incremental sync by passing the updated_at and
+// id of the last article it received as UpdatedSince and AfterID.
+type ArticleExportOptions struct {
+	UpdatedSince *time.Time
+	AfterID      uint
+	Status       string
+	Language     string
+	Limit        int // Zero exports every matching article
+}
+
+// ExportedTaxonomy is a category or tag reference in an export
+type ExportedTaxonomy struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+// ExportedTranslation is an article translation in an export
+type ExportedTranslation struct {
+	Language        string    `json:"language"`
+	Title           string    `json:"title"`
+	Slug            string    `json:"slug"`
+	Summary         string    `json:"summary"`
+	Content         string    `json:"content"`
+	MetaTitle       string    `json:"meta_title"`
+	MetaDescription string    `json:"meta_description"`
+	Status          string    `json:"status"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// ExportedBlock is an article content block in an export
+type ExportedBlock struct {
+	ID        uint           `json:"id"`
+	BlockType string         `json:"block_type"`
+	Content   string         `json:"content"`
+	Settings  datatypes.JSON `json:"settings" swaggertype:"object"`
+	Position  int            `json:"position"`
+	IsVisible bool           `json:"is_visible"`
+}
+
+// ExportedArticle is one article with its taxonomy, translations and blocks
+type ExportedArticle struct {
+	ID            uint                  `json:"id"`
+	Title         string                `json:"title"`
+	Slug          string                `json:"slug"`
+	Summary       string                `json:"summary"`
+	Content       string                `json:"content"`
+	ContentType   string                `json:"content_type"`
+	Status        string                `json:"status"`
+	Language      string                `json:"language"`
+	AuthorID      uint                  `json:"author_id"`
+	FeaturedImage string                `json:"featured_image"`
+	MetaTitle     string                `json:"meta_title"`
+	MetaDesc      string                `json:"meta_description"`
+	IsBreaking    bool                  `json:"is_breaking"`
+	PublishedAt   *time.Time            `json:"published_at"`
+	CreatedAt     time.Time             `json:"created_at"`
+	UpdatedAt     time.Time             `json:"updated_at"`
+	Categories    []ExportedTaxonomy    `json:"categories"`
+	Tags          []ExportedTaxonomy    `json:"tags"`
+	Translations  []ExportedTranslation `json:"translations"`
+	Blocks        []ExportedBlock       `json:"blocks"`
+}
+
+// ExportArticles loads the articles matching opts in batches and passes each one to emit, in
+// export order. It stops at the first error returned by emit or when ctx is cancelled.
+func ExportArticles(ctx context.Context, opts ArticleExportOptions, emit func(ExportedArticle) error) error {
+	updatedSince := time.Time{}
+	if opts.UpdatedSince != nil {
+		updatedSince = *opts.UpdatedSince
+	}
+	afterID := opts.AfterID
+	exported := 0
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		batchSize := exportBatchSize
+		if opts.Limit > 0 && opts.Limit-exported < batchSize {
+			batchSize = opts.Limit - exported
+		}
+		if batchSize <= 0 {
+			return nil
+		}
+
+		query := database.DB.WithContext(ctx).Model(&models.Article{}).
+			Where("(updated_at > ? OR (updated_at = ? AND id > ?))", updatedSince, updatedSince, afterID)
+		if opts.Status != "" {
+			query = query.Where("status = ?", opts.Status)
+		}
+		if opts.Language != "" {
+			query = query.Where("language = ?", opts.Language)
+		}
+
+		var articles []models.Article
+		if err := query.
+			Preload("Categories").
+			Preload("Tags").
+			Preload("Translations", "is_active = ?", true).
+			Preload("ContentBlocks", func(db *gorm.DB) *gorm.DB {
+				return db.Order("position ASC")
+			}).
+			Order("updated_at ASC, id ASC").
+			Limit(batchSize).
+			Find(&articles).Error; err != nil {
+			return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+		}
+
+		for i := range articles {
+			if err := emit(toExportedArticle(&articles[i])); err != nil {
+				return err
+			}
+		}
+
+		exported += len(articles)
+		if len(articles) < batchSize {
+			return nil
+		}
+
+		last := articles[len(articles)-1]
+		updatedSince, afterID = last.UpdatedAt, last.ID
+	}
+}
+
+func toExportedArticle(article *models.Article) ExportedArticle {
+	exported := ExportedArticle{
+		ID:            article.ID,
+		Title:         article.Title,
+		Slug:          article.Slug,
+		Summary:       article.Summary,
+		Content:       article.Content,
+		ContentType:   article.ContentType,
+		Status:        article.Status,
+		Language:      article.Language,
+		AuthorID:      article.AuthorID,
+		FeaturedImage: article.FeaturedImage,
+		MetaTitle:     article.MetaTitle,
+		MetaDesc:      article.MetaDesc,
+		IsBreaking:    article.IsBreaking,
+		PublishedAt:   article.PublishedAt,
+		CreatedAt:     article.CreatedAt,
+		UpdatedAt:     article.UpdatedAt,
+		Categories:    make([]ExportedTaxonomy, 0, len(article.Categories)),
+		Tags:          make([]ExportedTaxonomy, 0, len(article.Tags)),
+		Translations:  make([]ExportedTranslation, 0, len(article.Translations)),
+		Blocks:        make([]ExportedBlock, 0, len(article.ContentBlocks)),
+	}
+
+	for _, category := range article.Categories {
+		exported.Categories = append(exported.Categories, ExportedTaxonomy{ID: category.ID, Name: category.Name, Slug: category.Slug})
+	}
+	for _, tag := range article.Tags {
+		exported.Tags = append(exported.Tags, ExportedTaxonomy{ID: tag.ID, Name: tag.Name, Slug: tag.Slug})
+	}
+	for _, translation := range article.Translations {
+		exported.Translations = append(exported.Translations, ExportedTranslation{
+			Language:        translation.Language,
+			Title:           translation.Title,
+			Slug:            translation.Slug,
+			Summary:         translation.Summary,
+			Content:         translation.Content,
+			MetaTitle:       translation.MetaTitle,
+			MetaDescription: translation.MetaDescription,
+			Status:          translation.Status,
+			UpdatedAt:       translation.UpdatedAt,
+		})
+	}
+	for _, block := range article.ContentBlocks {
+		exported.Blocks = append(exported.Blocks, ExportedBlock{
+			ID:        block.ID,
+			BlockType: block.BlockType,
+			Content:   block.Content,
+			Settings:  block.Settings,
+			Position:  block.Position,
+			IsVisible: block.IsVisible,
+		})
+	}
+
+	return exported
+}
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"news/internal/database"
+	"news/internal/models"
+	"news/internal/repositories"
+	"news/internal/sitemap"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrRevisionNotFound is returned when a requested article revision does not exist
+	ErrRevisionNotFound = errors.New("article revision not found")
+)
+
+// Revision change types
+const (
+	RevisionChangeCreate  = "create"
+	RevisionChangeUpdate  = "update"
+	RevisionChangeBlocks  = "blocks"
+	RevisionChangeRestore = "restore"
+)
+
+// RevisionListResponse represents a paginated list of article revisions
+type RevisionListResponse struct {
+	ArticleID  uint                     `json:"article_id"`
+	Revisions  []models.ArticleRevision `json:"revisions"`
+	Total      int64                    `json:"total"`
+	Page       int                      `json:"page"`
+	Limit      int                      `json:"limit"`
+	TotalPages int                      `json:"total_pages"`
+}
+
+// recordArticleRevision snapshots the stored state of an article and all of its blocks
+func recordArticleRevision(tx *gorm.DB, articleID uint, changeType string, editorID uint, note string, restoredFromID *uint) (*models.ArticleRevision, error) {
+	var article models.Article
+	if err := tx.First(&article, articleID).Error; err != nil {
+		return nil, err
+	}
+
+	blocks, err := repositories.NewArticleContentBlockRepository(tx).GetBlocksByArticleID(articleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load content blocks: %w", err)
+	}
+
+	revision, err := models.NewArticleRevision(article, blocks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot article: %w", err)
+	}
+
+	revision.ChangeType = changeType
+	revision.ChangeNote = note
+	revision.RestoredFromID = restoredFromID
+	if editorID != 0 {
+		revision.EditorID = &editorID
+	}
+
+	// Concurrent edits can pick the same next number; the unique index rejects all but one,
+	// and the others take the following number. The insert runs in a savepoint so a conflict
+	// does not abort the caller's transaction.
+	for attempt := 1; ; attempt++ {
+		err := tx.Transaction(func(tx *gorm.DB) error {
+			revisionRepo := repositories.NewArticleRevisionRepository(tx)
+			latest, err := revisionRepo.GetLatestRevisionNumber(articleID)
+			if err != nil {
+				return err
+			}
+			revision.ID = 0
+			revision.RevisionNumber = latest + 1
+			return revisionRepo.Create(&revision)
+		})
+		if err == nil {
+			return &revision, nil
+		}
+		if attempt == revisionNumberAttempts || !isDuplicateKeyError(tx, err) {
+			return nil, err
+		}
+	}
+}
+
+// revisionNumberAttempts is how often a revision insert is retried after losing a race for its number
+const revisionNumberAttempts = 5
+
+// isDuplicateKeyError reports whether err is a unique constraint violation
+func isDuplicateKeyError(db *gorm.DB, err error) bool {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	if translator, ok := db.Dialector.(gorm.ErrorTranslator); ok {
+		return errors.Is(translator.Translate(err), gorm.ErrDuplicatedKey)
+	}
+	return false
+}
+
+// ensureBaselineRevision records the current state of an article that predates revision
+// tracking, so the first tracked change can still be reverted
+func ensureBaselineRevision(tx *gorm.DB, articleID uint) error {
+	count, err := repositories.NewArticleRevisionRepository(tx).CountByArticleID(articleID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	_, err = recordArticleRevision(tx, articleID, RevisionChangeCreate, 0, "Baseline snapshot before first tracked change", nil)
+	return err
+}
+
+// GetArticleRevisions retrieves the revision history of an article with pagination
+func GetArticleRevisions(articleID uint, page, limit int) (*RevisionListResponse, error) {
+	if err := database.DB.Select("id").First(&models.Article{}, articleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	revisionRepo := repositories.NewArticleRevisionRepository(database.DB)
+
+	total, err := revisionRepo.CountByArticleID(articleID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	revisions, err := revisionRepo.ListByArticleID(articleID, (page-1)*limit, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	return &RevisionListResponse{
+		ArticleID:  articleID,
+		Revisions:  revisions,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+	}, nil
+}
+
+// GetArticleRevision retrieves a single revision of an article by revision number
+func GetArticleRevision(articleID uint, revisionNumber int) (*models.ArticleRevision, error) {
+	revision, err := repositories.NewArticleRevisionRepository(database.DB).GetByNumber(articleID, revisionNumber)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRevisionNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return revision, nil
+}
+
+// CompareArticleRevisions returns a field- and block-level diff between two revisions.
+// When toNumber is zero the latest revision, which mirrors the current article, is used.
+func CompareArticleRevisions(articleID uint, fromNumber, toNumber int) (*models.ArticleRevisionDiff, error) {
+	from, err := GetArticleRevision(articleID, fromNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	var to *models.ArticleRevision
+	if toNumber == 0 {
+		to, err = repositories.NewArticleRevisionRepository(database.DB).GetLatest(articleID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrRevisionNotFound
+		}
+	} else {
+		to, err = GetArticleRevision(articleID, toNumber)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := models.DiffArticleRevisions(*from, *to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare revisions: %v", err)
+	}
+
+	return &diff, nil
+}
+
+// RestoreArticleRevision makes a past revision the current version of an article.
+// Title, summary, content, SEO fields and content blocks are restored; the publication
+// status is left untouched so restoring never publishes or unpublishes an article.
+func RestoreArticleRevision(articleID uint, revisionNumber int, editorID uint) (*models.ArticleRevision, error) {
+	var restored *models.ArticleRevision
+	var updated models.Article
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var article models.Article
+		if err := tx.First(&article, articleID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		revision, err := repositories.NewArticleRevisionRepository(tx).GetByNumber(articleID, revisionNumber)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRevisionNotFound
+			}
+			return err
+		}
+
+		if err := ensureBaselineRevision(tx, articleID); err != nil {
+			return fmt.Errorf("failed to record baseline revision: %v", err)
+		}
+
+		blocks, err := revision.ToContentBlocks()
+		if err != nil {
+			return fmt.Errorf("failed to decode revision blocks: %v", err)
+		}
+
+		// Blocks that still exist are restored in place, so editorial notes stay attached
+		for i := range blocks {
+			blocks[i].Position = i + 1
+		}
+		if err := repositories.NewArticleContentBlockRepository(tx).ReplaceBlocks(articleID, blocks); err != nil {
+			return fmt.Errorf("failed to restore content blocks: %v", err)
+		}
+
+		article.Title = revision.Title
+		article.Summary = revision.Summary
+		article.Content = revision.Content
+		article.FeaturedImage = revision.FeaturedImage
+		article.MetaTitle = revision.MetaTitle
+		article.MetaDesc = revision.MetaDesc
+		if revision.ContentType != "" {
+			article.ContentType = revision.ContentType
+		}
+		article.HasBlocks = len(blocks) > 0
+		article.BlocksVersion++
+
+		if err := tx.Save(&article).Error; err != nil {
+			return err
+		}
+		updated = article
+
+		note := fmt.Sprintf("Restored from revision %d", revision.RevisionNumber)
+		restored, err = recordArticleRevision(tx, articleID, RevisionChangeRestore, editorID, note, &revision.ID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrRevisionNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	invalidateArticleCaches(articleID)
+	if updated.Status == "published" {
+		go EmitWebhookEvent(models.WebhookEventArticleUpdated, ArticleWebhookData(&updated))
+		go publicContentChanged(sitemap.SectionArticles, articleID)
+	}
+
+	return restored, nil
+}
+
+// invalidateArticleCaches clears the single-article and list caches after a content change
+func invalidateArticleCaches(articleID uint) {
+	if cacheInvalidator == nil {
+		return
+	}
+
+	if err := cacheInvalidator.InvalidateArticle(int64(articleID)); err != nil {
+		log.Printf("Warning: Failed to invalidate article cache: %v", err)
+	}
+	if err := cacheInvalidator.InvalidateArticleLists(); err != nil {
+		log.Printf("Warning: Failed to invalidate article lists cache: %v", err)
+	}
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"news/internal/database"
+	"news/internal/models"
+	"news/internal/pubsub"
+	"news/internal/sitemap"
+
+	"gorm.io/gorm"
+)
+
+// ScheduledPublishResult summarises one run of the article scheduler
+type ScheduledPublishResult struct {
+	Published   []uint `json:"published"`
+	Unpublished []uint `json:"unpublished"`
+}
+
+// RunArticleSchedule publishes scheduled articles whose ScheduledAt has passed and takes down
+// published articles whose UnpublishAt has passed. At most batchSize articles are moved in
+// each direction per run.
+func RunArticleSchedule(now time.Time, batchSize int) (*ScheduledPublishResult, error) {
+	result := &ScheduledPublishResult{}
+
+	published, err := PublishDueArticles(now, batchSize)
+	result.Published = published
+	if err != nil {
+		return result, err
+	}
+
+	unpublished, err := UnpublishDueArticles(now, batchSize)
+	result.Unpublished = unpublished
+	return result, err
+}
+
+// PublishDueArticles publishes every scheduled article that is due and returns their IDs
+func PublishDueArticles(now time.Time, batchSize int) ([]uint, error) {
+	var due []models.Article
+	err := database.DB.Select("id").
+		Where("status = ? AND scheduled_at IS NOT NULL AND scheduled_at <= ?", "scheduled", now).
+		Order("scheduled_at ASC").
+		Limit(batchSize).
+		Find(&due).Error
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	var published []uint
+	for _, candidate := range due {
+		article, err := publishScheduledArticle(candidate.ID, now)
+		if err != nil {
+			log.Printf("Warning: Failed to publish scheduled article %d: %v", candidate.ID, err)
+			continue
+		}
+		if article == nil {
+			// Already handled elsewhere, or rescheduled since the query ran
+			continue
+		}
+
+		published = append(published, article.ID)
+		invalidateScheduledArticleCaches(article)
+		notifyArticlePublished(article)
+		notifyWorkflowTransition(database.DB, article, "scheduled", "published", 0, "Published on schedule")
+		log.Printf("Published scheduled article %d (%s)", article.ID, article.Title)
+	}
+
+	return published, nil
+}
+
+// UnpublishDueArticles moves every published article whose UnpublishAt has passed back to
+// draft or to the archive and returns their IDs
+func UnpublishDueArticles(now time.Time, batchSize int) ([]uint, error) {
+	var due []models.Article
+	err := database.DB.Select("id").
+		Where("status = ? AND unpublish_at IS NOT NULL AND unpublish_at <= ?", "published", now).
+		Order("unpublish_at ASC").
+		Limit(batchSize).
+		Find(&due).Error
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	var unpublished []uint
+	for _, candidate := range due {
+		article, err := unpublishArticle(candidate.ID, now)
+		if err != nil {
+			log.Printf("Warning: Failed to unpublish article %d: %v", candidate.ID, err)
+			continue
+		}
+		if article == nil {
+			continue
+		}
+
+		unpublished = append(unpublished, article.ID)
+		invalidateScheduledArticleCaches(article)
+		publicContentChanged(sitemap.SectionArticles, article.ID)
+		notifyWorkflowTransition(database.DB, article, "published", article.Status, 0, "")
+		log.Printf("Unpublished article %d (now %s)", article.ID, article.Status)
+	}
+
+	return unpublished, nil
+}
+
+// publishScheduledArticle publishes a single article. The status change is conditional on the
+// article still being scheduled, so concurrent runs cannot publish it twice. A nil article is
+// returned when there was nothing to do.
+func publishScheduledArticle(articleID uint, now time.Time) (*models.Article, error) {
+	var article models.Article
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := ensureBaselineRevision(tx, articleID); err != nil {
+			return fmt.Errorf("failed to record baseline revision: %v", err)
+		}
+
+		update := tx.Model(&models.Article{}).
+			Where("id = ? AND status = ? AND scheduled_at <= ?", articleID, "scheduled", now).
+			Updates(map[string]interface{}{
+				"status":       "published",
+				"published_at": now,
+				"updated_at":   now,
+			})
+		if update.Error != nil {
+			return update.Error
+		}
+		if update.RowsAffected == 0 {
+			return nil
+		}
+
+		if _, err := recordArticleRevision(tx, articleID, RevisionChangeUpdate, 0, "Published on schedule", nil); err != nil {
+			return fmt.Errorf("failed to record revision: %v", err)
+		}
+		if err := recordWorkflowTransition(tx, articleID, "scheduled", "published", 0, "", nil, "Published on schedule"); err != nil {
+			return fmt.Errorf("failed to log transition: %v", err)
+		}
+
+		return tx.Preload("Categories").First(&article, articleID).Error
+	})
+	if err != nil || article.ID == 0 {
+		return nil, err
+	}
+
+	return &article, nil
+}
+
+// unpublishArticle takes a single published article down once its UnpublishAt has passed.
+// UnpublishAt is cleared so that republishing the article later does not immediately take it
+// down again.
+func unpublishArticle(articleID uint, now time.Time) (*models.Article, error) {
+	var article models.Article
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var current models.Article
+		if err := tx.Select("id", "status", "unpublish_at", "unpublish_to").First(&current, articleID).Error; err != nil {
+			return err
+		}
+		if !current.IsDueForUnpublication(now) {
+			return nil
+		}
+
+		if err := ensureBaselineRevision(tx, articleID); err != nil {
+			return fmt.Errorf("failed to record baseline revision: %v", err)
+		}
+
+		target := current.UnpublishStatus()
+		update := tx.Model(&models.Article{}).
+			Where("id = ? AND status = ? AND unpublish_at <= ?", articleID, "published", now).
+			Updates(map[string]interface{}{
+				"status":       target,
+				"unpublish_at": nil,
+				"updated_at":   now,
+			})
+		if update.Error != nil {
+			return update.Error
+		}
+		if update.RowsAffected == 0 {
+			return nil
+		}
+
+		note := "Unpublished on schedule"
+		if target == "archived" {
+			note = "Archived on schedule"
+		}
+		if _, err := recordArticleRevision(tx, articleID, RevisionChangeUpdate, 0, note, nil); err != nil {
+			return fmt.Errorf("failed to record revision: %v", err)
+		}
+		if err := recordWorkflowTransition(tx, articleID, "published", target, 0, "", nil, note); err != nil {
+			return fmt.Errorf("failed to log transition: %v", err)
+		}
+
+		return tx.Preload("Categories").First(&article, articleID).Error
+	})
+	if err != nil || article.ID == 0 {
+		return nil, err
+	}
+
+	return &article, nil
+}
+
+// invalidateScheduledArticleCaches clears the article, list and category caches after the
+// scheduler changes an article's visibility
+func invalidateScheduledArticleCaches(article *models.Article) {
+	invalidateArticleCaches(article.ID)
+
+	if cacheInvalidator == nil {
+		return
+	}
+	for _, category := range article.Categories {
+		if err := cacheInvalidator.InvalidateCategory(int64(category.ID)); err != nil {
+			log.Printf("Warning: Failed to invalidate category cache for article %d: %v", article.ID, err)
+		}
+	}
+}
+
+// notifyArticlePublished queues the article's embedding and sitemap refresh, drops the cached
+// feeds and SEO metadata, emits the article.published webhook, pushes the article to its
+// category topics and broadcasts breaking news to everyone, or otherwise alerts the users
+// subscribed to the article's categories
+func notifyArticlePublished(article *models.Article) {
+	RequestArticleEmbedding(article.ID)
+	publicContentChanged(sitemap.SectionArticles, article.ID)
+	EmitWebhookEvent(models.WebhookEventArticlePublished, ArticleWebhookData(article))
+
+	for _, category := range article.Categories {
+		if category.Slug == "" {
+			continue
+		}
+		if err := pubsub.PublishCategoryArticle(category.Slug, *article); err != nil {
+			log.Printf("Warning: Failed to publish article %d to category %s: %v", article.ID, category.Slug, err)
+		}
+	}
+
+	if article.IsBreaking {
+		if err := pubsub.PublishBreakingNews(*article); err != nil {
+			log.Printf("Warning: Failed to publish breaking news for article %d: %v", article.ID, err)
+		}
+		return
+	}
+
+	for _, category := range article.Categories {
+		userIDs, err := GetCategorySubscriberIDs(category.ID)
+		if err != nil {
+			log.Printf("Warning: Failed to load subscribers of category %d: %v", category.ID, err)
+			continue
+		}
+		if len(userIDs) == 0 {
+			continue
+		}
+		if err := pubsub.PublishCategoryNewsAlert(category.ID, *article, userIDs); err != nil {
+			log.Printf("Warning: Failed to publish category alert for article %d: %v", article.ID, err)
+		}
+	}
+}
+
+// GetCategorySubscriberIDs returns the registered users with an active subscription to a category
+func GetCategorySubscriberIDs(categoryID uint) ([]uint, error) {
+	var userIDs []uint
+	err := database.DB.Model(&models.Subscription{}).
+		Where("type = ? AND category_id = ? AND is_active = ? AND user_id IS NOT NULL", "category", categoryID, true).
+		Distinct().
+		Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
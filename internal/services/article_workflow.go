@@ -0,0 +1,403 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"news/internal/config"
+	"news/internal/database"
+	"news/internal/models"
+	"news/internal/sitemap"
+	"news/internal/workflow"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrWorkflowForbidden is returned when the editorial workflow does not let the actor make
+	// a change, such as a status change their role may not make or an author editing another
+	// author's article
+	ErrWorkflowForbidden = errors.New("not allowed by the editorial workflow")
+)
+
+const maxReviewNoteLength = 5000
+
+// workflowStatusTitles are the notification titles of each status an article can move to
+var workflowStatusTitles = map[string]string{
+	workflow.StatusDraft:            "Article moved back to draft",
+	workflow.StatusInReview:         "Article submitted for review",
+	workflow.StatusChangesRequested: "Changes requested",
+	workflow.StatusApproved:         "Article approved",
+	workflow.StatusScheduled:        "Article scheduled",
+	workflow.StatusPublished:        "Article published",
+	workflow.StatusArchived:         "Article archived",
+	workflow.StatusTrash:            "Article moved to trash",
+}
+
+// WorkflowActor is the user changing an article and the role they act as
+type WorkflowActor struct {
+	ID   uint
+	Role string
+}
+
+// TransitionRequest moves an article to another status
+type TransitionRequest struct {
+	Status string `json:"status" binding:"required"`
+	Note   string `json:"note"`
+	// ReviewerID assigns the editor reviewing the article when it is submitted for review
+	ReviewerID *uint `json:"reviewer_id"`
+	// ScheduledAt is required when scheduling an article that has no publication time yet
+	ScheduledAt *time.Time `json:"scheduled_at"`
+}
+
+// ReviewNoteRequest is a note left while reviewing an article
+type ReviewNoteRequest struct {
+	Note string `json:"note" binding:"required"`
+}
+
+// ArticleWorkflow is the workflow state of an article: its status, its reviewer, the status
+// changes the requesting user may make and the history of transitions and review notes
+type ArticleWorkflow struct {
+	ArticleID          uint                               `json:"article_id"`
+	Status             string                             `json:"status"`
+	ReviewerID         *uint                              `json:"reviewer_id,omitempty"`
+	Reviewer           *models.User                       `json:"reviewer,omitempty"`
+	AllowedTransitions []string                           `json:"allowed_transitions"`
+	Transitions        []models.ArticleWorkflowTransition `json:"transitions"`
+	Notes              []models.ArticleReviewNote         `json:"notes"`
+}
+
+// ArticleWorkflowService moves articles through the editorial workflow, logs every status
+// change and notifies authors and reviewers
+type ArticleWorkflowService struct {
+	db    *gorm.DB
+	rules workflow.Rules
+}
+
+var (
+	articleWorkflowInstance *ArticleWorkflowService
+	articleWorkflowOnce     sync.Once
+)
+
+// NewArticleWorkflowService creates a workflow service with the given transition rules
+func NewArticleWorkflowService(db *gorm.DB, rules workflow.Rules) *ArticleWorkflowService {
+	return &ArticleWorkflowService{db: db, rules: rules}
+}
+
+// GetArticleWorkflowService returns the workflow service. Invalid ARTICLE_WORKFLOW_TRANSITIONS
+// are logged and the default rules are used instead.
+func GetArticleWorkflowService() *ArticleWorkflowService {
+	articleWorkflowOnce.Do(func() {
+		rules, err := workflow.ParseRules(config.GetWorkflowConfig().Transitions)
+		if err != nil {
+			log.Printf("Warning: %v; using the default workflow rules", err)
+			rules = workflow.DefaultRules
+		}
+		articleWorkflowInstance = NewArticleWorkflowService(database.DB, rules)
+	})
+	return articleWorkflowInstance
+}
+
+// Authorize checks that actor may work on article and move it from one status to another. A
+// nil article stands for a new one. Authors may only work on their own articles.
+func (s *ArticleWorkflowService) Authorize(actor WorkflowActor, article *models.Article, from, to string) error {
+	if article != nil && !workflow.CanReview(actor.Role) && article.AuthorID != actor.ID {
+		return fmt.Errorf("%w: authors can only work on their own articles", ErrWorkflowForbidden)
+	}
+	if to == "" || to == from {
+		return nil
+	}
+	if !workflow.ValidStatus(to) {
+		return fmt.Errorf("%w: unknown status %q", ErrValidation, to)
+	}
+	if !s.rules.Allowed(actor.Role, from, to) {
+		if workflow.Publishing(to) && !workflow.CanPublish(actor.Role) {
+			return fmt.Errorf("%w: only editors and admins can publish or schedule articles", ErrWorkflowForbidden)
+		}
+		return fmt.Errorf("%w: %s cannot move an article from %s to %s", ErrWorkflowForbidden, actor.Role, from, to)
+	}
+	return nil
+}
+
+// Transition moves an article to another status. Submitting for review may assign a reviewer,
+// requesting changes requires a note, and scheduling requires a publication time.
+func (s *ArticleWorkflowService) Transition(articleID uint, actor WorkflowActor, req TransitionRequest) (*models.Article, error) {
+	to := strings.TrimSpace(req.Status)
+	note := strings.TrimSpace(req.Note)
+	if len(note) > maxReviewNoteLength {
+		return nil, fmt.Errorf("%w: note must be at most %d characters", ErrValidation, maxReviewNoteLength)
+	}
+	if to == workflow.StatusChangesRequested && note == "" {
+		return nil, fmt.Errorf("%w: a note is required when requesting changes", ErrValidation)
+	}
+
+	var article models.Article
+	var from string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&article, articleID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+		from = article.Status
+		if from == to {
+			return fmt.Errorf("%w: article is already %s", ErrValidation, to)
+		}
+		if err := s.Authorize(actor, &article, from, to); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{
+			"status":     to,
+			"updated_at": now,
+		}
+		if req.ReviewerID != nil {
+			if to != workflow.StatusInReview {
+				return fmt.Errorf("%w: a reviewer can only be assigned when submitting for review", ErrValidation)
+			}
+			if err := s.validateReviewer(tx, *req.ReviewerID); err != nil {
+				return err
+			}
+			updates["reviewer_id"] = *req.ReviewerID
+			article.ReviewerID = req.ReviewerID
+		}
+		if to == workflow.StatusScheduled {
+			if req.ScheduledAt != nil {
+				updates["scheduled_at"] = *req.ScheduledAt
+				article.ScheduledAt = req.ScheduledAt
+			}
+			if article.ScheduledAt == nil {
+				return fmt.Errorf("%w: scheduled articles require scheduled_at", ErrValidation)
+			}
+		}
+		if to == workflow.StatusPublished && article.PublishedAt == nil {
+			updates["published_at"] = now
+		}
+
+		if err := ensureBaselineRevision(tx, articleID); err != nil {
+			return fmt.Errorf("failed to record baseline revision: %v", err)
+		}
+		if err := tx.Model(&models.Article{}).Where("id = ?", articleID).Updates(updates).Error; err != nil {
+			return err
+		}
+		revisionNote := fmt.Sprintf("Status changed from %s to %s", from, to)
+		if _, err := recordArticleRevision(tx, articleID, RevisionChangeUpdate, actor.ID, revisionNote, nil); err != nil {
+			return fmt.Errorf("failed to record revision: %v", err)
+		}
+		if err := recordWorkflowTransition(tx, articleID, from, to, actor.ID, actor.Role, article.ReviewerID, note); err != nil {
+			return fmt.Errorf("failed to log transition: %v", err)
+		}
+
+		return tx.Preload("Categories").First(&article, articleID).Error
+	})
+	if err != nil {
+		if errors.Is(err, ErrValidation) || errors.Is(err, ErrNotFound) || errors.Is(err, ErrWorkflowForbidden) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	invalidateScheduledArticleCaches(&article)
+	if to == workflow.StatusPublished {
+		published := article
+		go notifyArticlePublished(&published)
+	} else if from == workflow.StatusPublished {
+		go publicContentChanged(sitemap.SectionArticles, article.ID)
+	}
+	notifyWorkflowTransition(s.db, &article, from, to, actor.ID, note)
+
+	return &article, nil
+}
+
+// validateReviewer checks that a reviewer is an active editor or admin
+func (s *ArticleWorkflowService) validateReviewer(tx *gorm.DB, reviewerID uint) error {
+	var reviewer models.User
+	if err := tx.Select("id", "role", "status").First(&reviewer, reviewerID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: reviewer %d not found", ErrValidation, reviewerID)
+		}
+		return err
+	}
+	if !workflow.CanReview(reviewer.Role) || reviewer.IsSuspended() {
+		return fmt.Errorf("%w: reviewer must be an active editor or admin", ErrValidation)
+	}
+	return nil
+}
+
+// AddReviewNote leaves a review note on an article and notifies its author. Only editors and
+// admins review articles.
+func (s *ArticleWorkflowService) AddReviewNote(articleID uint, actor WorkflowActor, req ReviewNoteRequest) (*models.ArticleReviewNote, error) {
+	if !workflow.CanReview(actor.Role) {
+		return nil, fmt.Errorf("%w: only editors and admins can leave review notes", ErrWorkflowForbidden)
+	}
+	text := strings.TrimSpace(req.Note)
+	if text == "" {
+		return nil, fmt.Errorf("%w: note is required", ErrValidation)
+	}
+	if len(text) > maxReviewNoteLength {
+		return nil, fmt.Errorf("%w: note must be at most %d characters", ErrValidation, maxReviewNoteLength)
+	}
+
+	article, err := s.loadArticle(articleID)
+	if err != nil {
+		return nil, err
+	}
+
+	note := models.ArticleReviewNote{
+		ArticleID: articleID,
+		UserID:    actor.ID,
+		Status:    article.Status,
+		Note:      text,
+	}
+	if err := s.db.Create(&note).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	s.db.Preload("User").First(&note, note.ID)
+
+	if article.AuthorID != actor.ID {
+		NotifyUser(s.db, article.AuthorID, "review_note", "New review note",
+			fmt.Sprintf("A reviewer left a note on %q: %s", article.Title, truncate(text, 140)),
+			map[string]interface{}{
+				"article_id": articleID,
+				"note_id":    note.ID,
+				"user_id":    actor.ID,
+			})
+	}
+	return &note, nil
+}
+
+// Get returns the workflow state of an article as seen by actor
+func (s *ArticleWorkflowService) Get(articleID uint, actor WorkflowActor) (*ArticleWorkflow, error) {
+	article, err := s.loadArticle(articleID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Authorize(actor, article, article.Status, ""); err != nil {
+		return nil, err
+	}
+
+	state := &ArticleWorkflow{
+		ArticleID:          article.ID,
+		Status:             article.Status,
+		ReviewerID:         article.ReviewerID,
+		AllowedTransitions: s.rules.Targets(actor.Role, article.Status),
+	}
+	if article.ReviewerID != nil {
+		var reviewer models.User
+		if err := s.db.First(&reviewer, *article.ReviewerID).Error; err == nil {
+			state.Reviewer = &reviewer
+		}
+	}
+	if err := s.db.Preload("Actor").Preload("Reviewer").
+		Where("article_id = ?", articleID).
+		Order("created_at DESC, id DESC").
+		Find(&state.Transitions).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	if err := s.db.Preload("User").
+		Where("article_id = ?", articleID).
+		Order("created_at DESC, id DESC").
+		Find(&state.Notes).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return state, nil
+}
+
+// ReviewQueue lists the articles waiting for review, oldest submission first. Editors see the
+// articles assigned to them and the unassigned ones; admins see all of them unless mine is set.
+func (s *ArticleWorkflowService) ReviewQueue(actor WorkflowActor, mine bool, page, limit int) ([]models.Article, int64, error) {
+	if !workflow.CanReview(actor.Role) {
+		return nil, 0, fmt.Errorf("%w: only editors and admins review articles", ErrWorkflowForbidden)
+	}
+
+	query := s.db.Model(&models.Article{}).Where("status = ?", workflow.StatusInReview)
+	switch {
+	case mine:
+		query = query.Where("reviewer_id = ?", actor.ID)
+	case strings.EqualFold(actor.Role, workflow.RoleEditor):
+		query = query.Where("(reviewer_id = ? OR reviewer_id IS NULL)", actor.ID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	var articles []models.Article
+	err := query.Preload("Author").Preload("Reviewer").Preload("Categories").
+		Order("updated_at ASC, id ASC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&articles).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return articles, total, nil
+}
+
+func (s *ArticleWorkflowService) loadArticle(articleID uint) (*models.Article, error) {
+	var article models.Article
+	if err := s.db.First(&article, articleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return &article, nil
+}
+
+// recordWorkflowTransition logs a status change of an article. An actorID of 0 marks a change
+// made by the system; an empty role is looked up from the actor.
+func recordWorkflowTransition(tx *gorm.DB, articleID uint, from, to string, actorID uint, role string, reviewerID *uint, note string) error {
+	transition := models.ArticleWorkflowTransition{
+		ArticleID:  articleID,
+		FromStatus: from,
+		ToStatus:   to,
+		ActorRole:  strings.ToLower(role),
+		ReviewerID: reviewerID,
+		Note:       note,
+	}
+	if actorID == 0 {
+		transition.ActorRole = "system"
+	} else {
+		transition.ActorID = &actorID
+		if transition.ActorRole == "" {
+			tx.Model(&models.User{}).Where("id = ?", actorID).Limit(1).Pluck("role", &transition.ActorRole)
+		}
+	}
+	return tx.Create(&transition).Error
+}
+
+// notifyWorkflowTransition tells the author of an article that its status changed, unless they
+// changed it themselves, and tells the reviewer when the article is submitted to them
+func notifyWorkflowTransition(db *gorm.DB, article *models.Article, from, to string, actorID uint, note string) {
+	title := workflowStatusTitles[to]
+	if title == "" {
+		title = "Article status changed"
+	}
+	message := fmt.Sprintf("%q moved from %s to %s", article.Title, from, to)
+	if note != "" {
+		message += ": " + truncate(note, 140)
+	}
+	data := map[string]interface{}{
+		"article_id":  article.ID,
+		"from_status": from,
+		"to_status":   to,
+		"actor_id":    actorID,
+	}
+
+	if article.AuthorID != actorID {
+		NotifyUser(db, article.AuthorID, "article_workflow", title, message, data)
+	}
+	if to == workflow.StatusInReview && article.ReviewerID != nil && *article.ReviewerID != actorID {
+		NotifyUser(db, *article.ReviewerID, "review_requested", "Review requested",
+			fmt.Sprintf("%q is waiting for your review", article.Title), data)
+	}
+}
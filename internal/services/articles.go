@@ -14,6 +14,7 @@ import (
 	"news/internal/json"
 	"news/internal/models"
 	"news/internal/repositories"
+	"news/internal/sitemap"
 	"news/internal/tracing"
 
 	"go.opentelemetry.io/otel/attribute"
@@ -167,6 +168,19 @@ func GetArticleById(id string) (models.Article, error) {
 	return article, nil
 }
 
+// GetArticleForEdit loads an article in any status straight from the database, so edits work
+// on drafts, scheduled and in-review articles and never start from a cached copy
+func GetArticleForEdit(id string) (models.Article, error) {
+	article, err := repositories.GetArticleByIDAnyStatus(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Article{}, ErrNotFound
+		}
+		return models.Article{}, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return article, nil
+}
+
 // GetArticleByIdWithContext retrieves a single article by ID with tracing
 func GetArticleByIdWithContext(ctx context.Context, id string) (models.Article, error) {
 	ctx, span := tracing.StartSpan(ctx, "GetArticleById")
@@ -285,12 +299,27 @@ func CreateArticle(article models.Article) (models.Article, error) {
 	// Generate slug from title
 	article.Slug = repositories.GenerateSlug(article.Title)
 
+	if article.Status == "published" && article.PublishedAt == nil {
+		now := time.Now()
+		article.PublishedAt = &now
+	}
+
 	createdArticle, err := repositories.InsertArticle(article)
 	if err != nil {
 		log.Printf("Error creating article: %v", err)
 		return models.Article{}, fmt.Errorf("%w: %v", ErrDatabaseError, err)
 	}
 
+	// Record the initial revision
+	if _, err := recordArticleRevision(database.DB, createdArticle.ID, RevisionChangeCreate, createdArticle.AuthorID, "", nil); err != nil {
+		log.Printf("Warning: Failed to record initial revision for article %d: %v", createdArticle.ID, err)
+	}
+
+	if createdArticle.Status == "published" {
+		published := createdArticle
+		go notifyArticlePublished(&published)
+	}
+
 	// Use unified cache invalidation system
 	if cacheInvalidator != nil {
 		// Invalidate all article lists and pagination caches
@@ -327,33 +356,79 @@ func CreateArticle(article models.Article) (models.Article, error) {
 	return createdArticle, nil
 }
 
-// UpdateArticle updates an existing article with cache invalidation
-func UpdateArticle(id string, updatedArticle models.Article) (models.Article, error) {
+// UpdateArticle updates an existing article with cache invalidation and records a revision
+// attributed to editorID
+func UpdateArticle(id string, updatedArticle models.Article, editorID uint) (models.Article, error) {
 	// Validate article before updating
 	if err := validateArticle(updatedArticle); err != nil {
 		return models.Article{}, err
 	}
 
-	existingArticle, err := repositories.GetArticleByID(id)
+	existingArticle, err := GetArticleForEdit(id)
 	if err != nil {
-		log.Printf("Article with id %s not found: %v", id, err)
-		return models.Article{}, ErrNotFound
+		return models.Article{}, err
 	}
 
+	previousStatus := existingArticle.Status
+	wasPublished := previousStatus == "published"
+
 	// Update fields
 	existingArticle.Title = updatedArticle.Title
 	existingArticle.Content = updatedArticle.Content
 	existingArticle.FeaturedImage = updatedArticle.FeaturedImage
 	existingArticle.Status = updatedArticle.Status
+	existingArticle.ScheduledAt = updatedArticle.ScheduledAt
+	existingArticle.UnpublishAt = updatedArticle.UnpublishAt
+	existingArticle.UnpublishTo = updatedArticle.UnpublishTo
 	existingArticle.MetaTitle = updatedArticle.MetaTitle
 	existingArticle.MetaDesc = updatedArticle.MetaDesc
 	existingArticle.UpdatedAt = time.Now()
+	if existingArticle.Status == "published" && existingArticle.PublishedAt == nil {
+		now := time.Now()
+		existingArticle.PublishedAt = &now
+	}
+
+	// Save the article and snapshot the result so the change can be reviewed or reverted
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := ensureBaselineRevision(tx, existingArticle.ID); err != nil {
+			return fmt.Errorf("failed to record baseline revision: %v", err)
+		}
 
-	if err := repositories.UpdateArticle(existingArticle); err != nil {
+		if err := tx.Save(&existingArticle).Error; err != nil {
+			return err
+		}
+
+		if _, err := recordArticleRevision(tx, existingArticle.ID, RevisionChangeUpdate, editorID, "", nil); err != nil {
+			return fmt.Errorf("failed to record revision: %v", err)
+		}
+
+		if existingArticle.Status != previousStatus {
+			if err := recordWorkflowTransition(tx, existingArticle.ID, previousStatus, existingArticle.Status, editorID, "", existingArticle.ReviewerID, ""); err != nil {
+				return fmt.Errorf("failed to log transition: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		log.Printf("Error updating article: %v", err)
 		return models.Article{}, fmt.Errorf("%w: %v", ErrDatabaseError, err)
 	}
 
+	if existingArticle.Status != previousStatus {
+		notifyWorkflowTransition(database.DB, &existingArticle, previousStatus, existingArticle.Status, editorID, "")
+	}
+
+	if !wasPublished && existingArticle.Status == "published" {
+		published := existingArticle
+		go notifyArticlePublished(&published)
+	} else if wasPublished && existingArticle.Status == "published" {
+		go RequestArticleEmbedding(existingArticle.ID)
+		go EmitWebhookEvent(models.WebhookEventArticleUpdated, ArticleWebhookData(&existingArticle))
+	}
+	if wasPublished {
+		go publicContentChanged(sitemap.SectionArticles, existingArticle.ID)
+	}
+
 	// Use unified cache invalidation system
 	if cacheInvalidator != nil {
 		// Invalidate the specific article
@@ -379,9 +454,9 @@ func UpdateArticle(id string, updatedArticle models.Article) (models.Article, er
 		log.Printf("Successfully invalidated caches after updating article %s", id)
 	}
 
-	// Cache the updated article in unified cache
+	// Cache the updated article in unified cache; only published articles are served from it
 	unifiedCache := cache.GetUnifiedCache()
-	if cacheData, err := json.MarshalForCache(existingArticle); err == nil {
+	if cacheData, err := json.MarshalForCache(existingArticle); err == nil && existingArticle.Status == "published" {
 		cacheKey := articleKeyPrefix + id
 		l1TTL := 10 * time.Minute
 		l2TTL := articleCacheDuration // 30 minutes
@@ -399,10 +474,9 @@ func UpdateArticle(id string, updatedArticle models.Article) (models.Article, er
 // DeleteArticle deletes an article by ID with cache invalidation
 func DeleteArticle(id string) error {
 	// First get the article to check its categories before deletion
-	existingArticle, err := repositories.GetArticleByID(id)
+	existingArticle, err := GetArticleForEdit(id)
 	if err != nil {
-		log.Printf("Article with id %s not found: %v", id, err)
-		return ErrNotFound
+		return err
 	}
 
 	err = repositories.DeleteArticleByID(id)
@@ -410,6 +484,9 @@ func DeleteArticle(id string) error {
 		log.Printf("Error deleting article: %v", err)
 		return fmt.Errorf("%w: %v", ErrDatabaseError, err)
 	}
+	if existingArticle.Status == "published" {
+		go publicContentChanged(sitemap.SectionArticles, existingArticle.ID)
+	}
 
 	// Use unified cache invalidation system
 	if cacheInvalidator != nil {
@@ -455,6 +532,18 @@ func validateArticle(article models.Article) error {
 		}
 	}
 
+	if article.Status == "scheduled" && article.ScheduledAt == nil {
+		return fmt.Errorf("%w: scheduled articles require scheduled_at", ErrValidation)
+	}
+
+	if article.UnpublishTo != "" && article.UnpublishTo != "draft" && article.UnpublishTo != "archived" {
+		return fmt.Errorf("%w: unpublish_to must be draft or archived", ErrValidation)
+	}
+
+	if article.UnpublishAt != nil && article.ScheduledAt != nil && !article.UnpublishAt.After(*article.ScheduledAt) {
+		return fmt.Errorf("%w: unpublish_at must be after scheduled_at", ErrValidation)
+	}
+
 	return nil
 }
 
@@ -564,6 +653,11 @@ func CreateArticleWithBlocks(article models.Article, blocks []models.ArticleCont
 		return models.Article{}, err
 	}
 
+	// Record the initial revision
+	if _, err := recordArticleRevision(database.DB, createdArticle.ID, RevisionChangeCreate, createdArticle.AuthorID, "", nil); err != nil {
+		log.Printf("Warning: Failed to record initial revision for article %d: %v", createdArticle.ID, err)
+	}
+
 	// Invalidate caches
 	if cacheInvalidator != nil {
 		if err := cacheInvalidator.InvalidateArticleLists(); err != nil {
@@ -585,10 +679,10 @@ func CreateArticleWithBlocks(article models.Article, blocks []models.ArticleCont
 	return createdArticle, nil
 }
 
-// UpdateArticleBlocks updates article content blocks
-func UpdateArticleBlocks(articleID string, blocks []models.ArticleContentBlock) error {
+// UpdateArticleBlocks replaces article content blocks and records a revision attributed to editorID
+func UpdateArticleBlocks(articleID string, blocks []models.ArticleContentBlock, editorID uint) error {
 	// Get article
-	article, err := GetArticleById(articleID)
+	article, err := GetArticleForEdit(articleID)
 	if err != nil {
 		return err
 	}
@@ -599,31 +693,41 @@ func UpdateArticleBlocks(articleID string, blocks []models.ArticleContentBlock)
 	}
 
 	// Start transaction
-	return database.DB.Transaction(func(tx *gorm.DB) error {
-		// Delete existing blocks
-		if err := repositories.ArticleContentBlockRepo.DeleteBlocksByArticleID(article.ID); err != nil {
-			return fmt.Errorf("failed to delete existing blocks: %v", err)
-		}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		blockRepo := repositories.NewArticleContentBlockRepository(tx)
 
-		// Create new blocks
-		if len(blocks) > 0 {
-			for i := range blocks {
-				blocks[i].ArticleID = article.ID
-				blocks[i].Position = i + 1
-			}
+		// Snapshot the previous blocks if this article has no history yet
+		if err := ensureBaselineRevision(tx, article.ID); err != nil {
+			return fmt.Errorf("failed to record baseline revision: %v", err)
+		}
 
-			if err := repositories.ArticleContentBlockRepo.BulkCreateBlocks(blocks); err != nil {
-				return fmt.Errorf("failed to create content blocks: %v", err)
-			}
+		// Blocks sent with their ID are updated in place, so editorial notes stay attached
+		for i := range blocks {
+			blocks[i].Position = i + 1
+		}
+		if err := blockRepo.ReplaceBlocks(article.ID, blocks); err != nil {
+			return fmt.Errorf("failed to save content blocks: %v", err)
+		}
 
-			// Update content from blocks for backward compatibility
+		// Update content from blocks for backward compatibility
+		if len(blocks) > 0 {
 			article.ContentBlocks = blocks
 			article.UpdateContentFromBlocks()
+		}
 
-			// Update the article with generated content
-			if err := repositories.UpdateArticle(article); err != nil {
-				log.Printf("Warning: Failed to update article content from blocks: %v", err)
-			}
+		// Bump the blocks version and persist the regenerated content
+		article.BlocksVersion++
+		article.UpdatedAt = time.Now()
+		if err := tx.Model(&models.Article{}).Where("id = ?", article.ID).Updates(map[string]interface{}{
+			"content":        article.Content,
+			"blocks_version": article.BlocksVersion,
+			"updated_at":     article.UpdatedAt,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to update article: %v", err)
+		}
+
+		if _, err := recordArticleRevision(tx, article.ID, RevisionChangeBlocks, editorID, "", nil); err != nil {
+			return fmt.Errorf("failed to record revision: %v", err)
 		}
 
 		// Invalidate article cache
@@ -636,12 +740,21 @@ func UpdateArticleBlocks(articleID string, blocks []models.ArticleContentBlock)
 
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	if article.Status == "published" {
+		go EmitWebhookEvent(models.WebhookEventArticleUpdated, ArticleWebhookData(&article))
+		go publicContentChanged(sitemap.SectionArticles, article.ID)
+	}
+	return nil
 }
 
 // MigrateArticleToBlocks converts a legacy article to use content blocks
 func MigrateArticleToBlocks(articleID string) (models.Article, error) {
 	// Get article
-	article, err := GetArticleById(articleID)
+	article, err := GetArticleForEdit(articleID)
 	if err != nil {
 		return models.Article{}, err
 	}
@@ -657,10 +770,15 @@ func MigrateArticleToBlocks(articleID string) (models.Article, error) {
 	}
 
 	// Get updated article with blocks
-	updatedArticle, err := GetArticleWithBlocks(articleID)
+	updatedArticle, err := GetArticleForEdit(articleID)
 	if err != nil {
 		return models.Article{}, err
 	}
+	blocks, err := repositories.ArticleContentBlockRepo.GetVisibleBlocksByArticleID(updatedArticle.ID)
+	if err != nil {
+		return models.Article{}, fmt.Errorf("failed to load content blocks: %v", err)
+	}
+	updatedArticle.ContentBlocks = blocks
 
 	// Invalidate article cache
 	if cacheInvalidator != nil {
@@ -676,7 +794,7 @@ func MigrateArticleToBlocks(articleID string) (models.Article, error) {
 // AddContentBlock adds a single content block to an article
 func AddContentBlock(articleID string, block models.ArticleContentBlock) (*models.ArticleContentBlock, error) {
 	// Get article
-	article, err := GetArticleById(articleID)
+	article, err := GetArticleForEdit(articleID)
 	if err != nil {
 		return nil, err
 	}
@@ -734,7 +852,7 @@ func UpdateContentBlock(blockID uint, updateData map[string]interface{}) (*model
 	}
 
 	// Update article content from blocks if using blocks
-	article, err := GetArticleById(strconv.FormatUint(uint64(updatedBlock.ArticleID), 10))
+	article, err := GetArticleForEdit(strconv.FormatUint(uint64(updatedBlock.ArticleID), 10))
 	if err == nil && article.IsUsingBlocks() {
 		blocks, err := repositories.ArticleContentBlockRepo.GetVisibleBlocksByArticleID(article.ID)
 		if err == nil {
@@ -772,7 +890,7 @@ func DeleteContentBlock(blockID uint) error {
 	}
 
 	// Update article content from remaining blocks
-	article, err := GetArticleById(strconv.FormatUint(uint64(articleID), 10))
+	article, err := GetArticleForEdit(strconv.FormatUint(uint64(articleID), 10))
 	if err == nil && article.IsUsingBlocks() {
 		blocks, err := repositories.ArticleContentBlockRepo.GetVisibleBlocksByArticleID(article.ID)
 		if err == nil {
@@ -797,7 +915,7 @@ func DeleteContentBlock(blockID uint) error {
 // ReorderContentBlocks reorders content blocks for an article
 func ReorderContentBlocks(articleID string, blockPositions map[uint]int) error {
 	// Get article
-	article, err := GetArticleById(articleID)
+	article, err := GetArticleForEdit(articleID)
 	if err != nil {
 		return err
 	}
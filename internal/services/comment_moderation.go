@@ -0,0 +1,584 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"news/internal/config"
+	"news/internal/database"
+	"news/internal/json"
+	"news/internal/models"
+	"news/internal/moderation"
+	"news/internal/pubsub"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrCommentNotFound is returned when a comment to moderate does not exist
+	ErrCommentNotFound = errors.New("comment not found")
+	// ErrModerationPolicyNotFound is returned when a moderation policy does not exist
+	ErrModerationPolicyNotFound = errors.New("moderation policy not found")
+)
+
+// Moderator actions on comments
+const (
+	ModerationActionApprove = "approve"
+	ModerationActionReject  = "reject"
+	ModerationActionSpam    = "spam"
+)
+
+// maxBulkModeration limits how many comments a single bulk action may change
+const maxBulkModeration = 100
+
+// moderationActionStatus maps moderator actions to the comment status they set
+var moderationActionStatus = map[string]string{
+	ModerationActionApprove: moderation.StatusApproved,
+	ModerationActionReject:  moderation.StatusRejected,
+	ModerationActionSpam:    moderation.StatusSpam,
+}
+
+var (
+	commentModerationInstance *CommentModerationService
+	commentModerationOnce     sync.Once
+)
+
+// CommentScreener screens the text of a comment. AIService implements it.
+type CommentScreener interface {
+	ModerateComment(ctx context.Context, comment string, strict bool) (bool, float64, string, []models.ModerationCategory, string, error)
+}
+
+// ModerationPolicyRequest creates or replaces the moderation policy of a scope. Thresholds left
+// out take the configured defaults.
+type ModerationPolicyRequest struct {
+	ScopeType        string   `json:"scope_type" binding:"required" example:"category"` // global, category, article
+	ScopeID          uint     `json:"scope_id" example:"3"`
+	Mode             string   `json:"mode" binding:"required" example:"ai_screen"` // auto_approve, pre_moderate, ai_screen
+	ApproveThreshold *float64 `json:"approve_threshold,omitempty" example:"0.9"`
+	RejectThreshold  *float64 `json:"reject_threshold,omitempty" example:"0.85"`
+	TrustThreshold   *int     `json:"trust_threshold,omitempty" example:"5"`
+	Strict           bool     `json:"strict"`
+}
+
+// ModerationQueueFilter selects comments for the moderator queue
+type ModerationQueueFilter struct {
+	Status    string
+	ArticleID uint
+	Page      int
+	Limit     int
+}
+
+// ModerationQueueItem is a comment in the moderator queue with its author's trust score
+type ModerationQueueItem struct {
+	models.Comment
+	AuthorTrust int `json:"author_trust"`
+}
+
+// ModerationActionResult reports which comments a moderator action changed
+type ModerationActionResult struct {
+	Action   string           `json:"action"`
+	Updated  []uint           `json:"updated"`
+	NotFound []uint           `json:"not_found"`
+	Comments []models.Comment `json:"-"`
+}
+
+// CommentModerationService applies moderation policies to new comments and runs the moderator
+// queue
+type CommentModerationService struct {
+	db       *gorm.DB
+	cfg      *config.ModerationConfig
+	screener CommentScreener
+}
+
+// NewCommentModerationService creates a comment moderation service
+func NewCommentModerationService(db *gorm.DB, cfg *config.ModerationConfig, screener CommentScreener) *CommentModerationService {
+	return &CommentModerationService{db: db, cfg: cfg, screener: screener}
+}
+
+// GetCommentModerationService returns the comment moderation service, screening with the AI
+// service
+func GetCommentModerationService() *CommentModerationService {
+	commentModerationOnce.Do(func() {
+		commentModerationInstance = NewCommentModerationService(database.DB, config.GetModerationConfig(), GetAIService())
+	})
+	return commentModerationInstance
+}
+
+// CreateComment moderates a new comment under the policy of its article and saves it. The
+// returned decision tells whether the comment was published, queued or turned down.
+func (s *CommentModerationService) CreateComment(ctx context.Context, comment *models.Comment) (moderation.Decision, error) {
+	policy, err := s.PolicyFor(comment.ArticleID)
+	if err != nil {
+		return moderation.Decision{}, err
+	}
+	trust, err := s.TrustScore(comment.UserID)
+	if err != nil {
+		return moderation.Decision{}, err
+	}
+
+	var verdict *moderation.Verdict
+	var screening *models.ModerationResult
+	if policy.NeedsScreening(trust) {
+		verdict, screening = s.screen(ctx, comment.Content, policy.Strict)
+	}
+
+	decision := moderation.Decide(policy, trust, verdict)
+	comment.Status = decision.Status
+	comment.ModerationReason = decision.Reason
+	if decision.Screened && !decision.Queued() {
+		now := time.Now()
+		comment.ModeratedAt = &now
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(comment).Error; err != nil {
+			return err
+		}
+		if screening == nil {
+			return nil
+		}
+		screening.ContentID = comment.ID
+		return tx.Create(screening).Error
+	})
+	if err != nil {
+		return moderation.Decision{}, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	if err := s.db.Preload("User").First(comment, comment.ID).Error; err != nil {
+		log.Printf("Failed to reload comment %d: %v", comment.ID, err)
+	}
+	switch decision.Status {
+	case moderation.StatusApproved:
+		s.announce(*comment)
+	case moderation.StatusRejected:
+		s.notifyRejected(*comment)
+	}
+	return decision, nil
+}
+
+// announce tells the article's readers and webhook subscribers about a newly visible comment,
+// and notifies the author of the parent comment and mentioned users
+func (s *CommentModerationService) announce(comment models.Comment) {
+	if err := pubsub.PublishCommentNotification(comment.ArticleID, comment); err != nil {
+		log.Printf("Failed to publish comment notification: %v", err)
+	}
+
+	go EmitWebhookEvent(models.WebhookEventCommentCreated, map[string]interface{}{
+		"id":         comment.ID,
+		"article_id": comment.ArticleID,
+		"user_id":    comment.UserID,
+		"parent_id":  comment.ParentID,
+		"content":    comment.Content,
+		"status":     comment.Status,
+		"created_at": comment.CreatedAt,
+	})
+
+	notifyCommentAudience(s.db, comment)
+}
+
+// screen asks the screener about a comment. It returns a nil verdict when the screener fails,
+// and the result to record otherwise.
+func (s *CommentModerationService) screen(ctx context.Context, content string, strict bool) (*moderation.Verdict, *models.ModerationResult) {
+	if s.screener == nil {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.ScreeningTimeout)
+	defer cancel()
+
+	approved, confidence, reason, categories, severity, err := s.screener.ModerateComment(ctx, content, strict)
+	if err != nil {
+		log.Printf("Warning: Comment screening failed, queueing comment for review: %v", err)
+		return nil, nil
+	}
+
+	categoriesJSON, _ := json.Marshal(categories)
+	result := &models.ModerationResult{
+		ContentType: "comment",
+		Content:     content,
+		IsApproved:  approved,
+		Confidence:  confidence,
+		Reason:      reason,
+		Categories:  string(categoriesJSON),
+		Severity:    severity,
+	}
+	if !result.ValidateSeverity() {
+		result.Severity = "low"
+	}
+	return &moderation.Verdict{Approved: approved, Confidence: confidence, Spam: isSpam(categories)}, result
+}
+
+// isSpam reports whether spam is the most likely of the flagged categories
+func isSpam(categories []models.ModerationCategory) bool {
+	var top models.ModerationCategory
+	for _, category := range categories {
+		if category.Category != "safe" && category.Confidence > top.Confidence {
+			top = category
+		}
+	}
+	return top.Category == "spam"
+}
+
+// PolicyFor returns the moderation policy of an article: its own policy, else the strictest
+// policy of its categories, else the global policy, else the configured default
+func (s *CommentModerationService) PolicyFor(articleID uint) (moderation.Policy, error) {
+	var policies []models.CommentModerationPolicy
+	err := s.db.Where("(scope_type = ? AND scope_id = ?) OR scope_type = ? OR (scope_type = ? AND scope_id IN (?))",
+		models.ModerationScopeArticle, articleID,
+		models.ModerationScopeGlobal,
+		models.ModerationScopeCategory, s.db.Table("article_categories").Select("category_id").Where("article_id = ?", articleID)).
+		Order("scope_id ASC").
+		Find(&policies).Error
+	if err != nil {
+		return moderation.Policy{}, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	var global *moderation.Policy
+	var categories []moderation.Policy
+	for _, record := range policies {
+		policy := policyFromModel(record)
+		switch record.ScopeType {
+		case models.ModerationScopeArticle:
+			return policy, nil
+		case models.ModerationScopeCategory:
+			categories = append(categories, policy)
+		case models.ModerationScopeGlobal:
+			global = &policy
+		}
+	}
+	if policy, ok := moderation.Strictest(categories); ok {
+		return policy, nil
+	}
+	if global != nil {
+		return *global, nil
+	}
+	return s.defaultPolicy(), nil
+}
+
+func (s *CommentModerationService) defaultPolicy() moderation.Policy {
+	return moderation.Policy{
+		Mode:             s.cfg.Mode,
+		ApproveThreshold: s.cfg.ApproveThreshold,
+		RejectThreshold:  s.cfg.RejectThreshold,
+		TrustThreshold:   s.cfg.TrustThreshold,
+		Strict:           s.cfg.Strict,
+	}
+}
+
+func policyFromModel(record models.CommentModerationPolicy) moderation.Policy {
+	return moderation.Policy{
+		Mode:             record.Mode,
+		ApproveThreshold: record.ApproveThreshold,
+		RejectThreshold:  record.RejectThreshold,
+		TrustThreshold:   record.TrustThreshold,
+		Strict:           record.Strict,
+	}
+}
+
+// TrustScore returns the trust score of a commenter. Only comments a moderator or AI screening
+// decided on count, so comments published without review do not build trust.
+func (s *CommentModerationService) TrustScore(userID uint) (int, error) {
+	scores, err := s.trustScores([]uint{userID})
+	if err != nil {
+		return 0, err
+	}
+	return scores[userID], nil
+}
+
+func (s *CommentModerationService) trustScores(userIDs []uint) (map[uint]int, error) {
+	var rows []struct {
+		UserID uint
+		Status string
+		Count  int
+	}
+	err := s.db.Model(&models.Comment{}).
+		Select("user_id, status, COUNT(*) AS count").
+		Where("user_id IN ? AND moderated_at IS NOT NULL", userIDs).
+		Group("user_id, status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	counts := make(map[uint]map[string]int)
+	for _, row := range rows {
+		if counts[row.UserID] == nil {
+			counts[row.UserID] = make(map[string]int)
+		}
+		counts[row.UserID][row.Status] = row.Count
+	}
+	scores := make(map[uint]int, len(userIDs))
+	for _, userID := range userIDs {
+		c := counts[userID]
+		scores[userID] = moderation.TrustScore(c[moderation.StatusApproved], c[moderation.StatusRejected], c[moderation.StatusSpam])
+	}
+	return scores, nil
+}
+
+// Queue lists comments for moderators, oldest first, with the trust score of their authors
+func (s *CommentModerationService) Queue(filter ModerationQueueFilter) ([]ModerationQueueItem, int64, error) {
+	if filter.Status == "" {
+		filter.Status = moderation.StatusPending
+	}
+	if !(&models.Comment{Status: filter.Status}).ValidateStatus() {
+		return nil, 0, fmt.Errorf("%w: invalid status", ErrValidation)
+	}
+
+	query := s.db.Model(&models.Comment{}).Where("status = ?", filter.Status)
+	if filter.ArticleID != 0 {
+		query = query.Where("article_id = ?", filter.ArticleID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	var comments []models.Comment
+	err := query.Preload("User").
+		Preload("Article", func(db *gorm.DB) *gorm.DB { return db.Select("id", "title", "slug") }).
+		Order("created_at ASC").
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Find(&comments).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	userIDs := make([]uint, 0, len(comments))
+	for _, comment := range comments {
+		userIDs = append(userIDs, comment.UserID)
+	}
+	scores := map[uint]int{}
+	if len(userIDs) > 0 {
+		if scores, err = s.trustScores(userIDs); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	items := make([]ModerationQueueItem, len(comments))
+	for i, comment := range comments {
+		items[i] = ModerationQueueItem{Comment: comment, AuthorTrust: scores[comment.UserID]}
+	}
+	return items, total, nil
+}
+
+// Moderate applies a moderator action to comments. Comments already in the target status are
+// left alone. Comments approved from the queue are announced like comments published on
+// creation, and the authors of rejected comments are notified; spam is dropped silently.
+func (s *CommentModerationService) Moderate(ids []uint, action string, moderatorID uint, reason string) (*ModerationActionResult, error) {
+	status, ok := moderationActionStatus[action]
+	if !ok {
+		return nil, fmt.Errorf("%w: action must be approve, reject or spam", ErrValidation)
+	}
+	if len(ids) == 0 || len(ids) > maxBulkModeration {
+		return nil, fmt.Errorf("%w: between 1 and %d comments can be moderated at once", ErrValidation, maxBulkModeration)
+	}
+	reason = strings.TrimSpace(reason)
+	if len(reason) > 500 {
+		return nil, fmt.Errorf("%w: reason must be at most 500 characters", ErrValidation)
+	}
+
+	var comments []models.Comment
+	if err := s.db.Preload("User").Where("id IN ?", ids).Find(&comments).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	result := &ModerationActionResult{Action: action, Updated: []uint{}, NotFound: missingIDs(ids, comments)}
+	var changed []uint
+	for _, comment := range comments {
+		if comment.Status != status {
+			changed = append(changed, comment.ID)
+		}
+	}
+
+	now := time.Now()
+	if len(changed) > 0 {
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			err := tx.Model(&models.Comment{}).Where("id IN ?", changed).Updates(map[string]interface{}{
+				"status":            status,
+				"moderated_by":      moderatorID,
+				"moderated_at":      now,
+				"moderation_reason": reason,
+			}).Error
+			if err != nil {
+				return err
+			}
+			// Screening results of the comments are now reviewed
+			return tx.Model(&models.ModerationResult{}).
+				Where("content_type = ? AND content_id IN ? AND reviewed_at IS NULL", "comment", changed).
+				Updates(map[string]interface{}{"reviewed_by": moderatorID, "reviewed_at": now}).Error
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+		}
+	}
+
+	for i := range comments {
+		comment := &comments[i]
+		previous := comment.Status
+		if previous != status {
+			comment.Status = status
+			comment.ModeratedBy = &moderatorID
+			comment.ModeratedAt = &now
+			comment.ModerationReason = reason
+			result.Updated = append(result.Updated, comment.ID)
+
+			switch {
+			case status == moderation.StatusApproved && previous == moderation.StatusPending:
+				s.announce(*comment)
+			case status == moderation.StatusRejected:
+				s.notifyRejected(*comment)
+			}
+		}
+		result.Comments = append(result.Comments, *comment)
+	}
+	return result, nil
+}
+
+// ModerateOne applies a moderator action to a single comment and returns it
+func (s *CommentModerationService) ModerateOne(id uint, action string, moderatorID uint, reason string) (*models.Comment, error) {
+	result, err := s.Moderate([]uint{id}, action, moderatorID, reason)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Comments) == 0 {
+		return nil, ErrCommentNotFound
+	}
+	return &result.Comments[0], nil
+}
+
+// missingIDs returns the ids without a loaded comment, in request order
+func missingIDs(ids []uint, comments []models.Comment) []uint {
+	found := make(map[uint]bool, len(comments))
+	for _, comment := range comments {
+		found[comment.ID] = true
+	}
+	missing := []uint{}
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, id)
+			found[id] = true
+		}
+	}
+	return missing
+}
+
+// notifyRejected tells the author that their comment will not be published, both in their
+// notification list and in real time
+func (s *CommentModerationService) notifyRejected(comment models.Comment) {
+	message := "Your comment was not published because it does not follow the community guidelines."
+	if comment.ModerationReason != "" {
+		message = "Your comment was not published: " + comment.ModerationReason
+	}
+	NotifyUser(s.db, comment.UserID, "comment_rejected", "Your comment was rejected", message, map[string]interface{}{
+		"comment_id": comment.ID,
+		"article_id": comment.ArticleID,
+		"reason":     comment.ModerationReason,
+	})
+}
+
+// ListPolicies returns the moderation policies, global first
+func (s *CommentModerationService) ListPolicies() ([]models.CommentModerationPolicy, error) {
+	var policies []models.CommentModerationPolicy
+	if err := s.db.Find(&policies).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	order := map[string]int{models.ModerationScopeGlobal: 0, models.ModerationScopeCategory: 1, models.ModerationScopeArticle: 2}
+	sort.SliceStable(policies, func(i, j int) bool {
+		if policies[i].ScopeType != policies[j].ScopeType {
+			return order[policies[i].ScopeType] < order[policies[j].ScopeType]
+		}
+		return policies[i].ScopeID < policies[j].ScopeID
+	})
+	return policies, nil
+}
+
+// SavePolicy creates the moderation policy of a scope or replaces the existing one
+func (s *CommentModerationService) SavePolicy(req ModerationPolicyRequest, userID uint) (*models.CommentModerationPolicy, error) {
+	policy := models.CommentModerationPolicy{
+		ScopeType:        strings.ToLower(strings.TrimSpace(req.ScopeType)),
+		ScopeID:          req.ScopeID,
+		Mode:             strings.ToLower(strings.TrimSpace(req.Mode)),
+		ApproveThreshold: s.cfg.ApproveThreshold,
+		RejectThreshold:  s.cfg.RejectThreshold,
+		TrustThreshold:   s.cfg.TrustThreshold,
+		Strict:           req.Strict,
+		UpdatedBy:        &userID,
+	}
+	if req.ApproveThreshold != nil {
+		policy.ApproveThreshold = *req.ApproveThreshold
+	}
+	if req.RejectThreshold != nil {
+		policy.RejectThreshold = *req.RejectThreshold
+	}
+	if req.TrustThreshold != nil {
+		policy.TrustThreshold = *req.TrustThreshold
+	}
+
+	if !policy.ValidateScope() {
+		return nil, fmt.Errorf("%w: scope_type must be global, category or article, with scope_id set for categories and articles", ErrValidation)
+	}
+	if err := policyFromModel(policy).Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	if err := s.validatePolicyScope(policy); err != nil {
+		return nil, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.CommentModerationPolicy
+		err := tx.Where("scope_type = ? AND scope_id = ?", policy.ScopeType, policy.ScopeID).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tx.Create(&policy).Error
+		}
+		if err != nil {
+			return err
+		}
+		policy.ID = existing.ID
+		policy.CreatedAt = existing.CreatedAt
+		return tx.Save(&policy).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return &policy, nil
+}
+
+// validatePolicyScope checks that the category or article of a policy exists
+func (s *CommentModerationService) validatePolicyScope(policy models.CommentModerationPolicy) error {
+	var target interface{}
+	switch policy.ScopeType {
+	case models.ModerationScopeCategory:
+		target = &models.Category{}
+	case models.ModerationScopeArticle:
+		target = &models.Article{}
+	default:
+		return nil
+	}
+	var count int64
+	if err := s.db.Model(target).Where("id = ?", policy.ScopeID).Count(&count).Error; err != nil {
+		return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: %s %d does not exist", ErrValidation, policy.ScopeType, policy.ScopeID)
+	}
+	return nil
+}
+
+// DeletePolicy removes a moderation policy; its scope falls back to the next broader policy
+func (s *CommentModerationService) DeletePolicy(id uint) error {
+	result := s.db.Delete(&models.CommentModerationPolicy{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("%w: %v", ErrDatabaseError, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrModerationPolicyNotFound
+	}
+	return nil
+}
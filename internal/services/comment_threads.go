@@ -0,0 +1,351 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"news/internal/database"
+	"news/internal/models"
+	"news/internal/moderation"
+	"news/internal/threads"
+
+	"gorm.io/gorm"
+)
+
+// Limits of comment listings
+const (
+	DefaultThreadDepth        = 3
+	MaxThreadDepth            = 10
+	DefaultThreadRepliesLimit = 3
+	MaxThreadRepliesLimit     = 50
+	// maxThreadComments bounds how many comments of an article are ordered in memory for tree
+	// listings and vote sorts; beyond it the newest comments are kept
+	maxThreadComments = 5000
+	// mentionExcerptLength is the length of the comment excerpt sent with notifications
+	mentionExcerptLength = 140
+)
+
+// CommentThreadOptions selects a page of a comment tree. With ParentID set the page holds the
+// replies of that comment, which is how "load more replies" works.
+type CommentThreadOptions struct {
+	Sort         string
+	Depth        int
+	Limit        int
+	RepliesLimit int
+	ParentID     uint
+	Cursor       string
+}
+
+// CommentThread is a comment with its votes and the first replies of its subtree
+type CommentThread struct {
+	models.Comment
+	Likes      int `json:"likes"`
+	Dislikes   int `json:"dislikes"`
+	Score      int `json:"score"`
+	ReplyCount int `json:"reply_count"`
+	// Replies holds up to replies_limit replies while the depth limit allows
+	Replies []CommentThread `json:"replies"`
+	// RepliesCursor loads the replies not shown, with parent_id set to this comment. It is empty
+	// when all replies are shown or when the depth limit was reached, in which case the replies
+	// load from the start.
+	RepliesCursor string `json:"replies_cursor,omitempty"`
+}
+
+// CommentThreadPage is a page of top-level comments or of the replies to a comment
+type CommentThreadPage struct {
+	Comments   []CommentThread `json:"comments"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+	Total      int             `json:"total"`
+	Sort       string          `json:"sort"`
+	ParentID   *uint           `json:"parent_id,omitempty"`
+	// Truncated is set when the article has more comments than are ordered at once, in which
+	// case only the newest are listed
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// loadCommentForest arranges the approved comments of an article in the given order, and
+// reports whether older comments were left out to stay within maxThreadComments. Only the
+// columns needed for ordering are loaded; the comments shown are loaded by loadComments.
+func loadCommentForest(db *gorm.DB, articleID uint, order string) (*threads.Forest, bool, error) {
+	var rows []struct {
+		ID        uint
+		ParentID  *uint
+		CreatedAt time.Time
+	}
+	err := db.Model(&models.Comment{}).
+		Select("id, parent_id, created_at").
+		Where("article_id = ? AND status = ?", articleID, moderation.StatusApproved).
+		Order("id DESC").
+		Limit(maxThreadComments + 1).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	truncated := len(rows) > maxThreadComments
+	if truncated {
+		rows = rows[:maxThreadComments]
+	}
+
+	var votes []struct {
+		CommentID uint
+		Likes     int
+		Dislikes  int
+	}
+	err = db.Model(&models.Vote{}).
+		Select("comment_id, SUM(CASE WHEN type = 'like' THEN 1 ELSE 0 END) AS likes, SUM(CASE WHEN type = 'dislike' THEN 1 ELSE 0 END) AS dislikes").
+		Where("comment_id IN (?)", db.Model(&models.Comment{}).Select("id").Where("article_id = ? AND status = ?", articleID, moderation.StatusApproved)).
+		Group("comment_id").
+		Scan(&votes).Error
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	counts := make(map[uint][2]int, len(votes))
+	for _, vote := range votes {
+		counts[vote.CommentID] = [2]int{vote.Likes, vote.Dislikes}
+	}
+
+	entries := make([]threads.Entry, len(rows))
+	for i, row := range rows {
+		count := counts[row.ID]
+		entries[i] = threads.Entry{ID: row.ID, ParentID: row.ParentID, CreatedAt: row.CreatedAt, Likes: count[0], Dislikes: count[1]}
+	}
+	return threads.Build(entries, order), truncated, nil
+}
+
+// loadComments loads comments with their authors by ID
+func loadComments(db *gorm.DB, ids []uint) (map[uint]models.Comment, error) {
+	comments := make(map[uint]models.Comment, len(ids))
+	if len(ids) == 0 {
+		return comments, nil
+	}
+	var list []models.Comment
+	if err := db.Preload("User").Where("id IN ?", ids).Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	for _, comment := range list {
+		comments[comment.ID] = comment
+	}
+	return comments, nil
+}
+
+// GetCommentThreads returns a page of an article's comment tree. Each comment carries up to
+// RepliesLimit replies, nested Depth levels deep counting the page itself as the first level.
+func GetCommentThreads(articleID uint, opts CommentThreadOptions) (*CommentThreadPage, error) {
+	if !threads.ValidSort(opts.Sort) {
+		return nil, fmt.Errorf("%w: sort must be newest, oldest, top or controversial", ErrValidation)
+	}
+
+	forest, truncated, err := loadCommentForest(database.DB, articleID, opts.Sort)
+	if err != nil {
+		return nil, err
+	}
+	if opts.ParentID != 0 && !forest.Has(opts.ParentID) {
+		return nil, ErrCommentNotFound
+	}
+
+	// Walk the tree once to collect the comments shown, then load them in one query
+	type node struct {
+		entry    threads.Entry
+		children []*node
+		cursor   string
+	}
+	var shown []uint
+	var expand func(parent uint, cursor string, limit, depth int) ([]*node, string, error)
+	expand = func(parent uint, cursor string, limit, depth int) ([]*node, string, error) {
+		entries, next, err := forest.Page(parent, cursor, limit)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+		nodes := make([]*node, len(entries))
+		for i, entry := range entries {
+			shown = append(shown, entry.ID)
+			nodes[i] = &node{entry: entry}
+			if depth > 1 {
+				if nodes[i].children, nodes[i].cursor, err = expand(entry.ID, "", opts.RepliesLimit, depth-1); err != nil {
+					return nil, "", err
+				}
+			}
+		}
+		return nodes, next, nil
+	}
+	roots, next, err := expand(opts.ParentID, opts.Cursor, opts.Limit, opts.Depth)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, err := loadComments(database.DB, shown)
+	if err != nil {
+		return nil, err
+	}
+	var build func(nodes []*node) []CommentThread
+	build = func(nodes []*node) []CommentThread {
+		result := make([]CommentThread, 0, len(nodes))
+		for _, n := range nodes {
+			comment, ok := comments[n.entry.ID]
+			if !ok {
+				continue // Deleted since the tree was loaded
+			}
+			comment.Replies = nil
+			result = append(result, CommentThread{
+				Comment:       comment,
+				Likes:         n.entry.Likes,
+				Dislikes:      n.entry.Dislikes,
+				Score:         n.entry.Score(),
+				ReplyCount:    forest.Count(n.entry.ID),
+				Replies:       build(n.children),
+				RepliesCursor: n.cursor,
+			})
+		}
+		return result
+	}
+
+	page := &CommentThreadPage{
+		Comments:   build(roots),
+		NextCursor: next,
+		Total:      forest.Count(opts.ParentID),
+		Sort:       forest.Sort(),
+		Truncated:  truncated,
+	}
+	if opts.ParentID != 0 {
+		page.ParentID = &opts.ParentID
+	}
+	return page, nil
+}
+
+// GetCommentPage returns a numbered page of an article's top-level comments with all their
+// direct replies, oldest first, and the number of top-level comments. Time orders are paged
+// in the database; vote sorts order the comments in memory like GetCommentThreads.
+func GetCommentPage(articleID uint, order string, page, limit int) ([]models.Comment, int, error) {
+	if !threads.ValidSort(order) {
+		return nil, 0, fmt.Errorf("%w: sort must be newest, oldest, top or controversial", ErrValidation)
+	}
+
+	var ids []uint
+	var total int
+	switch order {
+	case threads.SortNewest, threads.SortOldest:
+		direction := "DESC"
+		if order == threads.SortOldest {
+			direction = "ASC"
+		}
+		topLevel := func() *gorm.DB {
+			return database.DB.Model(&models.Comment{}).
+				Where("article_id = ? AND status = ? AND parent_id IS NULL", articleID, moderation.StatusApproved)
+		}
+		var count int64
+		if err := topLevel().Count(&count).Error; err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+		}
+		if err := topLevel().
+			Order("created_at "+direction+", id "+direction).
+			Offset((page-1)*limit).
+			Limit(limit).
+			Pluck("id", &ids).Error; err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+		}
+		total = int(count)
+	default:
+		forest, _, err := loadCommentForest(database.DB, articleID, order)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, root := range forest.Offset(0, (page-1)*limit, limit) {
+			ids = append(ids, root.ID)
+		}
+		total = forest.Count(0)
+	}
+
+	comments, err := loadComments(database.DB, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var replies []models.Comment
+	if len(ids) > 0 {
+		err = database.DB.Where("parent_id IN ? AND status = ?", ids, moderation.StatusApproved).
+			Order("created_at ASC").
+			Preload("User").
+			Find(&replies).Error
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+		}
+	}
+	byParent := make(map[uint][]models.Comment)
+	for _, reply := range replies {
+		byParent[*reply.ParentID] = append(byParent[*reply.ParentID], reply)
+	}
+
+	result := make([]models.Comment, 0, len(ids))
+	for _, id := range ids {
+		if comment, ok := comments[id]; ok {
+			comment.Replies = byParent[id]
+			result = append(result, comment)
+		}
+	}
+	return result, total, nil
+}
+
+// notifyCommentAudience sends a comment_reply notification to the author of the parent
+// comment and a mention notification to every user mentioned as @username. Nobody is notified
+// about their own comment or twice about the same one.
+func notifyCommentAudience(db *gorm.DB, comment models.Comment) {
+	author := comment.User.Username
+	if author == "" {
+		author = "Someone"
+	}
+	data := map[string]interface{}{
+		"comment_id": comment.ID,
+		"article_id": comment.ArticleID,
+		"parent_id":  comment.ParentID,
+		"author":     comment.User.Username,
+		"excerpt":    commentExcerpt(comment.Content),
+	}
+	notified := map[uint]bool{comment.UserID: true}
+
+	if comment.ParentID != nil {
+		var parent models.Comment
+		err := db.Select("id", "user_id").First(&parent, *comment.ParentID).Error
+		switch {
+		case err == nil && !notified[parent.UserID]:
+			notified[parent.UserID] = true
+			NotifyUser(db, parent.UserID, "comment_reply", "New reply to your comment",
+				fmt.Sprintf("%s replied to your comment", author), data)
+		case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+			log.Printf("Failed to load parent of comment %d: %v", comment.ID, err)
+		}
+	}
+
+	names := threads.Mentions(comment.Content)
+	if len(names) == 0 {
+		return
+	}
+	lowered := make([]string, len(names))
+	for i, name := range names {
+		lowered[i] = strings.ToLower(name)
+	}
+	var users []models.User
+	if err := db.Select("id", "username").Where("LOWER(username) IN ?", lowered).Find(&users).Error; err != nil {
+		log.Printf("Failed to resolve mentions of comment %d: %v", comment.ID, err)
+		return
+	}
+	for _, user := range users {
+		if notified[user.ID] {
+			continue
+		}
+		notified[user.ID] = true
+		NotifyUser(db, user.ID, "mention", "You were mentioned in a comment",
+			fmt.Sprintf("%s mentioned you in a comment", author), data)
+	}
+}
+
+// commentExcerpt shortens a comment for notifications
+func commentExcerpt(content string) string {
+	runes := []rune(strings.TrimSpace(content))
+	if len(runes) <= mentionExcerptLength {
+		return string(runes)
+	}
+	return string(runes[:mentionExcerptLength]) + "…"
+}
@@ -0,0 +1,563 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"news/internal/config"
+	"news/internal/database"
+	"news/internal/json"
+	"news/internal/models"
+	"news/internal/pubsub"
+	"news/internal/reports"
+	"news/internal/sitemap"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrReportNotFound is returned when reported content does not exist
+	ErrReportNotFound = errors.New("report not found")
+	// ErrReportDuplicate is returned when a reader reports the same content twice
+	ErrReportDuplicate = errors.New("content already reported")
+	// ErrReportRateLimited is returned when a reader files too many reports
+	ErrReportRateLimited = errors.New("too many reports")
+)
+
+// Audit event types recorded as security events. The user of an event is the moderator who
+// acted, or 0 when reports hid content automatically.
+const (
+	AuditReportDismissed    = "report_dismissed"
+	AuditContentHidden      = "content_hidden"
+	AuditContentAutoHidden  = "content_auto_hidden"
+	AuditContentDeleted     = "content_deleted"
+	AuditUserSuspended      = "user_suspended"
+	maxReportDetailsLength  = 1000
+	maxReportResolutionNote = 1000
+)
+
+// reportAuditEvents lists the audit event types of the report subsystem
+var reportAuditEvents = []string{AuditReportDismissed, AuditContentHidden, AuditContentAutoHidden, AuditContentDeleted, AuditUserSuspended}
+
+// reportTarget describes a reportable content type
+type reportTarget struct {
+	model       func() interface{}
+	ownerColumn string
+	// hidden is the status of hidden content and visible the status restored when the status
+	// before hiding is unknown
+	hidden  string
+	visible string
+}
+
+var reportTargets = map[string]reportTarget{
+	models.ReportTargetComment: {
+		model: func() interface{} { return &models.Comment{} }, ownerColumn: "user_id", hidden: "hidden", visible: "approved",
+	},
+	models.ReportTargetVideoComment: {
+		model: func() interface{} { return &models.VideoComment{} }, ownerColumn: "user_id", hidden: "hidden", visible: "active",
+	},
+	models.ReportTargetArticle: {
+		model: func() interface{} { return &models.Article{} }, ownerColumn: "author_id", hidden: "archived", visible: "published",
+	},
+}
+
+var (
+	contentReportInstance *ContentReportService
+	contentReportOnce     sync.Once
+)
+
+// ReportRequest is a reader's report about a piece of content
+type ReportRequest struct {
+	TargetType string `json:"target_type" binding:"required" example:"comment"` // article, comment, video_comment
+	TargetID   uint   `json:"target_id" binding:"required" example:"42"`
+	Reason     string `json:"reason" binding:"required" example:"harassment"`
+	Details    string `json:"details" example:"Insults another reader"`
+}
+
+// ReportActionRequest is a moderator's decision about reported content
+type ReportActionRequest struct {
+	Action string `json:"action" binding:"required" example:"hide"` // dismiss, hide, delete, suspend
+	Note   string `json:"note" example:"Personal attack"`
+}
+
+// AuditInfo identifies who performed an audited action and from where
+type AuditInfo struct {
+	ActorID   uint
+	IP        string
+	UserAgent string
+}
+
+// ReportedContentFilter selects reported content for triage
+type ReportedContentFilter struct {
+	Status     string
+	TargetType string
+	Page       int
+	Limit      int
+}
+
+// ReportedContentItem is reported content in the triage list with its open reports per reason
+type ReportedContentItem struct {
+	models.ReportedContent
+	Reasons map[string]int `json:"reasons"`
+}
+
+// ReportedContentDetail is reported content with all its reports and the content itself
+type ReportedContentDetail struct {
+	models.ReportedContent
+	Content interface{} `json:"content,omitempty"`
+}
+
+// ContentReportService files reader reports, hides content that collects too many and carries
+// out moderator decisions
+type ContentReportService struct {
+	db  *gorm.DB
+	cfg *config.ModerationConfig
+}
+
+// NewContentReportService creates a content report service
+func NewContentReportService(db *gorm.DB, cfg *config.ModerationConfig) *ContentReportService {
+	return &ContentReportService{db: db, cfg: cfg}
+}
+
+// GetContentReportService returns the content report service
+func GetContentReportService() *ContentReportService {
+	contentReportOnce.Do(func() {
+		contentReportInstance = NewContentReportService(database.DB, config.GetModerationConfig())
+	})
+	return contentReportInstance
+}
+
+// Report files a reader's report. Once the open reports about the content weigh enough, the
+// content is hidden until a moderator decides.
+func (s *ContentReportService) Report(reporterID uint, req ReportRequest) (*models.ContentReport, error) {
+	report := models.ContentReport{
+		ReporterID: reporterID,
+		TargetType: strings.TrimSpace(req.TargetType),
+		TargetID:   req.TargetID,
+		Reason:     strings.TrimSpace(req.Reason),
+		Details:    strings.TrimSpace(req.Details),
+		Status:     models.ReportStatusOpen,
+	}
+	if !report.ValidateTargetType() {
+		return nil, fmt.Errorf("%w: target_type must be article, comment or video_comment", ErrValidation)
+	}
+	if !reports.ValidReason(report.Reason) {
+		return nil, fmt.Errorf("%w: unknown reason %q", ErrValidation, report.Reason)
+	}
+	if len(report.Details) > maxReportDetailsLength {
+		return nil, fmt.Errorf("%w: details must be at most %d characters", ErrValidation, maxReportDetailsLength)
+	}
+
+	target := reportTargets[report.TargetType]
+	var hidden *models.ReportedContent
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the reporter so their concurrent reports are counted one after another
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.User{}, reporterID).Error; err != nil {
+			return err
+		}
+		var recent int64
+		if err := tx.Model(&models.ContentReport{}).
+			Where("reporter_id = ? AND created_at > ?", reporterID, time.Now().Add(-s.cfg.ReportRateWindow)).
+			Count(&recent).Error; err != nil {
+			return err
+		}
+		if !reports.WithinRateLimit(recent, s.cfg.ReportRateLimit) {
+			return ErrReportRateLimited
+		}
+
+		ownerID, _, err := s.loadTarget(tx, report.TargetType, report.TargetID)
+		if err != nil {
+			return err
+		}
+		if ownerID == reporterID {
+			return fmt.Errorf("%w: you cannot report your own content", ErrValidation)
+		}
+
+		var existing int64
+		if err := tx.Model(&models.ContentReport{}).
+			Where("reporter_id = ? AND target_type = ? AND target_id = ?", reporterID, report.TargetType, report.TargetID).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return ErrReportDuplicate
+		}
+
+		// One row per content collects its reports; lock it so concurrent reports count once each
+		now := time.Now()
+		content := models.ReportedContent{
+			TargetType:   report.TargetType,
+			TargetID:     report.TargetID,
+			OwnerID:      ownerID,
+			Status:       models.ReportStatusOpen,
+			LastReportAt: now,
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&content).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("target_type = ? AND target_id = ?", report.TargetType, report.TargetID).
+			First(&content).Error; err != nil {
+			return err
+		}
+
+		// Content a moderator already acted on stays resolved; dismissed content is reopened
+		updates := map[string]interface{}{
+			"total_reports":  gorm.Expr("total_reports + 1"),
+			"last_report_at": now,
+		}
+		if content.Status == models.ReportStatusActioned {
+			report.Status = models.ReportStatusActioned
+		} else {
+			updates["status"] = models.ReportStatusOpen
+			updates["open_reports"] = gorm.Expr("open_reports + 1")
+		}
+
+		report.ReportedContentID = content.ID
+		if err := tx.Create(&report).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&content).Updates(updates).Error; err != nil {
+			return err
+		}
+		if report.Status != models.ReportStatusOpen || content.HiddenAt != nil {
+			return nil
+		}
+		if report.TargetType == models.ReportTargetArticle && !s.cfg.ReportHideArticles {
+			return nil
+		}
+
+		var reasons []string
+		if err := tx.Model(&models.ContentReport{}).
+			Where("reported_content_id = ? AND status = ?", content.ID, models.ReportStatusOpen).
+			Pluck("reason", &reasons).Error; err != nil {
+			return err
+		}
+		if !reports.ShouldHide(reasons, s.cfg.ReportHideThreshold) {
+			return nil
+		}
+		if err := s.hide(tx, &content, target, true); err != nil {
+			return err
+		}
+		hidden = &content
+		return s.audit(tx, AuditInfo{}, AuditContentAutoHidden, "warning", &content, map[string]interface{}{
+			"reasons": reports.TopReasons(reasons),
+		})
+	})
+	if err != nil {
+		if errors.Is(err, ErrValidation) || errors.Is(err, ErrNotFound) || errors.Is(err, ErrReportDuplicate) ||
+			errors.Is(err, ErrReportRateLimited) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	if hidden != nil && hidden.TargetType == models.ReportTargetArticle {
+		invalidateArticleCaches(hidden.TargetID)
+		go publicContentChanged(sitemap.SectionArticles, hidden.TargetID)
+	}
+	return &report, nil
+}
+
+// loadTarget returns the owner and status of reported content
+func (s *ContentReportService) loadTarget(tx *gorm.DB, targetType string, targetID uint) (uint, string, error) {
+	target := reportTargets[targetType]
+	var row struct {
+		Owner  uint
+		Status string
+	}
+	result := tx.Model(target.model()).
+		Select(target.ownerColumn+" AS owner, status").
+		Where("id = ?", targetID).
+		Limit(1).
+		Scan(&row)
+	if result.Error != nil {
+		return 0, "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, "", fmt.Errorf("%w: %s %d", ErrNotFound, targetType, targetID)
+	}
+	return row.Owner, row.Status, nil
+}
+
+// hide hides reported content, remembering its status so a dismissal can restore it
+func (s *ContentReportService) hide(tx *gorm.DB, content *models.ReportedContent, target reportTarget, auto bool) error {
+	if content.HiddenAt != nil {
+		return nil
+	}
+	_, status, err := s.loadTarget(tx, content.TargetType, content.TargetID)
+	if err != nil {
+		return err
+	}
+	if err := tx.Model(target.model()).Where("id = ?", content.TargetID).Update("status", target.hidden).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	content.HiddenAt = &now
+	content.AutoHidden = auto
+	content.PreviousStatus = status
+	return tx.Model(content).Updates(map[string]interface{}{
+		"hidden_at":       now,
+		"auto_hidden":     auto,
+		"previous_status": status,
+	}).Error
+}
+
+// restore makes hidden content visible again with the status it had before
+func (s *ContentReportService) restore(tx *gorm.DB, content *models.ReportedContent, target reportTarget) error {
+	if content.HiddenAt == nil {
+		return nil
+	}
+	status := content.PreviousStatus
+	if status == "" || status == target.hidden {
+		status = target.visible
+	}
+	result := tx.Model(target.model()).Where("id = ? AND status = ?", content.TargetID, target.hidden).Update("status", status)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	content.HiddenAt = nil
+	content.AutoHidden = false
+	content.PreviousStatus = ""
+	return tx.Model(content).Updates(map[string]interface{}{
+		"hidden_at":       nil,
+		"auto_hidden":     false,
+		"previous_status": "",
+	}).Error
+}
+
+// Resolve carries out a moderator's decision about reported content and resolves its open
+// reports. Dismissing restores content hidden by reports; suspending the author also hides the
+// content and closes the author's live connections.
+func (s *ContentReportService) Resolve(id uint, req ReportActionRequest, info AuditInfo) (*models.ReportedContent, error) {
+	action := strings.ToLower(strings.TrimSpace(req.Action))
+	note := strings.TrimSpace(req.Note)
+	if len(note) > maxReportResolutionNote {
+		return nil, fmt.Errorf("%w: note must be at most %d characters", ErrValidation, maxReportResolutionNote)
+	}
+
+	var content models.ReportedContent
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&content, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrReportNotFound
+			}
+			return err
+		}
+		target := reportTargets[content.TargetType]
+		status := models.ReportStatusActioned
+		metadata := map[string]interface{}{"note": note, "open_reports": content.OpenReports}
+
+		var event, severity string
+		switch action {
+		case models.ReportActionDismiss:
+			status, event, severity = models.ReportStatusDismissed, AuditReportDismissed, "info"
+			if err := s.restore(tx, &content, target); err != nil {
+				return err
+			}
+		case models.ReportActionHide:
+			event, severity = AuditContentHidden, "warning"
+			if err := s.hide(tx, &content, target, false); err != nil {
+				return err
+			}
+		case models.ReportActionDelete:
+			event, severity = AuditContentDeleted, "warning"
+			if err := tx.Delete(target.model(), content.TargetID).Error; err != nil {
+				return err
+			}
+		case models.ReportActionSuspend:
+			event, severity = AuditUserSuspended, "critical"
+			var owner models.User
+			if err := tx.Select("id", "role", "status").First(&owner, content.OwnerID).Error; err != nil {
+				return fmt.Errorf("%w: the author no longer exists", ErrValidation)
+			}
+			if owner.Role == "admin" {
+				return fmt.Errorf("%w: administrators cannot be suspended", ErrValidation)
+			}
+			if err := tx.Model(&owner).Update("status", "suspended").Error; err != nil {
+				return err
+			}
+			metadata["previous_user_status"] = owner.Status
+			if err := s.hide(tx, &content, target, false); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%w: action must be dismiss, hide, delete or suspend", ErrValidation)
+		}
+
+		now := time.Now()
+		content.Status = status
+		content.Action = action
+		content.OpenReports = 0
+		content.ResolvedBy = &info.ActorID
+		content.ResolvedAt = &now
+		content.ResolutionNote = note
+		if err := tx.Model(&content).Updates(map[string]interface{}{
+			"status":          status,
+			"action":          action,
+			"open_reports":    0,
+			"resolved_by":     info.ActorID,
+			"resolved_at":     now,
+			"resolution_note": note,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.ContentReport{}).
+			Where("reported_content_id = ? AND status = ?", content.ID, models.ReportStatusOpen).
+			Update("status", status).Error; err != nil {
+			return err
+		}
+		return s.audit(tx, info, event, severity, &content, metadata)
+	})
+	if err != nil {
+		if errors.Is(err, ErrValidation) || errors.Is(err, ErrReportNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	if content.TargetType == models.ReportTargetArticle {
+		invalidateArticleCaches(content.TargetID)
+		go publicContentChanged(sitemap.SectionArticles, content.TargetID)
+	}
+	if action == models.ReportActionSuspend {
+		if hub := pubsub.GetNotificationHub(); hub != nil {
+			hub.UnregisterClient(content.OwnerID)
+		}
+	}
+	return &content, nil
+}
+
+// audit records an action on reported content as a security event
+func (s *ContentReportService) audit(tx *gorm.DB, info AuditInfo, event, severity string, content *models.ReportedContent, metadata map[string]interface{}) error {
+	data := map[string]interface{}{
+		"reported_content_id": content.ID,
+		"target_type":         content.TargetType,
+		"target_id":           content.TargetID,
+		"owner_id":            content.OwnerID,
+	}
+	for key, value := range metadata {
+		data[key] = value
+	}
+	encoded, _ := json.Marshal(data)
+
+	description := fmt.Sprintf("%s %s %d", strings.ReplaceAll(event, "_", " "), content.TargetType, content.TargetID)
+	if event == AuditUserSuspended {
+		description = fmt.Sprintf("user %d suspended over %s %d", content.OwnerID, content.TargetType, content.TargetID)
+	}
+	return tx.Create(&models.SecurityEvent{
+		UserID:      info.ActorID,
+		EventType:   event,
+		Description: description,
+		IP:          info.IP,
+		UserAgent:   truncate(info.UserAgent, 255),
+		Metadata:    string(encoded),
+		Timestamp:   time.Now(),
+		Severity:    severity,
+	}).Error
+}
+
+// List returns reported content for triage, most reported first
+func (s *ContentReportService) List(filter ReportedContentFilter) ([]ReportedContentItem, int64, error) {
+	if filter.Status == "" {
+		filter.Status = models.ReportStatusOpen
+	}
+	query := s.db.Model(&models.ReportedContent{}).Where("status = ?", filter.Status)
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	var contents []models.ReportedContent
+	err := query.Preload("Owner").
+		Order("open_reports DESC, last_report_at DESC").
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Find(&contents).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	ids := make([]uint, len(contents))
+	for i, content := range contents {
+		ids[i] = content.ID
+	}
+	var counts []struct {
+		ReportedContentID uint
+		Reason            string
+		Count             int
+	}
+	if len(ids) > 0 {
+		err = s.db.Model(&models.ContentReport{}).
+			Select("reported_content_id, reason, COUNT(*) AS count").
+			Where("reported_content_id IN ? AND status = ?", ids, filter.Status).
+			Group("reported_content_id, reason").
+			Scan(&counts).Error
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+		}
+	}
+
+	items := make([]ReportedContentItem, len(contents))
+	for i, content := range contents {
+		items[i] = ReportedContentItem{ReportedContent: content, Reasons: map[string]int{}}
+	}
+	index := make(map[uint]int, len(contents))
+	for i, content := range contents {
+		index[content.ID] = i
+	}
+	for _, count := range counts {
+		items[index[count.ReportedContentID]].Reasons[count.Reason] = count.Count
+	}
+	return items, total, nil
+}
+
+// Get returns reported content with its reports, newest first, and the content itself
+func (s *ContentReportService) Get(id uint) (*ReportedContentDetail, error) {
+	var content models.ReportedContent
+	err := s.db.Preload("Owner").
+		Preload("Reports", func(db *gorm.DB) *gorm.DB { return db.Order("created_at DESC") }).
+		Preload("Reports.Reporter").
+		First(&content, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReportNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	detail := &ReportedContentDetail{ReportedContent: content}
+	target := reportTargets[content.TargetType].model()
+	if err := s.db.Unscoped().First(target, content.TargetID).Error; err == nil {
+		detail.Content = target
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Failed to load reported %s %d: %v", content.TargetType, content.TargetID, err)
+	}
+	return detail, nil
+}
+
+// AuditLog returns the audit trail of report decisions, newest first
+func (s *ContentReportService) AuditLog(page, limit int) ([]models.SecurityEvent, int64, error) {
+	query := s.db.Model(&models.SecurityEvent{}).Where("event_type IN ?", reportAuditEvents)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	var events []models.SecurityEvent
+	if err := query.Order("timestamp DESC").Offset((page - 1) * limit).Limit(limit).Find(&events).Error; err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return events, total, nil
+}
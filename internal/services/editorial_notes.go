@@ -0,0 +1,455 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"news/internal/annotations"
+	"news/internal/database"
+	"news/internal/models"
+	"news/internal/threads"
+	"news/internal/workflow"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrEditorialNoteNotFound is returned when an editorial note does not exist
+	ErrEditorialNoteNotFound = errors.New("editorial note not found")
+)
+
+const maxEditorialNoteLength = 5000
+
+// EditorialNoteRequest starts a note thread on a content block, optionally anchored to the
+// characters [anchor_start, anchor_end) of the block content
+type EditorialNoteRequest struct {
+	BlockID     uint   `json:"block_id" binding:"required"`
+	Body        string `json:"body" binding:"required"`
+	AnchorStart *int   `json:"anchor_start"`
+	AnchorEnd   *int   `json:"anchor_end"`
+}
+
+// EditorialReplyRequest replies to a note thread, or edits a note
+type EditorialReplyRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+// EditorialNoteFilter narrows the note threads of an article or page
+type EditorialNoteFilter struct {
+	BlockID  uint
+	Resolved *bool
+}
+
+// EditorialNoteService manages the private notes staff leave on article and page blocks.
+// Authors only see the notes on their own articles and pages.
+type EditorialNoteService struct {
+	db *gorm.DB
+}
+
+var (
+	editorialNoteInstance *EditorialNoteService
+	editorialNoteOnce     sync.Once
+)
+
+// NewEditorialNoteService creates an editorial note service
+func NewEditorialNoteService(db *gorm.DB) *EditorialNoteService {
+	return &EditorialNoteService{db: db}
+}
+
+// GetEditorialNoteService returns the editorial note service
+func GetEditorialNoteService() *EditorialNoteService {
+	editorialNoteOnce.Do(func() {
+		editorialNoteInstance = NewEditorialNoteService(database.DB)
+	})
+	return editorialNoteInstance
+}
+
+// editorialEntity is the article or page a note is attached to
+type editorialEntity struct {
+	Type    string
+	ID      uint
+	OwnerID uint
+	Title   string
+}
+
+// List returns the note threads of an article or page with their replies, oldest first.
+// Anchors follow their text when a block was edited since the note was written.
+func (s *EditorialNoteService) List(entityType string, entityID uint, actor WorkflowActor, filter EditorialNoteFilter) ([]models.EditorialNote, error) {
+	entity, err := s.authorize(entityType, entityID, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	query := s.db.Where("entity_type = ? AND entity_id = ? AND parent_id IS NULL", entity.Type, entity.ID)
+	if filter.BlockID != 0 {
+		query = query.Where("block_id = ?", filter.BlockID)
+	}
+	if filter.Resolved != nil {
+		query = query.Where("resolved = ?", *filter.Resolved)
+	}
+
+	var notes []models.EditorialNote
+	err = query.Preload("User").
+		Preload("Replies", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC, id ASC") }).
+		Preload("Replies.User").
+		Order("created_at ASC, id ASC").
+		Find(&notes).Error
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	contents, err := s.blockContents(entity)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	for i := range notes {
+		locateEditorialNote(&notes[i], contents)
+	}
+	return notes, nil
+}
+
+// locateEditorialNote checks a thread's anchor against the current block content and moves it
+// when the anchored text moved within the block. The stored anchor is left as written, since
+// the text is found again from it on every read.
+func locateEditorialNote(note *models.EditorialNote, contents map[uint]string) {
+	content, ok := contents[note.BlockID]
+	if !ok {
+		note.BlockMissing = true
+		return
+	}
+	if note.AnchorStart == nil || note.AnchorEnd == nil {
+		return
+	}
+
+	anchor, found := annotations.Relocate(content, annotations.Anchor{
+		Start: *note.AnchorStart,
+		End:   *note.AnchorEnd,
+		Quote: note.AnchorText,
+	})
+	if !found {
+		note.AnchorOutdated = true
+		return
+	}
+	note.AnchorStart, note.AnchorEnd = &anchor.Start, &anchor.End
+}
+
+// Create starts a note thread on a block of an article or page and notifies mentioned staff
+func (s *EditorialNoteService) Create(entityType string, entityID uint, actor WorkflowActor, req EditorialNoteRequest) (*models.EditorialNote, error) {
+	entity, err := s.authorize(entityType, entityID, actor)
+	if err != nil {
+		return nil, err
+	}
+	body, err := editorialNoteBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := s.blockContent(entity, req.BlockID)
+	if err != nil {
+		return nil, err
+	}
+
+	note := models.EditorialNote{
+		EntityType: entity.Type,
+		EntityID:   entity.ID,
+		BlockID:    req.BlockID,
+		UserID:     actor.ID,
+		Body:       body,
+	}
+	if req.AnchorStart != nil || req.AnchorEnd != nil {
+		if req.AnchorStart == nil || req.AnchorEnd == nil {
+			return nil, fmt.Errorf("%w: anchor_start and anchor_end must be given together", ErrValidation)
+		}
+		anchor, err := annotations.NewAnchor(content, *req.AnchorStart, *req.AnchorEnd)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+		note.AnchorStart, note.AnchorEnd, note.AnchorText = &anchor.Start, &anchor.End, anchor.Quote
+	}
+
+	if err := s.db.Create(&note).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	s.db.Preload("User").First(&note, note.ID)
+
+	s.notify(entity, &note, nil)
+	return &note, nil
+}
+
+// Reply adds a reply to a note thread and notifies the thread's participants and mentioned staff
+func (s *EditorialNoteService) Reply(noteID uint, actor WorkflowActor, req EditorialReplyRequest) (*models.EditorialNote, error) {
+	thread, entity, err := s.loadNote(noteID, actor)
+	if err != nil {
+		return nil, err
+	}
+	if !thread.IsThread() {
+		return nil, fmt.Errorf("%w: replies go to the note that starts the thread", ErrValidation)
+	}
+	body, err := editorialNoteBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	reply := models.EditorialNote{
+		EntityType: thread.EntityType,
+		EntityID:   thread.EntityID,
+		BlockID:    thread.BlockID,
+		ParentID:   &thread.ID,
+		UserID:     actor.ID,
+		Body:       body,
+	}
+	if err := s.db.Create(&reply).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	s.db.Preload("User").First(&reply, reply.ID)
+
+	s.notify(entity, &reply, thread)
+	return &reply, nil
+}
+
+// Update edits the body of a note. Staff can only edit their own notes.
+func (s *EditorialNoteService) Update(noteID uint, actor WorkflowActor, req EditorialReplyRequest) (*models.EditorialNote, error) {
+	note, _, err := s.loadNote(noteID, actor)
+	if err != nil {
+		return nil, err
+	}
+	if note.UserID != actor.ID {
+		return nil, fmt.Errorf("%w: you can only edit your own notes", ErrWorkflowForbidden)
+	}
+	body, err := editorialNoteBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Model(note).Update("body", body).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	note.Body = body
+	return note, nil
+}
+
+// SetResolved resolves or reopens a note thread
+func (s *EditorialNoteService) SetResolved(noteID uint, actor WorkflowActor, resolved bool) (*models.EditorialNote, error) {
+	note, _, err := s.loadNote(noteID, actor)
+	if err != nil {
+		return nil, err
+	}
+	if !note.IsThread() {
+		return nil, fmt.Errorf("%w: only the note that starts a thread can be resolved", ErrValidation)
+	}
+
+	updates := map[string]interface{}{
+		"resolved":    resolved,
+		"resolved_by": nil,
+		"resolved_at": nil,
+	}
+	note.Resolved, note.ResolvedBy, note.ResolvedAt = resolved, nil, nil
+	if resolved {
+		now := time.Now()
+		updates["resolved_by"] = actor.ID
+		updates["resolved_at"] = now
+		note.ResolvedBy, note.ResolvedAt = &actor.ID, &now
+	}
+	if err := s.db.Model(&models.EditorialNote{}).Where("id = ?", note.ID).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return note, nil
+}
+
+// Delete deletes a note, and with a thread all of its replies. Staff can delete their own
+// notes; admins can delete any note.
+func (s *EditorialNoteService) Delete(noteID uint, actor WorkflowActor) error {
+	note, _, err := s.loadNote(noteID, actor)
+	if err != nil {
+		return err
+	}
+	if note.UserID != actor.ID && !strings.EqualFold(actor.Role, workflow.RoleAdmin) {
+		return fmt.Errorf("%w: you can only delete your own notes", ErrWorkflowForbidden)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if note.IsThread() {
+			if err := tx.Where("parent_id = ?", note.ID).Delete(&models.EditorialNote{}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(note).Error
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return nil
+}
+
+// loadNote loads a note and checks that actor may see the content it is attached to
+func (s *EditorialNoteService) loadNote(noteID uint, actor WorkflowActor) (*models.EditorialNote, *editorialEntity, error) {
+	var note models.EditorialNote
+	if err := s.db.First(&note, noteID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrEditorialNoteNotFound
+		}
+		return nil, nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	entity, err := s.authorize(note.EntityType, note.EntityID, actor)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &note, entity, nil
+}
+
+// authorize loads the article or page notes are attached to and checks that actor is staff
+// and, for authors, that the content is theirs
+func (s *EditorialNoteService) authorize(entityType string, entityID uint, actor WorkflowActor) (*editorialEntity, error) {
+	if !isStaffRole(actor.Role) {
+		return nil, fmt.Errorf("%w: editorial notes are only available to staff", ErrWorkflowForbidden)
+	}
+
+	entity := editorialEntity{Type: entityType, ID: entityID}
+	var model interface{}
+	switch entityType {
+	case models.EditorialNoteArticle:
+		model = &models.Article{}
+	case models.EditorialNotePage:
+		model = &models.Page{}
+	default:
+		return nil, fmt.Errorf("%w: notes can be attached to articles or pages", ErrValidation)
+	}
+
+	var row struct {
+		AuthorID uint
+		Title    string
+	}
+	result := s.db.Model(model).Select("author_id, title").Where("id = ?", entityID).Limit(1).Scan(&row)
+	if result.Error != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotFound
+	}
+	entity.OwnerID, entity.Title = row.AuthorID, row.Title
+
+	if !workflow.CanReview(actor.Role) && entity.OwnerID != actor.ID {
+		return nil, fmt.Errorf("%w: authors can only see notes on their own content", ErrWorkflowForbidden)
+	}
+	return &entity, nil
+}
+
+// blockContent returns the content of a block of the entity
+func (s *EditorialNoteService) blockContent(entity *editorialEntity, blockID uint) (string, error) {
+	contents, err := s.blockContents(entity, blockID)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	content, ok := contents[blockID]
+	if !ok {
+		return "", fmt.Errorf("%w: block %d does not belong to this %s", ErrValidation, blockID, entity.Type)
+	}
+	return content, nil
+}
+
+// blockContents returns the content of the entity's blocks by block ID, limited to blockIDs
+// when given
+func (s *EditorialNoteService) blockContents(entity *editorialEntity, blockIDs ...uint) (map[uint]string, error) {
+	var blocks []struct {
+		ID      uint
+		Content string
+	}
+	var query *gorm.DB
+	if entity.Type == models.EditorialNotePage {
+		query = s.db.Model(&models.PageContentBlock{}).Where("page_id = ?", entity.ID)
+	} else {
+		query = s.db.Model(&models.ArticleContentBlock{}).Where("article_id = ?", entity.ID)
+	}
+	if len(blockIDs) > 0 {
+		query = query.Where("id IN ?", blockIDs)
+	}
+	if err := query.Select("id, content").Scan(&blocks).Error; err != nil {
+		return nil, err
+	}
+
+	contents := make(map[uint]string, len(blocks))
+	for _, block := range blocks {
+		contents[block.ID] = block.Content
+	}
+	return contents, nil
+}
+
+// notify tells mentioned staff about a note and, for a reply, the other people in the thread.
+// Authors are only mentioned on their own content, since they cannot see anyone else's notes.
+func (s *EditorialNoteService) notify(entity *editorialEntity, note *models.EditorialNote, thread *models.EditorialNote) {
+	notified := map[uint]bool{note.UserID: true}
+	data := map[string]interface{}{
+		"entity_type": entity.Type,
+		"entity_id":   entity.ID,
+		"block_id":    note.BlockID,
+		"note_id":     note.ID,
+	}
+	if thread != nil {
+		data["thread_id"] = thread.ID
+	}
+	excerpt := commentExcerpt(note.Body)
+
+	if names := threads.Mentions(note.Body); len(names) > 0 {
+		var users []models.User
+		if err := s.db.Select("id", "role").Where("LOWER(username) IN ?", lowerAll(names)).Find(&users).Error; err != nil {
+			log.Printf("Failed to resolve mentions of editorial note %d: %v", note.ID, err)
+		}
+		for _, user := range users {
+			if notified[user.ID] || !isStaffRole(user.Role) {
+				continue
+			}
+			if !workflow.CanReview(user.Role) && user.ID != entity.OwnerID {
+				continue
+			}
+			notified[user.ID] = true
+			NotifyUser(s.db, user.ID, "mention", "You were mentioned in an editorial note",
+				fmt.Sprintf("On %q: %s", entity.Title, excerpt), data)
+		}
+	}
+
+	if thread == nil {
+		return
+	}
+	var participants []uint
+	if err := s.db.Model(&models.EditorialNote{}).
+		Where("id = ? OR parent_id = ?", thread.ID, thread.ID).
+		Distinct().
+		Pluck("user_id", &participants).Error; err != nil {
+		log.Printf("Failed to load participants of editorial note %d: %v", thread.ID, err)
+	}
+	for _, userID := range participants {
+		if notified[userID] {
+			continue
+		}
+		notified[userID] = true
+		NotifyUser(s.db, userID, "editorial_note", "New reply to an editorial note",
+			fmt.Sprintf("On %q: %s", entity.Title, excerpt), data)
+	}
+}
+
+func editorialNoteBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", fmt.Errorf("%w: body is required", ErrValidation)
+	}
+	if len(body) > maxEditorialNoteLength {
+		return "", fmt.Errorf("%w: body must be at most %d characters", ErrValidation, maxEditorialNoteLength)
+	}
+	return body, nil
+}
+
+func isStaffRole(role string) bool {
+	return workflow.CanReview(role) || strings.EqualFold(role, workflow.RoleAuthor)
+}
+
+func lowerAll(values []string) []string {
+	lowered := make([]string, len(values))
+	for i, value := range values {
+		lowered[i] = strings.ToLower(value)
+	}
+	return lowered
+}
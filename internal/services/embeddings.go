@@ -0,0 +1,336 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"news/internal/config"
+	"news/internal/database"
+	"news/internal/embedding"
+	"news/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrEmbeddingNotFound is returned when an article has no embedding for the current provider
+var ErrEmbeddingNotFound = errors.New("article embedding not found")
+
+// maxEmbeddingTextLength keeps embedded text well within the input limits of embedding models
+const maxEmbeddingTextLength = 8000
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// EmbeddingEnqueuer enqueues article embedding jobs for background processing
+type EmbeddingEnqueuer interface {
+	EnqueueArticleEmbedding(articleID uint) error
+}
+
+var (
+	embeddingEnqueuer   EmbeddingEnqueuer
+	embeddingEnqueuerMu sync.RWMutex
+
+	embeddingServiceInstance *EmbeddingService
+	embeddingServiceOnce     sync.Once
+)
+
+// SetEmbeddingEnqueuer sets the queue used to refresh article embeddings. Without one,
+// articles are only embedded by an explicit reindex.
+func SetEmbeddingEnqueuer(enqueuer EmbeddingEnqueuer) {
+	embeddingEnqueuerMu.Lock()
+	defer embeddingEnqueuerMu.Unlock()
+	embeddingEnqueuer = enqueuer
+}
+
+func getEmbeddingEnqueuer() EmbeddingEnqueuer {
+	embeddingEnqueuerMu.RLock()
+	defer embeddingEnqueuerMu.RUnlock()
+	return embeddingEnqueuer
+}
+
+// RequestArticleEmbedding queues an article to have its embedding refreshed
+func RequestArticleEmbedding(articleID uint) {
+	enqueuer := getEmbeddingEnqueuer()
+	if enqueuer == nil {
+		return
+	}
+	if err := enqueuer.EnqueueArticleEmbedding(articleID); err != nil {
+		log.Printf("Warning: Failed to enqueue embedding for article %d: %v", articleID, err)
+	}
+}
+
+// openAIEmbeddingProvider generates embeddings with the OpenAI embeddings API
+type openAIEmbeddingProvider struct {
+	ai *AIService
+}
+
+func (p *openAIEmbeddingProvider) Name() string {
+	return "openai:" + getEnvOrDefault("OPENAI_EMBEDDING_MODEL", "text-embedding-3-small")
+}
+
+func (p *openAIEmbeddingProvider) Embed(ctx context.Context, text string) ([]float64, error) {
+	return p.ai.GenerateEmbedding(ctx, text)
+}
+
+// NewEmbeddingProvider returns the provider selected in the configuration
+func NewEmbeddingProvider(cfg *config.EmbeddingConfig) embedding.Provider {
+	if cfg.Provider == "fake" {
+		return embedding.NewFake(cfg.FakeDimensions)
+	}
+	return &openAIEmbeddingProvider{ai: GetAIService()}
+}
+
+// EmbeddingService stores article embeddings and ranks articles by similarity
+type EmbeddingService struct {
+	db       *gorm.DB
+	provider embedding.Provider
+	cfg      *config.EmbeddingConfig
+}
+
+// NewEmbeddingService creates an embedding service using the given provider
+func NewEmbeddingService(db *gorm.DB, provider embedding.Provider, cfg *config.EmbeddingConfig) *EmbeddingService {
+	return &EmbeddingService{db: db, provider: provider, cfg: cfg}
+}
+
+// GetEmbeddingService returns the embedding service configured from the environment
+func GetEmbeddingService() *EmbeddingService {
+	embeddingServiceOnce.Do(func() {
+		cfg := config.GetEmbeddingConfig()
+		embeddingServiceInstance = NewEmbeddingService(database.DB, NewEmbeddingProvider(cfg), cfg)
+	})
+	return embeddingServiceInstance
+}
+
+// EmbeddingReindexResponse reports how many articles were queued for embedding
+type EmbeddingReindexResponse struct {
+	Provider string `json:"provider"`
+	Queued   int    `json:"queued"`
+}
+
+// EmbedArticle stores the embedding of an article. Articles whose text and provider have not
+// changed since the last run are skipped, so repeated requests are cheap.
+func (s *EmbeddingService) EmbedArticle(ctx context.Context, articleID uint) error {
+	var article models.Article
+	if err := s.db.WithContext(ctx).First(&article, articleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Deleted since the job was queued
+			return s.db.WithContext(ctx).Where("article_id = ?", articleID).Delete(&models.ArticleEmbedding{}).Error
+		}
+		return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	text := articleEmbeddingText(&article)
+	sum := sha256.Sum256([]byte(text))
+	contentHash := hex.EncodeToString(sum[:])
+
+	var existing models.ArticleEmbedding
+	err := s.db.WithContext(ctx).Where("article_id = ?", articleID).First(&existing).Error
+	if err == nil && existing.ContentHash == contentHash && existing.Provider == s.provider.Name() {
+		if existing.Language != article.Language {
+			return s.db.WithContext(ctx).Model(&existing).Update("language", article.Language).Error
+		}
+		return nil
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	vector, err := s.provider.Embed(ctx, text)
+	if err != nil {
+		return fmt.Errorf("failed to embed article %d: %w", articleID, err)
+	}
+
+	record := models.ArticleEmbedding{
+		ArticleID:   articleID,
+		Provider:    s.provider.Name(),
+		Dimensions:  len(vector),
+		Vector:      embedding.Encode(vector),
+		ContentHash: contentHash,
+		Language:    article.Language,
+	}
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "article_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"provider", "dimensions", "vector", "content_hash", "language", "updated_at"}),
+	}).Create(&record).Error; err != nil {
+		return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return nil
+}
+
+// ReindexArticles queues every published article whose embedding is missing, outdated or from
+// another provider
+func (s *EmbeddingService) ReindexArticles() (*EmbeddingReindexResponse, error) {
+	enqueuer := getEmbeddingEnqueuer()
+	if enqueuer == nil {
+		return nil, fmt.Errorf("%w: the job queue is not available", ErrValidation)
+	}
+
+	var ids []uint
+	if err := s.db.Model(&models.Article{}).
+		Joins("LEFT JOIN article_embeddings e ON e.article_id = articles.id").
+		Where("articles.status = ?", "published").
+		Where("(e.id IS NULL OR e.provider <> ? OR e.updated_at < articles.updated_at)", s.provider.Name()).
+		Order("articles.id").
+		Pluck("articles.id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	response := &EmbeddingReindexResponse{Provider: s.provider.Name()}
+	for _, id := range ids {
+		if err := enqueuer.EnqueueArticleEmbedding(id); err != nil {
+			return response, fmt.Errorf("failed to enqueue embedding for article %d: %w", id, err)
+		}
+		response.Queued++
+	}
+	return response, nil
+}
+
+// SimilarArticleIDs ranks published articles by the similarity of their embedding to the
+// embedding of the given article
+func (s *EmbeddingService) SimilarArticleIDs(ctx context.Context, articleID uint, limit int) ([]embedding.Match, error) {
+	var source models.ArticleEmbedding
+	if err := s.db.WithContext(ctx).
+		Where("article_id = ? AND provider = ?", articleID, s.provider.Name()).
+		First(&source).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEmbeddingNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	vector, err := embedding.Decode(source.Vector)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := s.loadCandidates(ctx, "", articleID)
+	if err != nil {
+		return nil, err
+	}
+	return embedding.Rank(vector, candidates, limit, s.cfg.MinScore), nil
+}
+
+// Search embeds the query and returns the most similar published articles
+func (s *EmbeddingService) Search(ctx context.Context, req *models.SemanticSearchRequest) (*models.SemanticSearchResponse, error) {
+	startTime := time.Now()
+
+	vector, err := s.provider.Embed(ctx, req.Query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed search query: %w", err)
+	}
+	req.Embedding = vector
+
+	candidates, err := s.loadCandidates(ctx, req.Lang, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, ErrEmbeddingNotFound
+	}
+	matches := embedding.Rank(vector, candidates, req.Limit, s.cfg.MinScore)
+
+	response := &models.SemanticSearchResponse{
+		Query:   req.Query,
+		Results: make([]models.SemanticSearchResult, 0, len(matches)),
+		Method:  "vector",
+		Meta: models.SemanticSearchMeta{
+			QueryEmbedding: true,
+			IndexUsed:      "article_embeddings",
+		},
+	}
+
+	if len(matches) > 0 {
+		ids := make([]uint, 0, len(matches))
+		for _, match := range matches {
+			ids = append(ids, match.ID)
+		}
+		var articles []models.Article
+		if err := s.db.WithContext(ctx).Where("id IN ?", ids).Find(&articles).Error; err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+		}
+		byID := make(map[uint]*models.Article, len(articles))
+		for i := range articles {
+			byID[articles[i].ID] = &articles[i]
+		}
+
+		for _, match := range matches {
+			article, ok := byID[match.ID]
+			if !ok {
+				continue
+			}
+			result := models.SemanticSearchResult{
+				ID:      strconv.FormatUint(uint64(article.ID), 10),
+				Title:   article.Title,
+				Summary: article.Summary,
+				Score:   match.Score,
+				Lang:    article.Language,
+			}
+			if article.PublishedAt != nil {
+				result.PublishedAt = *article.PublishedAt
+			}
+			response.Results = append(response.Results, result)
+		}
+	}
+
+	response.Total = len(response.Results)
+	response.Meta.ProcessingTime = time.Since(startTime).String()
+	return response, nil
+}
+
+// loadCandidates loads the stored vectors of the most recently updated published articles
+func (s *EmbeddingService) loadCandidates(ctx context.Context, language string, excludeID uint) ([]embedding.Candidate, error) {
+	query := s.db.WithContext(ctx).Model(&models.ArticleEmbedding{}).
+		Select("article_embeddings.article_id, article_embeddings.vector").
+		Joins("JOIN articles ON articles.id = article_embeddings.article_id AND articles.status = ? AND articles.deleted_at IS NULL", "published").
+		Where("article_embeddings.provider = ?", s.provider.Name())
+	if language != "" {
+		query = query.Where("article_embeddings.language = ?", language)
+	}
+	if excludeID != 0 {
+		query = query.Where("article_embeddings.article_id <> ?", excludeID)
+	}
+
+	var rows []struct {
+		ArticleID uint
+		Vector    []byte
+	}
+	if err := query.
+		Order("articles.published_at DESC").
+		Limit(s.cfg.MaxCandidates).
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	candidates := make([]embedding.Candidate, 0, len(rows))
+	for _, row := range rows {
+		vector, err := embedding.Decode(row.Vector)
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, embedding.Candidate{ID: row.ArticleID, Vector: vector})
+	}
+	return candidates, nil
+}
+
+// articleEmbeddingText is the text embedded for an article: its title, summary and content
+// without markup
+func articleEmbeddingText(article *models.Article) string {
+	text := strings.Join([]string{
+		article.Title,
+		article.Summary,
+		htmlTagPattern.ReplaceAllString(article.Content, " "),
+	}, "\n")
+	text = strings.Join(strings.Fields(text), " ")
+	if len(text) > maxEmbeddingTextLength {
+		text = strings.ToValidUTF8(text[:maxEmbeddingTextLength], "")
+	}
+	return text
+}
@@ -0,0 +1,302 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"news/internal/cache"
+	"news/internal/config"
+	"news/internal/database"
+	"news/internal/feeds"
+	"news/internal/json"
+	"news/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrFeedNotFound is returned when the category, tag or author of a feed does not exist
+	ErrFeedNotFound = errors.New("feed not found")
+)
+
+// Feed kinds
+const (
+	FeedLatest   = "latest"
+	FeedCategory = "category"
+	FeedTag      = "tag"
+	FeedAuthor   = "author"
+)
+
+const feedCacheKeyPrefix = "feed:"
+
+// RenderedFeed is a feed ready to be served, with the validators of conditional requests
+type RenderedFeed struct {
+	Body         []byte    `json:"body"`
+	ContentType  string    `json:"content_type"`
+	ETag         string    `json:"etag"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// FeedService builds RSS, Atom and JSON feeds of published articles and caches them in the
+// unified cache
+type FeedService struct {
+	db  *gorm.DB
+	cfg *config.FeedConfig
+}
+
+var (
+	feedInstance *FeedService
+	feedOnce     sync.Once
+)
+
+// NewFeedService creates a feed service
+func NewFeedService(db *gorm.DB, cfg *config.FeedConfig) *FeedService {
+	return &FeedService{db: db, cfg: cfg}
+}
+
+// GetFeedService returns the feed service
+func GetFeedService() *FeedService {
+	feedOnce.Do(func() {
+		feedInstance = NewFeedService(database.DB, config.GetFeedConfig())
+	})
+	return feedInstance
+}
+
+// CacheTTL is how long clients and the cache may keep a feed
+func (s *FeedService) CacheTTL() time.Duration {
+	return s.cfg.CacheTTL
+}
+
+// Get returns a feed of the latest articles, or of the articles of a category, tag or author
+// identified by slug. With lang, articles are shown in that language: originals written in it
+// and published translations into it.
+func (s *FeedService) Get(kind, slug, lang, format string) (*RenderedFeed, error) {
+	if !feeds.ValidFormat(format) {
+		return nil, fmt.Errorf("%w: format must be rss, atom or json", ErrValidation)
+	}
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if len(lang) > 5 {
+		return nil, fmt.Errorf("%w: invalid language %q", ErrValidation, lang)
+	}
+
+	cacheKey := fmt.Sprintf("%s%s:%s:%s:%s", feedCacheKeyPrefix, kind, strings.ToLower(slug), lang, format)
+	unifiedCache := cache.GetUnifiedCache()
+	if cached, found := unifiedCache.GetString(cacheKey); found {
+		var rendered RenderedFeed
+		if err := json.UnmarshalForCache([]byte(cached), &rendered); err == nil {
+			return &rendered, nil
+		}
+	}
+
+	feed, err := s.build(kind, slug, lang)
+	if err != nil {
+		return nil, err
+	}
+	body, err := feeds.Render(feed, format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s feed: %v", format, err)
+	}
+
+	rendered := &RenderedFeed{
+		Body:         body,
+		ContentType:  feeds.ContentType(format),
+		ETag:         feeds.ETag(body),
+		LastModified: feed.Updated,
+	}
+	if data, err := json.MarshalForCache(rendered); err == nil {
+		// The in-process copy is short lived since InvalidateFeeds cannot reach other replicas
+		l1TTL := min(time.Minute, s.cfg.CacheTTL)
+		if err := unifiedCache.Set(cacheKey, string(data), l1TTL, s.cfg.CacheTTL); err != nil {
+			log.Printf("Warning: Failed to cache feed %s: %v", cacheKey, err)
+		}
+	}
+	return rendered, nil
+}
+
+// InvalidateFeeds drops every cached feed, so article changes show up before the cache expires
+func InvalidateFeeds() {
+	if err := cache.GetUnifiedCache().DeleteMatching(feedCacheKeyPrefix + "*"); err != nil {
+		log.Printf("Warning: Failed to invalidate cached feeds: %v", err)
+	}
+}
+
+// build loads the articles of a feed and describes them
+func (s *FeedService) build(kind, slug, lang string) (*feeds.Feed, error) {
+	feed := &feeds.Feed{
+		Title:       s.cfg.Title,
+		Description: s.cfg.Description,
+		Link:        s.cfg.SiteURL,
+		FeedURL:     s.cfg.SiteURL + "/feeds/" + kind,
+		Language:    lang,
+	}
+
+	query := s.db.Model(&models.Article{}).
+		Where("status = ? AND (published_at IS NULL OR published_at <= ?)", "published", time.Now())
+
+	switch kind {
+	case FeedLatest:
+	case FeedCategory:
+		var category models.Category
+		if err := s.db.Where("slug = ? AND is_active = ?", slug, true).First(&category).Error; err != nil {
+			return nil, feedLookupError(err)
+		}
+		query = query.Where("id IN (?)", s.db.Table("article_categories").Select("article_id").Where("category_id = ?", category.ID))
+		feed.Title = s.cfg.Title + " - " + category.Name
+		feed.Description = firstNonEmpty(category.Description, feed.Description)
+		feed.Link = s.cfg.SiteURL + "/categories/" + category.Slug
+		feed.FeedURL += "/" + url.PathEscape(category.Slug)
+	case FeedTag:
+		var tag models.Tag
+		if err := s.db.Where("slug = ?", slug).First(&tag).Error; err != nil {
+			return nil, feedLookupError(err)
+		}
+		query = query.Where("id IN (?)", s.db.Table("article_tags").Select("article_id").Where("tag_id = ?", tag.ID))
+		feed.Title = s.cfg.Title + " - " + tag.Name
+		feed.Description = firstNonEmpty(tag.Description, feed.Description)
+		feed.Link = s.cfg.SiteURL + "/tags/" + tag.Slug
+		feed.FeedURL += "/" + url.PathEscape(tag.Slug)
+	case FeedAuthor:
+		var author models.User
+		if err := s.db.Where("username = ?", slug).First(&author).Error; err != nil {
+			return nil, feedLookupError(err)
+		}
+		query = query.Where("author_id = ?", author.ID)
+		feed.Title = s.cfg.Title + " - " + author.DisplayName()
+		feed.Link = s.cfg.SiteURL + "/authors/" + url.PathEscape(author.Username)
+		feed.FeedURL += "/" + url.PathEscape(author.Username)
+	default:
+		return nil, ErrFeedNotFound
+	}
+
+	if lang != "" {
+		feed.FeedURL += "?lang=" + url.QueryEscape(lang)
+		query = query.Where("(language = ? OR id IN (?))", lang,
+			s.db.Model(&models.ArticleTranslation{}).Select("article_id").
+				Where("language = ? AND translation_status = ? AND is_active = ?", lang, "published", true))
+	}
+
+	var articles []models.Article
+	err := query.Preload("Author").Preload("Categories").Preload("Tags").
+		Order("published_at DESC, id DESC").
+		Limit(s.cfg.ItemLimit).
+		Find(&articles).Error
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	translations, err := s.translations(articles, lang)
+	if err != nil {
+		return nil, err
+	}
+
+	feed.Items = make([]feeds.Item, 0, len(articles))
+	for i := range articles {
+		item := s.item(&articles[i], translations[articles[i].ID])
+		if item.Updated.After(feed.Updated) {
+			feed.Updated = item.Updated
+		}
+		feed.Items = append(feed.Items, item)
+	}
+	return feed, nil
+}
+
+// translations loads the published translations of articles into lang, by article ID
+func (s *FeedService) translations(articles []models.Article, lang string) (map[uint]*models.ArticleTranslation, error) {
+	byArticle := make(map[uint]*models.ArticleTranslation)
+	if lang == "" || len(articles) == 0 {
+		return byArticle, nil
+	}
+
+	ids := make([]uint, 0, len(articles))
+	for _, article := range articles {
+		if article.Language != lang {
+			ids = append(ids, article.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return byArticle, nil
+	}
+
+	var translations []models.ArticleTranslation
+	err := s.db.Where("article_id IN ? AND language = ? AND translation_status = ? AND is_active = ?", ids, lang, "published", true).
+		Find(&translations).Error
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	for i := range translations {
+		byArticle[translations[i].ArticleID] = &translations[i]
+	}
+	return byArticle, nil
+}
+
+// item describes an article, in its translation when one is given
+func (s *FeedService) item(article *models.Article, translation *models.ArticleTranslation) feeds.Item {
+	item := feeds.Item{
+		ID:        fmt.Sprintf("%s/articles/%d", s.cfg.SiteURL, article.ID),
+		Title:     article.Title,
+		Link:      publicArticleURL(s.cfg.SiteURL, article.Slug, ""),
+		Summary:   article.Summary,
+		Content:   article.Content,
+		Updated:   article.UpdatedAt,
+		Language:  article.Language,
+		Image:     feeds.ImageEnclosure(article.FeaturedImage),
+		Published: article.CreatedAt,
+	}
+	if article.PublishedAt != nil {
+		item.Published = *article.PublishedAt
+	}
+	if article.Author.ID != 0 {
+		item.Author = article.Author.DisplayName()
+		item.AuthorURL = s.cfg.SiteURL + "/authors/" + url.PathEscape(article.Author.Username)
+	}
+	for _, category := range article.Categories {
+		item.Categories = append(item.Categories, category.Name)
+	}
+	for _, tag := range article.Tags {
+		item.Categories = append(item.Categories, tag.Name)
+	}
+
+	if translation != nil {
+		item.ID += "/" + translation.Language
+		item.Title = translation.Title
+		item.Link = publicArticleURL(s.cfg.SiteURL, translation.Slug, translation.Language)
+		item.Summary = translation.Summary
+		item.Content = translation.Content
+		item.Language = translation.Language
+		if translation.UpdatedAt.After(item.Updated) {
+			item.Updated = translation.UpdatedAt
+		}
+	}
+	return item
+}
+
+// publicArticleURL is the address of an article on the public site, in a translation when
+// lang is given
+func publicArticleURL(siteURL, slug, lang string) string {
+	link := siteURL + "/articles/" + url.PathEscape(slug)
+	if lang != "" {
+		link += "?lang=" + url.QueryEscape(lang)
+	}
+	return link
+}
+
+func feedLookupError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrFeedNotFound
+	}
+	return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"news/internal/database"
+	"news/internal/json"
+	"news/internal/models"
+	"news/internal/pubsub"
+)
+
+// MaxLiveNewsEventReplay bounds how many missed events a reconnecting client gets at once
+const MaxLiveNewsEventReplay = 500
+
+// liveNewsEventRetention is how long events are kept for clients to catch up with
+const liveNewsEventRetention = 7 * 24 * time.Hour
+
+// LiveNewsScheduleResult reports the live streams changed by one run of the live schedule
+type LiveNewsScheduleResult struct {
+	Started        []uint
+	Ended          []uint
+	ViewersUpdated int
+}
+
+// LiveUpdateEventData is the payload of live update events
+type LiveUpdateEventData struct {
+	Update      *models.LiveNewsUpdate `json:"update"`
+	Highlighted bool                   `json:"highlighted"`
+}
+
+// LiveUpdateDeletedEventData is the payload of live update deletions
+type LiveUpdateDeletedEventData struct {
+	UpdateID uint `json:"update_id"`
+}
+
+// LiveStatusEventData is the payload of live stream status changes
+type LiveStatusEventData struct {
+	Status    string     `json:"status"`
+	Previous  string     `json:"previous"`
+	StartTime *time.Time `json:"start_time,omitempty"`
+	EndTime   *time.Time `json:"end_time,omitempty"`
+}
+
+// LiveViewerCountData is the payload of viewer count updates
+type LiveViewerCountData struct {
+	ViewerCount int `json:"viewer_count"`
+}
+
+// RecordLiveNewsEvent appends an event to a stream's log and pushes it to the subscribers of the
+// stream's topic over WebSocket and Server-Sent Events
+func RecordLiveNewsEvent(streamID uint, eventType string, updateID *uint, data interface{}) (*models.LiveNewsEvent, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode live event: %w", err)
+	}
+
+	event := models.LiveNewsEvent{
+		StreamID: streamID,
+		Type:     eventType,
+		UpdateID: updateID,
+		Payload:  payload,
+	}
+	if err := database.DB.Create(&event).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	if err := pubsub.PublishTopic(pubsub.LiveTopic(streamID), LiveNewsEventMessage(&event)); err != nil {
+		log.Printf("Warning: Failed to publish live event %d of stream %d: %v", event.ID, streamID, err)
+	}
+	return &event, nil
+}
+
+// LiveNewsEventMessage returns the notification pushed to viewers for a logged event, so live
+// and replayed events look the same
+func LiveNewsEventMessage(event *models.LiveNewsEvent) pubsub.NotificationMessage {
+	return pubsub.NotificationMessage{
+		Type:      event.Type,
+		Topic:     pubsub.LiveTopic(event.StreamID),
+		EventID:   event.ID,
+		Data:      event.Payload,
+		Timestamp: event.CreatedAt,
+		Channel:   pubsub.TopicChannelPrefix + pubsub.LiveTopic(event.StreamID),
+	}
+}
+
+// RecordLiveUpdateEvent records the creation or edit of a live update
+func RecordLiveUpdateEvent(eventType string, update *models.LiveNewsUpdate) {
+	data := LiveUpdateEventData{Update: update, Highlighted: update.IsHighlighted()}
+	if _, err := RecordLiveNewsEvent(update.StreamID, eventType, &update.ID, data); err != nil {
+		log.Printf("Warning: Failed to record live update %d: %v", update.ID, err)
+	}
+}
+
+// RecordLiveStatusEvent records a status change of a live stream
+func RecordLiveStatusEvent(stream *models.LiveNewsStream, previous string) {
+	data := LiveStatusEventData{
+		Status:    stream.Status,
+		Previous:  previous,
+		StartTime: stream.StartTime,
+		EndTime:   stream.EndTime,
+	}
+	if _, err := RecordLiveNewsEvent(stream.ID, models.LiveEventStatusChanged, nil, data); err != nil {
+		log.Printf("Warning: Failed to record status of live stream %d: %v", stream.ID, err)
+	}
+}
+
+// GetLiveNewsEvents returns the events of a stream after the given event ID, oldest first
+func GetLiveNewsEvents(streamID, afterID uint, limit int) ([]models.LiveNewsEvent, error) {
+	if limit <= 0 || limit > MaxLiveNewsEventReplay {
+		limit = MaxLiveNewsEventReplay
+	}
+
+	var events []models.LiveNewsEvent
+	if err := database.DB.Where("stream_id = ? AND id > ?", streamID, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return events, nil
+}
+
+// PruneLiveNewsEvents deletes up to batch events older than the retention period, oldest first.
+// It returns the number of events deleted.
+func PruneLiveNewsEvents(now time.Time, batch int) (int, error) {
+	if database.DB == nil {
+		return 0, nil
+	}
+
+	var ids []uint
+	if err := database.DB.Model(&models.LiveNewsEvent{}).
+		Where("created_at < ?", now.Add(-liveNewsEventRetention)).
+		Order("id ASC").
+		Limit(batch).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	result := database.DB.Where("id IN ?", ids).Delete(&models.LiveNewsEvent{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("%w: %v", ErrDatabaseError, result.Error)
+	}
+	return int(result.RowsAffected), nil
+}
+
+// RunLiveNewsSchedule starts scheduled streams whose start time has passed, ends live streams
+// whose end time has passed and refreshes the viewer counts of live streams
+func RunLiveNewsSchedule(now time.Time, batchSize int) (*LiveNewsScheduleResult, error) {
+	result := &LiveNewsScheduleResult{}
+
+	var due []models.LiveNewsStream
+	if err := database.DB.
+		Where("(status = ? AND start_time IS NOT NULL AND start_time <= ?) OR (status = ? AND end_time IS NOT NULL AND end_time <= ?)",
+			models.LiveStreamScheduled, now, models.LiveStreamLive, now).
+		Order("id ASC").
+		Limit(batchSize).
+		Find(&due).Error; err != nil {
+		return result, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	for i := range due {
+		stream := &due[i]
+		previous := stream.Status
+		next := stream.ScheduledStatus(now)
+		if next == previous {
+			continue
+		}
+
+		// Guard on the previous status so an editor's concurrent change wins
+		res := database.DB.Model(&models.LiveNewsStream{}).
+			Where("id = ? AND status = ?", stream.ID, previous).
+			Update("status", next)
+		if res.Error != nil {
+			log.Printf("Warning: Failed to move live stream %d to %s: %v", stream.ID, next, res.Error)
+			continue
+		}
+		if res.RowsAffected == 0 {
+			continue
+		}
+
+		stream.Status = next
+		if next == models.LiveStreamLive {
+			result.Started = append(result.Started, stream.ID)
+		} else {
+			result.Ended = append(result.Ended, stream.ID)
+		}
+		RecordLiveStatusEvent(stream, previous)
+	}
+
+	updated, err := RefreshLiveViewerCounts()
+	result.ViewersUpdated = updated
+	return result, err
+}
+
+// RefreshLiveViewerCounts stores the number of connections watching each live stream and pushes
+// changed counts to the viewers. It returns how many streams changed.
+func RefreshLiveViewerCounts() (int, error) {
+	var streams []models.LiveNewsStream
+	if err := database.DB.Select("id", "viewer_count").
+		Where("status = ? OR viewer_count > 0", models.LiveStreamLive).
+		Find(&streams).Error; err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	updated := 0
+	for _, stream := range streams {
+		count, err := pubsub.CountTopicSubscribers(ctx, pubsub.LiveTopic(stream.ID))
+		if err != nil {
+			return updated, fmt.Errorf("failed to count viewers of live stream %d: %w", stream.ID, err)
+		}
+		if count == stream.ViewerCount {
+			continue
+		}
+
+		if err := database.DB.Model(&models.LiveNewsStream{}).
+			Where("id = ?", stream.ID).
+			Update("viewer_count", count).Error; err != nil {
+			log.Printf("Warning: Failed to store viewer count of live stream %d: %v", stream.ID, err)
+			continue
+		}
+		updated++
+
+		notification := pubsub.NotificationMessage{
+			Type: models.LiveEventViewerCount,
+			Data: LiveViewerCountData{ViewerCount: count},
+		}
+		if err := pubsub.PublishTopic(pubsub.LiveTopic(stream.ID), notification); err != nil {
+			log.Printf("Warning: Failed to publish viewer count of live stream %d: %v", stream.ID, err)
+		}
+	}
+	return updated, nil
+}
@@ -0,0 +1,293 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"news/internal/config"
+	"news/internal/database"
+	"news/internal/imaging"
+	"news/internal/json"
+	"news/internal/models"
+	"news/internal/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrMediaTooLarge is returned for uploads above the configured maximum size
+var ErrMediaTooLarge = errors.New("file too large")
+
+// legacyUploadDir is where media was written before uploads went through storage.Storage
+const legacyUploadDir = "uploads/"
+
+var (
+	mediaServiceInstance *MediaService
+	mediaServiceErr      error
+	mediaServiceOnce     sync.Once
+)
+
+// MediaUpload describes a file added to the media library
+type MediaUpload struct {
+	OriginalName string
+	MimeType     string
+	AltText      string
+	Caption      string
+	UploadedBy   uint
+}
+
+// MediaService stores uploaded files in the configured storage backend and generates image
+// derivatives
+type MediaService struct {
+	db      *gorm.DB
+	storage storage.Storage
+	cfg     *config.MediaConfig
+	webp    imaging.WebPEncoder
+	url     func(key string) string
+}
+
+// NewMediaService creates a media service. webp may be nil to skip WebP derivatives, and url
+// builds the public URL of a storage key.
+func NewMediaService(db *gorm.DB, store storage.Storage, cfg *config.MediaConfig, webp imaging.WebPEncoder, url func(key string) string) *MediaService {
+	return &MediaService{db: db, storage: store, cfg: cfg, webp: webp, url: url}
+}
+
+// InitMediaService creates the media service using the configured storage backend. It fails when
+// WebP derivatives are enabled but cwebp cannot be found, rather than silently dropping them.
+func InitMediaService() error {
+	mediaServiceOnce.Do(func() {
+		cfg := config.GetMediaConfig()
+		var webp imaging.WebPEncoder
+		if cfg.WebPEnabled {
+			encoder, err := imaging.NewCWebP(cfg.CWebPPath)
+			if err != nil {
+				mediaServiceErr = fmt.Errorf("WebP derivatives are enabled but %v at %q; install libwebp or set MEDIA_WEBP_ENABLED=false", err, cfg.CWebPPath)
+				return
+			}
+			webp = encoder
+		}
+		mediaServiceInstance = NewMediaService(database.DB, GetStorageService(), cfg, webp, StoragePublicURL)
+	})
+	return mediaServiceErr
+}
+
+// GetMediaService returns the media service, initializing it on first use
+func GetMediaService() *MediaService {
+	if err := InitMediaService(); err != nil {
+		panic(fmt.Sprintf("Failed to initialize media service: %v", err))
+	}
+	return mediaServiceInstance
+}
+
+// withDB returns a copy of the service that records media through db, such as a transaction
+func (s *MediaService) withDB(db *gorm.DB) *MediaService {
+	copy := *s
+	copy.db = db
+	return &copy
+}
+
+// MaxUploadSize returns the largest accepted upload in bytes
+func (s *MediaService) MaxUploadSize() int64 {
+	return s.cfg.MaxUploadSize
+}
+
+// Upload stores a file and its derivatives and records it in the media library. Images that
+// cannot be decoded, such as SVG, are stored without derivatives.
+func (s *MediaService) Upload(file io.Reader, upload MediaUpload) (*models.Media, error) {
+	data, err := io.ReadAll(io.LimitReader(file, s.cfg.MaxUploadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	if int64(len(data)) > s.cfg.MaxUploadSize {
+		return nil, fmt.Errorf("%w: maximum size is %d MB", ErrMediaTooLarge, s.cfg.MaxUploadSize>>20)
+	}
+
+	key := newStorageKey("media", upload.OriginalName)
+	if _, err := s.storage.Upload(bytes.NewReader(data), key); err != nil {
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+	return s.create(key, int64(len(data)), data, upload)
+}
+
+// Register records a file that is already in storage, such as a completed resumable upload.
+// Images up to MaxUploadSize get derivatives like regular uploads; the file is deleted if the
+// record cannot be created.
+func (s *MediaService) Register(key string, size int64, upload MediaUpload) (*models.Media, error) {
+	var data []byte
+	if strings.HasPrefix(upload.MimeType, "image/") && size <= s.cfg.MaxUploadSize {
+		var err error
+		if data, err = s.download(key); err != nil {
+			log.Printf("Warning: Could not read %s for derivatives: %v", key, err)
+		}
+	}
+	return s.create(key, size, data, upload)
+}
+
+// create records a stored file, generating derivatives from data when it is a decodable image
+func (s *MediaService) create(key string, size int64, data []byte, upload MediaUpload) (*models.Media, error) {
+	stored := []string{key}
+	media := models.Media{
+		FileName:     path.Base(key),
+		OriginalName: upload.OriginalName,
+		MimeType:     upload.MimeType,
+		Size:         size,
+		Path:         key,
+		URL:          s.url(key),
+		AltText:      upload.AltText,
+		Caption:      upload.Caption,
+		UploadedBy:   upload.UploadedBy,
+	}
+
+	if media.IsImage() && data != nil {
+		derivatives, err := s.processImage(&media, data, strings.TrimSuffix(key, path.Ext(key)))
+		for _, d := range derivatives {
+			stored = append(stored, d.Key)
+		}
+		if err != nil {
+			s.deleteKeys(stored)
+			return nil, err
+		}
+	}
+
+	if err := s.db.Create(&media).Error; err != nil {
+		s.deleteKeys(stored)
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return &media, nil
+}
+
+func (s *MediaService) download(key string) ([]byte, error) {
+	reader, err := s.storage.Download(key)
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	return io.ReadAll(io.LimitReader(reader, s.cfg.MaxUploadSize))
+}
+
+// newStorageKey returns a unique key under prefix/YYYY/MM keeping the extension of the original
+// file name
+func newStorageKey(prefix, originalName string) string {
+	ext := strings.ToLower(filepath.Ext(originalName))
+	return path.Join(prefix, time.Now().Format("2006/01"), uuid.New().String()+ext)
+}
+
+// Delete removes a media record with its file and every derivative
+func (s *MediaService) Delete(media *models.Media) error {
+	if err := s.db.Delete(media).Error; err != nil {
+		return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	// Uploads from before the storage backend was used were written to ./uploads directly
+	if strings.HasPrefix(media.Path, legacyUploadDir) {
+		if err := os.Remove(media.Path); err != nil {
+			log.Printf("Warning: Failed to delete file %s: %v", media.Path, err)
+		}
+		return nil
+	}
+
+	keys := []string{media.Path}
+	for _, d := range media.DerivativeList() {
+		keys = append(keys, d.Key)
+	}
+	s.deleteKeys(keys)
+	return nil
+}
+
+// processImage records the size and dominant colour of an image and stores its derivatives.
+// Decoding problems leave the image without derivatives; storage and WebP encoding failures are
+// returned.
+func (s *MediaService) processImage(media *models.Media, data []byte, base string) ([]models.MediaDerivative, error) {
+	img, format, err := imaging.Decode(data)
+	if err != nil {
+		if !errors.Is(err, imaging.ErrUnsupported) {
+			log.Printf("Warning: Could not process image %s (%s): %v", media.OriginalName, format, err)
+		}
+		return nil, nil
+	}
+
+	bounds := img.Bounds()
+	media.Width = bounds.Dx()
+	media.Height = bounds.Dy()
+	media.DominantColor = imaging.DominantColor(img)
+
+	var derivatives []models.MediaDerivative
+	for i, variant := range imaging.Variants {
+		// Larger variants than the original would only repeat it; the thumbnail always exists
+		if i > 0 && media.Width <= imaging.Variants[i-1].Width {
+			break
+		}
+
+		width, height := imaging.Fit(media.Width, media.Height, variant.Width)
+		resized := imaging.Resize(img, width, height)
+
+		var buf bytes.Buffer
+		encoded, ext, err := imaging.Encode(&buf, resized, s.cfg.JPEGQuality)
+		if err != nil {
+			log.Printf("Warning: Could not encode %s derivative of %s: %v", variant.Name, media.OriginalName, err)
+			continue
+		}
+		d, err := s.storeDerivative(variant.Name, encoded, base+"_"+variant.Name+ext, buf.Bytes(), width, height)
+		if err != nil {
+			return derivatives, err
+		}
+		derivatives = append(derivatives, d)
+
+		if s.webp != nil {
+			webp, err := s.webp.EncodeWebP(resized, s.cfg.WebPQuality)
+			if err != nil {
+				return derivatives, fmt.Errorf("failed to encode WebP %s derivative: %w", variant.Name, err)
+			}
+			d, err := s.storeDerivative(variant.Name, "webp", base+"_"+variant.Name+".webp", webp, width, height)
+			if err != nil {
+				return derivatives, err
+			}
+			derivatives = append(derivatives, d)
+		}
+	}
+
+	encoded, err := json.Marshal(derivatives)
+	if err != nil {
+		return derivatives, err
+	}
+	media.Derivatives = encoded
+	return derivatives, nil
+}
+
+func (s *MediaService) storeDerivative(name, format, key string, data []byte, width, height int) (models.MediaDerivative, error) {
+	if _, err := s.storage.Upload(bytes.NewReader(data), key); err != nil {
+		return models.MediaDerivative{}, fmt.Errorf("failed to store %s derivative: %w", name, err)
+	}
+	return models.MediaDerivative{
+		Name:   name,
+		Format: format,
+		Key:    key,
+		URL:    s.url(key),
+		Width:  width,
+		Height: height,
+		Size:   int64(len(data)),
+	}, nil
+}
+
+// deleteKeys removes stored files, logging failures so one missing file does not keep the rest
+func (s *MediaService) deleteKeys(keys []string) {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := s.storage.Delete(key); err != nil {
+			log.Printf("Warning: Failed to delete stored file %s: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,498 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"news/internal/config"
+	"news/internal/database"
+	"news/internal/mail"
+	"news/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrNewsletterNotFound is returned when a newsletter does not exist
+	ErrNewsletterNotFound = errors.New("newsletter not found")
+	// ErrNewsletterAlreadySent is returned when a newsletter is already being sent or has been sent
+	ErrNewsletterAlreadySent = errors.New("newsletter already sent")
+)
+
+// Newsletter delivery statuses
+const (
+	DeliveryStatusPending  = "pending"
+	DeliveryStatusQueued   = "queued"
+	DeliveryStatusSending  = "sending"
+	DeliveryStatusRetrying = "retrying"
+	DeliveryStatusSent     = "sent"
+	DeliveryStatusFailed   = "failed"
+	DeliveryStatusBounced  = "bounced"
+	DeliveryStatusSkipped  = "skipped"
+)
+
+// newsletterStaleDeliveryTimeout is how long a delivery may stay queued, sending or retrying
+// before the scheduler assumes its job was lost
+const newsletterStaleDeliveryTimeout = time.Hour
+
+// NewsletterDeliveryListResponse represents a paginated list of newsletter deliveries
+type NewsletterDeliveryListResponse struct {
+	NewsletterID uint                        `json:"newsletter_id"`
+	Deliveries   []models.NewsletterDelivery `json:"deliveries"`
+	Total        int64                       `json:"total"`
+	Page         int                         `json:"page"`
+	Limit        int                         `json:"limit"`
+	TotalPages   int                         `json:"total_pages"`
+}
+
+// PrepareNewsletterDelivery moves a draft or scheduled newsletter to "sending" and creates a
+// pending delivery for every subscriber in its audience. It returns the IDs of the deliveries
+// to enqueue. The status change is conditional, so a newsletter can only be prepared once.
+func PrepareNewsletterDelivery(newsletterID uint) ([]uint, error) {
+	var deliveryIDs []uint
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var newsletter models.Newsletter
+		if err := tx.First(&newsletter, newsletterID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNewsletterNotFound
+			}
+			return err
+		}
+
+		update := tx.Model(&models.Newsletter{}).
+			Where("id = ? AND status IN ?", newsletterID, []string{"draft", "scheduled"}).
+			Update("status", "sending")
+		if update.Error != nil {
+			return update.Error
+		}
+		if update.RowsAffected == 0 {
+			return ErrNewsletterAlreadySent
+		}
+
+		subscriptions, err := resolveNewsletterAudience(tx, newsletter)
+		if err != nil {
+			return fmt.Errorf("failed to resolve audience: %v", err)
+		}
+
+		defaultLanguage := config.GetTranslationConfig().DefaultLanguage
+		deliveries := make([]models.NewsletterDelivery, 0, len(subscriptions))
+		for _, subscription := range subscriptions {
+			language := subscription.Language
+			if language == "" {
+				language = defaultLanguage
+			}
+			deliveries = append(deliveries, models.NewsletterDelivery{
+				NewsletterID:   newsletterID,
+				SubscriptionID: subscription.ID,
+				Email:          subscription.Email,
+				Language:       language,
+				Status:         DeliveryStatusPending,
+			})
+		}
+
+		if len(deliveries) > 0 {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&deliveries, 500).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&models.NewsletterDelivery{}).
+			Where("newsletter_id = ? AND status = ?", newsletterID, DeliveryStatusPending).
+			Pluck("id", &deliveryIDs).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{"recipients": len(deliveryIDs)}
+		if len(deliveryIDs) == 0 {
+			// Nobody to send to, so the newsletter is done
+			updates["status"] = "sent"
+			updates["sent_at"] = time.Now()
+		}
+		return tx.Model(&models.Newsletter{}).Where("id = ?", newsletterID).Updates(updates).Error
+	})
+	if err != nil {
+		if errors.Is(err, ErrNewsletterNotFound) || errors.Is(err, ErrNewsletterAlreadySent) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	return deliveryIDs, nil
+}
+
+// resolveNewsletterAudience returns the active subscriptions a newsletter targets, one per email address
+func resolveNewsletterAudience(tx *gorm.DB, newsletter models.Newsletter) ([]models.Subscription, error) {
+	query := tx.Where("is_active = ?", true)
+
+	switch newsletter.Audience {
+	case "category":
+		query = query.Where("type = ? AND category_id = ?", "category", newsletter.CategoryID)
+	case "tag":
+		query = query.Where("type = ? AND tag_id = ?", "tag", newsletter.TagID)
+	case "author":
+		query = query.Where("type = ? AND author_id = ?", "author", newsletter.AuthorID)
+	default:
+		query = query.Where("type = ?", "newsletter")
+	}
+
+	var subscriptions []models.Subscription
+	if err := query.Order("id ASC").Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(subscriptions))
+	unique := subscriptions[:0]
+	for _, subscription := range subscriptions {
+		email := strings.ToLower(strings.TrimSpace(subscription.Email))
+		if email == "" || seen[email] {
+			continue
+		}
+		seen[email] = true
+		unique = append(unique, subscription)
+	}
+
+	return unique, nil
+}
+
+// GetDueNewsletterIDs returns scheduled newsletters whose ScheduledAt has passed
+func GetDueNewsletterIDs(now time.Time) ([]uint, error) {
+	var ids []uint
+	err := database.DB.Model(&models.Newsletter{}).
+		Where("status = ? AND scheduled_at IS NOT NULL AND scheduled_at <= ?", "scheduled", now).
+		Order("scheduled_at ASC").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// GetStalledNewsletterDeliveries returns deliveries of newsletters that are still sending but
+// whose job was never enqueued, or appears to have been lost
+func GetStalledNewsletterDeliveries(now time.Time, limit int) ([]models.NewsletterDelivery, error) {
+	var deliveries []models.NewsletterDelivery
+	err := database.DB.Model(&models.NewsletterDelivery{}).
+		Select("newsletter_deliveries.id", "newsletter_deliveries.newsletter_id").
+		Joins("JOIN newsletters ON newsletters.id = newsletter_deliveries.newsletter_id").
+		Where("newsletters.status = ?", "sending").
+		Where(stalledNewsletterDeliveryCondition("newsletter_deliveries."), DeliveryStatusPending,
+			inFlightDeliveryStatuses, now.Add(-newsletterStaleDeliveryTimeout)).
+		Order("newsletter_deliveries.id ASC").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// ClaimNewsletterDelivery marks a pending or stalled delivery as queued. It reports false if
+// another worker claimed it first.
+func ClaimNewsletterDelivery(deliveryID uint, now time.Time) (bool, error) {
+	result := database.DB.Model(&models.NewsletterDelivery{}).
+		Where("id = ?", deliveryID).
+		Where(stalledNewsletterDeliveryCondition(""), DeliveryStatusPending,
+			inFlightDeliveryStatuses, now.Add(-newsletterStaleDeliveryTimeout)).
+		Update("status", DeliveryStatusQueued)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ReleaseNewsletterDelivery returns a claimed delivery to the scheduler after enqueueing failed
+func ReleaseNewsletterDelivery(deliveryID uint) {
+	if err := database.DB.Model(&models.NewsletterDelivery{}).
+		Where("id = ? AND status = ?", deliveryID, DeliveryStatusQueued).
+		Update("status", DeliveryStatusPending).Error; err != nil {
+		log.Printf("Warning: Failed to release newsletter delivery %d: %v", deliveryID, err)
+	}
+}
+
+// inFlightDeliveryStatuses are the statuses of deliveries that have a job in the queue or being
+// worked on
+var inFlightDeliveryStatuses = []string{DeliveryStatusQueued, DeliveryStatusSending, DeliveryStatusRetrying}
+
+// stalledNewsletterDeliveryCondition matches deliveries that were never enqueued, or that have
+// been queued, sending or retrying for longer than the stale timeout
+func stalledNewsletterDeliveryCondition(table string) string {
+	return fmt.Sprintf("(%[1]sstatus = ? OR (%[1]sstatus IN ? AND %[1]supdated_at <= ?))", table)
+}
+
+// GetNewsletterDeliveries retrieves the per-recipient deliveries of a newsletter with pagination
+func GetNewsletterDeliveries(newsletterID uint, status string, page, limit int) (*NewsletterDeliveryListResponse, error) {
+	query := database.DB.Model(&models.NewsletterDelivery{}).Where("newsletter_id = ?", newsletterID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	var deliveries []models.NewsletterDelivery
+	if err := query.Order("id ASC").Offset((page - 1) * limit).Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	return &NewsletterDeliveryListResponse{
+		NewsletterID: newsletterID,
+		Deliveries:   deliveries,
+		Total:        total,
+		Page:         page,
+		Limit:        limit,
+		TotalPages:   int((total + int64(limit) - 1) / int64(limit)),
+	}, nil
+}
+
+// NewsletterDeliveryService renders and sends newsletter emails to individual subscribers
+type NewsletterDeliveryService struct {
+	mailer mail.Mailer
+	config *config.MailConfig
+}
+
+// NewNewsletterDeliveryService creates a new newsletter delivery service
+func NewNewsletterDeliveryService(mailer mail.Mailer, cfg *config.MailConfig) *NewsletterDeliveryService {
+	return &NewsletterDeliveryService{
+		mailer: mailer,
+		config: cfg,
+	}
+}
+
+// Deliver sends one newsletter delivery. Permanent SMTP failures are recorded as bounces and the
+// subscription is deactivated; temporary failures return an error so the queue retries the job.
+// finalAttempt marks a temporary failure as failed, since the queue will not retry it again.
+func (s *NewsletterDeliveryService) Deliver(ctx context.Context, deliveryID uint, finalAttempt bool) error {
+	var delivery models.NewsletterDelivery
+	if err := database.DB.Preload("Newsletter").Preload("Subscription.User").First(&delivery, deliveryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Newsletter delivery %d no longer exists, skipping", deliveryID)
+			return nil
+		}
+		return err
+	}
+
+	// Jobs can be retried after a crash, so never send a finished delivery twice
+	if delivery.IsFinal() || delivery.Status == DeliveryStatusSkipped {
+		return nil
+	}
+
+	// A stalled delivery can be enqueued again while its first job still runs, so only the
+	// worker that moves it to sending may send it
+	claimed, err := claimNewsletterDeliveryForSending(deliveryID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		log.Printf("Newsletter delivery %d is already being sent, skipping", deliveryID)
+		return nil
+	}
+
+	if !delivery.Subscription.IsActive || delivery.Subscription.DeletedAt.Valid {
+		s.finishDelivery(&delivery, map[string]interface{}{
+			"status":     DeliveryStatusSkipped,
+			"last_error": "subscription is no longer active",
+		}, "")
+		return nil
+	}
+
+	template := loadEmailTemplate(delivery.Newsletter.TemplateKey, delivery.Language)
+	recipient := mail.Recipient{
+		Email:          delivery.Email,
+		Language:       delivery.Language,
+		UnsubscribeURL: s.unsubscribeURL(delivery.Subscription.Token),
+	}
+	if user := delivery.Subscription.User; user != nil {
+		recipient.Name = strings.TrimSpace(user.FirstName + " " + user.LastName)
+	}
+
+	msg := mail.RenderNewsletter(delivery.Newsletter, template, recipient)
+	messageID, sendErr := s.mailer.Send(ctx, msg)
+
+	now := time.Now()
+	attempts := delivery.Attempts + 1
+
+	switch {
+	case sendErr == nil:
+		s.finishDelivery(&delivery, map[string]interface{}{
+			"status":     DeliveryStatusSent,
+			"attempts":   attempts,
+			"message_id": messageID,
+			"sent_at":    now,
+			"last_error": "",
+		}, "delivered")
+		return nil
+
+	case mail.IsPermanent(sendErr):
+		s.finishDelivery(&delivery, map[string]interface{}{
+			"status":        DeliveryStatusBounced,
+			"attempts":      attempts,
+			"bounce_reason": truncate(sendErr.Error(), 255),
+			"bounced_at":    now,
+			"last_error":    sendErr.Error(),
+		}, "bounced")
+
+		if err := database.DB.Model(&models.Subscription{}).
+			Where("id = ?", delivery.SubscriptionID).
+			Update("is_active", false).Error; err != nil {
+			log.Printf("Warning: Failed to deactivate bounced subscription %d: %v", delivery.SubscriptionID, err)
+		}
+		return nil
+
+	case finalAttempt:
+		s.finishDelivery(&delivery, map[string]interface{}{
+			"status":     DeliveryStatusFailed,
+			"attempts":   attempts,
+			"last_error": sendErr.Error(),
+		}, "failed")
+		return sendErr
+
+	default:
+		if err := database.DB.Model(&delivery).Updates(map[string]interface{}{
+			"status":     DeliveryStatusRetrying,
+			"attempts":   attempts,
+			"last_error": sendErr.Error(),
+		}).Error; err != nil {
+			log.Printf("Warning: Failed to record retry for newsletter delivery %d: %v", delivery.ID, err)
+		}
+		return sendErr
+	}
+}
+
+// claimNewsletterDeliveryForSending marks a delivery as sending unless another worker is
+// already sending it or it is finished. A delivery left sending by a crashed worker can be
+// claimed again after the stale timeout.
+func claimNewsletterDeliveryForSending(deliveryID uint, now time.Time) (bool, error) {
+	result := database.DB.Model(&models.NewsletterDelivery{}).
+		Where("id = ?", deliveryID).
+		Where("status IN ? OR (status = ? AND updated_at <= ?)",
+			[]string{DeliveryStatusPending, DeliveryStatusQueued, DeliveryStatusRetrying},
+			DeliveryStatusSending, now.Add(-newsletterStaleDeliveryTimeout)).
+		Update("status", DeliveryStatusSending)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// finishDelivery stores the final state of a delivery, bumps the newsletter counter and marks
+// the newsletter as sent once every delivery is final
+func (s *NewsletterDeliveryService) finishDelivery(delivery *models.NewsletterDelivery, updates map[string]interface{}, counter string) {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(delivery).Updates(updates).Error; err != nil {
+			return err
+		}
+		if counter != "" {
+			if err := tx.Model(&models.Newsletter{}).
+				Where("id = ?", delivery.NewsletterID).
+				Update(counter, gorm.Expr(counter+" + 1")).Error; err != nil {
+				return err
+			}
+		}
+
+		var remaining int64
+		if err := tx.Model(&models.NewsletterDelivery{}).
+			Where("newsletter_id = ? AND status IN ?", delivery.NewsletterID, append([]string{DeliveryStatusPending}, inFlightDeliveryStatuses...)).
+			Count(&remaining).Error; err != nil {
+			return err
+		}
+		if remaining > 0 {
+			return nil
+		}
+
+		return tx.Model(&models.Newsletter{}).
+			Where("id = ? AND status = ?", delivery.NewsletterID, "sending").
+			Updates(map[string]interface{}{"status": "sent", "sent_at": time.Now()}).Error
+	})
+	if err != nil {
+		log.Printf("Warning: Failed to record newsletter delivery %d: %v", delivery.ID, err)
+	}
+}
+
+// SendSubscriptionConfirmation sends the double opt-in email for a pending subscription.
+// Subscriptions that were confirmed or cancelled in the meantime are skipped.
+func (s *NewsletterDeliveryService) SendSubscriptionConfirmation(ctx context.Context, subscriptionID uint) error {
+	var subscription models.Subscription
+	if err := database.DB.Preload("User").First(&subscription, subscriptionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Subscription %d no longer exists, skipping confirmation", subscriptionID)
+			return nil
+		}
+		return err
+	}
+
+	if !subscription.IsPendingConfirmation() {
+		return nil
+	}
+
+	language := subscription.Language
+	if language == "" {
+		language = config.GetTranslationConfig().DefaultLanguage
+	}
+	recipient := mail.Recipient{
+		Email:          subscription.Email,
+		Language:       language,
+		UnsubscribeURL: s.unsubscribeURL(subscription.Token),
+	}
+	if user := subscription.User; user != nil {
+		recipient.Name = strings.TrimSpace(user.FirstName + " " + user.LastName)
+	}
+
+	template := loadEmailTemplate("subscription_confirmation", language)
+	msg := mail.RenderSubscriptionConfirmation(template, recipient, s.confirmURL(subscription.Token))
+	if _, err := s.mailer.Send(ctx, msg); err != nil {
+		if mail.IsPermanent(err) {
+			// Retrying will not help an address the server rejects
+			log.Printf("Confirmation email for subscription %d rejected: %v", subscriptionID, err)
+			return nil
+		}
+		return err
+	}
+
+	return MarkConfirmationSent(subscriptionID)
+}
+
+// unsubscribeURL builds the one-click unsubscribe link for a subscription token
+func (s *NewsletterDeliveryService) unsubscribeURL(token string) string {
+	if token == "" {
+		return ""
+	}
+	return strings.TrimRight(s.config.PublicBaseURL, "/") + "/api/subscriptions/unsubscribe/" + token
+}
+
+// confirmURL builds the double opt-in confirmation link for a subscription token
+func (s *NewsletterDeliveryService) confirmURL(token string) string {
+	return strings.TrimRight(s.config.PublicBaseURL, "/") + "/api/subscriptions/confirm/" + token
+}
+
+// loadEmailTemplate finds the active email template in the recipient's language, falling back
+// to the default language
+func loadEmailTemplate(templateKey, language string) *models.EmailTemplateTranslation {
+	if templateKey == "" {
+		templateKey = "newsletter"
+	}
+
+	languages := []string{language}
+	if defaultLanguage := config.GetTranslationConfig().DefaultLanguage; defaultLanguage != language {
+		languages = append(languages, defaultLanguage)
+	}
+
+	for _, lang := range languages {
+		var template models.EmailTemplateTranslation
+		err := database.DB.Where("template_key = ? AND language = ? AND is_active = ?", templateKey, lang, true).
+			First(&template).Error
+		if err == nil {
+			return &template
+		}
+	}
+
+	return nil
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max]
+}
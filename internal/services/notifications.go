@@ -0,0 +1,38 @@
+package services
+
+import (
+	"log"
+
+	"news/internal/json"
+	"news/internal/models"
+	"news/internal/pubsub"
+
+	"gorm.io/gorm"
+)
+
+// NotifyUser saves a notification to a user's notification list and pushes it to their open
+// connections. Failures are logged, so a notification never fails the action that caused it.
+func NotifyUser(db *gorm.DB, userID uint, notificationType, title, message string, data map[string]interface{}) {
+	dataJSON, _ := json.Marshal(data)
+	notification := models.Notification{
+		UserID:  userID,
+		Type:    notificationType,
+		Title:   title,
+		Message: message,
+		Data:    string(dataJSON),
+	}
+	if err := db.Create(&notification).Error; err != nil {
+		log.Printf("Failed to save %s notification for user %d: %v", notificationType, userID, err)
+	}
+
+	payload := make(map[string]interface{}, len(data)+3)
+	for key, value := range data {
+		payload[key] = value
+	}
+	payload["notification_id"] = notification.ID
+	payload["title"] = title
+	payload["message"] = message
+	if err := pubsub.PublishUserNotification(userID, notificationType, payload); err != nil {
+		log.Printf("Failed to publish %s notification for user %d: %v", notificationType, userID, err)
+	}
+}
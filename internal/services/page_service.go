@@ -8,6 +8,7 @@ import (
 
 	"news/internal/models"
 	"news/internal/repositories"
+	"news/internal/sitemap"
 
 	"gorm.io/datatypes"
 	"gorm.io/gorm"
@@ -314,6 +315,7 @@ func (s *PageService) UpdatePage(id uint, req UpdatePageRequest) (*models.Page,
 		}
 		return nil, err
 	}
+	wasPublished := page.Status == "published"
 
 	// Update fields
 	if req.Title != "" {
@@ -374,6 +376,9 @@ func (s *PageService) UpdatePage(id uint, req UpdatePageRequest) (*models.Page,
 	if err := s.pageRepo.Update(page); err != nil {
 		return nil, fmt.Errorf("failed to update page: %w", err)
 	}
+	if wasPublished || page.Status == "published" {
+		go publicContentChanged(sitemap.SectionPages, page.ID)
+	}
 
 	return page, nil
 }
@@ -388,7 +393,13 @@ func (s *PageService) DeletePage(id uint) error {
 		return err
 	}
 
-	return s.pageRepo.Delete(page.ID)
+	if err := s.pageRepo.Delete(page.ID); err != nil {
+		return err
+	}
+	if page.Status == "published" {
+		go publicContentChanged(sitemap.SectionPages, page.ID)
+	}
+	return nil
 }
 
 // PublishPage publishes a page
@@ -409,6 +420,7 @@ func (s *PageService) PublishPage(id uint) (*models.Page, error) {
 	if err := s.pageRepo.Update(page); err != nil {
 		return nil, fmt.Errorf("failed to publish page: %w", err)
 	}
+	go publicContentChanged(sitemap.SectionPages, page.ID)
 
 	return page, nil
 }
@@ -430,6 +442,7 @@ func (s *PageService) UnpublishPage(id uint) (*models.Page, error) {
 	if err := s.pageRepo.Update(page); err != nil {
 		return nil, fmt.Errorf("failed to unpublish page: %w", err)
 	}
+	go publicContentChanged(sitemap.SectionPages, page.ID)
 
 	return page, nil
 }
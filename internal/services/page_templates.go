@@ -0,0 +1,460 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"news/internal/models"
+	"news/internal/pagetemplate"
+	"news/internal/repositories"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// PageTemplateRequest represents a request to create a page template
+type PageTemplateRequest struct {
+	Name           string                 `json:"name"`
+	Description    string                 `json:"description"`
+	Category       string                 `json:"category"`
+	Thumbnail      string                 `json:"thumbnail"`
+	PreviewImage   string                 `json:"preview_image"`
+	BlockStructure json.RawMessage        `json:"block_structure" swaggertype:"object"`
+	DefaultStyles  map[string]interface{} `json:"default_styles"`
+	IsPublic       bool                   `json:"is_public"`
+	IsPremium      bool                   `json:"is_premium"`
+	Tags           []string               `json:"tags"`
+	CreatorID      uint                   `json:"-"`
+}
+
+// UpdatePageTemplateRequest represents a request to update a page template; empty fields are left unchanged
+type UpdatePageTemplateRequest struct {
+	Name           string                 `json:"name"`
+	Description    *string                `json:"description"`
+	Category       *string                `json:"category"`
+	Thumbnail      *string                `json:"thumbnail"`
+	PreviewImage   *string                `json:"preview_image"`
+	BlockStructure json.RawMessage        `json:"block_structure" swaggertype:"object"`
+	DefaultStyles  map[string]interface{} `json:"default_styles"`
+	IsPublic       *bool                  `json:"is_public"`
+	IsPremium      *bool                  `json:"is_premium"`
+	Tags           []string               `json:"tags"`
+}
+
+// CreatePageFromTemplateRequest represents a request to create a page from a template
+type CreatePageFromTemplateRequest struct {
+	Title    string `json:"title"`
+	Slug     string `json:"slug"`
+	Template string `json:"template"`
+	Language string `json:"language"`
+	ParentID *uint  `json:"parent_id"`
+	AuthorID uint   `json:"-"`
+}
+
+// SavePageAsTemplateRequest represents a request to save a page as a new template
+type SavePageAsTemplateRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Category    string   `json:"category"`
+	Thumbnail   string   `json:"thumbnail"`
+	IsPublic    bool     `json:"is_public"`
+	Tags        []string `json:"tags"`
+	CreatorID   uint     `json:"-"`
+}
+
+// PaginatedPageTemplatesResponse represents a paginated list of page templates
+type PaginatedPageTemplatesResponse struct {
+	Templates  []models.PageTemplate `json:"templates"`
+	Total      int64                 `json:"total"`
+	Page       int                   `json:"page"`
+	Limit      int                   `json:"limit"`
+	TotalPages int                   `json:"total_pages"`
+}
+
+// templateSortColumns are the columns templates can be sorted by
+var templateSortColumns = map[string]bool{
+	"name": true, "category": true, "usage_count": true, "rating": true, "created_at": true, "updated_at": true,
+}
+
+// ListTemplates lists page templates. Public listings only see public templates.
+func (s *PageService) ListTemplates(filters repositories.PageTemplateFilters, publicOnly bool) (*PaginatedPageTemplatesResponse, error) {
+	filters.SetDefaults()
+	if filters.SortBy != "" && !templateSortColumns[filters.SortBy] {
+		return nil, fmt.Errorf("%w: cannot sort templates by %q", ErrValidation, filters.SortBy)
+	}
+	if publicOnly {
+		public := true
+		filters.IsPublic = &public
+	}
+
+	templates, total, err := s.templateRepo.GetList(filters)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return &PaginatedPageTemplatesResponse{
+		Templates:  templates,
+		Total:      total,
+		Page:       filters.Page,
+		Limit:      filters.Limit,
+		TotalPages: int((total + int64(filters.Limit) - 1) / int64(filters.Limit)),
+	}, nil
+}
+
+// SearchTemplates searches public templates by name, description and tags
+func (s *PageService) SearchTemplates(query string, page, limit int) (*PaginatedPageTemplatesResponse, error) {
+	filters := repositories.PageTemplateFilters{Page: page, Limit: limit}
+	filters.SetDefaults()
+
+	templates, total, err := s.templateRepo.SearchTemplates(strings.TrimSpace(query), filters.Limit, (filters.Page-1)*filters.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return &PaginatedPageTemplatesResponse{
+		Templates:  templates,
+		Total:      total,
+		Page:       filters.Page,
+		Limit:      filters.Limit,
+		TotalPages: int((total + int64(filters.Limit) - 1) / int64(filters.Limit)),
+	}, nil
+}
+
+// GetPopularTemplates returns the most used public templates
+func (s *PageService) GetPopularTemplates(limit int) ([]models.PageTemplate, error) {
+	templates, err := s.templateRepo.GetPopularTemplates(limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return templates, nil
+}
+
+// GetFeaturedTemplates returns the best rated public premium templates
+func (s *PageService) GetFeaturedTemplates(limit int) ([]models.PageTemplate, error) {
+	templates, err := s.templateRepo.GetFeaturedTemplates(limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return templates, nil
+}
+
+// GetTemplateCategories returns the categories of public templates with their template counts
+func (s *PageService) GetTemplateCategories() ([]repositories.TemplateCategory, error) {
+	categories, err := s.templateRepo.GetTemplateCategories()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return categories, nil
+}
+
+// GetTemplate returns a page template. Private templates are not found unless includePrivate is set.
+func (s *PageService) GetTemplate(id uint, includePrivate bool) (*models.PageTemplate, error) {
+	template, err := s.templateRepo.GetByID(id)
+	if err != nil {
+		return nil, templateLookupError(err)
+	}
+	if !template.IsPublic && !includePrivate {
+		return nil, ErrNotFound
+	}
+	return template, nil
+}
+
+// CreateTemplate creates a page template after validating its block structure
+func (s *PageService) CreateTemplate(req PageTemplateRequest) (*models.PageTemplate, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, fmt.Errorf("%w: template name is required", ErrValidation)
+	}
+	structure, err := parseBlockStructure(req.BlockStructure)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &models.PageTemplate{
+		Name:           strings.TrimSpace(req.Name),
+		Description:    req.Description,
+		Category:       req.Category,
+		Thumbnail:      req.Thumbnail,
+		PreviewImage:   req.PreviewImage,
+		BlockStructure: structure,
+		DefaultStyles:  marshalJSONField(req.DefaultStyles),
+		IsPublic:       req.IsPublic,
+		IsPremium:      req.IsPremium,
+		Tags:           marshalTags(req.Tags),
+		CreatorID:      req.CreatorID,
+	}
+	if err := s.templateRepo.Create(template); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return template, nil
+}
+
+// UpdateTemplate updates a page template
+func (s *PageService) UpdateTemplate(id uint, req UpdatePageTemplateRequest) (*models.PageTemplate, error) {
+	template, err := s.templateRepo.GetByID(id)
+	if err != nil {
+		return nil, templateLookupError(err)
+	}
+
+	if name := strings.TrimSpace(req.Name); name != "" {
+		template.Name = name
+	}
+	if req.Description != nil {
+		template.Description = *req.Description
+	}
+	if req.Category != nil {
+		template.Category = *req.Category
+	}
+	if req.Thumbnail != nil {
+		template.Thumbnail = *req.Thumbnail
+	}
+	if req.PreviewImage != nil {
+		template.PreviewImage = *req.PreviewImage
+	}
+	if len(req.BlockStructure) > 0 {
+		structure, err := parseBlockStructure(req.BlockStructure)
+		if err != nil {
+			return nil, err
+		}
+		template.BlockStructure = structure
+	}
+	if req.DefaultStyles != nil {
+		template.DefaultStyles = marshalJSONField(req.DefaultStyles)
+	}
+	if req.IsPublic != nil {
+		template.IsPublic = *req.IsPublic
+	}
+	if req.IsPremium != nil {
+		template.IsPremium = *req.IsPremium
+	}
+	if req.Tags != nil {
+		template.Tags = marshalTags(req.Tags)
+	}
+
+	// Save writes associations too; the creator is not edited here
+	template.Creator = models.User{}
+	if err := s.templateRepo.Update(template); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return s.templateRepo.GetByID(id)
+}
+
+// DeleteTemplate deletes a page template; pages created from it keep their blocks
+func (s *PageService) DeleteTemplate(id uint) error {
+	if _, err := s.templateRepo.GetByID(id); err != nil {
+		return templateLookupError(err)
+	}
+	if err := s.templateRepo.Delete(id); err != nil {
+		return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return nil
+}
+
+// DuplicateTemplate copies a template as a new private template of the user
+func (s *PageService) DuplicateTemplate(id, creatorID uint, name string) (*models.PageTemplate, error) {
+	original, err := s.templateRepo.GetByID(id)
+	if err != nil {
+		return nil, templateLookupError(err)
+	}
+	if name = strings.TrimSpace(name); name == "" {
+		name = original.Name + " (Copy)"
+	}
+
+	template, err := s.templateRepo.DuplicateTemplate(id, creatorID, name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return template, nil
+}
+
+// RateTemplate sets the rating of a template, from 0 to 5
+func (s *PageService) RateTemplate(id uint, rating float64) (*models.PageTemplate, error) {
+	if rating < 0 || rating > 5 {
+		return nil, fmt.Errorf("%w: rating must be between 0 and 5", ErrValidation)
+	}
+	if _, err := s.templateRepo.GetByID(id); err != nil {
+		return nil, templateLookupError(err)
+	}
+	if err := s.templateRepo.UpdateRating(id, rating); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return s.templateRepo.GetByID(id)
+}
+
+// CreatePageFromTemplate creates a draft page with the template's block tree and counts the
+// use of the template. The page, its blocks and the usage count are written in one transaction.
+func (s *PageService) CreatePageFromTemplate(templateID uint, req CreatePageFromTemplateRequest) (*models.Page, error) {
+	template, err := s.templateRepo.GetByID(templateID)
+	if err != nil {
+		return nil, templateLookupError(err)
+	}
+	structure, err := pagetemplate.Parse(template.BlockStructure)
+	if err == nil {
+		err = structure.Validate()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: template %d: %v", ErrValidation, templateID, err)
+	}
+
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		title = template.Name
+	}
+	if len(title) < 3 {
+		return nil, fmt.Errorf("%w: title must be at least 3 characters", ErrValidation)
+	}
+	// The page remembers its template and carries the template's default styles
+	layout := map[string]interface{}{"page_template_id": template.ID}
+	if len(template.DefaultStyles) > 0 {
+		layout["default_styles"] = json.RawMessage(template.DefaultStyles)
+	}
+	page := &models.Page{
+		Title:      title,
+		Slug:       req.Slug,
+		Template:   firstNonEmpty(req.Template, "default"),
+		Layout:     firstNonEmpty(structure.Layout, "container"),
+		Status:     "draft",
+		Language:   firstNonEmpty(req.Language, "tr"),
+		ParentID:   req.ParentID,
+		AuthorID:   req.AuthorID,
+		LayoutData: marshalJSONField(layout),
+	}
+	if !page.ValidateTemplate() {
+		return nil, fmt.Errorf("%w: invalid page template %q", ErrValidation, page.Template)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := repositories.NewPageRepository(tx).Create(page); err != nil {
+			return err
+		}
+		if err := createBlockTree(repositories.NewPageContentBlockRepository(tx), page.ID, nil, structure.Blocks); err != nil {
+			return err
+		}
+		return repositories.NewPageTemplateRepository(tx).IncrementUsage(template.ID)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create page from template: %w", err)
+	}
+
+	return s.pageRepo.GetByID(page.ID, true)
+}
+
+// SavePageAsTemplate saves the blocks of a page, hidden ones included, as a new template
+func (s *PageService) SavePageAsTemplate(pageID uint, req SavePageAsTemplateRequest) (*models.PageTemplate, error) {
+	page, err := s.pageRepo.GetByID(pageID, false)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	var blocks []models.PageContentBlock
+	if err := s.db.Where("page_id = ?", page.ID).Order("position ASC").Find(&blocks).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	structure := pagetemplate.Structure{Blocks: pagetemplate.FromBlocks(blocks), Layout: page.Layout}
+	if err := structure.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: page %d: %v", ErrValidation, page.ID, err)
+	}
+	raw, err := structure.JSON()
+	if err != nil {
+		return nil, err
+	}
+
+	var defaultStyles map[string]interface{}
+	if len(page.LayoutData) > 0 {
+		var layout struct {
+			DefaultStyles map[string]interface{} `json:"default_styles"`
+		}
+		if json.Unmarshal(page.LayoutData, &layout) == nil {
+			defaultStyles = layout.DefaultStyles
+		}
+	}
+
+	return s.CreateTemplate(PageTemplateRequest{
+		Name:           firstNonEmpty(strings.TrimSpace(req.Name), page.Title),
+		Description:    firstNonEmpty(req.Description, page.ExcerptText),
+		Category:       req.Category,
+		Thumbnail:      firstNonEmpty(req.Thumbnail, page.FeaturedImage),
+		BlockStructure: raw,
+		DefaultStyles:  defaultStyles,
+		IsPublic:       req.IsPublic,
+		Tags:           req.Tags,
+		CreatorID:      req.CreatorID,
+	})
+}
+
+// createBlockTree creates the blocks of a template under a container, children after their
+// container so they can point at it
+func createBlockTree(repo *repositories.PageContentBlockRepository, pageID uint, containerID *uint, blocks []pagetemplate.Block) error {
+	for i, node := range blocks {
+		block := &models.PageContentBlock{
+			PageID:         pageID,
+			ContainerID:    containerID,
+			BlockType:      node.BlockType,
+			Content:        node.Content,
+			Settings:       marshalJSONField(node.Settings),
+			Styles:         marshalJSONField(node.Styles),
+			Position:       i + 1,
+			IsVisible:      node.Visible(),
+			IsContainer:    node.IsContainer || len(node.Children) > 0,
+			ContainerType:  node.ContainerType,
+			GridSettings:   marshalJSONField(node.GridSettings),
+			ResponsiveData: marshalJSONField(node.ResponsiveData),
+		}
+		if err := repo.Create(block); err != nil {
+			return fmt.Errorf("failed to create %s block: %w", node.BlockType, err)
+		}
+		if len(node.Children) > 0 {
+			if err := createBlockTree(repo, pageID, &block.ID, node.Children); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseBlockStructure validates a block structure from a request and writes it in the block format
+func parseBlockStructure(raw json.RawMessage) (datatypes.JSON, error) {
+	structure, err := pagetemplate.Parse(raw)
+	if err == nil {
+		err = structure.Validate()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	normalized, err := structure.JSON()
+	if err != nil {
+		return nil, err
+	}
+	return datatypes.JSON(normalized), nil
+}
+
+func marshalJSONField(values map[string]interface{}) datatypes.JSON {
+	if len(values) == 0 {
+		return nil
+	}
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return nil
+	}
+	return datatypes.JSON(raw)
+}
+
+func marshalTags(tags []string) datatypes.JSON {
+	cleaned := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			cleaned = append(cleaned, tag)
+		}
+	}
+	raw, _ := json.Marshal(cleaned)
+	return datatypes.JSON(raw)
+}
+
+func templateLookupError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+}
@@ -0,0 +1,317 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"news/internal/config"
+	"news/internal/database"
+	"news/internal/models"
+	"news/internal/preview"
+	"news/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrPreviewTokenNotFound is returned when a preview token record does not exist
+	ErrPreviewTokenNotFound = errors.New("preview token not found")
+	// ErrPreviewTokenInvalid is returned when a preview token is forged, expired, revoked, used
+	// up or issued for other content
+	ErrPreviewTokenInvalid = errors.New("invalid or expired preview token")
+	// ErrPreviewTokenForbidden is returned when an author asks for a preview of content they did not write
+	ErrPreviewTokenForbidden = errors.New("not allowed to preview this content")
+)
+
+// PreviewTokenRequest represents the request to issue a preview token
+type PreviewTokenRequest struct {
+	EntityType string `json:"entity_type" binding:"required"` // article or page
+	EntityID   uint   `json:"entity_id" binding:"required"`
+	// ExpiresInHours defaults to PREVIEW_TOKEN_TTL_HOURS
+	ExpiresInHours int    `json:"expires_in_hours"`
+	SingleUse      bool   `json:"single_use"`
+	Note           string `json:"note"`
+}
+
+// PreviewTokenSecretResponse returns a preview token record with the token itself and the path
+// that opens the draft. The token is only shown when it is issued.
+type PreviewTokenSecretResponse struct {
+	models.PreviewToken
+	Token       string `json:"token"`
+	PreviewPath string `json:"preview_path"`
+}
+
+// PreviewTokenListResponse represents a paginated list of preview tokens
+type PreviewTokenListResponse struct {
+	Tokens     []models.PreviewToken `json:"tokens"`
+	Total      int64                 `json:"total"`
+	Page       int                   `json:"page"`
+	Limit      int                   `json:"limit"`
+	TotalPages int                   `json:"total_pages"`
+}
+
+// PreviewTokenService issues, redeems and revokes draft preview tokens
+type PreviewTokenService struct {
+	db  *gorm.DB
+	cfg *config.PreviewConfig
+}
+
+var (
+	previewTokenInstance *PreviewTokenService
+	previewTokenOnce     sync.Once
+)
+
+// NewPreviewTokenService creates a preview token service
+func NewPreviewTokenService(db *gorm.DB, cfg *config.PreviewConfig) *PreviewTokenService {
+	return &PreviewTokenService{db: db, cfg: cfg}
+}
+
+// GetPreviewTokenService returns the preview token service
+func GetPreviewTokenService() *PreviewTokenService {
+	previewTokenOnce.Do(func() {
+		previewTokenInstance = NewPreviewTokenService(database.DB, config.GetPreviewConfig())
+	})
+	return previewTokenInstance
+}
+
+// Issue creates a preview token for an article or page, in any status, and returns the token
+// once. With ownOnly, the content must be written by the caller.
+func (s *PreviewTokenService) Issue(req PreviewTokenRequest, createdBy uint, ownOnly bool) (*PreviewTokenSecretResponse, error) {
+	if s.cfg.Secret == "" {
+		return nil, errors.New("preview tokens need PREVIEW_TOKEN_SECRET or JWT_SECRET to be set")
+	}
+	if !models.IsValidPreviewEntity(req.EntityType) {
+		return nil, fmt.Errorf("%w: entity_type must be article or page", ErrValidation)
+	}
+	ttl := s.cfg.DefaultTTL
+	if req.ExpiresInHours < 0 {
+		return nil, fmt.Errorf("%w: expires_in_hours must be positive", ErrValidation)
+	}
+	if req.ExpiresInHours > 0 {
+		ttl = time.Duration(req.ExpiresInHours) * time.Hour
+	}
+	if ttl > s.cfg.MaxTTL {
+		return nil, fmt.Errorf("%w: preview tokens expire after at most %d hours", ErrValidation, int(s.cfg.MaxTTL.Hours()))
+	}
+	if len(req.Note) > 255 {
+		return nil, fmt.Errorf("%w: note must be at most 255 characters", ErrValidation)
+	}
+
+	authorID := uint(0)
+	if ownOnly {
+		authorID = createdBy
+	}
+	path, err := s.previewPath(req.EntityType, req.EntityID, authorID)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenID, err := preview.NewID()
+	if err != nil {
+		return nil, err
+	}
+	// Expiry is kept to the second, as in the signed claims
+	record := models.PreviewToken{
+		TokenID:    tokenID,
+		EntityType: req.EntityType,
+		EntityID:   req.EntityID,
+		Note:       req.Note,
+		SingleUse:  req.SingleUse,
+		ExpiresAt:  time.Now().Add(ttl).Truncate(time.Second),
+		CreatedBy:  createdBy,
+	}
+	token, err := preview.Sign(preview.Claims{
+		ID:         tokenID,
+		EntityType: record.EntityType,
+		EntityID:   record.EntityID,
+		ExpiresAt:  record.ExpiresAt.Unix(),
+	}, []byte(s.cfg.Secret))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Omit("Creator").Create(&record).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	return &PreviewTokenSecretResponse{
+		PreviewToken: record,
+		Token:        token,
+		PreviewPath:  path + "?preview_token=" + url.QueryEscape(token),
+	}, nil
+}
+
+// List lists preview tokens, optionally only those of one entity. Unless includeInactive is set,
+// only tokens that can still be used are listed.
+func (s *PreviewTokenService) List(entityType string, entityID uint, includeInactive bool, page, limit int) (*PreviewTokenListResponse, error) {
+	query := s.db.Model(&models.PreviewToken{})
+	if entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+	if entityID != 0 {
+		query = query.Where("entity_id = ?", entityID)
+	}
+	if !includeInactive {
+		query = query.Where("revoked = ? AND expires_at > ? AND NOT (single_use = ? AND use_count > 0)", false, time.Now(), true)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	var tokens []models.PreviewToken
+	if err := query.Preload("Creator").Order("id DESC").Offset((page - 1) * limit).Limit(limit).Find(&tokens).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	return &PreviewTokenListResponse{
+		Tokens:     tokens,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+	}, nil
+}
+
+// Revoke stops a preview token from opening its draft
+func (s *PreviewTokenService) Revoke(id uint) (*models.PreviewToken, error) {
+	var token models.PreviewToken
+	if err := s.db.First(&token, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPreviewTokenNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	if token.Revoked {
+		return &token, nil
+	}
+
+	if err := s.db.Model(&token).Updates(map[string]interface{}{"revoked": true, "revoked_at": time.Now()}).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	if err := s.db.First(&token, id).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return &token, nil
+}
+
+// Redeem checks that a token opens the given entity and counts its use. A single-use token
+// is only accepted once, even under concurrent requests.
+func (s *PreviewTokenService) Redeem(raw, entityType string, entityID uint) (*models.PreviewToken, error) {
+	token, err := s.verify(raw, entityType, entityID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.use(token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// verify checks that a token opens the given entity without counting a use
+func (s *PreviewTokenService) verify(raw, entityType string, entityID uint) (*models.PreviewToken, error) {
+	if s.cfg.Secret == "" {
+		return nil, ErrPreviewTokenInvalid
+	}
+	now := time.Now()
+	claims, err := preview.Verify(raw, []byte(s.cfg.Secret), now)
+	if err != nil || claims.EntityType != entityType || claims.EntityID != entityID {
+		return nil, ErrPreviewTokenInvalid
+	}
+
+	var token models.PreviewToken
+	if err := s.db.Where("token_id = ?", claims.ID).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPreviewTokenInvalid
+		}
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	if !token.IsActive(now) {
+		return nil, ErrPreviewTokenInvalid
+	}
+	return &token, nil
+}
+
+// use counts a use of a verified token, failing if it was revoked or, when single use, used
+// since it was verified
+func (s *PreviewTokenService) use(token *models.PreviewToken) error {
+	use := s.db.Model(&models.PreviewToken{}).Where("id = ? AND revoked = ?", token.ID, false)
+	if token.SingleUse {
+		use = use.Where("use_count = 0")
+	}
+	result := use.Updates(map[string]interface{}{
+		"use_count":    gorm.Expr("use_count + 1"),
+		"last_used_at": time.Now(),
+	})
+	if result.Error != nil {
+		return fmt.Errorf("%w: %v", ErrDatabaseError, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrPreviewTokenInvalid
+	}
+	return nil
+}
+
+// PreviewArticle returns an article in any status for a valid preview token, read from the
+// database rather than the public caches. The token is only used up once the article has
+// loaded, so a failed request can be retried with a single-use token.
+func (s *PreviewTokenService) PreviewArticle(raw string, id uint, withBlocks bool) (*models.Article, error) {
+	token, err := s.verify(raw, models.PreviewEntityArticle, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var article models.Article
+	if err := s.db.Preload("Author").Preload("Categories").Preload("Tags").First(&article, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	if withBlocks && article.IsUsingBlocks() {
+		blocks, err := repositories.NewArticleContentBlockRepository(s.db).GetVisibleBlocksByArticleID(article.ID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+		}
+		article.ContentBlocks = blocks
+	}
+
+	if err := s.use(token); err != nil {
+		return nil, err
+	}
+	return &article, nil
+}
+
+// previewPath returns the API path that shows the entity, checking that it exists and, when
+// authorID is set, that it was written by that user
+func (s *PreviewTokenService) previewPath(entityType string, id, authorID uint) (string, error) {
+	if entityType == models.PreviewEntityPage {
+		var page models.Page
+		if err := s.db.Select("id", "slug", "author_id").First(&page, id).Error; err != nil {
+			return "", previewEntityError(err)
+		}
+		if authorID != 0 && page.AuthorID != authorID {
+			return "", fmt.Errorf("%w: authors can only share previews of their own content", ErrPreviewTokenForbidden)
+		}
+		return "/api/pages/slug/" + url.PathEscape(page.Slug), nil
+	}
+
+	var article models.Article
+	if err := s.db.Select("id", "author_id").First(&article, id).Error; err != nil {
+		return "", previewEntityError(err)
+	}
+	if authorID != 0 && article.AuthorID != authorID {
+		return "", fmt.Errorf("%w: authors can only share previews of their own content", ErrPreviewTokenForbidden)
+	}
+	return fmt.Sprintf("/api/articles/%d", article.ID), nil
+}
+
+func previewEntityError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: content does not exist", ErrValidation)
+	}
+	return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+}
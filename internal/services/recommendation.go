@@ -2,17 +2,39 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
+	"time"
+
 	"news/internal/models"
+	"news/internal/recommend"
 	"news/internal/tracing"
-	"time"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"gorm.io/gorm"
 )
 
+const (
+	recommendationProfileWindow   = 180 * 24 * time.Hour // Signals older than this are ignored
+	recommendationCandidateWindow = 30 * 24 * time.Hour  // Only articles published this recently are recommended
+	recommendationPopularWindow   = 7 * 24 * time.Hour
+	maxProfileSignals             = 500
+	maxRecommendationCandidates   = 300
+	profileTopFeatures            = 20
+)
+
+// RecommendedArticle is a recommended article with its score and why it was recommended
+type RecommendedArticle struct {
+	Article     models.Article `json:"article"`
+	Score       float64        `json:"score"`
+	Reason      string         `json:"reason"` // semantic, category, tag, author or popular
+	Explanation string         `json:"explanation"`
+}
+
 // RecommendationService handles article recommendation logic
 type RecommendationService struct {
 	db *gorm.DB
@@ -24,121 +46,97 @@ func NewRecommendationService(db *gorm.DB) *RecommendationService {
 }
 
 // GetPersonalizedRecommendations returns recommendations based on user's reading history and preferences
-func (rs *RecommendationService) GetPersonalizedRecommendations(userID uint, limit int) ([]models.Article, error) {
+func (rs *RecommendationService) GetPersonalizedRecommendations(userID uint, limit int) ([]RecommendedArticle, error) {
 	return rs.GetPersonalizedRecommendationsWithContext(context.Background(), userID, limit)
 }
 
-// GetPersonalizedRecommendationsWithContext returns recommendations with tracing context
-func (rs *RecommendationService) GetPersonalizedRecommendationsWithContext(ctx context.Context, userID uint, limit int) ([]models.Article, error) {
+// GetPersonalizedRecommendationsWithContext scores recently published articles by the user's
+// category, tag and author affinities. Affinities are built from interactions, bookmarks,
+// votes and follows, decaying with age. Articles the user has already read are excluded, and
+// users without history get popular articles instead.
+func (rs *RecommendationService) GetPersonalizedRecommendationsWithContext(ctx context.Context, userID uint, limit int) ([]RecommendedArticle, error) {
 	ctx, span := tracing.StartSpanWithAttributes(ctx, "RecommendationService.GetPersonalizedRecommendations",
 		attribute.Int("user.id", int(userID)),
 		attribute.Int("limit", limit))
 	defer span.End()
 
-	var articles []models.Article
-
-	// Complex recommendation query that considers:
-	// 1. User's category preferences (based on reading history)
-	// 2. Articles they haven't read yet
-	// 3. Popular articles from categories they like
-	// 4. Recent articles to keep recommendations fresh
-	query := `
-		SELECT DISTINCT a.* FROM articles a
-		JOIN categories c ON a.category_id = c.id
-		WHERE a.status = 'published' 
-		AND a.id NOT IN (
-			-- Exclude articles already read by user
-			SELECT DISTINCT article_id FROM user_article_interactions 
-			WHERE user_id = ? AND interaction_type IN ('view', 'bookmark')
-		)
-		AND (
-			-- Articles from categories user has interacted with
-			c.id IN (
-				SELECT DISTINCT cat.id FROM categories cat
-				JOIN articles art ON cat.id = art.category_id
-				JOIN user_article_interactions uai ON art.id = uai.article_id
-				WHERE uai.user_id = ? AND uai.interaction_type IN ('view', 'bookmark', 'upvote')
-			)
-			-- OR popular articles from last 7 days
-			OR a.id IN (
-				SELECT article_id FROM user_article_interactions
-				WHERE interaction_type = 'upvote' 
-				AND created_at > ?
-				GROUP BY article_id
-				HAVING COUNT(*) >= 2
-			)
-		)
-		ORDER BY 
-			-- Prioritize articles from preferred categories
-			CASE WHEN c.id IN (
-				SELECT DISTINCT cat.id FROM categories cat
-				JOIN articles art ON cat.id = art.category_id
-				JOIN user_article_interactions uai ON art.id = uai.article_id
-				WHERE uai.user_id = ? AND uai.interaction_type IN ('view', 'bookmark', 'upvote')
-			) THEN 1 ELSE 2 END,
-			-- Then by recency
-			a.published_at DESC
-		LIMIT ?
-	`
-
-	weekAgo := time.Now().AddDate(0, 0, -7)
+	if userID == 0 {
+		span.AddEvent("Anonymous user, using popular recommendations")
+		return rs.popularRecommendations(ctx, limit, nil)
+	}
 
-	// Trace the database query
-	ctx, dbSpan := tracing.StartSpanWithAttributes(ctx, "DB.RecommendationQuery",
-		attribute.String("db.type", "raw_sql"),
-		attribute.String("db.operation", "recommendation_query"),
-		attribute.Int("user.id", int(userID)),
-		attribute.String("db.timeframe", "7days"))
+	now := time.Now()
 
-	err := rs.db.Raw(query, userID, userID, weekAgo, userID, limit).Scan(&articles).Error
+	profile, err := rs.buildProfile(ctx, userID, now)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
 
+	read, err := rs.readArticleIDs(ctx, userID)
 	if err != nil {
-		dbSpan.RecordError(err)
-		dbSpan.SetStatus(codes.Error, err.Error())
-		dbSpan.End()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
 
-		log.Printf("Error getting personalized recommendations: %v", err)
-		span.AddEvent("Falling back to popular recommendations")
+	if profile.Empty() {
+		span.AddEvent("Cold start, using popular recommendations")
+		return rs.popularRecommendations(ctx, limit, read)
+	}
 
-		// Fallback to popular articles if personalized query fails
-		return rs.GetPopularRecommendationsWithContext(ctx, limit)
+	candidates, err := rs.personalizedCandidates(ctx, profile, read, now)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
 	}
 
-	dbSpan.SetAttributes(attribute.Int("result.count", len(articles)))
-	dbSpan.End()
+	popularity, err := rs.articlePopularity(ctx, articleIDs(candidates), now.Add(-recommendationPopularWindow))
+	if err != nil {
+		log.Printf("Error loading article popularity for recommendations: %v", err)
+	}
 
-	// If we don't have enough personalized recommendations, fill with popular ones
-	if len(articles) < limit {
-		remaining := limit - len(articles)
-
-		span.AddEvent("Filling with popular recommendations")
-		span.SetAttributes(
-			attribute.Int("current_count", len(articles)),
-			attribute.Int("requested_limit", limit),
-			attribute.Int("remaining_needed", remaining))
-
-		popularArticles, err := rs.GetPopularRecommendationsWithContext(ctx, remaining)
-		if err == nil {
-			// Remove duplicates and add popular articles
-			existingIDs := make(map[uint]bool)
-			for _, article := range articles {
-				existingIDs[article.ID] = true
-			}
+	recommendations := make([]RecommendedArticle, 0, len(candidates))
+	for _, article := range candidates {
+		match := profile.Match(articleFeatures(&article))
+		if match.Affinity <= 0 {
+			continue
+		}
+		publishedAt := article.CreatedAt
+		if article.PublishedAt != nil {
+			publishedAt = *article.PublishedAt
+		}
+		recommendations = append(recommendations, RecommendedArticle{
+			Article:     article,
+			Score:       recommend.Score(match.Affinity, publishedAt, now, popularity[article.ID]),
+			Reason:      match.Reason,
+			Explanation: match.Explanation,
+		})
+	}
+	sortRecommendations(recommendations)
+	if len(recommendations) > limit {
+		recommendations = recommendations[:limit]
+	}
 
-			for _, popular := range popularArticles {
-				if !existingIDs[popular.ID] && len(articles) < limit {
-					articles = append(articles, popular)
-				}
-			}
+	span.SetAttributes(
+		attribute.Int("candidate_count", len(candidates)),
+		attribute.Int("personalized_count", len(recommendations)))
 
-			span.SetAttributes(attribute.Int("final_count", len(articles)))
-		} else {
+	// Fill up with popular articles when the affinities do not match enough candidates
+	if len(recommendations) < limit {
+		exclude := append(append([]uint{}, read...), recommendedIDs(recommendations)...)
+		popular, err := rs.popularRecommendations(ctx, limit-len(recommendations), exclude)
+		if err != nil {
 			span.RecordError(err)
 			span.AddEvent("Failed to get popular recommendations")
+		} else {
+			recommendations = append(recommendations, popular...)
 		}
 	}
 
-	return articles, nil
+	return recommendations, nil
 }
 
 // GetPopularRecommendations returns trending/popular articles
@@ -146,51 +144,23 @@ func (rs *RecommendationService) GetPopularRecommendations(limit int) ([]models.
 	return rs.GetPopularRecommendationsWithContext(context.Background(), limit)
 }
 
-// GetPopularRecommendationsWithContext returns trending/popular articles with tracing context
+// GetPopularRecommendationsWithContext returns the published articles with the most
+// engagement in the last 7 days, topped up with the most recent articles
 func (rs *RecommendationService) GetPopularRecommendationsWithContext(ctx context.Context, limit int) ([]models.Article, error) {
 	ctx, span := tracing.StartSpanWithAttributes(ctx, "RecommendationService.GetPopularRecommendations",
 		attribute.Int("limit", limit))
 	defer span.End()
 
-	var articles []models.Article
-
-	// Get articles with most interactions in the last 7 days
-	query := `
-		SELECT a.*, COUNT(uai.id) as interaction_count
-		FROM articles a
-		LEFT JOIN user_article_interactions uai ON a.id = uai.article_id 
-			AND uai.created_at > ? 
-			AND uai.interaction_type IN ('view', 'upvote', 'bookmark')
-		WHERE a.status = 'published'
-		GROUP BY a.id
-		ORDER BY interaction_count DESC, a.published_at DESC
-		LIMIT ?
-	`
-
-	weekAgo := time.Now().AddDate(0, 0, -7)
-
-	// Trace the database query
-	_, dbSpan := tracing.StartSpanWithAttributes(ctx, "DB.PopularArticlesQuery",
-		attribute.String("db.type", "raw_sql"),
-		attribute.String("db.operation", "popular_articles_query"),
-		attribute.String("db.timeframe", "7days"),
-		attribute.Int("limit", limit))
-
-	err := rs.db.Raw(query, weekAgo, limit).Scan(&articles).Error
-
-	if err != nil {
-		dbSpan.RecordError(err)
-		dbSpan.SetStatus(codes.Error, err.Error())
-	} else {
-		dbSpan.SetAttributes(attribute.Int("result.count", len(articles)))
-	}
-	dbSpan.End()
+	articles, err := rs.popularArticles(ctx, limit, nil)
 	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		log.Printf("Error getting popular recommendations: %v", err)
 		// Final fallback to just recent articles
 		return rs.GetRecentRecommendations(limit)
 	}
 
+	span.SetAttributes(attribute.Int("result.count", len(articles)))
 	return articles, nil
 }
 
@@ -206,62 +176,129 @@ func (rs *RecommendationService) GetRecentRecommendations(limit int) ([]models.A
 	return articles, err
 }
 
-// GetSimilarArticles returns articles similar to a given article based on category and tags
-func (rs *RecommendationService) GetSimilarArticles(articleID uint, limit int) ([]models.Article, error) {
-	var articles []models.Article
+// GetSimilarArticles returns the published articles closest to the given article. Articles
+// with a similar embedding come first, followed by articles sharing tags, categories or the
+// author, topped up with popular articles.
+func (rs *RecommendationService) GetSimilarArticles(articleID uint, limit int) ([]RecommendedArticle, error) {
+	ctx := context.Background()
 
-	// First, get the source article to understand its properties
-	var sourceArticle models.Article
-	err := rs.db.First(&sourceArticle, articleID).Error
-	if err != nil {
-		return nil, fmt.Errorf("source article not found: %w", err)
+	var source models.Article
+	if err := rs.db.Preload("Categories").Preload("Tags").First(&source, articleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
 	}
 
-	// Find similar articles based on:
-	// 1. Same category
-	// 2. Exclude the source article itself
-	// 3. Order by recency and popularity
-	query := `
-		SELECT a.*, COUNT(uai.id) as interaction_count
-		FROM articles a
-		LEFT JOIN user_article_interactions uai ON a.id = uai.article_id 
-			AND uai.interaction_type IN ('view', 'upvote', 'bookmark')
-		WHERE a.status = 'published'
-		AND a.id != ?
-		AND (
-			a.category_id = ? 
-			OR a.title LIKE ?
-		)
-		GROUP BY a.id
-		ORDER BY 
-			CASE WHEN a.category_id = ? THEN 1 ELSE 2 END,
-			interaction_count DESC, 
-			a.published_at DESC
-		LIMIT ?
-	`
+	similar, err := rs.semanticallySimilar(ctx, &source, limit)
+	if err != nil && !errors.Is(err, ErrEmbeddingNotFound) {
+		log.Printf("Error getting semantically similar articles for %d: %v", source.ID, err)
+	}
 
-	// Simple keyword matching for title similarity
-	titlePattern := fmt.Sprintf("%%%s%%", extractKeywords(sourceArticle.Title))
+	if len(similar) < limit {
+		exclude := append([]uint{source.ID}, recommendedIDs(similar)...)
+		related, err := rs.relatedByTaxonomy(ctx, &source, limit-len(similar), exclude)
+		if err != nil {
+			return nil, err
+		}
+		similar = append(similar, related...)
+	}
 
-	err = rs.db.Raw(query, articleID, sourceArticle.Categories[0].ID, titlePattern, sourceArticle.Categories[0].ID, limit).Scan(&articles).Error
-	if err != nil {
-		log.Printf("Error getting similar articles: %v", err)
-		// Fallback to just same category articles
-		err = rs.db.Where("category_id = ? AND id != ? AND status = ?",
-			sourceArticle.Categories[0].ID, articleID, "published").
-			Order("published_at DESC").
-			Limit(limit).
-			Find(&articles).Error
+	if len(similar) < limit {
+		exclude := append([]uint{source.ID}, recommendedIDs(similar)...)
+		popular, err := rs.popularRecommendations(ctx, limit-len(similar), exclude)
+		if err != nil {
+			log.Printf("Error filling similar articles with popular ones: %v", err)
+		} else {
+			similar = append(similar, popular...)
+		}
 	}
 
-	return articles, err
+	return similar, nil
+}
+
+// semanticallySimilar returns the articles whose embedding is closest to the source article's
+func (rs *RecommendationService) semanticallySimilar(ctx context.Context, source *models.Article, limit int) ([]RecommendedArticle, error) {
+	matches, err := GetEmbeddingService().SimilarArticleIDs(ctx, source.ID, limit)
+	if err != nil || len(matches) == 0 {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(matches))
+	for _, match := range matches {
+		ids = append(ids, match.ID)
+	}
+	var articles []models.Article
+	if err := rs.articleQuery(ctx).Where("id IN ?", ids).Find(&articles).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	byID := make(map[uint]models.Article, len(articles))
+	for _, article := range articles {
+		byID[article.ID] = article
+	}
+
+	similar := make([]RecommendedArticle, 0, len(matches))
+	for _, match := range matches {
+		article, ok := byID[match.ID]
+		if !ok {
+			continue
+		}
+		similar = append(similar, RecommendedArticle{
+			Article:     article,
+			Score:       match.Score,
+			Reason:      recommend.ReasonSemantic,
+			Explanation: "Similar to \"" + source.Title + "\"",
+		})
+	}
+	return similar, nil
+}
+
+// relatedByTaxonomy returns published articles sharing tags, categories or the author with the
+// source article, closest first
+func (rs *RecommendationService) relatedByTaxonomy(ctx context.Context, source *models.Article, limit int, exclude []uint) ([]RecommendedArticle, error) {
+	sourceFeatures := articleFeatures(source)
+
+	var candidates []models.Article
+	if err := rs.articleQuery(ctx).
+		Where("id NOT IN ?", exclude).
+		Where(matchingFeaturesCondition(rs.db, sourceFeatures.CategoryIDs, sourceFeatures.TagIDs, []uint{source.AuthorID})).
+		Order("published_at DESC").
+		Limit(maxRecommendationCandidates).
+		Find(&candidates).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	now := time.Now()
+	related := make([]RecommendedArticle, 0, len(candidates))
+	for _, candidate := range candidates {
+		score, reason, shared := recommend.Similarity(sourceFeatures, articleFeatures(&candidate))
+		if score <= 0 {
+			continue
+		}
+		publishedAt := candidate.CreatedAt
+		if candidate.PublishedAt != nil {
+			publishedAt = *candidate.PublishedAt
+		}
+		related = append(related, RecommendedArticle{
+			Article:     candidate,
+			Score:       recommend.Score(score, publishedAt, now, 0),
+			Reason:      reason,
+			Explanation: similarityExplanation(source, reason, shared),
+		})
+	}
+	sortRecommendations(related)
+	if len(related) > limit {
+		related = related[:limit]
+	}
+	return related, nil
 }
 
 // GetCategoryRecommendations returns popular articles from a specific category
 func (rs *RecommendationService) GetCategoryRecommendations(categoryID uint, limit int, excludeArticleIDs []uint) ([]models.Article, error) {
 	var articles []models.Article
 
-	query := rs.db.Where("category_id = ? AND status = ?", categoryID, "published")
+	query := rs.db.Where("status = ?", "published").
+		Where("id IN (?)", rs.db.Table("article_categories").Select("article_id").Where("category_id = ?", categoryID))
 
 	if len(excludeArticleIDs) > 0 {
 		query = query.Where("id NOT IN ?", excludeArticleIDs)
@@ -271,16 +308,6 @@ func (rs *RecommendationService) GetCategoryRecommendations(categoryID uint, lim
 	return articles, err
 }
 
-// extractKeywords extracts simple keywords from a title for similarity matching
-func extractKeywords(title string) string {
-	// This is a simple implementation - in production, you might want to use
-	// more sophisticated NLP techniques or external services
-	if len(title) > 20 {
-		return title[:20] // Take first 20 characters as a simple keyword
-	}
-	return title
-}
-
 // GetUserReadingHistory returns articles the user has interacted with
 func (rs *RecommendationService) GetUserReadingHistory(userID uint, limit int) ([]models.Article, error) {
 	var articles []models.Article
@@ -298,3 +325,357 @@ func (rs *RecommendationService) GetUserReadingHistory(userID uint, limit int) (
 	err := rs.db.Raw(query, userID, limit).Scan(&articles).Error
 	return articles, err
 }
+
+// recommendationSignal is one thing a user did with an article
+type recommendationSignal struct {
+	ArticleID      uint
+	Type           string
+	CompletionRate *float64
+	CreatedAt      time.Time
+}
+
+// buildProfile turns the user's recent interactions, bookmarks, votes and follows into
+// category, tag and author affinities
+func (rs *RecommendationService) buildProfile(ctx context.Context, userID uint, now time.Time) (*recommend.Profile, error) {
+	ctx, span := tracing.StartSpanWithAttributes(ctx, "DB.RecommendationProfile",
+		attribute.Int("user.id", int(userID)))
+	defer span.End()
+
+	since := now.Add(-recommendationProfileWindow)
+	db := rs.db.WithContext(ctx)
+
+	var interactions, bookmarks, votes []recommendationSignal
+	if err := db.Model(&models.UserArticleInteraction{}).
+		Select("article_id, interaction_type AS type, completion_rate, created_at").
+		Where("user_id = ? AND created_at > ?", userID, since).
+		Order("created_at DESC").
+		Limit(maxProfileSignals).
+		Scan(&interactions).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	if err := db.Model(&models.Bookmark{}).
+		Select("article_id, 'bookmark' AS type, created_at").
+		Where("user_id = ? AND created_at > ?", userID, since).
+		Scan(&bookmarks).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	if err := db.Model(&models.Vote{}).
+		Select("article_id, type, created_at").
+		Where("user_id = ? AND article_id IS NOT NULL AND created_at > ?", userID, since).
+		Scan(&votes).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	// Count each kind of signal once per article, keeping the most recent one, so repeated
+	// views or a bookmark recorded both as an interaction and a Bookmark do not pile up
+	signals := append(append(interactions, bookmarks...), votes...)
+	sort.SliceStable(signals, func(i, j int) bool { return signals[i].CreatedAt.After(signals[j].CreatedAt) })
+	seen := make(map[string]bool, len(signals))
+	unique := signals[:0]
+	ids := make([]uint, 0, len(signals))
+	for _, signal := range signals {
+		key := fmt.Sprintf("%d:%s", signal.ArticleID, signal.Type)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, signal)
+		ids = append(ids, signal.ArticleID)
+	}
+
+	profile := recommend.NewProfile()
+
+	if len(ids) > 0 {
+		var sources []models.Article
+		if err := db.Select("id, title, author_id").
+			Preload("Categories").
+			Preload("Tags").
+			Where("id IN ?", ids).
+			Find(&sources).Error; err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+		}
+		byID := make(map[uint]*models.Article, len(sources))
+		for i := range sources {
+			byID[sources[i].ID] = &sources[i]
+		}
+
+		for _, signal := range unique {
+			article, ok := byID[signal.ArticleID]
+			if !ok {
+				continue
+			}
+			weight := recommend.SignalWeights[signal.Type]
+			if signal.Type == "view" && signal.CompletionRate != nil {
+				weight += *signal.CompletionRate
+			}
+			weight *= recommend.Decay(now.Sub(signal.CreatedAt), recommend.SignalHalfLife)
+			profile.AddArticle(articleFeatures(article), weight, article.Title)
+		}
+	}
+
+	var follows []struct {
+		FollowingID uint
+		Username    string
+		FirstName   string
+		LastName    string
+	}
+	if err := db.Model(&models.Follow{}).
+		Select("follows.following_id, users.username, users.first_name, users.last_name").
+		Joins("JOIN users ON users.id = follows.following_id AND users.deleted_at IS NULL").
+		Where("follows.follower_id = ?", userID).
+		Scan(&follows).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	for _, follow := range follows {
+		name := strings.TrimSpace(follow.FirstName + " " + follow.LastName)
+		if name == "" {
+			name = follow.Username
+		}
+		profile.AddFollow(follow.FollowingID, recommend.FollowWeight, name)
+	}
+
+	span.SetAttributes(
+		attribute.Int("signal_count", len(unique)),
+		attribute.Int("follow_count", len(follows)))
+	return profile, nil
+}
+
+// readArticleIDs returns the articles the user has viewed or bookmarked
+func (rs *RecommendationService) readArticleIDs(ctx context.Context, userID uint) ([]uint, error) {
+	var viewed, bookmarked []uint
+	if err := rs.db.WithContext(ctx).Model(&models.UserArticleInteraction{}).
+		Distinct("article_id").
+		Where("user_id = ? AND interaction_type IN ?", userID, []string{"view", "bookmark"}).
+		Pluck("article_id", &viewed).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	if err := rs.db.WithContext(ctx).Model(&models.Bookmark{}).
+		Where("user_id = ?", userID).
+		Pluck("article_id", &bookmarked).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return append(viewed, bookmarked...), nil
+}
+
+// personalizedCandidates loads recent published articles matching the user's strongest
+// affinities that the user has not read yet
+func (rs *RecommendationService) personalizedCandidates(ctx context.Context, profile *recommend.Profile, read []uint, now time.Time) ([]models.Article, error) {
+	query := rs.articleQuery(ctx).
+		Where("published_at > ?", now.Add(-recommendationCandidateWindow)).
+		Where(matchingFeaturesCondition(rs.db,
+			profile.TopCategories(profileTopFeatures),
+			profile.TopTags(profileTopFeatures),
+			profile.TopAuthors(profileTopFeatures)))
+	if len(read) > 0 {
+		query = query.Where("id NOT IN ?", read)
+	}
+
+	var candidates []models.Article
+	if err := query.Order("published_at DESC").Limit(maxRecommendationCandidates).Find(&candidates).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return candidates, nil
+}
+
+// popularRecommendations wraps the popular articles outside exclude as recommendations
+func (rs *RecommendationService) popularRecommendations(ctx context.Context, limit int, exclude []uint) ([]RecommendedArticle, error) {
+	articles, err := rs.popularArticles(ctx, limit, exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	recommendations := make([]RecommendedArticle, 0, len(articles))
+	for _, article := range articles {
+		recommendations = append(recommendations, RecommendedArticle{
+			Article:     article,
+			Reason:      recommend.ReasonPopular,
+			Explanation: recommend.PopularExplanation(),
+		})
+	}
+	return recommendations, nil
+}
+
+// popularArticles returns published articles ranked by engagement in the popular window,
+// topped up with the most recent articles when there is not enough engagement
+func (rs *RecommendationService) popularArticles(ctx context.Context, limit int, exclude []uint) ([]models.Article, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	ctx, dbSpan := tracing.StartSpanWithAttributes(ctx, "DB.PopularArticlesQuery",
+		attribute.String("db.operation", "popular_articles_query"),
+		attribute.String("db.timeframe", "7days"),
+		attribute.Int("limit", limit))
+	defer dbSpan.End()
+
+	ranked := rs.db.WithContext(ctx).Model(&models.UserArticleInteraction{}).
+		Select("user_article_interactions.article_id").
+		Joins("JOIN articles ON articles.id = user_article_interactions.article_id AND articles.status = ? AND articles.deleted_at IS NULL", "published").
+		Where("user_article_interactions.created_at > ?", time.Now().Add(-recommendationPopularWindow))
+	if len(exclude) > 0 {
+		ranked = ranked.Where("user_article_interactions.article_id NOT IN ?", exclude)
+	}
+
+	var ids []uint
+	if err := ranked.
+		Group("user_article_interactions.article_id").
+		Order(popularityScoreSQL+" DESC").
+		Limit(limit).
+		Pluck("user_article_interactions.article_id", &ids).Error; err != nil {
+		dbSpan.RecordError(err)
+		dbSpan.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	articles := make([]models.Article, 0, limit)
+	if len(ids) > 0 {
+		var found []models.Article
+		if err := rs.articleQuery(ctx).Where("id IN ?", ids).Find(&found).Error; err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+		}
+		byID := make(map[uint]models.Article, len(found))
+		for _, article := range found {
+			byID[article.ID] = article
+		}
+		for _, id := range ids {
+			if article, ok := byID[id]; ok {
+				articles = append(articles, article)
+			}
+		}
+	}
+
+	if len(articles) < limit {
+		recentQuery := rs.articleQuery(ctx)
+		if skip := append(append([]uint{}, exclude...), ids...); len(skip) > 0 {
+			recentQuery = recentQuery.Where("id NOT IN ?", skip)
+		}
+		var recent []models.Article
+		if err := recentQuery.Order("published_at DESC").Limit(limit - len(articles)).Find(&recent).Error; err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+		}
+		articles = append(articles, recent...)
+	}
+
+	dbSpan.SetAttributes(attribute.Int("result.count", len(articles)))
+	return articles, nil
+}
+
+// popularityScoreSQL weighs engagement above plain views and ignores negative votes
+const popularityScoreSQL = `SUM(CASE
+	WHEN user_article_interactions.interaction_type = 'view' THEN 1
+	WHEN user_article_interactions.interaction_type IN ('downvote', 'dislike') THEN 0
+	ELSE 3 END)`
+
+// articlePopularity returns the engagement score of each article since the given time
+func (rs *RecommendationService) articlePopularity(ctx context.Context, ids []uint, since time.Time) (map[uint]float64, error) {
+	popularity := make(map[uint]float64, len(ids))
+	if len(ids) == 0 {
+		return popularity, nil
+	}
+
+	var rows []struct {
+		ArticleID uint
+		Score     float64
+	}
+	if err := rs.db.WithContext(ctx).Model(&models.UserArticleInteraction{}).
+		Select("user_article_interactions.article_id, "+popularityScoreSQL+" AS score").
+		Where("article_id IN ? AND created_at > ?", ids, since).
+		Group("user_article_interactions.article_id").
+		Scan(&rows).Error; err != nil {
+		return popularity, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	for _, row := range rows {
+		popularity[row.ArticleID] = row.Score
+	}
+	return popularity, nil
+}
+
+// articleQuery selects published articles with what recommendations need to be scored and shown
+func (rs *RecommendationService) articleQuery(ctx context.Context) *gorm.DB {
+	return rs.db.WithContext(ctx).Model(&models.Article{}).
+		Preload("Author").
+		Preload("Categories").
+		Preload("Tags").
+		Where("status = ?", "published")
+}
+
+// matchingFeaturesCondition matches articles in any of the categories, with any of the tags or
+// by any of the authors
+func matchingFeaturesCondition(db *gorm.DB, categoryIDs, tagIDs, authorIDs []uint) *gorm.DB {
+	condition := db.Where("1 = 0")
+	if len(categoryIDs) > 0 {
+		condition = condition.Or("id IN (?)", db.Table("article_categories").Select("article_id").Where("category_id IN ?", categoryIDs))
+	}
+	if len(tagIDs) > 0 {
+		condition = condition.Or("id IN (?)", db.Table("article_tags").Select("article_id").Where("tag_id IN ?", tagIDs))
+	}
+	if len(authorIDs) > 0 {
+		condition = condition.Or("author_id IN ?", authorIDs)
+	}
+	return condition
+}
+
+func articleFeatures(article *models.Article) recommend.Features {
+	features := recommend.Features{
+		CategoryIDs: make([]uint, 0, len(article.Categories)),
+		TagIDs:      make([]uint, 0, len(article.Tags)),
+		AuthorID:    article.AuthorID,
+	}
+	for _, category := range article.Categories {
+		features.CategoryIDs = append(features.CategoryIDs, category.ID)
+	}
+	for _, tag := range article.Tags {
+		features.TagIDs = append(features.TagIDs, tag.ID)
+	}
+	return features
+}
+
+// similarityExplanation names what a similar article shares with the source article
+func similarityExplanation(source *models.Article, reason string, shared []uint) string {
+	in := make(map[uint]bool, len(shared))
+	for _, id := range shared {
+		in[id] = true
+	}
+
+	var names []string
+	switch reason {
+	case recommend.ReasonTag:
+		for _, tag := range source.Tags {
+			if in[tag.ID] {
+				names = append(names, tag.Name)
+			}
+		}
+		return "Also tagged " + strings.Join(names, ", ")
+	case recommend.ReasonCategory:
+		for _, category := range source.Categories {
+			if in[category.ID] {
+				names = append(names, category.Name)
+			}
+		}
+		return "Also in " + strings.Join(names, ", ")
+	default:
+		return "By the same author"
+	}
+}
+
+func sortRecommendations(recommendations []RecommendedArticle) {
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return recommendations[i].Score > recommendations[j].Score
+	})
+}
+
+func articleIDs(articles []models.Article) []uint {
+	ids := make([]uint, 0, len(articles))
+	for _, article := range articles {
+		ids = append(ids, article.ID)
+	}
+	return ids
+}
+
+func recommendedIDs(recommendations []RecommendedArticle) []uint {
+	ids := make([]uint, 0, len(recommendations))
+	for _, recommendation := range recommendations {
+		ids = append(ids, recommendation.Article.ID)
+	}
+	return ids
+}
@@ -0,0 +1,457 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"news/internal/cache"
+	"news/internal/config"
+	"news/internal/database"
+	"news/internal/json"
+	"news/internal/models"
+	"news/internal/seo"
+
+	"gorm.io/gorm"
+)
+
+// SEO entity types
+const (
+	SEOEntityArticle = "article"
+	SEOEntityPage    = "page"
+	SEOEntityVideo   = "video"
+	SEOEntityLive    = "live"
+)
+
+const (
+	seoCacheKeyPrefix = "seo:"
+	// seoLiveCacheTTL keeps live blog metadata close to the latest update
+	seoLiveCacheTTL = 30 * time.Second
+	// seoLiveUpdateLimit is the number of recent updates listed in LiveBlogPosting
+	seoLiveUpdateLimit   = 50
+	seoDescriptionLength = 160
+)
+
+// SEOService assembles the head metadata and JSON-LD of articles, pages, videos and live
+// streams, localized into a requested language
+type SEOService struct {
+	db  *gorm.DB
+	cfg *config.SEOConfig
+}
+
+var (
+	seoInstance *SEOService
+	seoOnce     sync.Once
+)
+
+// NewSEOService creates an SEO service
+func NewSEOService(db *gorm.DB, cfg *config.SEOConfig) *SEOService {
+	return &SEOService{db: db, cfg: cfg}
+}
+
+// GetSEOService returns the SEO service
+func GetSEOService() *SEOService {
+	seoOnce.Do(func() {
+		seoInstance = NewSEOService(database.DB, config.GetSEOConfig())
+	})
+	return seoInstance
+}
+
+// Metadata returns the metadata of a published entity. With lang, a published translation into
+// that language is described when there is one, and the original otherwise.
+func (s *SEOService) Metadata(entityType string, id uint, lang string) (*seo.Metadata, error) {
+	entityType = strings.ToLower(entityType)
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if len(lang) > 5 {
+		return nil, fmt.Errorf("%w: invalid language %q", ErrValidation, lang)
+	}
+
+	cacheKey := fmt.Sprintf("%s%s:%d:%s", seoCacheKeyPrefix, entityType, id, lang)
+	unifiedCache := cache.GetUnifiedCache()
+	if cached, found := unifiedCache.GetString(cacheKey); found {
+		var meta seo.Metadata
+		if err := json.UnmarshalForCache([]byte(cached), &meta); err == nil {
+			return &meta, nil
+		}
+	}
+
+	var meta *seo.Metadata
+	var err error
+	ttl := s.cfg.CacheTTL
+	switch entityType {
+	case SEOEntityArticle:
+		meta, err = s.article(id, lang)
+	case SEOEntityPage:
+		meta, err = s.page(id, lang)
+	case SEOEntityVideo:
+		meta, err = s.video(id, lang)
+	case SEOEntityLive:
+		meta, err = s.live(id, lang)
+		if ttl > seoLiveCacheTTL {
+			ttl = seoLiveCacheTTL
+		}
+	default:
+		return nil, fmt.Errorf("%w: entity type must be article, page, video or live", ErrValidation)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err := json.MarshalForCache(meta); err == nil {
+		// The in-process copy is short lived since InvalidateSEOMetadata cannot reach other
+		// replicas
+		if err := unifiedCache.Set(cacheKey, string(data), min(time.Minute, ttl), ttl); err != nil {
+			log.Printf("Warning: Failed to cache SEO metadata %s: %v", cacheKey, err)
+		}
+	}
+	return meta, nil
+}
+
+// InvalidateSEOMetadata drops the cached metadata of an entity in every language
+func InvalidateSEOMetadata(entityType string, id uint) {
+	pattern := fmt.Sprintf("%s%s:%d:*", seoCacheKeyPrefix, entityType, id)
+	if err := cache.GetUnifiedCache().DeleteMatching(pattern); err != nil {
+		log.Printf("Warning: Failed to invalidate SEO metadata of %s %d: %v", entityType, id, err)
+	}
+}
+
+// LocalizedSettings returns the metadata of an entity in the shape of its SEO settings, with
+// the JSON-LD as the schema
+func (s *SEOService) LocalizedSettings(entityType string, id uint, lang string) (*models.LocalizedSEOSettings, error) {
+	meta, err := s.Metadata(entityType, id, lang)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := json.Marshal(meta.JSONLD)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode structured data: %v", err)
+	}
+	return &models.LocalizedSEOSettings{
+		Keywords:           meta.Keywords,
+		CanonicalURL:       meta.CanonicalURL,
+		RobotsIndex:        meta.Robots == seo.RobotsIndex,
+		RobotsFollow:       true,
+		OGTitle:            meta.OpenGraph.Title,
+		OGDescription:      meta.OpenGraph.Description,
+		OGImage:            meta.OpenGraph.Image,
+		TwitterCard:        meta.Twitter.Card,
+		TwitterTitle:       meta.Twitter.Title,
+		TwitterDescription: meta.Twitter.Description,
+		TwitterImage:       meta.Twitter.Image,
+		Schema:             string(schema),
+	}, nil
+}
+
+func (s *SEOService) article(id uint, lang string) (*seo.Metadata, error) {
+	var article models.Article
+	err := s.db.Preload("Author").Preload("Categories").Preload("Tags").
+		Preload("Translations", "translation_status = ? AND is_active = ?", "published", true).
+		Where("status = ? AND (published_at IS NULL OR published_at <= ?)", "published", time.Now()).
+		First(&article, id).Error
+	if err != nil {
+		return nil, seoLookupError(err)
+	}
+
+	title, summary, content := article.Title, article.Summary, article.Content
+	metaTitle, metaDesc, slug := article.MetaTitle, article.MetaDesc, article.Slug
+	language, urlLang := article.Language, ""
+	alternates := []seo.Alternate{{Lang: article.Language, Href: publicArticleURL(s.cfg.SiteURL, article.Slug, "")}}
+	for _, translation := range article.Translations {
+		if translation.Language == article.Language {
+			continue
+		}
+		alternates = append(alternates, seo.Alternate{Lang: translation.Language, Href: publicArticleURL(s.cfg.SiteURL, translation.Slug, translation.Language)})
+		if translation.Language == lang {
+			title, summary, content = translation.Title, translation.Summary, translation.Content
+			metaTitle, metaDesc, slug = translation.MetaTitle, translation.MetaDescription, translation.Slug
+			language, urlLang = translation.Language, translation.Language
+		}
+	}
+
+	canonical := publicArticleURL(s.cfg.SiteURL, slug, urlLang)
+	description := firstNonEmpty(metaDesc, summary, seo.Excerpt(content, seoDescriptionLength))
+	meta := s.base(SEOEntityArticle, article.ID, language, firstNonEmpty(metaTitle, title), description, canonical, article.FeaturedImage)
+	meta.Alternates = seo.Localize(alternates)
+
+	published := article.CreatedAt
+	if article.PublishedAt != nil {
+		published = *article.PublishedAt
+	}
+	modified := article.UpdatedAt
+	meta.OpenGraph.Type = "article"
+	meta.OpenGraph.PublishedTime = &published
+	meta.OpenGraph.ModifiedTime = &modified
+	for _, tag := range article.Tags {
+		meta.Keywords = append(meta.Keywords, tag.Name)
+	}
+	meta.OpenGraph.Tags = meta.Keywords
+
+	crumbs := []seo.Crumb{{Name: s.cfg.SiteName, URL: s.cfg.SiteURL + "/"}}
+	if len(article.Categories) > 0 {
+		category := article.Categories[0]
+		meta.OpenGraph.Section = category.Name
+		crumbs = append(crumbs, seo.Crumb{Name: category.Name, URL: s.cfg.SiteURL + "/categories/" + url.PathEscape(category.Slug)})
+	}
+	crumbs = append(crumbs, seo.Crumb{Name: title, URL: canonical})
+
+	data := seo.Article{
+		URL:         canonical,
+		Headline:    title,
+		Description: description,
+		Published:   published,
+		Modified:    modified,
+		Section:     meta.OpenGraph.Section,
+		Keywords:    meta.Keywords,
+		Language:    language,
+	}
+	if meta.OpenGraph.Image != "" {
+		data.Images = []string{meta.OpenGraph.Image}
+	}
+	if article.Author.ID != 0 {
+		data.AuthorName = article.Author.DisplayName()
+		data.AuthorURL = s.cfg.SiteURL + "/authors/" + url.PathEscape(article.Author.Username)
+	}
+
+	s.finish(meta, []seo.Object{seo.NewsArticle(data, s.publisher()), seo.BreadcrumbList(crumbs)}, "")
+	return meta, nil
+}
+
+func (s *SEOService) page(id uint, lang string) (*seo.Metadata, error) {
+	var page models.Page
+	err := s.db.Preload("Parent").Preload("Translations", "is_active = ?", true).
+		Where("status = ? AND (published_at IS NULL OR published_at <= ?)", "published", time.Now()).
+		First(&page, id).Error
+	if err != nil {
+		return nil, seoLookupError(err)
+	}
+	settings := page.GetSEOSettings()
+
+	title := firstNonEmpty(page.MetaTitle, page.Title)
+	description := firstNonEmpty(page.MetaDesc, page.ExcerptText)
+	name, language, slug, urlLang := page.Title, page.Language, page.Slug, ""
+	alternates := []seo.Alternate{{Lang: page.Language, Href: publicPageURL(s.cfg.SiteURL, &page, page.Slug, "")}}
+	for _, translation := range page.Translations {
+		if translation.Language == page.Language {
+			continue
+		}
+		alternates = append(alternates, seo.Alternate{Lang: translation.Language, Href: publicPageURL(s.cfg.SiteURL, &page, translation.Slug, translation.Language)})
+		if translation.Language == lang {
+			title = firstNonEmpty(translation.MetaTitle, translation.Title)
+			description = firstNonEmpty(translation.MetaDesc, translation.ExcerptText)
+			name, language, slug, urlLang = translation.Title, translation.Language, translation.Slug, translation.Language
+		}
+	}
+	translated := urlLang != ""
+
+	canonical := publicPageURL(s.cfg.SiteURL, &page, slug, urlLang)
+	if !translated && settings.CanonicalURL != "" {
+		canonical = settings.CanonicalURL
+	}
+	meta := s.base(SEOEntityPage, page.ID, language, title, description, canonical, firstNonEmpty(settings.OGImage, page.FeaturedImage))
+	meta.Alternates = seo.Localize(alternates)
+	meta.Keywords = settings.Keywords
+	if page.NoIndex() {
+		meta.Robots = seo.RobotsNoIndex
+	}
+	if settings.TwitterImage != "" {
+		meta.Twitter.Image = settings.TwitterImage
+	}
+	if settings.TwitterCard != "" {
+		meta.Twitter.Card = settings.TwitterCard
+	}
+	// Social texts in the settings are written in the page's own language
+	if !translated {
+		meta.OpenGraph.Title = firstNonEmpty(settings.OGTitle, meta.OpenGraph.Title)
+		meta.OpenGraph.Description = firstNonEmpty(settings.OGDescription, meta.OpenGraph.Description)
+		meta.Twitter.Title = firstNonEmpty(settings.TwitterTitle, meta.Twitter.Title)
+		meta.Twitter.Description = firstNonEmpty(settings.TwitterDescription, meta.Twitter.Description)
+	}
+
+	nodes := []seo.Object{seo.WebPage(canonical, name, description, language, page.UpdatedAt)}
+	if !page.IsHomepage {
+		crumbs := []seo.Crumb{{Name: s.cfg.SiteName, URL: s.cfg.SiteURL + "/"}}
+		if page.Parent != nil && page.Parent.Status == "published" {
+			crumbs = append(crumbs, seo.Crumb{Name: page.Parent.Title, URL: publicPageURL(s.cfg.SiteURL, page.Parent, page.Parent.Slug, "")})
+		}
+		crumbs = append(crumbs, seo.Crumb{Name: name, URL: canonical})
+		nodes = append(nodes, seo.BreadcrumbList(crumbs))
+	}
+
+	s.finish(meta, nodes, settings.Schema)
+	return meta, nil
+}
+
+func (s *SEOService) video(id uint, lang string) (*seo.Metadata, error) {
+	var video models.Video
+	err := s.db.Preload("Category").
+		Where("status = ? AND is_public = ?", "published", true).
+		First(&video, id).Error
+	if err != nil {
+		return nil, seoLookupError(err)
+	}
+
+	canonical := fmt.Sprintf("%s/videos/%d", s.cfg.SiteURL, video.ID)
+	description := seo.Excerpt(video.Description, seoDescriptionLength)
+	// Videos are not translated; SEO translations can still localize their social texts
+	meta := s.base(SEOEntityVideo, video.ID, lang, video.Title, description, canonical, video.ThumbnailURL)
+	meta.OpenGraph.Type = "video.other"
+	meta.OpenGraph.Video = firstNonEmpty(video.StreamURL, video.VideoURL)
+	var tags []string
+	if video.Tags != "" && json.Unmarshal([]byte(video.Tags), &tags) == nil {
+		meta.Keywords = tags
+	}
+
+	uploaded := video.CreatedAt
+	if video.PublishedAt != nil {
+		uploaded = *video.PublishedAt
+	}
+	crumbs := []seo.Crumb{{Name: s.cfg.SiteName, URL: s.cfg.SiteURL + "/"}}
+	if video.Category.ID != 0 {
+		meta.OpenGraph.Section = video.Category.Name
+		crumbs = append(crumbs, seo.Crumb{Name: video.Category.Name, URL: s.cfg.SiteURL + "/categories/" + url.PathEscape(video.Category.Slug)})
+	}
+	crumbs = append(crumbs, seo.Crumb{Name: video.Title, URL: canonical})
+
+	object := seo.VideoObject(seo.Video{
+		URL:          canonical,
+		Name:         video.Title,
+		Description:  video.Description,
+		ThumbnailURL: video.ThumbnailURL,
+		ContentURL:   meta.OpenGraph.Video,
+		UploadDate:   uploaded,
+		Duration:     video.Duration,
+		Views:        video.ViewCount,
+	}, s.publisher())
+
+	s.finish(meta, []seo.Object{object, seo.BreadcrumbList(crumbs)}, "")
+	return meta, nil
+}
+
+func (s *SEOService) live(id uint, lang string) (*seo.Metadata, error) {
+	var stream models.LiveNewsStream
+	err := s.db.Where("status <> ?", models.LiveStreamDraft).
+		Preload("Updates", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at DESC").Limit(seoLiveUpdateLimit)
+		}).
+		First(&stream, id).Error
+	if err != nil {
+		return nil, seoLookupError(err)
+	}
+
+	canonical := fmt.Sprintf("%s/live/%d", s.cfg.SiteURL, stream.ID)
+	description := seo.Excerpt(stream.Description, seoDescriptionLength)
+	meta := s.base(SEOEntityLive, stream.ID, lang, stream.Title, description, canonical, stream.CoverImageURL)
+	meta.OpenGraph.Type = "article"
+	meta.OpenGraph.PublishedTime = stream.StartTime
+
+	blog := seo.LiveBlog{
+		URL:           canonical,
+		Headline:      stream.Title,
+		Description:   description,
+		Image:         meta.OpenGraph.Image,
+		CoverageStart: stream.StartTime,
+		CoverageEnd:   stream.EndTime,
+		Modified:      stream.UpdatedAt,
+		Language:      lang,
+	}
+	for _, update := range stream.Updates {
+		blog.Updates = append(blog.Updates, seo.LiveUpdate{
+			ID:        update.ID,
+			Headline:  update.Title,
+			Body:      seo.Excerpt(update.Content, 1000),
+			Published: update.CreatedAt,
+			Modified:  update.UpdatedAt,
+		})
+		if update.UpdatedAt.After(blog.Modified) {
+			blog.Modified = update.UpdatedAt
+		}
+	}
+	meta.OpenGraph.ModifiedTime = &blog.Modified
+
+	crumbs := []seo.Crumb{
+		{Name: s.cfg.SiteName, URL: s.cfg.SiteURL + "/"},
+		{Name: stream.Title, URL: canonical},
+	}
+	s.finish(meta, []seo.Object{seo.LiveBlogPosting(blog, s.publisher()), seo.BreadcrumbList(crumbs)}, "")
+	return meta, nil
+}
+
+// base fills the fields shared by every entity, with Open Graph and Twitter texts defaulting to
+// the title and description
+func (s *SEOService) base(entityType string, id uint, language, title, description, canonical, image string) *seo.Metadata {
+	image = firstNonEmpty(image, s.cfg.DefaultImage)
+	card := "summary"
+	if image != "" {
+		card = "summary_large_image"
+	}
+	return &seo.Metadata{
+		EntityType:   entityType,
+		EntityID:     id,
+		Language:     language,
+		Title:        title,
+		Description:  description,
+		CanonicalURL: canonical,
+		Robots:       seo.RobotsIndex,
+		OpenGraph: seo.OpenGraph{
+			Type:        "website",
+			Title:       title,
+			Description: description,
+			URL:         canonical,
+			Image:       image,
+			SiteName:    s.cfg.SiteName,
+			Locale:      seo.Locale(language),
+		},
+		Twitter: seo.TwitterCard{
+			Card:        card,
+			Site:        s.cfg.TwitterSite,
+			Title:       title,
+			Description: description,
+			Image:       image,
+		},
+	}
+}
+
+// finish applies the SEO translation of the entity into its language, lists the alternate
+// locales and appends custom JSON-LD from the settings or the translation to the generated nodes
+func (s *SEOService) finish(meta *seo.Metadata, nodes []seo.Object, customSchema string) {
+	if meta.Language != "" {
+		var translation models.SEOTranslation
+		err := s.db.Where("entity_id = ? AND entity_type = ? AND language = ?", meta.EntityID, meta.EntityType, meta.Language).
+			First(&translation).Error
+		if err == nil {
+			if keywords := translation.GetKeywords(); len(keywords) > 0 {
+				meta.Keywords = keywords
+			}
+			meta.OpenGraph.Title = firstNonEmpty(translation.OGTitle, meta.OpenGraph.Title)
+			meta.OpenGraph.Description = firstNonEmpty(translation.OGDescription, meta.OpenGraph.Description)
+			meta.Twitter.Title = firstNonEmpty(translation.TwitterTitle, meta.Twitter.Title)
+			meta.Twitter.Description = firstNonEmpty(translation.TwitterDescription, meta.Twitter.Description)
+			customSchema = firstNonEmpty(translation.Schema, customSchema)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Warning: Failed to load SEO translation of %s %d: %v", meta.EntityType, meta.EntityID, err)
+		}
+	}
+
+	for _, alternate := range meta.Alternates {
+		if alternate.Lang != meta.Language && alternate.Lang != "x-default" {
+			meta.OpenGraph.AlternateLocales = append(meta.OpenGraph.AlternateLocales, seo.Locale(alternate.Lang))
+		}
+	}
+	meta.JSONLD = append(nodes, seo.ParseSchema(customSchema)...)
+}
+
+func (s *SEOService) publisher() seo.Organization {
+	return seo.Organization{Name: s.cfg.SiteName, URL: s.cfg.SiteURL, Logo: s.cfg.LogoURL}
+}
+
+func seoLookupError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+}
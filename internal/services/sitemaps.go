@@ -0,0 +1,548 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+
+	"news/internal/cache"
+	"news/internal/config"
+	"news/internal/database"
+	"news/internal/models"
+	"news/internal/sitemap"
+	"news/internal/storage"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrSitemapNotFound is returned for a sitemap file name that does not exist
+	ErrSitemapNotFound = errors.New("sitemap not found")
+)
+
+const (
+	sitemapStoragePrefix  = "sitemaps/"
+	sitemapCacheKeyPrefix = "sitemap:"
+	// maxNewsSitemapURLs is the Google News limit on URLs per sitemap
+	maxNewsSitemapURLs = 1000
+)
+
+// SitemapEnqueuer enqueues sitemap regeneration for the worker
+type SitemapEnqueuer interface {
+	EnqueueSitemapRefresh(section string, entityID uint) error
+}
+
+var (
+	sitemapEnqueuer   SitemapEnqueuer
+	sitemapEnqueuerMu sync.RWMutex
+
+	sitemapInstance *SitemapService
+	sitemapOnce     sync.Once
+)
+
+// SetSitemapEnqueuer sets the queue used to regenerate sitemaps. Without one, sitemaps are only
+// rebuilt by the periodic refresh or an admin request.
+func SetSitemapEnqueuer(enqueuer SitemapEnqueuer) {
+	sitemapEnqueuerMu.Lock()
+	defer sitemapEnqueuerMu.Unlock()
+	sitemapEnqueuer = enqueuer
+}
+
+func getSitemapEnqueuer() SitemapEnqueuer {
+	sitemapEnqueuerMu.RLock()
+	defer sitemapEnqueuerMu.RUnlock()
+	return sitemapEnqueuer
+}
+
+// RequestSitemapRefresh queues the sitemap shard listing an item to be rebuilt, together with
+// the news sitemap and the index
+func RequestSitemapRefresh(section string, entityID uint) {
+	enqueuer := getSitemapEnqueuer()
+	if enqueuer == nil {
+		return
+	}
+	if err := enqueuer.EnqueueSitemapRefresh(section, entityID); err != nil {
+		log.Printf("Warning: Failed to enqueue sitemap refresh for %s %d: %v", section, entityID, err)
+	}
+}
+
+// publicContentChanged is called when a published item changes, is published or leaves the
+// site. It drops the cached feeds listing articles and the item's SEO metadata, and queues its
+// sitemap refresh.
+func publicContentChanged(section string, entityID uint) {
+	switch section {
+	case sitemap.SectionArticles:
+		InvalidateFeeds()
+		InvalidateSEOMetadata(SEOEntityArticle, entityID)
+	case sitemap.SectionPages:
+		InvalidateSEOMetadata(SEOEntityPage, entityID)
+	}
+	RequestSitemapRefresh(section, entityID)
+}
+
+// SitemapService generates the sitemap index, the sharded sitemaps of articles, categories,
+// tags and pages, and the Google News sitemap. Files are written to storage and served through
+// the unified cache.
+type SitemapService struct {
+	db    *gorm.DB
+	store storage.Storage
+	cfg   *config.SitemapConfig
+}
+
+// NewSitemapService creates a sitemap service writing to the given storage
+func NewSitemapService(db *gorm.DB, store storage.Storage, cfg *config.SitemapConfig) *SitemapService {
+	return &SitemapService{db: db, store: store, cfg: cfg}
+}
+
+// GetSitemapService returns the sitemap service
+func GetSitemapService() *SitemapService {
+	sitemapOnce.Do(func() {
+		sitemapInstance = NewSitemapService(database.DB, GetStorageService(), config.GetSitemapConfig())
+	})
+	return sitemapInstance
+}
+
+// CacheTTL is how long clients and the cache may keep a sitemap file
+func (s *SitemapService) CacheTTL() time.Duration {
+	return s.cfg.CacheTTL
+}
+
+// Get returns a sitemap file from the cache, then from storage. Files that were never
+// generated are generated on the spot; shards that list nothing are not found.
+func (s *SitemapService) Get(name string) ([]byte, error) {
+	section, shard, ok := sitemap.ParseFile(name)
+	if !ok {
+		return nil, ErrSitemapNotFound
+	}
+	// Files of shards that have emptied since they were stored are not served
+	if err := s.checkShard(name, section, shard); err != nil {
+		return nil, err
+	}
+
+	unifiedCache := cache.GetUnifiedCache()
+	if cached, found := unifiedCache.GetString(sitemapCacheKeyPrefix + name); found {
+		return []byte(cached), nil
+	}
+
+	if reader, err := s.store.Download(sitemapStoragePrefix + name); err == nil {
+		body, readErr := io.ReadAll(reader)
+		if closer, ok := reader.(io.Closer); ok {
+			closer.Close()
+		}
+		if readErr == nil {
+			s.cache(name, body)
+			return body, nil
+		}
+		log.Printf("Warning: Failed to read stored sitemap %s: %v", name, readErr)
+	}
+
+	return s.write(name, section, shard)
+}
+
+// Refresh rebuilds sitemaps after content changed and returns the files written. With an
+// entity ID only the shard listing it is rebuilt; without one the whole section is, and without
+// a section every sitemap is. The news sitemap follows article changes and the index is always
+// rebuilt last.
+func (s *SitemapService) Refresh(section string, entityID uint) ([]string, error) {
+	var names []string
+	switch {
+	case section == "":
+		for _, candidate := range sitemap.Sections {
+			shards, err := s.refreshShardNames(candidate)
+			if err != nil {
+				return nil, err
+			}
+			names = append(names, shards...)
+		}
+	case !sitemap.ValidSection(section):
+		return nil, fmt.Errorf("%w: unknown sitemap section %q", ErrValidation, section)
+	case entityID == 0:
+		shards, err := s.refreshShardNames(section)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, shards...)
+	default:
+		names = append(names, sitemap.ShardFile(section, sitemap.Shard(entityID, s.cfg.ShardSize)))
+	}
+	if section == "" || section == sitemap.SectionArticles {
+		names = append(names, sitemap.NewsFile)
+	}
+	names = append(names, sitemap.IndexFile)
+
+	written := make([]string, 0, len(names))
+	for _, name := range names {
+		if _, err := s.generate(name); err != nil {
+			// The shard of an item that was removed may have emptied, which deleted its file
+			if errors.Is(err, ErrSitemapNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		written = append(written, name)
+	}
+	return written, nil
+}
+
+// generate builds a sitemap file and writes it to storage and the cache. A shard that lists
+// nothing has its stored file deleted and is not found.
+func (s *SitemapService) generate(name string) ([]byte, error) {
+	section, shard, ok := sitemap.ParseFile(name)
+	if !ok {
+		return nil, ErrSitemapNotFound
+	}
+	if err := s.checkShard(name, section, shard); err != nil {
+		return nil, err
+	}
+	return s.write(name, section, shard)
+}
+
+// checkShard returns ErrSitemapNotFound for a shard that lists nothing, deleting its stored file.
+// The index and the news sitemap always exist.
+func (s *SitemapService) checkShard(name, section string, shard int) error {
+	if section == "" {
+		return nil
+	}
+	first, last := sitemap.ShardRange(shard, s.cfg.ShardSize)
+	var count int64
+	if err := s.sectionQuery(section).Where("id BETWEEN ? AND ?", first, last).Count(&count).Error; err != nil {
+		return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	if count == 0 {
+		s.discard(name)
+		return ErrSitemapNotFound
+	}
+	return nil
+}
+
+// write builds a sitemap file and stores it
+func (s *SitemapService) write(name, section string, shard int) ([]byte, error) {
+	var body []byte
+	var err error
+	switch {
+	case name == sitemap.IndexFile:
+		body, err = s.buildIndex()
+	case name == sitemap.NewsFile:
+		body, err = s.buildNews()
+	default:
+		body, err = s.buildShard(section, shard)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.store.Upload(bytes.NewReader(body), sitemapStoragePrefix+name); err != nil {
+		log.Printf("Warning: Failed to store sitemap %s: %v", name, err)
+	}
+	s.cache(name, body)
+	return body, nil
+}
+
+// cache keeps a sitemap file in the unified cache. The in-process copy is short lived so API
+// replicas pick up files regenerated by the worker.
+func (s *SitemapService) cache(name string, body []byte) {
+	l1TTL := time.Minute
+	if s.cfg.CacheTTL < l1TTL {
+		l1TTL = s.cfg.CacheTTL
+	}
+	if err := cache.GetUnifiedCache().Set(sitemapCacheKeyPrefix+name, string(body), l1TTL, s.cfg.CacheTTL); err != nil {
+		log.Printf("Warning: Failed to cache sitemap %s: %v", name, err)
+	}
+}
+
+// discard deletes a sitemap file from storage and the cache
+func (s *SitemapService) discard(name string) {
+	if err := s.store.Delete(sitemapStoragePrefix + name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Warning: Failed to delete sitemap %s: %v", name, err)
+	}
+	if err := cache.GetUnifiedCache().Delete(sitemapCacheKeyPrefix + name); err != nil {
+		log.Printf("Warning: Failed to uncache sitemap %s: %v", name, err)
+	}
+}
+
+// sitemapShardStat is a non-empty shard of a section with its newest change
+type sitemapShardStat struct {
+	Shard   int
+	LastMod time.Time
+}
+
+// shardStats lists the shards of a section that contain at least one listed item
+func (s *SitemapService) shardStats(section string) ([]sitemapShardStat, error) {
+	var stats []sitemapShardStat
+	err := s.sectionQuery(section).
+		Select("(id - 1) / ? + 1 AS shard, MAX(updated_at) AS last_mod", s.cfg.ShardSize).
+		Group("shard").
+		Order("shard").
+		Scan(&stats).Error
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return stats, nil
+}
+
+// refreshShardNames lists the non-empty shards of a section and deletes the files of shards
+// that have emptied, up to the shard of the highest ID ever used in the section
+func (s *SitemapService) refreshShardNames(section string) ([]string, error) {
+	stats, err := s.shardStats(section)
+	if err != nil {
+		return nil, err
+	}
+
+	var maxID uint
+	if err := s.db.Model(sitemapSectionModel(section)).Unscoped().
+		Select("COALESCE(MAX(id), 0)").Scan(&maxID).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	listed := make(map[int]bool, len(stats))
+	names := make([]string, 0, len(stats))
+	for _, stat := range stats {
+		listed[stat.Shard] = true
+		names = append(names, sitemap.ShardFile(section, stat.Shard))
+	}
+	for shard := 1; shard <= sitemap.Shard(maxID, s.cfg.ShardSize); shard++ {
+		if !listed[shard] {
+			s.discard(sitemap.ShardFile(section, shard))
+		}
+	}
+	return names, nil
+}
+
+// sitemapSectionModel is the model listed by a section
+func sitemapSectionModel(section string) interface{} {
+	switch section {
+	case sitemap.SectionArticles:
+		return &models.Article{}
+	case sitemap.SectionCategories:
+		return &models.Category{}
+	case sitemap.SectionTags:
+		return &models.Tag{}
+	default:
+		return &models.Page{}
+	}
+}
+
+// sectionQuery selects the items of a section that belong in sitemaps. Pages marked noindex
+// are filtered out after loading, since the flag lives in their SEO settings.
+func (s *SitemapService) sectionQuery(section string) *gorm.DB {
+	switch section {
+	case sitemap.SectionArticles:
+		return s.publishedArticles()
+	case sitemap.SectionCategories:
+		return s.db.Model(&models.Category{}).Where("is_active = ?", true)
+	case sitemap.SectionTags:
+		// Tags without a published article would only list empty pages
+		return s.db.Model(&models.Tag{}).
+			Where("id IN (?)", s.db.Table("article_tags").Select("tag_id").
+				Where("article_id IN (?)", s.publishedArticles().Select("id")))
+	default:
+		return s.db.Model(&models.Page{}).
+			Where("status = ? AND (published_at IS NULL OR published_at <= ?)", "published", time.Now())
+	}
+}
+
+func (s *SitemapService) publishedArticles() *gorm.DB {
+	return s.db.Model(&models.Article{}).
+		Where("status = ? AND (published_at IS NULL OR published_at <= ?)", "published", time.Now())
+}
+
+// buildIndex writes the sitemap index listing the news sitemap and every non-empty shard
+func (s *SitemapService) buildIndex() ([]byte, error) {
+	entries := []sitemap.IndexEntry{{Loc: s.fileURL(sitemap.NewsFile)}}
+	for _, section := range sitemap.Sections {
+		stats, err := s.shardStats(section)
+		if err != nil {
+			return nil, err
+		}
+		for _, stat := range stats {
+			entries = append(entries, sitemap.IndexEntry{
+				Loc:     s.fileURL(sitemap.ShardFile(section, stat.Shard)),
+				LastMod: stat.LastMod,
+			})
+		}
+	}
+	return sitemap.Index(entries)
+}
+
+// buildShard writes the sitemap of one ID range of a section
+func (s *SitemapService) buildShard(section string, shard int) ([]byte, error) {
+	first, last := sitemap.ShardRange(shard, s.cfg.ShardSize)
+	query := s.sectionQuery(section).Where("id BETWEEN ? AND ?", first, last).Order("id")
+
+	var urls []sitemap.URL
+	switch section {
+	case sitemap.SectionArticles:
+		var articles []models.Article
+		if err := query.Find(&articles).Error; err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+		}
+		translations, err := s.articleTranslations(articles)
+		if err != nil {
+			return nil, err
+		}
+		for i := range articles {
+			urls = append(urls, s.articleURLs(&articles[i], translations[articles[i].ID], nil)...)
+		}
+	case sitemap.SectionCategories:
+		var categories []models.Category
+		if err := query.Find(&categories).Error; err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+		}
+		for _, category := range categories {
+			urls = append(urls, sitemap.URL{Loc: s.cfg.SiteURL + "/categories/" + url.PathEscape(category.Slug), LastMod: category.UpdatedAt})
+		}
+	case sitemap.SectionTags:
+		var tags []models.Tag
+		if err := query.Find(&tags).Error; err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+		}
+		for _, tag := range tags {
+			urls = append(urls, sitemap.URL{Loc: s.cfg.SiteURL + "/tags/" + url.PathEscape(tag.Slug), LastMod: tag.UpdatedAt})
+		}
+	case sitemap.SectionPages:
+		var pages []models.Page
+		if err := query.Preload("Translations", "is_active = ?", true).Find(&pages).Error; err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+		}
+		for i := range pages {
+			if pages[i].NoIndex() {
+				continue
+			}
+			urls = append(urls, s.pageURLs(&pages[i])...)
+		}
+	}
+	return sitemap.URLSet(urls)
+}
+
+// buildNews writes the Google News sitemap of the articles published within the news window
+func (s *SitemapService) buildNews() ([]byte, error) {
+	var articles []models.Article
+	err := s.publishedArticles().
+		Where("published_at >= ?", time.Now().Add(-s.cfg.NewsWindow)).
+		Order("published_at DESC, id DESC").
+		Limit(maxNewsSitemapURLs).
+		Find(&articles).Error
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	translations, err := s.articleTranslations(articles)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]sitemap.URL, 0, len(articles))
+	for i := range articles {
+		group := s.articleURLs(&articles[i], translations[articles[i].ID], func(lang, title string) *sitemap.News {
+			return &sitemap.News{
+				PublicationName: s.cfg.PublicationName,
+				Language:        lang,
+				Title:           title,
+				PublishedAt:     *articles[i].PublishedAt,
+			}
+		})
+		if len(urls)+len(group) > maxNewsSitemapURLs {
+			break
+		}
+		urls = append(urls, group...)
+	}
+	return sitemap.URLSet(urls)
+}
+
+// articleTranslations loads the published translations of articles, by article ID
+func (s *SitemapService) articleTranslations(articles []models.Article) (map[uint][]models.ArticleTranslation, error) {
+	byArticle := make(map[uint][]models.ArticleTranslation)
+	if len(articles) == 0 {
+		return byArticle, nil
+	}
+
+	ids := make([]uint, 0, len(articles))
+	for _, article := range articles {
+		ids = append(ids, article.ID)
+	}
+	var translations []models.ArticleTranslation
+	err := s.db.Where("article_id IN ? AND translation_status = ? AND is_active = ?", ids, "published", true).
+		Order("language").
+		Find(&translations).Error
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	for _, translation := range translations {
+		byArticle[translation.ArticleID] = append(byArticle[translation.ArticleID], translation)
+	}
+	return byArticle, nil
+}
+
+// articleURLs lists an article and its translations, linked to each other with hreflang. With
+// news, each URL is also described as a news article.
+func (s *SitemapService) articleURLs(article *models.Article, translations []models.ArticleTranslation, news func(lang, title string) *sitemap.News) []sitemap.URL {
+	group := []sitemap.URL{{
+		Loc:     publicArticleURL(s.cfg.SiteURL, article.Slug, ""),
+		LastMod: article.UpdatedAt,
+		Lang:    article.Language,
+	}}
+	if news != nil {
+		group[0].News = news(article.Language, article.Title)
+	}
+	for _, translation := range translations {
+		if translation.Language == article.Language {
+			continue
+		}
+		entry := sitemap.URL{
+			Loc:     publicArticleURL(s.cfg.SiteURL, translation.Slug, translation.Language),
+			LastMod: translation.UpdatedAt,
+			Lang:    translation.Language,
+		}
+		if news != nil {
+			entry.News = news(translation.Language, translation.Title)
+		}
+		group = append(group, entry)
+	}
+	return sitemap.Localize(group)
+}
+
+// pageURLs lists a page and its active translations, linked to each other with hreflang
+func (s *SitemapService) pageURLs(page *models.Page) []sitemap.URL {
+	group := []sitemap.URL{{
+		Loc:     publicPageURL(s.cfg.SiteURL, page, page.Slug, ""),
+		LastMod: page.UpdatedAt,
+		Lang:    page.Language,
+	}}
+	for _, translation := range page.Translations {
+		if translation.Language == page.Language {
+			continue
+		}
+		group = append(group, sitemap.URL{
+			Loc:     publicPageURL(s.cfg.SiteURL, page, translation.Slug, translation.Language),
+			LastMod: translation.UpdatedAt,
+			Lang:    translation.Language,
+		})
+	}
+	return sitemap.Localize(group)
+}
+
+// fileURL is the public address of a sitemap file
+func (s *SitemapService) fileURL(name string) string {
+	if name == sitemap.IndexFile {
+		return s.cfg.BaseURL + "/" + name
+	}
+	return s.cfg.BaseURL + "/sitemaps/" + name
+}
+
+// publicPageURL is the address of a page on the public site, in a translation when lang is
+// given. The homepage lives at the site root.
+func publicPageURL(siteURL string, page *models.Page, slug, lang string) string {
+	link := siteURL + "/pages/" + url.PathEscape(slug)
+	if page.IsHomepage {
+		link = siteURL + "/"
+	}
+	if lang != "" {
+		link += "?lang=" + url.QueryEscape(lang)
+	}
+	return link
+}
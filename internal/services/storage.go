@@ -4,11 +4,21 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"news/internal/config"
 	"news/internal/storage"
 	"os"
+	"strings"
 )
 
-var storageService storage.Storage
+var (
+	storageService storage.Storage
+
+	// localStoragePath is the directory of the local backend, empty when S3 is used
+	localStoragePath string
+
+	// defaultStoragePublicURL is the base URL of stored files unless STORAGE_PUBLIC_URL is set
+	defaultStoragePublicURL = "/uploads"
+)
 
 // init initializes the storage service based on environment configuration
 func init() {
@@ -18,9 +28,14 @@ func init() {
 		if err != nil {
 			log.Printf("Failed to initialize S3 storage: %v, falling back to local storage", err)
 			localPath := os.Getenv("LOCAL_STORAGE_PATH")
+			if localPath == "" {
+				localPath = "./uploads"
+			}
 			storageService = storage.NewLocalStorage(localPath)
+			localStoragePath = localPath
 		} else {
 			storageService = s3Storage
+			defaultStoragePublicURL = s3PublicURL()
 			log.Println("Using S3 storage for file uploads")
 		}
 	} else {
@@ -29,6 +44,7 @@ func init() {
 			localPath = "./uploads"
 		}
 		storageService = storage.NewLocalStorage(localPath)
+		localStoragePath = localPath
 		log.Println("Using local storage for file uploads")
 	}
 }
@@ -38,6 +54,30 @@ func GetStorageService() storage.Storage {
 	return storageService
 }
 
+// GetLocalStoragePath returns the directory of the local storage backend, or "" when files are
+// stored in S3
+func GetLocalStoragePath() string {
+	return localStoragePath
+}
+
+// StoragePublicURL returns the public URL of a stored file
+func StoragePublicURL(key string) string {
+	base := config.GetMediaConfig().PublicURL
+	if base == "" {
+		base = defaultStoragePublicURL
+	}
+	return strings.TrimRight(base, "/") + "/" + strings.TrimLeft(key, "/")
+}
+
+// s3PublicURL is the URL objects are served from when STORAGE_PUBLIC_URL is not set
+func s3PublicURL() string {
+	bucket := os.Getenv("S3_BUCKET")
+	if endpoint := os.Getenv("S3_ENDPOINT"); endpoint != "" {
+		return strings.TrimRight(endpoint, "/") + "/" + bucket
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, os.Getenv("AWS_REGION"))
+}
+
 // UploadFile handles file uploads to the configured storage
 func UploadFile(file io.Reader, filename string) (string, error) {
 	url, err := storageService.Upload(file, filename)
@@ -0,0 +1,399 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"news/internal/database"
+	"news/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrSubscriptionNotFound is returned when a subscription or its token does not exist
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+	// ErrSubscriptionUnconfirmed is returned when a subscription cannot be turned on before its
+	// email address is confirmed
+	ErrSubscriptionUnconfirmed = errors.New("subscription email address is not confirmed")
+)
+
+// confirmationResendInterval limits how often a confirmation email is sent for the same subscription
+const confirmationResendInterval = 10 * time.Minute
+
+// SubscriptionRequest describes what a subscriber wants to receive
+type SubscriptionRequest struct {
+	Email      string `json:"email"`
+	Type       string `json:"type" binding:"required"` // newsletter, notifications, category, tag, author
+	CategoryID *uint  `json:"category_id"`
+	TagID      *uint  `json:"tag_id"`
+	AuthorID   *uint  `json:"author_id"`
+	Language   string `json:"language"`
+}
+
+// SubscribeResult reports the outcome of a subscribe request
+type SubscribeResult struct {
+	Subscription *models.Subscription
+	// SendConfirmation is true when a double opt-in email should be sent
+	SendConfirmation bool
+}
+
+// Subscribe creates or revives a subscription. Subscriptions start inactive until the email
+// address is confirmed through the token, unless confirmed is set because the caller already
+// proved ownership of the address (a verified account subscribing with its own email). userID
+// must only be set when the address is the account's own email.
+func Subscribe(req SubscriptionRequest, userID *uint, confirmed bool) (*SubscribeResult, error) {
+	address, err := mail.ParseAddress(strings.TrimSpace(req.Email))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid email address", ErrValidation)
+	}
+
+	subscription := models.Subscription{
+		UserID:     userID,
+		Email:      strings.ToLower(address.Address),
+		Type:       req.Type,
+		CategoryID: req.CategoryID,
+		TagID:      req.TagID,
+		AuthorID:   req.AuthorID,
+		Language:   req.Language,
+	}
+	if !subscription.ValidateSubscriptionType() {
+		return nil, fmt.Errorf("%w: invalid subscription type", ErrValidation)
+	}
+	if !subscription.ValidateTarget() {
+		return nil, fmt.Errorf("%w: %s subscriptions require exactly the matching target id", ErrValidation, subscription.Type)
+	}
+	if err := validateSubscriptionTarget(subscription); err != nil {
+		return nil, err
+	}
+
+	result := &SubscribeResult{}
+	now := time.Now()
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var existing models.Subscription
+		err := subscriptionTargetQuery(tx, subscription).First(&existing).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if err == nil {
+			updates := map[string]interface{}{}
+			if subscription.Language != "" {
+				updates["language"] = subscription.Language
+			}
+			// An existing email-only subscription is only claimed once the address is proven
+			if userID != nil && existing.UserID == nil && confirmed {
+				updates["user_id"] = *userID
+			}
+
+			switch {
+			case existing.IsActive:
+				// Already subscribed, nothing to confirm
+			case confirmed:
+				updates["is_active"] = true
+				updates["confirmed_at"] = now
+				updates["unsubscribed_at"] = nil
+			default:
+				// Pending or previously cancelled: ask for confirmation again
+				updates["unsubscribed_at"] = nil
+				updates["confirmed_at"] = nil
+				result.SendConfirmation = existing.ConfirmationSentAt == nil ||
+					existing.UnsubscribedAt != nil ||
+					now.Sub(*existing.ConfirmationSentAt) > confirmationResendInterval
+			}
+
+			if len(updates) > 0 {
+				if err := tx.Model(&existing).Updates(updates).Error; err != nil {
+					return err
+				}
+			}
+			result.Subscription = &existing
+			return tx.First(result.Subscription, existing.ID).Error
+		}
+
+		token, err := generateSubscriptionToken()
+		if err != nil {
+			return err
+		}
+		subscription.Token = token
+		subscription.IsActive = confirmed
+		if confirmed {
+			subscription.ConfirmedAt = &now
+		}
+
+		// Select all fields so an inactive subscription is not flipped by the column default
+		if err := tx.Select("*").Omit("User", "Category", "Tag", "Author").Create(&subscription).Error; err != nil {
+			return err
+		}
+
+		result.Subscription = &subscription
+		result.SendConfirmation = !confirmed
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrValidation) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	return result, nil
+}
+
+// ConfirmSubscription completes double opt-in for the subscription with the given token. A
+// verified account with the confirmed email address is linked to the subscription so that it
+// also receives real-time alerts.
+func ConfirmSubscription(token string) (*models.Subscription, error) {
+	subscription, err := GetSubscriptionByToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if subscription.IsActive && subscription.ConfirmedAt != nil {
+		return subscription, nil
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"is_active":       true,
+		"confirmed_at":    now,
+		"unsubscribed_at": nil,
+	}
+	if subscription.UserID == nil {
+		var user models.User
+		if err := database.DB.Select("id").Where("LOWER(email) = ? AND is_verified = ?", subscription.Email, true).First(&user).Error; err == nil {
+			updates["user_id"] = user.ID
+		}
+	}
+
+	if err := database.DB.Model(subscription).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	return GetSubscriptionByToken(token)
+}
+
+// UnsubscribeByToken cancels the subscription with the given token. It is idempotent so that
+// repeated one-click requests from mail clients succeed.
+func UnsubscribeByToken(token string) (*models.Subscription, error) {
+	subscription, err := GetSubscriptionByToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if subscription.UnsubscribedAt != nil {
+		return subscription, nil
+	}
+
+	if err := database.DB.Model(subscription).Updates(map[string]interface{}{
+		"is_active":       false,
+		"unsubscribed_at": time.Now(),
+	}).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	return GetSubscriptionByToken(token)
+}
+
+// GetSubscriptionByToken retrieves a subscription by its confirmation/unsubscribe token. Anyone
+// holding a link can call it, so only the subscription's own fields are loaded.
+func GetSubscriptionByToken(token string) (*models.Subscription, error) {
+	if token == "" {
+		return nil, ErrSubscriptionNotFound
+	}
+
+	var subscription models.Subscription
+	err := database.DB.Where("token = ?", token).First(&subscription).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSubscriptionNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return &subscription, nil
+}
+
+// GetUserSubscriptions lists the subscriptions that belong to a user, including email-only
+// subscriptions made with the account's address before signing in once that address is verified
+func GetUserSubscriptions(userID uint) ([]models.Subscription, error) {
+	user, err := getSubscriptionUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	return listUserSubscriptions(user)
+}
+
+// UpdateUserSubscription changes the active state or language of a user's subscription. A
+// subscription can only be turned on without confirmation if its address was confirmed before
+// or is the user's own verified email address.
+func UpdateUserSubscription(userID, subscriptionID uint, isActive *bool, language *string) (*models.Subscription, error) {
+	user, err := getSubscriptionUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	subscription, err := findUserSubscription(user, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+	if language != nil {
+		updates["language"] = *language
+	}
+	if isActive != nil {
+		updates["is_active"] = *isActive
+		if *isActive {
+			if !subscription.CanBeActivatedBy(user) {
+				return nil, ErrSubscriptionUnconfirmed
+			}
+			updates["unsubscribed_at"] = nil
+			if subscription.ConfirmedAt == nil {
+				updates["confirmed_at"] = time.Now()
+			}
+		} else {
+			updates["unsubscribed_at"] = time.Now()
+		}
+	}
+	// Claim email-only subscriptions made with the account's verified address
+	if subscription.UserID == nil {
+		updates["user_id"] = userID
+	}
+
+	if len(updates) > 0 {
+		if err := database.DB.Model(subscription).Updates(updates).Error; err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+		}
+	}
+
+	return findUserSubscription(user, subscriptionID)
+}
+
+// DeleteUserSubscription removes a user's subscription
+func DeleteUserSubscription(userID, subscriptionID uint) error {
+	subscription, err := getUserSubscription(userID, subscriptionID)
+	if err != nil {
+		return err
+	}
+
+	if err := database.DB.Delete(subscription).Error; err != nil {
+		return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return nil
+}
+
+// MarkConfirmationSent records when the double opt-in email was sent
+func MarkConfirmationSent(subscriptionID uint) error {
+	return database.DB.Model(&models.Subscription{}).
+		Where("id = ?", subscriptionID).
+		Update("confirmation_sent_at", time.Now()).Error
+}
+
+func getUserSubscription(userID, subscriptionID uint) (*models.Subscription, error) {
+	user, err := getSubscriptionUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	return findUserSubscription(user, subscriptionID)
+}
+
+func findUserSubscription(user *models.User, subscriptionID uint) (*models.Subscription, error) {
+	subscriptions, err := listUserSubscriptions(user)
+	if err != nil {
+		return nil, err
+	}
+	for i := range subscriptions {
+		if subscriptions[i].ID == subscriptionID {
+			return &subscriptions[i], nil
+		}
+	}
+	return nil, ErrSubscriptionNotFound
+}
+
+func getSubscriptionUser(userID uint) (*models.User, error) {
+	var user models.User
+	if err := database.DB.Select("id", "email", "is_verified").First(&user, userID).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return &user, nil
+}
+
+// listUserSubscriptions lists the subscriptions attached to a user and, when the account's email
+// is verified, the email-only subscriptions made with that address
+func listUserSubscriptions(user *models.User) ([]models.Subscription, error) {
+	query := database.DB.Preload("Category").Preload("Tag").Preload("Author")
+	if user.IsVerified {
+		query = query.Where("user_id = ? OR (user_id IS NULL AND LOWER(email) = ?)", user.ID, strings.ToLower(user.Email))
+	} else {
+		query = query.Where("user_id = ?", user.ID)
+	}
+
+	var subscriptions []models.Subscription
+	if err := query.Order("created_at DESC").Find(&subscriptions).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return subscriptions, nil
+}
+
+// subscriptionTargetQuery matches subscriptions of the same email, type and target
+func subscriptionTargetQuery(tx *gorm.DB, subscription models.Subscription) *gorm.DB {
+	query := tx.Where("email = ? AND type = ?", subscription.Email, subscription.Type)
+
+	targets := []struct {
+		column string
+		value  *uint
+	}{
+		{"category_id", subscription.CategoryID},
+		{"tag_id", subscription.TagID},
+		{"author_id", subscription.AuthorID},
+	}
+	for _, target := range targets {
+		if target.value == nil {
+			query = query.Where(target.column + " IS NULL")
+		} else {
+			query = query.Where(target.column+" = ?", *target.value)
+		}
+	}
+	return query
+}
+
+// validateSubscriptionTarget checks that the subscribed category, tag or author exists
+func validateSubscriptionTarget(subscription models.Subscription) error {
+	var (
+		model interface{}
+		id    *uint
+	)
+	switch subscription.Type {
+	case "category":
+		model, id = &models.Category{}, subscription.CategoryID
+	case "tag":
+		model, id = &models.Tag{}, subscription.TagID
+	case "author":
+		model, id = &models.User{}, subscription.AuthorID
+	default:
+		return nil
+	}
+
+	var count int64
+	if err := database.DB.Model(model).Where("id = ?", *id).Count(&count).Error; err != nil {
+		return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: %s not found", ErrValidation, subscription.Type)
+	}
+	return nil
+}
+
+// generateSubscriptionToken creates an unguessable token for confirmation and unsubscribe links
+func generateSubscriptionToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
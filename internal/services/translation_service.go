@@ -872,6 +872,18 @@ func (ts *AITranslationService) TranslateArticle(articleID uint, targetLanguages
 		}
 
 		log.Printf("Successfully created translation for article %d in %s", articleID, targetLang)
+
+		EmitWebhookEvent(models.WebhookEventTranslationCompleted, map[string]interface{}{
+			"entity_type":      "article",
+			"entity_id":        articleID,
+			"translation_id":   translation.ID,
+			"source_language":  sourceLang,
+			"language":         targetLang,
+			"title":            translation.Title,
+			"slug":             translation.Slug,
+			"status":           translation.Status,
+			"translation_type": translation.TranslationType,
+		})
 	}
 
 	return nil
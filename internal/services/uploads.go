@@ -0,0 +1,555 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"news/internal/config"
+	"news/internal/database"
+	"news/internal/json"
+	"news/internal/models"
+	"news/internal/storage"
+	"news/internal/upload"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrUploadNotFound is returned for unknown upload sessions and sessions of other users
+	ErrUploadNotFound = errors.New("upload not found")
+	// ErrUploadClosed is returned when writing to a completed, aborted or expired upload
+	ErrUploadClosed = errors.New("upload is no longer active")
+	// ErrUploadOffset is returned when a chunk does not continue the upload, including when
+	// another request wrote the same chunk first or is still writing it
+	ErrUploadOffset = errors.New("upload offset mismatch")
+	// ErrUploadChecksum is returned when a chunk or the completed file fails verification
+	ErrUploadChecksum = errors.New("upload checksum mismatch")
+)
+
+// uploadChunkClaimTimeout is how long a request may take to store a chunk before another
+// request for the same offset can take over
+const uploadChunkClaimTimeout = 10 * time.Minute
+
+// videoExtensions are the video formats accepted by resumable uploads
+var videoExtensions = map[string]bool{
+	".mp4": true, ".mov": true, ".avi": true, ".mkv": true, ".webm": true, ".m4v": true,
+}
+
+var (
+	uploadServiceInstance *UploadService
+	uploadServiceOnce     sync.Once
+)
+
+// CreateUploadRequest starts a resumable upload
+type CreateUploadRequest struct {
+	Kind     string `json:"kind" binding:"required" example:"video"` // media or video
+	FileName string `json:"file_name" binding:"required" example:"interview.mp4"`
+	MimeType string `json:"mime_type" example:"video/mp4"`
+	Size     int64  `json:"size" binding:"required" example:"734003200"`
+	// Checksum is the SHA-256 of the whole file as hex, base64 or "sha256 <base64>"
+	Checksum string                `json:"checksum"`
+	Metadata models.UploadMetadata `json:"metadata"`
+}
+
+// UploadService runs resumable uploads: chunks are stored as the parts of a multipart upload
+// and the Media or Video record is created once the last chunk has been written
+type UploadService struct {
+	db      *gorm.DB
+	storage storage.MultipartStorage
+	media   *MediaService
+	cfg     *config.MediaConfig
+	url     func(key string) string
+}
+
+// NewUploadService creates an upload service. Completed media uploads are recorded through
+// media, and url builds the public URL of uploaded videos.
+func NewUploadService(db *gorm.DB, store storage.MultipartStorage, media *MediaService, cfg *config.MediaConfig, url func(key string) string) *UploadService {
+	return &UploadService{db: db, storage: store, media: media, cfg: cfg, url: url}
+}
+
+// GetUploadService returns the upload service using the configured storage backend, or nil if
+// the backend cannot assemble multipart uploads
+func GetUploadService() *UploadService {
+	uploadServiceOnce.Do(func() {
+		store, ok := GetStorageService().(storage.MultipartStorage)
+		if !ok {
+			log.Printf("Warning: Storage backend does not support multipart uploads, resumable uploads are disabled")
+			return
+		}
+		uploadServiceInstance = NewUploadService(database.DB, store, GetMediaService(), config.GetMediaConfig(), StoragePublicURL)
+	})
+	return uploadServiceInstance
+}
+
+// chunkSize returns the configured chunk size, raised to the S3 minimum part size
+func (s *UploadService) chunkSize() int64 {
+	if s.cfg.UploadChunkSize < storage.MinPartSize {
+		return storage.MinPartSize
+	}
+	return s.cfg.UploadChunkSize
+}
+
+// Create starts a resumable upload for a user
+func (s *UploadService) Create(userID uint, req CreateUploadRequest) (*models.UploadSession, error) {
+	req.Kind = strings.ToLower(strings.TrimSpace(req.Kind))
+	req.FileName = filepath.Base(strings.TrimSpace(req.FileName))
+	if req.FileName == "." || req.FileName == "/" {
+		return nil, fmt.Errorf("%w: file_name is required", ErrValidation)
+	}
+	if req.Size <= 0 {
+		return nil, fmt.Errorf("%w: size must be positive", ErrValidation)
+	}
+	if req.Size > s.cfg.MaxResumableUploadSize {
+		return nil, fmt.Errorf("%w: maximum size is %d MB", ErrMediaTooLarge, s.cfg.MaxResumableUploadSize>>20)
+	}
+
+	var prefix string
+	switch req.Kind {
+	case models.UploadKindMedia:
+		prefix = "media"
+	case models.UploadKindVideo:
+		prefix = "videos"
+		if !videoExtensions[strings.ToLower(filepath.Ext(req.FileName))] {
+			return nil, fmt.Errorf("%w: invalid video format", ErrValidation)
+		}
+		if len(req.Metadata.Title) > 200 {
+			return nil, fmt.Errorf("%w: title must be at most 200 characters", ErrValidation)
+		}
+	default:
+		return nil, fmt.Errorf("%w: kind must be media or video", ErrValidation)
+	}
+
+	checksum, err := upload.NormalizeChecksum(req.Checksum)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	metadata, err := json.Marshal(req.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	key := newStorageKey(prefix, req.FileName)
+	uploadID, err := s.storage.CreateMultipartUpload(key, req.MimeType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start upload: %w", err)
+	}
+
+	session := models.UploadSession{
+		ID:              uuid.New().String(),
+		UserID:          userID,
+		Kind:            req.Kind,
+		Status:          models.UploadStatusUploading,
+		FileName:        req.FileName,
+		MimeType:        req.MimeType,
+		Size:            req.Size,
+		ChunkSize:       s.chunkSize(),
+		Checksum:        checksum,
+		StorageKey:      key,
+		StorageUploadID: uploadID,
+		Metadata:        metadata,
+		ExpiresAt:       time.Now().Add(s.cfg.UploadSessionTTL),
+	}
+	if err := s.db.Create(&session).Error; err != nil {
+		s.abortStorage(&session)
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return &session, nil
+}
+
+// Get returns an upload session of a user
+func (s *UploadService) Get(id string, userID uint) (*models.UploadSession, error) {
+	var session models.UploadSession
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUploadNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return &session, nil
+}
+
+// WriteChunk stores the chunk starting at offset. checksum is an optional tus Upload-Checksum
+// header. Writing the last chunk completes the upload and creates its Media or Video record; the
+// returned bool reports whether this call completed it. A request at offset == size writes
+// nothing and retries a completion that failed, like Complete.
+func (s *UploadService) WriteChunk(id string, userID uint, offset int64, body io.Reader, checksum string) (*models.UploadSession, bool, error) {
+	session, err := s.Get(id, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	if offset == session.Size && session.Offset == session.Size {
+		return s.finish(session)
+	}
+	if err := s.checkActive(session); err != nil {
+		return nil, false, err
+	}
+
+	digest, err := upload.ParseChecksum(checksum)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	data, err := io.ReadAll(io.LimitReader(body, session.ChunkSize+1))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read chunk: %w", err)
+	}
+
+	number, err := upload.PartNumber(offset, session.Offset, int64(len(data)), session.Size, session.ChunkSize)
+	if err != nil {
+		if errors.Is(err, upload.ErrOffsetMismatch) {
+			return nil, false, fmt.Errorf("%w: upload is at offset %d", ErrUploadOffset, session.Offset)
+		}
+		return nil, false, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	if err := upload.VerifyChunk(data, digest); err != nil {
+		return nil, false, fmt.Errorf("%w: chunk at offset %d", ErrUploadChecksum, offset)
+	}
+
+	hash, err := upload.ResumeHash(session.HashState)
+	if err != nil {
+		return nil, false, err
+	}
+	hash.Write(data)
+	state, err := hash.State()
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Claim the chunk before storing it: a part uploaded again replaces the stored one, so a
+	// concurrent request for the same offset would leave a part the session has no ETag for
+	claim, err := s.claimChunk(session, offset)
+	if err != nil {
+		return nil, false, err
+	}
+
+	etag, err := s.storage.UploadPart(session.StorageKey, session.StorageUploadID, number, data)
+	if err != nil {
+		s.releaseChunk(session, claim)
+		return nil, false, fmt.Errorf("failed to store chunk: %w", err)
+	}
+	parts := append(session.PartList(), models.UploadPart{Number: number, ETag: etag})
+	encodedParts, err := json.Marshal(parts)
+	if err != nil {
+		s.releaseChunk(session, claim)
+		return nil, false, err
+	}
+
+	// Only advance the offset if the claim was not taken over by another request
+	updates := map[string]interface{}{
+		"upload_offset":    offset + int64(len(data)),
+		"parts":            encodedParts,
+		"hash_state":       state,
+		"chunk_claim":      "",
+		"chunk_claimed_at": nil,
+		"expires_at":       time.Now().Add(s.cfg.UploadSessionTTL),
+	}
+	result := s.db.Model(&models.UploadSession{}).
+		Where("id = ? AND status = ? AND upload_offset = ? AND chunk_claim = ?", session.ID, models.UploadStatusUploading, offset, claim).
+		Updates(updates)
+	if result.Error != nil {
+		return nil, false, fmt.Errorf("%w: %v", ErrDatabaseError, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, false, fmt.Errorf("%w: chunk at offset %d was written by another request", ErrUploadOffset, offset)
+	}
+
+	session.Offset = offset + int64(len(data))
+	session.Parts = encodedParts
+	session.HashState = state
+	session.ExpiresAt = updates["expires_at"].(time.Time)
+
+	if session.Offset < session.Size {
+		return session, false, nil
+	}
+	if err := s.complete(session, hash.Sum()); err != nil {
+		return nil, false, err
+	}
+	return session, true, nil
+}
+
+// claimChunk reserves the chunk at offset for one request and returns the claim. It fails with
+// ErrUploadOffset when the chunk was written, or is being written, by another request.
+func (s *UploadService) claimChunk(session *models.UploadSession, offset int64) (string, error) {
+	claim := uuid.New().String()
+	now := time.Now()
+	result := s.db.Model(&models.UploadSession{}).
+		Where("id = ? AND status = ? AND upload_offset = ?", session.ID, models.UploadStatusUploading, offset).
+		Where("chunk_claim IS NULL OR chunk_claim = '' OR chunk_claimed_at < ?", now.Add(-uploadChunkClaimTimeout)).
+		Updates(map[string]interface{}{"chunk_claim": claim, "chunk_claimed_at": now})
+	if result.Error != nil {
+		return "", fmt.Errorf("%w: %v", ErrDatabaseError, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return "", fmt.Errorf("%w: chunk at offset %d is already being written", ErrUploadOffset, offset)
+	}
+	return claim, nil
+}
+
+// releaseChunk gives up a chunk claim after the chunk could not be stored, so it can be retried
+// right away
+func (s *UploadService) releaseChunk(session *models.UploadSession, claim string) {
+	if err := s.db.Model(&models.UploadSession{}).
+		Where("id = ? AND chunk_claim = ?", session.ID, claim).
+		Updates(map[string]interface{}{"chunk_claim": "", "chunk_claimed_at": nil}).Error; err != nil {
+		log.Printf("Warning: Failed to release chunk of upload %s: %v", session.ID, err)
+	}
+}
+
+// Complete finishes an upload whose chunks have all been written, retrying a completion that
+// failed after the last chunk was stored. Completing a completed upload returns it unchanged;
+// the bool reports whether this call completed it.
+func (s *UploadService) Complete(id string, userID uint) (*models.UploadSession, bool, error) {
+	session, err := s.Get(id, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	return s.finish(session)
+}
+
+// finish completes an upload whose offset has reached its size
+func (s *UploadService) finish(session *models.UploadSession) (*models.UploadSession, bool, error) {
+	if session.Status == models.UploadStatusCompleted {
+		return session, false, nil
+	}
+	if err := s.checkActive(session); err != nil {
+		return nil, false, err
+	}
+	if session.Offset != session.Size {
+		return nil, false, fmt.Errorf("%w: upload is at offset %d of %d", ErrUploadOffset, session.Offset, session.Size)
+	}
+
+	hash, err := upload.ResumeHash(session.HashState)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := s.complete(session, hash.Sum()); err != nil {
+		return nil, false, err
+	}
+	return session, true, nil
+}
+
+// Abort cancels an active upload and discards its chunks
+func (s *UploadService) Abort(id string, userID uint) (*models.UploadSession, error) {
+	session, err := s.Get(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != models.UploadStatusUploading {
+		return nil, ErrUploadClosed
+	}
+	if err := s.close(session, models.UploadStatusAborted); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// checkActive refuses chunks for closed uploads, expiring sessions that have timed out
+func (s *UploadService) checkActive(session *models.UploadSession) error {
+	if session.Status != models.UploadStatusUploading {
+		return ErrUploadClosed
+	}
+	if time.Now().After(session.ExpiresAt) {
+		if err := s.close(session, models.UploadStatusExpired); err != nil {
+			log.Printf("Warning: Failed to expire upload %s: %v", session.ID, err)
+		}
+		return ErrUploadClosed
+	}
+	return nil
+}
+
+// complete assembles the uploaded parts, verifies the file checksum and creates the record.
+// Failures to assemble the file or update the session leave the upload active so completion can
+// be retried; a record that cannot be created aborts it.
+func (s *UploadService) complete(session *models.UploadSession, sum string) error {
+	if session.Checksum != "" && session.Checksum != sum {
+		if err := s.close(session, models.UploadStatusAborted); err != nil {
+			log.Printf("Warning: Failed to abort upload %s: %v", session.ID, err)
+		}
+		return fmt.Errorf("%w: file checksum is %s", ErrUploadChecksum, sum)
+	}
+
+	// A cleared storage upload ID means an earlier attempt already assembled the file
+	if session.StorageUploadID != "" {
+		parts := session.PartList()
+		completed := make([]storage.CompletedPart, len(parts))
+		for i, part := range parts {
+			completed[i] = storage.CompletedPart{Number: part.Number, ETag: part.ETag}
+		}
+		if err := s.storage.CompleteMultipartUpload(session.StorageKey, session.StorageUploadID, completed); err != nil {
+			return fmt.Errorf("failed to assemble upload: %w", err)
+		}
+		if err := s.db.Model(session).Update("storage_upload_id", "").Error; err != nil {
+			return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+		}
+		session.StorageUploadID = ""
+	}
+
+	now := time.Now()
+	metadata := session.MetadataValue()
+	// recordErr is set when the record could not be created; the upload is then aborted and its
+	// file deleted, so it cannot be retried
+	var recordErr error
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Claiming the session first stops a concurrent retry from recording the file twice
+		result := tx.Model(&models.UploadSession{}).
+			Where("id = ? AND status = ?", session.ID, models.UploadStatusUploading).
+			Updates(map[string]interface{}{
+				"status":       models.UploadStatusCompleted,
+				"completed_at": now,
+				"hash_state":   nil,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("%w: %v", ErrDatabaseError, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrUploadClosed
+		}
+
+		switch session.Kind {
+		case models.UploadKindMedia:
+			media, err := s.media.withDB(tx).Register(session.StorageKey, session.Size, MediaUpload{
+				OriginalName: session.FileName,
+				MimeType:     session.MimeType,
+				AltText:      metadata.AltText,
+				Caption:      metadata.Caption,
+				UploadedBy:   session.UserID,
+			})
+			if err != nil {
+				recordErr = err
+				return err
+			}
+			session.MediaID = &media.ID
+
+		case models.UploadKindVideo:
+			video, err := s.createVideo(tx, session, metadata)
+			if err != nil {
+				recordErr = err
+				return err
+			}
+			session.VideoID = &video.ID
+		}
+
+		if err := tx.Model(&models.UploadSession{}).Where("id = ?", session.ID).
+			Updates(map[string]interface{}{"media_id": session.MediaID, "video_id": session.VideoID}).Error; err != nil {
+			return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+		}
+		return nil
+	})
+	if err != nil {
+		session.MediaID, session.VideoID = nil, nil
+		if recordErr != nil {
+			s.markFailed(session)
+		}
+		return err
+	}
+
+	session.Status = models.UploadStatusCompleted
+	session.CompletedAt = &now
+	session.HashState = nil
+	return nil
+}
+
+// createVideo records a completed video upload. Processing is left to the caller, which queues
+// the video workflow.
+func (s *UploadService) createVideo(tx *gorm.DB, session *models.UploadSession, metadata models.UploadMetadata) (*models.Video, error) {
+	title := strings.TrimSpace(metadata.Title)
+	if title == "" {
+		title = strings.TrimSuffix(session.FileName, path.Ext(session.FileName))
+	}
+	video := models.Video{
+		Title:       title,
+		Description: metadata.Description,
+		VideoURL:    s.url(session.StorageKey),
+		StorageKey:  session.StorageKey,
+		FileSize:    session.Size,
+		CategoryID:  metadata.CategoryID,
+		Tags:        metadata.Tags,
+		UserID:      session.UserID,
+		Status:      "pending",
+		IsPublic:    metadata.IsPublic == nil || *metadata.IsPublic,
+	}
+
+	// Select("*") so a false is_public is not replaced by the column default
+	if err := tx.Select("*").Omit("Category", "User", "Comments", "Votes", "Views").Create(&video).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return &video, nil
+}
+
+// markFailed aborts an upload whose file was assembled but could not be recorded, and deletes
+// the file
+func (s *UploadService) markFailed(session *models.UploadSession) {
+	if err := s.db.Model(session).Update("status", models.UploadStatusAborted).Error; err != nil {
+		log.Printf("Warning: Failed to mark upload %s as aborted: %v", session.ID, err)
+	}
+	session.Status = models.UploadStatusAborted
+	if err := s.storage.Delete(session.StorageKey); err != nil {
+		log.Printf("Warning: Failed to delete stored file %s: %v", session.StorageKey, err)
+	}
+}
+
+// close ends an active upload with the given status and discards its chunks
+func (s *UploadService) close(session *models.UploadSession, status string) error {
+	result := s.db.Model(&models.UploadSession{}).
+		Where("id = ? AND status = ?", session.ID, models.UploadStatusUploading).
+		Updates(map[string]interface{}{"status": status, "hash_state": nil})
+	if result.Error != nil {
+		return fmt.Errorf("%w: %v", ErrDatabaseError, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrUploadClosed
+	}
+	session.Status = status
+	s.abortStorage(session)
+	return nil
+}
+
+func (s *UploadService) abortStorage(session *models.UploadSession) {
+	// Assembled files that were never recorded are deleted instead
+	if session.StorageUploadID == "" {
+		if err := s.storage.Delete(session.StorageKey); err != nil {
+			log.Printf("Warning: Failed to delete stored file %s: %v", session.StorageKey, err)
+		}
+		return
+	}
+	if err := s.storage.AbortMultipartUpload(session.StorageKey, session.StorageUploadID); err != nil {
+		log.Printf("Warning: Failed to abort multipart upload for %s: %v", session.ID, err)
+	}
+}
+
+// ExpireUploadSessions expires up to batch uploads that have been idle past their expiry and
+// discards their chunks. It returns the number of sessions expired.
+func ExpireUploadSessions(now time.Time, batch int) (int, error) {
+	if database.DB == nil {
+		return 0, nil
+	}
+	service := GetUploadService()
+	if service == nil {
+		return 0, nil
+	}
+
+	var sessions []models.UploadSession
+	if err := service.db.Where("status = ? AND expires_at < ?", models.UploadStatusUploading, now).
+		Order("expires_at").Limit(batch).Find(&sessions).Error; err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	expired := 0
+	for i := range sessions {
+		if err := service.close(&sessions[i], models.UploadStatusExpired); err != nil {
+			if !errors.Is(err, ErrUploadClosed) {
+				log.Printf("Failed to expire upload %s: %v", sessions[i].ID, err)
+			}
+			continue
+		}
+		expired++
+	}
+	return expired, nil
+}
@@ -5,14 +5,18 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"os"
-	"os/exec"
+	"path"
 	"path/filepath"
-	"strconv"
 	"strings"
+	"time"
 
+	"news/internal/config"
+	"news/internal/hls"
 	"news/internal/json"
 	"news/internal/models"
+	"news/internal/pubsub"
 	"news/internal/storage"
 
 	"gorm.io/gorm"
@@ -49,22 +53,32 @@ type VideoProcessingService struct {
 	db          *gorm.DB
 	storage     storage.Storage
 	aiService   *AIService
+	cfg         *config.VideoConfig
+	executor    hls.Executor
 	ffmpegPath  string
 	tempDir     string
 	maxDuration int // Maximum video duration in seconds
 }
 
 func NewVideoProcessingService(db *gorm.DB, storage storage.Storage, ai *AIService) *VideoProcessingService {
+	cfg := config.GetVideoConfig()
 	return &VideoProcessingService{
 		db:          db,
 		storage:     storage,
 		aiService:   ai,
-		ffmpegPath:  "ffmpeg", // Should be configurable
-		tempDir:     "/tmp",
+		cfg:         cfg,
+		executor:    hls.ExecExecutor{},
+		ffmpegPath:  cfg.FFmpegPath,
+		tempDir:     cfg.TempDir,
 		maxDuration: 300, // 5 minutes max for short-form content
 	}
 }
 
+// SetExecutor replaces the executor that runs ffmpeg and ffprobe, for example with a fake
+func (s *VideoProcessingService) SetExecutor(executor hls.Executor) {
+	s.executor = executor
+}
+
 // ProcessingJob represents a video processing task
 type ProcessingJob struct {
 	Type     string `json:"type"`
@@ -131,16 +145,7 @@ func (q *VideoProcessingQueue) ProcessJobs(ctx context.Context) error {
 }
 
 // VideoMetadata represents video file information
-type VideoMetadata struct {
-	Duration   float64 `json:"duration"`
-	FileSize   int64   `json:"file_size"`
-	Width      int     `json:"width"`
-	Height     int     `json:"height"`
-	FrameRate  float64 `json:"frame_rate"`
-	Bitrate    int     `json:"bitrate"`
-	VideoCodec string  `json:"video_codec"`
-	AudioCodec string  `json:"audio_codec"`
-}
+type VideoMetadata = hls.Metadata
 
 // VideoAnalysisResult represents AI analysis results
 type VideoAnalysisResult struct {
@@ -154,71 +159,7 @@ type VideoAnalysisResult struct {
 
 // GetVideoMetadata extracts metadata from video file using ffprobe
 func (s *VideoProcessingService) GetVideoMetadata(ctx context.Context, videoPath string) (*VideoMetadata, error) {
-	cmd := exec.CommandContext(ctx, "ffprobe",
-		"-v", "quiet",
-		"-print_format", "json",
-		"-show_format",
-		"-show_streams",
-		videoPath)
-
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get video metadata: %w", err)
-	}
-
-	var probe struct {
-		Format struct {
-			Duration string `json:"duration"`
-			Size     string `json:"size"`
-		} `json:"format"`
-		Streams []struct {
-			CodecType  string `json:"codec_type"`
-			CodecName  string `json:"codec_name"`
-			Width      int    `json:"width"`
-			Height     int    `json:"height"`
-			RFrameRate string `json:"r_frame_rate"`
-			BitRate    string `json:"bit_rate"`
-		} `json:"streams"`
-	}
-
-	if err := json.Unmarshal(output, &probe); err != nil {
-		return nil, fmt.Errorf("failed to parse metadata: %w", err)
-	}
-
-	metadata := &VideoMetadata{}
-
-	if duration, err := strconv.ParseFloat(probe.Format.Duration, 64); err == nil {
-		metadata.Duration = duration
-	}
-
-	if size, err := strconv.ParseInt(probe.Format.Size, 10, 64); err == nil {
-		metadata.FileSize = size
-	}
-
-	for _, stream := range probe.Streams {
-		if stream.CodecType == "video" {
-			metadata.Width = stream.Width
-			metadata.Height = stream.Height
-			metadata.VideoCodec = stream.CodecName
-
-			if bitrate, err := strconv.Atoi(stream.BitRate); err == nil {
-				metadata.Bitrate = bitrate
-			}
-
-			// Parse frame rate from fraction format (e.g., "30/1")
-			if parts := strings.Split(stream.RFrameRate, "/"); len(parts) == 2 {
-				if num, err1 := strconv.ParseFloat(parts[0], 64); err1 == nil {
-					if den, err2 := strconv.ParseFloat(parts[1], 64); err2 == nil && den != 0 {
-						metadata.FrameRate = num / den
-					}
-				}
-			}
-		} else if stream.CodecType == "audio" {
-			metadata.AudioCodec = stream.CodecName
-		}
-	}
-
-	return metadata, nil
+	return hls.Probe(ctx, s.executor, s.cfg.FFprobePath, videoPath)
 }
 
 // GenerateThumbnail creates a thumbnail image from video
@@ -236,8 +177,7 @@ func (s *VideoProcessingService) GenerateThumbnail(ctx context.Context, videoID
 		}
 	}()
 
-	// Extract filename from VideoURL for storage interface
-	filename := filepath.Base(video.VideoURL)
+	filename := videoSourceKey(video)
 	reader, err := s.storage.Download(filename)
 	if err != nil {
 		return fmt.Errorf("failed to download video: %w", err)
@@ -280,14 +220,13 @@ func (s *VideoProcessingService) GenerateThumbnail(ctx context.Context, videoID
 	}
 
 	seekTime := metadata.Duration / 2 // Middle of video
-	cmd := exec.CommandContext(ctx, s.ffmpegPath,
+	_, err = s.executor.Run(ctx, s.ffmpegPath,
 		"-i", tempFile,
 		"-ss", fmt.Sprintf("%.2f", seekTime),
 		"-vframes", "1",
 		"-q:v", "2",
 		"-y", thumbnailPath)
-
-	if err := cmd.Run(); err != nil {
+	if err != nil {
 		return fmt.Errorf("failed to generate thumbnail: %w", err)
 	}
 
@@ -316,105 +255,260 @@ func (s *VideoProcessingService) GenerateThumbnail(ctx context.Context, videoID
 	return nil
 }
 
-// TranscodeVideo converts video to multiple formats
+// TranscodeVideo packages a video for adaptive streaming: it transcodes the HLS ladder, capped at
+// the source resolution, uploads the renditions and master playlist through storage and records
+// them on the video. Progress is tracked in a VideoProcessingJob and published to the owner.
 func (s *VideoProcessingService) TranscodeVideo(ctx context.Context, videoID uint) error {
 	video := &models.Video{}
 	if err := s.db.First(video, videoID).Error; err != nil {
 		return fmt.Errorf("video not found: %w", err)
 	}
 
-	// Download original video
-	tempFile := filepath.Join(s.tempDir, fmt.Sprintf("original_%d.mp4", videoID))
+	tracker := s.startJob(video, "transcoding")
+	result, err := s.packageHLS(ctx, video, tracker)
+	tracker.finish(err, result)
+	if err != nil {
+		return err
+	}
+
+	renditions := make(map[string]string, len(result.Outputs))
+	for _, rendition := range video.Renditions {
+		renditions[rendition.Name] = rendition.PlaylistURL
+	}
+	EmitWebhookEvent(models.WebhookEventVideoProcessed, map[string]interface{}{
+		"id":            video.ID,
+		"title":         video.Title,
+		"user_id":       video.UserID,
+		"video_url":     video.VideoURL,
+		"thumbnail_url": video.ThumbnailURL,
+		"stream_url":    video.StreamURL,
+		"renditions":    renditions,
+	})
+	return nil
+}
+
+// packageHLS runs the transcoding steps of TranscodeVideo, reporting progress as it goes:
+// download 10%, probe 15%, renditions up to 85%, upload up to 99%
+func (s *VideoProcessingService) packageHLS(ctx context.Context, video *models.Video, tracker *processingTracker) (*hls.Result, error) {
+	ladder, err := hls.SelectRenditions(s.cfg.HLSRenditions)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp(s.cfg.TempDir, fmt.Sprintf("video_%d_*", video.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
 	defer func() {
-		if err := os.Remove(tempFile); err != nil {
-			log.Printf("Warning: Failed to remove temp file %s: %v", tempFile, err)
+		if err := os.RemoveAll(dir); err != nil {
+			log.Printf("Warning: Failed to remove temp directory %s: %v", dir, err)
 		}
 	}()
 
-	// Extract filename from VideoURL for storage interface
-	filename := filepath.Base(video.VideoURL)
-	reader, err := s.storage.Download(filename)
-	if err != nil {
-		return fmt.Errorf("failed to download video: %w", err)
+	source := filepath.Join(dir, "source"+videoSourceExt(video))
+	if err := s.downloadSource(video, source); err != nil {
+		return nil, err
 	}
+	tracker.progress(10)
 
-	// Handle reader as ReadCloser if possible
-	if closer, ok := reader.(io.ReadCloser); ok {
-		defer func() {
-			if err := closer.Close(); err != nil {
-				log.Printf("Warning: Failed to close reader in TranscodeVideo: %v", err)
-			}
-		}()
+	metadata, err := hls.Probe(ctx, s.executor, s.cfg.FFprobePath, source)
+	if err != nil {
+		return nil, err
+	}
+	variants := hls.Plan(metadata.Width, metadata.Height, ladder)
+	if len(variants) == 0 {
+		return nil, fmt.Errorf("source has no usable video stream (%dx%d)", metadata.Width, metadata.Height)
 	}
+	tracker.progress(15)
 
-	file, err := os.Create(tempFile)
+	packager := &hls.Packager{Executor: s.executor, FFmpegPath: s.cfg.FFmpegPath, SegmentDuration: s.cfg.HLSSegmentSeconds}
+	output := filepath.Join(dir, "hls")
+	result, err := packager.Package(ctx, source, output, metadata, variants, func(done, total int) {
+		tracker.progress(15 + 70*done/total)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create temp file: %w", err)
+		return nil, err
 	}
-	defer func() {
-		if err := file.Close(); err != nil {
-			log.Printf("Warning: Failed to close temp file in TranscodeVideo: %v", err)
+
+	prefix := fmt.Sprintf("videos/hls/%d", video.ID)
+	files := result.Files()
+	for i, file := range files {
+		if err := s.uploadFile(filepath.Join(output, filepath.FromSlash(file)), path.Join(prefix, file)); err != nil {
+			return nil, err
 		}
-	}()
+		tracker.progress(85 + 14*(i+1)/len(files))
+	}
 
+	if err := s.recordRenditions(video, metadata, result, prefix); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// recordRenditions replaces the renditions of a video and sets its stream URL, filling in the
+// duration and resolution from the probed source when they are unknown
+func (s *VideoProcessingService) recordRenditions(video *models.Video, metadata *hls.Metadata, result *hls.Result, prefix string) error {
+	renditions := make([]models.VideoRendition, len(result.Outputs))
+	for i, output := range result.Outputs {
+		key := path.Join(prefix, output.Playlist)
+		renditions[i] = models.VideoRendition{
+			VideoID:          video.ID,
+			Name:             output.Name,
+			Width:            output.Width,
+			Height:           output.Height,
+			Bandwidth:        output.Bandwidth,
+			AverageBandwidth: output.AverageBandwidth,
+			VideoBitrate:     output.VideoBitrate,
+			Codecs:           output.Codecs,
+			PlaylistURL:      StoragePublicURL(key),
+			PlaylistKey:      key,
+			SegmentCount:     len(output.Segments),
+		}
+		if metadata.HasAudio() {
+			renditions[i].AudioBitrate = output.AudioBitrate
+		}
+	}
+
+	updates := map[string]interface{}{
+		"stream_url": StoragePublicURL(path.Join(prefix, result.Master)),
+	}
+	if video.Duration == 0 && metadata.Duration > 0 {
+		updates["duration"] = int(math.Round(metadata.Duration))
+	}
+	if video.Resolution == "" {
+		updates["resolution"] = fmt.Sprintf("%dx%d", metadata.Width, metadata.Height)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("video_id = ?", video.ID).Delete(&models.VideoRendition{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&renditions).Error; err != nil {
+			return err
+		}
+		return tx.Model(video).Updates(updates).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record renditions: %w", err)
+	}
+	video.Renditions = renditions
+	return nil
+}
+
+// downloadSource copies the original file of a video from storage to dst
+func (s *VideoProcessingService) downloadSource(video *models.Video, dst string) error {
+	reader, err := s.storage.Download(videoSourceKey(video))
+	if err != nil {
+		return fmt.Errorf("failed to download video: %w", err)
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	file, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
 	if _, err := io.Copy(file, reader); err != nil {
+		file.Close()
 		return fmt.Errorf("failed to copy video data: %w", err)
 	}
+	return file.Close()
+}
+
+func (s *VideoProcessingService) uploadFile(src, key string) error {
+	file, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err := s.storage.Upload(file, key); err != nil {
+		return fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	return nil
+}
 
-	// Transcode to different resolutions
-	resolutions := []struct {
-		name    string
-		width   int
-		height  int
-		bitrate string
-	}{
-		{"720p", 1280, 720, "2500k"},
-		{"480p", 854, 480, "1500k"},
-		{"360p", 640, 360, "800k"},
+// videoSourceExt returns the extension of a video's original file, defaulting to .mp4
+func videoSourceExt(video *models.Video) string {
+	if ext := strings.ToLower(path.Ext(videoSourceKey(video))); ext != "" && len(ext) <= 5 {
+		return ext
 	}
+	return ".mp4"
+}
 
-	for _, res := range resolutions {
-		outputFile := filepath.Join(s.tempDir, fmt.Sprintf("video_%d_%s.mp4", videoID, res.name))
-		defer func(file string) {
-			if err := os.Remove(file); err != nil {
-				log.Printf("Warning: Failed to remove output file %s: %v", file, err)
-			}
-		}(outputFile)
-
-		cmd := exec.CommandContext(ctx, s.ffmpegPath,
-			"-i", tempFile,
-			"-vf", fmt.Sprintf("scale=%d:%d", res.width, res.height),
-			"-c:v", "libx264",
-			"-b:v", res.bitrate,
-			"-c:a", "aac",
-			"-b:a", "128k",
-			"-y", outputFile)
-
-		if err := cmd.Run(); err != nil {
-			log.Printf("Failed to transcode %s: %v", res.name, err)
-			continue
-		}
+// processingTracker records the progress of a video processing step in a VideoProcessingJob
+// and publishes it to the video's owner
+type processingTracker struct {
+	db      *gorm.DB
+	job     *models.VideoProcessingJob
+	videoID uint
+	userID  uint
+	percent int
+}
 
-		// Upload transcoded video
-		transcodedFile, err := os.Open(outputFile)
-		if err != nil {
-			log.Printf("Failed to open transcoded file %s: %v", res.name, err)
-			continue
-		}
+// startJob creates the VideoProcessingJob of a processing step. Failing to record the job does
+// not stop processing.
+func (s *VideoProcessingService) startJob(video *models.Video, jobType string) *processingTracker {
+	now := time.Now()
+	job := &models.VideoProcessingJob{
+		VideoID:   video.ID,
+		JobType:   jobType,
+		Status:    "processing",
+		StartedAt: &now,
+	}
+	if err := s.db.Omit("Video").Create(job).Error; err != nil {
+		log.Printf("Warning: Failed to record %s job for video %d: %v", jobType, video.ID, err)
+		job = nil
+	}
+	t := &processingTracker{db: s.db, job: job, videoID: video.ID, userID: video.UserID}
+	t.publish("processing")
+	return t
+}
 
-		url, err := s.storage.Upload(transcodedFile, fmt.Sprintf("videos/transcoded/%d_%s.mp4", videoID, res.name))
-		if err := transcodedFile.Close(); err != nil {
-			log.Printf("Warning: Failed to close transcoded file: %v", err)
+// progress records a new percentage. Progress never goes backwards and repeated values are not
+// published again.
+func (t *processingTracker) progress(percent int) {
+	if percent <= t.percent {
+		return
+	}
+	t.percent = min(percent, 99)
+	if t.job != nil {
+		if err := t.db.Model(t.job).Update("progress", t.percent).Error; err != nil {
+			log.Printf("Warning: Failed to update progress of video job %d: %v", t.job.ID, err)
 		}
-		if err != nil {
-			log.Printf("Failed to upload transcoded video %s: %v", res.name, err)
-			continue
+	}
+	t.publish("processing")
+}
+
+// finish marks the job completed with its result, or failed with the error
+func (t *processingTracker) finish(err error, result interface{}) {
+	now := time.Now()
+	status := "completed"
+	updates := map[string]interface{}{"completed_at": now}
+	if err != nil {
+		status = "failed"
+		updates["error_msg"] = err.Error()
+	} else {
+		t.percent = 100
+		if encoded, marshalErr := json.Marshal(result); marshalErr == nil {
+			updates["result"] = string(encoded)
 		}
+	}
+	updates["status"] = status
+	updates["progress"] = t.percent
 
-		log.Printf("Successfully transcoded and uploaded %s version: %s", res.name, url)
+	if t.job != nil {
+		if updateErr := t.db.Model(t.job).Updates(updates).Error; updateErr != nil {
+			log.Printf("Warning: Failed to finish video job %d: %v", t.job.ID, updateErr)
+		}
 	}
+	t.publish(status)
+}
 
-	return nil
+func (t *processingTracker) publish(status string) {
+	if err := pubsub.PublishVideoProcessingUpdate(t.userID, t.videoID, status, t.percent); err != nil {
+		log.Printf("Warning: Failed to publish processing update for video %d: %v", t.videoID, err)
+	}
 }
 
 // AnalyzeVideo performs AI analysis on video content
@@ -436,8 +530,7 @@ func (s *VideoProcessingService) AnalyzeVideo(ctx context.Context, videoID uint)
 		}
 	}()
 
-	// Extract filename from VideoURL for storage interface
-	filename := filepath.Base(video.VideoURL)
+	filename := videoSourceKey(video)
 	reader, err := s.storage.Download(filename)
 	if err != nil {
 		return fmt.Errorf("failed to download video: %w", err)
@@ -478,13 +571,12 @@ func (s *VideoProcessingService) AnalyzeVideo(ctx context.Context, videoID uint)
 	}()
 
 	// Extract frames every 5 seconds
-	cmd := exec.CommandContext(ctx, s.ffmpegPath,
+	_, err = s.executor.Run(ctx, s.ffmpegPath,
 		"-i", tempFile,
 		"-vf", "fps=1/5", // 1 frame every 5 seconds
 		"-q:v", "2",
 		filepath.Join(frameDir, "frame_%03d.jpg"))
-
-	if err := cmd.Run(); err != nil {
+	if err != nil {
 		return fmt.Errorf("failed to extract frames: %w", err)
 	}
 
@@ -589,6 +681,15 @@ func (s *VideoProcessingService) GenerateTextToSpeech(ctx context.Context, video
 	return nil
 }
 
+// videoSourceKey returns the storage key of a video's original file. Videos created from a URL
+// have no key and are looked up by the file name in the URL.
+func videoSourceKey(video *models.Video) string {
+	if video.StorageKey != "" {
+		return video.StorageKey
+	}
+	return filepath.Base(video.VideoURL)
+}
+
 // ProcessVideo handles all video processing tasks for a given video
 func (s *VideoProcessingService) ProcessVideo(ctx context.Context, videoID uint) error {
 	jobs := []ProcessingJob{
@@ -0,0 +1,552 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"news/internal/config"
+	"news/internal/database"
+	"news/internal/json"
+	"news/internal/models"
+	"news/internal/webhook"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrWebhookEndpointNotFound is returned when a webhook endpoint does not exist
+	ErrWebhookEndpointNotFound = errors.New("webhook endpoint not found")
+	// ErrWebhookDeliveryNotFound is returned when a webhook delivery does not exist
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// Webhook delivery statuses
+const (
+	WebhookStatusPending   = "pending"
+	WebhookStatusQueued    = "queued"
+	WebhookStatusRetrying  = "retrying"
+	WebhookStatusDelivered = "delivered"
+	WebhookStatusFailed    = "failed"
+)
+
+// webhookStaleQueueTimeout is how long a delivery may stay queued before the scheduler assumes
+// its job was lost and dispatches it again
+const webhookStaleQueueTimeout = time.Hour
+
+// WebhookEnqueuer enqueues webhook deliveries for background sending
+type WebhookEnqueuer interface {
+	EnqueueWebhookDelivery(deliveryID uint) error
+}
+
+var (
+	webhookEnqueuer   WebhookEnqueuer
+	webhookEnqueuerMu sync.RWMutex
+)
+
+// SetWebhookEnqueuer sets the queue used to send webhook deliveries. Without one, deliveries
+// stay pending until the scheduler dispatches them.
+func SetWebhookEnqueuer(enqueuer WebhookEnqueuer) {
+	webhookEnqueuerMu.Lock()
+	defer webhookEnqueuerMu.Unlock()
+	webhookEnqueuer = enqueuer
+}
+
+func getWebhookEnqueuer() WebhookEnqueuer {
+	webhookEnqueuerMu.RLock()
+	defer webhookEnqueuerMu.RUnlock()
+	return webhookEnqueuer
+}
+
+// WebhookEndpointRequest represents the request to create or update a webhook endpoint
+type WebhookEndpointRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	URL         string   `json:"url" binding:"required"`
+	Events      []string `json:"events" binding:"required"`
+	Description string   `json:"description"`
+	IsActive    *bool    `json:"is_active"`
+}
+
+// WebhookEndpointSecretResponse returns an endpoint together with its signing secret. The
+// secret is only shown when the endpoint is created or the secret is rotated.
+type WebhookEndpointSecretResponse struct {
+	models.WebhookEndpoint
+	Secret string `json:"secret"`
+}
+
+// WebhookDeliveryListResponse represents a paginated list of webhook deliveries
+type WebhookDeliveryListResponse struct {
+	EndpointID uint                     `json:"endpoint_id"`
+	Deliveries []models.WebhookDelivery `json:"deliveries"`
+	Total      int64                    `json:"total"`
+	Page       int                      `json:"page"`
+	Limit      int                      `json:"limit"`
+	TotalPages int                      `json:"total_pages"`
+}
+
+// webhookEnvelope is the JSON body sent to webhook endpoints
+type webhookEnvelope struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// GetWebhookEndpoints returns all webhook endpoints
+func GetWebhookEndpoints() ([]models.WebhookEndpoint, error) {
+	var endpoints []models.WebhookEndpoint
+	if err := database.DB.Order("id ASC").Find(&endpoints).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return endpoints, nil
+}
+
+// GetWebhookEndpoint returns a webhook endpoint by ID
+func GetWebhookEndpoint(id uint) (*models.WebhookEndpoint, error) {
+	var endpoint models.WebhookEndpoint
+	if err := database.DB.First(&endpoint, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookEndpointNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return &endpoint, nil
+}
+
+// CreateWebhookEndpoint registers a webhook endpoint with a newly generated signing secret
+func CreateWebhookEndpoint(req WebhookEndpointRequest, createdBy uint) (*WebhookEndpointSecretResponse, error) {
+	events, err := validateWebhookEndpoint(req)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := models.WebhookEndpoint{
+		Name:        strings.TrimSpace(req.Name),
+		URL:         strings.TrimSpace(req.URL),
+		Secret:      secret,
+		Events:      events,
+		Description: req.Description,
+		IsActive:    req.IsActive == nil || *req.IsActive,
+		CreatedBy:   createdBy,
+	}
+
+	// Select all columns so an inactive endpoint is not overridden by the column default
+	if err := database.DB.Select("*").Create(&endpoint).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	return &WebhookEndpointSecretResponse{WebhookEndpoint: endpoint, Secret: secret}, nil
+}
+
+// UpdateWebhookEndpoint changes the URL, events and state of a webhook endpoint
+func UpdateWebhookEndpoint(id uint, req WebhookEndpointRequest) (*models.WebhookEndpoint, error) {
+	events, err := validateWebhookEndpoint(req)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint, err := GetWebhookEndpoint(id)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{
+		"name":        strings.TrimSpace(req.Name),
+		"url":         strings.TrimSpace(req.URL),
+		"events":      events,
+		"description": req.Description,
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+
+	if err := database.DB.Model(endpoint).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	return GetWebhookEndpoint(id)
+}
+
+// DeleteWebhookEndpoint removes a webhook endpoint. Its delivery log is kept.
+func DeleteWebhookEndpoint(id uint) error {
+	result := database.DB.Delete(&models.WebhookEndpoint{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("%w: %v", ErrDatabaseError, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrWebhookEndpointNotFound
+	}
+	return nil
+}
+
+// RotateWebhookSecret replaces the signing secret of a webhook endpoint
+func RotateWebhookSecret(id uint) (*WebhookEndpointSecretResponse, error) {
+	endpoint, err := GetWebhookEndpoint(id)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := database.DB.Model(endpoint).Update("secret", secret).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	endpoint.Secret = secret
+
+	return &WebhookEndpointSecretResponse{WebhookEndpoint: *endpoint, Secret: secret}, nil
+}
+
+// GetWebhookDeliveries retrieves the delivery log of a webhook endpoint with pagination
+func GetWebhookDeliveries(endpointID uint, status string, page, limit int) (*WebhookDeliveryListResponse, error) {
+	if _, err := GetWebhookEndpoint(endpointID); err != nil {
+		return nil, err
+	}
+
+	query := database.DB.Model(&models.WebhookDelivery{}).Where("endpoint_id = ?", endpointID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := query.Order("id DESC").Offset((page - 1) * limit).Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	return &WebhookDeliveryListResponse{
+		EndpointID: endpointID,
+		Deliveries: deliveries,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+	}, nil
+}
+
+// RedeliverWebhook sends the payload of an earlier delivery again as a new delivery
+func RedeliverWebhook(deliveryID uint) (*models.WebhookDelivery, error) {
+	var original models.WebhookDelivery
+	if err := database.DB.First(&original, deliveryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	if _, err := GetWebhookEndpoint(original.EndpointID); err != nil {
+		return nil, err
+	}
+
+	delivery := models.WebhookDelivery{
+		EndpointID:   original.EndpointID,
+		EventID:      original.EventID,
+		Event:        original.Event,
+		Payload:      original.Payload,
+		RedeliveryOf: &original.ID,
+	}
+	if err := createWebhookDeliveries([]*models.WebhookDelivery{&delivery}); err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+// EmitWebhookEvent records a delivery of event for every active endpoint subscribed to it
+// and enqueues them. Failures are logged, so callers can run it in the background.
+func EmitWebhookEvent(event string, data interface{}) {
+	if database.DB == nil {
+		return
+	}
+
+	var endpoints []models.WebhookEndpoint
+	if err := database.DB.Where("is_active = ?", true).Find(&endpoints).Error; err != nil {
+		log.Printf("Warning: Failed to load webhook endpoints for %s: %v", event, err)
+		return
+	}
+
+	var subscribed []uint
+	for i := range endpoints {
+		if endpoints[i].Subscribes(event) {
+			subscribed = append(subscribed, endpoints[i].ID)
+		}
+	}
+	if len(subscribed) == 0 {
+		return
+	}
+
+	eventID, err := generateWebhookEventID()
+	if err != nil {
+		log.Printf("Warning: Failed to emit webhook event %s: %v", event, err)
+		return
+	}
+	payload, err := json.Marshal(webhookEnvelope{
+		ID:        eventID,
+		Event:     event,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		log.Printf("Warning: Failed to encode webhook event %s: %v", event, err)
+		return
+	}
+
+	deliveries := make([]*models.WebhookDelivery, 0, len(subscribed))
+	for _, endpointID := range subscribed {
+		deliveries = append(deliveries, &models.WebhookDelivery{
+			EndpointID: endpointID,
+			EventID:    eventID,
+			Event:      event,
+			Payload:    datatypes.JSON(payload),
+		})
+	}
+
+	if err := createWebhookDeliveries(deliveries); err != nil {
+		log.Printf("Warning: Failed to record webhook deliveries for %s: %v", event, err)
+	}
+}
+
+// createWebhookDeliveries stores new deliveries and enqueues them. Deliveries that cannot be
+// enqueued are left pending for the scheduler.
+func createWebhookDeliveries(deliveries []*models.WebhookDelivery) error {
+	enqueuer := getWebhookEnqueuer()
+	now := time.Now()
+	for _, delivery := range deliveries {
+		delivery.Status = WebhookStatusPending
+		delivery.NextRetryAt = &now
+		if enqueuer != nil {
+			delivery.Status = WebhookStatusQueued
+			delivery.NextRetryAt = nil
+		}
+	}
+
+	if err := database.DB.Omit("Endpoint").Create(deliveries).Error; err != nil {
+		return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	if enqueuer == nil {
+		return nil
+	}
+	for _, delivery := range deliveries {
+		if err := enqueuer.EnqueueWebhookDelivery(delivery.ID); err != nil {
+			log.Printf("Warning: Failed to enqueue webhook delivery %d: %v", delivery.ID, err)
+			deferWebhookDelivery(delivery.ID, now)
+		}
+	}
+	return nil
+}
+
+// deferWebhookDelivery hands a delivery back to the scheduler
+func deferWebhookDelivery(deliveryID uint, at time.Time) {
+	if err := database.DB.Model(&models.WebhookDelivery{}).
+		Where("id = ?", deliveryID).
+		Updates(map[string]interface{}{"status": WebhookStatusPending, "next_retry_at": at}).Error; err != nil {
+		log.Printf("Warning: Failed to defer webhook delivery %d: %v", deliveryID, err)
+	}
+}
+
+// GetDueWebhookDeliveryIDs returns deliveries whose next attempt is due, including queued
+// deliveries whose job appears to have been lost
+func GetDueWebhookDeliveryIDs(now time.Time, limit int) ([]uint, error) {
+	var ids []uint
+	err := database.DB.Model(&models.WebhookDelivery{}).
+		Where("(status IN ? AND next_retry_at <= ?) OR (status = ? AND updated_at <= ?)",
+			[]string{WebhookStatusPending, WebhookStatusRetrying}, now,
+			WebhookStatusQueued, now.Add(-webhookStaleQueueTimeout)).
+		Order("next_retry_at ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// ClaimWebhookDelivery marks a due delivery as queued. It reports false if another worker
+// claimed it first.
+func ClaimWebhookDelivery(deliveryID uint) (bool, error) {
+	result := database.DB.Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status IN ?", deliveryID, []string{WebhookStatusPending, WebhookStatusRetrying, WebhookStatusQueued}).
+		Updates(map[string]interface{}{"status": WebhookStatusQueued, "next_retry_at": nil})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ReleaseWebhookDelivery returns a claimed delivery to the scheduler after enqueueing failed
+func ReleaseWebhookDelivery(deliveryID uint) {
+	deferWebhookDelivery(deliveryID, time.Now())
+}
+
+// WebhookDeliveryService sends webhook deliveries to their endpoints
+type WebhookDeliveryService struct {
+	sender *webhook.Sender
+	config *config.WebhookConfig
+}
+
+// NewWebhookDeliveryService creates a new webhook delivery service
+func NewWebhookDeliveryService(cfg *config.WebhookConfig) *WebhookDeliveryService {
+	return &WebhookDeliveryService{
+		sender: webhook.NewSender(cfg.Timeout),
+		config: cfg,
+	}
+}
+
+// Deliver sends one queued webhook delivery and records the response. Failed attempts are
+// scheduled for a retry with exponential backoff until the attempt limit is reached; only
+// database errors are returned, so the queue does not retry on its own schedule.
+func (s *WebhookDeliveryService) Deliver(ctx context.Context, deliveryID uint) error {
+	var delivery models.WebhookDelivery
+	if err := database.DB.Preload("Endpoint", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}).First(&delivery, deliveryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Webhook delivery %d no longer exists, skipping", deliveryID)
+			return nil
+		}
+		return err
+	}
+
+	// Jobs can be retried after a crash, so only send deliveries that are waiting for a worker
+	if delivery.Status != WebhookStatusQueued {
+		return nil
+	}
+
+	if !delivery.Endpoint.IsActive || delivery.Endpoint.DeletedAt.Valid {
+		return s.recordDelivery(&delivery, map[string]interface{}{
+			"status":     WebhookStatusFailed,
+			"last_error": "webhook endpoint is disabled or deleted",
+		})
+	}
+
+	result, sendErr := s.sender.Send(ctx, webhook.Request{
+		URL:        delivery.Endpoint.URL,
+		Secret:     delivery.Endpoint.Secret,
+		Event:      delivery.Event,
+		DeliveryID: strconv.FormatUint(uint64(delivery.ID), 10),
+		Body:       delivery.Payload,
+	})
+
+	attempts := delivery.Attempts + 1
+	updates := map[string]interface{}{
+		"attempts":      attempts,
+		"response_code": result.StatusCode,
+		"response_body": result.Body,
+		"duration_ms":   result.Duration.Milliseconds(),
+		"last_error":    "",
+	}
+
+	switch {
+	case sendErr == nil && result.Success():
+		updates["status"] = WebhookStatusDelivered
+		updates["delivered_at"] = time.Now()
+		updates["next_retry_at"] = nil
+
+	default:
+		if sendErr != nil {
+			updates["last_error"] = sendErr.Error()
+		} else {
+			updates["last_error"] = fmt.Sprintf("endpoint responded with status %d", result.StatusCode)
+		}
+
+		if attempts >= s.config.MaxAttempts {
+			updates["status"] = WebhookStatusFailed
+			updates["next_retry_at"] = nil
+		} else {
+			updates["status"] = WebhookStatusRetrying
+			updates["next_retry_at"] = time.Now().Add(webhook.RetryDelay(attempts))
+		}
+	}
+
+	return s.recordDelivery(&delivery, updates)
+}
+
+func (s *WebhookDeliveryService) recordDelivery(delivery *models.WebhookDelivery, updates map[string]interface{}) error {
+	if err := database.DB.Model(delivery).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to record webhook delivery %d: %w", delivery.ID, err)
+	}
+	return nil
+}
+
+// validateWebhookEndpoint checks the endpoint URL and events and returns the events as JSON
+func validateWebhookEndpoint(req WebhookEndpointRequest) (datatypes.JSON, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrValidation)
+	}
+
+	parsed, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute http or https URL", ErrValidation)
+	}
+
+	if len(req.Events) == 0 {
+		return nil, fmt.Errorf("%w: at least one event is required", ErrValidation)
+	}
+	seen := make(map[string]bool, len(req.Events))
+	events := make([]string, 0, len(req.Events))
+	for _, event := range req.Events {
+		if !models.IsValidWebhookEvent(event) {
+			return nil, fmt.Errorf("%w: unknown event %q", ErrValidation, event)
+		}
+		if !seen[event] {
+			seen[event] = true
+			events = append(events, event)
+		}
+	}
+
+	encoded, err := json.Marshal(events)
+	if err != nil {
+		return nil, err
+	}
+	return datatypes.JSON(encoded), nil
+}
+
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+func generateWebhookEventID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate webhook event ID: %w", err)
+	}
+	return "evt_" + hex.EncodeToString(buf), nil
+}
+
+// ArticleWebhookData returns the webhook payload data describing an article
+func ArticleWebhookData(article *models.Article) map[string]interface{} {
+	return map[string]interface{}{
+		"id":           article.ID,
+		"title":        article.Title,
+		"slug":         article.Slug,
+		"summary":      article.Summary,
+		"status":       article.Status,
+		"language":     article.Language,
+		"author_id":    article.AuthorID,
+		"is_breaking":  article.IsBreaking,
+		"published_at": article.PublishedAt,
+		"updated_at":   article.UpdatedAt,
+	}
+}
@@ -0,0 +1,235 @@
+// Package sitemap writes XML sitemaps, Google News sitemaps and sitemap indexes, and maps content
+// IDs to the sitemap shard that lists them. It has no database access; services collect the URLs.
+package sitemap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxURLs is the most URLs a single sitemap may list
+const MaxURLs = 50000
+
+// Sitemap sections. Each section is split into shards of consecutive IDs.
+const (
+	SectionArticles   = "articles"
+	SectionCategories = "categories"
+	SectionTags       = "tags"
+	SectionPages      = "pages"
+)
+
+// Sections lists every sharded section in index order
+var Sections = []string{SectionArticles, SectionCategories, SectionTags, SectionPages}
+
+// Files that are not shards
+const (
+	IndexFile = "sitemap.xml"
+	NewsFile  = "news.xml"
+)
+
+// URL is one entry of a sitemap
+type URL struct {
+	Loc     string
+	LastMod time.Time
+	// Lang is the language of the page at Loc, used when linking translations together
+	Lang       string
+	Alternates []Alternate
+	News       *News
+}
+
+// Alternate is a translation of a URL, written as an hreflang link
+type Alternate struct {
+	Lang string
+	Href string
+}
+
+// News marks a URL as a news article for the Google News sitemap
+type News struct {
+	PublicationName string
+	Language        string
+	Title           string
+	PublishedAt     time.Time
+}
+
+// IndexEntry is a sitemap listed in a sitemap index
+type IndexEntry struct {
+	Loc     string
+	LastMod time.Time
+}
+
+// ValidSection reports whether section is a sharded sitemap section
+func ValidSection(section string) bool {
+	for _, candidate := range Sections {
+		if candidate == section {
+			return true
+		}
+	}
+	return false
+}
+
+// Shard returns the 1-based shard listing the given ID. Shards cover fixed ID ranges, so an
+// item never moves between shards and publishing it only rewrites one of them.
+func Shard(id uint, size int) int {
+	if id == 0 || size <= 0 {
+		return 1
+	}
+	return int((id-1)/uint(size)) + 1
+}
+
+// ShardRange returns the first and last ID covered by a shard
+func ShardRange(shard, size int) (uint, uint) {
+	first := uint((shard-1)*size) + 1
+	return first, first + uint(size) - 1
+}
+
+// ShardFile is the file name of a shard, such as articles-3.xml
+func ShardFile(section string, shard int) string {
+	return fmt.Sprintf("%s-%d.xml", section, shard)
+}
+
+// ParseFile validates a sitemap file name. Shards return their section and number; the index
+// and the news sitemap return an empty section.
+func ParseFile(name string) (string, int, bool) {
+	if name == IndexFile || name == NewsFile {
+		return "", 0, true
+	}
+	base := strings.TrimSuffix(name, ".xml")
+	if base == name {
+		return "", 0, false
+	}
+	dash := strings.LastIndex(base, "-")
+	if dash < 0 || !ValidSection(base[:dash]) {
+		return "", 0, false
+	}
+	shard, err := strconv.Atoi(base[dash+1:])
+	if err != nil || shard < 1 || strconv.Itoa(shard) != base[dash+1:] {
+		return "", 0, false
+	}
+	return base[:dash], shard, true
+}
+
+// Localize links the translations of one page to each other: every URL of the group lists all of
+// them, itself included, as hreflang alternates, with the first URL as x-default. A group with a
+// single URL is left without alternates.
+func Localize(group []URL) []URL {
+	if len(group) < 2 {
+		return group
+	}
+	alternates := make([]Alternate, 0, len(group)+1)
+	for _, entry := range group {
+		if entry.Lang != "" {
+			alternates = append(alternates, Alternate{Lang: entry.Lang, Href: entry.Loc})
+		}
+	}
+	alternates = append(alternates, Alternate{Lang: "x-default", Href: group[0].Loc})
+	for i := range group {
+		group[i].Alternates = alternates
+	}
+	return group
+}
+
+type urlSet struct {
+	XMLName xml.Name `xml:"urlset"`
+	NS      string   `xml:"xmlns,attr"`
+	XHTMLNS string   `xml:"xmlns:xhtml,attr"`
+	NewsNS  string   `xml:"xmlns:news,attr,omitempty"`
+	URLs    []urlXML `xml:"url"`
+}
+
+type urlXML struct {
+	Loc        string    `xml:"loc"`
+	LastMod    string    `xml:"lastmod,omitempty"`
+	Alternates []linkXML `xml:"xhtml:link"`
+	News       *newsXML  `xml:"news:news"`
+}
+
+type linkXML struct {
+	Rel      string `xml:"rel,attr"`
+	HrefLang string `xml:"hreflang,attr"`
+	Href     string `xml:"href,attr"`
+}
+
+type newsXML struct {
+	Publication     publicationXML `xml:"news:publication"`
+	PublicationDate string         `xml:"news:publication_date"`
+	Title           string         `xml:"news:title"`
+}
+
+type publicationXML struct {
+	Name     string `xml:"news:name"`
+	Language string `xml:"news:language"`
+}
+
+// URLSet writes a sitemap. URLs with News set are written as Google News entries.
+func URLSet(urls []URL) ([]byte, error) {
+	if len(urls) > MaxURLs {
+		return nil, fmt.Errorf("sitemap has %d URLs, more than the limit of %d", len(urls), MaxURLs)
+	}
+
+	document := urlSet{
+		NS:      "http://www.sitemaps.org/schemas/sitemap/0.9",
+		XHTMLNS: "http://www.w3.org/1999/xhtml",
+		URLs:    make([]urlXML, 0, len(urls)),
+	}
+	for _, entry := range urls {
+		out := urlXML{Loc: entry.Loc}
+		if !entry.LastMod.IsZero() {
+			out.LastMod = entry.LastMod.UTC().Format(time.RFC3339)
+		}
+		for _, alternate := range entry.Alternates {
+			out.Alternates = append(out.Alternates, linkXML{Rel: "alternate", HrefLang: alternate.Lang, Href: alternate.Href})
+		}
+		if entry.News != nil {
+			document.NewsNS = "http://www.google.com/schemas/sitemap-news/0.9"
+			out.News = &newsXML{
+				Publication:     publicationXML{Name: entry.News.PublicationName, Language: entry.News.Language},
+				PublicationDate: entry.News.PublishedAt.UTC().Format(time.RFC3339),
+				Title:           entry.News.Title,
+			}
+		}
+		document.URLs = append(document.URLs, out)
+	}
+	return marshal(document)
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	NS       string       `xml:"xmlns,attr"`
+	Sitemaps []sitemapXML `xml:"sitemap"`
+}
+
+type sitemapXML struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// Index writes a sitemap index
+func Index(entries []IndexEntry) ([]byte, error) {
+	if len(entries) > MaxURLs {
+		return nil, fmt.Errorf("sitemap index has %d sitemaps, more than the limit of %d", len(entries), MaxURLs)
+	}
+
+	document := sitemapIndex{
+		NS:       "http://www.sitemaps.org/schemas/sitemap/0.9",
+		Sitemaps: make([]sitemapXML, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		out := sitemapXML{Loc: entry.Loc}
+		if !entry.LastMod.IsZero() {
+			out.LastMod = entry.LastMod.UTC().Format(time.RFC3339)
+		}
+		document.Sitemaps = append(document.Sitemaps, out)
+	}
+	return marshal(document)
+}
+
+func marshal(document interface{}) ([]byte, error) {
+	body, err := xml.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
@@ -1,10 +1,16 @@
 package storage
 
 import (
+	"crypto/md5"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/google/uuid"
 )
 
 type LocalStorage struct {
@@ -15,8 +21,16 @@ func NewLocalStorage(basePath string) *LocalStorage {
 	return &LocalStorage{basePath: basePath}
 }
 
+// BasePath returns the directory files are stored in
+func (l *LocalStorage) BasePath() string {
+	return l.basePath
+}
+
 func (l *LocalStorage) Upload(file io.Reader, filename string) (string, error) {
 	path := filepath.Join(l.basePath, filename)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
 	out, err := os.Create(path)
 	if err != nil {
 		return "", err
@@ -49,3 +63,76 @@ func (l *LocalStorage) Delete(filename string) error {
 	path := filepath.Join(l.basePath, filename)
 	return os.Remove(path)
 }
+
+// multipartDir is where the parts of unfinished multipart uploads are kept
+const multipartDir = ".multipart"
+
+func (l *LocalStorage) partPath(uploadID string, number int) string {
+	return filepath.Join(l.basePath, multipartDir, uploadID, strconv.Itoa(number))
+}
+
+// CreateMultipartUpload implements MultipartStorage
+func (l *LocalStorage) CreateMultipartUpload(key, contentType string) (string, error) {
+	uploadID := uuid.New().String()
+	if err := os.MkdirAll(filepath.Join(l.basePath, multipartDir, uploadID), 0755); err != nil {
+		return "", err
+	}
+	return uploadID, nil
+}
+
+// UploadPart implements MultipartStorage. The ETag is the MD5 of the part, as on S3.
+func (l *LocalStorage) UploadPart(key, uploadID string, number int, data []byte) (string, error) {
+	if err := os.WriteFile(l.partPath(uploadID, number), data, 0644); err != nil {
+		return "", err
+	}
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// CompleteMultipartUpload implements MultipartStorage by concatenating the parts in order
+func (l *LocalStorage) CompleteMultipartUpload(key, uploadID string, parts []CompletedPart) error {
+	sorted := append([]CompletedPart(nil), parts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Number < sorted[j].Number })
+
+	target := filepath.Join(l.basePath, key)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	tmp := target + "." + uploadID
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	for _, part := range sorted {
+		if err = l.appendPart(out, uploadID, part.Number); err != nil {
+			break
+		}
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, target)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.RemoveAll(filepath.Join(l.basePath, multipartDir, uploadID))
+}
+
+func (l *LocalStorage) appendPart(out io.Writer, uploadID string, number int) error {
+	in, err := os.Open(l.partPath(uploadID, number))
+	if err != nil {
+		return fmt.Errorf("part %d: %w", number, err)
+	}
+	defer in.Close()
+	_, err = io.Copy(out, in)
+	return err
+}
+
+// AbortMultipartUpload implements MultipartStorage
+func (l *LocalStorage) AbortMultipartUpload(key, uploadID string) error {
+	return os.RemoveAll(filepath.Join(l.basePath, multipartDir, uploadID))
+}
@@ -0,0 +1,21 @@
+package storage
+
+// MinPartSize is the smallest part S3 accepts for every part but the last
+const MinPartSize = 5 << 20
+
+// CompletedPart identifies an uploaded part when a multipart upload is completed
+type CompletedPart struct {
+	Number int    `json:"number"`
+	ETag   string `json:"etag"`
+}
+
+// MultipartStorage assembles an object from parts uploaded separately, so large files never
+// have to be held in memory or sent in a single request. Parts are numbered from 1 and may be
+// uploaded again to replace them until the upload is completed or aborted.
+type MultipartStorage interface {
+	Storage
+	CreateMultipartUpload(key, contentType string) (string, error)
+	UploadPart(key, uploadID string, number int, data []byte) (string, error)
+	CompleteMultipartUpload(key, uploadID string, parts []CompletedPart) error
+	AbortMultipartUpload(key, uploadID string) error
+}
@@ -0,0 +1,242 @@
+// Package threads orders the comments of an article as a tree: it sorts replies by score, time
+// or controversy, pages through them with opaque cursors and finds @mentions. It has no
+// database access, so services load comments and vote counts and this package arranges them.
+package threads
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Sort orders
+const (
+	SortNewest        = "newest"
+	SortOldest        = "oldest"
+	SortTop           = "top"           // Likes minus dislikes
+	SortControversial = "controversial" // Many votes, evenly split
+)
+
+// ErrInvalidCursor is returned for cursors that were not issued for the same sort
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// maxMentions limits how many users a single comment can mention
+const maxMentions = 10
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@.])@([A-Za-z0-9_][A-Za-z0-9_.-]{1,49})`)
+
+// Entry is a comment as far as ordering is concerned
+type Entry struct {
+	ID        uint
+	ParentID  *uint
+	CreatedAt time.Time
+	Likes     int
+	Dislikes  int
+}
+
+// Score is likes minus dislikes
+func (e Entry) Score() int {
+	return e.Likes - e.Dislikes
+}
+
+// Controversy is high for comments with many votes split evenly between likes and dislikes,
+// and zero for comments nobody disagrees on
+func (e Entry) Controversy() float64 {
+	if e.Likes == 0 || e.Dislikes == 0 {
+		return 0
+	}
+	total := float64(e.Likes + e.Dislikes)
+	balance := float64(min(e.Likes, e.Dislikes)) / float64(max(e.Likes, e.Dislikes))
+	return math.Pow(total, balance)
+}
+
+// ValidSort reports whether sort is a known order
+func ValidSort(sort string) bool {
+	switch sort {
+	case SortNewest, SortOldest, SortTop, SortControversial:
+		return true
+	}
+	return false
+}
+
+// Forest holds the comments of an article grouped by parent and sorted
+type Forest struct {
+	sort     string
+	children map[uint][]Entry // Replies by parent ID; 0 holds the top-level comments
+}
+
+// Build arranges entries into a forest. Entries whose parent is missing, for example because
+// it was removed by a moderator, are left out together with their replies.
+func Build(entries []Entry, order string) *Forest {
+	if !ValidSort(order) {
+		order = SortNewest
+	}
+	byID := make(map[uint]Entry, len(entries))
+	for _, entry := range entries {
+		byID[entry.ID] = entry
+	}
+
+	f := &Forest{sort: order, children: make(map[uint][]Entry)}
+	for _, entry := range entries {
+		if !f.rooted(entry, byID) {
+			continue
+		}
+		parent := uint(0)
+		if entry.ParentID != nil {
+			parent = *entry.ParentID
+		}
+		f.children[parent] = append(f.children[parent], entry)
+	}
+	for _, list := range f.children {
+		sort.Slice(list, func(i, j int) bool { return f.less(list[i], list[j]) })
+	}
+	return f
+}
+
+// rooted reports whether the ancestors of an entry are all present
+func (f *Forest) rooted(entry Entry, byID map[uint]Entry) bool {
+	seen := map[uint]bool{entry.ID: true}
+	for entry.ParentID != nil {
+		parent, ok := byID[*entry.ParentID]
+		if !ok || seen[parent.ID] {
+			return false
+		}
+		seen[parent.ID] = true
+		entry = parent
+	}
+	return true
+}
+
+// Sort returns the order of the forest
+func (f *Forest) Sort() string {
+	return f.sort
+}
+
+// Count returns the number of direct replies of a comment, or of top-level comments for 0
+func (f *Forest) Count(parent uint) int {
+	return len(f.children[parent])
+}
+
+// Has reports whether a comment is in the forest
+func (f *Forest) Has(id uint) bool {
+	for _, list := range f.children {
+		for _, entry := range list {
+			if entry.ID == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Page returns up to limit replies of parent (0 for top-level comments) following cursor, and
+// the cursor of the next page, which is empty on the last page
+func (f *Forest) Page(parent uint, cursor string, limit int) ([]Entry, string, error) {
+	list := f.children[parent]
+	start := 0
+	if cursor != "" {
+		after, err := f.decode(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		start = sort.Search(len(list), func(i int) bool { return f.less(after, list[i]) })
+	}
+
+	end := min(start+limit, len(list))
+	if start >= end {
+		return []Entry{}, "", nil
+	}
+	next := ""
+	if end < len(list) {
+		next = f.encode(list[end-1])
+	}
+	return list[start:end], next, nil
+}
+
+// Offset returns up to limit replies of parent starting at offset, for page-numbered listings
+func (f *Forest) Offset(parent uint, offset, limit int) []Entry {
+	list := f.children[parent]
+	if offset >= len(list) {
+		return []Entry{}
+	}
+	return list[offset:min(offset+limit, len(list))]
+}
+
+// less orders entries by the forest's sort, breaking ties by time and then ID so every entry
+// has a unique position a cursor can point to
+func (f *Forest) less(a, b Entry) bool {
+	if ka, kb := f.key(a), f.key(b); ka != kb {
+		return ka > kb
+	}
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		if f.sort == SortOldest {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	if f.sort == SortOldest {
+		return a.ID < b.ID
+	}
+	return a.ID > b.ID
+}
+
+// key is the primary sort key, larger first; time orders use only the tie-breakers
+func (f *Forest) key(e Entry) float64 {
+	switch f.sort {
+	case SortTop:
+		return float64(e.Score())
+	case SortControversial:
+		return e.Controversy()
+	}
+	return 0
+}
+
+// Cursors hold the sort and the position of the last entry of a page. Likes and dislikes are
+// kept rather than the key so a cursor survives votes cast on the entry it points to.
+func (f *Forest) encode(e Entry) string {
+	raw := fmt.Sprintf("%s:%d:%d:%d:%d", f.sort, e.Likes, e.Dislikes, e.CreatedAt.UnixNano(), e.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func (f *Forest) decode(cursor string) (Entry, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return Entry{}, ErrInvalidCursor
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 5 || parts[0] != f.sort {
+		return Entry{}, ErrInvalidCursor
+	}
+	var entry Entry
+	var nanos int64
+	if _, err := fmt.Sscanf(strings.Join(parts[1:], " "), "%d %d %d %d", &entry.Likes, &entry.Dislikes, &nanos, &entry.ID); err != nil {
+		return Entry{}, ErrInvalidCursor
+	}
+	entry.CreatedAt = time.Unix(0, nanos)
+	return entry, nil
+}
+
+// Mentions returns the usernames mentioned as @username in a comment, in order of appearance
+// without duplicates. Trailing dots are not part of a username, so "@alice." mentions alice.
+func Mentions(content string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		name := strings.TrimRight(match[1], ".-")
+		key := strings.ToLower(name)
+		if len(name) < 2 || seen[key] {
+			continue
+		}
+		seen[key] = true
+		names = append(names, name)
+		if len(names) == maxMentions {
+			break
+		}
+	}
+	return names
+}
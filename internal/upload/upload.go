@@ -0,0 +1,132 @@
+// Package upload implements the bookkeeping of resumable uploads: chunk offsets, tus-style
+// checksum headers and a SHA-256 of the whole file that survives between requests.
+package upload
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+var (
+	// ErrOffsetMismatch is returned when a chunk does not start where the upload stopped
+	ErrOffsetMismatch = errors.New("upload offset mismatch")
+	// ErrInvalidChunk is returned for chunks of the wrong size
+	ErrInvalidChunk = errors.New("invalid chunk size")
+	// ErrChecksumMismatch is returned when data does not match its checksum
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	// ErrUnsupportedChecksum is returned for checksum algorithms other than SHA-256
+	ErrUnsupportedChecksum = errors.New("unsupported checksum algorithm")
+)
+
+// ChecksumAlgorithm is the only algorithm accepted in Upload-Checksum headers
+const ChecksumAlgorithm = "sha256"
+
+// PartNumber checks that a chunk of length bytes written at offset continues an upload of size
+// bytes split into chunkSize chunks, and returns its part number starting from 1. Every chunk
+// but the last must be exactly chunkSize long, which keeps parts aligned with S3 multipart.
+func PartNumber(offset, current, length, size, chunkSize int64) (int, error) {
+	if offset != current {
+		return 0, fmt.Errorf("%w: expected %d, got %d", ErrOffsetMismatch, current, offset)
+	}
+	if length <= 0 || offset+length > size {
+		return 0, fmt.Errorf("%w: %d bytes at offset %d of %d", ErrInvalidChunk, length, offset, size)
+	}
+	if length != chunkSize && offset+length != size {
+		return 0, fmt.Errorf("%w: chunks must be %d bytes except the last", ErrInvalidChunk, chunkSize)
+	}
+	return int(offset/chunkSize) + 1, nil
+}
+
+// ParseChecksum parses a tus Upload-Checksum header, "sha256 <base64 digest>". An empty header
+// returns a nil digest.
+func ParseChecksum(header string) ([]byte, error) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return nil, nil
+	}
+	algorithm, encoded, ok := strings.Cut(header, " ")
+	if !ok {
+		return nil, fmt.Errorf("%w: expected \"sha256 <base64>\"", ErrUnsupportedChecksum)
+	}
+	if !strings.EqualFold(algorithm, ChecksumAlgorithm) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedChecksum, algorithm)
+	}
+	digest, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(digest) != sha256.Size {
+		return nil, fmt.Errorf("%w: invalid sha256 digest", ErrChecksumMismatch)
+	}
+	return digest, nil
+}
+
+// VerifyChunk compares data with a digest from ParseChecksum. A nil digest always matches.
+func VerifyChunk(data, digest []byte) error {
+	if digest == nil {
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	if !bytes.Equal(sum[:], digest) {
+		return ErrChecksumMismatch
+	}
+	return nil
+}
+
+// NormalizeChecksum validates the expected SHA-256 of a whole file, given as hex or base64, and
+// returns it as lowercase hex
+func NormalizeChecksum(checksum string) (string, error) {
+	checksum = strings.TrimSpace(checksum)
+	if checksum == "" {
+		return "", nil
+	}
+	if algorithm, value, ok := strings.Cut(checksum, " "); ok {
+		if !strings.EqualFold(algorithm, ChecksumAlgorithm) {
+			return "", fmt.Errorf("%w: %s", ErrUnsupportedChecksum, algorithm)
+		}
+		checksum = strings.TrimSpace(value)
+	}
+	if digest, err := hex.DecodeString(checksum); err == nil && len(digest) == sha256.Size {
+		return hex.EncodeToString(digest), nil
+	}
+	if digest, err := base64.StdEncoding.DecodeString(checksum); err == nil && len(digest) == sha256.Size {
+		return hex.EncodeToString(digest), nil
+	}
+	return "", fmt.Errorf("%w: checksum must be a sha256 digest", ErrUnsupportedChecksum)
+}
+
+// Hash is a running SHA-256 of an upload, saved after every chunk so the file never has to be
+// read back to verify it
+type Hash struct {
+	h hash.Hash
+}
+
+// ResumeHash restores a hash saved with State. An empty state starts a new hash.
+func ResumeHash(state []byte) (*Hash, error) {
+	h := sha256.New()
+	if len(state) > 0 {
+		if err := h.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+			return nil, fmt.Errorf("invalid hash state: %w", err)
+		}
+	}
+	return &Hash{h: h}, nil
+}
+
+// Write adds data to the hash
+func (h *Hash) Write(data []byte) {
+	h.h.Write(data)
+}
+
+// State returns the hash state to store with the upload
+func (h *Hash) State() ([]byte, error) {
+	return h.h.(encoding.BinaryMarshaler).MarshalBinary()
+}
+
+// Sum returns the hex digest of everything written so far
+func (h *Hash) Sum() string {
+	return hex.EncodeToString(h.h.Sum(nil))
+}
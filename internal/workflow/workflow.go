@@ -0,0 +1,188 @@
+// Package workflow holds the editorial workflow of articles: the statuses an article moves
+// through on its way to publication and which role may move it from one status to another. It
+// has no database access; services load the article and the actor and this package decides.
+package workflow
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"news/internal/json"
+)
+
+// Article statuses
+const (
+	StatusDraft            = "draft"
+	StatusInReview         = "in_review"         // Submitted to an editor
+	StatusChangesRequested = "changes_requested" // Sent back to the author
+	StatusApproved         = "approved"          // Ready to be published or scheduled
+	StatusScheduled        = "scheduled"
+	StatusPublished        = "published"
+	StatusArchived         = "archived"
+	StatusTrash            = "trash"
+)
+
+// Statuses lists every article status in workflow order
+var Statuses = []string{
+	StatusDraft,
+	StatusInReview,
+	StatusChangesRequested,
+	StatusApproved,
+	StatusScheduled,
+	StatusPublished,
+	StatusArchived,
+	StatusTrash,
+}
+
+// Roles taking part in the workflow
+const (
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+	RoleAuthor = "author"
+)
+
+// Any matches every status in Rules
+const Any = "*"
+
+// Rules maps a role to the statuses it may move an article out of, and for each of them the
+// statuses it may move the article to. Any can stand for either side.
+type Rules map[string]map[string][]string
+
+// DefaultRules let authors submit their work and withdraw it, editors review, publish and
+// archive, and admins make any change
+var DefaultRules = Rules{
+	RoleAuthor: {
+		StatusDraft:            {StatusInReview, StatusTrash},
+		StatusInReview:         {StatusDraft},
+		StatusChangesRequested: {StatusInReview, StatusDraft},
+		StatusApproved:         {StatusDraft},
+		StatusTrash:            {StatusDraft},
+	},
+	RoleEditor: {
+		StatusDraft:            {StatusInReview, StatusApproved, StatusScheduled, StatusPublished, StatusTrash},
+		StatusInReview:         {StatusChangesRequested, StatusApproved, StatusScheduled, StatusPublished, StatusDraft},
+		StatusChangesRequested: {StatusInReview, StatusDraft},
+		StatusApproved:         {StatusScheduled, StatusPublished, StatusChangesRequested, StatusInReview},
+		StatusScheduled:        {StatusApproved, StatusPublished, StatusDraft},
+		StatusPublished:        {StatusArchived, StatusDraft},
+		StatusArchived:         {StatusPublished, StatusDraft, StatusTrash},
+		StatusTrash:            {StatusDraft},
+	},
+	RoleAdmin: {
+		Any: {Any},
+	},
+}
+
+// ValidStatus reports whether status is an article status
+func ValidStatus(status string) bool {
+	for _, known := range Statuses {
+		if status == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Publishing reports whether moving an article to status makes it public, now or on schedule
+func Publishing(status string) bool {
+	return status == StatusPublished || status == StatusScheduled
+}
+
+// CanPublish reports whether role may publish articles. Only editors and admins can, whatever
+// the rules say.
+func CanPublish(role string) bool {
+	role = normalize(role)
+	return role == RoleEditor || role == RoleAdmin
+}
+
+// CanReview reports whether role may review articles and leave review notes
+func CanReview(role string) bool {
+	return CanPublish(role)
+}
+
+// Allowed reports whether role may move an article from one status to another
+func (r Rules) Allowed(role, from, to string) bool {
+	if from == to || !ValidStatus(to) {
+		return false
+	}
+	if Publishing(to) && !CanPublish(role) {
+		return false
+	}
+
+	transitions := r[normalize(role)]
+	for _, source := range []string{from, Any} {
+		for _, target := range transitions[source] {
+			if target == to || target == Any {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Targets returns the statuses role may move an article to from status, in workflow order
+func (r Rules) Targets(role, from string) []string {
+	targets := make([]string, 0, len(Statuses))
+	for _, status := range Statuses {
+		if r.Allowed(role, from, status) {
+			targets = append(targets, status)
+		}
+	}
+	return targets
+}
+
+// Validate checks that the rules only name known statuses and never let a role that cannot
+// publish reach a publishing status
+func (r Rules) Validate() error {
+	roles := make([]string, 0, len(r))
+	for role := range r {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+
+	for _, role := range roles {
+		for from, targets := range r[role] {
+			if from != Any && !ValidStatus(from) {
+				return fmt.Errorf("role %s: unknown status %q", role, from)
+			}
+			for _, to := range targets {
+				if to != Any && !ValidStatus(to) {
+					return fmt.Errorf("role %s: unknown status %q", role, to)
+				}
+				if Publishing(to) && !CanPublish(role) {
+					return fmt.Errorf("role %s cannot publish or schedule articles", role)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ParseRules reads rules from JSON such as {"author":{"draft":["in_review"]}}. The roles it
+// names replace their default rules; other roles keep the defaults.
+func ParseRules(raw string) (Rules, error) {
+	rules := make(Rules, len(DefaultRules))
+	for role, transitions := range DefaultRules {
+		rules[role] = transitions
+	}
+	if strings.TrimSpace(raw) == "" {
+		return rules, nil
+	}
+
+	var custom Rules
+	if err := json.Unmarshal([]byte(raw), &custom); err != nil {
+		return nil, fmt.Errorf("invalid workflow rules: %v", err)
+	}
+	for role, transitions := range custom {
+		rules[normalize(role)] = transitions
+	}
+	if err := rules.Validate(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func normalize(role string) string {
+	return strings.ToLower(strings.TrimSpace(role))
+}
@@ -8,9 +8,12 @@ import (
 	"testing"
 	"time"
 
+	"news/internal/database"
+
 	_ "github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
@@ -103,3 +106,23 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// SetupSQLiteDB opens an in-memory SQLite database with the given models migrated and
+// installs it as database.DB for the duration of the test
+func SetupSQLiteDB(t *testing.T, models ...interface{}) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err, "Failed to open test database")
+	require.NoError(t, db.AutoMigrate(models...), "Failed to migrate test database")
+
+	previous := database.DB
+	database.DB = db
+	t.Cleanup(func() {
+		database.DB = previous
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
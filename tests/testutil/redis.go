@@ -0,0 +1,34 @@
+package testutil
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"news/internal/cache"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testRedis     *miniredis.Miniredis
+	testRedisOnce sync.Once
+)
+
+// SetupTestRedis points the shared Redis clients at an in-memory Redis server and empties
+// it. The server is shared by every test in the package, because the cache clients are
+// process-wide singletons that only connect once.
+func SetupTestRedis(t *testing.T) *miniredis.Miniredis {
+	testRedisOnce.Do(func() {
+		server, err := miniredis.Run()
+		require.NoError(t, err, "Failed to start test Redis")
+		testRedis = server
+		os.Setenv("REDIS_URL", server.Addr())
+	})
+	require.NotNil(t, testRedis, "Test Redis is not running")
+
+	testRedis.FlushAll()
+	require.NoError(t, cache.InitRedis(), "Failed to connect to test Redis")
+	return testRedis
+}
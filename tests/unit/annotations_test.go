@@ -0,0 +1,46 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"news/internal/annotations"
+)
+
+func TestAnnotationsNewAnchor(t *testing.T) {
+	anchor, err := annotations.NewAnchor("Çok güzel bir gün", 4, 9)
+	require.NoError(t, err)
+	assert.Equal(t, "güzel", anchor.Quote, "ranges count characters, not bytes")
+
+	for _, r := range [][2]int{{-1, 2}, {3, 3}, {5, 2}, {0, 18}} {
+		_, err := annotations.NewAnchor("Çok güzel bir gün", r[0], r[1])
+		assert.ErrorIs(t, err, annotations.ErrInvalidRange, "range %v", r)
+	}
+}
+
+func TestAnnotationsRelocate(t *testing.T) {
+	anchor, err := annotations.NewAnchor("The mayor said no.", 4, 9)
+	require.NoError(t, err)
+
+	same, ok := annotations.Relocate("The mayor said no.", anchor)
+	assert.True(t, ok)
+	assert.Equal(t, anchor, same)
+
+	moved, ok := annotations.Relocate("Yesterday the mayor said no.", anchor)
+	assert.True(t, ok)
+	assert.Equal(t, 14, moved.Start)
+	assert.Equal(t, 19, moved.End)
+
+	_, ok = annotations.Relocate("The governor said no.", anchor)
+	assert.False(t, ok, "the anchored text was edited away")
+}
+
+func TestAnnotationsRelocatePicksNearestOccurrence(t *testing.T) {
+	anchor := annotations.Anchor{Start: 20, End: 23, Quote: "tax"}
+
+	moved, ok := annotations.Relocate("tax cuts, more tax, şu tax", anchor)
+	assert.True(t, ok)
+	assert.Equal(t, 23, moved.Start, "the occurrence closest to the old range wins")
+}
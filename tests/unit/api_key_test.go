@@ -0,0 +1,106 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"news/internal/middleware"
+	"news/internal/models"
+	"news/tests/testutil"
+)
+
+func TestHashAPIKey(t *testing.T) {
+	hash := models.HashAPIKey("api_key_basic_1234")
+
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, models.HashAPIKey("api_key_basic_1234"))
+	assert.NotEqual(t, hash, models.HashAPIKey("api_key_basic_1235"))
+}
+
+func TestAPIKeyIsUsable(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	assert.True(t, (&models.APIKey{}).IsUsable(now))
+	assert.True(t, (&models.APIKey{ExpiresAt: &future}).IsUsable(now))
+	assert.False(t, (&models.APIKey{ExpiresAt: &past}).IsUsable(now), "expired")
+	assert.False(t, (&models.APIKey{Revoked: true}).IsUsable(now), "revoked")
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	key := models.APIKey{Scopes: []byte(`["analytics","export"]`)}
+
+	assert.True(t, key.HasScope(models.APIKeyScopeAnalytics))
+	assert.True(t, key.HasScope(models.APIKeyScopeExport))
+	assert.False(t, key.HasScope(models.APIKeyScopeBulk))
+	assert.False(t, (&models.APIKey{}).HasScope(models.APIKeyScopeBulk))
+	assert.False(t, key.HasScope(models.APIKeyScopeExportUnpublished), "export does not include unpublished articles")
+
+	assert.True(t, models.IsValidAPIKeyTier("enterprise"))
+	assert.False(t, models.IsValidAPIKeyTier("platinum"))
+	assert.False(t, models.IsValidAPIKeyScope("admin"))
+	assert.True(t, models.IsValidAPIKeyScope(models.APIKeyScopeExportUnpublished))
+}
+
+func TestMemoryQuotaStoreRefusesWithoutSpendingOtherWindows(t *testing.T) {
+	store := middleware.NewMemoryQuotaStore([]middleware.QuotaWindow{
+		{Name: "hour", Limit: 2, Window: time.Hour},
+		{Name: "burst", Limit: 1, Window: 50 * time.Millisecond},
+	})
+
+	exceeded, err := store.Allow("key")
+	assert.NoError(t, err)
+	assert.Equal(t, -1, exceeded)
+
+	// Refused by the burst window, so the hour window keeps its last request
+	exceeded, _ = store.Allow("key")
+	assert.Equal(t, 1, exceeded)
+	exceeded, _ = store.Allow("another-key")
+	assert.Equal(t, -1, exceeded, "keys are counted separately")
+
+	time.Sleep(60 * time.Millisecond)
+	exceeded, _ = store.Allow("key")
+	assert.Equal(t, -1, exceeded)
+
+	time.Sleep(60 * time.Millisecond)
+	exceeded, _ = store.Allow("key")
+	assert.Equal(t, 0, exceeded, "hour window is used up")
+}
+
+func TestRedisQuotaStoreRefusesWithoutSpendingOtherWindows(t *testing.T) {
+	server := testutil.SetupTestRedis(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	store := middleware.NewRedisQuotaStore(client, []middleware.QuotaWindow{
+		{Name: "day", Limit: 3, Window: 24 * time.Hour},
+		{Name: "burst", Limit: 1, Window: time.Hour},
+	})
+
+	exceeded, err := store.Allow("key")
+	require.NoError(t, err)
+	assert.Equal(t, -1, exceeded)
+
+	// Refused by the burst window, so the day window keeps its remaining requests
+	for i := 0; i < 3; i++ {
+		exceeded, err = store.Allow("key")
+		require.NoError(t, err)
+		assert.Equal(t, 1, exceeded)
+	}
+	exceeded, _ = store.Allow("another-key")
+	assert.Equal(t, -1, exceeded, "keys are counted separately")
+
+	// Each window keeps a single counter per key
+	assert.Len(t, server.Keys(), 4)
+	for _, key := range server.Keys() {
+		assert.True(t, server.TTL(key) > 0, "counter %s expires", key)
+		value, err := server.Get(key)
+		require.NoError(t, err)
+		assert.Equal(t, "1", value, "refused requests are not counted in %s", key)
+	}
+}
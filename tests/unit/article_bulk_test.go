@@ -0,0 +1,193 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"news/internal/models"
+	"news/internal/services"
+	"news/tests/testutil"
+)
+
+var (
+	bulkAuthor = services.WorkflowActor{ID: 1, Role: "author"}
+	bulkEditor = services.WorkflowActor{ID: 2, Role: "editor"}
+)
+
+func setupArticleDB(t *testing.T) *gorm.DB {
+	testutil.SetupTestRedis(t)
+	return testutil.SetupSQLiteDB(t,
+		&models.User{}, &models.Category{}, &models.Tag{}, &models.Article{},
+		&models.ArticleContentBlock{}, &models.ArticleRevision{}, &models.ArticleTranslation{},
+		&models.ArticleWorkflowTransition{}, &models.Notification{}, &models.UserArticleInteraction{},
+	)
+}
+
+func createTestArticle(t *testing.T, db *gorm.DB, authorID uint, title, status string) models.Article {
+	article := models.Article{
+		Title:    title,
+		Slug:     title,
+		Content:  "Content of " + title,
+		AuthorID: authorID,
+		Status:   status,
+		Language: "en",
+		Gallery:  []byte("[]"),
+	}
+	require.NoError(t, db.Create(&article).Error)
+	return article
+}
+
+func TestBulkArticles_Validation(t *testing.T) {
+	_, err := services.RunBulkArticleOperations(nil, bulkEditor)
+	assert.ErrorIs(t, err, services.ErrValidation)
+
+	tooMany := make([]services.BulkArticleOperation, services.MaxBulkArticleOperations+1)
+	_, err = services.RunBulkArticleOperations(tooMany, bulkEditor)
+	assert.ErrorIs(t, err, services.ErrValidation)
+
+	response, err := services.RunBulkArticleOperations([]services.BulkArticleOperation{
+		{Action: "delete", ID: 1},
+		{Action: services.BulkActionUpdate},
+		{Action: services.BulkActionCreate, Title: "Bulk", Content: "Long enough content", Status: "unknown"},
+	}, bulkEditor)
+	require.NoError(t, err)
+	assert.Equal(t, 3, response.Failed)
+	for _, result := range response.Results {
+		assert.Equal(t, services.BulkResultFailed, result.Status)
+		assert.NotEmpty(t, result.Error)
+	}
+}
+
+func TestBulkArticles_CreateFollowsWorkflow(t *testing.T) {
+	db := setupArticleDB(t)
+
+	response, err := services.RunBulkArticleOperations([]services.BulkArticleOperation{
+		{Action: services.BulkActionCreate, Title: "Author draft", Content: "Long enough content"},
+		{Action: services.BulkActionCreate, Title: "Author publish", Content: "Long enough content", Status: "published"},
+	}, bulkAuthor)
+	require.NoError(t, err)
+	require.Len(t, response.Results, 2)
+
+	assert.Equal(t, services.BulkResultCreated, response.Results[0].Status)
+	assert.Equal(t, services.BulkResultFailed, response.Results[1].Status)
+	assert.Contains(t, response.Results[1].Error, "only editors and admins can publish")
+
+	var articles []models.Article
+	require.NoError(t, db.Find(&articles).Error)
+	require.Len(t, articles, 1, "a rejected create must not leave an article behind")
+	assert.Equal(t, "Author draft", articles[0].Title)
+	assert.Equal(t, bulkAuthor.ID, articles[0].AuthorID)
+	assert.Equal(t, "draft", articles[0].Status)
+}
+
+func TestBulkArticles_UpdateFollowsWorkflow(t *testing.T) {
+	db := setupArticleDB(t)
+	own := createTestArticle(t, db, bulkAuthor.ID, "Own article", "draft")
+	other := createTestArticle(t, db, bulkEditor.ID, "Other article", "draft")
+
+	response, err := services.RunBulkArticleOperations([]services.BulkArticleOperation{
+		{Action: services.BulkActionUpdate, ID: own.ID, Status: "published"},
+		{Action: services.BulkActionUpdate, ID: other.ID, Title: "Taken over"},
+		{Action: services.BulkActionUpdate, ID: own.ID, Title: "Own article, revised", Status: "in_review"},
+	}, bulkAuthor)
+	require.NoError(t, err)
+	require.Len(t, response.Results, 3)
+
+	assert.Equal(t, services.BulkResultFailed, response.Results[0].Status)
+	assert.Contains(t, response.Results[0].Error, "only editors and admins can publish")
+	assert.Equal(t, services.BulkResultFailed, response.Results[1].Status)
+	assert.Contains(t, response.Results[1].Error, "own articles")
+	assert.Equal(t, services.BulkResultUpdated, response.Results[2].Status)
+
+	require.NoError(t, db.First(&other, other.ID).Error)
+	assert.Equal(t, "Other article", other.Title)
+	require.NoError(t, db.First(&own, own.ID).Error)
+	assert.Equal(t, "Own article, revised", own.Title)
+	assert.Equal(t, "in_review", own.Status)
+
+	// Editors may work on every article
+	response, err = services.RunBulkArticleOperations([]services.BulkArticleOperation{
+		{Action: services.BulkActionUpdate, ID: own.ID, Status: "approved"},
+	}, bulkEditor)
+	require.NoError(t, err)
+	assert.Equal(t, 1, response.Succeeded, response.Results)
+}
+
+func TestExportArticles_IncrementalSync(t *testing.T) {
+	db := setupArticleDB(t)
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i, title := range []string{"First", "Second", "Third"} {
+		article := createTestArticle(t, db, 1, title, "published")
+		require.NoError(t, db.Model(&article).UpdateColumn("updated_at", base.Add(time.Duration(i)*time.Minute)).Error)
+	}
+	draft := createTestArticle(t, db, 1, "Draft", "draft")
+	require.NoError(t, db.Model(&draft).UpdateColumn("updated_at", base).Error)
+
+	export := func(opts services.ArticleExportOptions) []string {
+		var titles []string
+		require.NoError(t, services.ExportArticles(context.Background(), opts, func(article services.ExportedArticle) error {
+			titles = append(titles, article.Title)
+			return nil
+		}))
+		return titles
+	}
+
+	assert.Equal(t, []string{"First", "Second", "Third"}, export(services.ArticleExportOptions{Status: "published"}))
+	assert.Equal(t, []string{"First", "Second"}, export(services.ArticleExportOptions{Status: "published", Limit: 2}))
+	assert.ElementsMatch(t, []string{"First", "Draft", "Second", "Third"}, export(services.ArticleExportOptions{}))
+
+	// Resuming after the last article received returns only what follows it
+	var second models.Article
+	require.NoError(t, db.Where("title = ?", "Second").First(&second).Error)
+	since := second.UpdatedAt
+	assert.Equal(t, []string{"Third"}, export(services.ArticleExportOptions{Status: "published", UpdatedSince: &since, AfterID: second.ID}))
+}
+
+func TestArticleAnalytics_Aggregates(t *testing.T) {
+	db := setupArticleDB(t)
+	popular := createTestArticle(t, db, 1, "Popular", "published")
+	quiet := createTestArticle(t, db, 1, "Quiet", "published")
+
+	now := time.Now().UTC()
+	duration := 60
+	interactions := []models.UserArticleInteraction{
+		{UserID: 1, ArticleID: popular.ID, InteractionType: "view", Duration: &duration},
+		{UserID: 1, ArticleID: popular.ID, InteractionType: "view", Duration: &duration},
+		{UserID: 2, ArticleID: popular.ID, InteractionType: "view", Duration: &duration},
+		{UserID: 2, ArticleID: popular.ID, InteractionType: "share"},
+		{UserID: 3, ArticleID: quiet.ID, InteractionType: "view"},
+		{UserID: 3, ArticleID: quiet.ID, InteractionType: "bookmark"},
+		{UserID: 3, ArticleID: quiet.ID, InteractionType: "like"},
+		// Outside the period
+		{UserID: 4, ArticleID: quiet.ID, InteractionType: "view", CreatedAt: now.AddDate(0, 0, -60)},
+	}
+	require.NoError(t, db.Create(&interactions).Error)
+
+	report, err := services.GetArticleAnalytics(services.ArticleAnalyticsOptions{From: now.AddDate(0, 0, -30), To: now.Add(time.Minute)})
+	require.NoError(t, err)
+	require.Len(t, report.Articles, 2)
+
+	first := report.Articles[0]
+	assert.Equal(t, popular.ID, first.ArticleID)
+	assert.EqualValues(t, 3, first.Views)
+	assert.EqualValues(t, 2, first.UniqueViewers)
+	assert.EqualValues(t, 1, first.Shares)
+	assert.EqualValues(t, 1, first.Engagements)
+	assert.InDelta(t, 60, first.AvgReadSeconds, 0.001)
+
+	second := report.Articles[1]
+	assert.EqualValues(t, 1, second.Views)
+	assert.EqualValues(t, 2, second.Engagements)
+	assert.InDelta(t, 2.0, second.EngagementRate, 0.001)
+
+	assert.EqualValues(t, 4, report.Totals.Views)
+	assert.EqualValues(t, 3, report.Totals.Engagements)
+
+	_, err = services.GetArticleAnalytics(services.ArticleAnalyticsOptions{From: now, To: now.Add(-time.Hour)})
+	assert.ErrorIs(t, err, services.ErrValidation)
+}
@@ -0,0 +1,95 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+
+	"news/internal/models"
+	"news/tests/testutil"
+)
+
+func newTestRevision(t *testing.T, number int, article models.Article, blocks []models.ArticleContentBlock) models.ArticleRevision {
+	revision, err := models.NewArticleRevision(article, blocks)
+	require.NoError(t, err)
+	revision.RevisionNumber = number
+	return revision
+}
+
+func TestArticleRevision_SnapshotRoundTrip(t *testing.T) {
+	article := *testutil.NewTestData().CreateTestArticle()
+	blocks := []models.ArticleContentBlock{
+		{ID: 10, BlockType: "heading", Content: "Intro", Position: 1, IsVisible: true},
+		{ID: 11, BlockType: "text", Content: "Body", Settings: datatypes.JSON(`{"text_align":"left"}`), Position: 2, IsVisible: true},
+	}
+
+	revision := newTestRevision(t, 1, article, blocks)
+	assert.Equal(t, article.Title, revision.Title)
+	assert.Equal(t, article.MetaDesc, revision.MetaDesc)
+
+	restored, err := revision.ToContentBlocks()
+	require.NoError(t, err)
+	require.Len(t, restored, 2)
+	assert.Equal(t, "heading", restored[0].BlockType)
+	assert.Equal(t, "Body", restored[1].Content)
+	assert.JSONEq(t, `{"text_align":"left"}`, string(restored[1].Settings))
+	assert.Equal(t, uint(10), restored[0].ID, "restored blocks keep their IDs so they are restored in place")
+}
+
+func TestDiffArticleRevisions_Fields(t *testing.T) {
+	article := *testutil.NewTestData().CreateTestArticle()
+	from := newTestRevision(t, 1, article, nil)
+
+	article.Title = "Updated title"
+	article.MetaTitle = "Updated meta"
+	to := newTestRevision(t, 2, article, nil)
+
+	diff, err := models.DiffArticleRevisions(from, to)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, diff.FromRevision)
+	assert.Equal(t, 2, diff.ToRevision)
+	require.Len(t, diff.FieldChanges, 2)
+	assert.Equal(t, "title", diff.FieldChanges[0].Field)
+	assert.Equal(t, "Updated title", diff.FieldChanges[0].To)
+	assert.Equal(t, "meta_title", diff.FieldChanges[1].Field)
+	assert.Empty(t, diff.BlockChanges)
+}
+
+func TestDiffArticleRevisions_Blocks(t *testing.T) {
+	article := *testutil.NewTestData().CreateTestArticle()
+	from := newTestRevision(t, 1, article, []models.ArticleContentBlock{
+		{ID: 1, BlockType: "heading", Content: "Title", IsVisible: true},
+		{ID: 2, BlockType: "text", Content: "First paragraph", Settings: datatypes.JSON(`{"a":1,"b":2}`), IsVisible: true},
+		{ID: 3, BlockType: "quote", Content: "Removed quote", IsVisible: true},
+		{ID: 4, BlockType: "text", Content: "Closing", IsVisible: true},
+	})
+
+	// Blocks are recreated with new IDs on every save, so only content should be compared
+	to := newTestRevision(t, 2, article, []models.ArticleContentBlock{
+		{ID: 21, BlockType: "heading", Content: "Title", IsVisible: true},
+		{ID: 22, BlockType: "text", Content: "First paragraph", Settings: datatypes.JSON(`{ "b": 2, "a": 1 }`), IsVisible: true},
+		{ID: 23, BlockType: "text", Content: "Closing, revised", IsVisible: true},
+		{ID: 24, BlockType: "image", Content: "https://example.com/a.jpg", IsVisible: true},
+	})
+
+	diff, err := models.DiffArticleRevisions(from, to)
+	require.NoError(t, err)
+
+	kinds := make([]string, 0, len(diff.BlockChanges))
+	for _, change := range diff.BlockChanges {
+		kinds = append(kinds, change.Change)
+	}
+	assert.Equal(t, []string{"unchanged", "unchanged", "removed", "modified", "added"}, kinds)
+
+	modified := diff.BlockChanges[3]
+	assert.Equal(t, []string{"content"}, modified.ChangedFields)
+	assert.Equal(t, 4, *modified.FromPosition)
+	assert.Equal(t, 3, *modified.ToPosition)
+
+	assert.Equal(t, 1, diff.BlocksAdded)
+	assert.Equal(t, 1, diff.BlocksRemoved)
+	assert.Equal(t, 1, diff.BlocksChanged)
+}
@@ -0,0 +1,53 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"news/internal/models"
+)
+
+func TestArticle_IsDueForPublication(t *testing.T) {
+	now := time.Date(2025, 6, 20, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	tests := []struct {
+		name        string
+		status      string
+		scheduledAt *time.Time
+		due         bool
+	}{
+		{"scheduled in the past", "scheduled", &past, true},
+		{"scheduled exactly now", "scheduled", &now, true},
+		{"scheduled in the future", "scheduled", &future, false},
+		{"scheduled without time", "scheduled", nil, false},
+		{"draft with past time", "draft", &past, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			article := models.Article{Status: tt.status, ScheduledAt: tt.scheduledAt}
+			assert.Equal(t, tt.due, article.IsDueForPublication(now))
+		})
+	}
+}
+
+func TestArticle_IsDueForUnpublication(t *testing.T) {
+	now := time.Date(2025, 6, 20, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	assert.True(t, (&models.Article{Status: "published", UnpublishAt: &past}).IsDueForUnpublication(now))
+	assert.False(t, (&models.Article{Status: "published", UnpublishAt: &future}).IsDueForUnpublication(now))
+	assert.False(t, (&models.Article{Status: "published"}).IsDueForUnpublication(now))
+	assert.False(t, (&models.Article{Status: "archived", UnpublishAt: &past}).IsDueForUnpublication(now))
+}
+
+func TestArticle_UnpublishStatus(t *testing.T) {
+	assert.Equal(t, "archived", (&models.Article{}).UnpublishStatus())
+	assert.Equal(t, "archived", (&models.Article{UnpublishTo: "archived"}).UnpublishStatus())
+	assert.Equal(t, "draft", (&models.Article{UnpublishTo: "draft"}).UnpublishStatus())
+}
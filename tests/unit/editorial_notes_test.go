@@ -0,0 +1,107 @@
+package unit
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"news/internal/models"
+	"news/internal/services"
+)
+
+func setupBlockArticle(t *testing.T) (*gorm.DB, models.Article, []models.ArticleContentBlock) {
+	db := setupArticleDB(t)
+	require.NoError(t, db.AutoMigrate(&models.EditorialNote{}))
+
+	article := createTestArticle(t, db, bulkAuthor.ID, "Block article", "draft")
+	require.NoError(t, db.Model(&article).Updates(map[string]interface{}{"content_type": "blocks", "has_blocks": true}).Error)
+
+	blocks := []models.ArticleContentBlock{
+		{ArticleID: article.ID, BlockType: "heading", Content: "Heading", Position: 1, IsVisible: true},
+		{ArticleID: article.ID, BlockType: "text", Content: "The quick brown fox", Position: 2, IsVisible: true},
+	}
+	require.NoError(t, db.Create(&blocks).Error)
+	return db, article, blocks
+}
+
+func TestEditorialNotes_StayAnchoredWhenBlocksAreSaved(t *testing.T) {
+	db, article, blocks := setupBlockArticle(t)
+	notes := services.NewEditorialNoteService(db)
+	start, end := 10, 15
+	note, err := notes.Create(models.EditorialNoteArticle, article.ID, bulkEditor, services.EditorialNoteRequest{
+		BlockID: blocks[1].ID, Body: "Which fox?", AnchorStart: &start, AnchorEnd: &end,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "brown", note.AnchorText)
+
+	// Save the blocks in a new order, with text inserted before the anchor and a new block
+	saved := []models.ArticleContentBlock{
+		{ID: blocks[1].ID, BlockType: "text", Content: "Look: the quick brown fox", IsVisible: true},
+		{ID: blocks[0].ID, BlockType: "heading", Content: "Heading", IsVisible: true},
+		{BlockType: "quote", Content: "New quote", IsVisible: true},
+	}
+	require.NoError(t, services.UpdateArticleBlocks(articleIDString(article), saved, bulkEditor.ID))
+
+	var stored []models.ArticleContentBlock
+	require.NoError(t, db.Where("article_id = ?", article.ID).Order("position").Find(&stored).Error)
+	require.Len(t, stored, 3)
+	assert.Equal(t, blocks[1].ID, stored[0].ID, "blocks saved with their ID are updated in place")
+	assert.Equal(t, blocks[0].ID, stored[1].ID)
+	assert.NotZero(t, stored[2].ID)
+
+	listed, err := notes.List(models.EditorialNoteArticle, article.ID, bulkEditor, services.EditorialNoteFilter{})
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.False(t, listed[0].BlockMissing)
+	assert.False(t, listed[0].AnchorOutdated)
+	assert.Equal(t, 16, *listed[0].AnchorStart)
+	assert.Equal(t, 21, *listed[0].AnchorEnd)
+
+	// Listing does not rewrite the stored anchor
+	var unchanged models.EditorialNote
+	require.NoError(t, db.First(&unchanged, note.ID).Error)
+	assert.Equal(t, start, *unchanged.AnchorStart)
+
+	// Removing the block detaches the note
+	require.NoError(t, services.UpdateArticleBlocks(articleIDString(article), []models.ArticleContentBlock{
+		{ID: blocks[0].ID, BlockType: "heading", Content: "Heading", IsVisible: true},
+	}, bulkEditor.ID))
+	listed, err = notes.List(models.EditorialNoteArticle, article.ID, bulkEditor, services.EditorialNoteFilter{})
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.True(t, listed[0].BlockMissing)
+}
+
+func TestEditorialNotes_StayAnchoredWhenRevisionIsRestored(t *testing.T) {
+	db, article, blocks := setupBlockArticle(t)
+	notes := services.NewEditorialNoteService(db)
+	_, err := notes.Create(models.EditorialNoteArticle, article.ID, bulkEditor, services.EditorialNoteRequest{
+		BlockID: blocks[1].ID, Body: "Check this paragraph",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, services.UpdateArticleBlocks(articleIDString(article), []models.ArticleContentBlock{
+		{ID: blocks[0].ID, BlockType: "heading", Content: "Heading", IsVisible: true},
+		{ID: blocks[1].ID, BlockType: "text", Content: "The slow brown fox", IsVisible: true},
+	}, bulkEditor.ID))
+
+	// Revision 1 is the baseline taken before the first block save
+	_, err = services.RestoreArticleRevision(article.ID, 1, bulkEditor.ID)
+	require.NoError(t, err)
+
+	var restored models.ArticleContentBlock
+	require.NoError(t, db.First(&restored, blocks[1].ID).Error)
+	assert.Equal(t, "The quick brown fox", restored.Content)
+
+	listed, err := notes.List(models.EditorialNoteArticle, article.ID, bulkEditor, services.EditorialNoteFilter{})
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.False(t, listed[0].BlockMissing)
+}
+
+func articleIDString(article models.Article) string {
+	return fmt.Sprint(article.ID)
+}
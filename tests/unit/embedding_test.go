@@ -0,0 +1,66 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"news/internal/embedding"
+)
+
+func TestFakeEmbeddingIsDeterministic(t *testing.T) {
+	provider := embedding.NewFake(64)
+	ctx := context.Background()
+
+	first, err := provider.Embed(ctx, "Central bank raises interest rates")
+	require.NoError(t, err)
+	second, err := provider.Embed(ctx, "central bank raises INTEREST rates!")
+	require.NoError(t, err)
+
+	assert.Len(t, first, 64)
+	assert.Equal(t, first, second, "case and punctuation do not change the vector")
+
+	score, err := embedding.Cosine(first, second)
+	require.NoError(t, err)
+	assert.InDelta(t, 1.0, score, 1e-9)
+}
+
+func TestEmbeddingRankPrefersSharedWords(t *testing.T) {
+	provider := embedding.NewFake(256)
+	ctx := context.Background()
+
+	embed := func(text string) []float64 {
+		vector, err := provider.Embed(ctx, text)
+		require.NoError(t, err)
+		return vector
+	}
+
+	query := embed("interest rates inflation")
+	candidates := []embedding.Candidate{
+		{ID: 1, Vector: embed("Football club wins the cup final")},
+		{ID: 2, Vector: embed("Inflation falls as interest rates stay high")},
+		{ID: 3, Vector: embed("Interest rates unchanged")},
+		{ID: 4, Vector: []float64{1, 0}}, // Different dimensions are skipped
+	}
+
+	matches := embedding.Rank(query, candidates, 2, 0.1)
+	require.Len(t, matches, 2)
+	assert.ElementsMatch(t, []uint{2, 3}, []uint{matches[0].ID, matches[1].ID})
+	assert.GreaterOrEqual(t, matches[0].Score, matches[1].Score)
+}
+
+func TestEmbeddingEncodeRoundTrip(t *testing.T) {
+	vector := []float64{0.25, -0.5, 1, 0}
+
+	decoded, err := embedding.Decode(embedding.Encode(vector))
+	require.NoError(t, err)
+	assert.Equal(t, vector, decoded)
+
+	_, err = embedding.Decode([]byte{1, 2, 3})
+	assert.Error(t, err)
+
+	_, err = embedding.Cosine([]float64{1}, []float64{1, 2})
+	assert.ErrorIs(t, err, embedding.ErrDimensionMismatch)
+}
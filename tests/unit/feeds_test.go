@@ -0,0 +1,126 @@
+package unit
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"news/internal/feeds"
+	"news/internal/json"
+)
+
+func sampleFeed() *feeds.Feed {
+	published := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	return &feeds.Feed{
+		Title:       "News - Economy",
+		Description: "Markets & money",
+		Link:        "https://example.com/categories/economy",
+		FeedURL:     "https://example.com/feeds/category/economy",
+		Language:    "en",
+		Updated:     published.Add(time.Hour),
+		Items: []feeds.Item{{
+			ID:         "https://example.com/articles/7",
+			Title:      "Rates <held>",
+			Link:       "https://example.com/articles/rates-held",
+			Summary:    "The central bank held rates.",
+			Content:    "<p>The central bank held rates.</p>",
+			Author:     "Jane Doe",
+			AuthorURL:  "https://example.com/authors/jane",
+			Published:  published,
+			Updated:    published.Add(time.Hour),
+			Categories: []string{"Economy", "rates"},
+			Language:   "en",
+			Image:      feeds.ImageEnclosure("https://cdn.example.com/rates.png?w=800"),
+		}},
+	}
+}
+
+func TestFeedsRSS(t *testing.T) {
+	body, err := feeds.Render(sampleFeed(), feeds.FormatRSS)
+	require.NoError(t, err)
+	doc := string(body)
+
+	assert.True(t, strings.HasPrefix(doc, "<?xml"))
+	assert.Contains(t, doc, `<rss version="2.0"`)
+	assert.Contains(t, doc, `<atom:link href="https://example.com/feeds/category/economy" rel="self" type="application/rss+xml">`)
+	assert.Contains(t, doc, `<title>Rates &lt;held&gt;</title>`)
+	assert.Contains(t, doc, `<content:encoded><![CDATA[<p>The central bank held rates.</p>]]></content:encoded>`)
+	assert.Contains(t, doc, `<dc:creator>Jane Doe</dc:creator>`)
+	assert.Contains(t, doc, `<pubDate>Sun, 01 Mar 2026 09:30:00 +0000</pubDate>`)
+	assert.Contains(t, doc, `<enclosure url="https://cdn.example.com/rates.png?w=800" type="image/png" length="0">`)
+	assert.Contains(t, doc, `<media:content url="https://cdn.example.com/rates.png?w=800" type="image/png" medium="image">`)
+}
+
+func TestFeedsAtom(t *testing.T) {
+	body, err := feeds.Render(sampleFeed(), feeds.FormatAtom)
+	require.NoError(t, err)
+	doc := string(body)
+
+	assert.Contains(t, doc, `<feed xmlns="http://www.w3.org/2005/Atom" xml:lang="en">`)
+	assert.Contains(t, doc, `<link href="https://example.com/feeds/category/economy" rel="self" type="application/atom+xml">`)
+	assert.Contains(t, doc, `<updated>2026-03-01T10:30:00Z</updated>`)
+	assert.Contains(t, doc, `<published>2026-03-01T09:30:00Z</published>`)
+	assert.Contains(t, doc, `<link href="https://cdn.example.com/rates.png?w=800" rel="enclosure" type="image/png">`)
+	assert.Contains(t, doc, `<content type="html">&lt;p&gt;The central bank held rates.&lt;/p&gt;</content>`)
+}
+
+func TestFeedsJSONFeed(t *testing.T) {
+	body, err := feeds.Render(sampleFeed(), feeds.FormatJSON)
+	require.NoError(t, err)
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &doc))
+	assert.Equal(t, "https://jsonfeed.org/version/1.1", doc["version"])
+	assert.Equal(t, "https://example.com/feeds/category/economy", doc["feed_url"])
+
+	items := doc["items"].([]interface{})
+	require.Len(t, items, 1)
+	item := items[0].(map[string]interface{})
+	assert.Equal(t, "2026-03-01T09:30:00Z", item["date_published"])
+	assert.Equal(t, "https://cdn.example.com/rates.png?w=800", item["image"])
+	attachment := item["attachments"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "image/png", attachment["mime_type"])
+
+	_, err = feeds.Render(sampleFeed(), "xml")
+	assert.Error(t, err)
+}
+
+func TestFeedsImageEnclosure(t *testing.T) {
+	assert.Nil(t, feeds.ImageEnclosure(""))
+	assert.Equal(t, "image/webp", feeds.ImageEnclosure("https://cdn.example.com/a.WEBP").Type)
+	assert.Equal(t, "image/jpeg", feeds.ImageEnclosure("https://cdn.example.com/image").Type, "unknown extensions fall back to JPEG")
+}
+
+func TestFeedsETagIsStable(t *testing.T) {
+	first, err := feeds.Render(sampleFeed(), feeds.FormatRSS)
+	require.NoError(t, err)
+	second, err := feeds.Render(sampleFeed(), feeds.FormatRSS)
+	require.NoError(t, err)
+	assert.Equal(t, feeds.ETag(first), feeds.ETag(second))
+
+	changed := sampleFeed()
+	changed.Items[0].Title = "Rates cut"
+	third, err := feeds.Render(changed, feeds.FormatRSS)
+	require.NoError(t, err)
+	assert.NotEqual(t, feeds.ETag(first), feeds.ETag(third))
+}
+
+func TestFeedsNotModified(t *testing.T) {
+	etag := `"abc"`
+	modified := time.Date(2026, 3, 1, 10, 30, 0, 500, time.UTC)
+
+	assert.True(t, feeds.NotModified(`"abc"`, "", etag, modified))
+	assert.True(t, feeds.NotModified(`"x", W/"abc"`, "", etag, modified))
+	assert.True(t, feeds.NotModified("*", "", etag, modified))
+	assert.False(t, feeds.NotModified(`"x"`, modified.Format(http.TimeFormat), etag, modified),
+		"If-None-Match takes precedence over If-Modified-Since")
+
+	assert.True(t, feeds.NotModified("", modified.Format(http.TimeFormat), etag, modified))
+	assert.False(t, feeds.NotModified("", modified.Add(-time.Minute).Format(http.TimeFormat), etag, modified))
+	assert.False(t, feeds.NotModified("", "yesterday", etag, modified))
+	assert.False(t, feeds.NotModified("", "", etag, modified))
+}
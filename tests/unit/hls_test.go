@@ -0,0 +1,165 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"news/internal/hls"
+)
+
+// fakeExecutor answers ffprobe with canned JSON and plays ffmpeg by writing the playlist and
+// segments named in its arguments
+type fakeExecutor struct {
+	probe    string
+	segments int
+	fail     string
+	calls    [][]string
+}
+
+func (f *fakeExecutor) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	f.calls = append(f.calls, append([]string{name}, args...))
+	if strings.Contains(name, "ffprobe") {
+		return []byte(f.probe), nil
+	}
+
+	playlist := args[len(args)-1]
+	if f.fail != "" && strings.Contains(playlist, f.fail) {
+		return nil, errors.New("encoder crashed")
+	}
+	var pattern string
+	for i, arg := range args {
+		if arg == "-hls_segment_filename" {
+			pattern = args[i+1]
+		}
+	}
+	for i := 0; i < f.segments; i++ {
+		if err := os.WriteFile(fmt.Sprintf(pattern, i), []byte("ts"), 0o644); err != nil {
+			return nil, err
+		}
+	}
+	return nil, os.WriteFile(playlist, []byte("#EXTM3U\n"), 0o644)
+}
+
+const probeLandscape720 = `{
+	"format": {"duration": "12.5", "size": "1048576", "bit_rate": "800000"},
+	"streams": [
+		{"codec_type": "video", "codec_name": "h264", "width": 1280, "height": 720, "r_frame_rate": "30000/1001"},
+		{"codec_type": "audio", "codec_name": "aac"}
+	]
+}`
+
+func TestHLSParseProbe(t *testing.T) {
+	metadata, err := hls.ParseProbe([]byte(probeLandscape720))
+	require.NoError(t, err)
+	assert.Equal(t, 1280, metadata.Width)
+	assert.Equal(t, 720, metadata.Height)
+	assert.InDelta(t, 12.5, metadata.Duration, 0.001)
+	assert.InDelta(t, 29.97, metadata.FrameRate, 0.01)
+	assert.Equal(t, 800000, metadata.Bitrate, "falls back to the container bitrate")
+	assert.True(t, metadata.HasAudio())
+
+	_, err = hls.ParseProbe([]byte(`{"format": {}, "streams": [{"codec_type": "audio", "codec_name": "mp3"}]}`))
+	assert.Error(t, err, "audio-only files cannot be packaged")
+}
+
+func TestHLSPlanCapsAtSource(t *testing.T) {
+	ladder, err := hls.SelectRenditions([]string{"240p", "480p", "720p", "1080p"})
+	require.NoError(t, err)
+
+	variants := hls.Plan(1280, 720, ladder)
+	require.Len(t, variants, 3, "1080p is skipped for a 720p source")
+	assert.Equal(t, "240p", variants[0].Name)
+	assert.Equal(t, 426, variants[0].Width)
+	assert.Equal(t, 240, variants[0].Height)
+	assert.Equal(t, 1280, variants[2].Width)
+
+	// Portrait videos are scaled by their short side
+	variants = hls.Plan(1080, 1920, ladder)
+	require.Len(t, variants, 4)
+	assert.Equal(t, 480, variants[1].Width)
+	assert.Equal(t, 852, variants[1].Height)
+
+	variants = hls.Plan(160, 120, ladder)
+	require.Len(t, variants, 1, "small sources keep their own size")
+	assert.Equal(t, "120p", variants[0].Name)
+	assert.Equal(t, 160, variants[0].Width)
+
+	_, err = hls.SelectRenditions([]string{"4k"})
+	assert.Error(t, err)
+}
+
+func TestHLSPackageWritesLadderAndMaster(t *testing.T) {
+	dir := t.TempDir()
+	executor := &fakeExecutor{probe: probeLandscape720, segments: 2}
+
+	metadata, err := hls.Probe(context.Background(), executor, "/opt/bin/ffprobe", "source.mp4")
+	require.NoError(t, err)
+	ladder, err := hls.SelectRenditions([]string{"240p", "480p", "720p", "1080p"})
+	require.NoError(t, err)
+
+	var progress []int
+	packager := &hls.Packager{Executor: executor, FFmpegPath: "/opt/bin/ffmpeg", SegmentDuration: 4}
+	result, err := packager.Package(context.Background(), "source.mp4", dir, metadata, hls.Plan(metadata.Width, metadata.Height, ladder), func(done, total int) {
+		progress = append(progress, done*100/total)
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{33, 66, 100}, progress)
+	require.Len(t, result.Outputs, 3)
+	assert.Equal(t, "720p/index.m3u8", result.Outputs[2].Playlist)
+	assert.Equal(t, []string{"720p/segment_0000.ts", "720p/segment_0001.ts"}, result.Outputs[2].Segments)
+	assert.Equal(t, "avc1.4d401f,mp4a.40.2", result.Outputs[2].Codecs)
+	assert.Equal(t, (2996+128)*1000, result.Outputs[2].Bandwidth)
+
+	files := result.Files()
+	assert.Len(t, files, 10)
+	assert.Equal(t, hls.MasterPlaylist, files[len(files)-1], "the master playlist is uploaded last")
+
+	// The configured binaries are used
+	assert.Equal(t, "/opt/bin/ffprobe", executor.calls[0][0])
+	ffmpeg := strings.Join(executor.calls[1], " ")
+	assert.True(t, strings.HasPrefix(ffmpeg, "/opt/bin/ffmpeg "))
+	assert.Contains(t, ffmpeg, "-vf scale=426:240")
+	assert.Contains(t, ffmpeg, "-force_key_frames expr:gte(t,n_forced*4)")
+	assert.Contains(t, ffmpeg, "-map 0:a:0")
+
+	master, err := os.ReadFile(filepath.Join(dir, hls.MasterPlaylist))
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(master)), "\n")
+	assert.Equal(t, "#EXTM3U", lines[0])
+	assert.Contains(t, lines[3], "RESOLUTION=426x240")
+	assert.Equal(t, "240p/index.m3u8", lines[4])
+	assert.Equal(t, "720p/index.m3u8", lines[len(lines)-1])
+}
+
+func TestHLSPackageReportsFailedRendition(t *testing.T) {
+	executor := &fakeExecutor{probe: probeLandscape720, segments: 1, fail: "480p"}
+	metadata, err := hls.ParseProbe([]byte(probeLandscape720))
+	require.NoError(t, err)
+
+	packager := &hls.Packager{Executor: executor, FFmpegPath: "ffmpeg"}
+	_, err = packager.Package(context.Background(), "source.mp4", t.TempDir(), metadata, hls.Plan(1280, 720, hls.DefaultLadder), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "480p")
+}
+
+func TestHLSPackageWithoutAudio(t *testing.T) {
+	executor := &fakeExecutor{segments: 1}
+	metadata := &hls.Metadata{Width: 640, Height: 360, VideoCodec: "h264"}
+
+	packager := &hls.Packager{Executor: executor, FFmpegPath: "ffmpeg"}
+	result, err := packager.Package(context.Background(), "source.mp4", t.TempDir(), metadata, hls.Plan(640, 360, hls.DefaultLadder[:1]), nil)
+	require.NoError(t, err)
+
+	assert.Contains(t, executor.calls[0], "-an")
+	assert.Equal(t, "avc1.4d401e", result.Outputs[0].Codecs)
+	assert.Equal(t, 428*1000, result.Outputs[0].Bandwidth)
+}
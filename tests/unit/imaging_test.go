@@ -0,0 +1,116 @@
+package unit
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"news/internal/imaging"
+	"news/internal/storage"
+)
+
+func solidImage(width, height int, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestImagingFit(t *testing.T) {
+	w, h := imaging.Fit(4000, 3000, 800)
+	assert.Equal(t, 800, w)
+	assert.Equal(t, 600, h)
+
+	w, h = imaging.Fit(300, 200, 800)
+	assert.Equal(t, 300, w, "images are never scaled up")
+	assert.Equal(t, 200, h)
+}
+
+func TestImagingResizeScalesImages(t *testing.T) {
+	solid := imaging.Resize(solidImage(40, 20, color.NRGBA{R: 10, G: 120, B: 200, A: 255}), 8, 4)
+	assert.Equal(t, image.Rect(0, 0, 8, 4), solid.Bounds())
+	assert.Equal(t, color.NRGBA{R: 10, G: 120, B: 200, A: 255}, solid.NRGBAAt(3, 2))
+
+	// Left half red, right half blue
+	src := solidImage(40, 4, color.NRGBA{R: 255, A: 255})
+	for y := 0; y < 4; y++ {
+		for x := 20; x < 40; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{B: 255, A: 255})
+		}
+	}
+
+	dst := imaging.Resize(src, 4, 1)
+	left, right := dst.NRGBAAt(0, 0), dst.NRGBAAt(3, 0)
+	assert.Greater(t, int(left.R), 200)
+	assert.Less(t, int(left.B), 55)
+	assert.Greater(t, int(right.B), 200)
+	assert.Less(t, int(right.R), 55)
+
+	mixed := imaging.Resize(src, 1, 1).NRGBAAt(0, 0)
+	assert.InDelta(t, 127, int(mixed.R), 2)
+	assert.InDelta(t, 127, int(mixed.B), 2)
+}
+
+func TestImagingDominantColor(t *testing.T) {
+	img := solidImage(100, 100, color.NRGBA{R: 200, G: 30, B: 30, A: 255})
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 100; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: 10, G: 10, B: 240, A: 255})
+		}
+	}
+	assert.Equal(t, "#c81e1e", imaging.DominantColor(img))
+
+	transparent := solidImage(10, 10, color.NRGBA{})
+	assert.Empty(t, imaging.DominantColor(transparent))
+}
+
+func TestImagingDecodeAndEncode(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, solidImage(40, 20, color.NRGBA{G: 255, A: 255})))
+
+	img, format, err := imaging.Decode(buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, "png", format)
+	assert.Equal(t, 40, img.Bounds().Dx())
+
+	_, _, err = imaging.Decode([]byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`))
+	assert.True(t, errors.Is(err, imaging.ErrUnsupported))
+
+	format, ext, err := imaging.Encode(io.Discard, imaging.Resize(img, 20, 10), 85)
+	require.NoError(t, err)
+	assert.Equal(t, "jpeg", format, "opaque derivatives are JPEG")
+	assert.Equal(t, ".jpg", ext)
+
+	format, _, err = imaging.Encode(io.Discard, solidImage(2, 2, color.NRGBA{R: 255, A: 100}), 85)
+	require.NoError(t, err)
+	assert.Equal(t, "png", format, "transparency is kept")
+}
+
+func TestLocalStorageNestedKeys(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewLocalStorage(dir)
+
+	key, err := store.Upload(bytes.NewReader([]byte("data")), "media/2025/06/file_thumb.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, "media/2025/06/file_thumb.jpg", key)
+
+	content, err := os.ReadFile(filepath.Join(dir, "media", "2025", "06", "file_thumb.jpg"))
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(content))
+
+	require.NoError(t, store.Delete(key))
+	_, err = os.Stat(filepath.Join(dir, key))
+	assert.True(t, os.IsNotExist(err))
+}
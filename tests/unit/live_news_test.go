@@ -0,0 +1,72 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"news/internal/models"
+	"news/internal/services"
+	"news/tests/testutil"
+)
+
+func TestLiveNewsStreamScheduledStatus(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		stream models.LiveNewsStream
+		want   string
+	}{
+		{"scheduled before start", models.LiveNewsStream{Status: models.LiveStreamScheduled, StartTime: &future}, models.LiveStreamScheduled},
+		{"scheduled after start", models.LiveNewsStream{Status: models.LiveStreamScheduled, StartTime: &past}, models.LiveStreamLive},
+		{"scheduled after start and end", models.LiveNewsStream{Status: models.LiveStreamScheduled, StartTime: &past, EndTime: &past}, models.LiveStreamEnded},
+		{"scheduled without start", models.LiveNewsStream{Status: models.LiveStreamScheduled}, models.LiveStreamScheduled},
+		{"live before end", models.LiveNewsStream{Status: models.LiveStreamLive, EndTime: &future}, models.LiveStreamLive},
+		{"live after end", models.LiveNewsStream{Status: models.LiveStreamLive, EndTime: &past}, models.LiveStreamEnded},
+		{"draft is left alone", models.LiveNewsStream{Status: models.LiveStreamDraft, StartTime: &past}, models.LiveStreamDraft},
+		{"ended is left alone", models.LiveNewsStream{Status: models.LiveStreamEnded, StartTime: &past, EndTime: &future}, models.LiveStreamEnded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.stream.ScheduledStatus(now))
+		})
+	}
+}
+
+func TestLiveNewsUpdateHighlighted(t *testing.T) {
+	for _, importance := range []string{models.LiveUpdateHigh, models.LiveUpdateCritical, models.LiveUpdatePinned} {
+		update := models.LiveNewsUpdate{Importance: importance}
+		assert.True(t, update.IsHighlighted(), importance)
+		assert.True(t, models.ValidLiveUpdateImportance(importance))
+	}
+
+	normal := models.LiveNewsUpdate{Importance: models.LiveUpdateNormal}
+	assert.False(t, normal.IsHighlighted())
+	assert.True(t, models.ValidLiveUpdateImportance(models.LiveUpdateNormal))
+	assert.False(t, models.ValidLiveUpdateImportance("urgent"))
+}
+
+func TestPruneLiveNewsEvents(t *testing.T) {
+	db := testutil.SetupSQLiteDB(t, &models.LiveNewsEvent{})
+	now := time.Now()
+	for _, age := range []time.Duration{30 * 24 * time.Hour, 8 * 24 * time.Hour, 8 * 24 * time.Hour, time.Hour} {
+		require.NoError(t, db.Create(&models.LiveNewsEvent{StreamID: 1, Type: models.LiveEventUpdateCreated, Payload: []byte("{}"), CreatedAt: now.Add(-age)}).Error)
+	}
+
+	pruned, err := services.PruneLiveNewsEvents(now, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, pruned)
+	pruned, err = services.PruneLiveNewsEvents(now, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pruned)
+
+	var remaining []models.LiveNewsEvent
+	require.NoError(t, db.Find(&remaining).Error)
+	require.Len(t, remaining, 1)
+	assert.Equal(t, uint(4), remaining[0].ID, "recent events are kept")
+}
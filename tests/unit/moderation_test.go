@@ -0,0 +1,82 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"news/internal/moderation"
+)
+
+func TestModerationDecideByMode(t *testing.T) {
+	decision := moderation.Decide(moderation.Policy{Mode: moderation.ModeAutoApprove}, -20, nil)
+	assert.Equal(t, moderation.StatusApproved, decision.Status, "auto approve ignores trust")
+
+	decision = moderation.Decide(moderation.Policy{Mode: moderation.ModePreModerate}, 0, nil)
+	assert.Equal(t, moderation.StatusPending, decision.Status)
+	assert.True(t, decision.Queued())
+
+	trusting := moderation.Policy{Mode: moderation.ModePreModerate, TrustThreshold: 5}
+	assert.True(t, moderation.Decide(trusting, 4, nil).Queued())
+	decision = moderation.Decide(trusting, 5, nil)
+	assert.Equal(t, moderation.StatusApproved, decision.Status, "trusted commenters skip the queue")
+	assert.False(t, decision.Screened)
+}
+
+func TestModerationDecideAIScreen(t *testing.T) {
+	policy := moderation.Policy{Mode: moderation.ModeAIScreen, ApproveThreshold: 0.9, RejectThreshold: 0.8, TrustThreshold: 10}
+
+	decision := moderation.Decide(policy, 0, &moderation.Verdict{Approved: true, Confidence: 0.95})
+	assert.Equal(t, moderation.StatusApproved, decision.Status)
+	assert.True(t, decision.Screened)
+
+	decision = moderation.Decide(policy, 0, &moderation.Verdict{Approved: true, Confidence: 0.7})
+	assert.True(t, decision.Queued(), "unsure approvals wait for a moderator")
+
+	decision = moderation.Decide(policy, 0, &moderation.Verdict{Approved: false, Confidence: 0.85})
+	assert.Equal(t, moderation.StatusRejected, decision.Status)
+
+	decision = moderation.Decide(policy, 0, &moderation.Verdict{Approved: false, Confidence: 0.85, Spam: true})
+	assert.Equal(t, moderation.StatusSpam, decision.Status)
+
+	decision = moderation.Decide(policy, 0, &moderation.Verdict{Approved: false, Confidence: 0.5})
+	assert.True(t, decision.Queued())
+
+	decision = moderation.Decide(policy, 0, nil)
+	assert.True(t, decision.Queued(), "comments are queued when the AI is unavailable")
+	assert.False(t, decision.Screened)
+
+	assert.True(t, policy.NeedsScreening(9))
+	assert.False(t, policy.NeedsScreening(10), "trusted commenters are not screened")
+	assert.False(t, moderation.Policy{Mode: moderation.ModePreModerate}.NeedsScreening(0))
+}
+
+func TestModerationTrustScore(t *testing.T) {
+	assert.Equal(t, 0, moderation.TrustScore(0, 0, 0))
+	assert.Equal(t, 7, moderation.TrustScore(10, 1, 0))
+	assert.Equal(t, 0, moderation.TrustScore(10, 0, 1), "a spam verdict wipes out ten approvals")
+}
+
+func TestModerationStrictest(t *testing.T) {
+	_, ok := moderation.Strictest(nil)
+	assert.False(t, ok)
+
+	policy, ok := moderation.Strictest([]moderation.Policy{
+		{Mode: moderation.ModeAutoApprove},
+		{Mode: moderation.ModeAIScreen, ApproveThreshold: 0.9},
+		{Mode: moderation.ModeAIScreen, ApproveThreshold: 0.5},
+	})
+	assert.True(t, ok)
+	assert.Equal(t, moderation.ModeAIScreen, policy.Mode)
+	assert.Equal(t, 0.9, policy.ApproveThreshold, "ties keep the first policy")
+
+	policy, _ = moderation.Strictest([]moderation.Policy{{Mode: moderation.ModeAIScreen}, {Mode: moderation.ModePreModerate}})
+	assert.Equal(t, moderation.ModePreModerate, policy.Mode)
+}
+
+func TestModerationPolicyValidate(t *testing.T) {
+	assert.NoError(t, moderation.Policy{Mode: moderation.ModeAIScreen, ApproveThreshold: 0.9, RejectThreshold: 0.8}.Validate())
+	assert.Error(t, moderation.Policy{Mode: "post_moderate"}.Validate())
+	assert.Error(t, moderation.Policy{Mode: moderation.ModeAIScreen, ApproveThreshold: 1.5}.Validate())
+	assert.Error(t, moderation.Policy{Mode: moderation.ModePreModerate, TrustThreshold: -1}.Validate())
+}
@@ -0,0 +1,89 @@
+package unit
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"news/internal/config"
+	"news/internal/mail"
+	"news/internal/models"
+	"news/internal/services"
+	"news/tests/testutil"
+)
+
+// countingMailer accepts every message and counts them
+type countingMailer struct {
+	sent int32
+}
+
+func (m *countingMailer) Send(ctx context.Context, msg mail.Message) (string, error) {
+	atomic.AddInt32(&m.sent, 1)
+	return "<test@example.com>", nil
+}
+
+func setupNewsletterDelivery(t *testing.T, status string, updatedAt time.Time) (*gorm.DB, models.NewsletterDelivery) {
+	db := testutil.SetupSQLiteDB(t, &models.User{}, &models.Newsletter{}, &models.Subscription{},
+		&models.NewsletterDelivery{}, &models.EmailTemplateTranslation{})
+
+	newsletter := models.Newsletter{Title: "Weekly", Subject: "Weekly", Content: "News", Status: "sending", CreatedBy: 1}
+	require.NoError(t, db.Create(&newsletter).Error)
+	subscription := models.Subscription{Email: "reader@example.com", Type: "newsletter", IsActive: true, Token: "token"}
+	require.NoError(t, db.Create(&subscription).Error)
+	delivery := models.NewsletterDelivery{
+		NewsletterID:   newsletter.ID,
+		SubscriptionID: subscription.ID,
+		Email:          subscription.Email,
+		Language:       "en",
+		Status:         status,
+	}
+	require.NoError(t, db.Create(&delivery).Error)
+	require.NoError(t, db.Model(&delivery).UpdateColumn("updated_at", updatedAt).Error)
+	return db, delivery
+}
+
+func TestNewsletterDeliverSendsOnce(t *testing.T) {
+	db, delivery := setupNewsletterDelivery(t, services.DeliveryStatusQueued, time.Now())
+	mailer := &countingMailer{}
+	service := services.NewNewsletterDeliveryService(mailer, &config.MailConfig{PublicBaseURL: "https://news.example.com"})
+
+	require.NoError(t, service.Deliver(context.Background(), delivery.ID, false))
+	require.NoError(t, service.Deliver(context.Background(), delivery.ID, false))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&mailer.sent))
+
+	require.NoError(t, db.First(&delivery, delivery.ID).Error)
+	assert.Equal(t, services.DeliveryStatusSent, delivery.Status)
+}
+
+func TestNewsletterDeliverSkipsDeliveryBeingSent(t *testing.T) {
+	db, delivery := setupNewsletterDelivery(t, services.DeliveryStatusSending, time.Now())
+	mailer := &countingMailer{}
+	service := services.NewNewsletterDeliveryService(mailer, &config.MailConfig{PublicBaseURL: "https://news.example.com"})
+
+	require.NoError(t, service.Deliver(context.Background(), delivery.ID, false))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&mailer.sent))
+
+	// A delivery left sending by a crashed worker is sent once it is stale
+	require.NoError(t, db.Model(&delivery).UpdateColumn("updated_at", time.Now().Add(-2*time.Hour)).Error)
+	require.NoError(t, service.Deliver(context.Background(), delivery.ID, false))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&mailer.sent))
+}
+
+func TestNewsletterStalledDeliveries(t *testing.T) {
+	db, delivery := setupNewsletterDelivery(t, services.DeliveryStatusSending, time.Now())
+
+	stalled, err := services.GetStalledNewsletterDeliveries(time.Now(), 10)
+	require.NoError(t, err)
+	assert.Empty(t, stalled)
+
+	require.NoError(t, db.Model(&delivery).UpdateColumn("updated_at", time.Now().Add(-2*time.Hour)).Error)
+	stalled, err = services.GetStalledNewsletterDeliveries(time.Now(), 10)
+	require.NoError(t, err)
+	require.Len(t, stalled, 1)
+	assert.Equal(t, delivery.ID, stalled[0].ID)
+}
@@ -0,0 +1,214 @@
+package unit
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"news/internal/config"
+	"news/internal/mail"
+	"news/internal/models"
+)
+
+// smtpSink is a minimal SMTP server that records accepted messages and rejects
+// recipients whose address starts with "reject"
+type smtpSink struct {
+	listener net.Listener
+	messages chan string
+}
+
+func newSMTPSink(t *testing.T) *smtpSink {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	sink := &smtpSink{listener: listener, messages: make(chan string, 10)}
+	go sink.serve()
+	t.Cleanup(func() { _ = listener.Close() })
+	return sink
+}
+
+func (s *smtpSink) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpSink) handle(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+	reply("220 localhost ESMTP sink")
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM"):
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO"):
+			if strings.Contains(command, "<REJECT") {
+				reply("550 5.1.1 Mailbox unavailable")
+			} else {
+				reply("250 OK")
+			}
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			s.messages <- data.String()
+			reply("250 OK queued")
+		case command == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func (s *smtpSink) mailer() *mail.SMTPMailer {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+
+	return mail.NewSMTPMailer(&config.MailConfig{
+		SMTPHost:    host,
+		SMTPPort:    portNumber,
+		Timeout:     5 * time.Second,
+		FromAddress: "newsletter@example.com",
+		FromName:    "News",
+	})
+}
+
+func TestRenderNewsletter_WrapsContentWithTemplate(t *testing.T) {
+	newsletter := models.Newsletter{Title: "Weekly", Subject: "This week", Content: "<p>Top &amp; trending</p>"}
+	template := &models.EmailTemplateTranslation{
+		PlainBody:     "Hello {{name}}",
+		HTMLBody:      "<h1>Hello {{name}}</h1>{{content}}",
+		PreheaderText: "Your digest",
+	}
+	recipient := mail.Recipient{
+		Email:          "reader@example.com",
+		Name:           "Ada <Admin>",
+		Language:       "tr",
+		UnsubscribeURL: "https://news.example.com/api/subscriptions/unsubscribe/abc",
+	}
+
+	msg := mail.RenderNewsletter(newsletter, template, recipient)
+
+	assert.Equal(t, "This week", msg.Subject)
+	assert.Contains(t, msg.HTMLBody, "<h1>Hello Ada &lt;Admin&gt;</h1><p>Top &amp; trending</p>")
+	assert.Contains(t, msg.HTMLBody, "Your digest")
+	assert.Contains(t, msg.HTMLBody, "Abonelikten çık")
+	assert.Contains(t, msg.PlainBody, "Hello Ada <Admin>\n\nTop & trending")
+	assert.Contains(t, msg.PlainBody, recipient.UnsubscribeURL)
+	assert.Equal(t, "<"+recipient.UnsubscribeURL+">", msg.Headers["List-Unsubscribe"])
+	assert.Equal(t, "List-Unsubscribe=One-Click", msg.Headers["List-Unsubscribe-Post"])
+}
+
+func TestRenderSubscriptionConfirmation_UsesLocalizedDefault(t *testing.T) {
+	confirmURL := "https://news.example.com/api/subscriptions/confirm/abc?x=1&y=2"
+	recipient := mail.Recipient{Email: "reader@example.com", Language: "tr"}
+
+	msg := mail.RenderSubscriptionConfirmation(nil, recipient, confirmURL)
+
+	assert.Equal(t, "reader@example.com", msg.To)
+	assert.Equal(t, "Lütfen aboneliğinizi onaylayın", msg.Subject)
+	assert.Contains(t, msg.PlainBody, confirmURL)
+	assert.Contains(t, msg.HTMLBody, `href="https://news.example.com/api/subscriptions/confirm/abc?x=1&amp;y=2"`)
+}
+
+func TestRenderSubscriptionConfirmation_AppendsLinkToTemplate(t *testing.T) {
+	template := &models.EmailTemplateTranslation{
+		Subject:   "Confirm",
+		PlainBody: "Hi {{name}}",
+		HTMLBody:  "<p>Hi {{name}}</p>",
+	}
+	recipient := mail.Recipient{Email: "reader@example.com", Name: "Ada"}
+
+	msg := mail.RenderSubscriptionConfirmation(template, recipient, "https://example.com/c/abc")
+
+	assert.Equal(t, "Hi Ada\n\nhttps://example.com/c/abc", msg.PlainBody)
+	assert.Contains(t, msg.HTMLBody, `<p>Hi Ada</p>`)
+	assert.Contains(t, msg.HTMLBody, `href="https://example.com/c/abc"`)
+}
+
+func TestSMTPMailer_SendsToSink(t *testing.T) {
+	sink := newSMTPSink(t)
+
+	msg := mail.RenderNewsletter(
+		models.Newsletter{Subject: "Haftalık Bülten", Content: "<p>Hello</p>"},
+		nil,
+		mail.Recipient{Email: "reader@example.com", UnsubscribeURL: "https://example.com/u/abc"},
+	)
+
+	messageID, err := sink.mailer().Send(context.Background(), msg)
+	require.NoError(t, err)
+	assert.Contains(t, messageID, "@example.com>")
+
+	select {
+	case data := <-sink.messages:
+		assert.Contains(t, data, "Message-ID: "+messageID)
+		assert.Contains(t, data, "List-Unsubscribe: <https://example.com/u/abc>")
+		assert.Contains(t, data, "Subject: =?utf-8?q?")
+		assert.Contains(t, data, "multipart/alternative")
+	case <-time.After(2 * time.Second):
+		t.Fatal("sink did not receive the message")
+	}
+}
+
+func TestSMTPMailer_RejectedRecipientIsPermanent(t *testing.T) {
+	sink := newSMTPSink(t)
+
+	_, err := sink.mailer().Send(context.Background(), mail.Message{
+		To:        "reject@example.com",
+		Subject:   "Hello",
+		PlainBody: "Hello",
+	})
+	require.Error(t, err)
+	assert.True(t, mail.IsPermanent(err))
+}
+
+func TestSMTPMailer_ConnectionFailureIsTemporary(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().(*net.TCPAddr)
+	require.NoError(t, listener.Close())
+
+	mailer := mail.NewSMTPMailer(&config.MailConfig{
+		SMTPHost:    "127.0.0.1",
+		SMTPPort:    addr.Port,
+		Timeout:     time.Second,
+		FromAddress: "newsletter@example.com",
+	})
+
+	_, err = mailer.Send(context.Background(), mail.Message{To: "reader@example.com", PlainBody: "Hello"})
+	require.Error(t, err)
+	assert.False(t, mail.IsPermanent(err))
+}
@@ -0,0 +1,140 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+
+	"news/internal/models"
+	"news/internal/pagetemplate"
+)
+
+func TestPageTemplateParseBlocks(t *testing.T) {
+	structure, err := pagetemplate.Parse([]byte(`{
+		"layout": "fullwidth",
+		"blocks": [
+			{"block_type": "hero", "settings": {"title": "Welcome"}, "children": [
+				{"block_type": "heading", "content": "Hello"},
+				{"block_type": "button", "content": "Start", "is_visible": false}
+			]},
+			{"block_type": "paragraph", "content": "Body"}
+		]
+	}`))
+	require.NoError(t, err)
+	require.NoError(t, structure.Validate())
+
+	assert.Equal(t, "fullwidth", structure.Layout)
+	require.Len(t, structure.Blocks, 2)
+	assert.Equal(t, 4, pagetemplate.Count(structure.Blocks))
+	hero := structure.Blocks[0]
+	assert.Equal(t, "Welcome", hero.Settings["title"])
+	require.Len(t, hero.Children, 2)
+	assert.True(t, hero.Children[0].Visible(), "blocks are visible by default")
+	assert.False(t, hero.Children[1].Visible())
+}
+
+func TestPageTemplateParseSeededSections(t *testing.T) {
+	structure, err := pagetemplate.Parse([]byte(`{
+		"sections": [
+			{"type": "hero", "component": "hero-section", "props": {"title": "{{page.hero_title}}"}},
+			{"type": "mission", "component": "mission-section", "props": {"imagePosition": "right"}}
+		],
+		"layout": "default"
+	}`))
+	require.NoError(t, err)
+	require.NoError(t, structure.Validate())
+	require.Len(t, structure.Blocks, 2)
+
+	assert.Equal(t, "hero", structure.Blocks[0].BlockType, "section types that are block types are kept")
+	assert.Equal(t, "hero-section", structure.Blocks[0].Settings["component"])
+
+	mission := structure.Blocks[1]
+	assert.Equal(t, "section", mission.BlockType)
+	assert.True(t, mission.IsContainer)
+	assert.Equal(t, "mission", mission.Settings["section_type"])
+	assert.Equal(t, "right", mission.Settings["imagePosition"])
+
+	// Written back in the block format
+	raw, err := structure.JSON()
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), `"sections"`)
+	assert.Contains(t, string(raw), `"block_type":"section"`)
+}
+
+func TestPageTemplateValidate(t *testing.T) {
+	_, err := pagetemplate.Parse([]byte(`not json`))
+	assert.ErrorIs(t, err, pagetemplate.ErrInvalid)
+	_, err = pagetemplate.Parse(nil)
+	assert.ErrorIs(t, err, pagetemplate.ErrEmpty)
+
+	empty, err := pagetemplate.Parse([]byte(`{"blocks": []}`))
+	require.NoError(t, err)
+	assert.ErrorIs(t, empty.Validate(), pagetemplate.ErrEmpty)
+
+	unknown := pagetemplate.Structure{Blocks: []pagetemplate.Block{{BlockType: "marquee"}}}
+	assert.ErrorContains(t, unknown.Validate(), "invalid block type")
+
+	leafWithChildren := pagetemplate.Structure{Blocks: []pagetemplate.Block{
+		{BlockType: "paragraph", Children: []pagetemplate.Block{{BlockType: "text"}}},
+	}}
+	assert.ErrorContains(t, leafWithChildren.Validate(), "cannot contain blocks")
+
+	deep := pagetemplate.Block{BlockType: "text"}
+	for i := 0; i < pagetemplate.MaxDepth; i++ {
+		deep = pagetemplate.Block{BlockType: "container", Children: []pagetemplate.Block{deep}}
+	}
+	tooDeep := pagetemplate.Structure{Blocks: []pagetemplate.Block{deep}}
+	assert.ErrorContains(t, tooDeep.Validate(), "nested more than")
+
+	many := pagetemplate.Structure{Blocks: make([]pagetemplate.Block, pagetemplate.MaxBlocks+1)}
+	for i := range many.Blocks {
+		many.Blocks[i].BlockType = "text"
+	}
+	assert.ErrorContains(t, many.Validate(), "at most")
+}
+
+func TestPageTemplateFromBlocks(t *testing.T) {
+	section, column := uint(1), uint(2)
+	blocks := []models.PageContentBlock{
+		{ID: 4, PageID: 9, ContainerID: &column, BlockType: "paragraph", Content: "Second", Position: 2, IsVisible: true},
+		{ID: 1, PageID: 9, BlockType: "section", Position: 1, IsVisible: true, IsContainer: true,
+			Settings: datatypes.JSON(`{"background_color": "#fff"}`)},
+		{ID: 3, PageID: 9, ContainerID: &column, BlockType: "heading", Content: "First", Position: 1, IsVisible: true},
+		{ID: 2, PageID: 9, ContainerID: &section, BlockType: "column", Position: 1, IsVisible: true, IsContainer: true},
+		{ID: 5, PageID: 9, BlockType: "html", Content: "<hr>", Position: 2, IsVisible: false},
+	}
+
+	tree := pagetemplate.FromBlocks(blocks)
+	require.Len(t, tree, 2)
+	assert.Equal(t, "section", tree[0].BlockType)
+	assert.Equal(t, "#fff", tree[0].Settings["background_color"])
+	assert.False(t, tree[1].Visible(), "hidden blocks stay hidden")
+
+	require.Len(t, tree[0].Children, 1)
+	columnNode := tree[0].Children[0]
+	require.Len(t, columnNode.Children, 2)
+	assert.Equal(t, "First", columnNode.Children[0].Content, "children are ordered by position")
+	assert.Equal(t, "Second", columnNode.Children[1].Content)
+	assert.Equal(t, 5, pagetemplate.Count(tree))
+
+	// A tree built from a page is a valid template
+	structure := pagetemplate.Structure{Blocks: tree}
+	require.NoError(t, structure.Validate())
+	raw, err := structure.JSON()
+	require.NoError(t, err)
+	reparsed, err := pagetemplate.Parse(raw)
+	require.NoError(t, err)
+	assert.Equal(t, tree, reparsed.Blocks)
+	assert.False(t, strings.Contains(string(raw), `"id"`), "block IDs are not part of a template")
+}
+
+func TestPageTemplateFromBlocksOrphans(t *testing.T) {
+	missing := uint(42)
+	tree := pagetemplate.FromBlocks([]models.PageContentBlock{
+		{ID: 1, BlockType: "text", ContainerID: &missing, Position: 1, IsVisible: true},
+	})
+	require.Len(t, tree, 1, "a block whose container is gone is kept at the top level")
+}
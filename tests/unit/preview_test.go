@@ -0,0 +1,85 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"news/internal/models"
+	"news/internal/preview"
+)
+
+var previewSecret = []byte("preview-test-secret")
+
+func signPreview(t *testing.T, claims preview.Claims) string {
+	t.Helper()
+	token, err := preview.Sign(claims, previewSecret)
+	require.NoError(t, err)
+	return token
+}
+
+func TestPreviewTokenRoundTrip(t *testing.T) {
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	id, err := preview.NewID()
+	require.NoError(t, err)
+	assert.Len(t, id, 32)
+
+	token := signPreview(t, preview.Claims{ID: id, EntityType: "article", EntityID: 12, ExpiresAt: now.Add(time.Hour).Unix()})
+	assert.NotContains(t, token, "=", "tokens are URL safe")
+
+	claims, err := preview.Verify(token, previewSecret, now)
+	require.NoError(t, err)
+	assert.Equal(t, id, claims.ID)
+	assert.Equal(t, "article", claims.EntityType)
+	assert.Equal(t, uint(12), claims.EntityID)
+	assert.True(t, claims.Expires().Equal(now.Add(time.Hour)))
+}
+
+func TestPreviewTokenRejected(t *testing.T) {
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	claims := preview.Claims{ID: "abc", EntityType: "page", EntityID: 3, ExpiresAt: now.Add(time.Hour).Unix()}
+	token := signPreview(t, claims)
+
+	_, err := preview.Verify(token, []byte("other-secret"), now)
+	assert.ErrorIs(t, err, preview.ErrSignature)
+
+	// A payload for another entity under the original signature
+	other := signPreview(t, preview.Claims{ID: "abc", EntityType: "page", EntityID: 4, ExpiresAt: claims.ExpiresAt})
+	payload, _, _ := strings.Cut(other, ".")
+	_, signature, _ := strings.Cut(token, ".")
+	_, err = preview.Verify(payload+"."+signature, previewSecret, now)
+	assert.ErrorIs(t, err, preview.ErrSignature)
+
+	_, err = preview.Verify(token, previewSecret, now.Add(time.Hour))
+	assert.ErrorIs(t, err, preview.ErrExpired)
+
+	for _, malformed := range []string{"", "no-dot", ".sig", "payload.", "payload.!!!"} {
+		_, err = preview.Verify(malformed, previewSecret, now)
+		assert.ErrorIs(t, err, preview.ErrMalformed, malformed)
+	}
+}
+
+func TestPreviewTokenIsActive(t *testing.T) {
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	token := models.PreviewToken{ExpiresAt: now.Add(time.Hour)}
+	assert.True(t, token.IsActive(now))
+
+	token.UseCount = 3
+	assert.True(t, token.IsActive(now), "reusable tokens can be used again")
+
+	token.SingleUse = true
+	assert.False(t, token.IsActive(now), "single-use tokens stop after the first use")
+
+	token = models.PreviewToken{ExpiresAt: now.Add(time.Hour), Revoked: true}
+	assert.False(t, token.IsActive(now))
+
+	token = models.PreviewToken{ExpiresAt: now}
+	assert.False(t, token.IsActive(now))
+
+	assert.True(t, models.IsValidPreviewEntity(models.PreviewEntityArticle))
+	assert.True(t, models.IsValidPreviewEntity(models.PreviewEntityPage))
+	assert.False(t, models.IsValidPreviewEntity("video"))
+}
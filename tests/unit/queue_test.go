@@ -0,0 +1,175 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"news/internal/queue"
+	"news/tests/testutil"
+)
+
+// newFailedTestJob enqueues a job that fails on its first attempt and returns
+// the queue it was dead-lettered in
+func newFailedTestJob(t *testing.T, id string) *queue.RedisQueue {
+	testutil.SetupTestRedis(t)
+	rq := queue.NewRedisQueue("test")
+	require.NotNil(t, rq)
+
+	require.NoError(t, rq.Enqueue(&queue.Job{ID: id, Type: "test", MaxAttempts: 1}))
+	job, err := rq.Dequeue()
+	require.NoError(t, err)
+	require.Equal(t, id, job.ID)
+	require.NoError(t, rq.FailJob(id, "boom"))
+	return rq
+}
+
+func TestQueueFailedJobIsDeadLettered(t *testing.T) {
+	rq := newFailedTestJob(t, "job-1")
+
+	dead, total, err := rq.GetDeadLetterJobs(1, 10)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+	require.Len(t, dead, 1)
+	assert.Equal(t, "job-1", dead[0].ID)
+	assert.Equal(t, "boom", dead[0].ErrorMsg)
+
+	// The failed job stays visible to the regular job listings
+	job, err := rq.GetJob("job-1")
+	require.NoError(t, err)
+	assert.Equal(t, queue.JobStatusFailed, job.Status)
+
+	failed, total, err := rq.GetJobs(string(queue.JobStatusFailed), 1, 10)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+	require.Len(t, failed, 1)
+	assert.Equal(t, "job-1", failed[0].ID)
+}
+
+func TestQueueRetryFailedJob(t *testing.T) {
+	rq := newFailedTestJob(t, "job-1")
+
+	require.NoError(t, rq.RetryJob("job-1"))
+
+	job, err := rq.GetJob("job-1")
+	require.NoError(t, err)
+	assert.Equal(t, queue.JobStatusPending, job.Status)
+
+	_, total, err := rq.GetDeadLetterJobs(1, 10)
+	require.NoError(t, err)
+	assert.Zero(t, total, "a retried job leaves the dead letter queue")
+
+	next, err := rq.Dequeue()
+	require.NoError(t, err)
+	require.NotNil(t, next)
+	assert.Equal(t, "job-1", next.ID)
+
+	assert.Error(t, rq.RetryJob("job-1"), "only failed jobs can be retried")
+}
+
+func TestQueueDeleteFailedJob(t *testing.T) {
+	rq := newFailedTestJob(t, "job-1")
+
+	require.NoError(t, rq.DeleteJob("job-1"))
+
+	_, err := rq.GetJob("job-1")
+	assert.Error(t, err)
+	_, total, err := rq.GetDeadLetterJobs(1, 10)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+}
+
+func TestQueueReplayDeadLetterJobs(t *testing.T) {
+	rq := newFailedTestJob(t, "job-1")
+	require.NoError(t, rq.Enqueue(&queue.Job{ID: "job-2", Type: "test", MaxAttempts: 1}))
+	_, err := rq.Dequeue()
+	require.NoError(t, err)
+	require.NoError(t, rq.FailJob("job-2", "boom"))
+
+	replayed, err := rq.ReplayDeadLetterJobs([]string{"job-2"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, replayed)
+
+	job, err := rq.GetJob("job-2")
+	require.NoError(t, err)
+	assert.Equal(t, queue.JobStatusPending, job.Status)
+	assert.Zero(t, job.Attempts, "replayed jobs get a fresh attempt budget")
+	assert.Empty(t, job.ErrorMsg)
+
+	dead, total, err := rq.GetDeadLetterJobs(1, 10)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+	require.Len(t, dead, 1)
+	assert.Equal(t, "job-1", dead[0].ID)
+
+	// No IDs replays everything that is left
+	replayed, err = rq.ReplayDeadLetterJobs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, replayed)
+	stats, err := rq.GetStats()
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, stats.PendingJobs)
+}
+
+func TestQueuePurgeDeadLetterJobs(t *testing.T) {
+	rq := newFailedTestJob(t, "job-1")
+
+	purged, err := rq.PurgeDeadLetterJobs([]string{"unknown"})
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+
+	purged, err = rq.PurgeDeadLetterJobs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	_, total, err := rq.GetDeadLetterJobs(1, 10)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	_, err = rq.GetJob("job-1")
+	assert.Error(t, err, "purged jobs are removed completely")
+}
+
+func TestQueuePauseAndResume(t *testing.T) {
+	testutil.SetupTestRedis(t)
+	rq := queue.NewRedisQueue("test")
+	require.NotNil(t, rq)
+
+	require.NoError(t, rq.Pause())
+	paused, err := rq.IsPaused()
+	require.NoError(t, err)
+	assert.True(t, paused)
+
+	require.NoError(t, rq.Enqueue(&queue.Job{ID: "job-1", Type: "test"}))
+	job, err := rq.BlockingDequeue(100 * time.Millisecond)
+	require.NoError(t, err)
+	assert.Nil(t, job, "paused queues hand out no jobs")
+
+	require.NoError(t, rq.Resume())
+	paused, err = rq.IsPaused()
+	require.NoError(t, err)
+	assert.False(t, paused)
+
+	job, err = rq.BlockingDequeue(100 * time.Millisecond)
+	require.NoError(t, err)
+	require.NotNil(t, job)
+	assert.Equal(t, "job-1", job.ID)
+}
+
+func TestQueueIsWaiting(t *testing.T) {
+	testutil.SetupTestRedis(t)
+	rq := queue.NewRedisQueue("test")
+	require.NotNil(t, rq)
+	require.NoError(t, rq.Enqueue(&queue.Job{ID: "job-1", Type: "test"}))
+
+	waiting, err := rq.IsWaiting("job-1")
+	require.NoError(t, err)
+	assert.True(t, waiting)
+
+	_, err = rq.Dequeue()
+	require.NoError(t, err)
+	waiting, err = rq.IsWaiting("job-1")
+	require.NoError(t, err)
+	assert.False(t, waiting)
+}
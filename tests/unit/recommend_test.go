@@ -0,0 +1,71 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"news/internal/recommend"
+)
+
+func TestRecommendDecay(t *testing.T) {
+	halfLife := 24 * time.Hour
+
+	assert.Equal(t, 1.0, recommend.Decay(0, halfLife))
+	assert.InDelta(t, 0.5, recommend.Decay(halfLife, halfLife), 1e-9)
+	assert.InDelta(t, 0.25, recommend.Decay(2*halfLife, halfLife), 1e-9)
+}
+
+func TestRecommendProfileMatch(t *testing.T) {
+	profile := recommend.NewProfile()
+	assert.True(t, profile.Empty(), "a new profile is a cold start")
+
+	profile.AddArticle(recommend.Features{CategoryIDs: []uint{1}, TagIDs: []uint{10}, AuthorID: 100}, 3, "Election results")
+	profile.AddArticle(recommend.Features{CategoryIDs: []uint{2}}, 1, "Match report")
+	profile.AddArticle(recommend.Features{CategoryIDs: []uint{3}}, -2, "Celebrity gossip")
+	profile.AddFollow(200, recommend.FollowWeight, "Jane Doe")
+
+	assert.False(t, profile.Empty())
+	assert.Equal(t, []uint{1, 2}, profile.TopCategories(5), "disliked categories are not candidates")
+	assert.Equal(t, []uint{200, 100}, profile.TopAuthors(5))
+
+	politics := profile.Match(recommend.Features{CategoryIDs: []uint{1}, TagIDs: []uint{10}})
+	assert.Equal(t, 6.0, politics.Affinity)
+	assert.Equal(t, `Because you read "Election results"`, politics.Explanation)
+
+	followed := profile.Match(recommend.Features{CategoryIDs: []uint{2}, AuthorID: 200})
+	assert.Equal(t, recommend.ReasonAuthor, followed.Reason)
+	assert.Equal(t, "Because you follow Jane Doe", followed.Explanation)
+
+	disliked := profile.Match(recommend.Features{CategoryIDs: []uint{3}})
+	assert.Less(t, disliked.Affinity, 0.0)
+	assert.Empty(t, disliked.Explanation)
+}
+
+func TestRecommendScorePrefersFreshArticles(t *testing.T) {
+	now := time.Now()
+
+	fresh := recommend.Score(4, now.Add(-time.Hour), now, 0)
+	stale := recommend.Score(4, now.Add(-20*24*time.Hour), now, 0)
+	popular := recommend.Score(4, now.Add(-20*24*time.Hour), now, 100)
+
+	assert.Greater(t, fresh, stale)
+	assert.Greater(t, popular, stale)
+}
+
+func TestRecommendSimilarity(t *testing.T) {
+	source := recommend.Features{CategoryIDs: []uint{1, 2}, TagIDs: []uint{10, 11}, AuthorID: 100}
+
+	score, reason, shared := recommend.Similarity(source, recommend.Features{CategoryIDs: []uint{1}, TagIDs: []uint{11, 12}})
+	assert.Equal(t, 3.0, score)
+	assert.Equal(t, recommend.ReasonTag, reason)
+	assert.Equal(t, []uint{11}, shared)
+
+	score, reason, _ = recommend.Similarity(source, recommend.Features{AuthorID: 100})
+	assert.Equal(t, 0.5, score)
+	assert.Equal(t, recommend.ReasonAuthor, reason)
+
+	score, _, _ = recommend.Similarity(source, recommend.Features{CategoryIDs: []uint{9}})
+	assert.Zero(t, score)
+}
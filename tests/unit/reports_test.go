@@ -0,0 +1,86 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"news/internal/config"
+	"news/internal/models"
+	"news/internal/reports"
+	"news/internal/services"
+)
+
+func TestReportReasons(t *testing.T) {
+	assert.True(t, reports.ValidReason("spam"))
+	assert.True(t, reports.ValidReason("hate_speech"))
+	assert.False(t, reports.ValidReason("boring"))
+	assert.False(t, reports.ValidReason(""))
+
+	for _, reason := range reports.Reasons {
+		assert.Positive(t, reason.Weight, reason.Code)
+		assert.NotEmpty(t, reason.Label, reason.Code)
+	}
+}
+
+func TestReportAutoHide(t *testing.T) {
+	assert.Equal(t, 5, reports.Weight([]string{"spam", "harassment", "privacy"}))
+	assert.Equal(t, 0, reports.Weight([]string{"unknown"}), "unknown reasons carry no weight")
+
+	assert.False(t, reports.ShouldHide([]string{"spam", "spam"}, 3))
+	assert.True(t, reports.ShouldHide([]string{"spam", "harassment"}, 3))
+	assert.False(t, reports.ShouldHide([]string{"violence", "violence", "violence"}, 0), "threshold 0 disables auto-hiding")
+}
+
+func TestReportTopReasons(t *testing.T) {
+	top := reports.TopReasons([]string{"spam", "other", "harassment", "spam", "harassment", "spam"})
+	assert.Equal(t, []string{"spam", "harassment", "other"}, top)
+
+	top = reports.TopReasons([]string{"privacy", "copyright"})
+	assert.Equal(t, []string{"copyright", "privacy"}, top, "ties sort by code")
+	assert.Empty(t, reports.TopReasons(nil))
+}
+
+func TestReportRateLimit(t *testing.T) {
+	assert.True(t, reports.WithinRateLimit(9, 10))
+	assert.False(t, reports.WithinRateLimit(10, 10))
+	assert.True(t, reports.WithinRateLimit(1000, 0), "limit 0 disables the rate limit")
+}
+
+func TestContentReports_RateLimitAndArticleRefresh(t *testing.T) {
+	db := setupArticleDB(t)
+	require.NoError(t, db.AutoMigrate(&models.ContentReport{}, &models.ReportedContent{}, &models.SecurityEvent{}))
+	require.NoError(t, db.Create(&models.User{ID: 5, Username: "reader", Email: "reader@example.com", Password: "secret", Role: "user"}).Error)
+	first := createTestArticle(t, db, 1, "First report", "published")
+	second := createTestArticle(t, db, 1, "Second report", "published")
+
+	refreshed := make(recordingSitemapEnqueuer, 4)
+	services.SetSitemapEnqueuer(refreshed)
+	t.Cleanup(func() { services.SetSitemapEnqueuer(nil) })
+
+	reportService := services.NewContentReportService(db, &config.ModerationConfig{
+		ReportHideThreshold: 100,
+		ReportRateLimit:     1,
+		ReportRateWindow:    time.Hour,
+	})
+	_, err := reportService.Report(5, services.ReportRequest{TargetType: models.ReportTargetArticle, TargetID: first.ID, Reason: "spam"})
+	require.NoError(t, err)
+	_, err = reportService.Report(5, services.ReportRequest{TargetType: models.ReportTargetArticle, TargetID: second.ID, Reason: "spam"})
+	assert.ErrorIs(t, err, services.ErrReportRateLimited)
+
+	var content models.ReportedContent
+	require.NoError(t, db.Where("target_id = ?", first.ID).First(&content).Error)
+	_, err = reportService.Resolve(content.ID, services.ReportActionRequest{Action: models.ReportActionHide}, services.AuditInfo{ActorID: 2})
+	require.NoError(t, err)
+
+	select {
+	case id := <-refreshed:
+		assert.Equal(t, first.ID, id)
+	case <-time.After(2 * time.Second):
+		t.Fatal("hiding a reported article must refresh its public listings")
+	}
+	require.NoError(t, db.First(&first, first.ID).Error)
+	assert.Equal(t, "archived", first.Status)
+}
@@ -0,0 +1,191 @@
+package unit
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"news/internal/cache"
+	"news/internal/json"
+	"news/internal/models"
+	"news/internal/seo"
+	"news/internal/services"
+)
+
+var testPublisher = seo.Organization{Name: "News", URL: "https://example.com", Logo: "https://example.com/logo.png"}
+
+func TestSEONewsArticle(t *testing.T) {
+	published := time.Date(2026, 4, 2, 7, 0, 0, 0, time.UTC)
+	node := seo.NewsArticle(seo.Article{
+		URL:        "https://example.com/articles/rates",
+		Headline:   "Rates held",
+		Images:     []string{"https://cdn.example.com/rates.jpg"},
+		Published:  published,
+		Modified:   published.Add(time.Hour),
+		AuthorName: "Jane Doe",
+		Section:    "Economy",
+		Keywords:   []string{"rates", "banks"},
+		Language:   "en",
+	}, testPublisher)
+
+	assert.Equal(t, "NewsArticle", node["@type"])
+	assert.Equal(t, "2026-04-02T07:00:00Z", node["datePublished"])
+	assert.Equal(t, "2026-04-02T08:00:00Z", node["dateModified"])
+	assert.Equal(t, "rates, banks", node["keywords"])
+	assert.Equal(t, "Organization", node["publisher"].(seo.Object)["@type"])
+	assert.Equal(t, "Jane Doe", node["author"].([]seo.Object)[0]["name"])
+	assert.NotContains(t, node, "description", "empty fields are left out")
+
+	// Modified defaults to the publication date
+	node = seo.NewsArticle(seo.Article{URL: "https://example.com/a", Headline: "A", Published: published}, testPublisher)
+	assert.Equal(t, "2026-04-02T07:00:00Z", node["dateModified"])
+}
+
+func TestSEOVideoObject(t *testing.T) {
+	node := seo.VideoObject(seo.Video{
+		URL:        "https://example.com/videos/3",
+		Name:       "Flood footage",
+		ContentURL: "https://cdn.example.com/3/master.m3u8",
+		UploadDate: time.Date(2026, 4, 2, 7, 0, 0, 0, time.UTC),
+		Duration:   3725,
+		Views:      42,
+	}, testPublisher)
+
+	assert.Equal(t, "VideoObject", node["@type"])
+	assert.Equal(t, "PT1H2M5S", node["duration"])
+	assert.Equal(t, "Flood footage", node["description"], "a missing description falls back to the name")
+	assert.Equal(t, int64(42), node["interactionStatistic"].(seo.Object)["userInteractionCount"])
+	assert.NotContains(t, node, "thumbnailUrl")
+}
+
+func TestSEOLiveBlogPosting(t *testing.T) {
+	start := time.Date(2026, 4, 2, 7, 0, 0, 0, time.UTC)
+	node := seo.LiveBlogPosting(seo.LiveBlog{
+		URL:           "https://example.com/live/9",
+		Headline:      "Election night",
+		CoverageStart: &start,
+		Updates: []seo.LiveUpdate{
+			{ID: 2, Headline: "Polls closed", Body: "Counting starts.", Published: start.Add(2 * time.Hour)},
+			{ID: 1, Headline: "Turnout high", Body: "Queues everywhere.", Published: start.Add(time.Hour)},
+		},
+	}, testPublisher)
+
+	assert.Equal(t, "LiveBlogPosting", node["@type"])
+	assert.Equal(t, "2026-04-02T07:00:00Z", node["coverageStartTime"])
+	assert.NotContains(t, node, "coverageEndTime", "the stream is still live")
+
+	updates := node["liveBlogUpdate"].([]seo.Object)
+	require.Len(t, updates, 2)
+	assert.Equal(t, "BlogPosting", updates[0]["@type"])
+	assert.Equal(t, "https://example.com/live/9#update-2", updates[0]["url"])
+	assert.Equal(t, "Counting starts.", updates[0]["articleBody"])
+}
+
+func TestSEOBreadcrumbList(t *testing.T) {
+	node := seo.BreadcrumbList([]seo.Crumb{
+		{Name: "News", URL: "https://example.com/"},
+		{Name: "Economy", URL: "https://example.com/categories/economy"},
+		{Name: "Rates held", URL: "https://example.com/articles/rates"},
+	})
+
+	items := node["itemListElement"].([]seo.Object)
+	require.Len(t, items, 3)
+	assert.Equal(t, 1, items[0]["position"])
+	assert.Equal(t, 3, items[2]["position"])
+	assert.Equal(t, "https://example.com/categories/economy", items[1]["item"])
+}
+
+func TestSEOLocalize(t *testing.T) {
+	assert.Nil(t, seo.Localize([]seo.Alternate{{Lang: "tr", Href: "https://example.com/articles/secim"}}))
+
+	alternates := seo.Localize([]seo.Alternate{
+		{Lang: "tr", Href: "https://example.com/articles/secim"},
+		{Lang: "en", Href: "https://example.com/articles/election?lang=en"},
+	})
+	require.Len(t, alternates, 3)
+	assert.Equal(t, seo.Alternate{Lang: "x-default", Href: "https://example.com/articles/secim"}, alternates[2])
+}
+
+func TestSEOHelpers(t *testing.T) {
+	assert.Equal(t, "tr_TR", seo.Locale("tr"))
+	assert.Equal(t, "pt_BR", seo.Locale("pt-br"))
+	assert.Equal(t, "xx", seo.Locale("xx"))
+
+	assert.Equal(t, "PT45S", seo.ISODuration(45))
+	assert.Equal(t, "PT2M", seo.ISODuration(120))
+	assert.Empty(t, seo.ISODuration(0))
+
+	assert.Equal(t, "Fish & chips today", seo.Excerpt("<p>Fish &amp; chips</p>\n<p>today</p>", 160))
+	assert.Equal(t, "The quick brown…", seo.Excerpt("The quick brown fox jumps", 18))
+
+	assert.Len(t, seo.ParseSchema(`{"@type": "FAQPage"}`), 1)
+	assert.Len(t, seo.ParseSchema(`[{"@type": "FAQPage"}, {"@type": "Event"}]`), 2)
+	assert.Empty(t, seo.ParseSchema(`not json`))
+	assert.Empty(t, seo.ParseSchema(""))
+}
+
+func TestSEOMetadataJSON(t *testing.T) {
+	meta := seo.Metadata{
+		EntityType:   "article",
+		EntityID:     7,
+		Title:        "Rates held",
+		CanonicalURL: "https://example.com/articles/rates",
+		Robots:       seo.RobotsIndex,
+		OpenGraph:    seo.OpenGraph{Type: "article", Title: "Rates held", URL: "https://example.com/articles/rates"},
+		Twitter:      seo.TwitterCard{Card: "summary", Title: "Rates held"},
+		Alternates:   []seo.Alternate{{Lang: "en", Href: "https://example.com/articles/rates"}},
+		JSONLD:       []seo.Object{{"@type": "NewsArticle"}},
+	}
+
+	body, err := json.Marshal(meta)
+	require.NoError(t, err)
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &doc))
+	assert.Equal(t, "article", doc["open_graph"].(map[string]interface{})["type"])
+	assert.Equal(t, "en", doc["alternates"].([]interface{})[0].(map[string]interface{})["hreflang"])
+	assert.Equal(t, "NewsArticle", doc["json_ld"].([]interface{})[0].(map[string]interface{})["@type"])
+}
+
+// recordingSitemapEnqueuer records the sitemap refreshes requested by services
+type recordingSitemapEnqueuer chan uint
+
+func (r recordingSitemapEnqueuer) EnqueueSitemapRefresh(section string, entityID uint) error {
+	r <- entityID
+	return nil
+}
+
+func TestSEOMetadataRefreshedAfterBlockEditsAndRestores(t *testing.T) {
+	db, article, blocks := setupBlockArticle(t)
+	require.NoError(t, db.Model(&article).Update("status", "published").Error)
+
+	refreshed := make(recordingSitemapEnqueuer, 4)
+	services.SetSitemapEnqueuer(refreshed)
+	t.Cleanup(func() { services.SetSitemapEnqueuer(nil) })
+
+	unified := cache.GetUnifiedCache()
+	cacheKey := fmt.Sprintf("seo:article:%d:en", article.ID)
+	expectRefresh := func(change string) {
+		select {
+		case id := <-refreshed:
+			assert.Equal(t, article.ID, id, change)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no sitemap refresh was requested after %s", change)
+		}
+		_, found := unified.GetString(cacheKey)
+		assert.False(t, found, "SEO metadata must be dropped after %s", change)
+	}
+
+	require.NoError(t, unified.Set(cacheKey, `{"title":"Block article"}`, time.Minute, time.Minute))
+	require.NoError(t, services.UpdateArticleBlocks(articleIDString(article), []models.ArticleContentBlock{
+		{ID: blocks[0].ID, BlockType: "heading", Content: "New heading", IsVisible: true},
+	}, bulkEditor.ID))
+	expectRefresh("a block edit")
+
+	require.NoError(t, unified.Set(cacheKey, `{"title":"Block article"}`, time.Minute, time.Minute))
+	_, err := services.RestoreArticleRevision(article.ID, 1, bulkEditor.ID)
+	require.NoError(t, err)
+	expectRefresh("a revision restore")
+}
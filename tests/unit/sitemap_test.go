@@ -0,0 +1,117 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+
+	"news/internal/models"
+	"news/internal/sitemap"
+)
+
+func TestSitemapShards(t *testing.T) {
+	assert.Equal(t, 1, sitemap.Shard(1, 100))
+	assert.Equal(t, 1, sitemap.Shard(100, 100))
+	assert.Equal(t, 2, sitemap.Shard(101, 100))
+	assert.Equal(t, 1, sitemap.Shard(0, 100))
+
+	first, last := sitemap.ShardRange(2, 100)
+	assert.Equal(t, uint(101), first)
+	assert.Equal(t, uint(200), last)
+	assert.Equal(t, 2, sitemap.Shard(first, 100))
+	assert.Equal(t, 2, sitemap.Shard(last, 100))
+}
+
+func TestSitemapParseFile(t *testing.T) {
+	section, shard, ok := sitemap.ParseFile(sitemap.ShardFile(sitemap.SectionArticles, 12))
+	assert.True(t, ok)
+	assert.Equal(t, "articles", section)
+	assert.Equal(t, 12, shard)
+
+	section, _, ok = sitemap.ParseFile("news.xml")
+	assert.True(t, ok)
+	assert.Empty(t, section)
+
+	for _, name := range []string{"articles-0.xml", "articles-01.xml", "users-1.xml", "articles-1", "../sitemap.xml", "pages-x.xml"} {
+		_, _, ok := sitemap.ParseFile(name)
+		assert.False(t, ok, name)
+	}
+}
+
+func TestSitemapURLSetWithAlternates(t *testing.T) {
+	group := sitemap.Localize([]sitemap.URL{
+		{Loc: "https://example.com/articles/secim", Lang: "tr", LastMod: time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)},
+		{Loc: "https://example.com/articles/election?lang=en", Lang: "en"},
+	})
+	require.Len(t, group[1].Alternates, 3)
+
+	body, err := sitemap.URLSet(group)
+	require.NoError(t, err)
+	doc := string(body)
+
+	assert.Contains(t, doc, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:xhtml="http://www.w3.org/1999/xhtml">`)
+	assert.Contains(t, doc, `<lastmod>2026-05-01T08:00:00Z</lastmod>`)
+	assert.Contains(t, doc, `<xhtml:link rel="alternate" hreflang="en" href="https://example.com/articles/election?lang=en"></xhtml:link>`)
+	assert.Contains(t, doc, `<xhtml:link rel="alternate" hreflang="x-default" href="https://example.com/articles/secim"></xhtml:link>`)
+	assert.Equal(t, 2, strings.Count(doc, `hreflang="tr"`), "each URL lists every translation, itself included")
+	assert.NotContains(t, doc, "news:")
+
+	single := sitemap.Localize([]sitemap.URL{{Loc: "https://example.com/tags/go"}})
+	assert.Empty(t, single[0].Alternates)
+}
+
+func TestSitemapNews(t *testing.T) {
+	body, err := sitemap.URLSet([]sitemap.URL{{
+		Loc: "https://example.com/articles/rates",
+		News: &sitemap.News{
+			PublicationName: "News",
+			Language:        "en",
+			Title:           "Rates & markets",
+			PublishedAt:     time.Date(2026, 5, 1, 9, 30, 0, 0, time.FixedZone("TRT", 3*3600)),
+		},
+	}})
+	require.NoError(t, err)
+	doc := string(body)
+
+	assert.Contains(t, doc, `xmlns:news="http://www.google.com/schemas/sitemap-news/0.9"`)
+	assert.Contains(t, doc, `<news:publication><news:name>News</news:name><news:language>en</news:language></news:publication>`)
+	assert.Contains(t, doc, `<news:publication_date>2026-05-01T06:30:00Z</news:publication_date>`)
+	assert.Contains(t, doc, `<news:title>Rates &amp; markets</news:title>`)
+}
+
+func TestSitemapIndex(t *testing.T) {
+	body, err := sitemap.Index([]sitemap.IndexEntry{
+		{Loc: "https://example.com/sitemaps/news.xml"},
+		{Loc: "https://example.com/sitemaps/articles-1.xml", LastMod: time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)},
+	})
+	require.NoError(t, err)
+	doc := string(body)
+
+	assert.Contains(t, doc, `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+	assert.Contains(t, doc, `<sitemap><loc>https://example.com/sitemaps/news.xml</loc></sitemap>`)
+	assert.Contains(t, doc, `<sitemap><loc>https://example.com/sitemaps/articles-1.xml</loc><lastmod>2026-05-01T08:00:00Z</lastmod></sitemap>`)
+}
+
+func TestSitemapURLLimit(t *testing.T) {
+	_, err := sitemap.URLSet(make([]sitemap.URL, sitemap.MaxURLs+1))
+	assert.Error(t, err)
+}
+
+func TestPageNoIndex(t *testing.T) {
+	cases := map[string]bool{
+		``:                        false,
+		`{}`:                      false,
+		`{"robots_index": true}`:  false,
+		`{"robots_index": false}`: true,
+		`{"noindex": true}`:       true,
+		`{"og_title": "About", "noindex": false}`: false,
+	}
+	for settings, noindex := range cases {
+		page := models.Page{SEOSettings: datatypes.JSON(settings)}
+		assert.Equal(t, noindex, page.NoIndex(), settings)
+	}
+}
@@ -0,0 +1,107 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"news/internal/middleware"
+	"news/internal/models"
+	"news/internal/services"
+	"news/tests/testutil"
+)
+
+func TestSubscription_ValidateTarget(t *testing.T) {
+	id := uint(7)
+
+	tests := []struct {
+		name         string
+		subscription models.Subscription
+		valid        bool
+	}{
+		{"newsletter without target", models.Subscription{Type: "newsletter"}, true},
+		{"newsletter with category", models.Subscription{Type: "newsletter", CategoryID: &id}, false},
+		{"category with category", models.Subscription{Type: "category", CategoryID: &id}, true},
+		{"category without category", models.Subscription{Type: "category"}, false},
+		{"category with extra tag", models.Subscription{Type: "category", CategoryID: &id, TagID: &id}, false},
+		{"tag with tag", models.Subscription{Type: "tag", TagID: &id}, true},
+		{"tag with author", models.Subscription{Type: "tag", AuthorID: &id}, false},
+		{"author with author", models.Subscription{Type: "author", AuthorID: &id}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.valid, tt.subscription.ValidateTarget())
+		})
+	}
+}
+
+func TestSubscription_IsPendingConfirmation(t *testing.T) {
+	now := time.Now()
+
+	assert.True(t, (&models.Subscription{}).IsPendingConfirmation())
+	assert.False(t, (&models.Subscription{ConfirmedAt: &now}).IsPendingConfirmation())
+	assert.False(t, (&models.Subscription{UnsubscribedAt: &now}).IsPendingConfirmation())
+}
+
+func TestSubscription_CanBeActivatedBy(t *testing.T) {
+	now := time.Now()
+	verified := &models.User{Email: "Reader@Example.com", IsVerified: true}
+	unverified := &models.User{Email: "reader@example.com"}
+
+	own := &models.Subscription{Email: "reader@example.com"}
+	assert.True(t, own.CanBeActivatedBy(verified), "own verified address")
+	assert.False(t, own.CanBeActivatedBy(unverified), "own address is not verified")
+
+	other := &models.Subscription{Email: "someone@example.com"}
+	assert.False(t, other.CanBeActivatedBy(verified), "another address needs confirmation")
+
+	other.ConfirmedAt = &now
+	assert.True(t, other.CanBeActivatedBy(verified), "address was confirmed before")
+	assert.True(t, other.CanBeActivatedBy(unverified))
+}
+
+func TestSubscribeRateLimitPerIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/subscriptions", middleware.SubscribeRateLimit(nil), func(c *gin.Context) {
+		c.Status(http.StatusAccepted)
+	})
+
+	subscribe := func(ip string) int {
+		req := httptest.NewRequest(http.MethodPost, "/subscriptions", nil)
+		req.RemoteAddr = ip + ":1234"
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusAccepted, subscribe("192.0.2.1"))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, subscribe("192.0.2.1"))
+	assert.Equal(t, http.StatusAccepted, subscribe("192.0.2.2"), "addresses are limited separately")
+}
+
+func TestGetSubscriptionByTokenReturnsOnlySubscription(t *testing.T) {
+	db := testutil.SetupSQLiteDB(t, &models.User{}, &models.Category{}, &models.Tag{}, &models.Subscription{})
+	author := models.User{Username: "author", Email: "author@example.com", Password: "secret", Role: "author"}
+	require.NoError(t, db.Create(&author).Error)
+	subscription := models.Subscription{Email: "reader@example.com", Type: "author", AuthorID: &author.ID, IsActive: true, Token: "unsubscribe-token"}
+	require.NoError(t, db.Create(&subscription).Error)
+
+	found, err := services.GetSubscriptionByToken("unsubscribe-token")
+	require.NoError(t, err)
+	assert.Equal(t, subscription.ID, found.ID)
+	assert.Equal(t, &author.ID, found.AuthorID)
+	assert.Nil(t, found.Author, "the author's account is not exposed")
+	assert.Nil(t, found.User)
+
+	_, err = services.GetSubscriptionByToken("unknown")
+	assert.ErrorIs(t, err, services.ErrSubscriptionNotFound)
+}
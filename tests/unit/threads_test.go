@@ -0,0 +1,159 @@
+package unit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"news/internal/models"
+	"news/internal/services"
+	"news/internal/threads"
+	"news/tests/testutil"
+)
+
+func threadEntry(id uint, parent uint, minutes int, likes, dislikes int) threads.Entry {
+	entry := threads.Entry{
+		ID:        id,
+		CreatedAt: time.Date(2025, 6, 1, 12, minutes, 0, 0, time.UTC),
+		Likes:     likes,
+		Dislikes:  dislikes,
+	}
+	if parent != 0 {
+		entry.ParentID = &parent
+	}
+	return entry
+}
+
+func entryIDs(entries []threads.Entry) []uint {
+	ids := make([]uint, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID
+	}
+	return ids
+}
+
+var threadEntries = []threads.Entry{
+	threadEntry(1, 0, 0, 5, 0),
+	threadEntry(2, 0, 1, 10, 9),
+	threadEntry(3, 0, 2, 1, 0),
+	threadEntry(4, 1, 3, 0, 0),
+	threadEntry(5, 1, 4, 2, 0),
+	threadEntry(6, 4, 5, 0, 0),
+	threadEntry(7, 99, 6, 0, 0), // Parent was removed
+	threadEntry(8, 7, 7, 0, 0),  // Reply to a removed comment
+}
+
+func TestThreadsSorts(t *testing.T) {
+	cases := map[string][]uint{
+		threads.SortNewest:        {3, 2, 1},
+		threads.SortOldest:        {1, 2, 3},
+		threads.SortTop:           {1, 3, 2}, // Ties go to the newer comment
+		threads.SortControversial: {2, 3, 1},
+	}
+	for order, expected := range cases {
+		forest := threads.Build(threadEntries, order)
+		entries, next, err := forest.Page(0, "", 10)
+		require.NoError(t, err)
+		assert.Equal(t, expected, entryIDs(entries), order)
+		assert.Empty(t, next)
+	}
+
+	assert.Greater(t, threadEntry(0, 0, 0, 10, 9).Controversy(), threadEntry(0, 0, 0, 3, 3).Controversy())
+	assert.Zero(t, threadEntry(0, 0, 0, 50, 0).Controversy(), "unanimous comments are not controversial")
+}
+
+func TestThreadsTreeSkipsOrphans(t *testing.T) {
+	forest := threads.Build(threadEntries, threads.SortTop)
+
+	replies, _, err := forest.Page(1, "", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{5, 4}, entryIDs(replies))
+	assert.Equal(t, 2, forest.Count(1))
+	assert.Equal(t, 1, forest.Count(4))
+
+	assert.True(t, forest.Has(6))
+	assert.False(t, forest.Has(7), "replies to removed comments are hidden")
+	assert.False(t, forest.Has(8))
+	assert.Equal(t, 3, forest.Count(0))
+}
+
+func TestThreadsCursorPaging(t *testing.T) {
+	forest := threads.Build(threadEntries, threads.SortNewest)
+
+	first, cursor, err := forest.Page(0, "", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{3, 2}, entryIDs(first))
+	require.NotEmpty(t, cursor)
+
+	second, next, err := forest.Page(0, cursor, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{1}, entryIDs(second))
+	assert.Empty(t, next)
+
+	// A new comment does not shift the pages already handed out
+	grown := threads.Build(append(append([]threads.Entry{}, threadEntries...), threadEntry(9, 0, 30, 0, 0)), threads.SortNewest)
+	second, _, err = grown.Page(0, cursor, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{1}, entryIDs(second))
+
+	// Cursors only work with the sort they were issued for
+	_, _, err = threads.Build(threadEntries, threads.SortTop).Page(0, cursor, 2)
+	assert.True(t, errors.Is(err, threads.ErrInvalidCursor))
+	_, _, err = forest.Page(0, "not a cursor", 2)
+	assert.True(t, errors.Is(err, threads.ErrInvalidCursor))
+}
+
+func TestThreadsOffset(t *testing.T) {
+	forest := threads.Build(threadEntries, threads.SortOldest)
+	assert.Equal(t, []uint{2, 3}, entryIDs(forest.Offset(0, 1, 5)))
+	assert.Empty(t, forest.Offset(0, 5, 5))
+}
+
+func TestThreadsMentions(t *testing.T) {
+	assert.Equal(t, []string{"alice", "bob.smith"}, threads.Mentions("@alice agreed with @bob.smith. Thanks @Alice!"))
+	assert.Empty(t, threads.Mentions("mail me at reader@example.com"), "email addresses are not mentions")
+	assert.Empty(t, threads.Mentions("@ alone"))
+	assert.Len(t, threads.Mentions("@u01 @u02 @u03 @u04 @u05 @u06 @u07 @u08 @u09 @u10 @u11 @u12"), 10)
+}
+
+func TestCommentPageTimeOrders(t *testing.T) {
+	db := testutil.SetupSQLiteDB(t, &models.User{}, &models.Article{}, &models.Comment{}, &models.Vote{})
+	require.NoError(t, db.Create(&models.User{ID: 1, Username: "reader", Email: "reader@example.com", Password: "x"}).Error)
+
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i := 1; i <= 3; i++ {
+		comment := models.Comment{ArticleID: 7, UserID: 1, Content: "comment", Status: "approved", CreatedAt: base.Add(time.Duration(i) * time.Minute)}
+		require.NoError(t, db.Create(&comment).Error)
+	}
+	parent := uint(1)
+	require.NoError(t, db.Create(&models.Comment{ArticleID: 7, UserID: 1, ParentID: &parent, Content: "reply", Status: "approved", CreatedAt: base.Add(time.Hour)}).Error)
+	require.NoError(t, db.Create(&models.Comment{ArticleID: 7, UserID: 1, Content: "held", Status: "pending", CreatedAt: base}).Error)
+
+	comments, total, err := services.GetCommentPage(7, threads.SortNewest, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, comments, 2)
+	assert.Equal(t, uint(3), comments[0].ID)
+	assert.Equal(t, uint(2), comments[1].ID)
+
+	comments, total, err = services.GetCommentPage(7, threads.SortOldest, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, comments, 2)
+	assert.Equal(t, uint(1), comments[0].ID)
+	require.Len(t, comments[0].Replies, 1)
+	assert.Equal(t, uint(4), comments[0].Replies[0].ID)
+
+	comments, _, err = services.GetCommentPage(7, threads.SortOldest, 2, 2)
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+	assert.Equal(t, uint(3), comments[0].ID)
+
+	page, err := services.GetCommentThreads(7, services.CommentThreadOptions{Sort: threads.SortNewest, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 3, page.Total)
+	assert.False(t, page.Truncated)
+}
@@ -0,0 +1,204 @@
+package unit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"news/internal/config"
+	"news/internal/models"
+	"news/internal/services"
+	"news/internal/storage"
+	"news/internal/upload"
+	"news/tests/testutil"
+)
+
+func TestUploadPartNumber(t *testing.T) {
+	const chunk = 10
+
+	number, err := upload.PartNumber(0, 0, chunk, 25, chunk)
+	require.NoError(t, err)
+	assert.Equal(t, 1, number)
+
+	number, err = upload.PartNumber(20, 20, 5, 25, chunk)
+	require.NoError(t, err)
+	assert.Equal(t, 3, number, "the last chunk may be shorter")
+
+	_, err = upload.PartNumber(10, 20, chunk, 25, chunk)
+	assert.True(t, errors.Is(err, upload.ErrOffsetMismatch))
+
+	_, err = upload.PartNumber(0, 0, 4, 25, chunk)
+	assert.True(t, errors.Is(err, upload.ErrInvalidChunk), "only the last chunk may be short")
+
+	_, err = upload.PartNumber(20, 20, chunk, 25, chunk)
+	assert.True(t, errors.Is(err, upload.ErrInvalidChunk), "chunks cannot go past the end")
+
+	_, err = upload.PartNumber(0, 0, 0, 25, chunk)
+	assert.True(t, errors.Is(err, upload.ErrInvalidChunk))
+}
+
+func TestUploadChunkChecksum(t *testing.T) {
+	data := []byte("chunk data")
+	sum := sha256.Sum256(data)
+
+	digest, err := upload.ParseChecksum("sha256 " + base64.StdEncoding.EncodeToString(sum[:]))
+	require.NoError(t, err)
+	assert.NoError(t, upload.VerifyChunk(data, digest))
+	assert.True(t, errors.Is(upload.VerifyChunk([]byte("other data"), digest), upload.ErrChecksumMismatch))
+
+	digest, err = upload.ParseChecksum("")
+	require.NoError(t, err)
+	assert.Nil(t, digest)
+	assert.NoError(t, upload.VerifyChunk(data, digest), "the checksum header is optional")
+
+	_, err = upload.ParseChecksum("md5 " + base64.StdEncoding.EncodeToString(sum[:16]))
+	assert.True(t, errors.Is(err, upload.ErrUnsupportedChecksum))
+}
+
+func TestUploadNormalizeChecksum(t *testing.T) {
+	sum := sha256.Sum256([]byte("file"))
+	expected := hex.EncodeToString(sum[:])
+
+	for _, input := range []string{
+		expected,
+		base64.StdEncoding.EncodeToString(sum[:]),
+		"sha256 " + base64.StdEncoding.EncodeToString(sum[:]),
+	} {
+		checksum, err := upload.NormalizeChecksum(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, checksum)
+	}
+
+	checksum, err := upload.NormalizeChecksum("")
+	require.NoError(t, err)
+	assert.Empty(t, checksum)
+
+	_, err = upload.NormalizeChecksum("abc")
+	assert.Error(t, err)
+}
+
+func TestUploadHashResumes(t *testing.T) {
+	h, err := upload.ResumeHash(nil)
+	require.NoError(t, err)
+	h.Write([]byte("first chunk, "))
+	state, err := h.State()
+	require.NoError(t, err)
+
+	resumed, err := upload.ResumeHash(state)
+	require.NoError(t, err)
+	resumed.Write([]byte("second chunk"))
+
+	sum := sha256.Sum256([]byte("first chunk, second chunk"))
+	assert.Equal(t, hex.EncodeToString(sum[:]), resumed.Sum())
+}
+
+func TestLocalStorageMultipartUpload(t *testing.T) {
+	dir := t.TempDir()
+	var store storage.MultipartStorage = storage.NewLocalStorage(dir)
+
+	uploadID, err := store.CreateMultipartUpload("videos/2025/06/clip.mp4", "video/mp4")
+	require.NoError(t, err)
+
+	// Parts may arrive out of order and be replaced before completion
+	etag2, err := store.UploadPart("videos/2025/06/clip.mp4", uploadID, 2, []byte("world"))
+	require.NoError(t, err)
+	_, err = store.UploadPart("videos/2025/06/clip.mp4", uploadID, 1, []byte("stale "))
+	require.NoError(t, err)
+	etag1, err := store.UploadPart("videos/2025/06/clip.mp4", uploadID, 1, []byte("hello "))
+	require.NoError(t, err)
+	assert.NotEqual(t, etag1, etag2)
+
+	require.NoError(t, store.CompleteMultipartUpload("videos/2025/06/clip.mp4", uploadID, []storage.CompletedPart{
+		{Number: 2, ETag: etag2},
+		{Number: 1, ETag: etag1},
+	}))
+
+	content, err := os.ReadFile(filepath.Join(dir, "videos", "2025", "06", "clip.mp4"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(content))
+
+	entries, err := os.ReadDir(filepath.Join(dir, ".multipart"))
+	require.NoError(t, err)
+	assert.Empty(t, entries, "parts are removed once the file is assembled")
+}
+
+func TestLocalStorageAbortMultipartUpload(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewLocalStorage(dir)
+
+	uploadID, err := store.CreateMultipartUpload("media/file.bin", "")
+	require.NoError(t, err)
+	_, err = store.UploadPart("media/file.bin", uploadID, 1, []byte("partial"))
+	require.NoError(t, err)
+
+	require.NoError(t, store.AbortMultipartUpload("media/file.bin", uploadID))
+
+	_, err = os.Stat(filepath.Join(dir, ".multipart", uploadID))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dir, "media", "file.bin"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+// newTestUploadService returns an upload service storing files in a temporary directory
+func newTestUploadService(t *testing.T, migrated ...interface{}) (*services.UploadService, *gorm.DB, string) {
+	db := testutil.SetupSQLiteDB(t, append([]interface{}{&models.UploadSession{}}, migrated...)...)
+	dir := t.TempDir()
+	store := storage.NewLocalStorage(dir)
+	cfg := &config.MediaConfig{MaxUploadSize: 1 << 20, MaxResumableUploadSize: 1 << 20, UploadSessionTTL: time.Hour}
+	url := func(key string) string { return "/uploads/" + key }
+	media := services.NewMediaService(db, store, cfg, nil, url)
+	return services.NewUploadService(db, store, media, cfg, url), db, dir
+}
+
+func TestUploadChunkClaimedByAnotherRequest(t *testing.T) {
+	service, db, dir := newTestUploadService(t, &models.Media{})
+	data := []byte("resumable upload")
+	session, err := service.Create(1, services.CreateUploadRequest{Kind: "media", FileName: "notes.pdf", MimeType: "application/pdf", Size: int64(len(data))})
+	require.NoError(t, err)
+
+	// Another request is storing the chunk at this offset
+	claimedAt := time.Now()
+	require.NoError(t, db.Model(&models.UploadSession{}).Where("id = ?", session.ID).
+		Updates(map[string]interface{}{"chunk_claim": "other-request", "chunk_claimed_at": claimedAt}).Error)
+	_, _, err = service.WriteChunk(session.ID, 1, 0, bytes.NewReader(data), "")
+	assert.ErrorIs(t, err, services.ErrUploadOffset)
+
+	// A claim abandoned by a crashed request is taken over
+	require.NoError(t, db.Model(&models.UploadSession{}).Where("id = ?", session.ID).
+		Update("chunk_claimed_at", claimedAt.Add(-time.Hour)).Error)
+	session, completed, err := service.WriteChunk(session.ID, 1, 0, bytes.NewReader(data), "")
+	require.NoError(t, err)
+	assert.True(t, completed)
+	assert.Equal(t, models.UploadStatusCompleted, session.Status)
+	require.NotNil(t, session.MediaID)
+
+	stored, err := os.ReadFile(filepath.Join(dir, session.StorageKey))
+	require.NoError(t, err)
+	assert.Equal(t, data, stored)
+}
+
+func TestUploadDeletesFileWhenMediaCannotBeRecorded(t *testing.T) {
+	// Without a media table the record cannot be created
+	service, db, dir := newTestUploadService(t)
+	data := []byte("resumable upload")
+	session, err := service.Create(1, services.CreateUploadRequest{Kind: "media", FileName: "notes.pdf", MimeType: "application/pdf", Size: int64(len(data))})
+	require.NoError(t, err)
+
+	_, _, err = service.WriteChunk(session.ID, 1, 0, bytes.NewReader(data), "")
+	require.Error(t, err)
+
+	require.NoError(t, db.First(session, "id = ?", session.ID).Error)
+	assert.Equal(t, models.UploadStatusAborted, session.Status)
+	_, err = os.Stat(filepath.Join(dir, session.StorageKey))
+	assert.True(t, os.IsNotExist(err), "the assembled file is deleted")
+}
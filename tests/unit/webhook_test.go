@@ -0,0 +1,123 @@
+package unit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"news/internal/models"
+	"news/internal/services"
+	"news/internal/webhook"
+)
+
+func TestWebhookSignature(t *testing.T) {
+	body := []byte(`{"event":"article.published"}`)
+	signature := webhook.Sign("secret", 1700000000, body)
+
+	assert.Contains(t, signature, "sha256=")
+	assert.True(t, webhook.Verify("secret", 1700000000, body, signature))
+	assert.False(t, webhook.Verify("other", 1700000000, body, signature), "wrong secret")
+	assert.False(t, webhook.Verify("secret", 1700000001, body, signature), "replayed with a new timestamp")
+	assert.False(t, webhook.Verify("secret", 1700000000, []byte(`{}`), signature), "tampered body")
+}
+
+func TestWebhookRetryDelay(t *testing.T) {
+	assert.Equal(t, 30*time.Second, webhook.RetryDelay(1))
+	assert.Equal(t, time.Minute, webhook.RetryDelay(2))
+	assert.Equal(t, 4*time.Minute, webhook.RetryDelay(4))
+	assert.Equal(t, 6*time.Hour, webhook.RetryDelay(20), "delay is capped")
+}
+
+func TestWebhookSenderSignsRequests(t *testing.T) {
+	var received *http.Request
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	body := []byte(`{"id":"evt_1"}`)
+	result, err := webhook.NewSender(5*time.Second).Send(context.Background(), webhook.Request{
+		URL:        server.URL,
+		Secret:     "whsec_test",
+		Event:      models.WebhookEventCommentCreated,
+		DeliveryID: "42",
+		Body:       body,
+	})
+	require.NoError(t, err)
+
+	assert.True(t, result.Success())
+	assert.Equal(t, http.StatusAccepted, result.StatusCode)
+	assert.Equal(t, "ok", result.Body)
+	assert.Equal(t, body, receivedBody)
+	assert.Equal(t, "comment.created", received.Header.Get(webhook.EventHeader))
+	assert.Equal(t, "42", received.Header.Get(webhook.DeliveryHeader))
+
+	timestamp, err := strconv.ParseInt(received.Header.Get(webhook.TimestampHeader), 10, 64)
+	require.NoError(t, err)
+	assert.True(t, webhook.Verify("whsec_test", timestamp, receivedBody, received.Header.Get(webhook.SignatureHeader)))
+}
+
+func TestWebhookSenderReportsFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	result, err := webhook.NewSender(5*time.Second).Send(context.Background(), webhook.Request{URL: server.URL, Body: []byte(`{}`)})
+	require.NoError(t, err)
+	assert.False(t, result.Success())
+	assert.Equal(t, http.StatusInternalServerError, result.StatusCode)
+}
+
+func TestWebhookEndpointSubscribes(t *testing.T) {
+	endpoint := models.WebhookEndpoint{Events: []byte(`["article.published","video.processed"]`)}
+
+	assert.True(t, endpoint.Subscribes(models.WebhookEventArticlePublished))
+	assert.True(t, endpoint.Subscribes(models.WebhookEventVideoProcessed))
+	assert.False(t, endpoint.Subscribes(models.WebhookEventArticleUpdated))
+	assert.True(t, models.IsValidWebhookEvent("translation.completed"))
+	assert.False(t, models.IsValidWebhookEvent("article.deleted"))
+}
+
+func TestWebhookArticleUpdatedAfterBlockEditsAndRestores(t *testing.T) {
+	db, article, blocks := setupBlockArticle(t)
+	require.NoError(t, db.AutoMigrate(&models.WebhookEndpoint{}, &models.WebhookDelivery{}))
+	require.NoError(t, db.Model(&article).Update("status", "published").Error)
+	require.NoError(t, db.Create(&models.WebhookEndpoint{
+		Name: "Partner", URL: "https://partner.example.com/hooks", Secret: "secret",
+		Events: []byte(`["article.updated"]`), IsActive: true,
+	}).Error)
+
+	refreshed := make(recordingSitemapEnqueuer, 4)
+	services.SetSitemapEnqueuer(refreshed)
+	t.Cleanup(func() { services.SetSitemapEnqueuer(nil) })
+
+	expectDeliveries := func(count int64, change string) {
+		<-refreshed
+		assert.Eventually(t, func() bool {
+			var delivered int64
+			db.Model(&models.WebhookDelivery{}).Where("event = ?", models.WebhookEventArticleUpdated).Count(&delivered)
+			return delivered == count
+		}, 2*time.Second, 10*time.Millisecond, "article.updated must be emitted after %s", change)
+	}
+
+	require.NoError(t, services.UpdateArticleBlocks(articleIDString(article), []models.ArticleContentBlock{
+		{ID: blocks[0].ID, BlockType: "heading", Content: "New heading", IsVisible: true},
+	}, bulkEditor.ID))
+	expectDeliveries(1, "a block edit")
+
+	_, err := services.RestoreArticleRevision(article.ID, 1, bulkEditor.ID)
+	require.NoError(t, err)
+	expectDeliveries(2, "a revision restore")
+}
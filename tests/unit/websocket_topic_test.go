@@ -0,0 +1,95 @@
+package unit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"news/internal/pubsub"
+	"news/tests/testutil"
+)
+
+func TestParseTopic(t *testing.T) {
+	valid := map[string]string{
+		"breaking_news":       "breaking_news",
+		"article:42":          "article:42",
+		" Live:7 ":            "live:7",
+		"video:007":           "video:7",
+		"category:world-news": "category:world-news",
+	}
+	for input, want := range valid {
+		topic, err := pubsub.ParseTopic(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, topic)
+	}
+
+	for _, input := range []string{"", "article", "article:", "article:0", "article:abc", "live:-1", "category:no spaces", "category:-dash", "user:1", "system_alert"} {
+		_, err := pubsub.ParseTopic(input)
+		assert.True(t, errors.Is(err, pubsub.ErrInvalidTopic), input)
+	}
+}
+
+func TestTopicHelpers(t *testing.T) {
+	assert.Equal(t, "article:5", pubsub.ArticleTopic(5))
+	assert.Equal(t, "live:6", pubsub.LiveTopic(6))
+	assert.Equal(t, "video:7", pubsub.VideoTopic(7))
+	assert.Equal(t, "category:sports", pubsub.CategoryTopic("Sports"))
+}
+
+func TestParseClientFrame(t *testing.T) {
+	frame, topics, err := pubsub.ParseClientFrame([]byte(`{"action":"Subscribe","topic":"article:1","topics":["live:2","article:1"]}`))
+	require.NoError(t, err)
+	assert.Equal(t, pubsub.ActionSubscribe, frame.Action)
+	assert.Equal(t, []string{"article:1", "live:2"}, topics, "topics are normalized and deduplicated")
+
+	frame, topics, err = pubsub.ParseClientFrame([]byte(`{"action":"ping"}`))
+	require.NoError(t, err)
+	assert.Equal(t, pubsub.ActionPing, frame.Action)
+	assert.Empty(t, topics)
+
+	_, _, err = pubsub.ParseClientFrame([]byte(`{"action":"unsubscribe"}`))
+	assert.True(t, errors.Is(err, pubsub.ErrInvalidTopic), "a topic is required")
+
+	_, _, err = pubsub.ParseClientFrame([]byte(`{"action":"publish","topic":"article:1"}`))
+	assert.Error(t, err, "clients cannot publish")
+
+	_, _, err = pubsub.ParseClientFrame([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestTopicPresenceUpdatesInOrder(t *testing.T) {
+	server := testutil.SetupTestRedis(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	hub := pubsub.NewNotificationHub(client, nil)
+	go hub.Run()
+
+	conn := pubsub.NewEventStreamClient(0, "")
+	require.NoError(t, hub.Subscribe(conn, "live:1"))
+	hub.Register(conn)
+	assert.Eventually(t, func() bool {
+		members, _ := server.ZMembers("presence:live:1")
+		return len(members) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// The last change wins even when subscriptions flip faster than Redis is written
+	for i := 0; i < 50; i++ {
+		hub.Unsubscribe(conn, "live:1")
+		require.NoError(t, hub.Subscribe(conn, "live:1"))
+	}
+	hub.Unsubscribe(conn, "live:1")
+
+	assert.Eventually(t, func() bool {
+		members, _ := server.ZMembers("presence:live:1")
+		return len(members) == 0
+	}, time.Second, 10*time.Millisecond)
+	assert.Never(t, func() bool {
+		members, _ := server.ZMembers("presence:live:1")
+		return len(members) != 0
+	}, 200*time.Millisecond, 10*time.Millisecond)
+}
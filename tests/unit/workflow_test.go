@@ -0,0 +1,55 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"news/internal/workflow"
+)
+
+func TestWorkflowDefaultRules(t *testing.T) {
+	rules := workflow.DefaultRules
+
+	assert.True(t, rules.Allowed("author", "draft", "in_review"))
+	assert.True(t, rules.Allowed("author", "changes_requested", "in_review"))
+	assert.False(t, rules.Allowed("author", "in_review", "approved"), "authors cannot approve their own work")
+	assert.False(t, rules.Allowed("author", "approved", "published"))
+
+	assert.True(t, rules.Allowed("editor", "in_review", "changes_requested"))
+	assert.True(t, rules.Allowed("Editor", "approved", "published"), "roles are case-insensitive")
+	assert.False(t, rules.Allowed("editor", "published", "in_review"))
+
+	assert.True(t, rules.Allowed("admin", "trash", "published"))
+	assert.False(t, rules.Allowed("admin", "draft", "draft"), "staying put is not a transition")
+	assert.False(t, rules.Allowed("admin", "draft", "deleted"), "unknown statuses are never allowed")
+	assert.False(t, rules.Allowed("user", "draft", "in_review"))
+}
+
+func TestWorkflowTargets(t *testing.T) {
+	assert.Equal(t, []string{"in_review", "trash"}, workflow.DefaultRules.Targets("author", "draft"))
+	assert.Equal(t, []string{"draft", "in_review", "changes_requested", "approved", "scheduled", "archived", "trash"},
+		workflow.DefaultRules.Targets("admin", "published"))
+	assert.Empty(t, workflow.DefaultRules.Targets("user", "draft"))
+}
+
+func TestWorkflowParseRules(t *testing.T) {
+	rules, err := workflow.ParseRules("")
+	require.NoError(t, err)
+	assert.True(t, rules.Allowed("author", "draft", "in_review"))
+
+	rules, err = workflow.ParseRules(`{"Author":{"draft":["in_review"],"in_review":["*"]}}`)
+	require.NoError(t, err)
+	assert.False(t, rules.Allowed("author", "draft", "trash"), "named roles replace their defaults")
+	assert.True(t, rules.Allowed("author", "in_review", "approved"))
+	assert.False(t, rules.Allowed("author", "in_review", "published"), "only editors and admins publish")
+	assert.True(t, rules.Allowed("editor", "approved", "published"), "other roles keep their defaults")
+
+	_, err = workflow.ParseRules(`{"author":{"approved":["published"]}}`)
+	assert.Error(t, err)
+	_, err = workflow.ParseRules(`{"editor":{"draft":["live"]}}`)
+	assert.Error(t, err)
+	_, err = workflow.ParseRules(`not json`)
+	assert.Error(t, err)
+}